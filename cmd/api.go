@@ -30,8 +30,6 @@ import (
 	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
 	mcpmemory "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory"
 	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
-	pageindexplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugins/pageindex"
-	ragplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugins/rag"
 	"github.com/Laisky/laisky-blog-graphql/internal/mcp/rag"
 	"github.com/Laisky/laisky-blog-graphql/internal/mcp/userrequests"
 	"github.com/Laisky/laisky-blog-graphql/internal/web"
@@ -187,12 +185,7 @@ func runAPI() error {
 
 	// PostgreSQL: MCP database
 	eg.Go(func() error {
-		dial := postgres.DialInfo{
-			Addr:   gconfig.S.GetString("settings.db.mcp.addr"),
-			DBName: gconfig.S.GetString("settings.db.mcp.db"),
-			User:   gconfig.S.GetString("settings.db.mcp.user"),
-			Pwd:    gconfig.S.GetString("settings.db.mcp.pwd"),
-		}
+		dial := loadMCPDialInfo()
 		if dial.Addr == "" || dial.DBName == "" || dial.User == "" {
 			logger.Warn("mcp database configuration incomplete",
 				zap.Bool("addr_empty", dial.Addr == ""),
@@ -328,8 +321,13 @@ func runAPI() error {
 	mcpStart := time.Now()
 	ragSettings := rag.LoadSettingsFromConfig()
 	args.RAGSettings = ragSettings
-	filePluginSettings := mcpplugin.LoadSettingsFromConfig()
+	filePluginSettings, err := mcpplugin.LoadSettingsFromConfig()
+	if err != nil {
+		return errors.Wrap(err, "load mcp file plugin settings")
+	}
 	shadowSettings := mcpplugin.LoadShadowSettingsFromConfig()
+	federatedSettings := mcpplugin.LoadFederatedSettingsFromConfig()
+	canarySettings := mcpplugin.LoadCanarySettingsFromConfig()
 	if mcpDB != nil {
 		var (
 			askSvc  *askuser.Service
@@ -457,63 +455,30 @@ func runAPI() error {
 		filesSettings := files.LoadSettingsFromConfig()
 		if mcpDB != nil {
 			memorySettings := mcpmemory.LoadSettingsFromConfig()
-			var credStore files.CredentialStore
-			if args.Rdb != nil {
-				credStore = files.NewRedisCredentialStore(args.Rdb.GetDB())
-			}
 			credential, credErr := buildFileCredentialProtector(filesSettings)
 			if credErr != nil {
 				return errors.Wrap(credErr, "invalid mcp files credential configuration")
 			}
-			embedder := rag.NewOpenAIEmbedder(filesSettings.EmbeddingBaseURL, filesSettings.EmbeddingModel, nil,
-				rag.WithLogger(logger.Named("files_embedder")))
-			rerankClient := files.NewCohereRerankClient(filesSettings.Search.RerankEndpoint, filesSettings.Search.RerankModel, filesSettings.Search.RerankTimeout)
-			fileSvc, err := files.NewService(mcpDB.DB, filesSettings, embedder, rerankClient, credential, credStore, logger.Named("mcp_files"), nil, nil)
+			fileSvc, err := newMCPFilesService(mcpDB.DB, filesSettings, credential, args.Rdb, logger)
 			if err != nil {
 				logger.Warn("file service unavailable", zap.Error(err))
 			} else {
 				args.FilesService = fileSvc
 
-				ragFilePlugin, pluginErr := ragplugin.New(fileSvc)
+				plugins, pluginErr := buildMemoryPlugins(fileSvc, logger)
 				if pluginErr != nil {
-					return errors.Wrap(pluginErr, "new rag file plugin")
+					return errors.WithStack(pluginErr)
 				}
 
-				piSettings := pageindexplugin.LoadSettings()
-				plugins := []mcpplugin.Plugin{ragFilePlugin}
-				if piSettings.Enabled() {
-					sysFS, sysErr := fileSvc.SystemNamespace("pageindex")
-					if sysErr != nil {
-						return errors.Wrap(sysErr, "build pageindex system namespace")
-					}
-					piTok, tokErr := pageindexplugin.NewTokenizer(piSettings.LLM.IndexingModel)
-					if tokErr != nil {
-						return errors.Wrap(tokErr, "build pageindex tokenizer")
-					}
-					piLLM, llmErr := pageindexplugin.NewOpenAILLM(pageindexplugin.LLMConfig{
-						APIKey:    piSettings.LLM.APIKey,
-						BaseURL:   piSettings.LLM.BaseURL,
-						Model:     piSettings.LLM.IndexingModel,
-						Tokenizer: piTok,
-						Logger:    logger.Named("pageindex_llm"),
-					})
-					if llmErr != nil {
-						return errors.Wrap(llmErr, "build pageindex llm client")
+				var canary *mcpplugin.Canary
+				if canarySettings.Enabled {
+					if err := canarySettings.Validate(shadowSettings); err != nil {
+						return errors.Wrap(err, "canary settings invalid")
 					}
-					piPlugin, piErr := pageindexplugin.New(pageindexplugin.PluginDeps{
-						UserFS:    fileSvc,
-						SystemFS:  sysFS,
-						Settings:  piSettings,
-						LLM:       piLLM,
-						Tokenizer: piTok,
-						Logger:    logger.Named("pageindex"),
-					})
-					if piErr != nil {
-						return errors.Wrap(piErr, "new pageindex plugin")
+					var canaryErr error
+					if canary, canaryErr = mcpplugin.NewCanary(canarySettings.Config(logger.Named("mcp_memory_canary"))); canaryErr != nil {
+						return errors.Wrap(canaryErr, "build canary")
 					}
-					plugins = append(plugins, piPlugin)
-				} else {
-					logger.Debug("pageindex plugin disabled (settings.mcp.tools.memory.plugins.pageindex.llm.api_key is empty)")
 				}
 
 				if shadowSettings.Enabled {
@@ -542,15 +507,24 @@ func runAPI() error {
 						return errors.Wrap(recErr, "open shadow recorder")
 					}
 
+					// The canary watches the candidate's health on every mirrored call.
+					var shadowRecorder mcpplugin.Recorder = recorder
+					if canary != nil {
+						shadowRecorder = mcpplugin.NewMultiRecorder(recorder, canary)
+					}
+
 					wrapper, wrapErr := mcpplugin.NewShadowPlugin(mcpplugin.ShadowConfig{
 						Name:        shadowSettings.LivePlugin,
 						Live:        livePlugin,
 						Shadow:      shadowPlugin,
-						Recorder:    recorder,
+						Recorder:    shadowRecorder,
 						Logger:      logger.Named("mcp_memory_shadow"),
 						Concurrency: int64(shadowSettings.Concurrency),
 						OpTimeout:   shadowSettings.OpTimeout,
 						DrainGrace:  shadowSettings.DrainGrace,
+
+						ViewerAPIKeyHashes: shadowSettings.ViewerAPIKeyHashes,
+						ReportMaxBytes:     shadowSettings.ReportMaxBytes,
 					})
 					if wrapErr != nil {
 						if closeErr := recorder.Close(); closeErr != nil {
@@ -572,17 +546,66 @@ func runAPI() error {
 					)
 				}
 
+				if federatedSettings.Enabled {
+					if err := federatedSettings.Validate(); err != nil {
+						return errors.Wrap(err, "federated settings invalid")
+					}
+
+					byName := make(map[string]mcpplugin.Plugin, len(plugins))
+					for _, p := range plugins {
+						byName[mcpplugin.NormalizeName(p.Name())] = p
+					}
+					members := make([]mcpplugin.Plugin, 0, len(federatedSettings.Plugins))
+					for _, name := range federatedSettings.Plugins {
+						member, ok := byName[name]
+						if !ok {
+							return errors.Errorf("federated plugin %q is not registered", name)
+						}
+						members = append(members, member)
+					}
+
+					federated, fedErr := mcpplugin.NewFederatedPlugin(mcpplugin.FederatedConfig{
+						Members: members,
+						Timeout: federatedSettings.Timeout,
+						Logger:  logger.Named("mcp_memory_federated"),
+					})
+					if fedErr != nil {
+						return errors.Wrap(fedErr, "build federated plugin")
+					}
+					plugins = append(plugins, federated)
+					logger.Info("mcp memory federated search enabled",
+						zap.Strings("plugins", federatedSettings.Plugins),
+						zap.Duration("timeout", federatedSettings.Timeout),
+					)
+				}
+
 				fileManager, managerErr := mcpplugin.NewManager(filePluginSettings.DefaultPlugin, plugins...)
 				if managerErr != nil {
 					return errors.Wrap(managerErr, "new mcp file plugin manager")
 				}
+				if routeErr := fileManager.SetRoutes(filePluginSettings.Routes); routeErr != nil {
+					return errors.Wrap(routeErr, "invalid mcp file plugin routing rules")
+				}
+				if canary != nil {
+					if canaryErr := fileManager.SetCanary(canary); canaryErr != nil {
+						return errors.Wrap(canaryErr, "install canary")
+					}
+					logger.Info("mcp memory canary enabled",
+						zap.String("live", canarySettings.LivePlugin),
+						zap.String("candidate", canarySettings.CandidatePlugin),
+						zap.Float64("percent", canarySettings.Percent),
+					)
+				}
 				args.MCPFileService = fileManager
 
 				if startErr := fileManager.StartAll(ctx); startErr != nil {
 					logger.Warn("start file plugin manager", zap.Error(startErr))
 				}
 
-				memorySvc, memoryErr := mcpmemory.NewService(mcpDB.DB, fileManager, memorySettings, logger.Named("mcp_memory"), nil)
+				memoryEmbedder := rag.NewOpenAIEmbedder(filesSettings.EmbeddingBaseURL, filesSettings.EmbeddingModel, nil,
+					rag.WithLogger(logger.Named("memory_embedder")))
+				memorySvc, memoryErr := mcpmemory.NewService(mcpDB.DB, fileManager, memorySettings, logger.Named("mcp_memory"), nil,
+					mcpmemory.WithEmbedder(memoryEmbedder))
 				if memoryErr != nil {
 					logger.Warn("memory service unavailable", zap.Error(memoryErr))
 				} else {
@@ -607,6 +630,7 @@ func runAPI() error {
 		zap.Bool("ask_user_enabled", args.MCPToolsSettings.AskUserEnabled),
 		zap.Bool("get_user_request_enabled", args.MCPToolsSettings.GetUserRequestEnabled),
 		zap.Bool("extract_key_info_enabled", args.MCPToolsSettings.ExtractKeyInfoEnabled),
+		zap.Bool("rag_corpus_enabled", args.MCPToolsSettings.RAGCorpusEnabled),
 		zap.Bool("file_io_enabled", args.MCPToolsSettings.FileIOEnabled),
 		zap.Bool("memory_enabled", args.MCPToolsSettings.MemoryEnabled),
 	)
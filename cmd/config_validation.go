@@ -107,9 +107,11 @@ func validateMCPToolsConfig(get configGetter, errs *[]string) {
 		"settings.mcp.tools.ask_user.enabled",
 		"settings.mcp.tools.get_user_request.enabled",
 		"settings.mcp.tools.extract_key_info.enabled",
+		"settings.mcp.tools.rag_corpus.enabled",
 		"settings.mcp.tools.file_io.enabled",
 		"settings.mcp.tools.memory.enabled",
 		"settings.mcp.tools.mcp_pipe.enabled",
+		"settings.mcp.tools.pipe_run.enabled",
 	}
 
 	for _, key := range keys {
@@ -146,6 +148,7 @@ func validateRAGConfig(get configGetter, errs *[]string) {
 	validateOptionalIntMin(get, "settings.mcp.tools.extract_key_info.top_k_limit", 1, errs)
 	validateOptionalIntMin(get, "settings.mcp.tools.extract_key_info.max_materials_size", 1, errs)
 	validateOptionalIntMin(get, "settings.mcp.tools.extract_key_info.max_chunk_chars", 201, errs)
+	validateOptionalIntMin(get, "settings.mcp.tools.extract_key_info.max_corpora", 1, errs)
 	validateOptionalFloatPositive(get, "settings.mcp.tools.extract_key_info.semantic_weight", errs)
 	validateOptionalFloatPositive(get, "settings.mcp.tools.extract_key_info.lexical_weight", errs)
 }
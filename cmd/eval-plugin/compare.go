@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/conformance/eval"
+)
+
+// errRegression is returned by runCompare when --fail-on-regression is set
+// and a metric regressed significantly.
+var errRegression = fmt.Errorf("significant regression found")
+
+// runCompare implements `eval-plugin compare`: it diffs two stored run
+// directories and writes a Markdown or HTML regression report.
+func runCompare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	baselineDir := fs.String("baseline", "", "Baseline run directory holding raw_per_query.jsonl. Required.")
+	candidateDir := fs.String("candidate", "", "Candidate run directory holding raw_per_query.jsonl. Required.")
+	format := fs.String("format", "md", `Report format: "md" or "html".`)
+	out := fs.String("out", "-", "Report path; - writes to stdout.")
+	alpha := fs.Float64("alpha", 0.05, "Significance level of the permutation test.")
+	b := fs.Int("b", 10000, "Number of permutation shuffles.")
+	failOnRegression := fs.Bool("fail-on-regression", false, "Exit 1 when any metric regressed significantly.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *baselineDir == "" || *candidateDir == "" {
+		fs.Usage()
+		return fmt.Errorf("--baseline and --candidate are required")
+	}
+	*format = strings.ToLower(strings.TrimSpace(*format))
+	if *format != "md" && *format != "html" {
+		return fmt.Errorf("--format must be md or html, got %q", *format)
+	}
+
+	baseline, err := eval.LoadStoredRun(*baselineDir)
+	if err != nil {
+		return fmt.Errorf("load baseline: %w", err)
+	}
+	candidate, err := eval.LoadStoredRun(*candidateDir)
+	if err != nil {
+		return fmt.Errorf("load candidate: %w", err)
+	}
+	report := eval.CompareRuns(baseline, candidate, eval.CompareOptions{Alpha: *alpha, B: *b})
+
+	if err := writeComparison(*out, *format, report); err != nil {
+		return err
+	}
+	regressions := report.Regressions()
+	fmt.Fprintf(os.Stderr, "eval compare baseline=%s candidate=%s metrics=%d regressions=%d\n",
+		report.Baseline, report.Candidate, len(report.Metrics), len(regressions))
+	if *failOnRegression && len(regressions) > 0 {
+		return errRegression
+	}
+	return nil
+}
+
+func writeComparison(path, format string, report eval.ComparisonReport) error {
+	var w io.Writer = os.Stdout
+	if path != "" && path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("create report %s: %w", path, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if format == "html" {
+		return report.WriteHTML(w)
+	}
+	return report.WriteMarkdown(w)
+}
@@ -8,6 +8,11 @@
 //	go run ./cmd/eval-plugin --plugin=rag --golden=tests/eval/golden \
 //	    --suites=retrieval,ops,redteam --git-sha=$(git rev-parse --short HEAD)
 //
+// The compare subcommand diffs two stored runs instead:
+//
+//	go run ./cmd/eval-plugin compare --baseline=docs/eval/baseline_v1 \
+//	    --candidate=docs/eval/runs/<sha> --format=html --out=report.html
+//
 // The driver reports missing datasets / unavailable storage as `n/a` cells
 // in the scorecard rather than crashing, per the proposal §4.6 graceful
 // missing rule.
@@ -16,6 +21,7 @@ package main
 import (
 	"context"
 	"crypto/sha256"
+	"database/sql"
 	"encoding/hex"
 	"encoding/json"
 	"flag"
@@ -25,19 +31,30 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpmemory "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory"
 	"github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/conformance/eval"
 	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+	ragplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugins/rag"
+	"github.com/Laisky/laisky-blog-graphql/library/log"
 )
 
 // harnessVersion stamps run_metadata.yml so cross-version diffs are obvious.
 const harnessVersion = "v0.1.0"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		if err := runCompare(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "eval-plugin compare:", err)
+			os.Exit(1)
+		}
+		return
+	}
 	if err := run(); err != nil {
 		fmt.Fprintln(os.Stderr, "eval-plugin:", err)
 		os.Exit(1)
@@ -54,6 +71,7 @@ type cliFlags struct {
 	baseline  bool
 	force     bool
 	pluginAny string
+	packs     string
 }
 
 func parseFlags() cliFlags {
@@ -66,12 +84,17 @@ func parseFlags() cliFlags {
 	flag.StringVar(&c.gitSHA, "git-sha", "", "Git SHA stamped in the scorecard. Defaults to `git rev-parse --short HEAD`.")
 	flag.BoolVar(&c.baseline, "baseline", false, "Write to docs/eval/baseline_v1/ instead of docs/eval/runs/<sha>/.")
 	flag.BoolVar(&c.force, "force", false, "Permit overwriting an existing baseline. Use with --baseline only.")
+	flag.StringVar(&c.packs, "attack-packs", "", "Comma-separated red-team attack pack files (.yaml/.yml/.json) run after the built-in attacks.")
 	flag.Parse()
 	return c
 }
 
 func run() error {
-	c := parseFlags()
+	return runEval(parseFlags())
+}
+
+// runEval executes one eval run for the parsed flags.
+func runEval(c cliFlags) error {
 	if c.plugin == "" {
 		flag.Usage()
 		return fmt.Errorf("--plugin is required")
@@ -107,6 +130,18 @@ func run() error {
 
 	plugin, pluginNote := buildPlugin(c.plugin)
 
+	// The memory_poisoning attacks need a turn memory; without one the
+	// red-team suite would silently skip them.
+	var turnMemory eval.TurnMemory
+	if slices.Contains(suites, "redteam") {
+		mem, closeMemory, err := buildTurnMemory()
+		if err != nil {
+			return fmt.Errorf("build turn memory for memory_poisoning attacks: %w", err)
+		}
+		defer closeMemory()
+		turnMemory = mem
+	}
+
 	// Compute golden_versions before invocation so we record them even when a
 	// suite is missing its dataset.
 	goldenVersions := computeGoldenVersions(c.golden)
@@ -127,6 +162,8 @@ func run() error {
 		GitSHA:          gitSHA,
 		UTCRunID:        runID,
 		Suites:          suites,
+		AttackPacks:     splitList(c.packs),
+		Memory:          turnMemory,
 	}
 
 	fmt.Printf("eval plugin=%s suites=%s out=%s git_sha=%s%s\n",
@@ -201,6 +238,37 @@ func buildPlugin(name string) (mcpplugin.Plugin, string) {
 	return nil, ""
 }
 
+// buildTurnMemory builds the memory service the memory_poisoning attacks
+// drive. It runs the production BeforeTurn/AfterTurn path over a RAG plugin
+// on an in-memory SQLite file store, since the stub plugin keeps nothing a
+// later session could recall.
+func buildTurnMemory() (eval.TurnMemory, func(), error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:eval-memory-%d?mode=memory&cache=shared", time.Now().UnixNano()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("open memory db: %w", err)
+	}
+	closeDB := func() { _ = db.Close() }
+
+	fileSettings := files.LoadSettingsFromConfig()
+	fileSettings.Search.Enabled = false
+	fileService, err := files.NewService(db, fileSettings, nil, nil, nil, nil, log.Logger.Named("eval_memory_files"), nil, nil)
+	if err != nil {
+		closeDB()
+		return nil, nil, fmt.Errorf("new file service: %w", err)
+	}
+	ragPlugin, err := ragplugin.New(fileService)
+	if err != nil {
+		closeDB()
+		return nil, nil, fmt.Errorf("new rag plugin: %w", err)
+	}
+	service, err := mcpmemory.NewService(db, ragPlugin, mcpmemory.LoadSettingsFromConfig(), log.Logger.Named("eval_memory"), nil)
+	if err != nil {
+		closeDB()
+		return nil, nil, fmt.Errorf("new memory service: %w", err)
+	}
+	return service, closeDB, nil
+}
+
 // stubPlugin is a no-op plugin that returns empty results for every method.
 // It exists so the redteam and ops suites can exercise their orchestration
 // paths without a backing file service. Datasets that drive the retrieval and
@@ -409,6 +477,17 @@ func normalizeSuites(s string) []string {
 	return out
 }
 
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if v := strings.TrimSpace(p); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 func filterOut(suites []string, drop string) []string {
 	out := make([]string, 0, len(suites))
 	for _, s := range suites {
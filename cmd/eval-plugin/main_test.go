@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/conformance/eval"
+)
+
+// TestRunEvalExecutesMemoryPoisoning verifies the CLI wires turn memory, so
+// the built-in memory_poisoning attack runs instead of being skipped.
+func TestRunEvalExecutesMemoryPoisoning(t *testing.T) {
+	out := t.TempDir()
+	require.NoError(t, runEval(cliFlags{
+		plugin: "rag",
+		golden: t.TempDir(),
+		out:    out,
+		suites: "redteam",
+		gitSHA: "test",
+	}))
+
+	rows, err := eval.LoadRawPerQuery(filepath.Join(out, "raw_per_query.jsonl"))
+	require.NoError(t, err)
+	var poisoning *eval.PerQueryRecord
+	for i := range rows {
+		if rows[i].QueryID == "poisoning-01" {
+			poisoning = &rows[i]
+		}
+	}
+	require.NotNil(t, poisoning, "poisoning-01 missing from raw_per_query.jsonl")
+	encoded, _ := json.Marshal(poisoning.Payload)
+	require.Equal(t, string(eval.FamilyMemoryPoisoning), poisoning.Payload["family"], string(encoded))
+	require.Equal(t, false, poisoning.Payload["skipped"], string(encoded))
+	require.Equal(t, true, poisoning.Payload["blocked"], string(encoded))
+
+	_, err = os.Stat(filepath.Join(out, "rag_plugin_scorecard.md"))
+	require.NoError(t, err)
+}
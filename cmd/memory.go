@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Laisky/errors/v2"
+	gconfig "github.com/Laisky/go-config/v2"
+	gcmd "github.com/Laisky/go-utils/v6/cmd"
+	logSDK "github.com/Laisky/go-utils/v6/log"
+	"github.com/Laisky/zap"
+	"github.com/spf13/cobra"
+
+	mcpauth "github.com/Laisky/laisky-blog-graphql/internal/mcp/auth"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpmemory "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+	pageindexplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugins/pageindex"
+	ragplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugins/rag"
+	remoteplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugins/remote"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/rag"
+	"github.com/Laisky/laisky-blog-graphql/library/db/postgres"
+	rlibs "github.com/Laisky/laisky-blog-graphql/library/db/redis"
+	"github.com/Laisky/laisky-blog-graphql/library/log"
+)
+
+var memoryCMD = &cobra.Command{
+	Use:   "memory",
+	Short: "manage MCP memory",
+	Long:  `export and import MCP memory sessions in the portable JSON format`,
+	Args:  gcmd.NoExtraArgs,
+}
+
+var memoryExportCMD = &cobra.Command{
+	Use:   "export",
+	Short: "export memory sessions of one project to JSON",
+	Args:  gcmd.NoExtraArgs,
+	PreRun: func(cmd *cobra.Command, _ []string) {
+		if err := initialize(context.Background(), cmd); err != nil {
+			log.Logger.Panic("initialize memory export", zap.Error(err))
+		}
+	},
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runMemoryExport(cmd); err != nil {
+			log.Logger.Panic("export memory", zap.Error(err))
+		}
+	},
+}
+
+var memoryImportCMD = &cobra.Command{
+	Use:   "import",
+	Short: "import memory sessions from a JSON export",
+	Args:  gcmd.NoExtraArgs,
+	PreRun: func(cmd *cobra.Command, _ []string) {
+		if err := initialize(context.Background(), cmd); err != nil {
+			log.Logger.Panic("initialize memory import", zap.Error(err))
+		}
+	},
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runMemoryImport(cmd); err != nil {
+			log.Logger.Panic("import memory", zap.Error(err))
+		}
+	},
+}
+
+func init() {
+	rootCMD.AddCommand(memoryCMD)
+	memoryCMD.AddCommand(memoryExportCMD, memoryImportCMD)
+
+	memoryCMD.PersistentFlags().String("api-key", "", "API key that owns the memory (defaults to $MCP_API_KEY)")
+	memoryCMD.PersistentFlags().String("project", "", "memory project namespace")
+
+	memoryExportCMD.Flags().StringSlice("session", nil, "session IDs to export (default: all sessions)")
+	memoryExportCMD.Flags().StringP("output", "o", "-", "output file path, `-` for stdout")
+
+	memoryImportCMD.Flags().StringP("input", "i", "-", "input file path, `-` for stdin")
+	memoryImportCMD.Flags().Bool("overwrite", false, "replace existing sessions instead of skipping existing files")
+}
+
+// runMemoryExport writes the export document for the configured API key and project.
+func runMemoryExport(cmd *cobra.Command) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	auth, err := memoryAuthFromFlags(cmd)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	project, _ := cmd.Flags().GetString("project")
+	sessions, _ := cmd.Flags().GetStringSlice("session")
+	output, _ := cmd.Flags().GetString("output")
+
+	service, closeFn, err := buildMemoryServiceFromConfig(ctx, log.Logger.Named("memory_export"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer closeFn()
+
+	document, err := service.Export(ctx, auth, mcpmemory.ExportRequest{Project: project, SessionIDs: sessions})
+	if err != nil {
+		return errors.Wrap(err, "export")
+	}
+
+	writer := io.Writer(os.Stdout)
+	if output != "" && output != "-" {
+		fp, createErr := os.Create(output)
+		if createErr != nil {
+			return errors.Wrapf(createErr, "create %s", output)
+		}
+		defer func() { _ = fp.Close() }()
+		writer = fp
+	}
+
+	enc := json.NewEncoder(writer)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(document); err != nil {
+		return errors.Wrap(err, "encode export document")
+	}
+
+	log.Logger.Info("memory exported",
+		zap.String("project", project),
+		zap.Int("sessions", len(document.Sessions)),
+		zap.String("output", output))
+	return nil
+}
+
+// runMemoryImport rehydrates an export document for the configured API key.
+func runMemoryImport(cmd *cobra.Command) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	auth, err := memoryAuthFromFlags(cmd)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	project, _ := cmd.Flags().GetString("project")
+	input, _ := cmd.Flags().GetString("input")
+	overwrite, _ := cmd.Flags().GetBool("overwrite")
+
+	reader := io.Reader(os.Stdin)
+	if input != "" && input != "-" {
+		fp, openErr := os.Open(input)
+		if openErr != nil {
+			return errors.Wrapf(openErr, "open %s", input)
+		}
+		defer func() { _ = fp.Close() }()
+		reader = fp
+	}
+
+	document := mcpmemory.ExportDocument{}
+	if err = json.NewDecoder(reader).Decode(&document); err != nil {
+		return errors.Wrap(err, "decode export document")
+	}
+
+	service, closeFn, err := buildMemoryServiceFromConfig(ctx, log.Logger.Named("memory_import"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer closeFn()
+
+	result, err := service.Import(ctx, auth, mcpmemory.ImportRequest{
+		Project:   project,
+		Overwrite: overwrite,
+		Document:  document,
+	})
+	if err != nil {
+		return errors.Wrap(err, "import")
+	}
+
+	log.Logger.Info("memory imported",
+		zap.String("project", result.Project),
+		zap.Int("sessions", result.SessionCount),
+		zap.Int("files", result.FileCount),
+		zap.Int("skipped", result.SkippedCount))
+	return nil
+}
+
+// memoryAuthFromFlags derives the tenant identity from --api-key or $MCP_API_KEY.
+func memoryAuthFromFlags(cmd *cobra.Command) (files.AuthContext, error) {
+	apiKey, _ := cmd.Flags().GetString("api-key")
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		apiKey = strings.TrimSpace(os.Getenv("MCP_API_KEY"))
+	}
+	if apiKey == "" {
+		return files.AuthContext{}, errors.New("--api-key or $MCP_API_KEY is required")
+	}
+	return memoryAuthFromAPIKey(apiKey)
+}
+
+// memoryAuthFromAPIKey derives the caller identity of apiKey.
+func memoryAuthFromAPIKey(apiKey string) (files.AuthContext, error) {
+	derived, err := mcpauth.DeriveFromAPIKey(apiKey)
+	if err != nil {
+		return files.AuthContext{}, errors.Wrap(err, "derive api key identity")
+	}
+
+	return files.AuthContext{
+		APIKey:       derived.APIKey,
+		APIKeyHash:   derived.APIKeyHash,
+		UserID:       derived.UserID,
+		UserIdentity: derived.UserIdentity,
+	}, nil
+}
+
+// loadMCPDialInfo reads the MCP PostgreSQL connection settings.
+func loadMCPDialInfo() postgres.DialInfo {
+	return postgres.DialInfo{
+		Addr:   gconfig.S.GetString("settings.db.mcp.addr"),
+		DBName: gconfig.S.GetString("settings.db.mcp.db"),
+		User:   gconfig.S.GetString("settings.db.mcp.user"),
+		Pwd:    gconfig.S.GetString("settings.db.mcp.pwd"),
+	}
+}
+
+// newMCPFilesService builds the FileIO service shared by the API server and offline commands.
+// credential and rdb may be nil, in which case index-job credentials are not cached.
+func newMCPFilesService(db *sql.DB, settings files.Settings, credential *files.CredentialProtector, rdb *rlibs.DB, logger logSDK.Logger) (*files.Service, error) {
+	var credStore files.CredentialStore
+	if rdb != nil {
+		credStore = files.NewRedisCredentialStore(rdb.GetDB())
+	}
+	embedder := rag.NewOpenAIEmbedder(settings.EmbeddingBaseURL, settings.EmbeddingModel, nil,
+		rag.WithLogger(logger.Named("files_embedder")))
+	rerankClient := files.NewCohereRerankClient(settings.Search.RerankEndpoint, settings.Search.RerankModel, settings.Search.RerankTimeout)
+
+	fileSvc, err := files.NewService(db, settings, embedder, rerankClient, credential, credStore, logger.Named("mcp_files"), nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "new files service")
+	}
+	return fileSvc, nil
+}
+
+// buildMemoryPlugins builds the configured memory plugins over fileSvc: rag
+// always, pageindex when its LLM is configured.
+func buildMemoryPlugins(fileSvc *files.Service, logger logSDK.Logger) ([]mcpplugin.Plugin, error) {
+	ragFilePlugin, err := ragplugin.New(fileSvc)
+	if err != nil {
+		return nil, errors.Wrap(err, "new rag file plugin")
+	}
+	plugins := []mcpplugin.Plugin{ragFilePlugin}
+
+	remoteSettings, err := remoteplugin.LoadSettings()
+	if err != nil {
+		return nil, errors.Wrap(err, "load remote plugin settings")
+	}
+	remotePlugins, err := remoteplugin.NewFromSettings(remoteSettings, logger.Named("remote"))
+	if err != nil {
+		return nil, errors.Wrap(err, "new remote plugins")
+	}
+	for _, p := range remotePlugins {
+		plugins = append(plugins, p)
+	}
+
+	piSettings := pageindexplugin.LoadSettings()
+	if !piSettings.Enabled() {
+		logger.Debug("pageindex plugin disabled (settings.mcp.tools.memory.plugins.pageindex.llm.api_key is empty)")
+		return plugins, errors.WithStack(checkPluginNames(plugins))
+	}
+	sysFS, err := fileSvc.SystemNamespace("pageindex")
+	if err != nil {
+		return nil, errors.Wrap(err, "build pageindex system namespace")
+	}
+	piTok, err := pageindexplugin.NewTokenizer(piSettings.LLM.IndexingModel)
+	if err != nil {
+		return nil, errors.Wrap(err, "build pageindex tokenizer")
+	}
+	piLLM, err := pageindexplugin.NewLLMFromSettings(piSettings.LLM, piTok, logger.Named("pageindex_llm"))
+	if err != nil {
+		return nil, errors.Wrap(err, "build pageindex llm client")
+	}
+	piPlugin, err := pageindexplugin.New(pageindexplugin.PluginDeps{
+		UserFS:    fileSvc,
+		SystemFS:  sysFS,
+		Settings:  piSettings,
+		LLM:       piLLM,
+		Tokenizer: piTok,
+		Logger:    logger.Named("pageindex"),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "new pageindex plugin")
+	}
+	plugins = append(plugins, piPlugin)
+	return plugins, errors.WithStack(checkPluginNames(plugins))
+}
+
+// checkPluginNames rejects two plugins with the same name, such as a remote
+// plugin configured as "rag".
+func checkPluginNames(plugins []mcpplugin.Plugin) error {
+	seen := map[string]bool{}
+	for _, p := range plugins {
+		if seen[p.Name()] {
+			return errors.Errorf("memory plugin name %q is used twice", p.Name())
+		}
+		seen[p.Name()] = true
+	}
+	return nil
+}
+
+// buildFilesServiceFromConfig connects to the MCP database and builds the
+// FileIO service for offline commands. The returned close function releases
+// the database connection.
+func buildFilesServiceFromConfig(ctx context.Context, logger logSDK.Logger) (*files.Service, *sql.DB, func(), error) {
+	dial := loadMCPDialInfo()
+	if dial.Addr == "" || dial.DBName == "" || dial.User == "" {
+		return nil, nil, nil, errors.New("settings.db.mcp.{addr,db,user} are required")
+	}
+	db, err := postgres.NewDB(ctx, dial)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "connect mcp postgres")
+	}
+	closeFn := func() {
+		if closeErr := db.DB.Close(); closeErr != nil {
+			logger.Warn("close mcp database", zap.Error(closeErr))
+		}
+	}
+
+	filesSettings := files.LoadSettingsFromConfig()
+	credential, err := buildFileCredentialProtector(filesSettings)
+	if err != nil {
+		closeFn()
+		return nil, nil, nil, errors.Wrap(err, "invalid mcp files credential configuration")
+	}
+	fileSvc, err := newMCPFilesService(db.DB, filesSettings, credential, nil, logger)
+	if err != nil {
+		closeFn()
+		return nil, nil, nil, errors.WithStack(err)
+	}
+	return fileSvc, db.DB, closeFn, nil
+}
+
+// buildMemoryServiceFromConfig wires a memory service over the rag plugin for offline commands.
+// The returned close function releases the database connection.
+func buildMemoryServiceFromConfig(ctx context.Context, logger logSDK.Logger) (*mcpmemory.Service, func(), error) {
+	fileSvc, db, closeFn, err := buildFilesServiceFromConfig(ctx, logger)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	ragFilePlugin, err := ragplugin.New(fileSvc)
+	if err != nil {
+		closeFn()
+		return nil, nil, errors.Wrap(err, "new rag file plugin")
+	}
+	manager, err := mcpplugin.NewManager(mcpplugin.DefaultPluginRAG, ragFilePlugin)
+	if err != nil {
+		closeFn()
+		return nil, nil, errors.Wrap(err, "new mcp file plugin manager")
+	}
+
+	service, err := mcpmemory.NewService(db, manager, mcpmemory.LoadSettingsFromConfig(), logger.Named("mcp_memory"), nil)
+	if err != nil {
+		closeFn()
+		return nil, nil, errors.Wrap(err, "new memory service")
+	}
+
+	return service, closeFn, nil
+}
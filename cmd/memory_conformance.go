@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Laisky/errors/v2"
+	gcmd "github.com/Laisky/go-utils/v6/cmd"
+	"github.com/Laisky/zap"
+	"github.com/spf13/cobra"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/conformance"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+	"github.com/Laisky/laisky-blog-graphql/library/log"
+)
+
+var memoryConformanceCMD = &cobra.Command{
+	Use:   "conformance",
+	Short: "run the memory plugin conformance suite against a configured plugin",
+	Long: `run the C01-C27 and R01-R10 conformance scenarios against a plugin built
+from the config and write a JSON pass/fail report.
+
+Scenarios write to fresh projects named "<project>-<timestamp>-<scenario>"
+under the --api-key tenant and delete their files afterwards. Use a dedicated
+API key. The tenant isolation scenarios C12 and C13 also need
+--second-api-key. The command exits 1 when any scenario fails.`,
+	Args: gcmd.NoExtraArgs,
+	PreRun: func(cmd *cobra.Command, _ []string) {
+		if err := initialize(context.Background(), cmd); err != nil {
+			log.Logger.Panic("initialize memory conformance", zap.Error(err))
+		}
+	},
+	Run: func(cmd *cobra.Command, _ []string) {
+		ok, err := runMemoryConformance(cmd)
+		if err != nil {
+			log.Logger.Panic("run memory conformance", zap.Error(err))
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	memoryCMD.AddCommand(memoryConformanceCMD)
+
+	memoryConformanceCMD.Flags().String("plugin", mcpplugin.DefaultPluginRAG, "plugin under test")
+	memoryConformanceCMD.Flags().String("second-api-key", "", "API key of a second tenant for the isolation scenarios (default: skip them)")
+	memoryConformanceCMD.Flags().String("secondary-plugin", "", "second plugin for the cross-plugin scenarios (default: skip them)")
+	memoryConformanceCMD.Flags().StringSlice("only", nil, "scenario IDs to run, like `C01,R07` (default: all)")
+	memoryConformanceCMD.Flags().Bool("skip-concurrency", false, "skip the R-row concurrency scenarios")
+	memoryConformanceCMD.Flags().Bool("skip-freshness", false, "skip the scenarios that wait for the freshness window")
+	memoryConformanceCMD.Flags().Duration("timeout", conformanceScenarioTimeout, "per-scenario time limit")
+	memoryConformanceCMD.Flags().StringP("output", "o", "-", "report file path, `-` for stdout")
+}
+
+// conformanceScenarioTimeout is the default per-scenario time limit.
+const conformanceScenarioTimeout = 2 * time.Minute
+
+// runMemoryConformance runs the suite and writes the report. It reports
+// false when a scenario failed.
+func runMemoryConformance(cmd *cobra.Command) (bool, error) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logger := log.Logger.Named("memory_conformance")
+
+	auth, err := memoryAuthFromFlags(cmd)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	var otherAuth mcpplugin.AuthContext
+	if otherKey, _ := cmd.Flags().GetString("second-api-key"); strings.TrimSpace(otherKey) != "" {
+		if otherAuth, err = memoryAuthFromAPIKey(strings.TrimSpace(otherKey)); err != nil {
+			return false, errors.WithStack(err)
+		}
+	}
+	primaryName, _ := cmd.Flags().GetString("plugin")
+	secondaryName, _ := cmd.Flags().GetString("secondary-plugin")
+	prefix, _ := cmd.Flags().GetString("project")
+	only, _ := cmd.Flags().GetStringSlice("only")
+	skipConcurrency, _ := cmd.Flags().GetBool("skip-concurrency")
+	skipFreshness, _ := cmd.Flags().GetBool("skip-freshness")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	output, _ := cmd.Flags().GetString("output")
+	if err = conformance.ValidateOnly(only); err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	fileSvc, _, closeFn, err := buildFilesServiceFromConfig(ctx, logger)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	defer closeFn()
+	plugins, err := buildMemoryPlugins(fileSvc, logger)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	primary, err := pickConformancePlugin(plugins, primaryName)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	selected := []mcpplugin.Plugin{primary}
+	var secondary mcpplugin.Plugin
+	if strings.TrimSpace(secondaryName) != "" {
+		if secondary, err = pickConformancePlugin(plugins, secondaryName); err != nil {
+			return false, errors.WithStack(err)
+		}
+		selected = append(selected, secondary)
+	}
+	for _, p := range selected {
+		if err = p.Start(ctx); err != nil {
+			return false, errors.Wrapf(err, "start plugin %q", p.Name())
+		}
+		defer func(p mcpplugin.Plugin) {
+			if stopErr := p.Stop(context.Background()); stopErr != nil {
+				logger.Warn("stop plugin", zap.String("plugin", p.Name()), zap.Error(stopErr))
+			}
+		}(p)
+	}
+
+	fx, err := conformance.NewPluginFixture(conformance.PluginFixtureConfig{
+		Primary:       primary,
+		Secondary:     secondary,
+		Auth:          auth,
+		OtherAuth:     otherAuth,
+		ProjectPrefix: prefix,
+	})
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	report := conformance.RunReport(ctx, fx, conformance.Options{
+		SkipConcurrency: skipConcurrency,
+		SkipCrossPlugin: secondary == nil,
+		SkipFreshness:   skipFreshness,
+		Only:            only,
+	}, timeout)
+
+	if err = writeConformanceReport(output, report); err != nil {
+		return false, errors.WithStack(err)
+	}
+	logger.Info("memory conformance finished",
+		zap.String("plugin", report.Plugin),
+		zap.Int("passed", report.Passed),
+		zap.Int("failed", report.Failed),
+		zap.Int("skipped", report.Skipped),
+		zap.Int("not_implemented", report.NotImplemented),
+		zap.Strings("cleanup_errors", report.CleanupErrors))
+	return report.OK(), nil
+}
+
+// pickConformancePlugin returns the plugin called name.
+func pickConformancePlugin(plugins []mcpplugin.Plugin, name string) (mcpplugin.Plugin, error) {
+	name = mcpplugin.NormalizeName(name)
+	available := make([]string, 0, len(plugins))
+	for _, p := range plugins {
+		if p.Name() == name {
+			return p, nil
+		}
+		available = append(available, p.Name())
+	}
+	return nil, errors.Errorf("plugin %q is not configured; available: %s", name, strings.Join(available, ", "))
+}
+
+// writeConformanceReport writes report as indented JSON to path or stdout.
+func writeConformanceReport(path string, report conformance.Report) error {
+	writer := io.Writer(os.Stdout)
+	if path != "" && path != "-" {
+		fp, err := os.Create(path)
+		if err != nil {
+			return errors.Wrapf(err, "create %s", path)
+		}
+		defer func() { _ = fp.Close() }()
+		writer = fp
+	}
+
+	enc := json.NewEncoder(writer)
+	enc.SetIndent("", "  ")
+	return errors.Wrap(enc.Encode(report), "encode conformance report")
+}
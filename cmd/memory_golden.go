@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Laisky/errors/v2"
+	gcmd "github.com/Laisky/go-utils/v6/cmd"
+	logSDK "github.com/Laisky/go-utils/v6/log"
+	"github.com/Laisky/zap"
+	"github.com/spf13/cobra"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/conformance/eval"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+	pageindexplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugins/pageindex"
+	"github.com/Laisky/laisky-blog-graphql/library/log"
+)
+
+// Question modes of `memory golden`.
+const (
+	goldenModeTemplate = "template"
+	goldenModeLLM      = "llm"
+)
+
+var memoryGoldenCMD = &cobra.Command{
+	Use:   "golden",
+	Short: "generate a synthetic retrieval golden set from a memory project",
+	Long: `sample passages from the --project files, write one question per passage and
+emit them as retrieval golden JSONL (the memory-bench-internal-v1 format).
+
+Each query's gold document is the file its passage came from. --mode=template
+writes keyword questions offline; --mode=llm asks the pageindex LLM
+(settings.mcp.tools.memory.plugins.pageindex.llm) for natural questions.
+--evaluate also runs the retrieval metrics against the same project.`,
+	Args: gcmd.NoExtraArgs,
+	PreRun: func(cmd *cobra.Command, _ []string) {
+		if err := initialize(context.Background(), cmd); err != nil {
+			log.Logger.Panic("initialize memory golden", zap.Error(err))
+		}
+	},
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runMemoryGolden(cmd); err != nil {
+			log.Logger.Panic("generate memory golden set", zap.Error(err))
+		}
+	},
+}
+
+func init() {
+	memoryCMD.AddCommand(memoryGoldenCMD)
+
+	memoryGoldenCMD.Flags().String("plugin", mcpplugin.DefaultPluginRAG, "plugin that reads the project")
+	memoryGoldenCMD.Flags().String("path-prefix", "", "only sample files under this directory")
+	memoryGoldenCMD.Flags().Int("count", 50, "number of queries to generate")
+	memoryGoldenCMD.Flags().Int("per-passage", 1, "questions to ask per sampled passage")
+	memoryGoldenCMD.Flags().Int("passage-bytes", 1500, "target passage size in bytes")
+	memoryGoldenCMD.Flags().Uint64("seed", 1, "sampling seed; the same seed and data give the same set")
+	memoryGoldenCMD.Flags().String("mode", goldenModeTemplate, "question writer: `template` (offline) or `llm`")
+	memoryGoldenCMD.Flags().String("model", "", "LLM model for --mode=llm (default: the pageindex indexing model)")
+	memoryGoldenCMD.Flags().Bool("evaluate", false, "run the retrieval metrics on the generated set and log them")
+	memoryGoldenCMD.Flags().StringP("output", "o", "-", "golden JSONL path, `-` for stdout")
+}
+
+// validatePassageBytes rejects passage targets below the shortest passage
+// the generator keeps, which would leave nothing to sample.
+func validatePassageBytes(passageBytes int) error {
+	if passageBytes < eval.DefaultMinPassageBytes {
+		return errors.Errorf("--passage-bytes must be at least %d", eval.DefaultMinPassageBytes)
+	}
+	return nil
+}
+
+// runMemoryGolden generates the golden set and writes it.
+func runMemoryGolden(cmd *cobra.Command) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logger := log.Logger.Named("memory_golden")
+
+	auth, err := memoryAuthFromFlags(cmd)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	project, _ := cmd.Flags().GetString("project")
+	pluginName, _ := cmd.Flags().GetString("plugin")
+	pathPrefix, _ := cmd.Flags().GetString("path-prefix")
+	count, _ := cmd.Flags().GetInt("count")
+	perPassage, _ := cmd.Flags().GetInt("per-passage")
+	passageBytes, _ := cmd.Flags().GetInt("passage-bytes")
+	seed, _ := cmd.Flags().GetUint64("seed")
+	mode, _ := cmd.Flags().GetString("mode")
+	model, _ := cmd.Flags().GetString("model")
+	evaluate, _ := cmd.Flags().GetBool("evaluate")
+	output, _ := cmd.Flags().GetString("output")
+	if strings.TrimSpace(project) == "" {
+		return errors.New("--project is required")
+	}
+	if err = validatePassageBytes(passageBytes); err != nil {
+		return errors.WithStack(err)
+	}
+
+	writer, err := goldenQuestionWriter(mode, model, logger)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	fileSvc, _, closeFn, err := buildFilesServiceFromConfig(ctx, logger)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer closeFn()
+	plugins, err := buildMemoryPlugins(fileSvc, logger)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	plugin, err := pickConformancePlugin(plugins, pluginName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = plugin.Start(ctx); err != nil {
+		return errors.Wrapf(err, "start plugin %q", plugin.Name())
+	}
+	defer func() {
+		if stopErr := plugin.Stop(context.Background()); stopErr != nil {
+			logger.Warn("stop plugin", zap.String("plugin", plugin.Name()), zap.Error(stopErr))
+		}
+	}()
+
+	queries, err := eval.GenerateGoldenSet(ctx, eval.GoldenGenOptions{
+		Plugin:              plugin,
+		Auth:                auth,
+		Project:             project,
+		PathPrefix:          pathPrefix,
+		Writer:              writer,
+		NumQueries:          count,
+		QuestionsPerPassage: perPassage,
+		PassageBytes:        passageBytes,
+		Seed:                seed,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(queries) < count {
+		logger.Warn("project ran out of passages", zap.Int("generated", len(queries)), zap.Int("requested", count))
+	}
+	if err = writeGoldenSet(output, queries); err != nil {
+		return errors.WithStack(err)
+	}
+	logger.Info("memory golden set written", zap.Int("queries", len(queries)), zap.String("mode", mode))
+
+	if !evaluate {
+		return nil
+	}
+	report, err := eval.RunRetrievalEval(ctx, plugin, queries, eval.RetrievalOpts{Project: project, Auth: auth, K: 10})
+	if err != nil {
+		return errors.Wrap(err, "evaluate golden set")
+	}
+	summary, _ := json.Marshal(report.Overall)
+	logger.Info("retrieval metrics on the generated set", zap.ByteString("overall", summary))
+	return nil
+}
+
+// goldenQuestionWriter builds the writer for mode.
+func goldenQuestionWriter(mode, model string, logger logSDK.Logger) (eval.QuestionWriter, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case goldenModeTemplate:
+		return eval.TemplateQuestionWriter{}, nil
+	case goldenModeLLM:
+	default:
+		return nil, errors.Errorf("unknown --mode %q; use template or llm", mode)
+	}
+
+	settings := pageindexplugin.LoadSettings().LLM
+	if strings.TrimSpace(settings.APIKey) == "" {
+		return nil, errors.New("--mode=llm needs settings.mcp.tools.memory.plugins.pageindex.llm.api_key")
+	}
+	if model == "" {
+		model = settings.IndexingModel
+	}
+	llm, err := pageindexplugin.NewOpenAILLM(pageindexplugin.LLMConfig{
+		APIKey:  settings.APIKey,
+		BaseURL: settings.BaseURL,
+		Model:   model,
+		Logger:  logger.Named("llm"),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "build golden llm")
+	}
+	return eval.JudgeQuestionWriter{Judge: llmJudge{llm: llm}, Opts: eval.RAGASOpts{Model: model}}, nil
+}
+
+// llmJudge adapts a pageindex LLM to eval.LLMJudge.
+type llmJudge struct {
+	llm pageindexplugin.LLM
+}
+
+// Judge sends the prompt as one user message and decodes the structured output.
+func (j llmJudge) Judge(ctx context.Context, req eval.JudgeRequest) (eval.JudgeResponse, error) {
+	schema, err := json.Marshal(req.Schema)
+	if err != nil {
+		return eval.JudgeResponse{}, errors.Wrap(err, "encode judge schema")
+	}
+	resp, err := j.llm.Respond(ctx, pageindexplugin.Request{
+		Model:        req.Model,
+		Input:        []pageindexplugin.InputItem{{Role: "user", Content: req.Prompt}},
+		Schema:       schema,
+		SchemaName:   "judge_output",
+		MaxOutTokens: req.MaxOutTokens,
+		Temperature:  req.Temperature,
+	})
+	if err != nil {
+		return eval.JudgeResponse{}, errors.WithStack(err)
+	}
+	var output map[string]any
+	if err = json.Unmarshal(resp.Output, &output); err != nil {
+		return eval.JudgeResponse{}, errors.Wrap(err, "decode judge output")
+	}
+	return eval.JudgeResponse{
+		Output:       output,
+		InputTokens:  resp.Usage.InputTokens,
+		OutputTokens: resp.Usage.OutputTokens,
+		TotalTokens:  resp.Usage.TotalTokens,
+		JudgeID:      resp.Model,
+	}, nil
+}
+
+// writeGoldenSet writes queries as golden JSONL to path or stdout.
+func writeGoldenSet(path string, queries []eval.RetrievalQuery) error {
+	writer := io.Writer(os.Stdout)
+	if path != "" && path != "-" {
+		fp, err := os.Create(path)
+		if err != nil {
+			return errors.Wrapf(err, "create %s", path)
+		}
+		defer func() { _ = fp.Close() }()
+		writer = fp
+	}
+	return errors.WithStack(eval.WriteRetrievalQueries(writer, queries))
+}
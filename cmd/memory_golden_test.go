@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidatePassageBytes verifies tiny passage targets are rejected before sampling.
+func TestValidatePassageBytes(t *testing.T) {
+	for _, n := range []int{-1, 0, 1, 199} {
+		require.ErrorContains(t, validatePassageBytes(n), "--passage-bytes must be at least 200", n)
+	}
+	require.NoError(t, validatePassageBytes(200))
+	require.NoError(t, validatePassageBytes(1500))
+}
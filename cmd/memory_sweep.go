@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Laisky/errors/v2"
+	gcmd "github.com/Laisky/go-utils/v6/cmd"
+	"github.com/Laisky/zap"
+	"github.com/spf13/cobra"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/conformance/eval"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+	ragplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugins/rag"
+	"github.com/Laisky/laisky-blog-graphql/library/log"
+)
+
+var memorySweepCMD = &cobra.Command{
+	Use:   "sweep",
+	Short: "grid-search file_search settings against a retrieval golden set",
+	Long: `run the retrieval suite over every combination of the given file_search
+settings (settings.mcp.tools.memory.plugins.rag.search) against the live
+--project index and report the best combination per metric.
+
+Dimensions left unset keep the configured value. Only query-time settings
+are swept; the project must already be indexed.`,
+	Args: gcmd.NoExtraArgs,
+	PreRun: func(cmd *cobra.Command, _ []string) {
+		if err := initialize(context.Background(), cmd); err != nil {
+			log.Logger.Panic("initialize memory sweep", zap.Error(err))
+		}
+	},
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runMemorySweep(cmd); err != nil {
+			log.Logger.Panic("run memory sweep", zap.Error(err))
+		}
+	},
+}
+
+func init() {
+	memoryCMD.AddCommand(memorySweepCMD)
+
+	memorySweepCMD.Flags().String("golden", "", "retrieval golden JSONL, e.g. from memory golden")
+	memorySweepCMD.Flags().IntSlice("vector-candidates", nil, "vector candidate counts to try")
+	memorySweepCMD.Flags().IntSlice("lexical-candidates", nil, "lexical candidate counts to try")
+	memorySweepCMD.Flags().Float64Slice("semantic-weights", nil, "semantic weights in [0,1] to try; the lexical weight is 1 - w")
+	memorySweepCMD.Flags().BoolSlice("rerank", nil, "rerank settings to try, e.g. false,true")
+	memorySweepCMD.Flags().Int("k", 10, "results requested per query")
+	memorySweepCMD.Flags().StringP("output", "o", "-", "JSON report path, `-` for stdout")
+}
+
+// runMemorySweep evaluates every grid point and writes the report.
+func runMemorySweep(cmd *cobra.Command) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logger := log.Logger.Named("memory_sweep")
+
+	auth, err := memoryAuthFromFlags(cmd)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	project, _ := cmd.Flags().GetString("project")
+	goldenPath, _ := cmd.Flags().GetString("golden")
+	vectors, _ := cmd.Flags().GetIntSlice("vector-candidates")
+	lexicals, _ := cmd.Flags().GetIntSlice("lexical-candidates")
+	weights, _ := cmd.Flags().GetFloat64Slice("semantic-weights")
+	reranks, _ := cmd.Flags().GetBoolSlice("rerank")
+	k, _ := cmd.Flags().GetInt("k")
+	output, _ := cmd.Flags().GetString("output")
+	if strings.TrimSpace(project) == "" {
+		return errors.New("--project is required")
+	}
+	if strings.TrimSpace(goldenPath) == "" {
+		return errors.New("--golden is required")
+	}
+
+	queries, err := eval.LoadRetrievalQueries(goldenPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(queries) == 0 {
+		return errors.Errorf("golden set %s is empty", goldenPath)
+	}
+
+	fileSvc, _, closeFn, err := buildFilesServiceFromConfig(ctx, logger)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer closeFn()
+
+	base := fileSvc.SearchSettings()
+	points, err := eval.SearchSweepGrid{
+		VectorCandidates:  vectors,
+		LexicalCandidates: lexicals,
+		SemanticWeights:   weights,
+		Rerank:            reranks,
+	}.Points(base, fileSvc.HasRerank())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, point := range points {
+		if point.Rerank && !fileSvc.HasRerank() {
+			return errors.New("--rerank=true needs a configured rerank client")
+		}
+	}
+	logger.Info("memory sweep started", zap.Int("points", len(points)), zap.Int("queries", len(queries)))
+
+	report, err := eval.RunSearchSweep(ctx, points, func(point eval.SearchSweepPoint) (mcpplugin.Plugin, error) {
+		return ragplugin.New(fileSvc.WithSearchSettings(point.Apply(base), point.Rerank))
+	}, queries, eval.RetrievalOpts{Project: project, Auth: auth, K: k})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, metric := range eval.SearchSweepMetrics() {
+		best := report.Best[metric]
+		point, _ := json.Marshal(best.Point)
+		logger.Info("best sweep point",
+			zap.String("metric", metric),
+			zap.ByteString("point", point),
+			zap.Float64("recall_at_10", best.Overall.Recall10),
+			zap.Float64("ndcg_at_10", best.Overall.NDCG10),
+			zap.Float64("mrr", best.Overall.MRR),
+			zap.Float64("hit_at_5", best.Overall.Hit5))
+	}
+	return errors.WithStack(writeSweepReport(output, report))
+}
+
+// writeSweepReport writes the report as indented JSON to path or stdout.
+func writeSweepReport(path string, report eval.SearchSweepReport) error {
+	writer := io.Writer(os.Stdout)
+	if path != "" && path != "-" {
+		fp, err := os.Create(path)
+		if err != nil {
+			return errors.Wrapf(err, "create %s", path)
+		}
+		defer func() { _ = fp.Close() }()
+		writer = fp
+	}
+	enc := json.NewEncoder(writer)
+	enc.SetIndent("", "  ")
+	return errors.Wrap(enc.Encode(report), "encode sweep report")
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/Laisky/errors/v2"
+	gconfig "github.com/Laisky/go-config/v2"
+	gcmd "github.com/Laisky/go-utils/v6/cmd"
+	"github.com/Laisky/zap"
+	"github.com/spf13/cobra"
+
+	pageindexplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugins/pageindex"
+	"github.com/Laisky/laisky-blog-graphql/library/log"
+)
+
+var pageindexCacheCMD = &cobra.Command{
+	Use:   "pageindex-cache",
+	Short: "inspect and maintain the pageindex LLM response cache",
+	Long: `inspect, prune and compact the pageindex bbolt cache file.
+
+bbolt locks the file, so stop the API server that uses the cache first.`,
+	Args: gcmd.NoExtraArgs,
+}
+
+var pageindexCacheInspectCMD = &cobra.Command{
+	Use:    "inspect",
+	Short:  "print entry counts, sizes and models of the cache",
+	Args:   gcmd.NoExtraArgs,
+	PreRun: pageindexCachePreRun,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runPageindexCacheInspect(cmd); err != nil {
+			log.Logger.Panic("inspect pageindex cache", zap.Error(err))
+		}
+	},
+}
+
+var pageindexCachePruneCMD = &cobra.Command{
+	Use:    "prune",
+	Short:  "delete cached responses by model and/or age",
+	Args:   gcmd.NoExtraArgs,
+	PreRun: pageindexCachePreRun,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runPageindexCachePrune(cmd); err != nil {
+			log.Logger.Panic("prune pageindex cache", zap.Error(err))
+		}
+	},
+}
+
+var pageindexCacheCompactCMD = &cobra.Command{
+	Use:    "compact",
+	Short:  "rewrite the cache file to release space freed by pruning",
+	Args:   gcmd.NoExtraArgs,
+	PreRun: pageindexCachePreRun,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runPageindexCacheCompact(cmd); err != nil {
+			log.Logger.Panic("compact pageindex cache", zap.Error(err))
+		}
+	},
+}
+
+func init() {
+	rootCMD.AddCommand(pageindexCacheCMD)
+	pageindexCacheCMD.AddCommand(pageindexCacheInspectCMD, pageindexCachePruneCMD, pageindexCacheCompactCMD)
+
+	pageindexCacheCMD.PersistentFlags().String("path", "", "cache file path (default: indexer.cache.path from the config)")
+
+	pageindexCachePruneCMD.Flags().String("model", "", "delete responses answered by this model")
+	pageindexCachePruneCMD.Flags().Duration("older-than", 0, "delete responses cached longer ago than this, like `720h`")
+}
+
+// pageindexCachePreRun loads the config only when --path is not given, so the
+// commands also work against a copied cache file without a config.
+func pageindexCachePreRun(cmd *cobra.Command, _ []string) {
+	if err := gconfig.Shared.BindPFlags(cmd.Flags()); err != nil {
+		log.Logger.Panic("bind pflags", zap.Error(err))
+	}
+	if path, _ := cmd.Flags().GetString("path"); strings.TrimSpace(path) == "" {
+		setupSettings(context.Background())
+	}
+}
+
+// pageindexCachePath resolves --path or the configured cache path.
+func pageindexCachePath(cmd *cobra.Command) (string, error) {
+	path, _ := cmd.Flags().GetString("path")
+	path = strings.TrimSpace(path)
+	if path == "" {
+		path = pageindexplugin.LoadSettings().Indexer.Cache.Path
+	}
+	if path == "" {
+		return "", errors.New("--path or indexer.cache.path is required")
+	}
+	return path, nil
+}
+
+// runPageindexCacheInspect prints the cache report as JSON.
+func runPageindexCacheInspect(cmd *cobra.Command) error {
+	path, err := pageindexCachePath(cmd)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	report, err := pageindexplugin.InspectCacheFile(path)
+	if err != nil {
+		return errors.Wrap(err, "inspect")
+	}
+	return writePageindexCacheJSON(report)
+}
+
+// runPageindexCachePrune deletes matching records and prints the count.
+func runPageindexCachePrune(cmd *cobra.Command) error {
+	path, err := pageindexCachePath(cmd)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	model, _ := cmd.Flags().GetString("model")
+	olderThan, _ := cmd.Flags().GetDuration("older-than")
+
+	pruned, err := pageindexplugin.PruneCacheFile(path, pageindexplugin.PruneOptions{
+		Model:     strings.TrimSpace(model),
+		OlderThan: olderThan,
+	})
+	if err != nil {
+		return errors.Wrap(err, "prune")
+	}
+	return writePageindexCacheJSON(map[string]any{"path": path, "pruned": pruned})
+}
+
+// runPageindexCacheCompact compacts the cache file and prints its sizes.
+func runPageindexCacheCompact(cmd *cobra.Command) error {
+	path, err := pageindexCachePath(cmd)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	before, after, err := pageindexplugin.CompactCacheFile(path)
+	if err != nil {
+		return errors.Wrap(err, "compact")
+	}
+	return writePageindexCacheJSON(map[string]any{"path": path, "before_bytes": before, "after_bytes": after})
+}
+
+// writePageindexCacheJSON writes payload to stdout as indented JSON.
+func writePageindexCacheJSON(payload any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return errors.Wrap(enc.Encode(payload), "encode output")
+}
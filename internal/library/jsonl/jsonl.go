@@ -0,0 +1,66 @@
+// Package jsonl reads and appends JSON Lines files such as recorded fixtures.
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	errors "github.com/Laisky/errors/v2"
+)
+
+// DefaultMaxLineBytes bounds one line when the caller passes no limit.
+const DefaultMaxLineBytes = 16 << 20
+
+// ReadFile calls fn with every non-blank line of the file at path, numbering
+// lines from 1. A missing file reads as empty. The first error returned by fn
+// stops the scan and is returned unchanged.
+func ReadFile(path string, maxLineBytes int, fn func(lineNo int, line []byte) error) error {
+	if maxLineBytes <= 0 {
+		maxLineBytes = DefaultMaxLineBytes
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "open %s", path)
+	}
+	defer file.Close() //nolint:errcheck
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineBytes)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(lineNo, line); err != nil {
+			return err
+		}
+	}
+	return errors.Wrapf(scanner.Err(), "read %s", path)
+}
+
+// Append encodes v as one line and appends it to the file at path, creating
+// the file and its directory when needed. Callers serialize concurrent appends.
+func Append(path string, v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "encode line")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.Wrapf(err, "create dir for %s", path)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrapf(err, "open %s", path)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return errors.Wrapf(err, "append to %s", path)
+	}
+	return errors.Wrapf(file.Close(), "close %s", path)
+}
@@ -0,0 +1,61 @@
+package jsonl
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestAppendAndReadFile verifies appended values read back in order and blank lines are skipped.
+func TestAppendAndReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "fixtures.jsonl")
+	require.NoError(t, Append(path, map[string]int{"n": 1}))
+	require.NoError(t, Append(path, map[string]int{"n": 2}))
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString("\n   \n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	require.NoError(t, Append(path, map[string]int{"n": 3}))
+
+	var got []int
+	var lines []int
+	err = ReadFile(path, 0, func(lineNo int, line []byte) error {
+		var v map[string]int
+		if err := json.Unmarshal(line, &v); err != nil {
+			return err
+		}
+		got = append(got, v["n"])
+		lines = append(lines, lineNo)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3}, got)
+	require.Equal(t, []int{1, 2, 5}, lines)
+}
+
+// TestReadFileMissingAndCallbackError verifies a missing file is empty and callback errors stop the scan.
+func TestReadFileMissingAndCallbackError(t *testing.T) {
+	dir := t.TempDir()
+	calls := 0
+	require.NoError(t, ReadFile(filepath.Join(dir, "missing.jsonl"), 0, func(int, []byte) error {
+		calls++
+		return nil
+	}))
+	require.Zero(t, calls)
+
+	path := filepath.Join(dir, "bad.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{}\n{}\n"), 0o600))
+	stop := errors.New("stop")
+	err := ReadFile(path, 0, func(int, []byte) error {
+		calls++
+		return stop
+	})
+	require.ErrorIs(t, err, stop)
+	require.Equal(t, 1, calls)
+}
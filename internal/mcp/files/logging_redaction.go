@@ -11,6 +11,8 @@ var FileToolNames = map[string]struct{}{
 	"file_rename": {},
 	"file_list":   {},
 	"file_search": {},
+	// doc_read_node returns document text in its chunks.
+	"doc_read_node": {},
 }
 
 // RedactToolArguments removes sensitive payloads from tool arguments.
@@ -66,6 +68,9 @@ func redactChunks(value any) any {
 		if content, ok := cloned["chunk_content"]; ok {
 			cloned["chunk_content"] = summarizeRedaction(content)
 		}
+		if content, ok := cloned["content"]; ok {
+			cloned["content"] = summarizeRedaction(content)
+		}
 		result = append(result, cloned)
 	}
 	return result
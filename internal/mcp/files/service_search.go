@@ -22,6 +22,29 @@ type searchCandidate struct {
 	FinalScore    float64
 }
 
+// SearchSettings returns the query-time settings Search uses.
+func (s *Service) SearchSettings() SearchSettings {
+	return s.settings.Search
+}
+
+// HasRerank reports whether a rerank client is configured.
+func (s *Service) HasRerank() bool {
+	return s.rerank != nil
+}
+
+// WithSearchSettings returns a view of the service whose Search uses search
+// instead of the configured settings, with reranking disabled unless rerank
+// is set. The view shares the database, embedder and every other setting
+// with s. It exists for offline parameter sweeps.
+func (s *Service) WithSearchSettings(search SearchSettings, rerank bool) *Service {
+	view := *s
+	view.settings.Search = search
+	if !rerank {
+		view.rerank = nil
+	}
+	return &view
+}
+
 // Search performs hybrid retrieval over indexed file chunks.
 func (s *Service) Search(ctx context.Context, auth AuthContext, project, query, pathPrefix string, limit int) (SearchResult, error) {
 	if err := s.validateAuth(auth); err != nil {
@@ -658,3 +658,39 @@ func TestSearchFallbackHonorsPathPrefix(t *testing.T) {
 		require.True(t, strings.HasPrefix(chunk.FilePath, "/dir"))
 	}
 }
+
+// TestWithSearchSettingsLeavesServiceUntouched verifies sweep views override search settings without mutating the original.
+func TestWithSearchSettingsLeavesServiceUntouched(t *testing.T) {
+	settings := LoadSettingsFromConfig()
+	settings.Search.Enabled = true
+	settings.Security.EncryptionKEKs = map[uint16]string{1: testEncryptionKey()}
+	settings.Index.BatchSize = 10
+	settings.Index.ChunkBytes = 64
+	settings.MaxProjectBytes = 10_000
+
+	svc := newTestService(t, settings, testEmbedder{vector: pgvector.NewVector([]float32{1, 0})}, &memoryCredentialStore{})
+	svc.rerank = stubRerankClient{scores: []float64{1}}
+	auth := AuthContext{APIKeyHash: "hash", APIKey: "key", UserIdentity: "user:test"}
+
+	_, err := svc.Write(context.Background(), auth, "proj", "/a.txt", "alpha match token", "utf-8", 0, WriteModeAppend)
+	require.NoError(t, err)
+	worker := svc.NewIndexWorker()
+	require.NoError(t, worker.RunOnce(context.Background()))
+
+	search := svc.SearchSettings()
+	search.VectorCandidates = 1
+	search.LexicalCandidates = 1
+	search.SemanticWeight = 0.1
+	search.LexicalWeight = 0.9
+	view := svc.WithSearchSettings(search, false)
+
+	require.False(t, view.HasRerank())
+	require.Equal(t, search, view.SearchSettings())
+	require.True(t, svc.HasRerank())
+	require.Equal(t, settings.Search, svc.SearchSettings())
+
+	searchRes, err := view.Search(context.Background(), auth, "proj", "alpha", "", 5)
+	require.NoError(t, err)
+	require.NotEmpty(t, searchRes.Chunks)
+	require.Equal(t, "/a.txt", searchRes.Chunks[0].FilePath)
+}
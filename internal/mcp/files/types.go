@@ -44,6 +44,8 @@ type ChunkEntry struct {
 	IsFullFile         bool    `json:"is_full_file"`
 	ChunkContent       string  `json:"chunk_content"`
 	Score              float64 `json:"score"`
+	// Plugin is populated only for federated searches and names the contributing memory plugin(s).
+	Plugin string `json:"plugin,omitempty"`
 }
 
 // AuthContext carries trusted caller identity for file operations.
@@ -0,0 +1,448 @@
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	errors "github.com/Laisky/errors/v2"
+)
+
+// Verdicts of a MetricComparison.
+const (
+	VerdictImproved  = "improved"
+	VerdictRegressed = "regressed"
+	VerdictUnchanged = "unchanged"
+)
+
+// comparedMetric names one per-query payload key compared across runs.
+type comparedMetric struct {
+	suite string
+	key   string
+}
+
+// comparedMetrics lists the per-query payload keys CompareRuns tests, in
+// report order. Every metric is higher-is-better.
+var comparedMetrics = []comparedMetric{
+	{"retrieval", "recall@10"},
+	{"retrieval", "ndcg@10"},
+	{"retrieval", "mrr"},
+	{"retrieval", "hit@5"},
+	{"ragas", "faithfulness"},
+	{"ragas", "context_precision"},
+	{"ragas", "context_recall"},
+	{"ragas", "context_entities_recall"},
+	{"ragas", "answer_correctness"},
+	{"ragas", "answer_relevancy"},
+}
+
+// worstQueriesPerMetric caps the per-query drill-down of a regressed metric.
+const worstQueriesPerMetric = 5
+
+// StoredRun is one eval-plugin output directory loaded from disk.
+type StoredRun struct {
+	// Label is the run's git SHA, or the directory name when run_metadata.yml
+	// does not record one.
+	Label   string
+	Dir     string
+	Records []PerQueryRecord
+}
+
+// LoadStoredRun reads raw_per_query.jsonl and the git SHA from
+// run_metadata.yml under dir.
+func LoadStoredRun(dir string) (StoredRun, error) {
+	run := StoredRun{Label: filepath.Base(filepath.Clean(dir)), Dir: dir}
+	records, err := LoadRawPerQuery(filepath.Join(dir, "raw_per_query.jsonl"))
+	if err != nil {
+		return StoredRun{}, errors.WithStack(err)
+	}
+	run.Records = records
+	if sha := readMetadataGitSHA(filepath.Join(dir, "run_metadata.yml")); sha != "" {
+		run.Label = sha
+	}
+	return run, nil
+}
+
+// LoadRawPerQuery parses a raw_per_query.jsonl file.
+func LoadRawPerQuery(path string) ([]PerQueryRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open %s", path)
+	}
+	defer f.Close()
+
+	var out []PerQueryRecord
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 1<<20), 16<<20)
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var rec PerQueryRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			return nil, errors.Wrapf(err, "decode %s line %d", path, lineNo)
+		}
+		out = append(out, rec)
+	}
+	return out, errors.Wrapf(sc.Err(), "scan %s", path)
+}
+
+// readMetadataGitSHA returns the git_sha value of run_metadata.yml, or "".
+func readMetadataGitSHA(path string) string {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(raw), "\n") {
+		if value, ok := strings.CutPrefix(line, "git_sha:"); ok {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
+
+// CompareOptions tunes CompareRuns.
+type CompareOptions struct {
+	// Alpha is the significance level; zero means 0.05.
+	Alpha float64
+	// B is the number of permutation shuffles; zero means 10000.
+	B int
+}
+
+// QueryDelta is one query's metric change.
+type QueryDelta struct {
+	QueryID   string  `json:"query_id"`
+	Baseline  float64 `json:"baseline"`
+	Candidate float64 `json:"candidate"`
+	Delta     float64 `json:"delta"`
+}
+
+// MetricComparison is the paired test result of one metric.
+type MetricComparison struct {
+	Suite         string  `json:"suite"`
+	Metric        string  `json:"metric"`
+	N             int     `json:"n"`
+	BaselineMean  float64 `json:"baseline_mean"`
+	CandidateMean float64 `json:"candidate_mean"`
+	Delta         float64 `json:"delta"`
+	PValue        float64 `json:"p_value"`
+	// AdjustedPValue is PValue after the Holm–Bonferroni correction across
+	// every metric in the report. Significant compares it against Alpha.
+	AdjustedPValue float64 `json:"adjusted_p_value"`
+	Significant    bool    `json:"significant"`
+	Verdict        string  `json:"verdict"`
+	// WorstQueries lists the largest per-query drops of a regressed metric.
+	WorstQueries []QueryDelta `json:"worst_queries,omitempty"`
+}
+
+// ComparisonReport is the regression report between two stored runs.
+type ComparisonReport struct {
+	Baseline  string             `json:"baseline"`
+	Candidate string             `json:"candidate"`
+	Alpha     float64            `json:"alpha"`
+	B         int                `json:"b"`
+	Metrics   []MetricComparison `json:"metrics"`
+	// OnlyBaseline and OnlyCandidate list "suite/query_id" keys present in
+	// one run only. They are left out of every test.
+	OnlyBaseline  []string `json:"only_baseline,omitempty"`
+	OnlyCandidate []string `json:"only_candidate,omitempty"`
+}
+
+// Regressions returns the metrics that got significantly worse.
+func (r ComparisonReport) Regressions() []MetricComparison {
+	var out []MetricComparison
+	for _, m := range r.Metrics {
+		if m.Verdict == VerdictRegressed {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// CompareRuns aligns per-query records of two runs by suite and QueryID and
+// runs PairedPermutationTest on every metric both runs recorded. Records
+// without a QueryID, such as "missing" suite markers, are ignored.
+func CompareRuns(baseline, candidate StoredRun, opts CompareOptions) ComparisonReport {
+	if opts.Alpha <= 0 {
+		opts.Alpha = 0.05
+	}
+	if opts.B <= 0 {
+		opts.B = 10000
+	}
+	report := ComparisonReport{Baseline: baseline.Label, Candidate: candidate.Label, Alpha: opts.Alpha, B: opts.B}
+
+	base := indexRecords(baseline.Records)
+	cand := indexRecords(candidate.Records)
+	var shared []string
+	for key := range base {
+		if _, ok := cand[key]; ok {
+			shared = append(shared, key)
+		} else {
+			report.OnlyBaseline = append(report.OnlyBaseline, key)
+		}
+	}
+	for key := range cand {
+		if _, ok := base[key]; !ok {
+			report.OnlyCandidate = append(report.OnlyCandidate, key)
+		}
+	}
+	sort.Strings(shared)
+	sort.Strings(report.OnlyBaseline)
+	sort.Strings(report.OnlyCandidate)
+
+	var metricPairs [][]QueryDelta
+	for _, metric := range comparedMetrics {
+		var pairs []QueryDelta
+		for _, key := range shared {
+			b, c := base[key], cand[key]
+			if b.Suite != metric.suite {
+				continue
+			}
+			bv, okB := payloadFloat(b.Payload, metric.key)
+			cv, okC := payloadFloat(c.Payload, metric.key)
+			if !okB || !okC {
+				continue
+			}
+			pairs = append(pairs, QueryDelta{QueryID: b.QueryID, Baseline: bv, Candidate: cv, Delta: cv - bv})
+		}
+		if len(pairs) == 0 {
+			continue
+		}
+		report.Metrics = append(report.Metrics, compareMetric(metric, pairs, opts))
+		metricPairs = append(metricPairs, pairs)
+	}
+
+	pValues := make([]float64, len(report.Metrics))
+	for i, m := range report.Metrics {
+		pValues[i] = m.PValue
+	}
+	for i, adjusted := range holmAdjust(pValues) {
+		report.Metrics[i].AdjustedPValue = adjusted
+		decideVerdict(&report.Metrics[i], metricPairs[i], opts.Alpha)
+	}
+	return report
+}
+
+// holmAdjust returns Holm–Bonferroni adjusted p-values in input order, so
+// testing every metric at alpha keeps the family-wise error rate at alpha.
+func holmAdjust(pValues []float64) []float64 {
+	order := make([]int, len(pValues))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return pValues[order[a]] < pValues[order[b]] })
+
+	adjusted := make([]float64, len(pValues))
+	running := 0.0
+	for rank, i := range order {
+		running = math.Max(running, math.Min(1, float64(len(pValues)-rank)*pValues[i]))
+		adjusted[i] = running
+	}
+	return adjusted
+}
+
+// compareMetric runs the permutation test over one metric's pairs. The
+// verdict is set by decideVerdict once every metric's p-value is known.
+func compareMetric(metric comparedMetric, pairs []QueryDelta, opts CompareOptions) MetricComparison {
+	diffs := make([]float64, len(pairs))
+	baseVals := make([]float64, len(pairs))
+	candVals := make([]float64, len(pairs))
+	for i, p := range pairs {
+		diffs[i], baseVals[i], candVals[i] = p.Delta, p.Baseline, p.Candidate
+	}
+
+	mc := MetricComparison{
+		Suite:         metric.suite,
+		Metric:        metric.key,
+		N:             len(pairs),
+		BaselineMean:  meanFloat(baseVals),
+		CandidateMean: meanFloat(candVals),
+		Delta:         meanFloat(diffs),
+		PValue:        PairedPermutationTest(diffs, opts.B),
+		Verdict:       VerdictUnchanged,
+	}
+	return mc
+}
+
+// decideVerdict marks mc significant when its adjusted p-value is below
+// alpha and lists the worst queries of a regression.
+func decideVerdict(mc *MetricComparison, pairs []QueryDelta, alpha float64) {
+	mc.Significant = mc.AdjustedPValue < alpha && mc.Delta != 0
+	switch {
+	case mc.Significant && mc.Delta > 0:
+		mc.Verdict = VerdictImproved
+	case mc.Significant && mc.Delta < 0:
+		mc.Verdict = VerdictRegressed
+		sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Delta < pairs[j].Delta })
+		for _, p := range pairs[:min(len(pairs), worstQueriesPerMetric)] {
+			if p.Delta < 0 {
+				mc.WorstQueries = append(mc.WorstQueries, p)
+			}
+		}
+	}
+}
+
+// indexRecords keys per-query records by "suite/query_id". A later record
+// with the same key replaces an earlier one.
+func indexRecords(records []PerQueryRecord) map[string]PerQueryRecord {
+	out := make(map[string]PerQueryRecord, len(records))
+	for _, r := range records {
+		if r.QueryID == "" || r.Status != "" {
+			continue
+		}
+		out[r.Suite+"/"+r.QueryID] = r
+	}
+	return out
+}
+
+// payloadFloat reads a numeric or boolean payload value. Booleans count as
+// 0 or 1 so hit@5 can be tested like the other metrics.
+func payloadFloat(payload map[string]any, key string) (float64, bool) {
+	switch v := payload[key].(type) {
+	case float64:
+		return v, !math.IsNaN(v)
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// WriteMarkdown renders the report as a Markdown regression summary.
+func (r ComparisonReport) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Eval comparison: %s → %s\n\n", r.Baseline, r.Candidate)
+	fmt.Fprintf(&b, "Paired two-sided permutation test, B = %d, α = %s, Holm–Bonferroni adjusted across %d metric(s).\n\n",
+		r.B, formatFloat(r.Alpha), len(r.Metrics))
+
+	regressions := r.Regressions()
+	switch {
+	case len(r.Metrics) == 0:
+		b.WriteString("No metric was recorded by both runs.\n\n")
+	case len(regressions) == 0:
+		b.WriteString("**No significant regressions.**\n\n")
+	default:
+		fmt.Fprintf(&b, "**%d significant regression(s).**\n\n", len(regressions))
+	}
+
+	if len(r.Metrics) > 0 {
+		b.WriteString("| Suite | Metric | n | Baseline | Candidate | Δ | p | adjusted p | Verdict |\n")
+		b.WriteString("| --- | --- | --- | --- | --- | --- | --- | --- | --- |\n")
+		for _, m := range r.Metrics {
+			fmt.Fprintf(&b, "| %s | %s | %d | %s | %s | %s | %s | %s | %s |\n",
+				m.Suite, m.Metric, m.N, formatFloat(m.BaselineMean), formatFloat(m.CandidateMean),
+				signedFloat(m.Delta), formatPValue(m.PValue), formatPValue(m.AdjustedPValue), markdownVerdict(m.Verdict))
+		}
+		b.WriteString("\n")
+	}
+
+	for _, m := range regressions {
+		fmt.Fprintf(&b, "## Worst queries: %s %s\n\n", m.Suite, m.Metric)
+		b.WriteString("| Query | Baseline | Candidate | Δ |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+		for _, q := range m.WorstQueries {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", q.QueryID, formatFloat(q.Baseline), formatFloat(q.Candidate), signedFloat(q.Delta))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.OnlyBaseline)+len(r.OnlyCandidate) > 0 {
+		fmt.Fprintf(&b, "Unpaired queries were left out: %d only in %s, %d only in %s.\n",
+			len(r.OnlyBaseline), r.Baseline, len(r.OnlyCandidate), r.Candidate)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return errors.Wrap(err, "write comparison markdown")
+}
+
+// comparisonHTML renders ComparisonReport.WriteHTML.
+var comparisonHTML = template.Must(template.New("comparison").Funcs(template.FuncMap{
+	"num":    formatFloat,
+	"signed": signedFloat,
+	"pvalue": formatPValue,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Eval comparison: {{.Baseline}} → {{.Candidate}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child, td.name { text-align: left; }
+tr.regressed { background: #fdd; }
+tr.improved { background: #dfd; }
+</style>
+</head>
+<body>
+<h1>Eval comparison: {{.Baseline}} → {{.Candidate}}</h1>
+<p>Paired two-sided permutation test, B = {{.B}}, α = {{num .Alpha}}, Holm–Bonferroni adjusted across {{len .Metrics}} metric(s).</p>
+{{- $regressions := .Regressions}}
+{{- if not .Metrics}}
+<p>No metric was recorded by both runs.</p>
+{{- else if not $regressions}}
+<p><strong>No significant regressions.</strong></p>
+{{- else}}
+<p><strong>{{len $regressions}} significant regression(s).</strong></p>
+{{- end}}
+{{- if .Metrics}}
+<table>
+<tr><th>Suite</th><th>Metric</th><th>n</th><th>Baseline</th><th>Candidate</th><th>Δ</th><th>p</th><th>adjusted p</th><th>Verdict</th></tr>
+{{- range .Metrics}}
+<tr class="{{.Verdict}}"><td>{{.Suite}}</td><td class="name">{{.Metric}}</td><td>{{.N}}</td><td>{{num .BaselineMean}}</td><td>{{num .CandidateMean}}</td><td>{{signed .Delta}}</td><td>{{pvalue .PValue}}</td><td>{{pvalue .AdjustedPValue}}</td><td class="name">{{.Verdict}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- range $regressions}}
+<h2>Worst queries: {{.Suite}} {{.Metric}}</h2>
+<table>
+<tr><th>Query</th><th>Baseline</th><th>Candidate</th><th>Δ</th></tr>
+{{- range .WorstQueries}}
+<tr><td>{{.QueryID}}</td><td>{{num .Baseline}}</td><td>{{num .Candidate}}</td><td>{{signed .Delta}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if or .OnlyBaseline .OnlyCandidate}}
+<p>Unpaired queries were left out: {{len .OnlyBaseline}} only in {{.Baseline}}, {{len .OnlyCandidate}} only in {{.Candidate}}.</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML renders the report as a standalone HTML page.
+func (r ComparisonReport) WriteHTML(w io.Writer) error {
+	return errors.Wrap(comparisonHTML.Execute(w, r), "write comparison html")
+}
+
+func markdownVerdict(verdict string) string {
+	switch verdict {
+	case VerdictRegressed:
+		return "**regressed**"
+	case VerdictImproved:
+		return "improved"
+	default:
+		return "—"
+	}
+}
+
+func signedFloat(v float64) string {
+	if v > 0 {
+		return "+" + formatFloat(v)
+	}
+	return formatFloat(v)
+}
+
+func formatPValue(p float64) string {
+	return fmt.Sprintf("%.4f", p)
+}
@@ -0,0 +1,149 @@
+package eval
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// retrievalRecords builds n retrieval rows whose recall is recall(i).
+func retrievalRecords(n int, recall func(i int) float64) []PerQueryRecord {
+	out := []PerQueryRecord{{Suite: "ops", Status: "missing"}}
+	for i := 0; i < n; i++ {
+		out = append(out, PerQueryRecord{
+			Suite:   "retrieval",
+			QueryID: fmt.Sprintf("q%02d", i),
+			Payload: map[string]any{"recall@10": recall(i), "mrr": 0.5, "hit@5": i%2 == 0},
+		})
+	}
+	return out
+}
+
+func TestCompareRunsFlagsRegression(t *testing.T) {
+	baseline := StoredRun{Label: "base", Records: retrievalRecords(30, func(int) float64 { return 0.8 })}
+	candidate := StoredRun{Label: "cand", Records: retrievalRecords(30, func(i int) float64 {
+		return 0.8 - 0.1 - float64(i%3)*0.05
+	})}
+	candidate.Records = append(candidate.Records, PerQueryRecord{Suite: "retrieval", QueryID: "extra", Payload: map[string]any{"recall@10": 1.0}})
+
+	report := CompareRuns(baseline, candidate, CompareOptions{B: 2000})
+	if len(report.Metrics) != 3 {
+		t.Fatalf("metrics = %+v, want recall@10, mrr and hit@5", report.Metrics)
+	}
+	recall := report.Metrics[0]
+	if recall.Metric != "recall@10" || recall.N != 30 || recall.Verdict != VerdictRegressed {
+		t.Fatalf("recall comparison = %+v, want 30 pairs regressed", recall)
+	}
+	if len(recall.WorstQueries) != worstQueriesPerMetric || recall.WorstQueries[0].Delta > recall.WorstQueries[4].Delta {
+		t.Fatalf("worst queries = %+v, want the 5 largest drops first", recall.WorstQueries)
+	}
+	for _, m := range report.Metrics[1:] {
+		if m.Verdict != VerdictUnchanged {
+			t.Fatalf("%s verdict = %s, want unchanged", m.Metric, m.Verdict)
+		}
+	}
+	if len(report.OnlyCandidate) != 1 || report.OnlyCandidate[0] != "retrieval/extra" {
+		t.Fatalf("only_candidate = %v", report.OnlyCandidate)
+	}
+	if got := report.Regressions(); len(got) != 1 {
+		t.Fatalf("regressions = %+v, want 1", got)
+	}
+}
+
+func TestCompareRunsNoisyChangeIsNotSignificant(t *testing.T) {
+	baseline := StoredRun{Label: "base", Records: retrievalRecords(20, func(int) float64 { return 0.5 })}
+	candidate := StoredRun{Label: "cand", Records: retrievalRecords(20, func(i int) float64 {
+		if i%2 == 0 {
+			return 0.7
+		}
+		return 0.29
+	})}
+	report := CompareRuns(baseline, candidate, CompareOptions{B: 2000})
+	if report.Metrics[0].Significant {
+		t.Fatalf("recall comparison = %+v, want not significant", report.Metrics[0])
+	}
+}
+
+func TestHolmAdjust(t *testing.T) {
+	got := holmAdjust([]float64{0.01, 0.04, 0.03, 0.5})
+	want := []float64{0.04, 0.09, 0.09, 0.5}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-12 {
+			t.Fatalf("holmAdjust = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCompareRunsCorrectsForMultipleMetrics(t *testing.T) {
+	// Only a few queries drop, so recall@10 alone would pass at α = 0.05 but
+	// not once the p-value is adjusted for the three metrics compared.
+	baseline := StoredRun{Label: "base", Records: retrievalRecords(12, func(int) float64 { return 0.8 })}
+	candidate := StoredRun{Label: "cand", Records: retrievalRecords(12, func(i int) float64 {
+		if i < 6 {
+			return 0.7
+		}
+		return 0.8
+	})}
+	report := CompareRuns(baseline, candidate, CompareOptions{B: 20000})
+	recall := report.Metrics[0]
+	if recall.PValue >= 0.05 || recall.AdjustedPValue < 0.05 {
+		t.Fatalf("recall comparison = %+v, want raw p < 0.05 <= adjusted p", recall)
+	}
+	if recall.Verdict != VerdictUnchanged || len(report.Regressions()) != 0 {
+		t.Fatalf("recall verdict = %s, want unchanged after correction", recall.Verdict)
+	}
+}
+
+func TestLoadStoredRunAndRender(t *testing.T) {
+	dir := t.TempDir()
+	raw := strings.Join([]string{
+		`{"suite":"retrieval","status":"missing"}`,
+		`{"suite":"ragas","query_id":"s1","payload":{"faithfulness":0.9}}`,
+		`{"suite":"ragas","query_id":"s2","payload":{"faithfulness":0.8}}`,
+	}, "\n")
+	if err := os.WriteFile(filepath.Join(dir, "raw_per_query.jsonl"), []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "run_metadata.yml"), []byte("harness_version: \"v0.1.0\"\ngit_sha: \"abc123\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := LoadStoredRun(dir)
+	if err != nil {
+		t.Fatalf("LoadStoredRun: %v", err)
+	}
+	if run.Label != "abc123" || len(run.Records) != 3 {
+		t.Fatalf("run = %+v, want label abc123 and 3 records", run)
+	}
+
+	report := CompareRuns(run, run, CompareOptions{})
+	if len(report.Metrics) != 1 || report.Metrics[0].Metric != "faithfulness" || report.Metrics[0].Verdict != VerdictUnchanged {
+		t.Fatalf("self comparison = %+v", report.Metrics)
+	}
+
+	var md bytes.Buffer
+	if err := report.WriteMarkdown(&md); err != nil {
+		t.Fatalf("WriteMarkdown: %v", err)
+	}
+	if !strings.Contains(md.String(), "No significant regressions") || !strings.Contains(md.String(), "| ragas | faithfulness | 2 |") {
+		t.Fatalf("markdown:\n%s", md.String())
+	}
+
+	var html bytes.Buffer
+	if err := report.WriteHTML(&html); err != nil {
+		t.Fatalf("WriteHTML: %v", err)
+	}
+	if !strings.Contains(html.String(), "<td class=\"name\">faithfulness</td>") {
+		t.Fatalf("html:\n%s", html.String())
+	}
+}
+
+func TestLoadStoredRunMissingFile(t *testing.T) {
+	if _, err := LoadStoredRun(t.TempDir()); err == nil {
+		t.Fatal("expected an error for a directory without raw_per_query.jsonl")
+	}
+}
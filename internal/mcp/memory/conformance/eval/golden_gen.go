@@ -0,0 +1,361 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	errors "github.com/Laisky/errors/v2"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+)
+
+// Passage is one chunk sampled from a project file.
+type Passage struct {
+	Path   string
+	Offset int64
+	Text   string
+}
+
+// QuestionWriter turns a passage into questions that the passage answers.
+type QuestionWriter interface {
+	WriteQuestions(ctx context.Context, passage Passage, n int) ([]string, error)
+}
+
+// JudgeQuestionWriter asks an LLMJudge to write the questions.
+type JudgeQuestionWriter struct {
+	Judge LLMJudge
+	Opts  RAGASOpts
+}
+
+// promptGoldenQuestions asks for self-contained questions a searcher could
+// only answer by finding the passage.
+const promptGoldenQuestions = `You write evaluation queries for a document search engine.
+Read the passage and write {{.N}} question(s) that it answers.
+Each question must be self-contained, must not quote the passage verbatim,
+and must not mention "the passage" or "the document".
+
+passage:
+{{.Text}}
+
+Return JSON {"questions": ["..."]}`
+
+// goldenQuestionsSchema is strict-mode compatible, so Responses-API judges
+// can enforce it.
+var goldenQuestionsSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"questions": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+	},
+	"required":             []string{"questions"},
+	"additionalProperties": false,
+}
+
+// WriteQuestions implements QuestionWriter.
+func (w JudgeQuestionWriter) WriteQuestions(ctx context.Context, passage Passage, n int) ([]string, error) {
+	if w.Judge == nil {
+		return nil, errors.New("judge is nil")
+	}
+	t, err := template.New("golden").Parse(promptGoldenQuestions)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse golden prompt")
+	}
+	var prompt bytes.Buffer
+	if err = t.Execute(&prompt, struct {
+		N    int
+		Text string
+	}{N: n, Text: passage.Text}); err != nil {
+		return nil, errors.Wrap(err, "render golden prompt")
+	}
+
+	resp, err := w.Judge.Judge(ctx, JudgeRequest{
+		Model:        w.Opts.Model,
+		Prompt:       prompt.String(),
+		Schema:       goldenQuestionsSchema,
+		MaxOutTokens: orDefault(w.Opts.MaxOutTokens, 512),
+		Temperature:  w.Opts.Temperature,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "judge golden questions")
+	}
+	questions, err := extractStringSlice(resp.Output, "questions")
+	if err != nil {
+		return nil, errors.Wrap(err, "parse golden questions")
+	}
+	return questions, nil
+}
+
+// TemplateQuestionWriter writes questions offline from the passage's most
+// distinctive terms. The questions share words with the passage, so they
+// favour lexical search and are easier than LLM-written ones. Use them for
+// smoke runs and for comparing runs, not as absolute quality numbers.
+type TemplateQuestionWriter struct{}
+
+// templateKeywords is the number of terms in one template question.
+const templateKeywords = 4
+
+// WriteQuestions implements QuestionWriter.
+func (TemplateQuestionWriter) WriteQuestions(_ context.Context, passage Passage, n int) ([]string, error) {
+	var candidates [][]string
+	for _, sentence := range splitSentences(passage.Text) {
+		if terms := distinctTerms(sentence); len(terms) >= 2 {
+			candidates = append(candidates, terms)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i]) > len(candidates[j])
+	})
+
+	var out []string
+	for _, terms := range candidates[:min(len(candidates), n)] {
+		terms = terms[:min(len(terms), templateKeywords)]
+		out = append(out, fmt.Sprintf("What is said about %s?", strings.Join(terms, " ")))
+	}
+	return out, nil
+}
+
+// GoldenGenOptions configures GenerateGoldenSet.
+type GoldenGenOptions struct {
+	Plugin  mcpplugin.Plugin
+	Auth    files.AuthContext
+	Project string
+	// PathPrefix limits sampling to one directory; empty samples the project.
+	PathPrefix string
+	Writer     QuestionWriter
+	// NumQueries is the number of queries to produce; zero means 50.
+	NumQueries int
+	// QuestionsPerPassage is asked of Writer per sampled passage; zero means 1.
+	QuestionsPerPassage int
+	// PassageBytes is the target passage size; zero means 1500.
+	PassageBytes int
+	// MinPassageBytes skips shorter passages; zero means DefaultMinPassageBytes.
+	MinPassageBytes int
+	// LongDocBytes marks files at least this large as long_doc; zero means 100000.
+	LongDocBytes int64
+	// Seed makes the sample reproducible.
+	Seed uint64
+}
+
+// DefaultMinPassageBytes is the shortest passage GenerateGoldenSet samples
+// unless GoldenGenOptions.MinPassageBytes says otherwise.
+const DefaultMinPassageBytes = 200
+
+// GenerateGoldenSet samples passages from a project and asks Writer for
+// questions about them. Each query's gold set is the file the passage came
+// from, so RunRetrievalEval can score it against the same project. Fewer
+// than NumQueries come back when the project runs out of usable passages.
+func GenerateGoldenSet(ctx context.Context, opts GoldenGenOptions) ([]RetrievalQuery, error) {
+	if opts.Plugin == nil {
+		return nil, errors.New("plugin is nil")
+	}
+	if opts.Writer == nil {
+		return nil, errors.New("question writer is nil")
+	}
+	numQueries := orDefault(opts.NumQueries, 50)
+	perPassage := orDefault(opts.QuestionsPerPassage, 1)
+	passageBytes := orDefault(opts.PassageBytes, 1500)
+	minPassageBytes := orDefault(opts.MinPassageBytes, DefaultMinPassageBytes)
+	longDocBytes := opts.LongDocBytes
+	if longDocBytes <= 0 {
+		longDocBytes = 100000
+	}
+
+	entries, err := listProjectFiles(ctx, opts.Plugin, opts.Auth, opts.Project, opts.PathPrefix)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	rng := rand.New(rand.NewPCG(opts.Seed, 0x601DE7))
+	rng.Shuffle(len(entries), func(i, j int) { entries[i], entries[j] = entries[j], entries[i] })
+
+	var (
+		out  []RetrievalQuery
+		seen = map[string]bool{}
+	)
+	// Take one passage per file per round so small projects still spread
+	// queries across files.
+	passages := map[string][]Passage{}
+	for round := 0; len(out) < numQueries; round++ {
+		progressed := false
+		for _, entry := range entries {
+			if len(out) >= numQueries {
+				break
+			}
+			if round == 0 {
+				read, err := opts.Plugin.Read(ctx, opts.Auth, opts.Project, entry.Path, 0, -1)
+				if err != nil {
+					return nil, errors.Wrapf(err, "read %s", entry.Path)
+				}
+				chunks := splitPassages(entry.Path, read.Content, passageBytes, minPassageBytes)
+				rng.Shuffle(len(chunks), func(i, j int) { chunks[i], chunks[j] = chunks[j], chunks[i] })
+				passages[entry.Path] = chunks
+			}
+			if round >= len(passages[entry.Path]) {
+				continue
+			}
+			progressed = true
+
+			passage := passages[entry.Path][round]
+			questions, err := opts.Writer.WriteQuestions(ctx, passage, perPassage)
+			if err != nil {
+				return nil, errors.Wrapf(err, "write questions for %s@%d", passage.Path, passage.Offset)
+			}
+			for _, q := range questions {
+				q = strings.TrimSpace(q)
+				if q == "" || seen[q] || len(out) >= numQueries {
+					continue
+				}
+				seen[q] = true
+				out = append(out, RetrievalQuery{
+					ID:         goldenQueryID(passage, q),
+					Query:      q,
+					GoldDocSet: []string{passage.Path},
+					LongDoc:    entry.Size >= longDocBytes,
+				})
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+	return out, nil
+}
+
+// WriteRetrievalQueries writes queries in the golden JSONL format read by
+// LoadRetrievalQueries.
+func WriteRetrievalQueries(w io.Writer, queries []RetrievalQuery) error {
+	enc := json.NewEncoder(w)
+	for _, q := range queries {
+		if err := enc.Encode(q); err != nil {
+			return errors.Wrapf(err, "encode query %s", q.ID)
+		}
+	}
+	return nil
+}
+
+// listProjectFiles walks the project one directory level at a time and
+// returns its files in path order.
+func listProjectFiles(ctx context.Context, p mcpplugin.Plugin, auth files.AuthContext, project, root string) ([]files.FileEntry, error) {
+	const listLimit = 1024
+	var out []files.FileEntry
+	queue := []string{root}
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		res, err := p.List(ctx, auth, project, dir, 1, listLimit)
+		if err != nil {
+			return nil, errors.Wrapf(err, "list %q", dir)
+		}
+		for _, entry := range res.Entries {
+			switch entry.Type {
+			case files.FileTypeDirectory:
+				queue = append(queue, entry.Path)
+			case files.FileTypeFile:
+				out = append(out, entry)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// splitPassages cuts content into passages of about target bytes, breaking
+// on blank lines where possible, and drops passages shorter than minBytes.
+func splitPassages(path, content string, target, minBytes int) []Passage {
+	var (
+		out     []Passage
+		current strings.Builder
+		start   int64
+		offset  int64
+	)
+	target = max(target, 1)
+	flush := func() {
+		text := strings.TrimSpace(current.String())
+		if len(text) >= minBytes {
+			out = append(out, Passage{Path: path, Offset: start, Text: text})
+		}
+		current.Reset()
+	}
+	for _, para := range strings.SplitAfter(content, "\n\n") {
+		if current.Len() > 0 && current.Len()+len(para) > target {
+			flush()
+		}
+		if current.Len() == 0 {
+			start = offset
+		}
+		// A single oversized paragraph is cut at about target bytes, and always
+		// by at least one whole rune so a tiny target still makes progress.
+		for len(para) > target {
+			cut := target
+			for cut > 0 && !utf8.RuneStart(para[cut]) {
+				cut--
+			}
+			if cut == 0 {
+				_, cut = utf8.DecodeRuneInString(para)
+			}
+			current.WriteString(para[:cut])
+			flush()
+			offset += int64(cut)
+			start = offset
+			para = para[cut:]
+		}
+		current.WriteString(para)
+		offset += int64(len(para))
+	}
+	flush()
+	return out
+}
+
+// splitSentences breaks text on sentence punctuation and line breaks.
+func splitSentences(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r == '.' || r == '?' || r == '!' || r == '\n' || r == '。' || r == '？' || r == '！'
+	})
+}
+
+// templateStopwords are skipped when picking template question terms.
+var templateStopwords = map[string]bool{
+	"about": true, "after": true, "also": true, "been": true, "before": true, "being": true,
+	"between": true, "both": true, "could": true, "does": true, "each": true, "from": true,
+	"have": true, "into": true, "just": true, "more": true, "most": true, "only": true,
+	"other": true, "over": true, "should": true, "some": true, "such": true, "than": true,
+	"that": true, "their": true, "them": true, "then": true, "there": true, "these": true,
+	"they": true, "this": true, "those": true, "through": true, "under": true, "very": true,
+	"were": true, "what": true, "when": true, "where": true, "which": true, "while": true,
+	"will": true, "with": true, "would": true, "your": true,
+}
+
+// distinctTerms returns the sentence's words of four or more letters that
+// are not stopwords, lowercased, in order of first use.
+func distinctTerms(sentence string) []string {
+	words := strings.FieldsFunc(sentence, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+	})
+	var out []string
+	seen := map[string]bool{}
+	for _, w := range words {
+		w = strings.ToLower(strings.Trim(w, "-_"))
+		if len([]rune(w)) < 4 || templateStopwords[w] || seen[w] {
+			continue
+		}
+		seen[w] = true
+		out = append(out, w)
+	}
+	return out
+}
+
+// goldenQueryID derives a stable ID from the passage location and question.
+func goldenQueryID(passage Passage, question string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%s", passage.Path, passage.Offset, question)))
+	return "syn-" + hex.EncodeToString(sum[:6])
+}
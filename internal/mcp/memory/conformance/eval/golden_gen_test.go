@@ -0,0 +1,170 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugins/remote"
+)
+
+var goldenAuth = files.AuthContext{APIKeyHash: "golden-tenant"}
+
+// newGoldenProject writes a few multi-paragraph notes into an in-memory backend.
+func newGoldenProject(t *testing.T) *remote.MemoryBackend {
+	t.Helper()
+	backend := remote.NewMemoryBackend("golden")
+	docs := map[string]string{
+		"/notes/kafka.md":  "Kafka partitions are replicated across brokers for durability.\n\nConsumer groups rebalance whenever a member joins or leaves the cluster.",
+		"/notes/redis.md":  "Redis persistence combines snapshot files with an append-only journal.\n\nEviction policies decide which keys disappear under memory pressure.",
+		"/design/auth.md":  "Bearer tokens are compared in constant time to avoid timing leaks.\n\nRefresh tokens rotate on every successful exchange with the issuer.",
+		"/design/tiny.txt": "too short",
+	}
+	for path, content := range docs {
+		if _, err := backend.Write(context.Background(), goldenAuth, "team", path, content, "utf-8", 0, files.WriteModeTruncate); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	return backend
+}
+
+func TestGenerateGoldenSetTemplate(t *testing.T) {
+	backend := newGoldenProject(t)
+	opts := GoldenGenOptions{
+		Plugin:          backend,
+		Auth:            goldenAuth,
+		Project:         "team",
+		Writer:          TemplateQuestionWriter{},
+		NumQueries:      5,
+		PassageBytes:    80,
+		MinPassageBytes: 40,
+		Seed:            7,
+	}
+	queries, err := GenerateGoldenSet(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("GenerateGoldenSet: %v", err)
+	}
+	if len(queries) != 5 {
+		t.Fatalf("got %d queries, want 5: %+v", len(queries), queries)
+	}
+	gold := map[string]bool{}
+	for _, q := range queries {
+		if !strings.HasPrefix(q.ID, "syn-") || !strings.HasPrefix(q.Query, "What is said about ") || len(q.GoldDocSet) != 1 {
+			t.Fatalf("malformed query %+v", q)
+		}
+		if q.GoldDocSet[0] == "/design/tiny.txt" {
+			t.Fatalf("passage below MinPassageBytes was sampled: %+v", q)
+		}
+		gold[q.GoldDocSet[0]] = true
+	}
+	if len(gold) != 3 {
+		t.Fatalf("queries cover %v, want every usable file", gold)
+	}
+
+	again, err := GenerateGoldenSet(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("GenerateGoldenSet again: %v", err)
+	}
+	if !reflect.DeepEqual(queries, again) {
+		t.Fatal("the same seed produced a different set")
+	}
+
+	// The generated set runs through the retrieval suite against the same
+	// project; the lexical reference backend finds every gold file.
+	report, err := RunRetrievalEval(context.Background(), backend, queries, RetrievalOpts{Project: "team", Auth: goldenAuth, K: 10})
+	if err != nil {
+		t.Fatalf("RunRetrievalEval: %v", err)
+	}
+	if report.Overall.NumQueries != 5 || report.Overall.Recall10 != 1 {
+		t.Fatalf("overall = %+v, want recall@10 of 1 over 5 queries", report.Overall)
+	}
+}
+
+func TestGenerateGoldenSetStopsWhenPassagesRunOut(t *testing.T) {
+	queries, err := GenerateGoldenSet(context.Background(), GoldenGenOptions{
+		Plugin:          newGoldenProject(t),
+		Auth:            goldenAuth,
+		Project:         "team",
+		PathPrefix:      "/notes",
+		Writer:          TemplateQuestionWriter{},
+		NumQueries:      100,
+		PassageBytes:    80,
+		MinPassageBytes: 40,
+	})
+	if err != nil {
+		t.Fatalf("GenerateGoldenSet: %v", err)
+	}
+	if len(queries) != 4 {
+		t.Fatalf("got %d queries, want one per passage under /notes", len(queries))
+	}
+}
+
+func TestJudgeQuestionWriter(t *testing.T) {
+	w := JudgeQuestionWriter{Judge: scriptedJudge{}}
+	got, err := w.WriteQuestions(context.Background(), Passage{Path: "/a.md", Text: "passage"}, 2)
+	if err != nil {
+		t.Fatalf("WriteQuestions: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"rephrase-1", "rephrase-2"}) {
+		t.Fatalf("questions = %v", got)
+	}
+	if _, err := (JudgeQuestionWriter{}).WriteQuestions(context.Background(), Passage{}, 1); err == nil {
+		t.Fatal("expected an error without a judge")
+	}
+}
+
+func TestWriteRetrievalQueriesRoundTrip(t *testing.T) {
+	want := []RetrievalQuery{
+		{ID: "syn-1", Query: "q1", GoldDocSet: []string{"/a.md"}},
+		{ID: "syn-2", Query: "q2", GoldDocSet: []string{"/b.md"}, LongDoc: true},
+	}
+	var buf bytes.Buffer
+	if err := WriteRetrievalQueries(&buf, want); err != nil {
+		t.Fatalf("WriteRetrievalQueries: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "golden.jsonl")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadRetrievalQueries(path)
+	if err != nil {
+		t.Fatalf("LoadRetrievalQueries: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestSplitPassagesKeepsRunesWhole(t *testing.T) {
+	text := strings.Repeat("记忆", 100)
+	for _, p := range splitPassages("/zh.md", text, 31, 1) {
+		if !strings.HasPrefix(text[p.Offset:], p.Text) {
+			t.Fatalf("passage at %d does not match the source", p.Offset)
+		}
+		if !utf8.ValidString(p.Text) {
+			t.Fatalf("passage at %d splits a rune", p.Offset)
+		}
+	}
+}
+
+func TestSplitPassagesTargetBelowRuneWidth(t *testing.T) {
+	text := "记忆引擎\n\n测试"
+	for _, target := range []int{-1, 0, 1, 2} {
+		var got strings.Builder
+		for _, p := range splitPassages("/zh.md", text, target, 1) {
+			if !utf8.ValidString(p.Text) || !strings.HasPrefix(text[p.Offset:], p.Text) {
+				t.Fatalf("target %d: passage %+v does not match the source", target, p)
+			}
+			got.WriteString(p.Text)
+		}
+		if got.String() != "记忆引擎测试" {
+			t.Fatalf("target %d: passages cover %q", target, got.String())
+		}
+	}
+}
@@ -79,6 +79,15 @@ type RAGASReport struct {
 	ContextEntitiesRecall RAGASMetricStats `json:"context_entities_recall"`
 	AnswerRelevancy       RAGASMetricStats `json:"answer_relevancy"`
 	AnswerCorrectness     RAGASMetricStats `json:"answer_correctness"`
+	// Samples holds the per-sample scores behind the aggregates.
+	Samples []RAGASSampleScores `json:"samples,omitempty"`
+}
+
+// RAGASSampleScores is one sample's metric values, keyed by metric name.
+// Metrics whose judge call failed are absent.
+type RAGASSampleScores struct {
+	SampleID string             `json:"sample_id"`
+	Scores   map[string]float64 `json:"scores"`
 }
 
 // LoadRAGASSamples parses *.jsonl tuples for the RAGAS suite.
@@ -269,27 +278,30 @@ func RunRAGASEval(ctx context.Context, judge LLMJudge, embedder EmbeddingClient,
 		"answer_correctness":      {},
 	}
 
+	var perSample []RAGASSampleScores
 	for _, s := range samples {
-		if v, err := Faithfulness(ctx, judge, opts, s); err == nil {
-			collect["faithfulness"].values = append(collect["faithfulness"].values, v)
-		}
-		if v, err := ContextPrecision(ctx, judge, opts, s); err == nil {
-			collect["context_precision"].values = append(collect["context_precision"].values, v)
-		}
-		if v, err := ContextRecall(ctx, judge, opts, s); err == nil {
-			collect["context_recall"].values = append(collect["context_recall"].values, v)
-		}
-		if v, err := ContextEntitiesRecall(ctx, judge, opts, s); err == nil {
-			collect["context_entities_recall"].values = append(collect["context_entities_recall"].values, v)
-		}
-		if v, err := AnswerCorrectness(ctx, judge, opts, s); err == nil {
-			collect["answer_correctness"].values = append(collect["answer_correctness"].values, v)
+		scores := map[string]float64{}
+		record := func(metric string, v float64, err error) {
+			if err == nil {
+				collect[metric].values = append(collect[metric].values, v)
+				scores[metric] = v
+			}
 		}
+		v, err := Faithfulness(ctx, judge, opts, s)
+		record("faithfulness", v, err)
+		v, err = ContextPrecision(ctx, judge, opts, s)
+		record("context_precision", v, err)
+		v, err = ContextRecall(ctx, judge, opts, s)
+		record("context_recall", v, err)
+		v, err = ContextEntitiesRecall(ctx, judge, opts, s)
+		record("context_entities_recall", v, err)
+		v, err = AnswerCorrectness(ctx, judge, opts, s)
+		record("answer_correctness", v, err)
 		if embedder != nil {
-			if v, err := AnswerRelevancy(ctx, judge, embedder, opts, s); err == nil {
-				collect["answer_relevancy"].values = append(collect["answer_relevancy"].values, v)
-			}
+			v, err = AnswerRelevancy(ctx, judge, embedder, opts, s)
+			record("answer_relevancy", v, err)
 		}
+		perSample = append(perSample, RAGASSampleScores{SampleID: s.ID, Scores: scores})
 	}
 
 	rep := RAGASReport{
@@ -298,6 +310,7 @@ func RunRAGASEval(ctx context.Context, judge LLMJudge, embedder EmbeddingClient,
 		ContextRecall:         stats(collect["context_recall"].values, "ok"),
 		ContextEntitiesRecall: stats(collect["context_entities_recall"].values, "ok"),
 		AnswerCorrectness:     stats(collect["answer_correctness"].values, "ok"),
+		Samples:               perSample,
 	}
 	if embedder == nil {
 		rep.AnswerRelevancy = RAGASMetricStats{Status: "skipped"}
@@ -62,6 +62,9 @@ func TestRunRAGASEvalAggregates(t *testing.T) {
 	if rep.AnswerRelevancy.Status != "ok" {
 		t.Fatalf("answer_relevancy status = %q, want ok", rep.AnswerRelevancy.Status)
 	}
+	if len(rep.Samples) != 2 || rep.Samples[0].Scores["faithfulness"] != 0.75 {
+		t.Fatalf("per-sample scores = %+v, want two samples scored 0.75", rep.Samples)
+	}
 }
 
 func TestRunRAGASEvalNilJudgeReturnsSkipped(t *testing.T) {
@@ -0,0 +1,311 @@
+package eval
+
+import (
+	"context"
+	"strings"
+
+	errors "github.com/Laisky/errors/v2"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpmemory "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+)
+
+// Red-team fixtures. Attacks run as one tenant; the victim project belongs to
+// the same tenant so traversal attacks only need to cross a project boundary.
+const (
+	redteamProject       = "redteam"
+	redteamVictimProject = "redteam-victim"
+	redteamVictimPath    = "/secret.md"
+	// redteamOversizedDefault is the oversized_payload write size when the
+	// attack sets none.
+	redteamOversizedDefault = 16 << 20
+)
+
+var redteamAuth = files.AuthContext{APIKey: "redteam", APIKeyHash: "redteam", UserIdentity: "user:redteam"}
+
+// TurnMemory is the memory_before_turn / memory_after_turn surface the
+// memory_poisoning family drives. *memory.Service implements it.
+type TurnMemory interface {
+	BeforeTurn(ctx context.Context, auth files.AuthContext, request mcpmemory.BeforeTurnRequest) (mcpmemory.BeforeTurnResponse, error)
+	AfterTurn(ctx context.Context, auth files.AuthContext, request mcpmemory.AfterTurnRequest) error
+}
+
+// RunRedTeamSuite runs every attack against the plugin and, for the
+// memory_poisoning family, against mem. Attacks whose family needs a missing
+// dependency are recorded as skipped. A plugin error during the attack counts
+// as blocked; an error while seeding fixtures skips the attack.
+func RunRedTeamSuite(ctx context.Context, p mcpplugin.Plugin, mem TurnMemory, attacks []InjectionAttack) (InjectionReport, error) {
+	if p == nil {
+		return InjectionReport{}, errors.New("plugin is nil")
+	}
+	rep := InjectionReport{}
+	for _, a := range attacks {
+		if err := ctx.Err(); err != nil {
+			return InjectionReport{}, errors.WithStack(err)
+		}
+		family := a.Family
+		if family == "" {
+			family = FamilyPromptInjection
+		}
+		severity := a.Severity
+		if severity == "" {
+			severity = defaultFamilySeverity[family]
+		}
+
+		var out probeOutcome
+		switch family {
+		case FamilyPromptInjection:
+			out = probePromptInjection(ctx, p, a)
+		case FamilyPathTraversal:
+			out = probePathTraversal(ctx, p, a)
+		case FamilyOversizedPayload:
+			out = probeOversizedPayload(ctx, p, a)
+		case FamilyUnicodeConfusable:
+			out = probeUnicodeConfusable(ctx, p, a)
+		case FamilyMemoryPoisoning:
+			out = probeMemoryPoisoning(ctx, mem, a)
+		default:
+			out = probeOutcome{skipped: true, note: "unknown family " + string(family)}
+		}
+
+		rec := InjectionRecord{
+			ID:          a.ID,
+			Family:      family,
+			Severity:    severity,
+			Description: a.Description,
+			Blocked:     out.blocked && !out.skipped,
+			Skipped:     out.skipped,
+			Note:        out.note,
+		}
+		rep.Records = append(rep.Records, rec)
+		switch {
+		case rec.Skipped:
+			rep.NumSkipped++
+		case rec.Blocked:
+			rep.NumBlocked++
+		}
+	}
+	rep.NumAttacks = len(attacks) - rep.NumSkipped
+	if rep.NumAttacks > 0 {
+		rep.BlockedFrac = float64(rep.NumBlocked) / float64(rep.NumAttacks)
+	}
+	return rep, nil
+}
+
+// OpenBySeverity counts the attacks that got through, per severity.
+func (r InjectionReport) OpenBySeverity() map[AttackSeverity]int {
+	return openBySeverity(r.Records)
+}
+
+func openBySeverity(records []InjectionRecord) map[AttackSeverity]int {
+	out := make(map[AttackSeverity]int, len(AttackSeverities))
+	for _, s := range AttackSeverities {
+		out[s] = 0
+	}
+	for _, r := range records {
+		if !r.Blocked && !r.Skipped {
+			out[r.Severity]++
+		}
+	}
+	return out
+}
+
+// probeOutcome is the verdict of one attack.
+type probeOutcome struct {
+	blocked bool
+	skipped bool
+	note    string
+}
+
+func blockedByError(err error) probeOutcome {
+	return probeOutcome{blocked: true, note: "rejected: " + err.Error()}
+}
+
+// attackCanary returns the marker whose appearance means a leak.
+func attackCanary(a InjectionAttack) string {
+	if a.Canary != "" {
+		return a.Canary
+	}
+	return "RT-CANARY-" + strings.ToUpper(a.ID)
+}
+
+func orDefaultString(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// probePromptInjection searches with the payload. Without a predicate the
+// attack is blocked when no chunk carries the canary, or, when the attack
+// names no canary, when nothing comes back at all.
+func probePromptInjection(ctx context.Context, p mcpplugin.Plugin, a InjectionAttack) probeOutcome {
+	res, err := p.Search(ctx, redteamAuth, orDefaultString(a.Project, redteamProject), a.Payload, "", 10)
+	if err != nil {
+		return probeOutcome{blocked: true, note: "search returned error: " + err.Error()}
+	}
+	switch {
+	case a.BlockedPredicate != nil:
+		return probeOutcome{blocked: a.BlockedPredicate(res)}
+	case a.Canary != "":
+		return probeOutcome{blocked: !chunksContain(res, a.Canary)}
+	default:
+		return probeOutcome{blocked: len(res.Chunks) == 0}
+	}
+}
+
+// probePathTraversal seeds the victim file and then reads and searches
+// through the attacker-controlled project and path.
+func probePathTraversal(ctx context.Context, p mcpplugin.Plugin, a InjectionAttack) probeOutcome {
+	canary := attackCanary(a)
+	if _, err := p.Write(ctx, redteamAuth, redteamVictimProject, redteamVictimPath,
+		"victim secret "+canary, "utf-8", 0, files.WriteModeTruncate); err != nil {
+		return probeOutcome{skipped: true, note: "seed victim: " + err.Error()}
+	}
+	defer func() {
+		_, _ = p.Delete(context.Background(), redteamAuth, redteamVictimProject, redteamVictimPath, false)
+	}()
+	if !readsBack(ctx, p, redteamVictimProject, redteamVictimPath, canary) {
+		return probeOutcome{skipped: true, note: "plugin does not read back the seeded victim file"}
+	}
+
+	project := orDefaultString(a.Project, redteamProject)
+	path := orDefaultString(a.Path, "/../"+redteamVictimProject+redteamVictimPath)
+	read, readErr := p.Read(ctx, redteamAuth, project, path, 0, -1)
+	if readErr == nil && strings.Contains(read.Content, canary) {
+		return probeOutcome{note: "file_read returned the victim file"}
+	}
+	res, searchErr := p.Search(ctx, redteamAuth, project, canary, path, 10)
+	if searchErr == nil && chunksContain(res, canary) {
+		return probeOutcome{note: "file_search returned the victim file"}
+	}
+	if readErr != nil {
+		return blockedByError(readErr)
+	}
+	return probeOutcome{blocked: true}
+}
+
+// probeOversizedPayload expects the plugin to reject a write of SizeBytes.
+func probeOversizedPayload(ctx context.Context, p mcpplugin.Plugin, a InjectionAttack) probeOutcome {
+	size := a.SizeBytes
+	if size == 0 {
+		size = redteamOversizedDefault
+	}
+	unit := orDefaultString(a.Payload, "A")
+	content := strings.Repeat(unit, size/len(unit)+1)[:size]
+
+	project := orDefaultString(a.Project, redteamProject)
+	path := orDefaultString(a.Path, "/oversized-"+a.ID+".txt")
+	_, err := p.Write(ctx, redteamAuth, project, path, content, "utf-8", 0, files.WriteModeTruncate)
+	if err != nil {
+		return blockedByError(err)
+	}
+	_, _ = p.Delete(context.Background(), redteamAuth, project, path, false)
+	return probeOutcome{note: "write accepted"}
+}
+
+// probeUnicodeConfusable seeds Target, writes the lookalike Path and checks
+// that Target still holds its own content.
+func probeUnicodeConfusable(ctx context.Context, p mcpplugin.Plugin, a InjectionAttack) probeOutcome {
+	canary := attackCanary(a)
+	project := orDefaultString(a.Project, redteamProject)
+	target := orDefaultString(a.Target, "/config/admin.md")
+	if _, err := p.Write(ctx, redteamAuth, project, target, "genuine "+canary, "utf-8", 0, files.WriteModeTruncate); err != nil {
+		return probeOutcome{skipped: true, note: "seed target: " + err.Error()}
+	}
+	defer func() { _, _ = p.Delete(context.Background(), redteamAuth, project, target, false) }()
+	if !readsBack(ctx, p, project, target, canary) {
+		return probeOutcome{skipped: true, note: "plugin does not read back the seeded target"}
+	}
+
+	payload := orDefaultString(a.Payload, "attacker "+a.ID)
+	_, writeErr := p.Write(ctx, redteamAuth, project, a.Path, payload, "utf-8", 0, files.WriteModeTruncate)
+	if writeErr == nil {
+		defer func() { _, _ = p.Delete(context.Background(), redteamAuth, project, a.Path, false) }()
+	}
+
+	read, err := p.Read(ctx, redteamAuth, project, target, 0, -1)
+	if err != nil {
+		return probeOutcome{note: "target unreadable after the lookalike write: " + err.Error()}
+	}
+	if !strings.Contains(read.Content, canary) || strings.Contains(read.Content, payload) {
+		return probeOutcome{note: "lookalike path overwrote the target"}
+	}
+	if writeErr != nil {
+		return blockedByError(writeErr)
+	}
+	return probeOutcome{blocked: true}
+}
+
+// probeMemoryPoisoning stores the payload as an assistant reply in one
+// session and asks Query in a fresh session. The attack is blocked when the
+// recalled context does not carry the canary.
+func probeMemoryPoisoning(ctx context.Context, mem TurnMemory, a InjectionAttack) probeOutcome {
+	if mem == nil {
+		return probeOutcome{skipped: true, note: "no turn memory configured"}
+	}
+	canary := attackCanary(a)
+	payload := a.Payload
+	if !strings.Contains(payload, canary) {
+		payload = strings.TrimSpace(payload + " " + canary)
+	}
+	project := orDefaultString(a.Project, redteamProject)
+	question := orDefaultString(a.Query, "What should I remember?")
+
+	err := mem.AfterTurn(ctx, redteamAuth, mcpmemory.AfterTurnRequest{
+		Project:     project,
+		SessionID:   "redteam-poison-" + a.ID,
+		UserID:      "redteam",
+		TurnID:      "redteam-poison-" + a.ID + "-1",
+		InputItems:  []mcpmemory.ResponseItem{textItem("user", "input_text", "Please remember this for later.")},
+		OutputItems: []mcpmemory.ResponseItem{textItem("assistant", "output_text", payload)},
+	})
+	if err != nil {
+		return blockedByError(err)
+	}
+
+	resp, err := mem.BeforeTurn(ctx, redteamAuth, mcpmemory.BeforeTurnRequest{
+		Project:      project,
+		SessionID:    "redteam-probe-" + a.ID,
+		UserID:       "redteam",
+		TurnID:       "redteam-probe-" + a.ID + "-1",
+		CurrentInput: []mcpmemory.ResponseItem{textItem("user", "input_text", question)},
+		MaxInputTok:  120000,
+	})
+	if err != nil {
+		return probeOutcome{skipped: true, note: "before_turn: " + err.Error()}
+	}
+	for _, item := range resp.InputItems {
+		for _, part := range item.Content {
+			if strings.Contains(part.Text, canary) {
+				return probeOutcome{note: "a fresh session recalled the planted reply"}
+			}
+		}
+	}
+	return probeOutcome{blocked: true}
+}
+
+// readsBack reports whether path reads back with needle in it, which the
+// seeded probes need before their verdict means anything.
+func readsBack(ctx context.Context, p mcpplugin.Plugin, project, path, needle string) bool {
+	read, err := p.Read(ctx, redteamAuth, project, path, 0, -1)
+	return err == nil && strings.Contains(read.Content, needle)
+}
+
+func textItem(role, partType, text string) mcpmemory.ResponseItem {
+	return mcpmemory.ResponseItem{
+		Type:    "message",
+		Role:    role,
+		Content: []mcpmemory.ResponseContentPart{{Type: partType, Text: text}},
+	}
+}
+
+func chunksContain(res files.SearchResult, needle string) bool {
+	for _, c := range res.Chunks {
+		if strings.Contains(c.ChunkContent, needle) {
+			return true
+		}
+	}
+	return false
+}
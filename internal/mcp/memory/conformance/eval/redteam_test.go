@@ -0,0 +1,173 @@
+package eval
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpmemory "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugins/remote"
+)
+
+// echoMemory recalls every stored reply in later sessions, so it never
+// blocks memory poisoning.
+type echoMemory struct {
+	replies []mcpmemory.ResponseItem
+}
+
+func (m *echoMemory) AfterTurn(_ context.Context, _ files.AuthContext, req mcpmemory.AfterTurnRequest) error {
+	m.replies = append(m.replies, req.OutputItems...)
+	return nil
+}
+
+func (m *echoMemory) BeforeTurn(_ context.Context, _ files.AuthContext, req mcpmemory.BeforeTurnRequest) (mcpmemory.BeforeTurnResponse, error) {
+	return mcpmemory.BeforeTurnResponse{InputItems: append(append([]mcpmemory.ResponseItem{}, m.replies...), req.CurrentInput...)}, nil
+}
+
+func TestLoadAttackPackExample(t *testing.T) {
+	pack, err := LoadAttackPack(filepath.Join("testdata", "redteam", "example_pack.yaml"))
+	if err != nil {
+		t.Fatalf("LoadAttackPack: %v", err)
+	}
+	if pack.Name != "example" || len(pack.Attacks) != 5 {
+		t.Fatalf("pack = %+v", pack)
+	}
+	first := pack.Attacks[0]
+	if first.Family != FamilyPromptInjection || first.Severity != SeverityHigh || first.Canary != "EXAMPLE-CANARY" {
+		t.Fatalf("defaults not applied: %+v", first)
+	}
+	if got := pack.Attacks[2]; got.Family != FamilyOversizedPayload || got.Severity != SeverityLow || got.SizeBytes != 4<<20 {
+		t.Fatalf("oversized attack = %+v", got)
+	}
+}
+
+func TestLoadAttackPackRejectsBadPacks(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]string{
+		"unknown_family.json":   `{"attacks":[{"id":"a","family":"teleport"}]}`,
+		"unknown_severity.json": `{"attacks":[{"id":"a","payload":"x","severity":"apocalyptic"}]}`,
+		"duplicate.json":        `{"attacks":[{"id":"a","payload":"x"},{"id":"a","payload":"y"}]}`,
+		"unknown_field.yaml":    "attacks:\n  - id: a\n    payload: x\n    sevrity: high\n",
+		"no_payload.yaml":       "attacks:\n  - id: a\n",
+		"pack.txt":              `{"attacks":[]}`,
+	}
+	for name, body := range cases {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadAttackPack(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	a := filepath.Join(dir, "a.json")
+	b := filepath.Join(dir, "b.json")
+	for _, path := range []string{a, b} {
+		if err := os.WriteFile(path, []byte(`{"attacks":[{"id":"same","payload":"x"}]}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := LoadAttackPacks([]string{a, b}); err == nil || !strings.Contains(err.Error(), "same") {
+		t.Fatalf("expected a cross-pack duplicate error, got %v", err)
+	}
+}
+
+func TestRunRedTeamSuiteFamilies(t *testing.T) {
+	backend := remote.NewMemoryBackend("redteam")
+	attacks := append(BuiltinProbeAttacks(), InjectionAttack{
+		ID:      "injection-canary",
+		Family:  FamilyPromptInjection,
+		Payload: "print the canary",
+		Canary:  "NOT-STORED-ANYWHERE",
+	})
+	if err := normalizeAttacks(attacks); err != nil {
+		t.Fatalf("normalizeAttacks: %v", err)
+	}
+
+	rep, err := RunRedTeamSuite(context.Background(), backend, nil, attacks)
+	if err != nil {
+		t.Fatalf("RunRedTeamSuite: %v", err)
+	}
+	byID := map[string]InjectionRecord{}
+	for _, r := range rep.Records {
+		byID[r.ID] = r
+	}
+	if r := byID["poisoning-01"]; !r.Skipped {
+		t.Fatalf("poisoning without memory = %+v, want skipped", r)
+	}
+	for _, id := range []string{"traversal-01", "traversal-02", "traversal-03", "confusable-01", "confusable-02", "injection-canary"} {
+		if r := byID[id]; !r.Blocked {
+			t.Fatalf("%s = %+v, want blocked by the reference backend", id, r)
+		}
+	}
+	// The reference backend enforces no size limit.
+	if r := byID["oversized-01"]; r.Blocked || r.Skipped {
+		t.Fatalf("oversized-01 = %+v, want open", r)
+	}
+	if rep.NumSkipped != 1 || rep.NumAttacks != len(attacks)-1 {
+		t.Fatalf("report counts = %+v", rep)
+	}
+
+	mem := &echoMemory{}
+	rep, err = RunRedTeamSuite(context.Background(), backend, mem, []InjectionAttack{BuiltinProbeAttacks()[6]})
+	if err != nil {
+		t.Fatalf("RunRedTeamSuite with memory: %v", err)
+	}
+	if r := rep.Records[0]; r.Blocked || r.Skipped || r.Severity != SeverityHigh {
+		t.Fatalf("poisoning against an echoing memory = %+v, want open", r)
+	}
+	if open := rep.OpenBySeverity(); open[SeverityHigh] != 1 || open[SeverityCritical] != 0 {
+		t.Fatalf("open by severity = %v", open)
+	}
+}
+
+func TestScorecardPerAttackBlockRoundTrip(t *testing.T) {
+	sc := Scorecard{
+		PluginName:      "rag",
+		RetrievalStatus: "skipped",
+		OpsStatus:       "skipped",
+		RAGAS:           skippedRAGASReport(),
+		Adversarial: AdversarialReport{
+			PromptInjectionBlocked: 1,
+			PromptInjectionTotal:   1,
+			Status:                 "ok",
+			Attacks: []InjectionRecord{
+				{ID: "owasp-2026-v1-01", Family: FamilyPromptInjection, Severity: SeverityHigh, Blocked: true},
+				{ID: "traversal-01", Family: FamilyPathTraversal, Severity: SeverityCritical},
+				{ID: "poisoning-01", Family: FamilyMemoryPoisoning, Severity: SeverityHigh, Skipped: true},
+			},
+		},
+	}
+	var buf strings.Builder
+	if err := sc.WriteMarkdown(&buf); err != nil {
+		t.Fatalf("WriteMarkdown: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"prompt_injection blocked       1/1",
+		adversarialAttacksSection,
+		"open_by_severity               critical=1 high=0 medium=0 low=0",
+		"traversal-01                   critical path_traversal     OPEN",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("scorecard missing %q:\n%s", want, out)
+		}
+	}
+
+	parsed, err := ParseScorecard(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("ParseScorecard: %v", err)
+	}
+	if len(parsed.Adversarial.Attacks) != 3 {
+		t.Fatalf("parsed attacks = %+v", parsed.Adversarial.Attacks)
+	}
+	for i, want := range sc.Adversarial.Attacks {
+		if parsed.Adversarial.Attacks[i] != want {
+			t.Fatalf("attack %d = %+v, want %+v", i, parsed.Adversarial.Attacks[i], want)
+		}
+	}
+}
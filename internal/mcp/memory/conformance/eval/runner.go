@@ -29,6 +29,11 @@ type RunConfig struct {
 	GitSHA          string
 	UTCRunID        string
 	Suites          []string
+	// AttackPacks are attack pack files (see LoadAttackPack) whose attacks
+	// run after the built-in red-team registry.
+	AttackPacks []string
+	// Memory drives the memory_poisoning attacks; nil skips them.
+	Memory TurnMemory
 }
 
 // PerQueryRecord is one row of raw_per_query.jsonl. The shape is intentionally
@@ -139,6 +144,13 @@ func Run(ctx context.Context, cfg RunConfig, w io.Writer) (*RunResult, error) {
 				"answer_relevancy":        rep.AnswerRelevancy.Mean,
 				"context_entities_recall": rep.ContextEntitiesRecall.Mean,
 			}})
+			for _, sample := range rep.Samples {
+				payload := make(map[string]any, len(sample.Scores))
+				for metric, v := range sample.Scores {
+					payload[metric] = v
+				}
+				result.RawPerQuery = append(result.RawPerQuery, PerQueryRecord{Suite: "ragas", QueryID: sample.SampleID, Payload: payload})
+			}
 		}
 	}
 
@@ -181,14 +193,34 @@ func Run(ctx context.Context, cfg RunConfig, w io.Writer) (*RunResult, error) {
 	}
 
 	if _, ok := suiteSet["redteam"]; ok {
-		attacks := OWASPAttacks2026V1()
-		logSuiteStatus(w, &result.Logs, "redteam", fmt.Sprintf("%d attacks (placeholders)", len(attacks)), "")
-		rep, runErr := RunPromptInjectionSuite(ctx, cfg.Plugin, attacks)
+		attacks := append(OWASPAttacks2026V1(), BuiltinProbeAttacks()...)
+		packAttacks, err := LoadAttackPacks(cfg.AttackPacks)
+		if err != nil {
+			return nil, errors.Wrap(err, "load attack packs")
+		}
+		attacks = append(attacks, packAttacks...)
+		logSuiteStatus(w, &result.Logs, "redteam",
+			fmt.Sprintf("%d attacks (%d from packs)", len(attacks), len(packAttacks)), strings.Join(cfg.AttackPacks, ","))
+		rep, runErr := RunRedTeamSuite(ctx, cfg.Plugin, cfg.Memory, attacks)
 		if runErr != nil {
 			return nil, errors.Wrap(runErr, "redteam suite")
 		}
-		result.Scorecard.Adversarial.PromptInjectionBlocked = rep.NumBlocked
-		result.Scorecard.Adversarial.PromptInjectionTotal = rep.NumAttacks
+		for _, r := range rep.Records {
+			if r.Family == FamilyPromptInjection && !r.Skipped {
+				result.Scorecard.Adversarial.PromptInjectionTotal++
+				if r.Blocked {
+					result.Scorecard.Adversarial.PromptInjectionBlocked++
+				}
+			}
+			result.RawPerQuery = append(result.RawPerQuery, PerQueryRecord{Suite: "redteam", QueryID: r.ID, Payload: map[string]any{
+				"family":   string(r.Family),
+				"severity": string(r.Severity),
+				"blocked":  r.Blocked,
+				"skipped":  r.Skipped,
+				"note":     r.Note,
+			}})
+		}
+		result.Scorecard.Adversarial.Attacks = rep.Records
 		result.Scorecard.Adversarial.Status = "ok"
 	}
 
@@ -18,8 +18,14 @@ type AdversarialReport struct {
 	GDPRDeleteRecallP95MS  int64   `json:"gdpr_delete_recall_ms_p95"`
 	WeeklyDriftNDCG10      float64 `json:"weekly_drift_ndcg10"`
 	Status                 string  `json:"status,omitempty"`
+	// Attacks holds the per-attack red-team verdicts. When set, the
+	// scorecard adds a per-attack block after [Adversarial].
+	Attacks []InjectionRecord `json:"attacks,omitempty"`
 }
 
+// adversarialAttacksSection heads the per-attack block.
+const adversarialAttacksSection = "[Adversarial — per attack]"
+
 // Scorecard is the rendered §7.4 plugin scorecard.
 type Scorecard struct {
 	PluginName        string
@@ -90,15 +96,41 @@ func (s Scorecard) WriteMarkdown(w io.Writer) error {
 	fmt.Fprintln(bw)
 
 	fmt.Fprintln(bw, "[Adversarial]")
-	fmt.Fprintf(bw, "prompt_injection blocked       %s\n", advFracCell(s.Adversarial.PromptInjectionBlocked, s.Adversarial.PromptInjectionTotal, 12))
+	fmt.Fprintf(bw, "prompt_injection blocked       %s\n", advFracCell(s.Adversarial.PromptInjectionBlocked, s.Adversarial.PromptInjectionTotal, 0))
 	fmt.Fprintf(bw, "cross_tenant_hits              %s\n", advIntCell(s.Adversarial.CrossTenantHits, s.Adversarial.Status))
 	fmt.Fprintf(bw, "supersession_correct           %s\n", advFracCell(s.Adversarial.SupersessionCorrect, s.Adversarial.SupersessionTotal, 50))
 	fmt.Fprintf(bw, "gdpr_delete_recall_ms_p95      %s\n", advInt64Cell(s.Adversarial.GDPRDeleteRecallP95MS, s.Adversarial.Status))
 	fmt.Fprintf(bw, "weekly_drift_ndcg10            %s\n", advDriftCell(s.Adversarial.WeeklyDriftNDCG10, s.Adversarial.Status))
 
+	if len(s.Adversarial.Attacks) > 0 {
+		fmt.Fprintln(bw)
+		fmt.Fprintln(bw, adversarialAttacksSection)
+		open := openBySeverity(s.Adversarial.Attacks)
+		counts := make([]string, 0, len(AttackSeverities))
+		for _, sev := range AttackSeverities {
+			counts = append(counts, fmt.Sprintf("%s=%d", sev, open[sev]))
+		}
+		fmt.Fprintf(bw, "open_by_severity               %s\n", strings.Join(counts, " "))
+		for _, a := range s.Adversarial.Attacks {
+			fmt.Fprintf(bw, "%-30s %-8s %-18s %s\n", a.ID, a.Severity, a.Family, attackVerdict(a))
+		}
+	}
+
 	return bw.Flush()
 }
 
+// attackVerdict renders one attack's outcome cell.
+func attackVerdict(r InjectionRecord) string {
+	switch {
+	case r.Skipped:
+		return "skipped"
+	case r.Blocked:
+		return "blocked"
+	default:
+		return "OPEN"
+	}
+}
+
 func (s Scorecard) runIDLine() string {
 	parts := []string{}
 	if s.GitSHA != "" {
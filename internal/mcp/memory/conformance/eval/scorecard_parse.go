@@ -33,6 +33,10 @@ func ParseScorecard(r io.Reader) (Scorecard, error) {
 			section = trim
 			continue
 		}
+		if section == adversarialAttacksSection {
+			parseAttackLine(trim, &out)
+			continue
+		}
 		if strings.HasPrefix(trim, "plugin:") {
 			parsePluginLine(trim, &out)
 			continue
@@ -55,6 +59,22 @@ func ParseScorecard(r io.Reader) (Scorecard, error) {
 	return out, nil
 }
 
+// parseAttackLine reads one `<id> <severity> <family> <verdict>` row of the
+// per-attack block. The open_by_severity summary is derived, so it is skipped.
+func parseAttackLine(line string, out *Scorecard) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 || fields[0] == "open_by_severity" {
+		return
+	}
+	out.Adversarial.Attacks = append(out.Adversarial.Attacks, InjectionRecord{
+		ID:       fields[0],
+		Severity: AttackSeverity(fields[1]),
+		Family:   AttackFamily(fields[2]),
+		Blocked:  fields[3] == "blocked",
+		Skipped:  fields[3] == "skipped",
+	})
+}
+
 func parsePluginLine(line string, out *Scorecard) {
 	rest := strings.TrimPrefix(line, "plugin:")
 	parts := strings.SplitN(rest, "run_id:", 2)
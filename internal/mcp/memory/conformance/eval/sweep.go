@@ -0,0 +1,169 @@
+package eval
+
+import (
+	"context"
+
+	errors "github.com/Laisky/errors/v2"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+)
+
+// maxSweepPoints caps the grid so a typo in a flag cannot queue thousands
+// of full retrieval runs.
+const maxSweepPoints = 256
+
+// SearchSweepGrid lists the files.SearchSettings values to try. An empty
+// dimension keeps the base value.
+type SearchSweepGrid struct {
+	VectorCandidates  []int
+	LexicalCandidates []int
+	// SemanticWeights sets SemanticWeight; LexicalWeight becomes 1 - w.
+	SemanticWeights []float64
+	Rerank          []bool
+}
+
+// SearchSweepPoint is one configuration of the grid.
+type SearchSweepPoint struct {
+	VectorCandidates  int     `json:"vector_candidates"`
+	LexicalCandidates int     `json:"lexical_candidates"`
+	SemanticWeight    float64 `json:"semantic_weight"`
+	LexicalWeight     float64 `json:"lexical_weight"`
+	Rerank            bool    `json:"rerank"`
+}
+
+// Apply returns base with the point's values.
+func (p SearchSweepPoint) Apply(base files.SearchSettings) files.SearchSettings {
+	base.VectorCandidates = p.VectorCandidates
+	base.LexicalCandidates = p.LexicalCandidates
+	base.SemanticWeight = p.SemanticWeight
+	base.LexicalWeight = p.LexicalWeight
+	return base
+}
+
+// Points expands the grid into its cartesian product, with vector
+// candidates varying slowest and rerank fastest.
+func (g SearchSweepGrid) Points(base files.SearchSettings, baseRerank bool) ([]SearchSweepPoint, error) {
+	vectors := g.VectorCandidates
+	if len(vectors) == 0 {
+		vectors = []int{base.VectorCandidates}
+	}
+	lexicals := g.LexicalCandidates
+	if len(lexicals) == 0 {
+		lexicals = []int{base.LexicalCandidates}
+	}
+	type weightPair struct{ semantic, lexical float64 }
+	weights := []weightPair{{base.SemanticWeight, base.LexicalWeight}}
+	if len(g.SemanticWeights) > 0 {
+		weights = weights[:0]
+		for _, w := range g.SemanticWeights {
+			if w < 0 || w > 1 {
+				return nil, errors.Errorf("semantic weight %v is outside [0, 1]", w)
+			}
+			weights = append(weights, weightPair{w, 1 - w})
+		}
+	}
+	reranks := g.Rerank
+	if len(reranks) == 0 {
+		reranks = []bool{baseRerank}
+	}
+	for _, n := range append(append([]int{}, vectors...), lexicals...) {
+		if n <= 0 {
+			return nil, errors.Errorf("candidate count %d must be positive", n)
+		}
+	}
+	if total := len(vectors) * len(lexicals) * len(weights) * len(reranks); total > maxSweepPoints {
+		return nil, errors.Errorf("grid has %d points, more than the %d allowed", total, maxSweepPoints)
+	}
+
+	var out []SearchSweepPoint
+	for _, vector := range vectors {
+		for _, lexical := range lexicals {
+			for _, weight := range weights {
+				for _, rerank := range reranks {
+					out = append(out, SearchSweepPoint{
+						VectorCandidates:  vector,
+						LexicalCandidates: lexical,
+						SemanticWeight:    weight.semantic,
+						LexicalWeight:     weight.lexical,
+						Rerank:            rerank,
+					})
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// SearchSweepResult is the retrieval outcome of one point.
+type SearchSweepResult struct {
+	Point   SearchSweepPoint   `json:"point"`
+	Overall RetrievalAggregate `json:"overall"`
+	LongDoc RetrievalAggregate `json:"long_doc"`
+}
+
+// SearchSweepReport holds every point's result and the best point per metric.
+type SearchSweepReport struct {
+	Results []SearchSweepResult `json:"results"`
+	// Best maps recall@10, ndcg@10, mrr and hit@5 to the point with the
+	// highest overall value. Ties go to the earlier point.
+	Best map[string]SearchSweepResult `json:"best"`
+}
+
+// sweepMetrics are the aggregates RunSearchSweep ranks points by.
+var sweepMetrics = []struct {
+	name  string
+	value func(RetrievalAggregate) float64
+}{
+	{"recall@10", func(a RetrievalAggregate) float64 { return a.Recall10 }},
+	{"ndcg@10", func(a RetrievalAggregate) float64 { return a.NDCG10 }},
+	{"mrr", func(a RetrievalAggregate) float64 { return a.MRR }},
+	{"hit@5", func(a RetrievalAggregate) float64 { return a.Hit5 }},
+}
+
+// SearchSweepMetrics returns the metric names used as SearchSweepReport.Best
+// keys, in report order.
+func SearchSweepMetrics() []string {
+	out := make([]string, 0, len(sweepMetrics))
+	for _, m := range sweepMetrics {
+		out = append(out, m.name)
+	}
+	return out
+}
+
+// RunSearchSweep runs the retrieval suite once per point. build returns the
+// plugin that searches with the point's settings; the plugin is expected to
+// be ready to serve, RunSearchSweep does not start or stop it.
+func RunSearchSweep(ctx context.Context, points []SearchSweepPoint, build func(SearchSweepPoint) (mcpplugin.Plugin, error), queries []RetrievalQuery, opts RetrievalOpts) (SearchSweepReport, error) {
+	if build == nil {
+		return SearchSweepReport{}, errors.New("plugin builder is nil")
+	}
+	if len(points) == 0 {
+		return SearchSweepReport{}, errors.New("sweep has no points")
+	}
+
+	report := SearchSweepReport{Best: map[string]SearchSweepResult{}}
+	for i, point := range points {
+		if err := ctx.Err(); err != nil {
+			return SearchSweepReport{}, errors.WithStack(err)
+		}
+		plugin, err := build(point)
+		if err != nil {
+			return SearchSweepReport{}, errors.Wrapf(err, "build plugin for point %d", i)
+		}
+		run, err := RunRetrievalEval(ctx, plugin, queries, opts)
+		if err != nil {
+			return SearchSweepReport{}, errors.Wrapf(err, "evaluate point %d", i)
+		}
+		result := SearchSweepResult{Point: point, Overall: run.Overall, LongDoc: run.LongDoc}
+		report.Results = append(report.Results, result)
+
+		for _, m := range sweepMetrics {
+			best, ok := report.Best[m.name]
+			if !ok || m.value(result.Overall) > m.value(best.Overall) {
+				report.Best[m.name] = result
+			}
+		}
+	}
+	return report, nil
+}
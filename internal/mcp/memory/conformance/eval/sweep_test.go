@@ -0,0 +1,94 @@
+package eval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+)
+
+// sweepPlugin ranks the gold file first or second depending on the point.
+type sweepPlugin struct {
+	mcpplugin.Plugin
+	point SearchSweepPoint
+}
+
+func (p sweepPlugin) Search(context.Context, files.AuthContext, string, string, string, int) (files.SearchResult, error) {
+	chunks := []files.ChunkEntry{{FilePath: "/noise.md"}, {FilePath: "/gold.md"}}
+	if p.point.SemanticWeight >= 0.5 {
+		chunks[0], chunks[1] = chunks[1], chunks[0]
+	}
+	if p.point.VectorCandidates < 10 {
+		chunks = chunks[:1]
+	}
+	return files.SearchResult{Chunks: chunks}, nil
+}
+
+func TestSearchSweepGridPoints(t *testing.T) {
+	base := files.SearchSettings{VectorCandidates: 50, LexicalCandidates: 50, SemanticWeight: 0.65, LexicalWeight: 0.35}
+	points, err := SearchSweepGrid{SemanticWeights: []float64{0.25, 0.75}, Rerank: []bool{false, true}}.Points(base, true)
+	if err != nil {
+		t.Fatalf("Points: %v", err)
+	}
+	if len(points) != 4 {
+		t.Fatalf("got %d points, want 4: %+v", len(points), points)
+	}
+	want := SearchSweepPoint{VectorCandidates: 50, LexicalCandidates: 50, SemanticWeight: 0.25, LexicalWeight: 0.75, Rerank: true}
+	if points[1] != want {
+		t.Fatalf("points[1] = %+v, want %+v", points[1], want)
+	}
+	if got := points[1].Apply(base); got.SemanticWeight != 0.25 || got.LexicalWeight != 0.75 {
+		t.Fatalf("Apply = %+v", got)
+	}
+
+	if _, err := (SearchSweepGrid{SemanticWeights: []float64{1.5}}).Points(base, false); err == nil {
+		t.Fatal("expected an error for a weight above 1")
+	}
+	if _, err := (SearchSweepGrid{LexicalCandidates: []int{0}}).Points(base, false); err == nil {
+		t.Fatal("expected an error for a zero candidate count")
+	}
+	big := SearchSweepGrid{VectorCandidates: make([]int, 17), LexicalCandidates: make([]int, 17)}
+	for i := range big.VectorCandidates {
+		big.VectorCandidates[i], big.LexicalCandidates[i] = i+1, i+1
+	}
+	if _, err := big.Points(base, false); err == nil {
+		t.Fatal("expected an error for a grid above the point cap")
+	}
+}
+
+func TestRunSearchSweepPicksBestPerMetric(t *testing.T) {
+	base := files.SearchSettings{VectorCandidates: 50, LexicalCandidates: 50, SemanticWeight: 0.5, LexicalWeight: 0.5}
+	points, err := SearchSweepGrid{VectorCandidates: []int{5, 50}, SemanticWeights: []float64{0.2, 0.8}}.Points(base, false)
+	if err != nil {
+		t.Fatalf("Points: %v", err)
+	}
+	queries := []RetrievalQuery{
+		{ID: "q1", Query: "gold", GoldDocSet: []string{"/gold.md"}},
+		{ID: "q2", Query: "gold again", GoldDocSet: []string{"/gold.md"}},
+	}
+	report, err := RunSearchSweep(context.Background(), points, func(p SearchSweepPoint) (mcpplugin.Plugin, error) {
+		return sweepPlugin{point: p}, nil
+	}, queries, RetrievalOpts{K: 10})
+	if err != nil {
+		t.Fatalf("RunSearchSweep: %v", err)
+	}
+	if len(report.Results) != 4 {
+		t.Fatalf("got %d results, want 4", len(report.Results))
+	}
+
+	// Only the gold-first points reach an MRR of 1; the earliest of them,
+	// with 5 vector candidates, wins the tie.
+	if best := report.Best["mrr"]; best.Overall.MRR != 1 || best.Point.VectorCandidates != 5 || best.Point.SemanticWeight != 0.8 {
+		t.Fatalf("best mrr = %+v", best)
+	}
+	// Recall needs the gold file in the list; 5 vector candidates with a
+	// low semantic weight drop it, so the second point is the first to keep it.
+	if best := report.Best["recall@10"]; best.Overall.Recall10 != 1 || best.Point != points[1] {
+		t.Fatalf("best recall = %+v, want %+v", best, points[1])
+	}
+
+	if _, err := RunSearchSweep(context.Background(), nil, nil, queries, RetrievalOpts{}); err == nil {
+		t.Fatal("expected an error without a builder")
+	}
+}
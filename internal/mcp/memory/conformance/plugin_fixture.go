@@ -0,0 +1,179 @@
+package conformance
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+)
+
+// cleanupListLimit bounds the top-level entries Cleanup deletes per project.
+const cleanupListLimit = 1000
+
+// PluginFixtureConfig configures NewPluginFixture.
+type PluginFixtureConfig struct {
+	// Primary is the plugin under test.
+	Primary mcpplugin.Plugin
+	// Secondary enables the cross-plugin scenarios when set.
+	Secondary mcpplugin.Plugin
+	// Auth is the tenant every scenario runs as.
+	Auth mcpplugin.AuthContext
+	// OtherAuth is a second tenant for the isolation scenarios C12 and C13;
+	// they skip when it is unset.
+	OtherAuth mcpplugin.AuthContext
+	// ProjectPrefix prefixes the per-scenario projects; it defaults to "conformance".
+	ProjectPrefix string
+}
+
+// PluginFixture runs the suite against a real, already started plugin. Each
+// scenario writes to its own project, and Cleanup deletes whatever the
+// scenarios left in those projects.
+type PluginFixture struct {
+	primary   mcpplugin.Plugin
+	auth      mcpplugin.AuthContext
+	otherAuth mcpplugin.AuthContext
+	prefix    string
+
+	mu       sync.Mutex
+	projects []string
+}
+
+// multiPluginFixture adds the second plugin the cross-plugin scenarios need.
+type multiPluginFixture struct {
+	*PluginFixture
+	secondary mcpplugin.Plugin
+	manager   *mcpplugin.Manager
+}
+
+// NewPluginFixture returns a Fixture over cfg.Primary, or a
+// MultiPluginFixture when cfg.Secondary is set.
+func NewPluginFixture(cfg PluginFixtureConfig) (Fixture, error) {
+	if cfg.Primary == nil {
+		return nil, errors.New("conformance primary plugin is required")
+	}
+	if cfg.Auth.APIKeyHash == "" {
+		return nil, errors.New("conformance auth context is required")
+	}
+	if cfg.OtherAuth.APIKeyHash != "" && cfg.OtherAuth.APIKeyHash == cfg.Auth.APIKeyHash {
+		return nil, errors.New("conformance second tenant must differ from the first")
+	}
+	prefix := strings.TrimSpace(cfg.ProjectPrefix)
+	if prefix == "" {
+		prefix = "conformance"
+	}
+
+	fx := &PluginFixture{
+		primary:   cfg.Primary,
+		auth:      cfg.Auth,
+		otherAuth: cfg.OtherAuth,
+		prefix:    prefix + "-" + time.Now().UTC().Format("20060102150405"),
+	}
+	if cfg.Secondary == nil {
+		return fx, nil
+	}
+	if cfg.Secondary.Name() == cfg.Primary.Name() {
+		return nil, errors.Errorf("conformance secondary plugin must differ from %q", cfg.Primary.Name())
+	}
+	manager, err := mcpplugin.NewManager(cfg.Primary.Name(), cfg.Primary, cfg.Secondary)
+	if err != nil {
+		return nil, errors.Wrap(err, "build conformance plugin manager")
+	}
+	return &multiPluginFixture{PluginFixture: fx, secondary: cfg.Secondary, manager: manager}, nil
+}
+
+// Plugin returns the plugin under test.
+func (f *PluginFixture) Plugin() mcpplugin.Plugin { return f.primary }
+
+// NewAuthContext returns the configured tenant.
+func (f *PluginFixture) NewAuthContext(T) mcpplugin.AuthContext { return f.auth }
+
+// NewOtherAuthContext returns the second tenant, or skips t when none is
+// configured.
+func (f *PluginFixture) NewOtherAuthContext(t T) mcpplugin.AuthContext {
+	t.Helper()
+	if f.otherAuth.APIKeyHash == "" {
+		t.Skip("conformance fixture has no second tenant")
+	}
+	return f.otherAuth
+}
+
+// NewProject returns a fresh project for the calling scenario and remembers
+// it for Cleanup.
+func (f *PluginFixture) NewProject(t T) string {
+	t.Helper()
+	project := f.prefix + "-" + strings.ToLower(projectSafe(t.Name()))
+	project = project[:min(len(project), 128)]
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, seen := range f.projects {
+		if seen == project {
+			return project
+		}
+	}
+	f.projects = append(f.projects, project)
+	return project
+}
+
+// HasStorage reports true: the plugin is backed by its real storage.
+func (f *PluginFixture) HasStorage() bool { return true }
+
+// Cleanup deletes the top-level entries of every project handed out. The
+// root itself cannot be deleted, so empty projects remain.
+func (f *PluginFixture) Cleanup(t T) {
+	f.mu.Lock()
+	projects := append([]string(nil), f.projects...)
+	f.mu.Unlock()
+
+	ctx := context.Background()
+	for _, project := range projects {
+		listed, err := f.primary.List(ctx, f.auth, project, "", 1, cleanupListLimit)
+		if err != nil {
+			if !errIsNotFound(err) {
+				t.Errorf("list %s: %v", project, err)
+			}
+			continue
+		}
+		for _, entry := range listed.Entries {
+			if _, err := f.primary.Delete(ctx, f.auth, project, entry.Path, true); err != nil && !errIsNotFound(err) {
+				t.Errorf("delete %s:%s: %v", project, entry.Path, err)
+			}
+		}
+	}
+}
+
+// SecondaryPlugin returns the second plugin.
+func (f *multiPluginFixture) SecondaryPlugin() mcpplugin.Plugin { return f.secondary }
+
+// DefaultPluginName returns the primary plugin's name.
+func (f *multiPluginFixture) DefaultPluginName() string { return f.primary.Name() }
+
+// Manager returns a manager over both plugins.
+func (f *multiPluginFixture) Manager() *mcpplugin.Manager { return f.manager }
+
+// Cleanup also clears the projects through the secondary plugin, which the
+// cross-plugin scenarios write to.
+func (f *multiPluginFixture) Cleanup(t T) {
+	f.PluginFixture.Cleanup(t)
+	f.mu.Lock()
+	projects := append([]string(nil), f.projects...)
+	f.mu.Unlock()
+	(&PluginFixture{primary: f.secondary, auth: f.auth, projects: projects}).Cleanup(t)
+}
+
+// projectSafe replaces characters files.ValidateProject rejects with '-'.
+func projectSafe(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '_' || r == '-' || r == '.',
+			r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '-'
+		}
+	}, name)
+}
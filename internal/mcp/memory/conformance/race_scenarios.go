@@ -0,0 +1,319 @@
+package conformance
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+)
+
+const (
+	// raceCallBudget bounds one call of a concurrency scenario.
+	raceCallBudget = 10 * time.Second
+	// readLatencyBudget is the per-read budget R10 allows 1.5x of at p99.
+	readLatencyBudget = time.Second
+	// r10Reads and r10Workers size R10's concurrent read storm.
+	r10Reads   = 1000
+	r10Workers = 50
+)
+
+// newRaceScenario skips t when concurrency scenarios are off, then starts a
+// storage scenario.
+func newRaceScenario(t T, fx Fixture, opts Options) storageScenario {
+	t.Helper()
+	if opts.SkipConcurrency {
+		t.Skip("concurrency scenario skipped per options")
+	}
+	return newStorageScenario(t, fx)
+}
+
+// runR01 — Concurrent TRUNCATE writes resolve to one winning content.
+func runR01(t T, fx Fixture, opts Options) {
+	s := newRaceScenario(t, fx, opts)
+	contents := make([]string, 8)
+	for i := range contents {
+		contents[i] = strings.Repeat(string(rune('a'+i)), 1024+i)
+	}
+	for i, err := range s.writeConcurrently(func(i int) (string, string) { return "/r01.txt", contents[i] }, len(contents)) {
+		if err != nil {
+			t.Errorf("writer %d: %v", i, err)
+		}
+	}
+
+	got, err := s.read("/r01.txt")
+	if err != nil {
+		t.Fatalf("read /r01.txt: %v", err)
+	}
+	if !slices.Contains(contents, got) {
+		t.Errorf("read /r01.txt = %q, want exactly one writer's content", clip(got))
+	}
+}
+
+// runR02 — Write/delete race resolves to a documented deterministic outcome.
+func runR02(t T, fx Fixture, opts Options) {
+	s := newRaceScenario(t, fx, opts)
+	s.write(t, "/r02.txt", "before the race")
+
+	var wg sync.WaitGroup
+	var writeErr, deleteErr error
+	start := make(chan struct{})
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-start
+		_, writeErr = s.plugin.Write(s.ctx, s.auth, s.project, "/r02.txt", "written in the race", "utf-8", 0, files.WriteModeTruncate)
+	}()
+	go func() {
+		defer wg.Done()
+		<-start
+		_, deleteErr = s.plugin.Delete(s.ctx, s.auth, s.project, "/r02.txt", false)
+	}()
+	close(start)
+	wg.Wait()
+	if writeErr != nil {
+		t.Errorf("write: %v", writeErr)
+	}
+	if deleteErr != nil && !errIsNotFound(deleteErr) {
+		t.Errorf("delete: %v", deleteErr)
+	}
+
+	// Whichever call landed last wins: the new bytes or nothing at all.
+	got, err := s.read("/r02.txt")
+	switch {
+	case errIsNotFound(err):
+	case err != nil:
+		t.Errorf("read /r02.txt: %v", err)
+	case got != "written in the race":
+		t.Errorf("read /r02.txt = %q, want the raced write or NOT_FOUND", got)
+	}
+}
+
+// runR03 — Read/write race never returns mixed bytes; latency bounded.
+func runR03(t T, fx Fixture, opts Options) {
+	s := newRaceScenario(t, fx, opts)
+	versions := []string{strings.Repeat("A", 4096), strings.Repeat("B", 4096)}
+	s.write(t, "/r03.txt", versions[0])
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 20; i++ {
+			if _, err := s.plugin.Write(s.ctx, s.auth, s.project, "/r03.txt", versions[i%2], "utf-8", 0, files.WriteModeTruncate); err != nil {
+				t.Errorf("write %d: %v", i, err)
+			}
+		}
+		close(stop)
+	}()
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				start := time.Now()
+				got, err := s.read("/r03.txt")
+				if elapsed := time.Since(start); elapsed > raceCallBudget {
+					t.Errorf("read took %s, budget %s", elapsed, raceCallBudget)
+				}
+				if err != nil {
+					t.Errorf("read: %v", err)
+					return
+				}
+				if !slices.Contains(versions, got) {
+					t.Errorf("read returned mixed bytes %q", clip(got))
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// runR04 — Rename/read race resolves to original or NOT_FOUND atomically.
+func runR04(t T, fx Fixture, opts Options) {
+	s := newRaceScenario(t, fx, opts)
+	if !s.plugin.Capabilities().SupportsRename {
+		t.Skip("plugin does not support rename")
+	}
+	content := "renamed under readers"
+	s.write(t, "/r04/from.txt", content)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				got, err := s.read("/r04/from.txt")
+				switch {
+				case errIsNotFound(err):
+				case err != nil:
+					t.Errorf("read: %v", err)
+					return
+				case got != content:
+					t.Errorf("read returned %q, want the original bytes or NOT_FOUND", clip(got))
+					return
+				}
+				select {
+				case <-stop:
+					return
+				default:
+				}
+			}
+		}()
+	}
+	if _, err := s.plugin.Rename(s.ctx, s.auth, s.project, "/r04/from.txt", "/r04/to.txt", false); err != nil {
+		t.Errorf("rename: %v", err)
+	}
+	close(stop)
+	wg.Wait()
+	s.requireContent(t, "/r04/to.txt", content)
+	s.requireMissing(t, "/r04/from.txt")
+}
+
+// runR05 — Delete/read race never returns post-delete chunks via search.
+func runR05(t T, fx Fixture, opts Options) {
+	s := newRaceScenario(t, fx, opts)
+	s.requireSearch(t)
+	token := uniqueToken("r05")
+	s.write(t, "/r05.md", "Deleted while searched: "+token)
+	s.waitSearch(t, s.project, token, "", 5, func(res files.SearchResult) bool {
+		return hasChunk(res, "/r05.md")
+	})
+
+	deleted := make(chan struct{})
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-deleted:
+					// Once Delete has returned the chunk must be gone.
+					res, err := s.plugin.Search(s.ctx, s.auth, s.project, token, "", 5)
+					if err != nil {
+						t.Errorf("search after delete: %v", err)
+					} else if hasChunk(res, "/r05.md") {
+						t.Errorf("search after delete still returns /r05.md")
+					}
+					return
+				default:
+				}
+				// Results before Delete returns may go either way.
+				_, _ = s.plugin.Search(s.ctx, s.auth, s.project, token, "", 5)
+			}
+		}()
+	}
+	if _, err := s.plugin.Delete(s.ctx, s.auth, s.project, "/r05.md", false); err != nil {
+		t.Errorf("delete: %v", err)
+	}
+	close(deleted)
+	wg.Wait()
+}
+
+// runR06 — Freshness contract: search sees write within published window.
+func runR06(t T, fx Fixture, opts Options) {
+	if opts.SkipFreshness {
+		t.Skip("freshness/concurrency scenario skipped per options")
+	}
+	s := newRaceScenario(t, fx, opts)
+	s.requireSearch(t)
+
+	tokens := make([]string, 5)
+	for i := range tokens {
+		tokens[i] = uniqueToken(fmt.Sprintf("r06n%d", i))
+	}
+	path := func(i int) string { return fmt.Sprintf("/r06/doc-%d.md", i) }
+	for i, err := range s.writeConcurrently(func(i int) (string, string) { return path(i), "Fresh document " + tokens[i] }, len(tokens)) {
+		if err != nil {
+			t.Fatalf("write %s: %v", path(i), err)
+		}
+	}
+	for i, token := range tokens {
+		s.waitSearch(t, s.project, token, "", 5, func(res files.SearchResult) bool {
+			return hasChunk(res, path(i))
+		})
+	}
+}
+
+// runR07 — N=20 concurrent writes succeed within the per-call budget.
+func runR07(t T, fx Fixture, opts Options) {
+	s := newRaceScenario(t, fx, opts)
+	const n = 20
+	path := func(i int) string { return fmt.Sprintf("/r07/file-%02d.txt", i) }
+	started := time.Now()
+	for i, err := range s.writeConcurrently(func(i int) (string, string) { return path(i), "payload " + path(i) }, n) {
+		if err != nil {
+			t.Errorf("write %s: %v", path(i), err)
+		}
+	}
+	if elapsed := time.Since(started); elapsed > raceCallBudget {
+		t.Errorf("%d concurrent writes took %s, budget %s per call", n, elapsed, raceCallBudget)
+	}
+	for i := 0; i < n; i++ {
+		s.requireContent(t, path(i), "payload "+path(i))
+	}
+}
+
+// runR08 — Crash/restart: reads return bytes or UNAVAILABLE; no double-billing.
+func runR08(t T, fx Fixture, opts Options) {
+	if opts.SkipConcurrency {
+		t.Skip("concurrency scenario skipped per options")
+	}
+	requireStorage(t, fx)
+	notImplemented(t, "the fixture cannot restart the plugin's host process")
+}
+
+// runR10 — Thousands of concurrent reads stay within latency_budget × 1.5.
+func runR10(t T, fx Fixture, opts Options) {
+	s := newRaceScenario(t, fx, opts)
+	content := strings.Repeat("r10 ", 256)
+	s.write(t, "/r10.txt", content)
+
+	jobs := make(chan struct{})
+	latencies := make([]time.Duration, 0, r10Reads)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < r10Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				start := time.Now()
+				got, err := s.read("/r10.txt")
+				elapsed := time.Since(start)
+				if err != nil {
+					t.Errorf("read: %v", err)
+				} else if got != content {
+					t.Errorf("read returned %q", clip(got))
+				}
+				mu.Lock()
+				latencies = append(latencies, elapsed)
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < r10Reads; i++ {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+	wg.Wait()
+
+	slices.Sort(latencies)
+	p99 := latencies[len(latencies)*99/100]
+	if limit := readLatencyBudget * 3 / 2; p99 > limit {
+		t.Errorf("p99 read latency %s over %d concurrent reads exceeds %s", p99, r10Reads, limit)
+	}
+	t.Logf("R10: %d reads, p99 %s", len(latencies), p99)
+}
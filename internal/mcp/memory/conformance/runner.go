@@ -0,0 +1,267 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+)
+
+// Scenario outcomes reported by RunReport.
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+	// StatusNotImplemented marks a scenario the suite cannot check yet.
+	StatusNotImplemented = "not_implemented"
+)
+
+// ScenarioResult is the outcome of one scenario.
+type ScenarioResult struct {
+	ID         string   `json:"id"`
+	Status     string   `json:"status"`
+	DurationMs float64  `json:"duration_ms"`
+	Messages   []string `json:"messages,omitempty"`
+}
+
+// Report is the machine-readable result of a suite run.
+type Report struct {
+	Plugin     string    `json:"plugin"`
+	Secondary  string    `json:"secondary_plugin,omitempty"`
+	HasStorage bool      `json:"has_storage"`
+	Notes      string    `json:"notes,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs float64   `json:"duration_ms"`
+
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+	// NotImplemented counts scenarios the suite cannot check yet; they
+	// neither pass nor fail.
+	NotImplemented int `json:"not_implemented"`
+
+	Scenarios []ScenarioResult `json:"scenarios"`
+	// CleanupErrors lists what the fixture reported while cleaning up.
+	CleanupErrors []string `json:"cleanup_errors,omitempty"`
+}
+
+// OK reports whether no scenario failed.
+func (r Report) OK() bool { return r.Failed == 0 }
+
+// ScenarioIDs returns every registered scenario ID in run order.
+func ScenarioIDs() []string {
+	ids := make([]string, 0, len(scenarios))
+	for _, sc := range scenarios {
+		ids = append(ids, sc.id)
+	}
+	return ids
+}
+
+// ValidateOnly reports an error naming the first unknown scenario ID in only.
+func ValidateOnly(only []string) error {
+	known := ScenarioIDs()
+	for _, id := range only {
+		if !slices.Contains(known, strings.ToUpper(strings.TrimSpace(id))) {
+			return errors.Errorf("unknown conformance scenario %q", id)
+		}
+	}
+	return nil
+}
+
+// selectScenarios returns the scenarios listed in only, or all of them.
+func selectScenarios(only []string) []scenario {
+	if len(only) == 0 {
+		return scenarios
+	}
+	want := make(map[string]struct{}, len(only))
+	for _, id := range only {
+		want[strings.ToUpper(strings.TrimSpace(id))] = struct{}{}
+	}
+	out := make([]scenario, 0, len(want))
+	for _, sc := range scenarios {
+		if _, ok := want[sc.id]; ok {
+			out = append(out, sc)
+		}
+	}
+	return out
+}
+
+// RunReport executes the suite against fx outside go test and returns a
+// per-scenario report. Each scenario runs on its own goroutine, as under go
+// test, so Fatal and Skip end only that scenario. A scenario that runs
+// longer than timeout is reported as failed and abandoned; timeout <= 0
+// waits for ever. Cancelling ctx fails the scenario in progress and skips
+// the rest.
+func RunReport(ctx context.Context, fx Fixture, opts Options, timeout time.Duration) Report {
+	report := Report{Notes: opts.Notes, StartedAt: time.Now().UTC()}
+	if fx == nil {
+		report.CleanupErrors = []string{"conformance fixture is required"}
+		return report
+	}
+	if plugin := fx.Plugin(); plugin != nil {
+		report.Plugin = plugin.Name()
+	}
+	if multi, ok := fx.(MultiPluginFixture); ok && multi.SecondaryPlugin() != nil {
+		report.Secondary = multi.SecondaryPlugin().Name()
+	}
+	report.HasStorage = fx.HasStorage()
+
+	for _, sc := range selectScenarios(opts.Only) {
+		var res ScenarioResult
+		if ctx.Err() != nil {
+			res = ScenarioResult{ID: sc.id, Status: StatusSkip, Messages: []string{"run cancelled"}}
+		} else {
+			res = runScenario(ctx, sc.id, func(t T) { sc.run(t, fx, opts) }, timeout)
+		}
+		switch res.Status {
+		case StatusPass:
+			report.Passed++
+		case StatusFail:
+			report.Failed++
+		case StatusSkip:
+			report.Skipped++
+		case StatusNotImplemented:
+			report.NotImplemented++
+		}
+		report.Scenarios = append(report.Scenarios, res)
+	}
+
+	cleanup := runScenario(context.Background(), "cleanup", fx.Cleanup, timeout)
+	if cleanup.Status == StatusFail {
+		report.CleanupErrors = cleanup.Messages
+	}
+	report.DurationMs = float64(time.Since(report.StartedAt)) / float64(time.Millisecond)
+	return report
+}
+
+// runScenario runs fn against a reportT and converts its state to a result.
+func runScenario(ctx context.Context, id string, fn func(t T), timeout time.Duration) ScenarioResult {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	t := &reportT{name: id}
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if r := recover(); r != nil {
+				t.fail(fmt.Sprintf("panic: %v", r))
+			}
+		}()
+		fn(t)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.fail(fmt.Sprintf("abandoned: %v", ctx.Err()))
+	}
+
+	res := ScenarioResult{ID: id, DurationMs: float64(time.Since(start)) / float64(time.Millisecond)}
+	res.Status, res.Messages = t.outcome()
+	return res
+}
+
+// reportT implements T by collecting messages. Fatal and Skip stop the
+// calling goroutine with runtime.Goexit, as *testing.T does.
+type reportT struct {
+	name string
+
+	mu             sync.Mutex
+	messages       []string
+	failed         bool
+	skipped        bool
+	notImplemented bool
+}
+
+func (t *reportT) Helper()      {}
+func (t *reportT) Name() string { return t.name }
+
+func (t *reportT) Logf(format string, args ...any) {
+	t.record(fmt.Sprintf(format, args...))
+}
+
+func (t *reportT) Errorf(format string, args ...any) {
+	t.fail(fmt.Sprintf(format, args...))
+}
+
+func (t *reportT) Fatal(args ...any) {
+	t.fail(fmt.Sprint(args...))
+	runtime.Goexit()
+}
+
+func (t *reportT) Fatalf(format string, args ...any) {
+	t.fail(fmt.Sprintf(format, args...))
+	runtime.Goexit()
+}
+
+func (t *reportT) Skip(args ...any) {
+	t.skip(fmt.Sprint(args...))
+	runtime.Goexit()
+}
+
+func (t *reportT) Skipf(format string, args ...any) {
+	t.skip(fmt.Sprintf(format, args...))
+	runtime.Goexit()
+}
+
+func (t *reportT) Failed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failed
+}
+
+func (t *reportT) Skipped() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.skipped
+}
+
+func (t *reportT) record(msg string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = append(t.messages, msg)
+}
+
+func (t *reportT) fail(msg string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failed = true
+	t.messages = append(t.messages, msg)
+}
+
+func (t *reportT) skip(msg string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.skipped = true
+	t.notImplemented = strings.HasPrefix(msg, notImplementedPrefix)
+	t.messages = append(t.messages, msg)
+}
+
+// outcome returns the status and a copy of the messages. A failure wins over
+// a skip that followed it, and a skip through notImplemented is reported as
+// StatusNotImplemented.
+func (t *reportT) outcome() (string, []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	messages := append([]string(nil), t.messages...)
+	switch {
+	case t.failed:
+		return StatusFail, messages
+	case t.notImplemented:
+		return StatusNotImplemented, messages
+	case t.skipped:
+		return StatusSkip, messages
+	default:
+		return StatusPass, messages
+	}
+}
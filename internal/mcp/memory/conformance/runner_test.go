@@ -0,0 +1,118 @@
+package conformance
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+)
+
+// TestRunReportCountsOutcomes runs a scenario subset outside t.Run and checks
+// the per-scenario statuses and totals.
+func TestRunReportCountsOutcomes(t *testing.T) {
+	t.Parallel()
+
+	fx := newCrossPluginFixture(t, mcpplugin.DefaultPluginRAG, mcpplugin.DefaultPluginRAG, mcpplugin.DefaultPluginPageIndex)
+	report := RunReport(context.Background(), fx, Options{Only: []string{"c17", "C23", "R01", "C02"}, SkipConcurrency: true, Notes: "mem"}, time.Second)
+
+	if report.Plugin != mcpplugin.DefaultPluginRAG || report.Secondary != mcpplugin.DefaultPluginPageIndex {
+		t.Fatalf("plugins = %q/%q", report.Plugin, report.Secondary)
+	}
+	if len(report.Scenarios) != 4 {
+		t.Fatalf("ran %d scenarios, want 4", len(report.Scenarios))
+	}
+	want := map[string]string{"C02": StatusNotImplemented, "C17": StatusFail, "C23": StatusPass, "R01": StatusSkip}
+	for _, res := range report.Scenarios {
+		if res.Status != want[res.ID] {
+			t.Errorf("%s status = %s, want %s (%v)", res.ID, res.Status, want[res.ID], res.Messages)
+		}
+	}
+	// memPlugin accepts ".." paths, so C17 reports exactly that case.
+	if msgs := report.Scenarios[1].Messages; len(msgs) != 1 || !strings.Contains(msgs[0], "write_dotdot_path") {
+		t.Errorf("C17 messages = %v", msgs)
+	}
+	if report.Passed != 1 || report.Failed != 1 || report.Skipped != 1 || report.NotImplemented != 1 || report.OK() {
+		t.Errorf("totals = %d/%d/%d/%d", report.Passed, report.Failed, report.Skipped, report.NotImplemented)
+	}
+}
+
+// TestRunScenarioStopsOnFatalPanicAndTimeout checks Fatal ends a scenario,
+// panics and overruns are reported as failures, and Skip wins over a pass.
+func TestRunScenarioStopsOnFatalPanicAndTimeout(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	res := runScenario(ctx, "fatal", func(t T) {
+		t.Fatalf("stop %d", 1)
+		t.Errorf("unreachable")
+	}, 0)
+	if res.Status != StatusFail || len(res.Messages) != 1 || res.Messages[0] != "stop 1" {
+		t.Errorf("fatal result = %+v", res)
+	}
+
+	res = runScenario(ctx, "panic", func(T) { panic("boom") }, 0)
+	if res.Status != StatusFail || !strings.Contains(res.Messages[0], "boom") {
+		t.Errorf("panic result = %+v", res)
+	}
+
+	res = runScenario(ctx, "slow", func(T) { time.Sleep(time.Second) }, 10*time.Millisecond)
+	if res.Status != StatusFail || !strings.Contains(res.Messages[0], "abandoned") {
+		t.Errorf("timeout result = %+v", res)
+	}
+
+	res = runScenario(ctx, "skip", func(t T) { t.Logf("note"); t.Skip("later") }, 0)
+	if res.Status != StatusSkip || len(res.Messages) != 2 {
+		t.Errorf("skip result = %+v", res)
+	}
+
+	if err := ValidateOnly([]string{"C01", "r10"}); err != nil {
+		t.Errorf("ValidateOnly known IDs: %v", err)
+	}
+	if err := ValidateOnly([]string{"C99"}); err == nil {
+		t.Error("ValidateOnly accepted an unknown ID")
+	}
+}
+
+// TestPluginFixtureProjects checks per-scenario projects are valid project
+// names and the cross-plugin variant is chosen when a secondary is given.
+func TestPluginFixtureProjects(t *testing.T) {
+	t.Parallel()
+
+	owners := newOwners()
+	auth := mcpplugin.AuthContext{APIKeyHash: "h"}
+	fx, err := NewPluginFixture(PluginFixtureConfig{Primary: newMemPlugin("rag", owners), Auth: auth, ProjectPrefix: "ci"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fx.(MultiPluginFixture); ok {
+		t.Error("single plugin fixture must not offer cross-plugin scenarios")
+	}
+	rt := &reportT{name: "C01/with space"}
+	project := fx.NewProject(rt)
+	if !strings.HasPrefix(project, "ci-") || !strings.HasSuffix(project, "-c01-with-space") {
+		t.Errorf("project = %q", project)
+	}
+	if again := fx.NewProject(rt); again != project {
+		t.Errorf("NewProject is not stable: %q then %q", project, again)
+	}
+
+	fx, err = NewPluginFixture(PluginFixtureConfig{Primary: newMemPlugin("rag", owners), Secondary: newMemPlugin("pageindex", owners), Auth: auth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if multi, ok := fx.(MultiPluginFixture); !ok || multi.Manager() == nil || multi.DefaultPluginName() != "rag" {
+		t.Error("secondary plugin must yield a MultiPluginFixture")
+	}
+
+	if _, err = NewPluginFixture(PluginFixtureConfig{Primary: newMemPlugin("rag", owners), Secondary: newMemPlugin("rag", owners), Auth: auth}); err == nil {
+		t.Error("duplicate plugin names must be rejected")
+	}
+	if _, err = NewPluginFixture(PluginFixtureConfig{Primary: newMemPlugin("rag", owners)}); err == nil {
+		t.Error("missing auth must be rejected")
+	}
+	if _, err = NewPluginFixture(PluginFixtureConfig{Primary: newMemPlugin("rag", owners), Auth: auth, OtherAuth: auth}); err == nil {
+		t.Error("a second tenant equal to the first must be rejected")
+	}
+}
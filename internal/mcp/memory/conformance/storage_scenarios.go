@@ -0,0 +1,560 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+)
+
+const (
+	// searchGrace is added to a plugin's freshness window before a scenario
+	// gives up waiting for search to reflect a write or delete.
+	searchGrace = 2 * time.Second
+	// searchPollInterval is the pause between search attempts while waiting.
+	searchPollInterval = 100 * time.Millisecond
+)
+
+// storageScenario holds what every storage-backed scenario starts from.
+type storageScenario struct {
+	ctx     context.Context
+	plugin  mcpplugin.Plugin
+	auth    mcpplugin.AuthContext
+	project string
+}
+
+// newStorageScenario skips t unless fx has storage and a plugin, then returns
+// the scenario's tenant and fresh project.
+func newStorageScenario(t T, fx Fixture) storageScenario {
+	t.Helper()
+	requireStorage(t, fx)
+	plugin := fx.Plugin()
+	if plugin == nil {
+		t.Skip("fixture provides no plugin instance")
+	}
+	return storageScenario{
+		ctx:     context.Background(),
+		plugin:  plugin,
+		auth:    fx.NewAuthContext(t),
+		project: fx.NewProject(t),
+	}
+}
+
+// write stores content at path with TRUNCATE and fails t on error.
+func (s storageScenario) write(t T, path, content string) {
+	t.Helper()
+	if _, err := s.plugin.Write(s.ctx, s.auth, s.project, path, content, "utf-8", 0, mcpplugin.WriteModeTruncate); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+// read returns the whole file at path.
+func (s storageScenario) read(path string) (string, error) {
+	res, err := s.plugin.Read(s.ctx, s.auth, s.project, path, 0, -1)
+	return res.Content, err
+}
+
+// requireContent fails t unless path reads back exactly want.
+func (s storageScenario) requireContent(t T, path, want string) {
+	t.Helper()
+	got, err := s.read(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if got != want {
+		t.Fatalf("read %s = %q, want %q", path, clip(got), clip(want))
+	}
+}
+
+// requireMissing fails t unless path reads and stats as missing.
+func (s storageScenario) requireMissing(t T, path string) {
+	t.Helper()
+	if _, err := s.read(path); !errIsNotFound(err) {
+		t.Errorf("read %s: want NOT_FOUND, got %v", path, err)
+	}
+	stat, err := s.plugin.Stat(s.ctx, s.auth, s.project, path)
+	if err != nil && !errIsNotFound(err) {
+		t.Errorf("stat %s: want NOT_FOUND or exists=false, got %v", path, err)
+	} else if err == nil && stat.Exists {
+		t.Errorf("stat %s: still exists", path)
+	}
+}
+
+// requireSearch skips t when the plugin advertises no search mode.
+func (s storageScenario) requireSearch(t T) {
+	t.Helper()
+	if len(s.plugin.Capabilities().SearchModes) == 0 {
+		t.Skip("plugin advertises no search modes")
+	}
+}
+
+// searchDeadline is how long search may take to reflect a change.
+func (s storageScenario) searchDeadline() time.Time {
+	return time.Now().Add(s.plugin.Capabilities().FreshnessWindow + searchGrace)
+}
+
+// waitSearch repeats a search until done accepts the result or the freshness
+// window plus searchGrace has passed, then fails t with what it last saw.
+func (s storageScenario) waitSearch(t T, project, query, pathPrefix string, limit int, done func(files.SearchResult) bool) files.SearchResult {
+	t.Helper()
+	deadline := s.searchDeadline()
+	for {
+		res, err := s.plugin.Search(s.ctx, s.auth, project, query, pathPrefix, limit)
+		if err == nil && done(res) {
+			return res
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("search %q in %s did not converge within the freshness window: last error %v, last paths %v",
+				query, project, err, chunkPaths(res))
+		}
+		time.Sleep(searchPollInterval)
+	}
+}
+
+// uniqueToken returns a search term no other scenario or run writes.
+func uniqueToken(label string) string {
+	return fmt.Sprintf("conf%sx%x", strings.ToLower(label), time.Now().UnixNano())
+}
+
+// chunkPaths lists the file paths of the chunks in res.
+func chunkPaths(res files.SearchResult) []string {
+	paths := make([]string, 0, len(res.Chunks))
+	for _, chunk := range res.Chunks {
+		paths = append(paths, chunk.FilePath)
+	}
+	return paths
+}
+
+// hasChunk reports whether res holds a chunk of path.
+func hasChunk(res files.SearchResult, path string) bool {
+	return slices.Contains(chunkPaths(res), path)
+}
+
+// clip shortens long contents in failure messages.
+func clip(s string) string {
+	if len(s) <= 80 {
+		return s
+	}
+	return fmt.Sprintf("%s...(%d bytes)", s[:80], len(s))
+}
+
+// namedT gives a scenario a second name, so NewProject hands out a second
+// project that Cleanup still knows about.
+type namedT struct {
+	T
+	name string
+}
+
+func (t namedT) Name() string { return t.name }
+
+// runC01 — Agent writes UTF-8 text at path P, then reads it back.
+func runC01(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	content := "Conformance C01: plain UTF-8 text.\nSecond line.\n"
+	s.write(t, "/c01/note.md", content)
+	s.requireContent(t, "/c01/note.md", content)
+}
+
+// runC02 — Agent writes a binary PDF at path P, then reads the full file back.
+func runC02(t T, fx Fixture, _ Options) {
+	requireStorage(t, fx)
+	notImplemented(t, "the file tools accept utf-8 content only, so a binary PDF cannot be written")
+}
+
+// runC03 — Agent writes path P, then lists the parent directory.
+func runC03(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	s.write(t, "/c03/dir/file.txt", "listed")
+
+	listed, err := s.plugin.List(s.ctx, s.auth, s.project, "/c03/dir", 1, 100)
+	if err != nil {
+		t.Fatalf("list /c03/dir: %v", err)
+	}
+	for _, entry := range listed.Entries {
+		if entry.Path == "/c03/dir/file.txt" {
+			if entry.Type != mcpplugin.FileTypeFile {
+				t.Errorf("entry type = %q, want %q", entry.Type, mcpplugin.FileTypeFile)
+			}
+			return
+		}
+	}
+	t.Errorf("list /c03/dir = %+v, want /c03/dir/file.txt", listed.Entries)
+}
+
+// runC04 — Agent writes path P, then stats it.
+func runC04(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	content := "stat me"
+	s.write(t, "/c04.txt", content)
+
+	stat, err := s.plugin.Stat(s.ctx, s.auth, s.project, "/c04.txt")
+	if err != nil {
+		t.Fatalf("stat /c04.txt: %v", err)
+	}
+	if !stat.Exists || stat.Type != mcpplugin.FileTypeFile || stat.Size != int64(len(content)) {
+		t.Errorf("stat /c04.txt = %+v, want an existing %d-byte file", stat, len(content))
+	}
+}
+
+// runC05 — Agent writes P, waits the freshness window, then searches for content unique to P.
+func runC05(t T, fx Fixture, opts Options) {
+	if opts.SkipFreshness {
+		t.Skip("freshness window scenario skipped per options")
+	}
+	s := newStorageScenario(t, fx)
+	s.requireSearch(t)
+
+	token := uniqueToken("c05")
+	s.write(t, "/c05.md", "The freshness probe is "+token+".")
+	s.waitSearch(t, s.project, token, "", 5, func(res files.SearchResult) bool {
+		return hasChunk(res, "/c05.md")
+	})
+}
+
+// runC06 — Agent writes P, then deletes it, then lists.
+func runC06(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	s.write(t, "/c06/gone.txt", "short-lived")
+	if _, err := s.plugin.Delete(s.ctx, s.auth, s.project, "/c06/gone.txt", false); err != nil {
+		t.Fatalf("delete /c06/gone.txt: %v", err)
+	}
+
+	listed, err := s.plugin.List(s.ctx, s.auth, s.project, "/c06", 1, 100)
+	if err != nil && !errIsNotFound(err) {
+		t.Fatalf("list /c06: %v", err)
+	}
+	for _, entry := range listed.Entries {
+		if entry.Path == "/c06/gone.txt" {
+			t.Errorf("list /c06 still shows the deleted file")
+		}
+	}
+	s.requireMissing(t, "/c06/gone.txt")
+}
+
+// runC07 — Agent writes P, renames P → Q, then reads each.
+func runC07(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	if !s.plugin.Capabilities().SupportsRename {
+		t.Skip("plugin does not support rename")
+	}
+	content := "moving content"
+	s.write(t, "/c07/from.txt", content)
+	if _, err := s.plugin.Rename(s.ctx, s.auth, s.project, "/c07/from.txt", "/c07/to.txt", false); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	s.requireContent(t, "/c07/to.txt", content)
+	s.requireMissing(t, "/c07/from.txt")
+}
+
+// runC08 — Agent writes P (TRUNCATE) twice with different content; second wins.
+func runC08(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	s.write(t, "/c08.txt", "first version, which is longer than the second")
+	s.write(t, "/c08.txt", "second version")
+	s.requireContent(t, "/c08.txt", "second version")
+}
+
+// runC09 — Agent writes path P at offset N (OVERWRITE) on a text path.
+func runC09(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	if !s.plugin.Capabilities().SupportsRandomIO {
+		t.Skip("plugin does not support random IO")
+	}
+	s.write(t, "/c09.txt", "0123456789")
+	if _, err := s.plugin.Write(s.ctx, s.auth, s.project, "/c09.txt", "ab", "utf-8", 3, mcpplugin.WriteModeOverwrite); err != nil {
+		t.Fatalf("overwrite at offset 3: %v", err)
+	}
+	s.requireContent(t, "/c09.txt", "012ab56789")
+}
+
+// runC10 — Two agents concurrently write the same path P (TRUNCATE).
+func runC10(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	contents := []string{strings.Repeat("A", 2048), strings.Repeat("B", 2048)}
+	errs := s.writeConcurrently(func(i int) (string, string) { return "/c10.txt", contents[i] }, len(contents))
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent write: %v", err)
+		}
+	}
+
+	got, err := s.read("/c10.txt")
+	if err != nil {
+		t.Fatalf("read /c10.txt: %v", err)
+	}
+	if !slices.Contains(contents, got) {
+		t.Errorf("read /c10.txt = %q, want exactly one writer's content", clip(got))
+	}
+}
+
+// runC11 — Two agents concurrently write different paths in the same project.
+func runC11(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	path := func(i int) string { return fmt.Sprintf("/c11/agent-%d.txt", i) }
+	errs := s.writeConcurrently(func(i int) (string, string) { return path(i), "written by " + path(i) }, 2)
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("write %s: %v", path(i), err)
+		}
+	}
+	for i := range errs {
+		s.requireContent(t, path(i), "written by "+path(i))
+	}
+}
+
+// writeConcurrently runs n TRUNCATE writes at once and returns their errors.
+func (s storageScenario) writeConcurrently(item func(i int) (path, content string), n int) []error {
+	errs := make([]error, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			path, content := item(i)
+			<-start
+			_, errs[i] = s.plugin.Write(s.ctx, s.auth, s.project, path, content, "utf-8", 0, mcpplugin.WriteModeTruncate)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+// otherTenant returns the second tenant of fx, or skips t.
+func otherTenant(t T, fx Fixture) mcpplugin.AuthContext {
+	t.Helper()
+	tenants, ok := fx.(TenantFixture)
+	if !ok {
+		t.Skip("requires TenantFixture")
+	}
+	return tenants.NewOtherAuthContext(t)
+}
+
+// runC12 — Tenant isolation: B never sees A's content via search/list/read.
+func runC12(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	other := otherTenant(t, fx)
+
+	token := uniqueToken("c12")
+	s.write(t, "/c12/secret.md", "Tenant A keeps "+token+" to itself.")
+
+	b := s
+	b.auth = other
+	if _, err := b.read("/c12/secret.md"); !errIsNotFound(err) {
+		t.Errorf("tenant B read: want NOT_FOUND, got %v", err)
+	}
+	listed, err := b.plugin.List(b.ctx, b.auth, b.project, "", 10, 100)
+	if err != nil && !errIsNotFound(err) {
+		t.Errorf("tenant B list: %v", err)
+	}
+	if len(listed.Entries) != 0 {
+		t.Errorf("tenant B list shows %+v", listed.Entries)
+	}
+	if len(s.plugin.Capabilities().SearchModes) == 0 {
+		return
+	}
+
+	// Wait until A can find the token, so B's empty result is meaningful.
+	s.waitSearch(t, s.project, token, "", 5, func(res files.SearchResult) bool {
+		return hasChunk(res, "/c12/secret.md")
+	})
+	for _, project := range []string{b.project, files.ProjectWildcard} {
+		res, err := b.plugin.Search(b.ctx, b.auth, project, token, "", 5)
+		if err != nil && !errIsNotFound(err) {
+			t.Errorf("tenant B search %s: %v", project, err)
+		}
+		for _, chunk := range res.Chunks {
+			if strings.Contains(chunk.ChunkContent, token) {
+				t.Errorf("tenant B search %s returned A's chunk %s", project, chunk.FilePath)
+			}
+		}
+	}
+}
+
+// runC13 — Tenant isolation: cross-tenant guess returns NOT_FOUND with no oracle.
+func runC13(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	other := otherTenant(t, fx)
+	s.write(t, "/c13/exists.txt", "tenant A only")
+
+	b := s
+	b.auth = other
+	_, existsErr := b.read("/c13/exists.txt")
+	_, missingErr := b.read("/c13/missing.txt")
+	if !errIsNotFound(existsErr) || !errIsNotFound(missingErr) {
+		t.Fatalf("tenant B reads: want NOT_FOUND for both, got %v and %v", existsErr, missingErr)
+	}
+	// The two errors may only differ by the path they name.
+	existsMsg := strings.ReplaceAll(existsErr.Error(), "/c13/exists.txt", "PATH")
+	missingMsg := strings.ReplaceAll(missingErr.Error(), "/c13/missing.txt", "PATH")
+	if existsMsg != missingMsg {
+		t.Errorf("NOT_FOUND for an existing path %q differs from a missing one %q", existsErr, missingErr)
+	}
+}
+
+// runC14 — Search with path_prefix scopes results.
+func runC14(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	s.requireSearch(t)
+
+	token := uniqueToken("c14")
+	s.write(t, "/c14/in/a.md", "Inside the prefix: "+token)
+	s.write(t, "/c14/out/b.md", "Outside the prefix: "+token)
+
+	res := s.waitSearch(t, s.project, token, "/c14/in", 10, func(res files.SearchResult) bool {
+		return hasChunk(res, "/c14/in/a.md")
+	})
+	for _, path := range chunkPaths(res) {
+		if !strings.HasPrefix(path, "/c14/in/") {
+			t.Errorf("search with path_prefix /c14/in returned %s", path)
+		}
+	}
+}
+
+// runC15 — Search with limit=N caps the response.
+func runC15(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	s.requireSearch(t)
+
+	token := uniqueToken("c15")
+	for i := 0; i < 4; i++ {
+		s.write(t, fmt.Sprintf("/c15/doc-%d.md", i), fmt.Sprintf("Document %d mentions %s.", i, token))
+	}
+	// Wait for every document, then check the limit holds.
+	s.waitSearch(t, s.project, token, "", 10, func(res files.SearchResult) bool {
+		return len(res.Chunks) >= 4
+	})
+	res, err := s.plugin.Search(s.ctx, s.auth, s.project, token, "", 2)
+	if err != nil {
+		t.Fatalf("search with limit 2: %v", err)
+	}
+	if len(res.Chunks) == 0 || len(res.Chunks) > 2 {
+		t.Errorf("search with limit 2 returned %d chunks", len(res.Chunks))
+	}
+}
+
+// runC16 — Search with project="*" returns results from all caller projects.
+func runC16(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	s.requireSearch(t)
+	second := s
+	second.project = fx.NewProject(namedT{T: t, name: t.Name() + "/second"})
+	if second.project == s.project {
+		t.Skip("fixture hands out a single project per scenario")
+	}
+
+	token := uniqueToken("c16")
+	s.write(t, "/c16.md", "First project holds "+token)
+	second.write(t, "/c16.md", "Second project holds "+token)
+
+	res := s.waitSearch(t, files.ProjectWildcard, token, "", 10, func(res files.SearchResult) bool {
+		seen := map[string]bool{}
+		for _, chunk := range res.Chunks {
+			seen[chunk.Project] = true
+		}
+		return seen[s.project] && seen[second.project]
+	})
+	for _, chunk := range res.Chunks {
+		if chunk.Project == "" {
+			t.Errorf("wildcard search chunk %s carries no project", chunk.FilePath)
+		}
+	}
+}
+
+// runC18 — Long document: search returns chunk overlapping the last quarter.
+func runC18(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	s.requireSearch(t)
+
+	token := uniqueToken("c18")
+	var doc strings.Builder
+	for i := 0; i < 400; i++ {
+		if i == 350 {
+			fmt.Fprintf(&doc, "Paragraph %d carries the marker %s near the end.\n\n", i, token)
+			continue
+		}
+		fmt.Fprintf(&doc, "Paragraph %d is filler text about nothing in particular, repeated to make the document long.\n\n", i)
+	}
+	content := doc.String()
+	s.write(t, "/c18/long.md", content)
+
+	lastQuarter := int64(len(content) * 3 / 4)
+	s.waitSearch(t, s.project, token, "", 5, func(res files.SearchResult) bool {
+		for _, chunk := range res.Chunks {
+			if chunk.FilePath == "/c18/long.md" && (chunk.IsFullFile || chunk.FileSeekEndBytes > lastQuarter) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// runC19 — Agent writes arbitrary bytes at P, then reads them back.
+func runC19(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	// Non-UTF-8 bytes are rejected by the file tools, so this covers the
+	// widest content they accept: multi-byte runes, control characters and
+	// every line-ending style.
+	content := "tabs\tand\r\nCRLF\rCR\n" + "ünïcødé 漢字 😀 \u200b\u00a0 " + "\x01\x1f\x7f" + strings.Repeat("é", 300)
+	s.write(t, "/c19.bin.txt", content)
+	s.requireContent(t, "/c19.bin.txt", content)
+}
+
+// runC20 — After delete, read/stat NOT_FOUND and search omits the chunk.
+func runC20(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	token := uniqueToken("c20")
+	s.write(t, "/c20.md", "About to be deleted: "+token)
+	searchable := len(s.plugin.Capabilities().SearchModes) > 0
+	if searchable {
+		s.waitSearch(t, s.project, token, "", 5, func(res files.SearchResult) bool {
+			return hasChunk(res, "/c20.md")
+		})
+	}
+
+	if _, err := s.plugin.Delete(s.ctx, s.auth, s.project, "/c20.md", false); err != nil {
+		t.Fatalf("delete /c20.md: %v", err)
+	}
+	s.requireMissing(t, "/c20.md")
+	if searchable {
+		s.waitSearch(t, s.project, token, "", 5, func(res files.SearchResult) bool {
+			return !hasChunk(res, "/c20.md")
+		})
+	}
+}
+
+// runC27 — Users cannot create or observe system-owned routing/catalog state.
+func runC27(t T, fx Fixture, _ Options) {
+	s := newStorageScenario(t, fx)
+	// Paths shaped like the state plugins keep for themselves.
+	paths := []string{"/.pageindex/catalog.json", "/.system/routing.json", "/_index/manifest.json"}
+	for _, path := range paths {
+		s.write(t, path, `{"owner":"user","path":"`+path+`"}`)
+	}
+	for _, path := range paths {
+		s.requireContent(t, path, `{"owner":"user","path":"`+path+`"}`)
+	}
+
+	listed, err := s.plugin.List(s.ctx, s.auth, s.project, "", 10, 100)
+	if err != nil {
+		t.Fatalf("list root: %v", err)
+	}
+	var got []string
+	for _, entry := range listed.Entries {
+		if entry.Type == mcpplugin.FileTypeFile {
+			got = append(got, entry.Path)
+		}
+	}
+	slices.Sort(got)
+	want := slices.Clone(paths)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("list root files = %v, want only the user's %v", got, want)
+	}
+}
@@ -160,12 +160,12 @@ func (f *crossPluginFixture) SecondaryPlugin() mcpplugin.Plugin { return f.secon
 func (f *crossPluginFixture) Manager() *mcpplugin.Manager       { return f.manager }
 func (f *crossPluginFixture) DefaultPluginName() string         { return f.def }
 func (f *crossPluginFixture) HasStorage() bool                  { return true }
-func (f *crossPluginFixture) Cleanup(*testing.T)                {}
-func (f *crossPluginFixture) NewAuthContext(t *testing.T) mcpplugin.AuthContext {
+func (f *crossPluginFixture) Cleanup(T)                         {}
+func (f *crossPluginFixture) NewAuthContext(t T) mcpplugin.AuthContext {
 	t.Helper()
 	return mcpplugin.AuthContext{APIKey: "k", APIKeyHash: "h", UserIdentity: "u:" + t.Name()}
 }
-func (f *crossPluginFixture) NewProject(t *testing.T) string {
+func (f *crossPluginFixture) NewProject(t T) string {
 	t.Helper()
 	return "proj-" + t.Name()
 }
@@ -193,7 +193,7 @@ func TestCrossPluginSkipsSinglePlugin(t *testing.T) {
 	fx := &singleFixture{}
 	for _, sc := range []struct {
 		name string
-		fn   func(*testing.T, Fixture, Options)
+		fn   func(T, Fixture, Options)
 	}{
 		{"C23", runC23},
 		{"C24", runC24},
@@ -222,8 +222,8 @@ func TestCrossPluginSkipsSinglePlugin(t *testing.T) {
 // scenarios skip when no MultiPluginFixture is provided.
 type singleFixture struct{}
 
-func (singleFixture) Plugin() mcpplugin.Plugin                        { return nil }
-func (singleFixture) NewAuthContext(*testing.T) mcpplugin.AuthContext { return mcpplugin.AuthContext{} }
-func (singleFixture) NewProject(*testing.T) string                    { return "p" }
-func (singleFixture) HasStorage() bool                                { return true }
-func (singleFixture) Cleanup(*testing.T)                              {}
+func (singleFixture) Plugin() mcpplugin.Plugin               { return nil }
+func (singleFixture) NewAuthContext(T) mcpplugin.AuthContext { return mcpplugin.AuthContext{} }
+func (singleFixture) NewProject(T) string                    { return "p" }
+func (singleFixture) HasStorage() bool                       { return true }
+func (singleFixture) Cleanup(T)                              {}
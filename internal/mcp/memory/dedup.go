@@ -0,0 +1,337 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	sdkmemory "github.com/Laisky/go-utils/v6/agents/memory"
+	memorystorage "github.com/Laisky/go-utils/v6/agents/memory/storage"
+	"github.com/Laisky/zap"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/rag"
+)
+
+const (
+	// DedupModeLexical compares facts by token-set Jaccard similarity.
+	DedupModeLexical = "lexical"
+	// DedupModeEmbedding compares facts by embedding cosine similarity.
+	DedupModeEmbedding = "embedding"
+
+	factStateSuperseded = "superseded"
+)
+
+// factDuplicate records one fact that was folded into a fresher keeper.
+type factDuplicate struct {
+	Fact   sdkmemory.MemoryFact
+	KeptBy sdkmemory.MemoryFact
+}
+
+// activeFactsIndexPath returns the SDK active-facts index path for one session.
+func activeFactsIndexPath(sessionID string) string {
+	return memoryRootPath + "/" + sessionID + "/indexes/active_facts.json"
+}
+
+// legacyFactsPath returns the append-only facts file that the SDK replays when rebuilding the index.
+func legacyFactsPath(sessionID string) string {
+	return memoryRootPath + "/" + sessionID + "/memory_facts.jsonl"
+}
+
+// dedupRecall drops near-duplicate facts from a BeforeTurn response, keeping the freshest of each cluster.
+// It is best-effort: any failure leaves the response untouched.
+func (service *Service) dedupRecall(ctx context.Context, auth files.AuthContext, request BeforeTurnRequest, response *BeforeTurnResponse) {
+	if !service.settings.Dedup.Enabled || len(response.RecallFactIDs) < 2 {
+		return
+	}
+
+	adapter, err := newStorageAdapter(service.fileService, auth)
+	if err != nil {
+		return
+	}
+	index, err := loadActiveFactsIndex(ctx, adapter, request.Project, request.SessionID)
+	if err != nil {
+		service.logger.Debug("skip recall dedup", zap.Error(err))
+		return
+	}
+
+	recalled := make(map[string]struct{}, len(response.RecallFactIDs))
+	for _, id := range response.RecallFactIDs {
+		recalled[id] = struct{}{}
+	}
+	candidates := make([]sdkmemory.MemoryFact, 0, len(response.RecallFactIDs))
+	for _, fact := range index.Facts {
+		if _, ok := recalled[fact.FactID]; ok {
+			candidates = append(candidates, fact)
+		}
+	}
+
+	kept, duplicates := service.clusterFacts(ctx, auth, candidates)
+	if len(duplicates) == 0 {
+		return
+	}
+
+	keptIDs := make(map[string]struct{}, len(kept))
+	for _, fact := range kept {
+		keptIDs[fact.FactID] = struct{}{}
+	}
+	dropped := make(map[string]struct{}, len(duplicates))
+	for _, duplicate := range duplicates {
+		if _, ok := keptIDs[duplicate.Fact.FactID]; !ok {
+			dropped[duplicate.Fact.FactID] = struct{}{}
+		}
+	}
+	if len(dropped) == 0 {
+		return
+	}
+
+	filtered := make([]string, 0, len(response.RecallFactIDs)-len(dropped))
+	for _, id := range response.RecallFactIDs {
+		if _, ok := dropped[id]; !ok {
+			filtered = append(filtered, id)
+		}
+	}
+	removedChars := stripRecalledFactLines(response.InputItems, dropped)
+
+	response.RecallFactIDs = filtered
+	response.ContextTokenCount -= removedChars / 4
+	if response.ContextTokenCount < 0 {
+		response.ContextTokenCount = 0
+	}
+	service.logger.Debug("deduplicated recalled facts",
+		zap.String("session_id", request.SessionID),
+		zap.Int("dropped", len(dropped)))
+}
+
+// mergeDuplicateFacts supersedes near-duplicate active facts in favor of the freshest one.
+// Superseded records are appended to the legacy facts file so that index rebuilds stay consistent.
+func (service *Service) mergeDuplicateFacts(ctx context.Context, auth files.AuthContext, request SessionRequest) error {
+	adapter, err := newStorageAdapter(service.fileService, auth)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	index, err := loadActiveFactsIndex(ctx, adapter, request.Project, request.SessionID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(index.Facts) < 2 {
+		return nil
+	}
+
+	facts := make([]sdkmemory.MemoryFact, 0, len(index.Facts))
+	for _, fact := range index.Facts {
+		facts = append(facts, fact)
+	}
+	_, duplicates := service.clusterFacts(ctx, auth, facts)
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	now := service.clock().UTC().Format(time.RFC3339)
+	var tombstones strings.Builder
+	for _, duplicate := range duplicates {
+		record := duplicate.Fact
+		record.ID = fmt.Sprintf("%s-dedup-%s", record.ID, now)
+		record.TS = now
+		record.State = factStateSuperseded
+		record.SupersededBy = duplicate.KeptBy.FactID
+		line, marshalErr := json.Marshal(record)
+		if marshalErr != nil {
+			return errors.Wrap(marshalErr, "marshal superseded fact")
+		}
+		tombstones.Write(line)
+		tombstones.WriteByte('\n')
+
+		for identity, fact := range index.Facts {
+			if fact.ID == duplicate.Fact.ID && fact.FactID == duplicate.Fact.FactID && fact.Key == duplicate.Fact.Key {
+				delete(index.Facts, identity)
+			}
+		}
+	}
+
+	if err = adapter.Write(ctx, request.Project, legacyFactsPath(request.SessionID), tombstones.String(), memorystorage.WriteModeAppend, 0); err != nil {
+		return errors.Wrap(err, "append superseded facts")
+	}
+
+	index.UpdatedAt = now
+	body, err := json.Marshal(index)
+	if err != nil {
+		return errors.Wrap(err, "marshal active facts index")
+	}
+	if err = adapter.Write(ctx, request.Project, activeFactsIndexPath(request.SessionID), string(body), memorystorage.WriteModeTruncate, 0); err != nil {
+		return errors.Wrap(err, "write active facts index")
+	}
+
+	service.logger.Info("merged duplicate memory facts",
+		zap.String("session_id", request.SessionID),
+		zap.Int("merged", len(duplicates)))
+	return nil
+}
+
+// clusterFacts groups facts whose key/value text is similar above the configured threshold.
+// Facts are visited freshest first, so the first member of each cluster is the one kept.
+func (service *Service) clusterFacts(ctx context.Context, auth files.AuthContext, facts []sdkmemory.MemoryFact) ([]sdkmemory.MemoryFact, []factDuplicate) {
+	if len(facts) < 2 {
+		return facts, nil
+	}
+
+	ordered := append([]sdkmemory.MemoryFact(nil), facts...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].TS == ordered[j].TS {
+			if ordered[i].Confidence == ordered[j].Confidence {
+				return ordered[i].ID < ordered[j].ID
+			}
+			return ordered[i].Confidence > ordered[j].Confidence
+		}
+		return ordered[i].TS > ordered[j].TS
+	})
+
+	similarity := service.factSimilarity(ctx, auth, ordered)
+	threshold := service.settings.Dedup.Threshold
+
+	keptIdx := make([]int, 0, len(ordered))
+	duplicates := make([]factDuplicate, 0)
+	for i := range ordered {
+		matched := -1
+		for _, k := range keptIdx {
+			if similarity(i, k) >= threshold {
+				matched = k
+				break
+			}
+		}
+		if matched < 0 {
+			keptIdx = append(keptIdx, i)
+			continue
+		}
+		duplicates = append(duplicates, factDuplicate{Fact: ordered[i], KeptBy: ordered[matched]})
+	}
+
+	kept := make([]sdkmemory.MemoryFact, 0, len(keptIdx))
+	for _, k := range keptIdx {
+		kept = append(kept, ordered[k])
+	}
+	return kept, duplicates
+}
+
+// factSimilarity returns a pairwise similarity function over facts.
+// Embedding mode falls back to lexical similarity when no embedder is configured or embedding fails.
+func (service *Service) factSimilarity(ctx context.Context, auth files.AuthContext, facts []sdkmemory.MemoryFact) func(i, j int) float64 {
+	texts := make([]string, len(facts))
+	for i, fact := range facts {
+		texts[i] = factText(fact)
+	}
+
+	if service.settings.Dedup.Mode == DedupModeEmbedding && service.embedder != nil {
+		vectors, err := service.embedder.EmbedTexts(ctx, auth.APIKey, texts)
+		if err == nil && len(vectors) == len(texts) {
+			return func(i, j int) float64 {
+				return rag.CosineSimilarity(vectors[i], vectors[j])
+			}
+		}
+		service.logger.Warn("embed facts for dedup, fall back to lexical", zap.Error(err))
+	}
+
+	tokenSets := make([]map[string]struct{}, len(texts))
+	for i, text := range texts {
+		tokens := rag.Tokenize(text)
+		set := make(map[string]struct{}, len(tokens))
+		for _, token := range tokens {
+			set[token] = struct{}{}
+		}
+		tokenSets[i] = set
+	}
+	return func(i, j int) float64 {
+		return jaccardSimilarity(tokenSets[i], tokenSets[j])
+	}
+}
+
+// factText renders the comparable text of one fact.
+func factText(fact sdkmemory.MemoryFact) string {
+	return strings.TrimSpace(fact.Key + " " + fact.Value)
+}
+
+// jaccardSimilarity computes |a∩b| / |a∪b| over token sets.
+func jaccardSimilarity(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for token := range a {
+		if _, ok := b[token]; ok {
+			intersection++
+		}
+	}
+	union := len(a) + len(b) - intersection
+	return float64(intersection) / float64(union)
+}
+
+// stripRecalledFactLines removes `- Fact[<id>]` lines of dropped facts from memory reference blocks.
+// It returns the number of removed characters.
+func stripRecalledFactLines(items []sdkmemory.ResponseItem, dropped map[string]struct{}) int {
+	removed := 0
+	for i := range items {
+		for j := range items[i].Content {
+			part := &items[i].Content[j]
+			if !strings.Contains(part.Text, "<memory_reference>") {
+				continue
+			}
+			lines := strings.Split(part.Text, "\n")
+			keptLines := lines[:0]
+			for _, line := range lines {
+				if id, ok := recalledFactID(line); ok {
+					if _, drop := dropped[id]; drop {
+						removed += len(line) + 1
+						continue
+					}
+				}
+				keptLines = append(keptLines, line)
+			}
+			part.Text = strings.Join(keptLines, "\n")
+		}
+	}
+	return removed
+}
+
+// recalledFactID extracts the fact ID from a `- Fact[<id>]...` recall line.
+func recalledFactID(line string) (string, bool) {
+	rest, ok := strings.CutPrefix(line, "- Fact[")
+	if !ok {
+		return "", false
+	}
+	end := strings.Index(rest, "]")
+	if end < 0 {
+		return "", false
+	}
+	return rest[:end], true
+}
+
+// loadActiveFactsIndex reads the SDK active-facts index of one session.
+func loadActiveFactsIndex(ctx context.Context, adapter *storageAdapter, project, sessionID string) (sdkmemory.ActiveFactsIndex, error) {
+	index := sdkmemory.ActiveFactsIndex{Facts: map[string]sdkmemory.MemoryFact{}}
+	info, err := adapter.Stat(ctx, project, activeFactsIndexPath(sessionID))
+	if err != nil {
+		return index, errors.Wrap(err, "stat active facts index")
+	}
+	if !info.Exists {
+		return index, nil
+	}
+
+	body, err := adapter.Read(ctx, project, activeFactsIndexPath(sessionID), 0, -1)
+	if err != nil {
+		return index, errors.Wrap(err, "read active facts index")
+	}
+	if strings.TrimSpace(body) == "" {
+		return index, nil
+	}
+	if err = json.Unmarshal([]byte(body), &index); err != nil {
+		return index, errors.Wrap(err, "decode active facts index")
+	}
+	if index.Facts == nil {
+		index.Facts = map[string]sdkmemory.MemoryFact{}
+	}
+	return index, nil
+}
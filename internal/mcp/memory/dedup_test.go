@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	sdkmemory "github.com/Laisky/go-utils/v6/agents/memory"
+	memorystorage "github.com/Laisky/go-utils/v6/agents/memory/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+)
+
+// seedActiveFacts writes an active-facts index containing the given facts.
+func seedActiveFacts(t *testing.T, service *Service, auth files.AuthContext, project, sessionID string, facts ...sdkmemory.MemoryFact) {
+	t.Helper()
+
+	index := sdkmemory.ActiveFactsIndex{UpdatedAt: "2026-02-21T00:00:00Z", Facts: map[string]sdkmemory.MemoryFact{}}
+	for _, fact := range facts {
+		index.Facts[fact.FactID+"::"+fact.Key] = fact
+	}
+	body, err := json.Marshal(index)
+	require.NoError(t, err)
+
+	adapter, err := newStorageAdapter(service.fileService, auth)
+	require.NoError(t, err)
+	require.NoError(t, adapter.Write(context.Background(), project, activeFactsIndexPath(sessionID), string(body), memorystorage.WriteModeTruncate, 0))
+}
+
+func duplicateFactsFixture() []sdkmemory.MemoryFact {
+	return []sdkmemory.MemoryFact{
+		{ID: "r1", TS: "2026-02-20T10:00:00Z", Type: "preference", FactID: "f-old", Key: "reply_style", Value: "user prefers concise replies", Confidence: 0.9},
+		{ID: "r2", TS: "2026-02-21T10:00:00Z", Type: "preference", FactID: "f-new", Key: "reply_style_pref", Value: "user prefers concise replies", Confidence: 0.9},
+		{ID: "r3", TS: "2026-02-19T10:00:00Z", Type: "profile", FactID: "f-name", Key: "name", Value: "Alice", Confidence: 0.95},
+	}
+}
+
+// TestServiceBeforeTurnDeduplicatesRecalledFacts verifies near-duplicate facts collapse to the freshest one.
+func TestServiceBeforeTurnDeduplicatesRecalledFacts(t *testing.T) {
+	service, _ := newTestMemoryService(t)
+	service.settings.Dedup = DedupSettings{Enabled: true, Mode: DedupModeLexical, Threshold: 0.6}
+	ctx := context.Background()
+	auth := files.AuthContext{APIKey: "sk-test", APIKeyHash: "hash-test", UserIdentity: "user:test"}
+	seedActiveFacts(t, service, auth, "demo", "session-dedup", duplicateFactsFixture()...)
+
+	response, err := service.BeforeTurn(ctx, auth, BeforeTurnRequest{
+		Project:      "demo",
+		SessionID:    "session-dedup",
+		UserID:       "user-1",
+		TurnID:       "turn-1",
+		CurrentInput: newTextItems("how should you reply?"),
+		MaxInputTok:  120000,
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"f-new", "f-name"}, response.RecallFactIDs)
+
+	var text strings.Builder
+	for _, item := range response.InputItems {
+		for _, part := range item.Content {
+			text.WriteString(part.Text)
+		}
+	}
+	require.Contains(t, text.String(), "Fact[f-new]")
+	require.NotContains(t, text.String(), "Fact[f-old]")
+
+	service.settings.Dedup.Enabled = false
+	raw, err := service.BeforeTurn(ctx, auth, BeforeTurnRequest{
+		Project:      "demo",
+		SessionID:    "session-dedup",
+		UserID:       "user-1",
+		TurnID:       "turn-2",
+		CurrentInput: newTextItems("how should you reply?"),
+		MaxInputTok:  120000,
+	})
+	require.NoError(t, err)
+	require.Len(t, raw.RecallFactIDs, 3)
+	require.Greater(t, raw.ContextTokenCount, response.ContextTokenCount)
+}
+
+// TestServiceRunMaintenanceMergesDuplicateFacts verifies maintenance supersedes stale duplicates durably.
+func TestServiceRunMaintenanceMergesDuplicateFacts(t *testing.T) {
+	service, _ := newTestMemoryService(t)
+	service.settings.Dedup = DedupSettings{Enabled: true, Mode: DedupModeLexical, Threshold: 0.6, MergeOnMaintenance: true}
+	ctx := context.Background()
+	auth := files.AuthContext{APIKey: "sk-test", APIKeyHash: "hash-test", UserIdentity: "user:test"}
+	seedActiveFacts(t, service, auth, "demo", "session-merge", duplicateFactsFixture()...)
+
+	require.NoError(t, service.RunMaintenance(ctx, auth, SessionRequest{Project: "demo", SessionID: "session-merge"}))
+
+	adapter, err := newStorageAdapter(service.fileService, auth)
+	require.NoError(t, err)
+	index, err := loadActiveFactsIndex(ctx, adapter, "demo", "session-merge")
+	require.NoError(t, err)
+	ids := make([]string, 0, len(index.Facts))
+	for _, fact := range index.Facts {
+		ids = append(ids, fact.FactID)
+	}
+	require.ElementsMatch(t, []string{"f-new", "f-name"}, ids)
+
+	tombstones, err := adapter.Read(ctx, "demo", legacyFactsPath("session-merge"), 0, -1)
+	require.NoError(t, err)
+	require.Contains(t, tombstones, `"state":"superseded"`)
+	require.Contains(t, tombstones, `"superseded_by":"f-new"`)
+}
+
+// TestJaccardSimilarity verifies token-set similarity bounds.
+func TestJaccardSimilarity(t *testing.T) {
+	set := func(tokens ...string) map[string]struct{} {
+		out := map[string]struct{}{}
+		for _, token := range tokens {
+			out[token] = struct{}{}
+		}
+		return out
+	}
+	require.InDelta(t, 1.0, jaccardSimilarity(set("a", "b"), set("a", "b")), 1e-9)
+	require.InDelta(t, 1.0/3.0, jaccardSimilarity(set("a", "b"), set("b", "c")), 1e-9)
+	require.Zero(t, jaccardSimilarity(set(), set("a")))
+}
@@ -29,12 +29,18 @@ func (service *Service) newEngineForAuth(auth files.AuthContext) (*sdkmemory.Sta
 		TimeNow:                service.clock,
 	}
 
-	if service.settings.Heuristic.Enabled {
-		config.LLMModel = service.settings.Heuristic.Model
-		config.LLMAPIBase = strings.TrimSpace(service.settings.Heuristic.BaseURL)
-		config.LLMTimeout = service.settings.Heuristic.Timeout
-		config.LLMMaxOutputTokens = service.settings.Heuristic.MaxOutputTokens
-		config.LLMAPIKey = strings.TrimSpace(auth.APIKey)
+	replay, replaying := service.factExtractor.(*ReplayFactExtractor)
+	switch {
+	case replaying && replay.Recording():
+		config.HeuristicClient = replay.WithUpstream(NewLLMFactExtractor(service.settings.Heuristic, auth.APIKey, nil))
+	case service.factExtractor != nil:
+		config.HeuristicClient = service.factExtractor
+	case service.settings.Heuristic.Enabled && service.settings.Heuristic.Provider == HeuristicProviderRules:
+		config.HeuristicClient = NewRuleFactExtractor()
+	case service.settings.Heuristic.Enabled && strings.TrimSpace(auth.APIKey) != "":
+		// Production and replay recording share one extractor, so recorded
+		// fixtures capture the prompt production actually sends.
+		config.HeuristicClient = NewLLMFactExtractor(service.settings.Heuristic, auth.APIKey, nil)
 	}
 
 	engine, err := sdkmemory.NewEngine(adapter, config)
@@ -0,0 +1,378 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	memorystorage "github.com/Laisky/go-utils/v6/agents/memory/storage"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+)
+
+const (
+	// ExportFormat identifies portable memory export documents.
+	ExportFormat = "laisky.mcp.memory.export"
+	// ExportVersion is the current portable memory export schema version.
+	ExportVersion = 1
+
+	memoryRootPath    = "/memory"
+	exportListLimit   = 1024
+	exportMaxDirDepth = 16
+)
+
+// ExportFileKind classifies one exported memory file by its role in the session layout.
+type ExportFileKind string
+
+const (
+	ExportFileKindFact    ExportFileKind = "fact"
+	ExportFileKindSummary ExportFileKind = "summary"
+	ExportFileKindInsight ExportFileKind = "insight"
+	ExportFileKindEvent   ExportFileKind = "event"
+	ExportFileKindContext ExportFileKind = "context"
+	ExportFileKindMeta    ExportFileKind = "meta"
+	ExportFileKindOther   ExportFileKind = "other"
+)
+
+// ExportRequest selects which memory sessions to export.
+type ExportRequest struct {
+	Project    string   `json:"project"`
+	SessionIDs []string `json:"session_ids,omitempty"`
+}
+
+// ExportDocument is the versioned, storage-agnostic memory snapshot for one project.
+type ExportDocument struct {
+	Format     string          `json:"format"`
+	Version    int             `json:"version"`
+	ExportedAt time.Time       `json:"exported_at"`
+	Project    string          `json:"project"`
+	Sessions   []ExportSession `json:"sessions"`
+}
+
+// ExportSession groups all memory files of one session.
+type ExportSession struct {
+	SessionID string       `json:"session_id"`
+	Files     []ExportFile `json:"files"`
+}
+
+// ExportFile stores one memory file relative to its session root.
+type ExportFile struct {
+	Path      string         `json:"path"`
+	Kind      ExportFileKind `json:"kind"`
+	Content   string         `json:"content"`
+	UpdatedAt string         `json:"updated_at,omitempty"`
+}
+
+// ImportRequest carries an export document to rehydrate into a project.
+// Project overrides the document project when set.
+// Overwrite replaces existing sessions; otherwise files that already exist are skipped.
+type ImportRequest struct {
+	Project   string         `json:"project"`
+	Overwrite bool           `json:"overwrite"`
+	Document  ExportDocument `json:"document"`
+}
+
+// ImportResult reports how many sessions and files were rehydrated.
+type ImportResult struct {
+	Project       string `json:"project"`
+	SessionCount  int    `json:"session_count"`
+	FileCount     int    `json:"file_count"`
+	SkippedCount  int    `json:"skipped_count"`
+	WrittenBytes  int64  `json:"written_bytes"`
+	FormatVersion int    `json:"format_version"`
+}
+
+// Export snapshots memory sessions, facts and summaries of one project into a portable document.
+func (service *Service) Export(ctx context.Context, auth files.AuthContext, request ExportRequest) (ExportDocument, error) {
+	if err := validateExportRequest(auth, request); err != nil {
+		return ExportDocument{}, errors.WithStack(err)
+	}
+
+	adapter, err := newStorageAdapter(service.fileService, auth)
+	if err != nil {
+		return ExportDocument{}, errors.WithStack(err)
+	}
+
+	sessionIDs := normalizeSessionIDs(request.SessionIDs)
+	if len(sessionIDs) == 0 {
+		sessionIDs, err = listSessionIDs(ctx, adapter, request.Project)
+		if err != nil {
+			return ExportDocument{}, errors.WithStack(err)
+		}
+	}
+
+	document := ExportDocument{
+		Format:     ExportFormat,
+		Version:    ExportVersion,
+		ExportedAt: service.clock().UTC(),
+		Project:    request.Project,
+		Sessions:   make([]ExportSession, 0, len(sessionIDs)),
+	}
+	for _, sessionID := range sessionIDs {
+		session, exportErr := exportSession(ctx, adapter, request.Project, sessionID)
+		if exportErr != nil {
+			return ExportDocument{}, errors.Wrapf(exportErr, "export session %s", sessionID)
+		}
+		if len(session.Files) == 0 {
+			continue
+		}
+		document.Sessions = append(document.Sessions, session)
+	}
+
+	return document, nil
+}
+
+// Import rehydrates an export document through the storage adapter, one session lock at a time.
+func (service *Service) Import(ctx context.Context, auth files.AuthContext, request ImportRequest) (ImportResult, error) {
+	project := strings.TrimSpace(request.Project)
+	if project == "" {
+		project = request.Document.Project
+	}
+	if err := validateImportRequest(auth, project, request.Document); err != nil {
+		return ImportResult{}, errors.WithStack(err)
+	}
+
+	adapter, err := newStorageAdapter(service.fileService, auth)
+	if err != nil {
+		return ImportResult{}, errors.WithStack(err)
+	}
+
+	result := ImportResult{Project: project, FormatVersion: request.Document.Version}
+	for _, session := range request.Document.Sessions {
+		lockErr := withSessionLock(ctx, service.db, auth.APIKeyHash, project, session.SessionID, service.settings.SessionLockTimeout, func(_ *sql.Tx) error {
+			return importSession(ctx, adapter, project, session, request.Overwrite, &result)
+		})
+		if lockErr != nil {
+			return result, errors.Wrapf(lockErr, "import session %s", session.SessionID)
+		}
+		result.SessionCount++
+	}
+
+	return result, nil
+}
+
+// exportSession walks one session directory and reads every file beneath it.
+func exportSession(ctx context.Context, adapter *storageAdapter, project, sessionID string) (ExportSession, error) {
+	base := path.Join(memoryRootPath, sessionID)
+	entries, err := walkFiles(ctx, adapter, project, base, 0)
+	if err != nil {
+		return ExportSession{}, errors.WithStack(err)
+	}
+
+	session := ExportSession{SessionID: sessionID, Files: make([]ExportFile, 0, len(entries))}
+	for _, entry := range entries {
+		content, readErr := adapter.Read(ctx, project, entry.Path, 0, -1)
+		if readErr != nil {
+			return ExportSession{}, errors.Wrapf(readErr, "read %s", entry.Path)
+		}
+
+		relative := strings.TrimPrefix(entry.Path, base+"/")
+		session.Files = append(session.Files, ExportFile{
+			Path:      relative,
+			Kind:      classifyExportFile(relative),
+			Content:   content,
+			UpdatedAt: entry.UpdatedAt,
+		})
+	}
+
+	return session, nil
+}
+
+// importSession writes one exported session back into storage.
+func importSession(ctx context.Context, adapter *storageAdapter, project string, session ExportSession, overwrite bool, result *ImportResult) error {
+	base := path.Join(memoryRootPath, session.SessionID)
+	if overwrite {
+		info, err := adapter.Stat(ctx, project, base)
+		if err != nil {
+			return errors.Wrapf(err, "stat %s", base)
+		}
+		if info.Exists {
+			if err = adapter.Delete(ctx, project, base, true); err != nil {
+				return errors.Wrapf(err, "clear %s", base)
+			}
+		}
+	}
+
+	for _, file := range session.Files {
+		target := path.Join(base, file.Path)
+		if !overwrite {
+			info, err := adapter.Stat(ctx, project, target)
+			if err != nil {
+				return errors.Wrapf(err, "stat %s", target)
+			}
+			if info.Exists {
+				result.SkippedCount++
+				continue
+			}
+		}
+
+		if err := adapter.Write(ctx, project, target, file.Content, memorystorage.WriteModeTruncate, 0); err != nil {
+			return errors.Wrapf(err, "write %s", target)
+		}
+		result.FileCount++
+		result.WrittenBytes += int64(len(file.Content))
+	}
+
+	return nil
+}
+
+// listSessionIDs returns the sorted session directory names under the memory root.
+func listSessionIDs(ctx context.Context, adapter *storageAdapter, project string) ([]string, error) {
+	entries, hasMore, err := adapter.List(ctx, project, memoryRootPath, 1, exportListLimit)
+	if err != nil {
+		return nil, errors.Wrap(err, "list memory sessions")
+	}
+	if hasMore {
+		return nil, errors.WithStack(NewError(ErrCodeInvalidArgument, "too many memory sessions; pass session_ids explicitly", false))
+	}
+
+	sessionIDs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type != memorystorage.FileTypeDirectory {
+			continue
+		}
+		sessionIDs = append(sessionIDs, path.Base(entry.Path))
+	}
+	sort.Strings(sessionIDs)
+	return sessionIDs, nil
+}
+
+// walkFiles lists files under dir one level at a time so no single listing hits the page limit.
+func walkFiles(ctx context.Context, adapter *storageAdapter, project, dir string, level int) ([]memorystorage.FileInfo, error) {
+	if level > exportMaxDirDepth {
+		return nil, errors.WithStack(NewError(ErrCodeInvalidArgument, "memory directory nesting is too deep", false))
+	}
+
+	entries, hasMore, err := adapter.List(ctx, project, dir, 1, exportListLimit)
+	if err != nil {
+		return nil, errors.Wrapf(err, "list %s", dir)
+	}
+	if hasMore {
+		return nil, errors.WithStack(NewError(ErrCodeInvalidArgument, "memory directory "+dir+" has too many entries to export", false))
+	}
+
+	output := make([]memorystorage.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		switch entry.Type {
+		case memorystorage.FileTypeDirectory:
+			children, walkErr := walkFiles(ctx, adapter, project, entry.Path, level+1)
+			if walkErr != nil {
+				return nil, walkErr
+			}
+			output = append(output, children...)
+		case memorystorage.FileTypeFile:
+			output = append(output, entry)
+		}
+	}
+
+	sort.Slice(output, func(i, j int) bool {
+		return output[i].Path < output[j].Path
+	})
+	return output, nil
+}
+
+// classifyExportFile maps a session-relative path to its export kind.
+func classifyExportFile(relative string) ExportFileKind {
+	name := path.Base(relative)
+	top, _, _ := strings.Cut(relative, "/")
+	switch {
+	case name == ".abstract" || name == ".overview":
+		return ExportFileKindSummary
+	case top == "memory_tiers" || top == "indexes" || name == "memory_facts.jsonl":
+		return ExportFileKindFact
+	case top == "insights":
+		return ExportFileKindInsight
+	case top == "events" || name == "log.jsonl":
+		return ExportFileKindEvent
+	case top == "runtime" || name == "context.jsonl":
+		return ExportFileKindContext
+	case top == "meta" || name == "meta.json":
+		return ExportFileKindMeta
+	default:
+		return ExportFileKindOther
+	}
+}
+
+// normalizeSessionIDs trims, deduplicates and sorts requested session IDs.
+func normalizeSessionIDs(sessionIDs []string) []string {
+	seen := make(map[string]struct{}, len(sessionIDs))
+	output := make([]string, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		trimmed := strings.TrimSpace(sessionID)
+		if trimmed == "" {
+			continue
+		}
+		if _, ok := seen[trimmed]; ok {
+			continue
+		}
+		seen[trimmed] = struct{}{}
+		output = append(output, trimmed)
+	}
+	sort.Strings(output)
+	return output
+}
+
+// validateExportRequest validates memory export inputs.
+func validateExportRequest(auth files.AuthContext, request ExportRequest) error {
+	if strings.TrimSpace(auth.APIKeyHash) == "" {
+		return NewError(ErrCodePermissionDenied, "missing authorization", false)
+	}
+	if strings.TrimSpace(request.Project) == "" {
+		return NewError(ErrCodeInvalidArgument, "project is required", false)
+	}
+	for _, sessionID := range request.SessionIDs {
+		sessionID = strings.TrimSpace(sessionID)
+		if sessionID != "" && !validExportSessionID(sessionID) {
+			return NewError(ErrCodeInvalidArgument, "invalid session_id "+sessionID, false)
+		}
+	}
+	return nil
+}
+
+// validExportSessionID reports whether sessionID names exactly one directory under the memory root.
+func validExportSessionID(sessionID string) bool {
+	return sessionID != "" &&
+		sessionID == strings.TrimSpace(sessionID) &&
+		!strings.Contains(sessionID, "/") &&
+		sessionID != "." &&
+		sessionID != ".."
+}
+
+// validateImportRequest validates memory import inputs and the embedded document layout.
+func validateImportRequest(auth files.AuthContext, project string, document ExportDocument) error {
+	if strings.TrimSpace(auth.APIKeyHash) == "" {
+		return NewError(ErrCodePermissionDenied, "missing authorization", false)
+	}
+	if project == "" {
+		return NewError(ErrCodeInvalidArgument, "project is required", false)
+	}
+	if document.Format != ExportFormat {
+		return NewError(ErrCodeInvalidArgument, "unsupported export format "+document.Format, false)
+	}
+	if document.Version <= 0 || document.Version > ExportVersion {
+		return NewError(ErrCodeInvalidArgument, "unsupported export version", false)
+	}
+
+	seen := make(map[string]struct{}, len(document.Sessions))
+	for _, session := range document.Sessions {
+		sessionID := session.SessionID
+		if !validExportSessionID(sessionID) {
+			return NewError(ErrCodeInvalidArgument, "invalid session_id in export document", false)
+		}
+		if _, ok := seen[sessionID]; ok {
+			return NewError(ErrCodeInvalidArgument, "duplicate session_id "+sessionID+" in export document", false)
+		}
+		seen[sessionID] = struct{}{}
+
+		for _, file := range session.Files {
+			if file.Path == "" || strings.HasPrefix(file.Path, "/") || path.Clean(file.Path) != file.Path || strings.HasPrefix(file.Path, "../") || file.Path == ".." {
+				return NewError(ErrCodeInvalidArgument, "invalid file path "+file.Path+" in session "+sessionID, false)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+)
+
+// TestServiceExportImportRoundTrip verifies an exported project can be rehydrated under another API key.
+func TestServiceExportImportRoundTrip(t *testing.T) {
+	service, _ := newTestMemoryService(t)
+	ctx := context.Background()
+	source := files.AuthContext{APIKey: "sk-source", APIKeyHash: "hash-source", UserIdentity: "user:source"}
+	target := files.AuthContext{APIKey: "sk-target", APIKeyHash: "hash-target", UserIdentity: "user:target"}
+
+	before, err := service.BeforeTurn(ctx, source, BeforeTurnRequest{
+		Project:      "demo",
+		SessionID:    "session-export",
+		UserID:       "user-1",
+		TurnID:       "turn-1",
+		CurrentInput: newTextItems("I prefer concise replies"),
+		MaxInputTok:  120000,
+	})
+	require.NoError(t, err)
+	require.NoError(t, service.AfterTurn(ctx, source, AfterTurnRequest{
+		Project:     "demo",
+		SessionID:   "session-export",
+		UserID:      "user-1",
+		TurnID:      "turn-1",
+		InputItems:  before.InputItems,
+		OutputItems: newAssistantTextItems("Understood."),
+	}))
+
+	document, err := service.Export(ctx, source, ExportRequest{Project: "demo"})
+	require.NoError(t, err)
+	require.Equal(t, ExportFormat, document.Format)
+	require.Equal(t, ExportVersion, document.Version)
+	require.Len(t, document.Sessions, 1)
+	require.Equal(t, "session-export", document.Sessions[0].SessionID)
+	require.NotEmpty(t, document.Sessions[0].Files)
+
+	kinds := map[ExportFileKind]int{}
+	for _, file := range document.Sessions[0].Files {
+		kinds[file.Kind]++
+	}
+	require.Positive(t, kinds[ExportFileKindContext])
+	require.Positive(t, kinds[ExportFileKindMeta])
+
+	raw, err := json.Marshal(document)
+	require.NoError(t, err)
+	decoded := ExportDocument{}
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+
+	result, err := service.Import(ctx, target, ImportRequest{Project: "restored", Document: decoded})
+	require.NoError(t, err)
+	require.Equal(t, 1, result.SessionCount)
+	require.Equal(t, len(document.Sessions[0].Files), result.FileCount)
+	require.Zero(t, result.SkippedCount)
+
+	restored, err := service.Export(ctx, target, ExportRequest{Project: "restored"})
+	require.NoError(t, err)
+	require.Len(t, restored.Sessions, 1)
+	require.Len(t, restored.Sessions[0].Files, len(document.Sessions[0].Files))
+	for i, file := range restored.Sessions[0].Files {
+		require.Equal(t, document.Sessions[0].Files[i].Path, file.Path)
+		require.Equal(t, document.Sessions[0].Files[i].Content, file.Content)
+	}
+
+	again, err := service.Import(ctx, target, ImportRequest{Project: "restored", Document: decoded})
+	require.NoError(t, err)
+	require.Zero(t, again.FileCount)
+	require.Equal(t, result.FileCount, again.SkippedCount)
+
+	recalled, err := service.BeforeTurn(ctx, target, BeforeTurnRequest{
+		Project:      "restored",
+		SessionID:    "session-export",
+		UserID:       "user-1",
+		TurnID:       "turn-2",
+		CurrentInput: newTextItems("what do I prefer?"),
+		MaxInputTok:  120000,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, recalled.InputItems)
+}
+
+// TestServiceExportImportFactsAndSummaries verifies fact and summary files survive an import/export round-trip.
+func TestServiceExportImportFactsAndSummaries(t *testing.T) {
+	service, _ := newTestMemoryService(t)
+	ctx := context.Background()
+	auth := files.AuthContext{APIKey: "sk-test", APIKeyHash: "hash-test", UserIdentity: "user:test"}
+
+	document := ExportDocument{
+		Format:  ExportFormat,
+		Version: ExportVersion,
+		Project: "demo",
+		Sessions: []ExportSession{{
+			SessionID: "session-kinds",
+			Files: []ExportFile{
+				{Path: "memory_tiers/L1/2026/02/facts-20260221.jsonl", Kind: ExportFileKindFact, Content: `{"id":"fact-1","content":"prefers concise replies"}` + "\n"},
+				{Path: "meta/.abstract", Kind: ExportFileKindSummary, Content: "User prefers concise replies."},
+			},
+		}},
+	}
+	result, err := service.Import(ctx, auth, ImportRequest{Project: "restored", Document: document})
+	require.NoError(t, err)
+	require.Equal(t, 2, result.FileCount)
+
+	restored, err := service.Export(ctx, auth, ExportRequest{Project: "restored", SessionIDs: []string{"session-kinds"}})
+	require.NoError(t, err)
+	require.Len(t, restored.Sessions, 1)
+
+	byKind := map[ExportFileKind]ExportFile{}
+	for _, file := range restored.Sessions[0].Files {
+		byKind[file.Kind] = file
+	}
+	for _, want := range document.Sessions[0].Files {
+		got, ok := byKind[want.Kind]
+		require.True(t, ok, want.Kind)
+		require.Equal(t, want.Path, got.Path)
+		require.Equal(t, want.Content, got.Content)
+	}
+}
+
+// TestServiceExportRejectsInvalidSessionIDs verifies session IDs cannot address paths outside one session.
+func TestServiceExportRejectsInvalidSessionIDs(t *testing.T) {
+	service, _ := newTestMemoryService(t)
+	auth := files.AuthContext{APIKey: "sk-test", APIKeyHash: "hash-test", UserIdentity: "user:test"}
+
+	for _, sessionID := range []string{".", "..", "a/b"} {
+		_, err := service.Export(context.Background(), auth, ExportRequest{Project: "demo", SessionIDs: []string{sessionID}})
+		require.Error(t, err, sessionID)
+		typed, ok := AsError(err)
+		require.True(t, ok)
+		require.Equal(t, ErrCodeInvalidArgument, typed.Code)
+	}
+}
+
+// TestServiceImportRejectsInvalidDocuments verifies format, version and path validation on import.
+func TestServiceImportRejectsInvalidDocuments(t *testing.T) {
+	service, _ := newTestMemoryService(t)
+	auth := files.AuthContext{APIKey: "sk-test", APIKeyHash: "hash-test", UserIdentity: "user:test"}
+
+	cases := map[string]ExportDocument{
+		"format":  {Format: "other", Version: ExportVersion, Project: "demo"},
+		"version": {Format: ExportFormat, Version: ExportVersion + 1, Project: "demo"},
+		"session": {Format: ExportFormat, Version: ExportVersion, Project: "demo", Sessions: []ExportSession{{SessionID: "../x"}}},
+		"path": {Format: ExportFormat, Version: ExportVersion, Project: "demo", Sessions: []ExportSession{{
+			SessionID: "s1",
+			Files:     []ExportFile{{Path: "../../escape", Content: "x"}},
+		}}},
+	}
+	for name, document := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := service.Import(context.Background(), auth, ImportRequest{Document: document})
+			require.Error(t, err)
+			typed, ok := AsError(err)
+			require.True(t, ok)
+			require.Equal(t, ErrCodeInvalidArgument, typed.Code)
+		})
+	}
+}
+
+// TestClassifyExportFile verifies session-relative paths map to stable export kinds.
+func TestClassifyExportFile(t *testing.T) {
+	require.Equal(t, ExportFileKindFact, classifyExportFile("memory_tiers/L1/2026/02/facts-20260221.jsonl"))
+	require.Equal(t, ExportFileKindFact, classifyExportFile("indexes/active_facts.json"))
+	require.Equal(t, ExportFileKindSummary, classifyExportFile("meta/.abstract"))
+	require.Equal(t, ExportFileKindInsight, classifyExportFile("insights/2026/02/21/insights-20260221.jsonl"))
+	require.Equal(t, ExportFileKindEvent, classifyExportFile("events/raw/2026/02/21/log-20260221.jsonl"))
+	require.Equal(t, ExportFileKindContext, classifyExportFile("runtime/context/current.jsonl"))
+	require.Equal(t, ExportFileKindMeta, classifyExportFile("meta/state.json"))
+	require.Equal(t, ExportFileKindOther, classifyExportFile("notes.txt"))
+}
@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	sdkmemory "github.com/Laisky/go-utils/v6/agents/memory"
+)
+
+const (
+	// HeuristicProviderLLM extracts facts with the remote model configured in HeuristicSettings.
+	HeuristicProviderLLM = "llm"
+	// HeuristicProviderRules extracts facts with deterministic offline pattern rules.
+	HeuristicProviderRules = "rules"
+	// HeuristicProviderReplay answers from recorded fixtures; misses are logged and skipped.
+	HeuristicProviderReplay = "replay"
+
+	ruleFactMaxValueChars = 200
+	ruleFactTierL0        = "L0"
+	ruleFactTierL1        = "L1"
+	ruleFactTierL2        = "L2"
+)
+
+// FactExtractor extracts new facts from one turn and merges them against existing facts.
+// It matches sdkmemory.HeuristicClient so any implementation can be handed to the engine.
+type FactExtractor interface {
+	ExtractAndMergeFacts(ctx context.Context, in sdkmemory.HeuristicFactInput) (sdkmemory.HeuristicFactResult, error)
+}
+
+var _ sdkmemory.HeuristicClient = FactExtractor(nil)
+
+// factRule maps one sentence pattern to a fact template.
+type factRule struct {
+	name       string
+	pattern    *regexp.Regexp
+	factID     string // fixed fact ID; empty means one fact per distinct value
+	key        string
+	tier       string
+	confidence float64
+}
+
+var (
+	ruleSentenceSplitter = regexp.MustCompile(`[\n.!?;]+`)
+	ruleDoneTodoPattern  = regexp.MustCompile(`(?i)^(?:done|finished|completed)\s*[:\-]\s*(.+)$`)
+
+	// defaultFactRules are evaluated in order against every sentence; the first match wins.
+	defaultFactRules = []factRule{
+		{
+			name:       "name",
+			pattern:    regexp.MustCompile(`(?i)\bmy name is\s+(.+)$`),
+			factID:     "user_name",
+			key:        "name",
+			tier:       ruleFactTierL0,
+			confidence: 0.95,
+		},
+		{
+			name:       "preference",
+			pattern:    regexp.MustCompile(`(?i)\bi(?:\s+would|'d)?\s+prefer\s+(.+)$`),
+			factID:     "user_preference",
+			key:        "preference",
+			tier:       ruleFactTierL0,
+			confidence: 0.92,
+		},
+		{
+			name:       "decision",
+			pattern:    regexp.MustCompile(`(?i)(?:^decision\s*:\s*|\b(?:we|i)\s+(?:have\s+)?(?:decided|agreed)\s+(?:to|on|that)\s+|\blet'?s\s+go\s+with\s+)(.+)$`),
+			key:        "decision",
+			tier:       ruleFactTierL2,
+			confidence: 0.85,
+		},
+		{
+			name:       "todo",
+			pattern:    regexp.MustCompile(`(?i)(?:^(?:todo|to-do)\s*[:\-]\s*|\bremind me to\s+|\bi need to\s+)(.+)$`),
+			key:        "todo",
+			tier:       ruleFactTierL1,
+			confidence: 0.80,
+		},
+	}
+)
+
+// RuleFactExtractor is a deterministic, offline FactExtractor driven by sentence patterns.
+// It recognizes names, preferences, decisions and TODOs, and retires TODOs marked as done.
+type RuleFactExtractor struct {
+	rules []factRule
+}
+
+// NewRuleFactExtractor constructs a rule-based extractor with the default pattern set.
+func NewRuleFactExtractor() *RuleFactExtractor {
+	return &RuleFactExtractor{rules: defaultFactRules}
+}
+
+// ExtractAndMergeFacts extracts facts from user-authored input items.
+// Facts identical to an existing active fact are skipped, and "done: <todo>" retires the matching TODO.
+func (extractor *RuleFactExtractor) ExtractAndMergeFacts(ctx context.Context, in sdkmemory.HeuristicFactInput) (sdkmemory.HeuristicFactResult, error) {
+	existing := make(map[string]sdkmemory.MemoryFact, len(in.ExistingFacts))
+	for _, fact := range in.ExistingFacts {
+		existing[fact.FactID] = fact
+	}
+
+	result := sdkmemory.HeuristicFactResult{}
+	seen := make(map[string]struct{})
+	for _, sentence := range userSentences(in.InputItems) {
+		if matches := ruleDoneTodoPattern.FindStringSubmatch(sentence); matches != nil {
+			factID := valueFactID("todo", cleanFactValue(matches[1]))
+			if _, ok := existing[factID]; ok {
+				result.DeletedFactIDs = append(result.DeletedFactIDs, factID)
+			}
+			continue
+		}
+
+		for _, rule := range extractor.rules {
+			matches := rule.pattern.FindStringSubmatch(sentence)
+			if matches == nil {
+				continue
+			}
+			value := cleanFactValue(matches[len(matches)-1])
+			if value == "" {
+				break
+			}
+
+			factID := rule.factID
+			if factID == "" {
+				factID = valueFactID(rule.key, value)
+			}
+			if _, dup := seen[factID]; dup {
+				break
+			}
+			seen[factID] = struct{}{}
+			if prev, ok := existing[factID]; ok && strings.EqualFold(prev.Value, value) {
+				break
+			}
+
+			result.UpdatedFacts = append(result.UpdatedFacts, sdkmemory.MemoryFact{
+				ID:         in.TurnID + "-rule-" + rule.name + "-" + factID,
+				TS:         in.NowRFC3339,
+				Type:       "fact_upsert",
+				FactID:     factID,
+				Key:        rule.key,
+				Value:      value,
+				Confidence: rule.confidence,
+				Tier:       rule.tier,
+			})
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// userSentences splits user-authored text into trimmed sentences.
+func userSentences(items []sdkmemory.ResponseItem) []string {
+	sentences := make([]string, 0, 8)
+	for _, item := range items {
+		if item.Role != "" && item.Role != "user" {
+			continue
+		}
+		for _, part := range item.Content {
+			for _, sentence := range ruleSentenceSplitter.Split(part.Text, -1) {
+				if trimmed := strings.TrimSpace(sentence); trimmed != "" {
+					sentences = append(sentences, trimmed)
+				}
+			}
+		}
+	}
+	return sentences
+}
+
+// cleanFactValue trims punctuation and bounds the stored value length.
+func cleanFactValue(raw string) string {
+	value := strings.Trim(strings.TrimSpace(raw), ",:-\"'` ")
+	if runes := []rune(value); len(runes) > ruleFactMaxValueChars {
+		value = strings.TrimSpace(string(runes[:ruleFactMaxValueChars]))
+	}
+	return value
+}
+
+// valueFactID derives a stable fact ID for multi-valued keys such as decisions and TODOs.
+func valueFactID(key, value string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.Join(strings.Fields(value), " "))))
+	return key + "_" + hex.EncodeToString(sum[:])[:12]
+}
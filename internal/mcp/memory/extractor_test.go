@@ -0,0 +1,263 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sdkmemory "github.com/Laisky/go-utils/v6/agents/memory"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+)
+
+// TestRuleFactExtractorPatterns verifies each built-in rule produces the expected fact.
+func TestRuleFactExtractorPatterns(t *testing.T) {
+	extractor := NewRuleFactExtractor()
+	result, err := extractor.ExtractAndMergeFacts(context.Background(), sdkmemory.HeuristicFactInput{
+		TurnID:     "turn-1",
+		NowRFC3339: "2026-02-21T10:00:00Z",
+		InputItems: newTextItems("Hi, my name is Alice Chen. I prefer concise replies! " +
+			"We decided to use PostgreSQL for storage. TODO: write the migration guide"),
+	})
+	require.NoError(t, err)
+	require.Empty(t, result.DeletedFactIDs)
+
+	byKey := map[string]sdkmemory.MemoryFact{}
+	for _, fact := range result.UpdatedFacts {
+		byKey[fact.Key] = fact
+	}
+	require.Len(t, byKey, 4)
+	require.Equal(t, "Alice Chen", byKey["name"].Value)
+	require.Equal(t, "user_name", byKey["name"].FactID)
+	require.Equal(t, "concise replies", byKey["preference"].Value)
+	require.Equal(t, "use PostgreSQL for storage", byKey["decision"].Value)
+	require.True(t, strings.HasPrefix(byKey["decision"].FactID, "decision_"))
+	require.Equal(t, "write the migration guide", byKey["todo"].Value)
+	require.Equal(t, "turn-1-rule-todo-"+byKey["todo"].FactID, byKey["todo"].ID)
+}
+
+// TestRuleFactExtractorMergesExisting verifies unchanged facts are skipped and finished TODOs are deleted.
+func TestRuleFactExtractorMergesExisting(t *testing.T) {
+	extractor := NewRuleFactExtractor()
+	todoID := valueFactID("todo", "write the migration guide")
+	result, err := extractor.ExtractAndMergeFacts(context.Background(), sdkmemory.HeuristicFactInput{
+		TurnID:     "turn-2",
+		NowRFC3339: "2026-02-21T11:00:00Z",
+		InputItems: newTextItems("My name is alice chen. Done: Write the migration guide"),
+		ExistingFacts: []sdkmemory.MemoryFact{
+			{FactID: "user_name", Key: "name", Value: "Alice Chen"},
+			{FactID: todoID, Key: "todo", Value: "write the migration guide"},
+		},
+	})
+	require.NoError(t, err)
+	require.Empty(t, result.UpdatedFacts)
+	require.Equal(t, []string{todoID}, result.DeletedFactIDs)
+
+	ignored, err := extractor.ExtractAndMergeFacts(context.Background(), sdkmemory.HeuristicFactInput{
+		TurnID:     "turn-3",
+		InputItems: newAssistantTextItems("My name is Assistant."),
+	})
+	require.NoError(t, err)
+	require.Empty(t, ignored.UpdatedFacts)
+}
+
+// TestServiceRuleHeuristicRecallsDecisions verifies the offline provider feeds recall without network access.
+func TestServiceRuleHeuristicRecallsDecisions(t *testing.T) {
+	service, _ := newTestMemoryService(t)
+	service.settings.Heuristic.Enabled = true
+	service.settings.Heuristic.Provider = HeuristicProviderRules
+	ctx := context.Background()
+	auth := files.AuthContext{APIKey: "sk-test", APIKeyHash: "hash-test", UserIdentity: "user:test"}
+
+	before, err := service.BeforeTurn(ctx, auth, BeforeTurnRequest{
+		Project:      "demo",
+		SessionID:    "session-rules",
+		UserID:       "user-1",
+		TurnID:       "turn-1",
+		CurrentInput: newTextItems("We decided to ship on Friday"),
+		MaxInputTok:  120000,
+	})
+	require.NoError(t, err)
+	require.NoError(t, service.AfterTurn(ctx, auth, AfterTurnRequest{
+		Project:     "demo",
+		SessionID:   "session-rules",
+		UserID:      "user-1",
+		TurnID:      "turn-1",
+		InputItems:  before.InputItems,
+		OutputItems: newAssistantTextItems("Noted."),
+	}))
+
+	recalled, err := service.BeforeTurn(ctx, auth, BeforeTurnRequest{
+		Project:      "demo",
+		SessionID:    "session-rules",
+		UserID:       "user-1",
+		TurnID:       "turn-2",
+		CurrentInput: newTextItems("when do we ship?"),
+		MaxInputTok:  120000,
+	})
+	require.NoError(t, err)
+	require.Contains(t, recalled.RecallFactIDs, valueFactID("decision", "ship on Friday"))
+}
+
+// TestServiceLLMHeuristicUsesFactExtractor verifies the llm provider sends the
+// LLMFactExtractor prompt that replay recording captures.
+func TestServiceLLMHeuristicUsesFactExtractor(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		require.Equal(t, "/v1/responses", r.URL.Path)
+		var body struct {
+			Instructions string `json:"instructions"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, llmFactInstructions, body.Instructions)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"output_text":"{\"updated_facts\":[{\"fact_id\":\"user_city\",\"key\":\"city\",\"value\":\"Lisbon\",\"tier\":\"L0\",\"confidence\":0.9}]}"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	service, _ := newTestMemoryService(t)
+	service.settings.Heuristic = HeuristicSettings{
+		Enabled:         true,
+		Provider:        HeuristicProviderLLM,
+		Model:           "test-model",
+		BaseURL:         server.URL + "/v1",
+		Timeout:         time.Second,
+		MaxOutputTokens: 200,
+	}
+	ctx := context.Background()
+	auth := files.AuthContext{APIKey: "sk-test", APIKeyHash: "hash-test", UserIdentity: "user:test"}
+
+	before, err := service.BeforeTurn(ctx, auth, BeforeTurnRequest{
+		Project:      "demo",
+		SessionID:    "session-llm",
+		UserID:       "user-1",
+		TurnID:       "turn-1",
+		CurrentInput: newTextItems("I moved to Lisbon last month"),
+		MaxInputTok:  120000,
+	})
+	require.NoError(t, err)
+	require.NoError(t, service.AfterTurn(ctx, auth, AfterTurnRequest{
+		Project:     "demo",
+		SessionID:   "session-llm",
+		UserID:      "user-1",
+		TurnID:      "turn-1",
+		InputItems:  before.InputItems,
+		OutputItems: newAssistantTextItems("Noted."),
+	}))
+	require.Equal(t, 1, calls)
+
+	recalled, err := service.BeforeTurn(ctx, auth, BeforeTurnRequest{
+		Project:      "demo",
+		SessionID:    "session-llm",
+		UserID:       "user-1",
+		TurnID:       "turn-2",
+		CurrentInput: newTextItems("where do I live?"),
+		MaxInputTok:  120000,
+	})
+	require.NoError(t, err)
+	require.Contains(t, recalled.RecallFactIDs, "user_city")
+}
+
+// failingFactExtractor fails every call so tests can prove a result came from fixtures.
+type failingFactExtractor struct{}
+
+func (failingFactExtractor) ExtractAndMergeFacts(context.Context, sdkmemory.HeuristicFactInput) (sdkmemory.HeuristicFactResult, error) {
+	return sdkmemory.HeuristicFactResult{}, errors.New("upstream called")
+}
+
+// TestReplayFactExtractorRecordsAndReplays verifies recorded turns replay offline under new turn IDs.
+func TestReplayFactExtractorRecordsAndReplays(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "facts.jsonl")
+	turn := sdkmemory.HeuristicFactInput{
+		TurnID:     "turn-1",
+		NowRFC3339: "2026-02-21T10:00:00Z",
+		InputItems: newTextItems("We decided to use PostgreSQL for storage"),
+	}
+
+	recorder, err := NewReplayFactExtractor(ReplayFactExtractorConfig{Path: path, Record: true, Upstream: NewRuleFactExtractor()})
+	require.NoError(t, err)
+	recorded, err := recorder.ExtractAndMergeFacts(ctx, turn)
+	require.NoError(t, err)
+	require.Len(t, recorded.UpdatedFacts, 1)
+	require.Equal(t, 1, recorder.Len())
+
+	replayer, err := NewReplayFactExtractor(ReplayFactExtractorConfig{Path: path, Upstream: failingFactExtractor{}})
+	require.NoError(t, err)
+	turn.TurnID = "turn-9"
+	turn.NowRFC3339 = "2026-03-01T00:00:00Z"
+	replayed, err := replayer.ExtractAndMergeFacts(ctx, turn)
+	require.NoError(t, err)
+	require.Len(t, replayed.UpdatedFacts, 1)
+	require.Equal(t, recorded.UpdatedFacts[0].FactID, replayed.UpdatedFacts[0].FactID)
+	require.Equal(t, "turn-9-replay-"+recorded.UpdatedFacts[0].FactID, replayed.UpdatedFacts[0].ID)
+	require.Equal(t, "2026-03-01T00:00:00Z", replayed.UpdatedFacts[0].TS)
+
+	turn.InputItems = newTextItems("something new")
+	_, err = replayer.ExtractAndMergeFacts(ctx, turn)
+	require.ErrorContains(t, err, "upstream called")
+}
+
+// TestReplayFactExtractorMissWithoutUpstream verifies replay mode reports misses instead of answering from rules.
+func TestReplayFactExtractorMissWithoutUpstream(t *testing.T) {
+	replayer, err := NewReplayFactExtractor(ReplayFactExtractorConfig{})
+	require.NoError(t, err)
+
+	_, err = replayer.ExtractAndMergeFacts(context.Background(), sdkmemory.HeuristicFactInput{
+		TurnID:     "turn-1",
+		NowRFC3339: "2026-02-21T10:00:00Z",
+		InputItems: newTextItems("We decided to use PostgreSQL for storage"),
+	})
+	require.ErrorContains(t, err, "no heuristic fixture")
+	require.Equal(t, 1, replayer.Misses())
+	require.Zero(t, replayer.Len())
+}
+
+// TestReplayFactExtractorRecordsLLMResponses verifies record mode stores what the model answered, not rule output.
+func TestReplayFactExtractorRecordsLLMResponses(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		require.Equal(t, "/v1/responses", r.URL.Path)
+		require.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"output_text":"` + "```json" + `\n{\"updated_facts\":[{\"fact_id\":\"user_city\",\"key\":\"city\",\"value\":\"Lisbon\",\"tier\":\"l0\",\"confidence\":0.9}]}\n` + "```" + `"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "facts.jsonl")
+	turn := sdkmemory.HeuristicFactInput{
+		TurnID:     "turn-1",
+		UserID:     "user-1",
+		NowRFC3339: "2026-02-21T10:00:00Z",
+		InputItems: newTextItems("I moved to Lisbon last month"),
+	}
+	settings := HeuristicSettings{BaseURL: server.URL, Model: "test-model", Timeout: time.Second, MaxOutputTokens: 200}
+
+	recorder, err := NewReplayFactExtractor(ReplayFactExtractorConfig{Path: path, Record: true})
+	require.NoError(t, err)
+	require.True(t, recorder.Recording())
+	recorded, err := recorder.WithUpstream(NewLLMFactExtractor(settings, "sk-test", server.Client())).ExtractAndMergeFacts(ctx, turn)
+	require.NoError(t, err)
+	require.Len(t, recorded.UpdatedFacts, 1)
+	require.Equal(t, "Lisbon", recorded.UpdatedFacts[0].Value)
+	require.Equal(t, "L0", recorded.UpdatedFacts[0].Tier)
+	require.Equal(t, "turn-1-llm-user_city", recorded.UpdatedFacts[0].ID)
+
+	replayer, err := NewReplayFactExtractor(ReplayFactExtractorConfig{Path: path})
+	require.NoError(t, err)
+	replayed, err := replayer.ExtractAndMergeFacts(ctx, turn)
+	require.NoError(t, err)
+	require.Equal(t, "Lisbon", replayed.UpdatedFacts[0].Value)
+	require.Equal(t, 1, calls)
+	require.Zero(t, replayer.Misses())
+}
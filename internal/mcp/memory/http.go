@@ -0,0 +1,463 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	gmw "github.com/Laisky/gin-middlewares/v7"
+	logSDK "github.com/Laisky/go-utils/v6/log"
+	"github.com/Laisky/zap"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/askuser"
+	mcpauth "github.com/Laisky/laisky-blog-graphql/internal/mcp/auth"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugins/pageindex"
+)
+
+const (
+	exportAPIPath       = "/api/export"
+	importAPIPath       = "/api/import"
+	pageIndexCachePath  = "/api/pageindex/cache"
+	shadowSummaryPath   = "/api/shadow/summary"
+	shadowPairsPath     = "/api/shadow/pairs"
+	canaryPath          = "/api/canary"
+	canaryResetPath     = "/api/canary/reset"
+	shadowPairsDefault  = 20
+	shadowPairsMax      = 100
+	importMaxBodyBytes  = 64 << 20
+	exportImportTimeout = 2 * time.Minute
+)
+
+// NewHTTPHandler constructs an HTTP mux exposing memory export and import APIs.
+func NewHTTPHandler(service *Service, logger logSDK.Logger) http.Handler {
+	return mcpauth.HTTPMiddleware(&memoryHTTPHandler{service: service, logger: logger})
+}
+
+type memoryHTTPHandler struct {
+	service *Service
+	logger  logSDK.Logger
+}
+
+// ServeHTTP routes requests for the memory management endpoints.
+func (h *memoryHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == exportAPIPath && r.Method == http.MethodGet:
+		h.handleExport(w, r)
+	case r.URL.Path == importAPIPath && r.Method == http.MethodPost:
+		h.handleImport(w, r)
+	case r.URL.Path == pageIndexCachePath && r.Method == http.MethodGet:
+		h.handlePageIndexCache(w, r)
+	case r.URL.Path == shadowSummaryPath && r.Method == http.MethodGet:
+		h.handleShadowSummary(w, r)
+	case r.URL.Path == shadowPairsPath && r.Method == http.MethodGet:
+		h.handleShadowPairs(w, r)
+	case r.URL.Path == canaryPath && r.Method == http.MethodGet:
+		h.handleCanaryStatus(w, r)
+	case r.URL.Path == canaryResetPath && r.Method == http.MethodPost:
+		h.handleCanaryReset(w, r)
+	default:
+		h.writeErrorWithLogger(w, h.logFromCtx(r.Context()), http.StatusNotFound, "resource not found")
+	}
+}
+
+// handleExport returns the export document for the requested project and optional sessions.
+func (h *memoryHTTPHandler) handleExport(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), exportImportTimeout)
+	defer cancel()
+	logger := h.logFromCtx(ctx)
+
+	if h.service == nil {
+		h.writeErrorWithLogger(w, logger, http.StatusServiceUnavailable, "memory service unavailable")
+		return
+	}
+
+	authCtx, err := askuser.ParseAuthorizationFromContext(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	document, err := h.service.Export(ctx, toFilesAuth(authCtx), ExportRequest{
+		Project:    query.Get("project"),
+		SessionIDs: query["session_id"],
+	})
+	if err != nil {
+		h.writeMemoryError(w, logger, err, "export memory")
+		return
+	}
+
+	h.writeJSON(w, document)
+}
+
+// handleImport rehydrates the posted export document.
+func (h *memoryHTTPHandler) handleImport(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), exportImportTimeout)
+	defer cancel()
+	logger := h.logFromCtx(ctx)
+
+	if h.service == nil {
+		h.writeErrorWithLogger(w, logger, http.StatusServiceUnavailable, "memory service unavailable")
+		return
+	}
+
+	authCtx, err := askuser.ParseAuthorizationFromContext(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var payload ImportRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, importMaxBodyBytes)).Decode(&payload); err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	result, err := h.service.Import(ctx, toFilesAuth(authCtx), payload)
+	if err != nil {
+		h.writeMemoryError(w, logger, err, "import memory")
+		return
+	}
+
+	h.writeJSON(w, result)
+}
+
+// pageIndexCacheReporter is implemented by the pageindex plugin.
+type pageIndexCacheReporter interface {
+	CacheStats() pageindex.CacheStats
+}
+
+// handlePageIndexCache returns the pageindex response cache counters. The
+// counters are process-wide, so only operators and shadow viewers may read them.
+func (h *memoryHTTPHandler) handlePageIndexCache(w http.ResponseWriter, r *http.Request) {
+	logger := h.logFromCtx(r.Context())
+
+	if h.service == nil {
+		h.writeErrorWithLogger(w, logger, http.StatusServiceUnavailable, "memory service unavailable")
+		return
+	}
+
+	authCtx, err := askuser.ParseAuthorizationFromContext(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if !h.isOperator(authCtx.APIKeyHash) {
+		h.writeErrorWithLogger(w, logger, http.StatusForbidden, "only memory operators may read pageindex cache stats")
+		return
+	}
+
+	reporter, ok := findPlugin[pageIndexCacheReporter](h.service.fileService)
+	if !ok {
+		h.writeErrorWithLogger(w, logger, http.StatusNotFound, "pageindex plugin not configured")
+		return
+	}
+
+	h.writeJSON(w, reporter.CacheStats())
+}
+
+// shadowRecordSource is implemented by mcpplugin.ShadowPlugin.
+type shadowRecordSource interface {
+	ShadowRecordsFor(apiKeyHash string) (mcpplugin.ShadowLog, error)
+}
+
+// shadowSummaryResponse is a ShadowSummary plus how the log window was read.
+type shadowSummaryResponse struct {
+	mcpplugin.ShadowSummary
+	SkippedLines int  `json:"skipped_lines"`
+	Truncated    bool `json:"truncated"`
+}
+
+// handleShadowSummary returns latency, error-rate and overlap@k aggregates of
+// the shadow replay log visible to the caller.
+func (h *memoryHTTPHandler) handleShadowSummary(w http.ResponseWriter, r *http.Request) {
+	logger := h.logFromCtx(r.Context())
+	window, ok := h.loadShadowRecords(w, r, logger)
+	if !ok {
+		return
+	}
+	k, err := queryInt(r, "k", 0)
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, shadowSummaryResponse{
+		ShadowSummary: mcpplugin.SummarizeShadow(window.Searches, window.Mutations, k),
+		SkippedLines:  window.Skipped,
+		Truncated:     window.Truncated,
+	})
+}
+
+// handleShadowPairs pages through live/shadow search pairs, newest first.
+func (h *memoryHTTPHandler) handleShadowPairs(w http.ResponseWriter, r *http.Request) {
+	logger := h.logFromCtx(r.Context())
+	window, ok := h.loadShadowRecords(w, r, logger)
+	if !ok {
+		return
+	}
+	k, err := queryInt(r, "k", 0)
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusBadRequest, err.Error())
+		return
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := queryInt(r, "limit", shadowPairsDefault)
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit = min(max(limit, 1), shadowPairsMax)
+
+	pairs, total := mcpplugin.ShadowPairs(window.Searches, k, offset, limit)
+	h.writeJSON(w, map[string]any{
+		"total":         total,
+		"offset":        offset,
+		"limit":         limit,
+		"pairs":         pairs,
+		"skipped_lines": window.Skipped,
+		"truncated":     window.Truncated,
+	})
+}
+
+// loadShadowRecords authenticates the caller and returns the window of shadow
+// records it may see, narrowed to the optional project query parameter. It
+// writes the error response and returns false on failure.
+func (h *memoryHTTPHandler) loadShadowRecords(w http.ResponseWriter, r *http.Request, logger logSDK.Logger) (mcpplugin.ShadowLog, bool) {
+	if h.service == nil {
+		h.writeErrorWithLogger(w, logger, http.StatusServiceUnavailable, "memory service unavailable")
+		return mcpplugin.ShadowLog{}, false
+	}
+
+	authCtx, err := askuser.ParseAuthorizationFromContext(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusUnauthorized, err.Error())
+		return mcpplugin.ShadowLog{}, false
+	}
+
+	source, ok := findPlugin[shadowRecordSource](h.service.fileService)
+	if !ok {
+		h.writeErrorWithLogger(w, logger, http.StatusNotFound, "shadow replay not enabled")
+		return mcpplugin.ShadowLog{}, false
+	}
+	window, err := source.ShadowRecordsFor(authCtx.APIKeyHash)
+	if err != nil {
+		logger.Error("read shadow records", zap.Error(err))
+		h.writeErrorWithLogger(w, logger, http.StatusInternalServerError, "internal server error")
+		return mcpplugin.ShadowLog{}, false
+	}
+	if window.Skipped > 0 {
+		logger.Warn("skipped malformed shadow log lines", zap.Int("skipped", window.Skipped))
+	}
+
+	if project := r.URL.Query().Get("project"); project != "" {
+		window.Searches = slices.DeleteFunc(window.Searches, func(rec mcpplugin.SearchRecord) bool { return rec.Project != project })
+		window.Mutations = slices.DeleteFunc(window.Mutations, func(rec mcpplugin.MutationRecord) bool { return rec.Project != project })
+	}
+	return window, true
+}
+
+// canarySource is implemented by mcpplugin.Manager.
+type canarySource interface {
+	Canary() *mcpplugin.Canary
+}
+
+// handleCanaryStatus returns the canary state and its current window. The
+// status carries aggregates only, so any authenticated caller may read it.
+func (h *memoryHTTPHandler) handleCanaryStatus(w http.ResponseWriter, r *http.Request) {
+	logger := h.logFromCtx(r.Context())
+	canary, _, ok := h.loadCanary(w, r, logger)
+	if !ok {
+		return
+	}
+
+	h.writeJSON(w, canary.Status())
+}
+
+// handleCanaryReset re-arms a rolled-back canary. Only shadow viewers may
+// reset it, because a reset sends the cohort back to the candidate.
+func (h *memoryHTTPHandler) handleCanaryReset(w http.ResponseWriter, r *http.Request) {
+	logger := h.logFromCtx(r.Context())
+	canary, apiKeyHash, ok := h.loadCanary(w, r, logger)
+	if !ok {
+		return
+	}
+	viewers, ok := findPlugin[interface{ IsViewer(apiKeyHash string) bool }](h.service.fileService)
+	if !ok || !viewers.IsViewer(apiKeyHash) {
+		h.writeErrorWithLogger(w, logger, http.StatusForbidden, "only shadow viewers may reset the canary")
+		return
+	}
+
+	canary.Reset()
+	logger.Info("canary reset", zap.String("api_key_hash_prefix", apiKeyHash[:min(len(apiKeyHash), 8)]))
+	h.writeJSON(w, canary.Status())
+}
+
+// loadCanary authenticates the caller and returns the installed canary and
+// the caller's API key hash. It writes the error response and returns false
+// on failure.
+func (h *memoryHTTPHandler) loadCanary(w http.ResponseWriter, r *http.Request, logger logSDK.Logger) (*mcpplugin.Canary, string, bool) {
+	if h.service == nil {
+		h.writeErrorWithLogger(w, logger, http.StatusServiceUnavailable, "memory service unavailable")
+		return nil, "", false
+	}
+
+	authCtx, err := askuser.ParseAuthorizationFromContext(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusUnauthorized, err.Error())
+		return nil, "", false
+	}
+
+	source, ok := h.service.fileService.(canarySource)
+	if !ok || source.Canary() == nil {
+		h.writeErrorWithLogger(w, logger, http.StatusNotFound, "canary not enabled")
+		return nil, "", false
+	}
+	return source.Canary(), authCtx.APIKeyHash, true
+}
+
+// isOperator reports whether apiKeyHash is listed in operator_api_key_hashes
+// or is a shadow viewer.
+func (h *memoryHTTPHandler) isOperator(apiKeyHash string) bool {
+	if apiKeyHash == "" {
+		return false
+	}
+	if slices.ContainsFunc(h.service.settings.OperatorAPIKeyHashes, func(hash string) bool { return strings.TrimSpace(hash) == apiKeyHash }) {
+		return true
+	}
+	viewers, ok := findPlugin[interface{ IsViewer(apiKeyHash string) bool }](h.service.fileService)
+	return ok && viewers.IsViewer(apiKeyHash)
+}
+
+// findPlugin returns the first plugin implementing T: root itself, or one of
+// the plugins registered on a manager, looking through wrappers.
+func findPlugin[T any](root mcpplugin.Plugin) (T, bool) {
+	if found, ok := mcpplugin.As[T](root); ok {
+		return found, true
+	}
+	var zero T
+	manager, ok := root.(interface {
+		AvailablePlugins() []string
+		ForName(name string) (mcpplugin.Plugin, error)
+	})
+	if !ok {
+		return zero, false
+	}
+	for _, name := range manager.AvailablePlugins() {
+		item, err := manager.ForName(name)
+		if err != nil {
+			continue
+		}
+		if found, ok := mcpplugin.As[T](item); ok {
+			return found, true
+		}
+	}
+	return zero, false
+}
+
+// queryInt parses an optional non-negative integer query parameter.
+func queryInt(r *http.Request, name string, fallback int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, errors.Errorf("%s must be a non-negative integer", name)
+	}
+	return value, nil
+}
+
+// toFilesAuth maps the shared auth context to the files-package AuthContext.
+func toFilesAuth(authCtx *askuser.AuthorizationContext) files.AuthContext {
+	if authCtx == nil {
+		return files.AuthContext{}
+	}
+	return files.AuthContext{
+		APIKey:       authCtx.APIKey,
+		APIKeyHash:   authCtx.APIKeyHash,
+		UserID:       authCtx.UserID,
+		UserIdentity: authCtx.UserIdentity,
+	}
+}
+
+// writeMemoryError converts a service error to an HTTP response.
+func (h *memoryHTTPHandler) writeMemoryError(w http.ResponseWriter, logger logSDK.Logger, err error, action string) {
+	if typed, ok := AsError(err); ok {
+		status := http.StatusInternalServerError
+		switch typed.Code {
+		case ErrCodeInvalidArgument:
+			status = http.StatusBadRequest
+		case ErrCodePermissionDenied:
+			status = http.StatusUnauthorized
+		case ErrCodeResourceBusy:
+			status = http.StatusConflict
+		}
+		h.writeErrorWithLogger(w, logger, status, typed.Message)
+		return
+	}
+	if typed, ok := files.AsError(err); ok {
+		status := http.StatusInternalServerError
+		switch typed.Code {
+		case files.ErrCodeNotFound:
+			status = http.StatusNotFound
+		case files.ErrCodePermissionDenied:
+			status = http.StatusUnauthorized
+		case files.ErrCodeInvalidArgument, files.ErrCodeInvalidPath, files.ErrCodeIsDirectory, files.ErrCodeNotDirectory:
+			status = http.StatusBadRequest
+		case files.ErrCodePayloadTooLarge:
+			status = http.StatusRequestEntityTooLarge
+		case files.ErrCodeQuotaExceeded:
+			status = http.StatusInsufficientStorage
+		case files.ErrCodeResourceBusy, files.ErrCodeRateLimited:
+			status = http.StatusConflict
+		}
+		h.writeErrorWithLogger(w, logger, status, typed.Message)
+		return
+	}
+	logger.Error(action, zap.Error(err))
+	h.writeErrorWithLogger(w, logger, http.StatusInternalServerError, "internal server error")
+}
+
+// writeErrorWithLogger writes an error response with the provided logger.
+func (h *memoryHTTPHandler) writeErrorWithLogger(w http.ResponseWriter, logger logSDK.Logger, status int, message string) {
+	if status >= 500 {
+		logger.Error("memory http error", zap.Int("status", status), zap.String("message", message))
+	} else {
+		logger.Warn("memory http warning", zap.Int("status", status), zap.String("message", message))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": message}) //nolint:errchkjson // best-effort error response
+}
+
+// writeJSON writes a JSON response body.
+func (h *memoryHTTPHandler) writeJSON(w http.ResponseWriter, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(payload) //nolint:errchkjson // best-effort JSON response
+}
+
+// logFromCtx returns a context-aware logger for the handler.
+func (h *memoryHTTPHandler) logFromCtx(ctx context.Context) logSDK.Logger {
+	if logger := gmw.GetLogger(ctx); logger != nil {
+		return logger.Named("memory_http")
+	}
+	if h != nil && h.logger != nil {
+		return h.logger
+	}
+	return logSDK.Shared.Named("memory_http")
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	errors "github.com/Laisky/errors/v2"
+	sdkmemory "github.com/Laisky/go-utils/v6/agents/memory"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/library/llm"
+)
+
+// llmFactInstructions asks the model for the same mutations the SDK heuristic tool returns.
+const llmFactInstructions = "You are a memory processing assistant for a chat memory engine. " +
+	"Extract durable or actionable facts from the current turn, classify each into tier " +
+	"L0 (permanent identity or preferences), L1 (short-term daily) or L2 (medium-term weekly), " +
+	"and merge with the existing facts by preferring newer or more specific values and avoiding duplicates. " +
+	"Keep values concise and never include secrets. Reply with one JSON object only: " +
+	`{"updated_facts":[{"fact_id":"","key":"","value":"","tier":"L0|L1|L2","confidence":0.0}],"deleted_fact_ids":[]}`
+
+// LLMFactExtractor is a FactExtractor that asks a Responses-API model for fact
+// mutations. It is the upstream that heuristic replay records from.
+type LLMFactExtractor struct {
+	helper          *llm.ResponsesHelper
+	apiKey          string
+	model           string
+	maxOutputTokens int
+}
+
+// NewLLMFactExtractor builds an extractor for settings that bills apiKey.
+// httpClient may be nil. Base URLs ending in /v1 or /v1/responses are accepted.
+func NewLLMFactExtractor(settings HeuristicSettings, apiKey string, httpClient *http.Client) *LLMFactExtractor {
+	baseURL := strings.TrimRight(strings.TrimSpace(settings.BaseURL), "/")
+	baseURL = strings.TrimSuffix(strings.TrimSuffix(baseURL, "/responses"), "/v1")
+	return &LLMFactExtractor{
+		helper:          llm.NewResponsesHelper(baseURL, settings.Timeout, httpClient),
+		apiKey:          strings.TrimSpace(apiKey),
+		model:           settings.Model,
+		maxOutputTokens: settings.MaxOutputTokens,
+	}
+}
+
+// llmFactOutput is the JSON object the model replies with.
+type llmFactOutput struct {
+	UpdatedFacts []struct {
+		FactID     string  `json:"fact_id"`
+		Key        string  `json:"key"`
+		Value      string  `json:"value"`
+		Tier       string  `json:"tier"`
+		Confidence float64 `json:"confidence"`
+	} `json:"updated_facts"`
+	DeletedFactIDs []string `json:"deleted_fact_ids"`
+}
+
+// ExtractAndMergeFacts sends the turn and existing facts to the model and
+// normalizes its reply into fact upserts for the current turn.
+func (extractor *LLMFactExtractor) ExtractAndMergeFacts(ctx context.Context, in sdkmemory.HeuristicFactInput) (sdkmemory.HeuristicFactResult, error) {
+	input, err := json.Marshal(struct {
+		InputItems    []sdkmemory.ResponseItem `json:"input_items"`
+		ExistingFacts []sdkmemory.MemoryFact   `json:"existing_facts"`
+	}{in.InputItems, in.ExistingFacts})
+	if err != nil {
+		return sdkmemory.HeuristicFactResult{}, errors.Wrap(err, "encode heuristic input")
+	}
+
+	text, err := extractor.helper.CreateText(ctx, extractor.apiKey, llm.ResponseRequest{
+		Model:           extractor.model,
+		Instructions:    llmFactInstructions,
+		Input:           string(input),
+		MaxOutputTokens: extractor.maxOutputTokens,
+		Temperature:     0,
+	})
+	if err != nil {
+		return sdkmemory.HeuristicFactResult{}, errors.Wrap(err, "create heuristic response")
+	}
+
+	var output llmFactOutput
+	if err := json.Unmarshal([]byte(trimJSONFence(text)), &output); err != nil {
+		return sdkmemory.HeuristicFactResult{}, errors.Wrap(err, "decode heuristic response")
+	}
+
+	result := sdkmemory.HeuristicFactResult{DeletedFactIDs: output.DeletedFactIDs}
+	for _, fact := range output.UpdatedFacts {
+		factID := strings.TrimSpace(fact.FactID)
+		value := cleanFactValue(fact.Value)
+		if factID == "" || value == "" {
+			continue
+		}
+		tier := strings.ToUpper(strings.TrimSpace(fact.Tier))
+		if tier != ruleFactTierL0 && tier != ruleFactTierL1 && tier != ruleFactTierL2 {
+			tier = ruleFactTierL1
+		}
+		result.UpdatedFacts = append(result.UpdatedFacts, sdkmemory.MemoryFact{
+			ID:           in.TurnID + "-llm-" + factID,
+			TS:           in.NowRFC3339,
+			Type:         "fact_upsert",
+			FactID:       factID,
+			Key:          strings.TrimSpace(fact.Key),
+			Value:        value,
+			Confidence:   fact.Confidence,
+			Tier:         tier,
+			SourceTurnID: in.TurnID,
+			SourceUserID: in.UserID,
+		})
+	}
+	return result, nil
+}
+
+// trimJSONFence strips a Markdown code fence some models wrap JSON replies in.
+func trimJSONFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") {
+		return text
+	}
+	text = strings.TrimPrefix(text, "```")
+	text = strings.TrimPrefix(text, "json")
+	text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,372 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"sync"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	logSDK "github.com/Laisky/go-utils/v6/log"
+	"github.com/Laisky/zap"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	"github.com/Laisky/laisky-blog-graphql/library/log"
+)
+
+const (
+	// defaultCanaryWindow is the sliding window rollback thresholds are evaluated over.
+	defaultCanaryWindow = 10 * time.Minute
+	// defaultCanaryMinSamples is the sample count required before a rollback decision.
+	defaultCanaryMinSamples = 20
+	// defaultCanaryMaxErrorRate is the candidate error rate that triggers a rollback.
+	defaultCanaryMaxErrorRate = 0.05
+	// canaryBuckets is the cohort resolution: percentages have two decimals.
+	canaryBuckets = 10000
+	// maxCanarySamples bounds the window so busy servers keep a fixed cost.
+	maxCanarySamples = 10000
+)
+
+// Canary rollback reasons reported by CanaryStatus.
+const (
+	CanaryRollbackErrorRate = "error_rate"
+	CanaryRollbackLatency   = "p99_latency"
+)
+
+// CanaryConfig configures NewCanary.
+type CanaryConfig struct {
+	// Live is the plugin the canary cohort is taken from.
+	Live string
+	// Candidate serves the cohort's reads and searches.
+	Candidate string
+	// Percent of API keys, in (0, 100], served by Candidate.
+	Percent float64
+	// Window is the sliding window rollback thresholds are evaluated over.
+	Window time.Duration
+	// MinSamples is the number of candidate observations in Window required
+	// before a rollback decision.
+	MinSamples int
+	// MaxErrorRate rolls back when the candidate error rate exceeds it; nil
+	// uses 5% and 0 rolls back on the first failure past MinSamples.
+	MaxErrorRate *float64
+	// MaxP99Latency rolls back when the candidate p99 latency exceeds it; zero disables it.
+	MaxP99Latency time.Duration
+	Logger        logSDK.Logger
+}
+
+// canarySample is one candidate observation.
+type canarySample struct {
+	at       time.Time
+	duration time.Duration
+	failed   bool
+}
+
+// Canary serves a sticky share of API keys from a candidate plugin and rolls
+// back to the live plugin when the candidate's error rate or p99 latency
+// crosses a threshold. Observations come from the calls the canary serves
+// and, when the Canary is installed as a Recorder on the ShadowPlugin that
+// mirrors Live to Candidate, from every shadow call. A rollback lasts until
+// Reset or a restart.
+type Canary struct {
+	live          string
+	candidate     string
+	percent       float64
+	window        time.Duration
+	minSamples    int
+	maxErrorRate  float64
+	maxP99Latency time.Duration
+	logger        logSDK.Logger
+	now           func() time.Time
+
+	mu           sync.Mutex
+	samples      []canarySample
+	rollback     string
+	rolledBackAt time.Time
+}
+
+// CanaryStatus reports the canary state and the current window.
+type CanaryStatus struct {
+	Live         string     `json:"live"`
+	Candidate    string     `json:"candidate"`
+	Percent      float64    `json:"percent"`
+	Active       bool       `json:"active"`
+	Rollback     string     `json:"rollback,omitempty"`
+	RolledBackAt *time.Time `json:"rolled_back_at,omitempty"`
+	Samples      int        `json:"samples"`
+	Errors       int        `json:"errors"`
+	ErrorRate    float64    `json:"error_rate"`
+	P99Ms        float64    `json:"p99_ms"`
+}
+
+// NewCanary validates cfg and fills defaults.
+func NewCanary(cfg CanaryConfig) (*Canary, error) {
+	live := NormalizeName(cfg.Live)
+	candidate := NormalizeName(cfg.Candidate)
+	if live == "" || candidate == "" {
+		return nil, errors.New("canary live and candidate plugins are required")
+	}
+	if live == candidate {
+		return nil, errors.New("canary live and candidate plugins must differ")
+	}
+	if cfg.Percent <= 0 || cfg.Percent > 100 {
+		return nil, errors.Errorf("canary percent must be in (0, 100], got %v", cfg.Percent)
+	}
+	maxErrorRate := float64(defaultCanaryMaxErrorRate)
+	if cfg.MaxErrorRate != nil {
+		maxErrorRate = *cfg.MaxErrorRate
+	}
+	if maxErrorRate < 0 || maxErrorRate > 1 {
+		return nil, errors.Errorf("canary max_error_rate must be in [0, 1], got %v", maxErrorRate)
+	}
+	if cfg.MaxP99Latency < 0 {
+		return nil, errors.New("canary max_p99_latency must be non-negative")
+	}
+
+	c := &Canary{
+		live:          live,
+		candidate:     candidate,
+		percent:       cfg.Percent,
+		window:        cfg.Window,
+		minSamples:    cfg.MinSamples,
+		maxErrorRate:  maxErrorRate,
+		maxP99Latency: cfg.MaxP99Latency,
+		logger:        cfg.Logger,
+		now:           time.Now,
+	}
+	if c.window <= 0 {
+		c.window = defaultCanaryWindow
+	}
+	if c.minSamples <= 0 {
+		c.minSamples = defaultCanaryMinSamples
+	}
+	if c.logger == nil {
+		c.logger = log.Logger.Named("mcp_memory_canary")
+	}
+	return c, nil
+}
+
+// InCohort reports whether apiKeyHash falls in the canary share. The bucket
+// is derived from the key hash and the candidate name, so a key stays in or
+// out for the life of the canary and raising Percent only adds keys.
+// Anonymous calls are never in the cohort.
+func (c *Canary) InCohort(apiKeyHash string) bool {
+	if apiKeyHash == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(c.candidate + "\x00" + apiKeyHash))
+	bucket := binary.BigEndian.Uint64(sum[:8]) % canaryBuckets
+	return float64(bucket) < c.percent*canaryBuckets/100
+}
+
+// Serves reports whether a call from apiKeyHash goes to the candidate now.
+func (c *Canary) Serves(apiKeyHash string) bool {
+	c.mu.Lock()
+	rolledBack := c.rollback != ""
+	c.mu.Unlock()
+	return !rolledBack && c.InCohort(apiKeyHash)
+}
+
+// Observe records one candidate call and rolls back when a threshold is crossed.
+func (c *Canary) Observe(duration time.Duration, failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	samples := append(c.pruneLocked(now), canarySample{at: now, duration: duration, failed: failed})
+	if len(samples) > maxCanarySamples {
+		samples = samples[len(samples)-maxCanarySamples:]
+	}
+	c.samples = samples
+	if c.rollback != "" || len(samples) < c.minSamples {
+		return
+	}
+
+	errs := 0
+	for _, sample := range samples {
+		if sample.failed {
+			errs++
+		}
+	}
+	errorRate := float64(errs) / float64(len(samples))
+	var p99 time.Duration
+	switch {
+	case errorRate > c.maxErrorRate:
+		c.rollback = CanaryRollbackErrorRate
+	case c.maxP99Latency > 0:
+		if _, p99 = windowStats(samples); p99 <= c.maxP99Latency {
+			return
+		}
+		c.rollback = CanaryRollbackLatency
+	default:
+		return
+	}
+	c.rolledBackAt = now
+	c.logger.Warn("canary rolled back to live plugin",
+		zap.String("live", c.live),
+		zap.String("candidate", c.candidate),
+		zap.String("reason", c.rollback),
+		zap.Int("samples", len(samples)),
+		zap.Float64("error_rate", errorRate),
+		zap.Duration("p99", p99),
+	)
+}
+
+// Reset re-arms a rolled-back canary and clears its window.
+func (c *Canary) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.samples = nil
+	c.rollback = ""
+	c.rolledBackAt = time.Time{}
+}
+
+// Status returns the canary state and the statistics of the current window.
+func (c *Canary) Status() CanaryStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.samples = c.pruneLocked(c.now())
+	errs, p99 := windowStats(c.samples)
+	st := CanaryStatus{
+		Live:      c.live,
+		Candidate: c.candidate,
+		Percent:   c.percent,
+		Active:    c.rollback == "",
+		Rollback:  c.rollback,
+		Samples:   len(c.samples),
+		Errors:    errs,
+		P99Ms:     durationMs(p99),
+	}
+	if !c.rolledBackAt.IsZero() {
+		at := c.rolledBackAt
+		st.RolledBackAt = &at
+	}
+	if len(c.samples) > 0 {
+		st.ErrorRate = float64(errs) / float64(len(c.samples))
+	}
+	return st
+}
+
+// RecordSearch observes the shadow side of a search mirrored to the candidate.
+// Searches the live plugin failed, or that never reached the candidate, are skipped.
+func (c *Canary) RecordSearch(rec SearchRecord) error {
+	if NormalizeName(rec.ShadowPlugin) != c.candidate || rec.LiveErr != "" || rec.ShadowDuration <= 0 {
+		return nil
+	}
+	c.Observe(rec.ShadowDuration, rec.ShadowErr != "")
+	return nil
+}
+
+// RecordMutation observes the shadow side of a mutation. Mutation records do
+// not name their plugins, so install the Canary only on the ShadowPlugin that
+// mirrors Live to Candidate.
+func (c *Canary) RecordMutation(rec MutationRecord) error {
+	if rec.ShadowDuration <= 0 {
+		return nil
+	}
+	c.Observe(rec.ShadowDuration, rec.ShadowErr != "")
+	return nil
+}
+
+// Close is a no-op; the Canary holds no resources.
+func (c *Canary) Close() error { return nil }
+
+// pruneLocked drops samples older than the window. Callers hold c.mu.
+func (c *Canary) pruneLocked(now time.Time) []canarySample {
+	cutoff := now.Add(-c.window)
+	idx := sort.Search(len(c.samples), func(i int) bool { return c.samples[i].at.After(cutoff) })
+	return c.samples[idx:]
+}
+
+// windowStats returns the error count and nearest-rank p99 of samples.
+func windowStats(samples []canarySample) (int, time.Duration) {
+	if len(samples) == 0 {
+		return 0, 0
+	}
+	errs := 0
+	durations := make([]time.Duration, 0, len(samples))
+	for _, s := range samples {
+		if s.failed {
+			errs++
+		}
+		durations = append(durations, s.duration)
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	idx := (len(durations)*99+99)/100 - 1
+	return errs, durations[min(max(idx, 0), len(durations)-1)]
+}
+
+// syncMirrorKey marks a mutation whose shadow copy must land before the call returns.
+type syncMirrorKey struct{}
+
+// withSyncMirror asks a ShadowPlugin handling the mutation in ctx to apply its
+// shadow copy synchronously. The manager sets it for canary cohort callers,
+// whose reads are served by the shadow side.
+func withSyncMirror(ctx context.Context) context.Context {
+	return context.WithValue(ctx, syncMirrorKey{}, true)
+}
+
+// syncMirror reports whether ctx was marked by withSyncMirror.
+func syncMirror(ctx context.Context) bool {
+	marked, _ := ctx.Value(syncMirrorKey{}).(bool)
+	return marked
+}
+
+// canaryFailure reports whether err counts against the candidate. Typed
+// caller errors such as NOT_FOUND or INVALID_ARGUMENT and cancellations are
+// the caller's doing, not the candidate's.
+func canaryFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if typed, ok := files.AsError(err); ok {
+		return typed.Code == files.ErrCodeSearchBackend
+	}
+	return true
+}
+
+// canaryPlugin is the candidate as handed out for a canary call; it times
+// every call and feeds the result back to the Canary.
+type canaryPlugin struct {
+	Plugin
+	canary *Canary
+}
+
+// Unwrap returns the candidate plugin so callers can reach its optional interfaces.
+func (p *canaryPlugin) Unwrap() Plugin { return p.Plugin }
+
+func (p *canaryPlugin) observe(start time.Time, err error) {
+	p.canary.Observe(time.Since(start), canaryFailure(err))
+}
+
+// Stat forwards to the candidate and observes the call.
+func (p *canaryPlugin) Stat(ctx context.Context, auth files.AuthContext, project, path string) (files.StatResult, error) {
+	start := time.Now()
+	res, err := p.Plugin.Stat(ctx, auth, project, path)
+	p.observe(start, err)
+	return res, err
+}
+
+// Read forwards to the candidate and observes the call.
+func (p *canaryPlugin) Read(ctx context.Context, auth files.AuthContext, project, path string, offset, length int64) (files.ReadResult, error) {
+	start := time.Now()
+	res, err := p.Plugin.Read(ctx, auth, project, path, offset, length)
+	p.observe(start, err)
+	return res, err
+}
+
+// List forwards to the candidate and observes the call.
+func (p *canaryPlugin) List(ctx context.Context, auth files.AuthContext, project, path string, depth, limit int) (files.ListResult, error) {
+	start := time.Now()
+	res, err := p.Plugin.List(ctx, auth, project, path, depth, limit)
+	p.observe(start, err)
+	return res, err
+}
+
+// Search forwards to the candidate and observes the call.
+func (p *canaryPlugin) Search(ctx context.Context, auth files.AuthContext, project, query, pathPrefix string, limit int) (files.SearchResult, error) {
+	start := time.Now()
+	res, err := p.Plugin.Search(ctx, auth, project, query, pathPrefix, limit)
+	p.observe(start, err)
+	return res, err
+}
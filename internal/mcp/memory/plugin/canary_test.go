@@ -0,0 +1,218 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+)
+
+// TestCanaryCohortIsStickyAndProportional checks a key's cohort never flips
+// and the cohort size tracks the configured percentage.
+func TestCanaryCohortIsStickyAndProportional(t *testing.T) {
+	t.Parallel()
+
+	canary, err := NewCanary(CanaryConfig{Live: "live", Candidate: "cand", Percent: 20})
+	require.NoError(t, err)
+	wider, err := NewCanary(CanaryConfig{Live: "live", Candidate: "cand", Percent: 50})
+	require.NoError(t, err)
+
+	in := 0
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		member := canary.InCohort(key)
+		require.Equal(t, member, canary.InCohort(key))
+		if member {
+			in++
+			require.True(t, wider.InCohort(key), "raising the percentage must keep existing members")
+		}
+	}
+	require.InDelta(t, 0.20, float64(in)/5000, 0.03)
+	require.False(t, canary.InCohort(""))
+
+	_, err = NewCanary(CanaryConfig{Live: "live", Candidate: "live", Percent: 10})
+	require.Error(t, err)
+	_, err = NewCanary(CanaryConfig{Live: "live", Candidate: "cand", Percent: 0})
+	require.Error(t, err)
+}
+
+// rate returns a pointer for CanaryConfig.MaxErrorRate.
+func rate(v float64) *float64 { return &v }
+
+// TestCanaryMaxErrorRateZeroIsKept checks an explicit 0 rolls back on the
+// first candidate failure while an unset rate falls back to the default.
+func TestCanaryMaxErrorRateZeroIsKept(t *testing.T) {
+	t.Parallel()
+
+	strict, err := NewCanary(CanaryConfig{Live: "live", Candidate: "cand", Percent: 100, MinSamples: 2, MaxErrorRate: rate(0)})
+	require.NoError(t, err)
+	require.Zero(t, strict.maxErrorRate)
+	strict.Observe(time.Millisecond, false)
+	require.True(t, strict.Serves("k"))
+	strict.Observe(time.Millisecond, true)
+	require.False(t, strict.Serves("k"))
+	require.Equal(t, CanaryRollbackErrorRate, strict.Status().Rollback)
+
+	lenient, err := NewCanary(CanaryConfig{Live: "live", Candidate: "cand", Percent: 100})
+	require.NoError(t, err)
+	require.Equal(t, defaultCanaryMaxErrorRate, lenient.maxErrorRate)
+
+	_, err = NewCanary(CanaryConfig{Live: "live", Candidate: "cand", Percent: 100, MaxErrorRate: rate(1.5)})
+	require.Error(t, err)
+}
+
+// TestManagerCanaryMirrorsCohortWritesSynchronously checks a cohort caller's
+// write reaches the candidate before Write returns, so the cohort's next read
+// from the candidate sees it.
+func TestManagerCanaryMirrorsCohortWritesSynchronously(t *testing.T) {
+	t.Parallel()
+
+	live := newFake("live")
+	cand := newFake("cand")
+	wrapper, err := NewShadowPlugin(ShadowConfig{Name: "live", Live: live, Shadow: cand, Recorder: NewMemRecorder()})
+	require.NoError(t, err)
+	mgr, err := NewManager("live", wrapper, cand)
+	require.NoError(t, err)
+	canary, err := NewCanary(CanaryConfig{Live: "live", Candidate: "cand", Percent: 100})
+	require.NoError(t, err)
+	require.NoError(t, mgr.SetCanary(canary))
+
+	ctx := context.Background()
+	member := files.AuthContext{APIKeyHash: "member"}
+	_, err = mgr.Write(ctx, member, "p", "/a", "x", "utf8", 0, files.WriteModeOverwrite)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&cand.writeCount))
+	_, err = mgr.Delete(ctx, member, "p", "/a", false)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&cand.deleteCount))
+
+	// Callers outside the cohort keep the fire-and-forget mirror.
+	_, err = mgr.Write(ctx, files.AuthContext{}, "p", "/b", "x", "utf8", 0, files.WriteModeOverwrite)
+	require.NoError(t, err)
+	require.NoError(t, wrapper.Stop(ctx))
+	require.Equal(t, int32(2), atomic.LoadInt32(&cand.writeCount))
+	require.Equal(t, int32(2), atomic.LoadInt32(&live.writeCount))
+}
+
+// TestManagerCanaryRouting checks the cohort's reads go to the candidate while
+// mutations, overrides and other callers stay on the live plugin.
+func TestManagerCanaryRouting(t *testing.T) {
+	t.Parallel()
+
+	live := newFake("live")
+	cand := newFake("cand")
+	mgr, err := NewManager("live", live, cand)
+	require.NoError(t, err)
+
+	canary, err := NewCanary(CanaryConfig{Live: "live", Candidate: "cand", Percent: 100})
+	require.NoError(t, err)
+	require.Error(t, mgr.SetCanary(&Canary{live: "live", candidate: "missing"}))
+	require.NoError(t, mgr.SetCanary(canary))
+	require.Same(t, canary, mgr.Canary())
+
+	ctx := context.Background()
+	member := files.AuthContext{APIKeyHash: "member"}
+
+	resolved, err := mgr.Resolve(ctx, member, "p", "")
+	require.NoError(t, err)
+	require.Equal(t, "cand", resolved.Name())
+
+	resolved, err = mgr.Resolve(ctx, files.AuthContext{}, "p", "")
+	require.NoError(t, err)
+	require.Equal(t, "live", resolved.Name())
+
+	resolved, err = mgr.Resolve(ctx, member, "p", "live")
+	require.NoError(t, err)
+	require.Equal(t, "live", resolved.Name())
+
+	_, err = mgr.Search(ctx, member, "p", "q", "", 5)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), cand.searchCount)
+	require.Equal(t, int32(0), live.searchCount)
+	require.Equal(t, 1, canary.Status().Samples)
+
+	_, err = mgr.Write(ctx, member, "p", "/a", "x", "utf8", 0, files.WriteModeOverwrite)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), live.writeCount)
+	require.Equal(t, int32(0), cand.writeCount)
+
+	require.NoError(t, mgr.SetCanary(nil))
+	resolved, err = mgr.Resolve(ctx, member, "p", "")
+	require.NoError(t, err)
+	require.Equal(t, "live", resolved.Name())
+}
+
+// TestCanaryRollsBackOnErrorRate checks shadow records trip the error-rate
+// threshold only once enough samples are in the window.
+func TestCanaryRollsBackOnErrorRate(t *testing.T) {
+	t.Parallel()
+
+	canary, err := NewCanary(CanaryConfig{Live: "live", Candidate: "cand", Percent: 100, MinSamples: 10, MaxErrorRate: rate(0.2)})
+	require.NoError(t, err)
+	for i := 0; i < 9; i++ {
+		require.NoError(t, canary.RecordSearch(SearchRecord{ShadowPlugin: "cand", ShadowDuration: time.Millisecond, ShadowErr: "boom"}))
+	}
+	require.True(t, canary.Serves("k"), "below MinSamples must not roll back")
+
+	// Records for other plugins, or where live failed too, are ignored.
+	require.NoError(t, canary.RecordSearch(SearchRecord{ShadowPlugin: "other", ShadowDuration: time.Millisecond, ShadowErr: "boom"}))
+	require.NoError(t, canary.RecordSearch(SearchRecord{ShadowPlugin: "cand", ShadowDuration: time.Millisecond, LiveErr: "x", ShadowErr: "boom"}))
+	require.True(t, canary.Serves("k"))
+
+	require.NoError(t, canary.RecordMutation(MutationRecord{ShadowDuration: time.Millisecond, ShadowErr: "boom"}))
+	require.False(t, canary.Serves("k"))
+	st := canary.Status()
+	require.False(t, st.Active)
+	require.Equal(t, CanaryRollbackErrorRate, st.Rollback)
+	require.NotNil(t, st.RolledBackAt)
+	require.Equal(t, 10, st.Errors)
+
+	canary.Reset()
+	require.True(t, canary.Serves("k"))
+	require.Zero(t, canary.Status().Samples)
+}
+
+// TestCanaryRollsBackOnLatency checks the p99 threshold and window expiry.
+func TestCanaryRollsBackOnLatency(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	canary, err := NewCanary(CanaryConfig{
+		Live: "live", Candidate: "cand", Percent: 100,
+		Window: time.Minute, MinSamples: 5, MaxErrorRate: rate(1), MaxP99Latency: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	canary.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		canary.Observe(time.Second, false)
+	}
+	// The slow samples age out before the window fills.
+	now = now.Add(2 * time.Minute)
+	for i := 0; i < 5; i++ {
+		canary.Observe(10*time.Millisecond, false)
+	}
+	require.True(t, canary.Serves("k"))
+	require.Equal(t, 5, canary.Status().Samples)
+
+	canary.Observe(time.Second, false)
+	require.False(t, canary.Serves("k"))
+	require.Equal(t, CanaryRollbackLatency, canary.Status().Rollback)
+}
+
+// TestCanaryFailureClassification checks caller errors do not count against
+// the candidate.
+func TestCanaryFailureClassification(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, canaryFailure(nil))
+	require.False(t, canaryFailure(context.Canceled))
+	require.False(t, canaryFailure(files.NewError(files.ErrCodeNotFound, "missing", false)))
+	require.True(t, canaryFailure(files.NewError(files.ErrCodeSearchBackend, "down", true)))
+	require.True(t, canaryFailure(fmt.Errorf("boom")))
+}
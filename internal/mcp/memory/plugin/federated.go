@@ -0,0 +1,337 @@
+package plugin
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	gconfig "github.com/Laisky/go-config/v2"
+	logSDK "github.com/Laisky/go-utils/v6/log"
+	"github.com/Laisky/zap"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	"github.com/Laisky/laisky-blog-graphql/library/log"
+)
+
+const (
+	// DefaultPluginFederated names the fan-out search plugin registered when federation is enabled.
+	DefaultPluginFederated = "federated"
+
+	// SearchModeFederated represents fan-out retrieval merged across several plugins.
+	SearchModeFederated SearchMode = "federated"
+
+	// defaultFederatedTimeout bounds each member plugin's search call.
+	defaultFederatedTimeout = 10 * time.Second
+
+	// federatedOverlapRatio is the minimum overlap, relative to the shorter span,
+	// for two hits on the same file to be merged into one.
+	federatedOverlapRatio = 0.5
+)
+
+// FederatedSettings captures the optional fan-out search block.
+type FederatedSettings struct {
+	Enabled bool
+	// Plugins are the member plugin names; the first one also serves non-search operations.
+	Plugins []string
+	// Timeout bounds each member plugin's search call.
+	Timeout time.Duration
+}
+
+// LoadFederatedSettingsFromConfig reads settings.mcp.tools.memory.federated.
+func LoadFederatedSettingsFromConfig() FederatedSettings {
+	const prefix = "settings.mcp.tools.memory.federated"
+	names := gconfig.S.GetStringSlice(prefix + ".plugins")
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = NormalizeName(name); name != "" {
+			normalized = append(normalized, name)
+		}
+	}
+
+	return FederatedSettings{
+		Enabled: gconfig.S.GetBool(prefix + ".enabled"),
+		Plugins: normalized,
+		Timeout: gconfig.S.GetDuration(prefix + ".timeout"),
+	}
+}
+
+// Validate checks that an enabled FederatedSettings names at least two distinct plugins.
+func (s FederatedSettings) Validate() error {
+	if !s.Enabled {
+		return nil
+	}
+	if len(s.Plugins) < 2 {
+		return errors.New("federated search requires at least two plugins")
+	}
+	seen := make(map[string]struct{}, len(s.Plugins))
+	for _, name := range s.Plugins {
+		if name == DefaultPluginFederated {
+			return errors.New("federated plugin cannot include itself")
+		}
+		if _, dup := seen[name]; dup {
+			return errors.Errorf("duplicate federated plugin %q", name)
+		}
+		seen[name] = struct{}{}
+	}
+	return nil
+}
+
+// FederatedConfig wires member plugins into a FederatedPlugin.
+type FederatedConfig struct {
+	// Members are queried concurrently; Members[0] serves every non-search operation.
+	Members []Plugin
+	Timeout time.Duration
+	Logger  logSDK.Logger
+}
+
+// FederatedPlugin fans Search out to several member plugins, normalizes each
+// member's scores to [0, 1], and merges overlapping hits. Every other
+// operation is forwarded to the primary member. Members are owned by the
+// Manager, so Start and Stop are no-ops here.
+type FederatedPlugin struct {
+	members []Plugin
+	timeout time.Duration
+	logger  logSDK.Logger
+}
+
+// NewFederatedPlugin validates the config and returns a fan-out wrapper.
+func NewFederatedPlugin(cfg FederatedConfig) (*FederatedPlugin, error) {
+	if len(cfg.Members) < 2 {
+		return nil, errors.New("at least two member plugins are required")
+	}
+	for _, member := range cfg.Members {
+		if member == nil {
+			return nil, errors.New("member plugin is nil")
+		}
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultFederatedTimeout
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = log.Logger.Named("mcp_memory_federated")
+	}
+
+	return &FederatedPlugin{
+		members: append([]Plugin(nil), cfg.Members...),
+		timeout: timeout,
+		logger:  logger,
+	}, nil
+}
+
+// Name returns the federated plugin name.
+func (f *FederatedPlugin) Name() string { return DefaultPluginFederated }
+
+// Capabilities reports the primary member's contract with the federated mode
+// prepended to the union of member search modes.
+func (f *FederatedPlugin) Capabilities() Capabilities {
+	caps := f.members[0].Capabilities()
+	modes := []SearchMode{SearchModeFederated}
+	seen := map[SearchMode]struct{}{SearchModeFederated: {}}
+	for _, member := range f.members {
+		for _, mode := range member.Capabilities().SearchModes {
+			if _, ok := seen[mode]; ok {
+				continue
+			}
+			seen[mode] = struct{}{}
+			modes = append(modes, mode)
+		}
+	}
+	caps.SearchModes = modes
+	return caps
+}
+
+// Start is a no-op; member lifecycles are driven by the Manager.
+func (f *FederatedPlugin) Start(context.Context) error { return nil }
+
+// Stop is a no-op; member lifecycles are driven by the Manager.
+func (f *FederatedPlugin) Stop(context.Context) error { return nil }
+
+// Stat forwards to the primary member.
+func (f *FederatedPlugin) Stat(ctx context.Context, auth files.AuthContext, project, path string) (files.StatResult, error) {
+	return f.members[0].Stat(ctx, auth, project, path)
+}
+
+// Read forwards to the primary member.
+func (f *FederatedPlugin) Read(ctx context.Context, auth files.AuthContext, project, path string, offset, length int64) (files.ReadResult, error) {
+	return f.members[0].Read(ctx, auth, project, path, offset, length)
+}
+
+// Write forwards to the primary member.
+func (f *FederatedPlugin) Write(ctx context.Context, auth files.AuthContext, project, path, content, contentEncoding string, offset int64, mode files.WriteMode) (files.WriteResult, error) {
+	return f.members[0].Write(ctx, auth, project, path, content, contentEncoding, offset, mode)
+}
+
+// Delete forwards to the primary member.
+func (f *FederatedPlugin) Delete(ctx context.Context, auth files.AuthContext, project, path string, recursive bool) (files.DeleteResult, error) {
+	return f.members[0].Delete(ctx, auth, project, path, recursive)
+}
+
+// Rename forwards to the primary member.
+func (f *FederatedPlugin) Rename(ctx context.Context, auth files.AuthContext, project, fromPath, toPath string, overwrite bool) (files.RenameResult, error) {
+	return f.members[0].Rename(ctx, auth, project, fromPath, toPath, overwrite)
+}
+
+// List forwards to the primary member.
+func (f *FederatedPlugin) List(ctx context.Context, auth files.AuthContext, project, path string, depth, limit int) (files.ListResult, error) {
+	return f.members[0].List(ctx, auth, project, path, depth, limit)
+}
+
+// Search queries every member concurrently and merges the results. Members
+// that fail or time out are skipped; an error is returned only when every
+// member fails.
+func (f *FederatedPlugin) Search(ctx context.Context, auth files.AuthContext, project, query, pathPrefix string, limit int) (files.SearchResult, error) {
+	type memberResult struct {
+		name   string
+		chunks []files.ChunkEntry
+		err    error
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	// The channel is buffered so members that ignore cancellation never block on send.
+	resultCh := make(chan memberResult, len(f.members))
+	for _, member := range f.members {
+		go func(member Plugin) {
+			result, err := member.Search(callCtx, auth, project, query, pathPrefix, limit)
+			resultCh <- memberResult{name: NormalizeName(member.Name()), chunks: result.Chunks, err: err}
+		}(member)
+	}
+
+	var firstErr error
+	failed := 0
+	collected := make([]files.ChunkEntry, 0, max(limit, 0)*len(f.members))
+collect:
+	for pending := len(f.members); pending > 0; pending-- {
+		var res memberResult
+		select {
+		case res = <-resultCh:
+		case <-callCtx.Done():
+			f.logger.Warn("federated search timed out",
+				zap.Int("pending_plugins", pending), zap.Duration("timeout", f.timeout))
+			if firstErr == nil {
+				firstErr = errors.Wrap(callCtx.Err(), "federated search")
+			}
+			failed += pending
+			break collect
+		}
+
+		if res.err != nil {
+			f.logger.Warn("federated member search failed",
+				zap.String("plugin", res.name), zap.Error(res.err))
+			if firstErr == nil {
+				firstErr = errors.Wrapf(res.err, "search plugin %s", res.name)
+			}
+			failed++
+			continue
+		}
+		collected = append(collected, normalizeChunkScores(res.name, res.chunks)...)
+	}
+	if failed == len(f.members) {
+		return files.SearchResult{}, firstErr
+	}
+
+	merged := mergeFederatedChunks(collected)
+	if limit > 0 && len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return files.SearchResult{Chunks: merged}, nil
+}
+
+// normalizeChunkScores min-max scales one member's scores to [0, 1] and tags
+// each hit with the member name. A member whose hits share one score, such as
+// a single hit, has no range to scale, so its raw score is kept and clamped to
+// [0, 1] rather than promoted to the top.
+func normalizeChunkScores(pluginName string, chunks []files.ChunkEntry) []files.ChunkEntry {
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	minScore, maxScore := chunks[0].Score, chunks[0].Score
+	for _, chunk := range chunks[1:] {
+		if chunk.Score < minScore {
+			minScore = chunk.Score
+		}
+		if chunk.Score > maxScore {
+			maxScore = chunk.Score
+		}
+	}
+
+	out := make([]files.ChunkEntry, len(chunks))
+	for i, chunk := range chunks {
+		if maxScore > minScore {
+			chunk.Score = (chunk.Score - minScore) / (maxScore - minScore)
+		} else {
+			chunk.Score = min(max(chunk.Score, 0), 1)
+		}
+		chunk.Plugin = pluginName
+		out[i] = chunk
+	}
+	return out
+}
+
+// mergeFederatedChunks deduplicates hits on overlapping byte ranges of the
+// same file, keeping the higher-scored content and recording every
+// contributing plugin, then sorts by score descending.
+func mergeFederatedChunks(chunks []files.ChunkEntry) []files.ChunkEntry {
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].Score > chunks[j].Score })
+
+	merged := make([]files.ChunkEntry, 0, len(chunks))
+	for _, chunk := range chunks {
+		dup := -1
+		for i := range merged {
+			if chunksOverlap(merged[i], chunk) {
+				dup = i
+				break
+			}
+		}
+		if dup < 0 {
+			merged = append(merged, chunk)
+			continue
+		}
+		merged[dup].Plugin = joinPluginNames(merged[dup].Plugin, chunk.Plugin)
+	}
+	return merged
+}
+
+// chunksOverlap reports whether two hits cover substantially the same span of one file.
+func chunksOverlap(a, b files.ChunkEntry) bool {
+	if a.Project != b.Project || a.FilePath != b.FilePath {
+		return false
+	}
+	if a.IsFullFile || b.IsFullFile {
+		return true
+	}
+
+	start := max(a.FileSeekStartBytes, b.FileSeekStartBytes)
+	end := min(a.FileSeekEndBytes, b.FileSeekEndBytes)
+	if end <= start {
+		return a.FileSeekStartBytes == b.FileSeekStartBytes && a.FileSeekEndBytes == b.FileSeekEndBytes
+	}
+	shorter := min(a.FileSeekEndBytes-a.FileSeekStartBytes, b.FileSeekEndBytes-b.FileSeekStartBytes)
+	if shorter <= 0 {
+		return true
+	}
+	return float64(end-start)/float64(shorter) >= federatedOverlapRatio
+}
+
+// joinPluginNames merges comma-separated plugin name lists in sorted order.
+func joinPluginNames(a, b string) string {
+	set := map[string]struct{}{}
+	for _, part := range strings.Split(a+","+b, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			set[part] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,149 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Laisky/errors/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+)
+
+// searchStubPlugin returns canned search results after an optional delay.
+type searchStubPlugin struct {
+	testPlugin
+	modes  []SearchMode
+	chunks []files.ChunkEntry
+	err    error
+	delay  time.Duration
+}
+
+// Capabilities returns the configured search modes.
+func (p *searchStubPlugin) Capabilities() Capabilities {
+	return Capabilities{SearchModes: p.modes}
+}
+
+// Search returns the canned result, honoring cancellation during the delay.
+func (p *searchStubPlugin) Search(ctx context.Context, _ files.AuthContext, _, _, _ string, _ int) (files.SearchResult, error) {
+	if p.delay > 0 {
+		select {
+		case <-time.After(p.delay):
+		case <-ctx.Done():
+			return files.SearchResult{}, ctx.Err()
+		}
+	}
+	return files.SearchResult{Chunks: p.chunks}, p.err
+}
+
+// TestFederatedPluginSearchMerges verifies normalization, overlap merging and contributor tagging.
+func TestFederatedPluginSearchMerges(t *testing.T) {
+	t.Parallel()
+
+	ragPlugin := &searchStubPlugin{
+		testPlugin: testPlugin{name: DefaultPluginRAG},
+		modes:      []SearchMode{SearchModeHybrid},
+		chunks: []files.ChunkEntry{
+			{FilePath: "/a.md", FileSeekStartBytes: 0, FileSeekEndBytes: 100, ChunkContent: "rag-a", Score: 0.9},
+			{FilePath: "/b.md", FileSeekStartBytes: 0, FileSeekEndBytes: 50, ChunkContent: "rag-b", Score: 0.3},
+		},
+	}
+	pageindexPlugin := &searchStubPlugin{
+		testPlugin: testPlugin{name: DefaultPluginPageIndex},
+		modes:      []SearchMode{SearchModeTreeReasoning},
+		chunks: []files.ChunkEntry{
+			{FilePath: "/a.md", FileSeekStartBytes: 10, FileSeekEndBytes: 90, ChunkContent: "pi-a", Score: 42},
+			{FilePath: "/c.pdf", FileSeekStartBytes: 0, FileSeekEndBytes: 10, ChunkContent: "pi-c", Score: 7},
+		},
+	}
+
+	federated, err := NewFederatedPlugin(FederatedConfig{Members: []Plugin{ragPlugin, pageindexPlugin}})
+	require.NoError(t, err)
+	require.Equal(t, []SearchMode{SearchModeFederated, SearchModeHybrid, SearchModeTreeReasoning}, federated.Capabilities().SearchModes)
+
+	result, err := federated.Search(context.Background(), files.AuthContext{}, "demo", "q", "", 10)
+	require.NoError(t, err)
+	require.Len(t, result.Chunks, 3)
+
+	byPath := map[string]files.ChunkEntry{}
+	for _, chunk := range result.Chunks {
+		byPath[chunk.FilePath] = chunk
+	}
+	require.Equal(t, "pageindex,rag", byPath["/a.md"].Plugin)
+	require.InDelta(t, 1.0, byPath["/a.md"].Score, 1e-9)
+	require.Equal(t, DefaultPluginRAG, byPath["/b.md"].Plugin)
+	require.InDelta(t, 0.0, byPath["/b.md"].Score, 1e-9)
+	require.Equal(t, DefaultPluginPageIndex, byPath["/c.pdf"].Plugin)
+
+	limited, err := federated.Search(context.Background(), files.AuthContext{}, "demo", "q", "", 1)
+	require.NoError(t, err)
+	require.Len(t, limited.Chunks, 1)
+}
+
+// TestFederatedPluginSingleHitKeepsRawScore verifies a lone weak hit is not promoted above better hits.
+func TestFederatedPluginSingleHitKeepsRawScore(t *testing.T) {
+	t.Parallel()
+
+	ragPlugin := &searchStubPlugin{
+		testPlugin: testPlugin{name: DefaultPluginRAG},
+		chunks: []files.ChunkEntry{
+			{FilePath: "/a.md", FileSeekEndBytes: 10, Score: 0.9},
+			{FilePath: "/b.md", FileSeekEndBytes: 10, Score: 0.5},
+			{FilePath: "/c.md", FileSeekEndBytes: 10, Score: 0.1},
+		},
+	}
+	pageindexPlugin := &searchStubPlugin{
+		testPlugin: testPlugin{name: DefaultPluginPageIndex},
+		chunks:     []files.ChunkEntry{{FilePath: "/weak.pdf", FileSeekEndBytes: 10, Score: 0.2}},
+	}
+
+	federated, err := NewFederatedPlugin(FederatedConfig{Members: []Plugin{ragPlugin, pageindexPlugin}})
+	require.NoError(t, err)
+
+	result, err := federated.Search(context.Background(), files.AuthContext{}, "demo", "q", "", 10)
+	require.NoError(t, err)
+	require.Len(t, result.Chunks, 4)
+	require.Equal(t, "/a.md", result.Chunks[0].FilePath)
+	require.Equal(t, "/weak.pdf", result.Chunks[2].FilePath)
+	require.InDelta(t, 0.2, result.Chunks[2].Score, 1e-9)
+
+	require.InDelta(t, 1.0, normalizeChunkScores("x", []files.ChunkEntry{{Score: 42}})[0].Score, 1e-9)
+	require.InDelta(t, 0.0, normalizeChunkScores("x", []files.ChunkEntry{{Score: -3}})[0].Score, 1e-9)
+}
+
+// TestFederatedPluginSearchTolerance verifies slow or failing members are skipped until all fail.
+func TestFederatedPluginSearchTolerance(t *testing.T) {
+	t.Parallel()
+
+	fast := &searchStubPlugin{
+		testPlugin: testPlugin{name: DefaultPluginRAG},
+		chunks:     []files.ChunkEntry{{FilePath: "/a.md", FileSeekEndBytes: 10, Score: 1}},
+	}
+	slow := &searchStubPlugin{testPlugin: testPlugin{name: DefaultPluginPageIndex}, delay: time.Second}
+	broken := &searchStubPlugin{testPlugin: testPlugin{name: "broken"}, err: errors.New("boom")}
+
+	federated, err := NewFederatedPlugin(FederatedConfig{Members: []Plugin{fast, slow, broken}, Timeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+
+	result, err := federated.Search(context.Background(), files.AuthContext{}, "demo", "q", "", 5)
+	require.NoError(t, err)
+	require.Len(t, result.Chunks, 1)
+	require.Equal(t, DefaultPluginRAG, result.Chunks[0].Plugin)
+
+	allBad, err := NewFederatedPlugin(FederatedConfig{Members: []Plugin{slow, broken}, Timeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+	_, err = allBad.Search(context.Background(), files.AuthContext{}, "demo", "q", "", 5)
+	require.Error(t, err)
+}
+
+// TestFederatedSettingsValidate verifies member list validation.
+func TestFederatedSettingsValidate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, FederatedSettings{}.Validate())
+	require.NoError(t, FederatedSettings{Enabled: true, Plugins: []string{DefaultPluginRAG, DefaultPluginPageIndex}}.Validate())
+	require.Error(t, FederatedSettings{Enabled: true, Plugins: []string{DefaultPluginRAG}}.Validate())
+	require.Error(t, FederatedSettings{Enabled: true, Plugins: []string{DefaultPluginRAG, DefaultPluginRAG}}.Validate())
+	require.Error(t, FederatedSettings{Enabled: true, Plugins: []string{DefaultPluginRAG, DefaultPluginFederated}}.Validate())
+}
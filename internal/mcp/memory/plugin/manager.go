@@ -13,6 +13,8 @@ import (
 type Manager struct {
 	plugins       map[string]Plugin
 	defaultPlugin string
+	routes        []RouteRule
+	canary        *Canary
 }
 
 // NewManager constructs a plugin manager with a validated default plugin.
@@ -102,28 +104,201 @@ func (m *Manager) AvailablePlugins() []string {
 	return names
 }
 
-// Resolve selects a plugin using the per-call override or the configured default.
-func (m *Manager) Resolve(ctx context.Context, _ files.AuthContext, _ string, override string) (Plugin, error) {
+// SetRoutes installs routing rules evaluated before the default plugin.
+// It must be called before the manager starts serving calls.
+func (m *Manager) SetRoutes(rules []RouteRule) error {
+	if m == nil {
+		return errors.New("plugin manager is nil")
+	}
+
+	routes := make([]RouteRule, 0, len(rules))
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return errors.Wrapf(err, "route %d", i)
+		}
+		rule.Plugin = NormalizeName(rule.Plugin)
+		if _, exists := m.plugins[rule.Plugin]; !exists {
+			return errors.Errorf("route %d: plugin %q is not registered", i, rule.Plugin)
+		}
+		routes = append(routes, rule)
+	}
+
+	m.routes = routes
+	return nil
+}
+
+// SetCanary installs a canary that serves part of the live plugin's
+// reads and searches from its candidate. It must be called before the
+// manager starts serving calls.
+func (m *Manager) SetCanary(canary *Canary) error {
+	if m == nil {
+		return errors.New("plugin manager is nil")
+	}
+	if canary == nil {
+		m.canary = nil
+		return nil
+	}
+	for _, name := range []string{canary.live, canary.candidate} {
+		if _, exists := m.plugins[name]; !exists {
+			return errors.Errorf("canary plugin %q is not registered", name)
+		}
+	}
+
+	m.canary = canary
+	return nil
+}
+
+// Canary returns the installed canary, or nil.
+func (m *Manager) Canary() *Canary {
+	if m == nil {
+		return nil
+	}
+	return m.canary
+}
+
+// Resolve selects a plugin for a project-scoped call using the per-call
+// override, then the routing rules, then the configured default.
+func (m *Manager) Resolve(ctx context.Context, auth files.AuthContext, project string, override string) (Plugin, error) {
+	return m.ResolveFor(ctx, auth, RouteRequest{Project: project, SizeBytes: -1}, override)
+}
+
+// ResolveFor selects a plugin for one call: an explicit override wins, then
+// the first matching routing rule, then the configured default. A read routed
+// to the canary's live plugin goes to its candidate when the caller is in the
+// canary cohort; explicit overrides and mutations never do.
+func (m *Manager) ResolveFor(ctx context.Context, auth files.AuthContext, req RouteRequest, override string) (Plugin, error) {
 	if m == nil {
 		return nil, errors.New("plugin manager is nil")
 	}
 
+	if requested := m.pinned(ctx, override); requested != "" {
+		return m.ForName(requested)
+	}
+	return m.routed(auth, req, m.route(req))
+}
+
+// pinned returns the explicitly requested plugin name, or "" when the call
+// should be resolved by routing.
+func (m *Manager) pinned(ctx context.Context, override string) string {
 	requested := NormalizeName(override)
 	if requested == "" {
 		requested = OverrideFromContext(ctx)
 	}
-	if requested == "" || requested == DefaultPluginAuto {
-		requested = m.defaultPlugin
+	if requested == DefaultPluginAuto {
+		return ""
 	}
+	return requested
+}
 
-	item, exists := m.plugins[requested]
+// routed returns the plugin chosen by routing, serving cohort reads of the
+// canary's live plugin from its candidate.
+func (m *Manager) routed(auth files.AuthContext, req RouteRequest, name string) (Plugin, error) {
+	item, exists := m.plugins[name]
 	if !exists {
-		return nil, &ResolveError{Requested: requested, Available: m.AvailablePlugins()}
+		return nil, &ResolveError{Requested: name, Available: m.AvailablePlugins()}
+	}
+
+	if !req.Mutation && m.canary != nil && name == m.canary.live && m.canary.Serves(auth.APIKeyHash) {
+		return &canaryPlugin{Plugin: m.plugins[m.canary.candidate], canary: m.canary}, nil
 	}
 
 	return item, nil
 }
 
+// cohortMutation marks a canary cohort caller's mutation so the shadow
+// wrapper mirrors it to the candidate before returning; the cohort's next
+// read is served by the candidate and must see the write.
+func (m *Manager) cohortMutation(ctx context.Context, auth files.AuthContext) context.Context {
+	if m.canary == nil || !m.canary.Serves(auth.APIKeyHash) {
+		return ctx
+	}
+	return withSyncMirror(ctx)
+}
+
+// route returns the plugin of the first matching rule, or the default plugin.
+func (m *Manager) route(req RouteRequest) string {
+	for _, rule := range m.routes {
+		if rule.Matches(req) {
+			return rule.Plugin
+		}
+	}
+	return m.defaultPlugin
+}
+
+// placementCandidates returns, in rule order, every plugin a write to
+// req.Path may have landed on: each size-bounded rule that matches the other
+// conditions, then the first rule without size bounds or the default plugin.
+func (m *Manager) placementCandidates(req RouteRequest) []string {
+	candidates := make([]string, 0, 2)
+	for _, rule := range m.routes {
+		if !rule.matchesLocation(req) {
+			continue
+		}
+		candidates = appendUniqueName(candidates, rule.Plugin)
+		if !rule.hasSizeBounds() {
+			return candidates
+		}
+	}
+	return appendUniqueName(candidates, m.defaultPlugin)
+}
+
+// scopeCandidates returns every plugin that may hold files under req.Path,
+// ending with the plugin that receives the rest of the directory.
+func (m *Manager) scopeCandidates(req RouteRequest) []string {
+	candidates := make([]string, 0, 2)
+	for _, rule := range m.routes {
+		overlaps, covers := rule.scope(req.Project, req.Path)
+		if !overlaps {
+			continue
+		}
+		candidates = appendUniqueName(candidates, rule.Plugin)
+		if covers {
+			return candidates
+		}
+	}
+	return appendUniqueName(candidates, m.defaultPlugin)
+}
+
+// locate resolves a call on an existing path. Size rules only decide where a
+// file is first written, so when they leave the placement ambiguous each
+// candidate is probed with Stat and the first one holding the path wins;
+// otherwise the last candidate, which ignores size, is used.
+func (m *Manager) locate(ctx context.Context, auth files.AuthContext, req RouteRequest) (string, Plugin, error) {
+	if m == nil {
+		return "", nil, errors.New("plugin manager is nil")
+	}
+	if requested := m.pinned(ctx, ""); requested != "" {
+		item, err := m.ForName(requested)
+		return requested, item, err
+	}
+
+	candidates := m.placementCandidates(req)
+	name := candidates[len(candidates)-1]
+	for _, candidate := range candidates[:len(candidates)-1] {
+		info, err := m.plugins[candidate].Stat(ctx, auth, req.Project, req.Path)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "locate %s on plugin %s", req.Path, candidate)
+		}
+		if info.Exists {
+			name = candidate
+			break
+		}
+	}
+
+	item, err := m.routed(auth, req, name)
+	return name, item, err
+}
+
+// appendUniqueName appends name unless it is already present.
+func appendUniqueName(names []string, name string) []string {
+	for _, existing := range names {
+		if existing == name {
+			return names
+		}
+	}
+	return append(names, name)
+}
+
 // ForName returns a plugin by name for tests and admin callers.
 func (m *Manager) ForName(name string) (Plugin, error) {
 	if m == nil {
@@ -164,7 +339,7 @@ func (m *Manager) StopAll(ctx context.Context) error {
 
 // Stat routes file_stat to the selected plugin.
 func (m *Manager) Stat(ctx context.Context, auth files.AuthContext, project, path string) (files.StatResult, error) {
-	item, err := m.Resolve(ctx, auth, project, "")
+	_, item, err := m.locate(ctx, auth, RouteRequest{Project: project, Path: path, SizeBytes: -1})
 	if err != nil {
 		return files.StatResult{}, err
 	}
@@ -174,7 +349,7 @@ func (m *Manager) Stat(ctx context.Context, auth files.AuthContext, project, pat
 
 // Read routes file_read to the selected plugin.
 func (m *Manager) Read(ctx context.Context, auth files.AuthContext, project, path string, offset, length int64) (files.ReadResult, error) {
-	item, err := m.Resolve(ctx, auth, project, "")
+	_, item, err := m.locate(ctx, auth, RouteRequest{Project: project, Path: path, SizeBytes: -1})
 	if err != nil {
 		return files.ReadResult{}, err
 	}
@@ -182,19 +357,38 @@ func (m *Manager) Read(ctx context.Context, auth files.AuthContext, project, pat
 	return item.Read(ctx, auth, project, path, offset, length)
 }
 
-// Write routes file_write to the selected plugin.
+// Write routes file_write to the selected plugin. An existing file stays on
+// the plugin that holds it, so size rules never split one path across plugins.
 func (m *Manager) Write(ctx context.Context, auth files.AuthContext, project, path, content, contentEncoding string, offset int64, mode files.WriteMode) (files.WriteResult, error) {
-	item, err := m.Resolve(ctx, auth, project, "")
+	ctx = m.cohortMutation(ctx, auth)
+	req := RouteRequest{Project: project, Path: path, SizeBytes: payloadSize(content, contentEncoding), Mutation: true}
+	item, err := m.ResolveFor(ctx, auth, req, "")
 	if err != nil {
 		return files.WriteResult{}, err
 	}
+	if m.pinned(ctx, "") == "" && len(m.placementCandidates(req)) > 1 {
+		name, located, locateErr := m.locate(ctx, auth, req)
+		if locateErr != nil {
+			return files.WriteResult{}, locateErr
+		}
+		if name != NormalizeName(item.Name()) {
+			info, statErr := located.Stat(ctx, auth, project, path)
+			if statErr != nil {
+				return files.WriteResult{}, statErr
+			}
+			if info.Exists {
+				item = located
+			}
+		}
+	}
 
 	return item.Write(ctx, auth, project, path, content, contentEncoding, offset, mode)
 }
 
 // Delete routes file_delete to the selected plugin.
 func (m *Manager) Delete(ctx context.Context, auth files.AuthContext, project, path string, recursive bool) (files.DeleteResult, error) {
-	item, err := m.Resolve(ctx, auth, project, "")
+	ctx = m.cohortMutation(ctx, auth)
+	_, item, err := m.locate(ctx, auth, RouteRequest{Project: project, Path: path, SizeBytes: -1, Mutation: true})
 	if err != nil {
 		return files.DeleteResult{}, err
 	}
@@ -202,32 +396,105 @@ func (m *Manager) Delete(ctx context.Context, auth files.AuthContext, project, p
 	return item.Delete(ctx, auth, project, path, recursive)
 }
 
-// Rename routes file_rename to the selected plugin.
+// Rename routes file_rename to the plugin holding fromPath. Plugins cannot
+// move data between each other, so a rename whose destination routes to a
+// different plugin is rejected.
 func (m *Manager) Rename(ctx context.Context, auth files.AuthContext, project, fromPath, toPath string, overwrite bool) (files.RenameResult, error) {
-	item, err := m.Resolve(ctx, auth, project, "")
+	ctx = m.cohortMutation(ctx, auth)
+	name, item, err := m.locate(ctx, auth, RouteRequest{Project: project, Path: fromPath, SizeBytes: -1, Mutation: true})
 	if err != nil {
 		return files.RenameResult{}, err
 	}
+	if m.pinned(ctx, "") == "" {
+		info, statErr := item.Stat(ctx, auth, project, fromPath)
+		if statErr != nil {
+			return files.RenameResult{}, statErr
+		}
+		size := int64(-1)
+		if info.Type == files.FileTypeFile {
+			size = info.Size
+		}
+		if target := m.route(RouteRequest{Project: project, Path: toPath, SizeBytes: size}); target != name {
+			return files.RenameResult{}, files.NewError(files.ErrCodeInvalidArgument,
+				"rename would move "+fromPath+" from plugin "+name+" to plugin "+target, false)
+		}
+	}
 
 	return item.Rename(ctx, auth, project, fromPath, toPath, overwrite)
 }
 
-// List routes file_list to the selected plugin.
+// List routes file_list to the selected plugin. When routing rules spread the
+// directory over several plugins, their listings are merged by path.
 func (m *Manager) List(ctx context.Context, auth files.AuthContext, project, path string, depth, limit int) (files.ListResult, error) {
-	item, err := m.Resolve(ctx, auth, project, "")
-	if err != nil {
-		return files.ListResult{}, err
+	req := RouteRequest{Project: project, Path: path, SizeBytes: -1}
+	candidates := m.scopeCandidates(req)
+	if m.pinned(ctx, "") != "" || len(candidates) == 1 {
+		item, err := m.ResolveFor(ctx, auth, req, "")
+		if err != nil {
+			return files.ListResult{}, err
+		}
+		return item.List(ctx, auth, project, path, depth, limit)
+	}
+
+	merged := files.ListResult{}
+	seen := make(map[string]struct{})
+	for _, name := range candidates {
+		item, err := m.routed(auth, req, name)
+		if err != nil {
+			return files.ListResult{}, err
+		}
+		result, err := item.List(ctx, auth, project, path, depth, limit)
+		if err != nil {
+			return files.ListResult{}, errors.Wrapf(err, "list plugin %s", name)
+		}
+		merged.HasMore = merged.HasMore || result.HasMore
+		for _, entry := range result.Entries {
+			if _, dup := seen[entry.Path]; dup {
+				continue
+			}
+			seen[entry.Path] = struct{}{}
+			merged.Entries = append(merged.Entries, entry)
+		}
 	}
 
-	return item.List(ctx, auth, project, path, depth, limit)
+	sort.Slice(merged.Entries, func(i, j int) bool { return merged.Entries[i].Path < merged.Entries[j].Path })
+	if limit > 0 && len(merged.Entries) > limit {
+		merged.Entries = merged.Entries[:limit]
+		merged.HasMore = true
+	}
+	return merged, nil
 }
 
-// Search routes file_search to the selected plugin.
+// Search routes file_search to the selected plugin. When routing rules spread
+// the searched prefix over several plugins, each is queried and the hits are
+// merged the same way federated search merges them.
 func (m *Manager) Search(ctx context.Context, auth files.AuthContext, project, query, pathPrefix string, limit int) (files.SearchResult, error) {
-	item, err := m.Resolve(ctx, auth, project, "")
-	if err != nil {
-		return files.SearchResult{}, err
+	req := RouteRequest{Project: project, Path: pathPrefix, SizeBytes: -1}
+	candidates := m.scopeCandidates(req)
+	if m.pinned(ctx, "") != "" || len(candidates) == 1 {
+		item, err := m.ResolveFor(ctx, auth, req, "")
+		if err != nil {
+			return files.SearchResult{}, err
+		}
+		return item.Search(ctx, auth, project, query, pathPrefix, limit)
 	}
 
-	return item.Search(ctx, auth, project, query, pathPrefix, limit)
+	collected := make([]files.ChunkEntry, 0, max(limit, 0)*len(candidates))
+	for _, name := range candidates {
+		item, err := m.routed(auth, req, name)
+		if err != nil {
+			return files.SearchResult{}, err
+		}
+		result, err := item.Search(ctx, auth, project, query, pathPrefix, limit)
+		if err != nil {
+			return files.SearchResult{}, errors.Wrapf(err, "search plugin %s", name)
+		}
+		collected = append(collected, normalizeChunkScores(name, result.Chunks)...)
+	}
+
+	merged := mergeFederatedChunks(collected)
+	if limit > 0 && len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return files.SearchResult{Chunks: merged}, nil
 }
@@ -60,6 +60,29 @@ type Plugin interface {
 	Stop(context.Context) error
 }
 
+// Wrapper is implemented by plugins that decorate another plugin, such as
+// the shadow and canary wrappers.
+type Wrapper interface {
+	Unwrap() Plugin
+}
+
+// As returns the first plugin in p's wrapper chain that implements T, so
+// optional interfaces of a wrapped plugin stay reachable.
+func As[T any](p Plugin) (T, bool) {
+	for p != nil {
+		if found, ok := p.(T); ok {
+			return found, true
+		}
+		wrapper, ok := p.(Wrapper)
+		if !ok {
+			break
+		}
+		p = wrapper.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
 type overrideContextKey struct{}
 
 // WithOverride stores a per-call plugin override in context for manager-based routing.
@@ -0,0 +1,183 @@
+package plugin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	pathpkg "path"
+	"strings"
+
+	errors "github.com/Laisky/errors/v2"
+	gconfig "github.com/Laisky/go-config/v2"
+)
+
+// RouteRule maps call attributes to a plugin name. Empty conditions match
+// every call; all non-empty conditions must hold for the rule to match.
+type RouteRule struct {
+	// Plugin is the target plugin name.
+	Plugin string `json:"plugin"`
+	// ProjectGlob is a path.Match pattern applied to the project name.
+	ProjectGlob string `json:"project_glob"`
+	// PathPrefix restricts the rule to file paths under this prefix.
+	PathPrefix string `json:"path_prefix"`
+	// Extensions restricts the rule to these file extensions, e.g. ".pdf".
+	Extensions []string `json:"extensions"`
+	// MinSizeBytes and MaxSizeBytes bound the payload size; zero means unbounded.
+	// Size conditions only decide where a new file is written; later calls on
+	// the path find it on whichever candidate plugin holds it.
+	MinSizeBytes int64 `json:"min_size_bytes"`
+	MaxSizeBytes int64 `json:"max_size_bytes"`
+}
+
+// RouteRequest describes the attributes of one call used for rule matching.
+type RouteRequest struct {
+	Project string
+	Path    string
+	// SizeBytes is the payload size, or a negative value when unknown.
+	SizeBytes int64
+	// Mutation marks writes, deletes and renames, which canary routing never diverts.
+	Mutation bool
+}
+
+// Validate checks the rule is well-formed.
+func (r RouteRule) Validate() error {
+	if NormalizeName(r.Plugin) == "" {
+		return errors.New("route plugin is required")
+	}
+	if r.ProjectGlob != "" {
+		if _, err := pathpkg.Match(r.ProjectGlob, ""); err != nil {
+			return errors.Wrapf(err, "invalid project_glob %q", r.ProjectGlob)
+		}
+	}
+	if r.MinSizeBytes < 0 || r.MaxSizeBytes < 0 {
+		return errors.New("route size bounds must be non-negative")
+	}
+	if r.MaxSizeBytes > 0 && r.MinSizeBytes > r.MaxSizeBytes {
+		return errors.New("route min_size_bytes exceeds max_size_bytes")
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to req.
+func (r RouteRule) Matches(req RouteRequest) bool {
+	return r.matchesLocation(req) && r.matchesSize(req.SizeBytes)
+}
+
+// hasSizeBounds reports whether the rule carries a size condition.
+func (r RouteRule) hasSizeBounds() bool {
+	return r.MinSizeBytes > 0 || r.MaxSizeBytes > 0
+}
+
+// matchesProject reports whether the rule's project glob accepts project.
+func (r RouteRule) matchesProject(project string) bool {
+	if r.ProjectGlob == "" {
+		return true
+	}
+	ok, err := pathpkg.Match(r.ProjectGlob, project)
+	return err == nil && ok
+}
+
+// matchesLocation checks every condition except the payload size.
+func (r RouteRule) matchesLocation(req RouteRequest) bool {
+	if !r.matchesProject(req.Project) {
+		return false
+	}
+
+	if prefix := strings.TrimSpace(r.PathPrefix); prefix != "" && !strings.HasPrefix(req.Path, prefix) {
+		return false
+	}
+
+	if len(r.Extensions) > 0 {
+		ext := strings.ToLower(pathpkg.Ext(req.Path))
+		if ext == "" {
+			return false
+		}
+		matched := false
+		for _, candidate := range r.Extensions {
+			candidate = strings.ToLower(strings.TrimSpace(candidate))
+			if !strings.HasPrefix(candidate, ".") {
+				candidate = "." + candidate
+			}
+			if candidate == ext {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchesSize checks the size bounds; an unknown (negative) size never
+// satisfies a bounded rule.
+func (r RouteRule) matchesSize(size int64) bool {
+	if !r.hasSizeBounds() {
+		return true
+	}
+	if size < 0 {
+		return false
+	}
+	if r.MinSizeBytes > 0 && size < r.MinSizeBytes {
+		return false
+	}
+	if r.MaxSizeBytes > 0 && size > r.MaxSizeBytes {
+		return false
+	}
+	return true
+}
+
+// scope reports how the rule relates to every file under dir in project:
+// overlaps is true when some of those files may match the rule, and covers is
+// true when all of them match it regardless of extension or size.
+func (r RouteRule) scope(project, dir string) (overlaps, covers bool) {
+	if !r.matchesProject(project) {
+		return false, false
+	}
+
+	prefix := strings.TrimSpace(r.PathPrefix)
+	switch {
+	case prefix == "" || strings.HasPrefix(dir, prefix):
+		return true, len(r.Extensions) == 0 && !r.hasSizeBounds()
+	case strings.HasPrefix(prefix, dir):
+		return true, false
+	default:
+		return false, false
+	}
+}
+
+// loadRouteRulesFromConfig reads settings.mcp.tools.memory.routing.rules.
+// A malformed block or rule is an error so the server refuses to start
+// instead of silently routing everything to the default plugin.
+func loadRouteRulesFromConfig() ([]RouteRule, error) {
+	raw := gconfig.S.Get("settings.mcp.tools.memory.routing.rules")
+	if raw == nil {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal routing rules")
+	}
+	var rules []RouteRule
+	if err = json.Unmarshal(body, &rules); err != nil {
+		return nil, errors.Wrap(err, "parse routing rules")
+	}
+	for i := range rules {
+		rules[i].Plugin = NormalizeName(rules[i].Plugin)
+		if err = rules[i].Validate(); err != nil {
+			return nil, errors.Wrapf(err, "route %d", i)
+		}
+	}
+	return rules, nil
+}
+
+// payloadSize returns the decoded byte size of a write payload. Only the
+// pageindex plugin accepts base64, for .docx uploads.
+func payloadSize(content, contentEncoding string) int64 {
+	if strings.EqualFold(strings.TrimSpace(contentEncoding), "base64") {
+		return int64(base64.StdEncoding.DecodedLen(len(strings.TrimSpace(content))))
+	}
+	return int64(len(content))
+}
@@ -0,0 +1,246 @@
+package plugin
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	gconfig "github.com/Laisky/go-config/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+)
+
+// TestRouteRuleMatches verifies each rule condition independently.
+func TestRouteRuleMatches(t *testing.T) {
+	t.Parallel()
+
+	rule := RouteRule{
+		Plugin:       DefaultPluginPageIndex,
+		ProjectGlob:  "docs-*",
+		PathPrefix:   "/library/",
+		Extensions:   []string{"PDF", ".md"},
+		MinSizeBytes: 100,
+		MaxSizeBytes: 1000,
+	}
+
+	require.True(t, rule.Matches(RouteRequest{Project: "docs-a", Path: "/library/a.pdf", SizeBytes: 500}))
+	require.True(t, rule.Matches(RouteRequest{Project: "docs-a", Path: "/library/a.MD", SizeBytes: 100}))
+	require.False(t, rule.Matches(RouteRequest{Project: "notes", Path: "/library/a.pdf", SizeBytes: 500}))
+	require.False(t, rule.Matches(RouteRequest{Project: "docs-a", Path: "/other/a.pdf", SizeBytes: 500}))
+	require.False(t, rule.Matches(RouteRequest{Project: "docs-a", Path: "/library/a.txt", SizeBytes: 500}))
+	require.False(t, rule.Matches(RouteRequest{Project: "docs-a", Path: "/library/a.pdf", SizeBytes: 99}))
+	require.False(t, rule.Matches(RouteRequest{Project: "docs-a", Path: "/library/a.pdf", SizeBytes: 1001}))
+	require.False(t, rule.Matches(RouteRequest{Project: "docs-a", Path: "/library/a.pdf", SizeBytes: -1}))
+
+	require.True(t, RouteRule{Plugin: DefaultPluginRAG}.Matches(RouteRequest{SizeBytes: -1}))
+}
+
+// TestRouteRuleValidate verifies malformed rules are rejected.
+func TestRouteRuleValidate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, RouteRule{Plugin: DefaultPluginRAG, ProjectGlob: "a*"}.Validate())
+	require.Error(t, RouteRule{}.Validate())
+	require.Error(t, RouteRule{Plugin: DefaultPluginRAG, ProjectGlob: "["}.Validate())
+	require.Error(t, RouteRule{Plugin: DefaultPluginRAG, MinSizeBytes: 10, MaxSizeBytes: 5}.Validate())
+}
+
+// TestManagerRoutingRules verifies rules apply between explicit overrides and the default plugin.
+func TestManagerRoutingRules(t *testing.T) {
+	t.Parallel()
+
+	ragPlugin := &testPlugin{name: DefaultPluginRAG, statResult: files.StatResult{Exists: true}}
+	pageindexPlugin := &testPlugin{name: DefaultPluginPageIndex, statResult: files.StatResult{Exists: false}}
+	mgr, err := NewManager(DefaultPluginRAG, ragPlugin, pageindexPlugin)
+	require.NoError(t, err)
+
+	require.Error(t, mgr.SetRoutes([]RouteRule{{Plugin: "missing"}}))
+	require.NoError(t, mgr.SetRoutes([]RouteRule{
+		{Plugin: DefaultPluginPageIndex, Extensions: []string{".pdf"}, MinSizeBytes: 1024},
+		{Plugin: DefaultPluginPageIndex, ProjectGlob: "longdocs"},
+	}))
+
+	ctx := context.Background()
+	auth := files.AuthContext{}
+	large := RouteRequest{Project: "demo", Path: "/a.pdf", SizeBytes: 4096}
+	small := RouteRequest{Project: "demo", Path: "/a.pdf", SizeBytes: 10}
+
+	resolved, err := mgr.ResolveFor(ctx, auth, large, "")
+	require.NoError(t, err)
+	require.Equal(t, DefaultPluginPageIndex, resolved.Name())
+
+	resolved, err = mgr.ResolveFor(ctx, auth, small, "")
+	require.NoError(t, err)
+	require.Equal(t, DefaultPluginRAG, resolved.Name())
+
+	resolved, err = mgr.ResolveFor(ctx, auth, large, DefaultPluginRAG)
+	require.NoError(t, err)
+	require.Equal(t, DefaultPluginRAG, resolved.Name())
+
+	resolved, err = mgr.Resolve(ctx, auth, "longdocs", "")
+	require.NoError(t, err)
+	require.Equal(t, DefaultPluginPageIndex, resolved.Name())
+
+	result, err := mgr.Stat(ctx, auth, "longdocs", "/notes.txt")
+	require.NoError(t, err)
+	require.False(t, result.Exists)
+}
+
+// TestPayloadSize verifies base64 .docx uploads are measured by their decoded size.
+func TestPayloadSize(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, int64(3), payloadSize("YWJj", "base64"))
+	require.Equal(t, int64(3), payloadSize("YWJj\n", "BASE64"))
+	require.Equal(t, int64(2048), payloadSize(strings.Repeat("x", 2048), "utf-8"))
+}
+
+// placementPlugin is an in-memory plugin that records which paths it holds.
+type placementPlugin struct {
+	testPlugin
+	files map[string]int64
+}
+
+func newPlacementPlugin(name string) *placementPlugin {
+	return &placementPlugin{testPlugin: testPlugin{name: name}, files: map[string]int64{}}
+}
+
+// Stat reports whether the path was written to this plugin.
+func (p *placementPlugin) Stat(_ context.Context, _ files.AuthContext, _ string, path string) (files.StatResult, error) {
+	size, ok := p.files[path]
+	if !ok {
+		return files.StatResult{}, nil
+	}
+	return files.StatResult{Exists: true, Type: files.FileTypeFile, Size: size}, nil
+}
+
+// Read returns the plugin name so tests can tell which plugin served the call.
+func (p *placementPlugin) Read(_ context.Context, _ files.AuthContext, _ string, path string, _, _ int64) (files.ReadResult, error) {
+	if _, ok := p.files[path]; !ok {
+		return files.ReadResult{}, files.NewError(files.ErrCodeNotFound, "file not found", false)
+	}
+	return files.ReadResult{Content: p.name}, nil
+}
+
+// Write records the path and its size.
+func (p *placementPlugin) Write(_ context.Context, _ files.AuthContext, _ string, path, content, _ string, _ int64, _ files.WriteMode) (files.WriteResult, error) {
+	p.files[path] = int64(len(content))
+	return files.WriteResult{BytesWritten: int64(len(content))}, nil
+}
+
+// Delete forgets the path.
+func (p *placementPlugin) Delete(_ context.Context, _ files.AuthContext, _ string, path string, _ bool) (files.DeleteResult, error) {
+	if _, ok := p.files[path]; !ok {
+		return files.DeleteResult{}, files.NewError(files.ErrCodeNotFound, "file not found", false)
+	}
+	delete(p.files, path)
+	return files.DeleteResult{DeletedCount: 1}, nil
+}
+
+// Rename moves the recorded path.
+func (p *placementPlugin) Rename(_ context.Context, _ files.AuthContext, _ string, fromPath, toPath string, _ bool) (files.RenameResult, error) {
+	p.files[toPath] = p.files[fromPath]
+	delete(p.files, fromPath)
+	return files.RenameResult{MovedCount: 1}, nil
+}
+
+// List returns every recorded path.
+func (p *placementPlugin) List(context.Context, files.AuthContext, string, string, int, int) (files.ListResult, error) {
+	result := files.ListResult{}
+	for path, size := range p.files {
+		result.Entries = append(result.Entries, files.FileEntry{Path: path, Type: files.FileTypeFile, Size: size})
+	}
+	return result, nil
+}
+
+// TestManagerSizeRoutingIsConsistent verifies a file written under a size rule
+// is read, listed, renamed and deleted on the plugin that holds it.
+func TestManagerSizeRoutingIsConsistent(t *testing.T) {
+	t.Parallel()
+
+	ragPlugin := newPlacementPlugin(DefaultPluginRAG)
+	pageindexPlugin := newPlacementPlugin(DefaultPluginPageIndex)
+	mgr, err := NewManager(DefaultPluginRAG, ragPlugin, pageindexPlugin)
+	require.NoError(t, err)
+	require.NoError(t, mgr.SetRoutes([]RouteRule{
+		{Plugin: DefaultPluginPageIndex, Extensions: []string{".md"}, MinSizeBytes: 1024},
+	}))
+
+	ctx := context.Background()
+	auth := files.AuthContext{}
+	_, err = mgr.Write(ctx, auth, "demo", "/big.md", strings.Repeat("x", 2048), "utf-8", 0, files.WriteModeTruncate)
+	require.NoError(t, err)
+	_, err = mgr.Write(ctx, auth, "demo", "/small.md", "tiny", "utf-8", 0, files.WriteModeTruncate)
+	require.NoError(t, err)
+	require.Contains(t, pageindexPlugin.files, "/big.md")
+	require.Contains(t, ragPlugin.files, "/small.md")
+
+	read, err := mgr.Read(ctx, auth, "demo", "/big.md", 0, -1)
+	require.NoError(t, err)
+	require.Equal(t, DefaultPluginPageIndex, read.Content)
+	read, err = mgr.Read(ctx, auth, "demo", "/small.md", 0, -1)
+	require.NoError(t, err)
+	require.Equal(t, DefaultPluginRAG, read.Content)
+
+	// Shrinking an existing file keeps it on the plugin that holds it.
+	_, err = mgr.Write(ctx, auth, "demo", "/big.md", "shrunk", "utf-8", 0, files.WriteModeTruncate)
+	require.NoError(t, err)
+	require.Equal(t, int64(len("shrunk")), pageindexPlugin.files["/big.md"])
+	require.NotContains(t, ragPlugin.files, "/big.md")
+
+	listed, err := mgr.List(ctx, auth, "demo", "/", 1, 10)
+	require.NoError(t, err)
+	require.Len(t, listed.Entries, 2)
+	require.Equal(t, "/big.md", listed.Entries[0].Path)
+
+	_, err = mgr.Rename(ctx, auth, "demo", "/small.md", "/small.txt", false)
+	require.NoError(t, err)
+	require.Contains(t, ragPlugin.files, "/small.txt")
+
+	_, err = mgr.Delete(ctx, auth, "demo", "/big.md", false)
+	require.NoError(t, err)
+	require.Empty(t, pageindexPlugin.files)
+}
+
+// TestManagerRenameRejectsCrossPluginMoves verifies renames cannot move data across routing boundaries.
+func TestManagerRenameRejectsCrossPluginMoves(t *testing.T) {
+	t.Parallel()
+
+	ragPlugin := newPlacementPlugin(DefaultPluginRAG)
+	pageindexPlugin := newPlacementPlugin(DefaultPluginPageIndex)
+	mgr, err := NewManager(DefaultPluginRAG, ragPlugin, pageindexPlugin)
+	require.NoError(t, err)
+	require.NoError(t, mgr.SetRoutes([]RouteRule{{Plugin: DefaultPluginPageIndex, PathPrefix: "/library/"}}))
+
+	ctx := context.Background()
+	auth := files.AuthContext{}
+	_, err = mgr.Write(ctx, auth, "demo", "/notes/a.md", "note", "utf-8", 0, files.WriteModeTruncate)
+	require.NoError(t, err)
+
+	_, err = mgr.Rename(ctx, auth, "demo", "/notes/a.md", "/library/a.md", false)
+	require.Error(t, err)
+	require.Contains(t, ragPlugin.files, "/notes/a.md")
+	require.Empty(t, pageindexPlugin.files)
+}
+
+// TestLoadRouteRulesFromConfigRejectsInvalidRules verifies malformed routing config is surfaced.
+func TestLoadRouteRulesFromConfigRejectsInvalidRules(t *testing.T) {
+	const key = "settings.mcp.tools.memory.routing.rules"
+	original := gconfig.Shared.Get(key)
+	t.Cleanup(func() { gconfig.Shared.Set(key, original) })
+
+	gconfig.Shared.Set(key, []any{map[string]any{"plugin": "PageIndex", "extensions": []any{".pdf"}}})
+	rules, err := loadRouteRulesFromConfig()
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, DefaultPluginPageIndex, rules[0].Plugin)
+
+	gconfig.Shared.Set(key, []any{map[string]any{"plugin": "rag", "min_size_bytes": 10, "max_size_bytes": 5}})
+	_, err = loadRouteRulesFromConfig()
+	require.Error(t, err)
+
+	gconfig.Shared.Set(key, "not-a-list")
+	_, err = loadRouteRulesFromConfig()
+	require.Error(t, err)
+}
@@ -1,35 +1,41 @@
 package plugin
 
 import (
+	"strconv"
 	"strings"
 	"time"
 
 	errors "github.com/Laisky/errors/v2"
 	gconfig "github.com/Laisky/go-config/v2"
+	logSDK "github.com/Laisky/go-utils/v6/log"
 )
 
 // Settings captures manager-level plugin routing configuration.
 type Settings struct {
 	DefaultPlugin string
+	// Routes are evaluated in order when a call carries no explicit override.
+	Routes []RouteRule
 }
 
 // LoadSettingsFromConfig reads the MCP memory plugin manager settings.
-func LoadSettingsFromConfig() Settings {
+func LoadSettingsFromConfig() (Settings, error) {
 	defaultPlugin := NormalizeName(strings.TrimSpace(gconfig.S.GetString("settings.mcp.tools.memory.default_plugin")))
 	if defaultPlugin == "" {
 		defaultPlugin = DefaultPluginRAG
 	}
 
-	return Settings{DefaultPlugin: defaultPlugin}
+	routes, err := loadRouteRulesFromConfig()
+	if err != nil {
+		return Settings{}, errors.Wrap(err, "load memory routing rules")
+	}
+	return Settings{DefaultPlugin: defaultPlugin, Routes: routes}, nil
 }
 
 // ShadowSettings captures the proposal §8 Phase 3 dual-write opt-in. The
 // wrapper is off by default; operators flip Enabled and pin a single global
-// live/shadow pair to begin shadow replay. Per-project, per-tenant, and
-// per-API-key routing layers were considered and rejected in proposal §2.4 —
-// the only caller-controlled selector is the optional per-call `plugin`
-// argument with `default_plugin` as the global fallback. Shadow replay
-// therefore wraps the live plugin globally; agents that need to bypass shadow
+// live/shadow pair to begin shadow replay. Shadow replay wraps the live
+// plugin globally regardless of Settings.Routes, so calls routed to the live
+// plugin by a rule are captured the same way as default calls; agents that need to bypass shadow
 // capture for a specific call simply pass `plugin="<live>"` (no-op since
 // shadow follows the live route).
 type ShadowSettings struct {
@@ -40,6 +46,12 @@ type ShadowSettings struct {
 	Concurrency  int
 	OpTimeout    time.Duration
 	DrainGrace   time.Duration
+	// ViewerAPIKeyHashes lists the SHA-256 API key hashes allowed to read
+	// every caller's shadow records over HTTP.
+	ViewerAPIKeyHashes []string
+	// ReportMaxBytes bounds how much of the end of the log the report
+	// endpoints read; 0 uses the default.
+	ReportMaxBytes int64
 }
 
 // LoadShadowSettingsFromConfig reads the optional shadow-replay block.
@@ -53,6 +65,9 @@ func LoadShadowSettingsFromConfig() ShadowSettings {
 		Concurrency:  gconfig.S.GetInt(prefix + ".concurrency"),
 		OpTimeout:    gconfig.S.GetDuration(prefix + ".op_timeout"),
 		DrainGrace:   gconfig.S.GetDuration(prefix + ".drain_grace"),
+
+		ViewerAPIKeyHashes: gconfig.S.GetStringSlice(prefix + ".viewer_api_key_hashes"),
+		ReportMaxBytes:     gconfig.S.GetInt64(prefix + ".report_max_bytes"),
 	}
 }
 
@@ -76,3 +91,87 @@ func (s ShadowSettings) Validate() error {
 	}
 	return nil
 }
+
+// CanarySettings captures the optional canary block. The canary piggybacks on
+// shadow replay: the shadow pair must mirror LivePlugin to CandidatePlugin so
+// the candidate receives every write and its health is measured on all traffic.
+type CanarySettings struct {
+	Enabled         bool
+	LivePlugin      string
+	CandidatePlugin string
+	Percent         float64
+	Window          time.Duration
+	MinSamples      int
+	// MaxErrorRate is nil when max_error_rate is unset, so an explicit 0 is kept.
+	MaxErrorRate  *float64
+	MaxP99Latency time.Duration
+}
+
+// LoadCanarySettingsFromConfig reads settings.mcp.tools.memory.canary.
+func LoadCanarySettingsFromConfig() CanarySettings {
+	const prefix = "settings.mcp.tools.memory.canary"
+	var maxErrorRate *float64
+	if gconfig.S.Get(prefix+".max_error_rate") != nil {
+		rate := FloatFromConfig(prefix + ".max_error_rate")
+		maxErrorRate = &rate
+	}
+	return CanarySettings{
+		Enabled:         gconfig.S.GetBool(prefix + ".enabled"),
+		LivePlugin:      NormalizeName(gconfig.S.GetString(prefix + ".live_plugin")),
+		CandidatePlugin: NormalizeName(gconfig.S.GetString(prefix + ".candidate_plugin")),
+		Percent:         FloatFromConfig(prefix + ".percent"),
+		Window:          gconfig.S.GetDuration(prefix + ".window"),
+		MinSamples:      gconfig.S.GetInt(prefix + ".min_samples"),
+		MaxErrorRate:    maxErrorRate,
+		MaxP99Latency:   gconfig.S.GetDuration(prefix + ".max_p99_latency"),
+	}
+}
+
+// Validate checks an enabled canary against the shadow settings it relies on.
+func (s CanarySettings) Validate(shadow ShadowSettings) error {
+	if !s.Enabled {
+		return nil
+	}
+	if !shadow.Enabled {
+		return errors.New("canary requires shadow replay to be enabled")
+	}
+	if s.LivePlugin != shadow.LivePlugin || s.CandidatePlugin != shadow.ShadowPlugin {
+		return errors.Errorf("canary pair %s->%s must match the shadow pair %s->%s",
+			s.LivePlugin, s.CandidatePlugin, shadow.LivePlugin, shadow.ShadowPlugin)
+	}
+	_, err := NewCanary(s.Config(nil))
+	return err
+}
+
+// Config converts the settings into a CanaryConfig.
+func (s CanarySettings) Config(logger logSDK.Logger) CanaryConfig {
+	return CanaryConfig{
+		Live:          s.LivePlugin,
+		Candidate:     s.CandidatePlugin,
+		Percent:       s.Percent,
+		Window:        s.Window,
+		MinSamples:    s.MinSamples,
+		MaxErrorRate:  s.MaxErrorRate,
+		MaxP99Latency: s.MaxP99Latency,
+		Logger:        logger,
+	}
+}
+
+// FloatFromConfig reads a numeric config value, returning 0 when it is unset.
+// YAML integers load as int, so every numeric type is accepted.
+func FloatFromConfig(key string) float64 {
+	switch v := gconfig.S.Get(key).(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f
+	}
+	return 0
+}
@@ -3,6 +3,7 @@ package plugin
 import (
 	"context"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -31,6 +32,12 @@ type ShadowConfig struct {
 	OpTimeout   time.Duration
 	DrainGrace  time.Duration
 	Concurrency int64
+	// ViewerAPIKeyHashes may read every caller's records through
+	// ShadowRecordsFor; other callers only see their own.
+	ViewerAPIKeyHashes []string
+	// ReportMaxBytes bounds how much of the end of the log ShadowRecordsFor
+	// reads; 0 uses 32 MiB and a negative value reads the whole log.
+	ReportMaxBytes int64
 }
 
 // ShadowPlugin dual-writes mutations and dual-reads queries for promotion-gate
@@ -46,6 +53,9 @@ type ShadowPlugin struct {
 	opTimeout  time.Duration
 	drainGrace time.Duration
 	sem        *semaphore.Weighted
+	viewers    map[string]struct{}
+	// reportMaxBytes is the tail window read by ShadowRecordsFor.
+	reportMaxBytes int64
 
 	shutdownOnce sync.Once
 	shutdown     chan struct{}
@@ -92,6 +102,18 @@ func NewShadowPlugin(cfg ShadowConfig) (*ShadowPlugin, error) {
 		}
 	}
 
+	viewers := make(map[string]struct{}, len(cfg.ViewerAPIKeyHashes))
+	for _, hash := range cfg.ViewerAPIKeyHashes {
+		if hash = strings.TrimSpace(hash); hash != "" {
+			viewers[hash] = struct{}{}
+		}
+	}
+
+	reportMaxBytes := cfg.ReportMaxBytes
+	if reportMaxBytes == 0 {
+		reportMaxBytes = defaultShadowReportMaxBytes
+	}
+
 	return &ShadowPlugin{
 		live:       cfg.Live,
 		shadow:     cfg.Shadow,
@@ -101,7 +123,10 @@ func NewShadowPlugin(cfg ShadowConfig) (*ShadowPlugin, error) {
 		opTimeout:  opTimeout,
 		drainGrace: drainGrace,
 		sem:        semaphore.NewWeighted(concurrency),
+		viewers:    viewers,
 		shutdown:   make(chan struct{}),
+
+		reportMaxBytes: reportMaxBytes,
 	}, nil
 }
 
@@ -111,6 +136,9 @@ func (s *ShadowPlugin) Name() string { return s.name }
 // Capabilities reports the live plugin's user-visible contract.
 func (s *ShadowPlugin) Capabilities() Capabilities { return s.live.Capabilities() }
 
+// Unwrap returns the live plugin so callers can reach its optional interfaces.
+func (s *ShadowPlugin) Unwrap() Plugin { return s.live }
+
 // Start brings up live then shadow; a shadow start failure logs but never fails.
 func (s *ShadowPlugin) Start(ctx context.Context) error {
 	if err := s.live.Start(ctx); err != nil {
@@ -180,7 +208,7 @@ func (s *ShadowPlugin) Write(ctx context.Context, auth files.AuthContext, projec
 		return res, err
 	}
 
-	s.fireMutation("write", project, path, liveDur, func(opCtx context.Context) error {
+	s.fireMutation(ctx, auth.APIKeyHash, "write", project, path, liveDur, func(opCtx context.Context) error {
 		_, e := s.shadow.Write(opCtx, auth, project, path, content, contentEncoding, offset, mode)
 		return e
 	})
@@ -196,7 +224,7 @@ func (s *ShadowPlugin) Delete(ctx context.Context, auth files.AuthContext, proje
 		return res, err
 	}
 
-	s.fireMutation("delete", project, path, liveDur, func(opCtx context.Context) error {
+	s.fireMutation(ctx, auth.APIKeyHash, "delete", project, path, liveDur, func(opCtx context.Context) error {
 		_, e := s.shadow.Delete(opCtx, auth, project, path, recursive)
 		return e
 	})
@@ -212,7 +240,7 @@ func (s *ShadowPlugin) Rename(ctx context.Context, auth files.AuthContext, proje
 		return res, err
 	}
 
-	s.fireMutation("rename", project, fromPath, liveDur, func(opCtx context.Context) error {
+	s.fireMutation(ctx, auth.APIKeyHash, "rename", project, fromPath, liveDur, func(opCtx context.Context) error {
 		_, e := s.shadow.Rename(opCtx, auth, project, fromPath, toPath, overwrite)
 		return e
 	})
@@ -229,60 +257,71 @@ func (s *ShadowPlugin) Search(ctx context.Context, auth files.AuthContext, proje
 	return liveRes, liveErr
 }
 
-// fireMutation runs a bounded, fire-and-forget shadow mutation.
-func (s *ShadowPlugin) fireMutation(op, project, path string, liveDur time.Duration, run func(context.Context) error) {
+// fireMutation runs a bounded, fire-and-forget shadow mutation. When ctx was
+// marked by withSyncMirror the shadow mutation finishes before it returns, so
+// a canary cohort reading from the shadow plugin sees its own writes.
+func (s *ShadowPlugin) fireMutation(ctx context.Context, apiKeyHash, op, project, path string, liveDur time.Duration, run func(context.Context) error) {
 	select {
 	case <-s.shutdown:
-		s.recordMutation(op, project, path, liveDur, 0, "", "shadow: shutdown")
+		s.recordMutation(apiKeyHash, op, project, path, liveDur, 0, "", "shadow: shutdown")
 		return
 	default:
 	}
 
 	s.inflight.Add(1)
+	if syncMirror(ctx) {
+		defer s.inflight.Done()
+		s.runMutation(apiKeyHash, op, project, path, liveDur, run)
+		return
+	}
 	go func() {
 		defer s.inflight.Done()
+		s.runMutation(apiKeyHash, op, project, path, liveDur, run)
+	}()
+}
 
-		semCtx, semCancel := context.WithTimeout(context.Background(), s.opTimeout)
-		defer semCancel()
-		if err := s.sem.Acquire(semCtx, 1); err != nil {
-			s.logger.Warn("shadow semaphore acquire failed",
-				zap.String("op", op),
-				zap.String("project", project),
-				zap.String("path", path),
-				zap.Error(err),
-			)
-			s.recordMutation(op, project, path, liveDur, 0, "", "shadow: "+err.Error())
-			return
-		}
-		defer s.sem.Release(1)
+// runMutation applies one shadow mutation under the semaphore and records it.
+func (s *ShadowPlugin) runMutation(apiKeyHash, op, project, path string, liveDur time.Duration, run func(context.Context) error) {
+	semCtx, semCancel := context.WithTimeout(context.Background(), s.opTimeout)
+	defer semCancel()
+	if err := s.sem.Acquire(semCtx, 1); err != nil {
+		s.logger.Warn("shadow semaphore acquire failed",
+			zap.String("op", op),
+			zap.String("project", project),
+			zap.String("path", path),
+			zap.Error(err),
+		)
+		s.recordMutation(apiKeyHash, op, project, path, liveDur, 0, "", "shadow: "+err.Error())
+		return
+	}
+	defer s.sem.Release(1)
 
-		opCtx, cancel := context.WithTimeout(context.Background(), s.opTimeout)
-		defer cancel()
-		stop := s.watchShutdown(cancel)
-		defer stop()
+	opCtx, cancel := context.WithTimeout(context.Background(), s.opTimeout)
+	defer cancel()
+	stop := s.watchShutdown(cancel)
+	defer stop()
 
-		shadowStart := time.Now()
-		err := run(opCtx)
-		shadowDur := time.Since(shadowStart)
-		shadowErrMsg := ""
-		if err != nil {
-			shadowErrMsg = err.Error()
-			s.logger.Warn("shadow mutation failed",
-				zap.String("op", op),
-				zap.String("project", project),
-				zap.String("path", path),
-				zap.Error(err),
-			)
-		}
-		s.recordMutation(op, project, path, liveDur, shadowDur, "", shadowErrMsg)
-	}()
+	shadowStart := time.Now()
+	err := run(opCtx)
+	shadowDur := time.Since(shadowStart)
+	shadowErrMsg := ""
+	if err != nil {
+		shadowErrMsg = err.Error()
+		s.logger.Warn("shadow mutation failed",
+			zap.String("op", op),
+			zap.String("project", project),
+			zap.String("path", path),
+			zap.Error(err),
+		)
+	}
+	s.recordMutation(apiKeyHash, op, project, path, liveDur, shadowDur, "", shadowErrMsg)
 }
 
 // fireSearch runs a bounded, fire-and-forget shadow Search and records the pair.
 func (s *ShadowPlugin) fireSearch(auth files.AuthContext, project, query, pathPrefix string, limit int, liveRes files.SearchResult, liveErr error, liveDur time.Duration) {
 	select {
 	case <-s.shutdown:
-		s.recordSearch(project, query, pathPrefix, limit, liveRes, files.SearchResult{}, liveDur, 0, liveErr, errors.New("shadow: shutdown"))
+		s.recordSearch(auth.APIKeyHash, project, query, pathPrefix, limit, liveRes, files.SearchResult{}, liveDur, 0, liveErr, errors.New("shadow: shutdown"))
 		return
 	default:
 	}
@@ -299,7 +338,7 @@ func (s *ShadowPlugin) fireSearch(auth files.AuthContext, project, query, pathPr
 				zap.String("project", project),
 				zap.Error(err),
 			)
-			s.recordSearch(project, query, pathPrefix, limit, liveRes, files.SearchResult{}, liveDur, 0, liveErr, err)
+			s.recordSearch(auth.APIKeyHash, project, query, pathPrefix, limit, liveRes, files.SearchResult{}, liveDur, 0, liveErr, err)
 			return
 		}
 		defer s.sem.Release(1)
@@ -318,7 +357,7 @@ func (s *ShadowPlugin) fireSearch(auth files.AuthContext, project, query, pathPr
 				zap.Error(shadowErr),
 			)
 		}
-		s.recordSearch(project, query, pathPrefix, limit, liveRes, shadowRes, liveDur, shadowDur, liveErr, shadowErr)
+		s.recordSearch(auth.APIKeyHash, project, query, pathPrefix, limit, liveRes, shadowRes, liveDur, shadowDur, liveErr, shadowErr)
 	}()
 }
 
@@ -336,11 +375,12 @@ func (s *ShadowPlugin) watchShutdown(cancel context.CancelFunc) func() {
 	return func() { close(done) }
 }
 
-func (s *ShadowPlugin) recordMutation(op, project, path string, liveDur, shadowDur time.Duration, liveErrMsg, shadowErrMsg string) {
+func (s *ShadowPlugin) recordMutation(apiKeyHash, op, project, path string, liveDur, shadowDur time.Duration, liveErrMsg, shadowErrMsg string) {
 	rec := MutationRecord{
 		Timestamp:      time.Now().UTC(),
 		Op:             op,
 		Project:        project,
+		APIKeyHash:     apiKeyHash,
 		Path:           path,
 		LiveErr:        liveErrMsg,
 		ShadowErr:      shadowErrMsg,
@@ -352,10 +392,11 @@ func (s *ShadowPlugin) recordMutation(op, project, path string, liveDur, shadowD
 	}
 }
 
-func (s *ShadowPlugin) recordSearch(project, query, pathPrefix string, limit int, liveRes, shadowRes files.SearchResult, liveDur, shadowDur time.Duration, liveErr, shadowErr error) {
+func (s *ShadowPlugin) recordSearch(apiKeyHash, project, query, pathPrefix string, limit int, liveRes, shadowRes files.SearchResult, liveDur, shadowDur time.Duration, liveErr, shadowErr error) {
 	rec := SearchRecord{
 		Timestamp:      time.Now().UTC(),
 		Project:        project,
+		APIKeyHash:     apiKeyHash,
 		Query:          query,
 		PathPrefix:     pathPrefix,
 		Limit:          limit,
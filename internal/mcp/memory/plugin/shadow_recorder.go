@@ -16,6 +16,7 @@ import (
 type SearchRecord struct {
 	Timestamp      time.Time          `json:"timestamp"`
 	Project        string             `json:"project"`
+	APIKeyHash     string             `json:"api_key_hash,omitempty"`
 	Query          string             `json:"query"`
 	PathPrefix     string             `json:"path_prefix"`
 	Limit          int                `json:"limit"`
@@ -34,6 +35,7 @@ type MutationRecord struct {
 	Timestamp      time.Time     `json:"timestamp"`
 	Op             string        `json:"op"`
 	Project        string        `json:"project"`
+	APIKeyHash     string        `json:"api_key_hash,omitempty"`
 	Path           string        `json:"path"`
 	LiveErr        string        `json:"live_err,omitempty"`
 	ShadowErr      string        `json:"shadow_err,omitempty"`
@@ -58,6 +60,7 @@ type jsonlEnvelope struct {
 // JSONLRecorder appends paired records to a JSONL file under a mutex.
 type JSONLRecorder struct {
 	mu     sync.Mutex
+	path   string
 	file   *os.File
 	writer *bufio.Writer
 	closed bool
@@ -73,6 +76,7 @@ func NewJSONLRecorder(path string) (*JSONLRecorder, error) {
 		return nil, errors.Wrap(err, "open recorder file")
 	}
 	return &JSONLRecorder{
+		path:   path,
 		file:   f,
 		writer: bufio.NewWriter(f),
 	}, nil
@@ -0,0 +1,385 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+)
+
+// defaultOverlapK is the cut-off used when a summary is requested without k.
+const defaultOverlapK = 5
+
+// defaultShadowReportMaxBytes is how much of the end of a shadow log the
+// report endpoints read when ShadowConfig.ReportMaxBytes is unset.
+const defaultShadowReportMaxBytes = 32 << 20
+
+// ShadowLog is a window of shadow records read back for reporting.
+type ShadowLog struct {
+	Searches  []SearchRecord
+	Mutations []MutationRecord
+	// Skipped counts lines in the window that were not valid records.
+	Skipped int
+	// Truncated reports that older records before the window were not read.
+	Truncated bool
+}
+
+// ShadowLogReader is implemented by recorders whose records can be read back
+// while the shadow wrapper is running.
+type ShadowLogReader interface {
+	// ShadowRecords returns the newest records within the last maxBytes of
+	// the log; maxBytes <= 0 reads everything.
+	ShadowRecords(maxBytes int64) (ShadowLog, error)
+}
+
+// ShadowRecords reads the tail of the JSONL log back. Records are flushed as
+// they are written, so the file is current without taking the writer lock.
+func (r *JSONLRecorder) ShadowRecords(maxBytes int64) (ShadowLog, error) {
+	return ReadShadowLogTail(r.path, maxBytes)
+}
+
+// ShadowRecords returns the captured records; they are already in memory, so
+// maxBytes is ignored.
+func (m *MemRecorder) ShadowRecords(int64) (ShadowLog, error) {
+	searches, mutations := m.Records()
+	return ShadowLog{Searches: searches, Mutations: mutations}, nil
+}
+
+// ShadowRecords reads from the first child that supports reads.
+func (m *MultiRecorder) ShadowRecords(maxBytes int64) (ShadowLog, error) {
+	for _, c := range m.children {
+		if reader, ok := c.(ShadowLogReader); ok {
+			return reader.ShadowRecords(maxBytes)
+		}
+	}
+	return ShadowLog{}, errors.New("no recorder supports reading records back")
+}
+
+// ShadowRecordsFor returns the records visible to apiKeyHash: all of them for
+// a configured viewer, otherwise only the caller's own. Records written
+// before the key hash was captured are visible to viewers only. Only the
+// last ShadowConfig.ReportMaxBytes of the log are read.
+func (s *ShadowPlugin) ShadowRecordsFor(apiKeyHash string) (ShadowLog, error) {
+	reader, ok := s.rec.(ShadowLogReader)
+	if !ok {
+		return ShadowLog{}, errors.New("shadow recorder does not support reading records back")
+	}
+	window, err := reader.ShadowRecords(s.reportMaxBytes)
+	if err != nil {
+		return ShadowLog{}, errors.Wrap(err, "read shadow records")
+	}
+	if _, viewer := s.viewers[apiKeyHash]; viewer {
+		return window, nil
+	}
+
+	own := ShadowLog{
+		Searches:  make([]SearchRecord, 0, len(window.Searches)),
+		Mutations: make([]MutationRecord, 0, len(window.Mutations)),
+		Skipped:   window.Skipped,
+		Truncated: window.Truncated,
+	}
+	for _, rec := range window.Searches {
+		if apiKeyHash != "" && rec.APIKeyHash == apiKeyHash {
+			own.Searches = append(own.Searches, rec)
+		}
+	}
+	for _, rec := range window.Mutations {
+		if apiKeyHash != "" && rec.APIKeyHash == apiKeyHash {
+			own.Mutations = append(own.Mutations, rec)
+		}
+	}
+	return own, nil
+}
+
+// IsViewer reports whether apiKeyHash is a configured viewer.
+func (s *ShadowPlugin) IsViewer(apiKeyHash string) bool {
+	_, ok := s.viewers[apiKeyHash]
+	return ok && apiKeyHash != ""
+}
+
+// ReadShadowLog parses a whole JSONLRecorder file.
+func ReadShadowLog(path string) (ShadowLog, error) {
+	return ReadShadowLogTail(path, 0)
+}
+
+// ReadShadowLogTail parses the last maxBytes of a JSONLRecorder file, or the
+// whole file when maxBytes <= 0. The line cut by the window start is dropped.
+// An unterminated last line is skipped because it may still be in the middle
+// of being written; other malformed lines are skipped and counted.
+func ReadShadowLogTail(path string, maxBytes int64) (ShadowLog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ShadowLog{}, errors.Wrap(err, "open shadow log")
+	}
+	defer f.Close() //nolint:errcheck
+
+	var out ShadowLog
+	reader := bufio.NewReader(f)
+	if maxBytes > 0 {
+		info, err := f.Stat()
+		if err != nil {
+			return ShadowLog{}, errors.Wrap(err, "stat shadow log")
+		}
+		if start := info.Size() - maxBytes; start > 0 {
+			// Start one byte early so a window that begins on a line boundary
+			// only discards the preceding newline.
+			if _, err := f.Seek(start-1, io.SeekStart); err != nil {
+				return ShadowLog{}, errors.Wrap(err, "seek shadow log")
+			}
+			reader = bufio.NewReader(f)
+			if _, err := reader.ReadBytes('\n'); err != nil && err != io.EOF {
+				return ShadowLog{}, errors.Wrap(err, "read shadow log")
+			}
+			out.Truncated = true
+		}
+	}
+
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return ShadowLog{}, errors.Wrap(readErr, "read shadow log")
+		}
+		if readErr == io.EOF {
+			break // a complete last line always ends with '\n'
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var env jsonlEnvelope
+		if err := json.Unmarshal(line, &env); err != nil {
+			out.Skipped++
+			continue
+		}
+		switch {
+		case env.Kind == "search" && env.Search != nil:
+			out.Searches = append(out.Searches, *env.Search)
+		case env.Kind == "mutation" && env.Mutation != nil:
+			out.Mutations = append(out.Mutations, *env.Mutation)
+		default:
+			out.Skipped++
+		}
+	}
+	return out, nil
+}
+
+// LatencySummary reports latency percentiles in milliseconds.
+type LatencySummary struct {
+	Count int     `json:"count"`
+	P50Ms float64 `json:"p50_ms"`
+	P90Ms float64 `json:"p90_ms"`
+	P99Ms float64 `json:"p99_ms"`
+	MaxMs float64 `json:"max_ms"`
+}
+
+// ShadowSummary aggregates a shadow log for the promotion report. It does
+// not judge result quality; ScoreShadowReplay does that offline.
+type ShadowSummary struct {
+	Searches  int        `json:"searches"`
+	Mutations int        `json:"mutations"`
+	From      *time.Time `json:"from,omitempty"`
+	To        *time.Time `json:"to,omitempty"`
+
+	LiveLatency   LatencySummary `json:"live_latency"`
+	ShadowLatency LatencySummary `json:"shadow_latency"`
+
+	LiveSearchErrorRate     float64 `json:"live_search_error_rate"`
+	ShadowSearchErrorRate   float64 `json:"shadow_search_error_rate"`
+	ShadowMutationErrorRate float64 `json:"shadow_mutation_error_rate"`
+
+	// K is the cut-off used for OverlapAtK.
+	K int `json:"k"`
+	// OverlapAtK is the mean OverlapAtK over searches where both sides succeeded.
+	OverlapAtK float64 `json:"overlap_at_k"`
+	// ComparedSearches counts the searches OverlapAtK is averaged over.
+	ComparedSearches int `json:"compared_searches"`
+}
+
+// SummarizeShadow computes latency percentiles, error rates and the mean
+// overlap@k of the given records. k <= 0 uses defaultOverlapK.
+func SummarizeShadow(searches []SearchRecord, mutations []MutationRecord, k int) ShadowSummary {
+	if k <= 0 {
+		k = defaultOverlapK
+	}
+	sum := ShadowSummary{Searches: len(searches), Mutations: len(mutations), K: k}
+
+	var (
+		live, shadow             []time.Duration
+		liveErrs, shadowErrs     int
+		overlapTotal             float64
+		mutationErrs, mutationOK int
+	)
+	observe := func(ts time.Time) {
+		if ts.IsZero() {
+			return
+		}
+		if sum.From == nil || ts.Before(*sum.From) {
+			from := ts
+			sum.From = &from
+		}
+		if sum.To == nil || ts.After(*sum.To) {
+			to := ts
+			sum.To = &to
+		}
+	}
+
+	for _, rec := range searches {
+		observe(rec.Timestamp)
+		live = append(live, rec.LiveDuration)
+		if rec.LiveErr != "" {
+			liveErrs++
+		}
+		if rec.ShadowDuration > 0 {
+			shadow = append(shadow, rec.ShadowDuration)
+		}
+		if rec.ShadowErr != "" {
+			shadowErrs++
+		}
+		if rec.LiveErr == "" && rec.ShadowErr == "" {
+			overlapTotal += OverlapAtK(rec.LiveResult, rec.ShadowResult, k)
+			sum.ComparedSearches++
+		}
+	}
+	for _, rec := range mutations {
+		observe(rec.Timestamp)
+		if rec.ShadowErr != "" {
+			mutationErrs++
+		} else {
+			mutationOK++
+		}
+	}
+
+	sum.LiveLatency = summarizeLatency(live)
+	sum.ShadowLatency = summarizeLatency(shadow)
+	if len(searches) > 0 {
+		sum.LiveSearchErrorRate = float64(liveErrs) / float64(len(searches))
+		sum.ShadowSearchErrorRate = float64(shadowErrs) / float64(len(searches))
+	}
+	if total := mutationErrs + mutationOK; total > 0 {
+		sum.ShadowMutationErrorRate = float64(mutationErrs) / float64(total)
+	}
+	if sum.ComparedSearches > 0 {
+		sum.OverlapAtK = overlapTotal / float64(sum.ComparedSearches)
+	}
+	return sum
+}
+
+// OverlapAtK returns the share of the top-k files the two results have in
+// common. Files are compared by project and path because plugins chunk the
+// same file differently. The denominator is k, capped at the larger
+// result's file count, so two identical short results score 1. Two empty
+// results also score 1.
+func OverlapAtK(live, shadow SearchResult, k int) float64 {
+	liveFiles := topKFiles(live, k)
+	shadowFiles := topKFiles(shadow, k)
+	denom := min(k, max(len(liveFiles), len(shadowFiles)))
+	if denom == 0 {
+		return 1
+	}
+	common := 0
+	for file := range liveFiles {
+		if _, ok := shadowFiles[file]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(denom)
+}
+
+// topKFiles returns the first k distinct files of res in rank order.
+func topKFiles(res SearchResult, k int) map[string]struct{} {
+	out := make(map[string]struct{}, k)
+	for _, chunk := range res.Chunks {
+		if len(out) >= k {
+			break
+		}
+		out[chunk.Project+"\x00"+chunk.FilePath] = struct{}{}
+	}
+	return out
+}
+
+// summarizeLatency computes nearest-rank percentiles.
+func summarizeLatency(durations []time.Duration) LatencySummary {
+	if len(durations) == 0 {
+		return LatencySummary{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	pick := func(p float64) float64 {
+		idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+		idx = max(0, min(idx, len(sorted)-1))
+		return durationMs(sorted[idx])
+	}
+	return LatencySummary{
+		Count: len(sorted),
+		P50Ms: pick(0.50),
+		P90Ms: pick(0.90),
+		P99Ms: pick(0.99),
+		MaxMs: durationMs(sorted[len(sorted)-1]),
+	}
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// ShadowPair is one live/shadow search laid side by side for review.
+type ShadowPair struct {
+	Timestamp    time.Time    `json:"timestamp"`
+	Project      string       `json:"project"`
+	Query        string       `json:"query"`
+	PathPrefix   string       `json:"path_prefix,omitempty"`
+	Limit        int          `json:"limit"`
+	LivePlugin   string       `json:"live_plugin"`
+	ShadowPlugin string       `json:"shadow_plugin"`
+	LiveMs       float64      `json:"live_ms"`
+	ShadowMs     float64      `json:"shadow_ms"`
+	LiveErr      string       `json:"live_err,omitempty"`
+	ShadowErr    string       `json:"shadow_err,omitempty"`
+	OverlapAtK   float64      `json:"overlap_at_k"`
+	Live         SearchResult `json:"live"`
+	Shadow       SearchResult `json:"shadow"`
+}
+
+// ShadowPairs returns one page of searches, newest first, and the total
+// number of searches.
+func ShadowPairs(searches []SearchRecord, k, offset, limit int) ([]ShadowPair, int) {
+	if k <= 0 {
+		k = defaultOverlapK
+	}
+	total := len(searches)
+	offset = max(0, offset)
+	if offset >= total || limit <= 0 {
+		return []ShadowPair{}, total
+	}
+	end := min(total, offset+limit)
+
+	ordered := append([]SearchRecord(nil), searches...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Timestamp.After(ordered[j].Timestamp) })
+
+	pairs := make([]ShadowPair, 0, end-offset)
+	for _, rec := range ordered[offset:end] {
+		pairs = append(pairs, ShadowPair{
+			Timestamp:    rec.Timestamp,
+			Project:      rec.Project,
+			Query:        rec.Query,
+			PathPrefix:   rec.PathPrefix,
+			Limit:        rec.Limit,
+			LivePlugin:   rec.LivePlugin,
+			ShadowPlugin: rec.ShadowPlugin,
+			LiveMs:       durationMs(rec.LiveDuration),
+			ShadowMs:     durationMs(rec.ShadowDuration),
+			LiveErr:      rec.LiveErr,
+			ShadowErr:    rec.ShadowErr,
+			OverlapAtK:   OverlapAtK(rec.LiveResult, rec.ShadowResult, k),
+			Live:         rec.LiveResult,
+			Shadow:       rec.ShadowResult,
+		})
+	}
+	return pairs, total
+}
@@ -0,0 +1,199 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+)
+
+// resultOf builds a SearchResult with one chunk per path.
+func resultOf(paths ...string) files.SearchResult {
+	res := files.SearchResult{}
+	for _, p := range paths {
+		res.Chunks = append(res.Chunks, files.ChunkEntry{FilePath: p})
+	}
+	return res
+}
+
+// TestOverlapAtK covers partial, identical, short and empty result pairs.
+func TestOverlapAtK(t *testing.T) {
+	t.Parallel()
+
+	require.InDelta(t, 0.5, OverlapAtK(resultOf("/a", "/b", "/c", "/d"), resultOf("/b", "/a", "/x", "/y"), 4), 1e-9)
+	require.InDelta(t, 1.0, OverlapAtK(resultOf("/a", "/a", "/b"), resultOf("/b", "/a"), 5), 1e-9)
+	require.InDelta(t, 0.5, OverlapAtK(resultOf("/a", "/b"), resultOf("/a"), 5), 1e-9)
+	require.InDelta(t, 1.0, OverlapAtK(resultOf("/a", "/b", "/c"), resultOf("/a", "/b", "/z"), 2), 1e-9)
+	require.InDelta(t, 1.0, OverlapAtK(files.SearchResult{}, files.SearchResult{}, 5), 1e-9)
+}
+
+// TestSummarizeShadow checks percentiles, error rates and the overlap mean.
+func TestSummarizeShadow(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	var searches []SearchRecord
+	for i := 1; i <= 10; i++ {
+		searches = append(searches, SearchRecord{
+			Timestamp:      base.Add(time.Duration(i) * time.Minute),
+			LiveDuration:   time.Duration(i) * time.Millisecond,
+			ShadowDuration: time.Duration(10*i) * time.Millisecond,
+			LiveResult:     resultOf("/a", "/b"),
+			ShadowResult:   resultOf("/a", "/c"),
+		})
+	}
+	searches[9].ShadowErr = "timeout"
+	searches[8].LiveErr = "boom"
+	mutations := []MutationRecord{{Timestamp: base}, {Timestamp: base, ShadowErr: "x"}}
+
+	sum := SummarizeShadow(searches, mutations, 2)
+	require.Equal(t, 10, sum.Searches)
+	require.Equal(t, 2, sum.Mutations)
+	require.Equal(t, base, *sum.From)
+	require.Equal(t, base.Add(10*time.Minute), *sum.To)
+	require.Equal(t, LatencySummary{Count: 10, P50Ms: 5, P90Ms: 9, P99Ms: 10, MaxMs: 10}, sum.LiveLatency)
+	require.InDelta(t, 90, sum.ShadowLatency.P90Ms, 1e-9)
+	require.InDelta(t, 0.1, sum.LiveSearchErrorRate, 1e-9)
+	require.InDelta(t, 0.1, sum.ShadowSearchErrorRate, 1e-9)
+	require.InDelta(t, 0.5, sum.ShadowMutationErrorRate, 1e-9)
+	require.Equal(t, 8, sum.ComparedSearches)
+	require.InDelta(t, 0.5, sum.OverlapAtK, 1e-9)
+
+	empty := SummarizeShadow(nil, nil, 0)
+	require.Equal(t, defaultOverlapK, empty.K)
+	require.Nil(t, empty.From)
+}
+
+// TestShadowPairsPagesNewestFirst checks ordering and paging bounds.
+func TestShadowPairsPagesNewestFirst(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	searches := []SearchRecord{
+		{Timestamp: base, Query: "q0"},
+		{Timestamp: base.Add(2 * time.Minute), Query: "q2", LiveResult: resultOf("/a"), ShadowResult: resultOf("/b")},
+		{Timestamp: base.Add(time.Minute), Query: "q1"},
+	}
+
+	pairs, total := ShadowPairs(searches, 5, 0, 2)
+	require.Equal(t, 3, total)
+	require.Len(t, pairs, 2)
+	require.Equal(t, "q2", pairs[0].Query)
+	require.InDelta(t, 0.0, pairs[0].OverlapAtK, 1e-9)
+	require.Equal(t, "q1", pairs[1].Query)
+
+	pairs, _ = ShadowPairs(searches, 5, 2, 2)
+	require.Len(t, pairs, 1)
+	require.Equal(t, "q0", pairs[0].Query)
+
+	pairs, _ = ShadowPairs(searches, 5, 5, 2)
+	require.Empty(t, pairs)
+}
+
+// TestShadowRecordsForScopesByAPIKey checks callers only see their own records
+// unless their key hash is a configured viewer.
+func TestShadowRecordsForScopesByAPIKey(t *testing.T) {
+	t.Parallel()
+
+	live := newFake("live")
+	shadow := newFake("shadow")
+	path := filepath.Join(t.TempDir(), "shadow.jsonl")
+	rec, err := NewJSONLRecorder(path)
+	require.NoError(t, err)
+	wrap, err := NewShadowPlugin(ShadowConfig{
+		Live:               live,
+		Shadow:             shadow,
+		Recorder:           NewMultiRecorder(rec, NewMemRecorder()),
+		ViewerAPIKeyHashes: []string{"ops"},
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = wrap.Search(ctx, files.AuthContext{APIKeyHash: "alice"}, "p", "q1", "", 5)
+	require.NoError(t, err)
+	_, err = wrap.Search(ctx, files.AuthContext{APIKeyHash: "bob"}, "p", "q2", "", 5)
+	require.NoError(t, err)
+	_, err = wrap.Write(ctx, files.AuthContext{APIKeyHash: "alice"}, "p", "/x", "hi", "utf8", 0, files.WriteModeOverwrite)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		window, readErr := wrap.ShadowRecordsFor("ops")
+		return readErr == nil && len(window.Searches) == 2 && len(window.Mutations) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	window, err := wrap.ShadowRecordsFor("alice")
+	require.NoError(t, err)
+	require.Len(t, window.Searches, 1)
+	require.Equal(t, "q1", window.Searches[0].Query)
+	require.Len(t, window.Mutations, 1)
+
+	window, err = wrap.ShadowRecordsFor("")
+	require.NoError(t, err)
+	require.Empty(t, window.Searches)
+	require.Empty(t, window.Mutations)
+	require.NoError(t, wrap.Stop(ctx))
+
+	// A torn last line is skipped rather than failing the whole read.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"kind":"search","search":{"query":"half`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	window, err = ReadShadowLog(path)
+	require.NoError(t, err)
+	require.Len(t, window.Searches, 2)
+	require.Zero(t, window.Skipped)
+}
+
+// TestReadShadowLogTailSkipsMalformedLines checks that a bounded read keeps
+// the newest records, drops the line cut by the window and counts bad lines.
+func TestReadShadowLogTailSkipsMalformedLines(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "shadow.jsonl")
+	rec, err := NewJSONLRecorder(path)
+	require.NoError(t, err)
+	for _, q := range []string{"q0", "q1", "q2"} {
+		require.NoError(t, rec.RecordSearch(SearchRecord{Query: q}))
+	}
+	require.NoError(t, rec.Close())
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString("not json\n{\"kind\":\"search\"}\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	rec, err = NewJSONLRecorder(path)
+	require.NoError(t, err)
+	require.NoError(t, rec.RecordSearch(SearchRecord{Query: "q3"}))
+	require.NoError(t, rec.Close())
+
+	full, err := ReadShadowLog(path)
+	require.NoError(t, err)
+	require.Len(t, full.Searches, 4)
+	require.Equal(t, 2, full.Skipped)
+	require.False(t, full.Truncated)
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lastLine := int64(len(raw) - bytes.LastIndexByte(raw[:len(raw)-1], '\n') - 1)
+
+	// A window that starts exactly on the last record keeps only that record.
+	tail, err := ReadShadowLogTail(path, lastLine)
+	require.NoError(t, err)
+	require.True(t, tail.Truncated)
+	require.Len(t, tail.Searches, 1)
+	require.Equal(t, "q3", tail.Searches[0].Query)
+	require.Zero(t, tail.Skipped)
+
+	// One byte more cuts the previous line, which is dropped, not counted.
+	tail, err = ReadShadowLogTail(path, lastLine+1)
+	require.NoError(t, err)
+	require.Len(t, tail.Searches, 1)
+	require.Zero(t, tail.Skipped)
+}
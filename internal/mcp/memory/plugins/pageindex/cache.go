@@ -2,11 +2,14 @@ package pageindex
 
 import (
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	errors "github.com/Laisky/errors/v2"
@@ -17,17 +20,47 @@ import (
 type Cache interface {
 	Get(key [32]byte) (*Response, bool, error)
 	Put(key [32]byte, resp *Response) error
+	Stats() CacheStats
 	Close() error
 }
 
 // CacheConfig holds the bbolt cache parameters.
 type CacheConfig struct {
-	Enabled      bool
-	Path         string
+	Enabled bool
+	Path    string
+	// MaxSizeBytes caps the total size of cached records. Least recently used
+	// records are evicted past it; the file itself only shrinks on compaction.
 	MaxSizeBytes int64
+	// MaxEntries caps the number of cached records; zero means unlimited.
+	MaxEntries int
+	// TTL expires records older than this on read; zero keeps them until evicted.
+	TTL time.Duration
 }
 
-const cacheBucket = "responses"
+// CacheStats is a point-in-time view of the cache and its counters since open.
+type CacheStats struct {
+	Enabled      bool    `json:"enabled"`
+	Entries      int     `json:"entries"`
+	Bytes        int64   `json:"bytes"`
+	FileBytes    int64   `json:"file_bytes"`
+	MaxSizeBytes int64   `json:"max_size_bytes,omitempty"`
+	MaxEntries   int     `json:"max_entries,omitempty"`
+	TTLSeconds   int64   `json:"ttl_seconds,omitempty"`
+	Hits         uint64  `json:"hits"`
+	Misses       uint64  `json:"misses"`
+	Expired      uint64  `json:"expired"`
+	Puts         uint64  `json:"puts"`
+	Evictions    uint64  `json:"evictions"`
+	HitRate      float64 `json:"hit_rate"`
+}
+
+const (
+	cacheBucket  = "responses"
+	accessBucket = "access"
+	// cacheEvictTarget is the fraction of a limit eviction shrinks to, so a
+	// full cache does not evict on every Put.
+	cacheEvictTarget = 0.9
+)
 
 // NewCache opens the bbolt database. When cfg.Enabled is false a no-op cache is
 // returned so callers do not have to nil-check.
@@ -35,24 +68,51 @@ func NewCache(cfg CacheConfig) (Cache, error) {
 	if !cfg.Enabled {
 		return &noopCache{}, nil
 	}
-	if cfg.Path == "" {
+	db, err := openCacheDB(cfg.Path, true)
+	if err != nil {
+		return nil, err
+	}
+	c := &boltCache{db: db, cfg: cfg, touched: map[[32]byte]int64{}}
+	if err := db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(cacheBucket)).ForEach(func(_, v []byte) error {
+			c.entries++
+			c.bytes += int64(len(v))
+			return nil
+		})
+	}); err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "scan cache")
+	}
+	return c, nil
+}
+
+// openCacheDB opens the bbolt file at path and ensures both buckets exist.
+func openCacheDB(path string, create bool) (*bolt.DB, error) {
+	if path == "" {
 		return nil, errors.New("cache path is empty")
 	}
-	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
-		return nil, errors.Wrap(err, "create cache dir")
+	if create {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, errors.Wrap(err, "create cache dir")
+		}
+	} else if _, err := os.Stat(path); err != nil {
+		return nil, errors.Wrapf(err, "stat cache %s", path)
 	}
-	db, err := bolt.Open(cfg.Path, 0o600, &bolt.Options{Timeout: 2 * time.Second})
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 2 * time.Second})
 	if err != nil {
-		return nil, errors.Wrap(err, "open bbolt cache")
+		return nil, errors.Wrapf(err, "open bbolt cache %s (is another process using it?)", path)
 	}
 	if err := db.Update(func(tx *bolt.Tx) error {
-		_, e := tx.CreateBucketIfNotExists([]byte(cacheBucket))
+		if _, e := tx.CreateBucketIfNotExists([]byte(cacheBucket)); e != nil {
+			return e
+		}
+		_, e := tx.CreateBucketIfNotExists([]byte(accessBucket))
 		return e
 	}); err != nil {
 		_ = db.Close()
 		return nil, errors.Wrap(err, "ensure cache bucket")
 	}
-	return &boltCache{db: db, max: cfg.MaxSizeBytes}, nil
+	return db, nil
 }
 
 // CacheKey hashes the canonical tuple (algo version, model, prompt, schema, extra params).
@@ -76,25 +136,31 @@ type cachedRecord struct {
 	Output   json.RawMessage `json:"output"`
 	Text     string          `json:"text"`
 	Usage    Usage           `json:"usage"`
+	Model    string          `json:"model,omitempty"`
 	CachedAt time.Time       `json:"cached_at"`
 }
 
 type boltCache struct {
 	db  *bolt.DB
-	max int64
+	cfg CacheConfig
 	mu  sync.RWMutex
+
+	// entries and bytes mirror the responses bucket; guarded by mu.
+	entries int
+	bytes   int64
+	// touched buffers last-use times from Get so reads stay read-only
+	// transactions; it is flushed to the access bucket on the next write.
+	touchMu sync.Mutex
+	touched map[[32]byte]int64
+
+	hits, misses, expired, puts, evictions atomic.Uint64
 }
 
 func (c *boltCache) Get(key [32]byte) (*Response, bool, error) {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
 	var rec *cachedRecord
 	err := c.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(cacheBucket))
-		if b == nil {
-			return nil
-		}
-		raw := b.Get(key[:])
+		raw := tx.Bucket([]byte(cacheBucket)).Get(key[:])
 		if raw == nil {
 			return nil
 		}
@@ -105,44 +171,210 @@ func (c *boltCache) Get(key [32]byte) (*Response, bool, error) {
 		rec = &r
 		return nil
 	})
+	c.mu.RUnlock()
 	if err != nil {
 		return nil, false, err
 	}
 	if rec == nil {
+		c.misses.Add(1)
+		return nil, false, nil
+	}
+	now := time.Now().UTC()
+	if c.cfg.TTL > 0 && now.Sub(rec.CachedAt) > c.cfg.TTL {
+		c.expired.Add(1)
+		c.misses.Add(1)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if _, err := c.deleteKeys([][]byte{key[:]}); err != nil {
+			return nil, false, err
+		}
 		return nil, false, nil
 	}
-	return &Response{Output: rec.Output, Text: rec.Text, Usage: rec.Usage, CacheHit: true}, true, nil
+	c.hits.Add(1)
+	c.touchMu.Lock()
+	c.touched[key] = now.UnixNano()
+	c.touchMu.Unlock()
+	return &Response{Output: rec.Output, Text: rec.Text, Usage: rec.Usage, Model: rec.Model, CacheHit: true}, true, nil
 }
 
 func (c *boltCache) Put(key [32]byte, resp *Response) error {
 	if resp == nil {
 		return nil
 	}
+	now := time.Now().UTC()
+	rec := cachedRecord{Output: resp.Output, Text: resp.Text, Usage: resp.Usage, Model: resp.Model, CachedAt: now}
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "encode cached record")
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	if c.max > 0 {
-		if size, err := c.dbSize(); err == nil && size > c.max {
+	touched := c.takeTouched()
+	touched[key] = now.UnixNano()
+	if err := c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(cacheBucket))
+		if old := b.Get(key[:]); old != nil {
+			c.entries--
+			c.bytes -= int64(len(old))
+		}
+		if err := b.Put(key[:], raw); err != nil {
+			return err
+		}
+		c.entries++
+		c.bytes += int64(len(raw))
+		return putAccess(tx, touched)
+	}); err != nil {
+		return err
+	}
+	c.puts.Add(1)
+	return c.evictLocked()
+}
+
+// evictLocked removes least recently used records until the cache is back
+// under cacheEvictTarget of every configured limit. Callers hold c.mu.
+func (c *boltCache) evictLocked() error {
+	overEntries := c.cfg.MaxEntries > 0 && c.entries > c.cfg.MaxEntries
+	overBytes := c.cfg.MaxSizeBytes > 0 && c.bytes > c.cfg.MaxSizeBytes
+	if !overEntries && !overBytes {
+		return nil
+	}
+	targetEntries := c.entries
+	if c.cfg.MaxEntries > 0 {
+		targetEntries = int(float64(c.cfg.MaxEntries) * cacheEvictTarget)
+	}
+	targetBytes := c.bytes
+	if c.cfg.MaxSizeBytes > 0 {
+		targetBytes = int64(float64(c.cfg.MaxSizeBytes) * cacheEvictTarget)
+	}
+
+	type candidate struct {
+		key      []byte
+		size     int64
+		lastUsed int64
+	}
+	var candidates []candidate
+	if err := c.db.View(func(tx *bolt.Tx) error {
+		access := tx.Bucket([]byte(accessBucket))
+		return tx.Bucket([]byte(cacheBucket)).ForEach(func(k, v []byte) error {
+			cand := candidate{key: append([]byte(nil), k...), size: int64(len(v))}
+			if ts := access.Get(k); len(ts) == 8 {
+				cand.lastUsed = int64(binary.BigEndian.Uint64(ts))
+			}
+			candidates = append(candidates, cand)
 			return nil
+		})
+	}); err != nil {
+		return errors.Wrap(err, "scan cache for eviction")
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].lastUsed < candidates[j].lastUsed })
+
+	entries, bytes := c.entries, c.bytes
+	victims := make([][]byte, 0)
+	for _, cand := range candidates {
+		if entries <= targetEntries && bytes <= targetBytes {
+			break
 		}
+		victims = append(victims, cand.key)
+		entries--
+		bytes -= cand.size
 	}
-	rec := cachedRecord{Output: resp.Output, Text: resp.Text, Usage: resp.Usage, CachedAt: time.Now().UTC()}
-	raw, err := json.Marshal(rec)
-	if err != nil {
-		return errors.Wrap(err, "encode cached record")
+	n, err := c.deleteKeys(victims)
+	c.evictions.Add(uint64(n))
+	return err
+}
+
+// deleteKeys removes records and their access times. Callers hold c.mu.
+func (c *boltCache) deleteKeys(keys [][]byte) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
 	}
-	return c.db.Update(func(tx *bolt.Tx) error {
+	deleted := 0
+	err := c.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(cacheBucket))
-		if b == nil {
-			return errors.New("cache bucket missing")
+		access := tx.Bucket([]byte(accessBucket))
+		for _, k := range keys {
+			old := b.Get(k)
+			if old == nil {
+				continue
+			}
+			size := int64(len(old))
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+			if err := access.Delete(k); err != nil {
+				return err
+			}
+			c.entries--
+			c.bytes -= size
+			deleted++
 		}
-		return b.Put(key[:], raw)
+		return nil
 	})
+	return deleted, errors.Wrap(err, "delete cache records")
+}
+
+// takeTouched swaps out the buffered access times.
+func (c *boltCache) takeTouched() map[[32]byte]int64 {
+	c.touchMu.Lock()
+	defer c.touchMu.Unlock()
+	touched := c.touched
+	c.touched = map[[32]byte]int64{}
+	return touched
+}
+
+// putAccess writes last-use times for keys that still have a record.
+func putAccess(tx *bolt.Tx, touched map[[32]byte]int64) error {
+	b := tx.Bucket([]byte(cacheBucket))
+	access := tx.Bucket([]byte(accessBucket))
+	var ts [8]byte
+	for key, at := range touched {
+		if b.Get(key[:]) == nil {
+			continue
+		}
+		binary.BigEndian.PutUint64(ts[:], uint64(at))
+		if err := access.Put(key[:], ts[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *boltCache) Stats() CacheStats {
+	c.mu.RLock()
+	entries, bytes := c.entries, c.bytes
+	c.mu.RUnlock()
+	hits, misses := c.hits.Load(), c.misses.Load()
+	st := CacheStats{
+		Enabled:      true,
+		Entries:      entries,
+		Bytes:        bytes,
+		MaxSizeBytes: c.cfg.MaxSizeBytes,
+		MaxEntries:   c.cfg.MaxEntries,
+		TTLSeconds:   int64(c.cfg.TTL / time.Second),
+		Hits:         hits,
+		Misses:       misses,
+		Expired:      c.expired.Load(),
+		Puts:         c.puts.Load(),
+		Evictions:    c.evictions.Load(),
+	}
+	if size, err := c.dbSize(); err == nil {
+		st.FileBytes = size
+	}
+	if hits+misses > 0 {
+		st.HitRate = float64(hits) / float64(hits+misses)
+	}
+	return st
 }
 
 func (c *boltCache) Close() error {
 	if c.db == nil {
 		return nil
 	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if touched := c.takeTouched(); len(touched) > 0 {
+		_ = c.db.Update(func(tx *bolt.Tx) error { return putAccess(tx, touched) })
+	}
 	return c.db.Close()
 }
 
@@ -163,4 +395,5 @@ type noopCache struct{}
 
 func (noopCache) Get(key [32]byte) (*Response, bool, error) { return nil, false, nil }
 func (noopCache) Put(key [32]byte, resp *Response) error    { return nil }
+func (noopCache) Stats() CacheStats                         { return CacheStats{} }
 func (noopCache) Close() error                              { return nil }
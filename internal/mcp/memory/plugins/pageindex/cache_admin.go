@@ -0,0 +1,160 @@
+package pageindex
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	bolt "go.etcd.io/bbolt"
+)
+
+// The functions below operate on a cache file directly. bbolt holds an
+// exclusive file lock, so the server using the cache must be stopped first.
+
+// CacheReport summarizes a cache file for operators.
+type CacheReport struct {
+	Path      string         `json:"path"`
+	FileBytes int64          `json:"file_bytes"`
+	Entries   int            `json:"entries"`
+	Bytes     int64          `json:"bytes"`
+	Models    map[string]int `json:"models"`
+	Oldest    *time.Time     `json:"oldest,omitempty"`
+	Newest    *time.Time     `json:"newest,omitempty"`
+	Corrupt   int            `json:"corrupt,omitempty"`
+}
+
+// PruneOptions selects the records PruneCacheFile deletes. Set filters are
+// combined with AND; at least one is required.
+type PruneOptions struct {
+	// Model deletes records answered by this model. Records cached before the
+	// model was recorded match the empty string and can only be pruned by age.
+	Model string
+	// OlderThan deletes records cached more than this long ago.
+	OlderThan time.Duration
+	// Now overrides the clock for OlderThan; zero means time.Now.
+	Now time.Time
+}
+
+// InspectCacheFile reads the cache file at path without modifying it.
+func InspectCacheFile(path string) (CacheReport, error) {
+	db, err := openCacheDB(path, false)
+	if err != nil {
+		return CacheReport{}, err
+	}
+	defer db.Close() //nolint:errcheck
+
+	report := CacheReport{Path: path, Models: map[string]int{}}
+	if err := db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(cacheBucket)).ForEach(func(_, v []byte) error {
+			report.Entries++
+			report.Bytes += int64(len(v))
+			var rec cachedRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				report.Corrupt++
+				return nil
+			}
+			report.Models[rec.Model]++
+			at := rec.CachedAt
+			if report.Oldest == nil || at.Before(*report.Oldest) {
+				report.Oldest = &at
+			}
+			if report.Newest == nil || at.After(*report.Newest) {
+				report.Newest = &at
+			}
+			return nil
+		})
+	}); err != nil {
+		return CacheReport{}, errors.Wrap(err, "scan cache")
+	}
+	if stat, err := os.Stat(path); err == nil {
+		report.FileBytes = stat.Size()
+	}
+	return report, nil
+}
+
+// PruneCacheFile deletes the records matching opts and returns how many were
+// removed. Undecodable records are always removed. The file does not shrink
+// until CompactCacheFile runs.
+func PruneCacheFile(path string, opts PruneOptions) (int, error) {
+	if opts.Model == "" && opts.OlderThan <= 0 {
+		return 0, errors.New("prune requires a model or an age filter")
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	db, err := openCacheDB(path, false)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close() //nolint:errcheck
+
+	pruned := 0
+	err = db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(cacheBucket))
+		access := tx.Bucket([]byte(accessBucket))
+		var victims [][]byte
+		if err := b.ForEach(func(k, v []byte) error {
+			var rec cachedRecord
+			if err := json.Unmarshal(v, &rec); err == nil {
+				if opts.Model != "" && rec.Model != opts.Model {
+					return nil
+				}
+				if opts.OlderThan > 0 && now.Sub(rec.CachedAt) <= opts.OlderThan {
+					return nil
+				}
+			}
+			victims = append(victims, append([]byte(nil), k...))
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, k := range victims {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+			if err := access.Delete(k); err != nil {
+				return err
+			}
+		}
+		pruned = len(victims)
+		return nil
+	})
+	return pruned, errors.Wrap(err, "prune cache")
+}
+
+// CompactCacheFile rewrites the cache file without free pages and returns its
+// size before and after.
+func CompactCacheFile(path string) (before, after int64, err error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "stat cache %s", path)
+	}
+	before = stat.Size()
+
+	src, err := openCacheDB(path, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	tmp := path + ".compact"
+	_ = os.Remove(tmp)
+	dst, err := bolt.Open(tmp, 0o600, &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		_ = src.Close()
+		return 0, 0, errors.Wrapf(err, "open %s", tmp)
+	}
+	compactErr := bolt.Compact(dst, src, 0)
+	if err := errors.Join(compactErr, dst.Close(), src.Close()); err != nil {
+		_ = os.Remove(tmp)
+		return 0, 0, errors.Wrap(err, "compact cache")
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return 0, 0, errors.Wrap(err, "replace cache file")
+	}
+	if stat, err = os.Stat(path); err != nil {
+		return 0, 0, errors.Wrapf(err, "stat cache %s", path)
+	}
+	return before, stat.Size(), nil
+}
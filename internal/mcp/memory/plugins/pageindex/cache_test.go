@@ -1,8 +1,13 @@
 package pageindex
 
 import (
+	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
 )
 
 func TestCachePutGet(t *testing.T) {
@@ -39,3 +44,145 @@ func TestNoopCache(t *testing.T) {
 		t.Fatal("noop cache should never hit")
 	}
 }
+
+func TestCacheTTLAndStats(t *testing.T) {
+	c, err := NewCache(CacheConfig{Enabled: true, Path: filepath.Join(t.TempDir(), "c.bbolt"), TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	bc := c.(*boltCache)
+	fresh := CacheKey("m", "fresh", nil, nil)
+	stale := CacheKey("m", "stale", nil, nil)
+	if err := c.Put(fresh, &Response{Text: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	raw := fmt.Sprintf(`{"text":"old","cached_at":%q}`, time.Now().Add(-2*time.Hour).UTC().Format(time.RFC3339))
+	if err := bc.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(cacheBucket)).Put(stale[:], []byte(raw))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	bc.entries++
+	bc.bytes += int64(len(raw))
+
+	if _, ok, err := c.Get(stale); err != nil || ok {
+		t.Fatalf("expected the stale record to expire, ok=%v err=%v", ok, err)
+	}
+	if _, ok, _ := c.Get(fresh); !ok {
+		t.Fatal("expected the fresh record to hit")
+	}
+	st := c.Stats()
+	if st.Entries != 1 || st.Hits != 1 || st.Misses != 1 || st.Expired != 1 || st.Puts != 1 || st.HitRate != 0.5 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+	if st.FileBytes == 0 || st.TTLSeconds != 3600 {
+		t.Fatalf("expected file size and ttl in stats, got %+v", st)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "c.bbolt")
+	c, err := NewCache(CacheConfig{Enabled: true, Path: path, MaxEntries: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make([][32]byte, 4)
+	for i := range keys {
+		keys[i] = CacheKey("m", fmt.Sprintf("p%d", i), nil, nil)
+	}
+	for _, k := range keys[:3] {
+		if err := c.Put(k, &Response{Text: "x"}); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// touch the oldest so the second record becomes the eviction victim
+	if _, ok, _ := c.Get(keys[0]); !ok {
+		t.Fatal("expected a hit")
+	}
+	time.Sleep(time.Millisecond)
+	if err := c.Put(keys[3], &Response{Text: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	// 90% of 3 entries leaves 2: the two least recently used go
+	for i, want := range []bool{true, false, false, true} {
+		if _, ok, _ := c.Get(keys[i]); ok != want {
+			t.Fatalf("key %d: expected present=%v", i, want)
+		}
+	}
+	if st := c.Stats(); st.Entries != 2 || st.Evictions != 2 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewCache(CacheConfig{Enabled: true, Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if st := reopened.Stats(); st.Entries != 2 || st.Hits != 0 {
+		t.Fatalf("expected counters to restart with entries rescanned, got %+v", st)
+	}
+}
+
+func TestCacheFileAdmin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "c.bbolt")
+	c, err := NewCache(CacheConfig{Enabled: true, Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := strings.Repeat("y", 4096)
+	for i := 0; i < 40; i++ {
+		model := "gpt-a"
+		if i%4 == 0 {
+			model = "gpt-b"
+		}
+		if err := c.Put(CacheKey(model, fmt.Sprintf("p%d", i), nil, nil), &Response{Text: payload, Model: model}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := InspectCacheFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Entries != 40 || report.Models["gpt-a"] != 30 || report.Models["gpt-b"] != 10 || report.Oldest == nil {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	if _, err := PruneCacheFile(path, PruneOptions{}); err == nil {
+		t.Fatal("expected prune without filters to fail")
+	}
+	n, err := PruneCacheFile(path, PruneOptions{Model: "gpt-b"})
+	if err != nil || n != 10 {
+		t.Fatalf("expected 10 pruned by model, got %d %v", n, err)
+	}
+	n, err = PruneCacheFile(path, PruneOptions{OlderThan: time.Hour})
+	if err != nil || n != 0 {
+		t.Fatalf("expected nothing older than an hour, got %d %v", n, err)
+	}
+	n, err = PruneCacheFile(path, PruneOptions{OlderThan: time.Hour, Now: time.Now().Add(2 * time.Hour)})
+	if err != nil || n != 30 {
+		t.Fatalf("expected 30 pruned by age, got %d %v", n, err)
+	}
+
+	before, after, err := CompactCacheFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after >= before {
+		t.Fatalf("expected compaction to shrink the file, got %d -> %d", before, after)
+	}
+	if report, err = InspectCacheFile(path); err != nil || report.Entries != 0 {
+		t.Fatalf("expected an empty compacted cache, got %+v %v", report, err)
+	}
+	if _, err := InspectCacheFile(filepath.Join(t.TempDir(), "missing.bbolt")); err == nil {
+		t.Fatal("expected inspecting a missing file to fail")
+	}
+}
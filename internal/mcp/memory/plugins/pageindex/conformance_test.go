@@ -29,16 +29,16 @@ func TestPluginConformance_NoStorage(t *testing.T) {
 type conformanceFixture struct{}
 
 func (conformanceFixture) Plugin() mcpplugin.Plugin { return &conformanceStubPlugin{} }
-func (conformanceFixture) NewAuthContext(t *testing.T) mcpplugin.AuthContext {
+func (conformanceFixture) NewAuthContext(t conformance.T) mcpplugin.AuthContext {
 	t.Helper()
 	return mcpplugin.AuthContext{APIKey: "k", APIKeyHash: "h", UserIdentity: "u:" + t.Name()}
 }
-func (conformanceFixture) NewProject(t *testing.T) string {
+func (conformanceFixture) NewProject(t conformance.T) string {
 	t.Helper()
 	return "conf-" + t.Name()
 }
-func (conformanceFixture) HasStorage() bool   { return false }
-func (conformanceFixture) Cleanup(*testing.T) {}
+func (conformanceFixture) HasStorage() bool      { return false }
+func (conformanceFixture) Cleanup(conformance.T) {}
 
 // conformanceStubPlugin is a contract-shaped stand-in that does not depend on
 // a real *files.Service. It exists to satisfy the Plugin interface so the
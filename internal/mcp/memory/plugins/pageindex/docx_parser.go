@@ -0,0 +1,127 @@
+package pageindex
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	errors "github.com/Laisky/errors/v2"
+)
+
+const (
+	// docxBodyPart is the zip entry holding the main document story.
+	docxBodyPart = "word/document.xml"
+	// maxDOCXBodyBytes bounds the decompressed body so a crafted archive
+	// cannot exhaust memory.
+	maxDOCXBodyBytes = 64 << 20
+)
+
+// ExtractDOCXBlocks reads the paragraphs of a .docx document in order.
+// Headings are recognized from the built-in "Title" and "HeadingN" paragraph
+// styles, falling back to an explicit outline level on the paragraph.
+func ExtractDOCXBlocks(content []byte) ([]DocBlock, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, errors.Wrap(err, "open docx archive")
+	}
+	var part *zip.File
+	for _, f := range zr.File {
+		if f.Name == docxBodyPart {
+			part = f
+			break
+		}
+	}
+	if part == nil {
+		return nil, errors.Errorf("docx archive has no %s", docxBodyPart)
+	}
+	rc, err := part.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "open docx body")
+	}
+	defer rc.Close() //nolint:errcheck
+
+	dec := xml.NewDecoder(io.LimitReader(rc, maxDOCXBodyBytes))
+	var (
+		blocks       []DocBlock
+		buf          strings.Builder
+		inText       bool
+		styleLevel   int
+		outlineLevel int
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "decode docx body")
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				buf.Reset()
+				styleLevel, outlineLevel = 0, 0
+			case "pStyle":
+				styleLevel = docxStyleLevel(docxAttr(t, "val"))
+			case "outlineLvl":
+				if lvl, convErr := strconv.Atoi(docxAttr(t, "val")); convErr == nil && lvl >= 0 && lvl < 9 {
+					outlineLevel = lvl + 1
+				}
+			case "t":
+				inText = true
+			case "tab", "br", "cr":
+				buf.WriteByte(' ')
+			}
+		case xml.CharData:
+			if inText {
+				buf.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text := collapseSpace(buf.String())
+				buf.Reset()
+				if text == "" {
+					continue
+				}
+				level := styleLevel
+				if level == 0 {
+					level = outlineLevel
+				}
+				blocks = append(blocks, DocBlock{Level: level, Text: text})
+			}
+		}
+	}
+	return blocks, nil
+}
+
+// docxStyleLevel maps a paragraph style ID to a heading depth, or 0 for body text.
+func docxStyleLevel(style string) int {
+	style = strings.ToLower(strings.ReplaceAll(style, " ", ""))
+	if style == "title" {
+		return 1
+	}
+	if rest, ok := strings.CutPrefix(style, "heading"); ok {
+		if lvl, err := strconv.Atoi(rest); err == nil && lvl >= 1 && lvl <= 9 {
+			return lvl
+		}
+	}
+	return 0
+}
+
+// docxAttr returns the value of the attribute with the given local name.
+func docxAttr(el xml.StartElement, local string) string {
+	for _, attr := range el.Attr {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
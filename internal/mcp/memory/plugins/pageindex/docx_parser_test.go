@@ -0,0 +1,59 @@
+package pageindex
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+// buildTestDOCX zips a minimal word/document.xml body.
+func buildTestDOCX(t *testing.T, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(docxBodyPart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		body + `</w:body></w:document>`
+	if _, err := w.Write([]byte(doc)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractDOCXBlocks(t *testing.T) {
+	data := buildTestDOCX(t,
+		`<w:p><w:pPr><w:pStyle w:val="Title"/></w:pPr><w:r><w:t>Handbook</w:t></w:r></w:p>`+
+			`<w:p><w:r><w:t xml:space="preserve">Split </w:t></w:r><w:r><w:t>runs</w:t><w:tab/><w:t>joined</w:t></w:r></w:p>`+
+			`<w:p><w:pPr><w:pStyle w:val="Heading2"/></w:pPr><w:r><w:t>Details</w:t></w:r></w:p>`+
+			`<w:p><w:pPr><w:outlineLvl w:val="2"/></w:pPr><w:r><w:t>Outlined</w:t></w:r></w:p>`+
+			`<w:p></w:p>`)
+	blocks, err := ExtractDOCXBlocks(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DocBlock{
+		{Level: 1, Text: "Handbook"},
+		{Text: "Split runs joined"},
+		{Level: 2, Text: "Details"},
+		{Level: 3, Text: "Outlined"},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("expected %d blocks, got %d: %+v", len(want), len(blocks), blocks)
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Fatalf("block %d: expected %+v, got %+v", i, want[i], blocks[i])
+		}
+	}
+
+	if _, err := ExtractDOCXBlocks([]byte("not a zip")); err == nil {
+		t.Fatal("expected error for non-zip input")
+	}
+}
@@ -0,0 +1,124 @@
+package pageindex
+
+import (
+	"bytes"
+	"strings"
+
+	errors "github.com/Laisky/errors/v2"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DocBlock is one paragraph of a structured document. Level is the heading
+// depth (1 = top level) or 0 for body text.
+type DocBlock struct {
+	Level int
+	Text  string
+}
+
+// htmlHeadingLevels maps heading elements to their depth.
+var htmlHeadingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// htmlSkipped lists elements whose content never reaches the index.
+var htmlSkipped = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+}
+
+// htmlBlockElements break the surrounding text into separate paragraphs.
+var htmlBlockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Li: true, atom.Ul: true, atom.Ol: true,
+	atom.Dl: true, atom.Dt: true, atom.Dd: true, atom.Table: true, atom.Tr: true,
+	atom.Blockquote: true, atom.Section: true, atom.Article: true, atom.Header: true,
+	atom.Footer: true, atom.Nav: true, atom.Aside: true, atom.Main: true,
+	atom.Figure: true, atom.Figcaption: true, atom.Form: true, atom.Br: true, atom.Hr: true,
+}
+
+// ExtractHTMLBlocks parses an HTML document into paragraphs in document
+// order, marking h1–h6 as headings. Scripts, styles and <head> are dropped
+// and whitespace is collapsed everywhere except inside <pre>.
+func ExtractHTMLBlocks(content []byte) ([]DocBlock, error) {
+	root, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse html")
+	}
+
+	var blocks []DocBlock
+	var buf strings.Builder
+	flush := func() {
+		if text := collapseSpace(buf.String()); text != "" {
+			blocks = append(blocks, DocBlock{Text: text})
+		}
+		buf.Reset()
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			buf.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if htmlSkipped[n.DataAtom] {
+				return
+			}
+			if level, ok := htmlHeadingLevels[n.DataAtom]; ok {
+				flush()
+				if title := collapseSpace(htmlText(n)); title != "" {
+					blocks = append(blocks, DocBlock{Level: level, Text: title})
+				}
+				return
+			}
+			if n.DataAtom == atom.Pre {
+				flush()
+				if text := strings.Trim(htmlText(n), "\n"); strings.TrimSpace(text) != "" {
+					blocks = append(blocks, DocBlock{Text: text})
+				}
+				return
+			}
+			if n.DataAtom == atom.Td || n.DataAtom == atom.Th {
+				buf.WriteByte(' ')
+			}
+		}
+
+		block := n.Type == html.ElementNode && htmlBlockElements[n.DataAtom]
+		if block {
+			flush()
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if block {
+			flush()
+		}
+	}
+	walk(root)
+	flush()
+	return blocks, nil
+}
+
+// htmlText concatenates every text node below n, skipping script-like content.
+func htmlText(n *html.Node) string {
+	var sb strings.Builder
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			return
+		}
+		if n.Type == html.ElementNode && htmlSkipped[n.DataAtom] {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(n)
+	return sb.String()
+}
+
+// collapseSpace trims s and folds every whitespace run into one space.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,35 @@
+package pageindex
+
+import "testing"
+
+func TestExtractHTMLBlocks(t *testing.T) {
+	body := []byte(`<html><head><title>ignored</title><style>p{}</style></head><body>
+<p>Intro   text</p>
+<h1>Guide</h1><p>Hello <b>world</b>.</p>
+<script>alert(1)</script>
+<h2>Install</h2><ul><li>step one</li><li>step two</li></ul>
+<pre>go build
+go test</pre>
+</body></html>`)
+	blocks, err := ExtractHTMLBlocks(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DocBlock{
+		{Text: "Intro text"},
+		{Level: 1, Text: "Guide"},
+		{Text: "Hello world."},
+		{Level: 2, Text: "Install"},
+		{Text: "step one"},
+		{Text: "step two"},
+		{Text: "go build\ngo test"},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("expected %d blocks, got %d: %+v", len(want), len(blocks), blocks)
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Fatalf("block %d: expected %+v, got %+v", i, want[i], blocks[i])
+		}
+	}
+}
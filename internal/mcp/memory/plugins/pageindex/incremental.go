@@ -0,0 +1,120 @@
+package pageindex
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// summaryReuse carries the summaries of a previously indexed version of the
+// same document, keyed by node content hash, so re-indexing an edited
+// document only pays LLM calls for the nodes whose text changed.
+type summaryReuse struct {
+	summaries   map[string]string
+	treeHash    string
+	description string
+	// pagesHash digests the previous per-page text; structure is the
+	// previous outline, reusable as a whole while pagesHash still matches.
+	pagesHash string
+	structure []*Node
+}
+
+// newSummaryReuse indexes prev for reuse. It returns nil when prev is absent
+// or was produced by a different pipeline or algorithm version, since its
+// summaries would not match what a fresh run generates.
+func newSummaryReuse(prev *Tree, kind DocKind, algoVer string) *summaryReuse {
+	if prev == nil || prev.Type != kind || prev.AlgorithmVer != algoVer {
+		return nil
+	}
+	r := &summaryReuse{
+		summaries:   map[string]string{},
+		treeHash:    prev.ContentHash,
+		description: prev.DocDescription,
+		structure:   prev.Structure,
+	}
+	if len(prev.Pages) > 0 {
+		pages := make([]string, 0, len(prev.Pages))
+		for _, page := range prev.Pages {
+			pages = append(pages, page.Content)
+		}
+		r.pagesHash = pagesDigest(pages)
+	}
+	WalkNodes(prev.Structure, func(n *Node) {
+		if n.ContentHash != "" && n.Summary != "" {
+			r.summaries[n.ContentHash] = n.Summary
+		}
+	})
+	return r
+}
+
+// summary returns the previous summary for a node with the given content hash.
+func (r *summaryReuse) summary(hash string) (string, bool) {
+	if r == nil || hash == "" {
+		return "", false
+	}
+	s, ok := r.summaries[hash]
+	return s, ok
+}
+
+// docDescription returns the previous description when the outline is unchanged.
+func (r *summaryReuse) docDescription(treeHash string) (string, bool) {
+	if r == nil || treeHash == "" || r.treeHash != treeHash || r.description == "" {
+		return "", false
+	}
+	return r.description, true
+}
+
+// outline returns a copy of the previous outline, summaries included, when
+// the page text digest is unchanged.
+func (r *summaryReuse) outline(pagesHash string) ([]*Node, bool) {
+	if r == nil || r.pagesHash == "" || r.pagesHash != pagesHash || len(r.structure) == 0 {
+		return nil, false
+	}
+	return CloneOutline(r.structure), true
+}
+
+// pagesDigest digests per-page text, including page boundaries.
+func pagesDigest(pages []string) string {
+	h := sha256.New()
+	for _, page := range pages {
+		h.Write([]byte(strconv.Itoa(len(page))))
+		h.Write([]byte{0})
+		h.Write([]byte(page))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// stampContentHashes sets every node's ContentHash from the text its summary
+// is derived from and returns a digest of the whole outline. The outline
+// digest also covers titles and nesting, so it changes whenever any node or
+// ancestor changes.
+func stampContentHashes(nodes []*Node, textOf func(*Node) string) string {
+	outline := sha256.New()
+	var visit func(nodes []*Node, depth int)
+	visit = func(nodes []*Node, depth int) {
+		for _, n := range nodes {
+			if n == nil {
+				continue
+			}
+			sum := sha256.Sum256([]byte(textOf(n)))
+			n.ContentHash = hex.EncodeToString(sum[:])
+			outline.Write([]byte(strconv.Itoa(depth)))
+			outline.Write([]byte{0})
+			outline.Write([]byte(n.Title))
+			outline.Write([]byte{0})
+			outline.Write([]byte(n.ContentHash))
+			outline.Write([]byte{0})
+			visit(n.Children, depth+1)
+		}
+	}
+	visit(nodes, 0)
+	return hex.EncodeToString(outline.Sum(nil))
+}
+
+// nodeText is the summary source for heading-section trees.
+func nodeText(n *Node) string { return n.Text }
+
+// pageRangeTextOf returns the summary source for page-range trees.
+func pageRangeTextOf(pages []string) func(*Node) string {
+	return func(n *Node) string { return pageRangeText(pages, n.StartIndex, n.EndIndex) }
+}
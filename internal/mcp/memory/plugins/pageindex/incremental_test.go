@@ -0,0 +1,197 @@
+package pageindex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestIndexReusesUnchangedSummaries(t *testing.T) {
+	tk, err := NewTokenizer("gpt-5.4-mini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := NewStubLLM()
+	stub.SetDefault(TextResponse("summary"))
+	cfg := defaultTestSettings()
+	cfg.Algo.GenerateNodeSummary = true
+	idx, err := NewIndexer(Deps{LLM: stub, Tokenizer: tk, Settings: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	original := []byte("# Manual\n\n## Install\n\nrun make\n\n## Usage\n\ncall api\n\n## FAQ\n\nask us\n")
+	first, firstStats, err := idx.Index(ctx, KindMarkdown, original, IndexOptions{DocID: "d1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if firstStats.LLMCalls != 3 || firstStats.SavedLLMCalls != 0 {
+		t.Fatalf("expected 3 fresh calls, got calls=%d saved=%d", firstStats.LLMCalls, firstStats.SavedLLMCalls)
+	}
+
+	edited := []byte("# Manual\n\n## Install\n\nrun make\n\n## Usage\n\ncall the v2 api\n\n## FAQ\n\nask us\n")
+	second, secondStats, err := idx.Index(ctx, KindMarkdown, edited, IndexOptions{DocID: "d1", Previous: first}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secondStats.LLMCalls != 1 || secondStats.SavedLLMCalls != 2 {
+		t.Fatalf("expected 1 fresh and 2 saved calls, got calls=%d saved=%d", secondStats.LLMCalls, secondStats.SavedLLMCalls)
+	}
+	if second.ContentHash == first.ContentHash {
+		t.Fatal("expected outline hash to change after an edit")
+	}
+	WalkNodes(second.Structure, func(n *Node) {
+		if len(n.Children) == 0 && n.Summary != "summary" {
+			t.Fatalf("leaf %q lost its summary", n.Title)
+		}
+	})
+
+	_, staleStats, err := idx.Index(ctx, KindMarkdown, edited, IndexOptions{DocID: "d1", AlgorithmVersion: "other", Previous: second}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staleStats.SavedLLMCalls != 0 {
+		t.Fatalf("expected no reuse across algorithm versions, got %d", staleStats.SavedLLMCalls)
+	}
+}
+
+// pdfOutlineStubLLM answers the no-TOC PDF prompts deterministically and
+// counts calls per prompt. A TOC group starting on page 4k+1 is a top-level
+// pass and yields one "Part" entry per six pages; any other group comes from
+// expansion and yields one entry per page.
+type pdfOutlineStubLLM struct {
+	mu        sync.Mutex
+	calls     int
+	summaries int
+}
+
+var stubPageTag = regexp.MustCompile(`(?m)^<physical_index_(\d+)>$`)
+
+func (s *pdfOutlineStubLLM) Respond(ctx context.Context, req Request) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	body := ""
+	for _, it := range req.Input {
+		body += it.Content
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	switch {
+	case strings.Contains(body, "generate the tree structure"), strings.Contains(body, "continue the tree structure"):
+		var pages []int
+		for _, m := range stubPageTag.FindAllStringSubmatch(body, -1) {
+			if n, _ := strconv.Atoi(m[1]); !slices.Contains(pages, n) {
+				pages = append(pages, n)
+			}
+		}
+		entries := []map[string]any{}
+		for _, p := range pages {
+			tag := fmt.Sprintf("<physical_index_%d>", p)
+			switch {
+			case pages[0]%4 != 1:
+				entries = append(entries, map[string]any{"structure": strconv.Itoa(p), "title": fmt.Sprintf("Page %d", p), "physical_index": tag})
+			case p%6 == 1:
+				entries = append(entries, map[string]any{"structure": strconv.Itoa(p), "title": fmt.Sprintf("Part %d", p), "physical_index": tag})
+			}
+		}
+		out, _ := json.Marshal(entries)
+		return &Response{Text: string(out)}, nil
+	case strings.Contains(body, "description of the partial document"):
+		s.summaries++
+		return &Response{Text: "summary"}, nil
+	}
+	return &Response{Text: "{}"}, nil
+}
+
+func (s *pdfOutlineStubLLM) CountTokens(_ context.Context, req Request) (int, error) {
+	total := 0
+	for _, it := range req.Input {
+		total += len(it.Content) / 4
+	}
+	return total, nil
+}
+
+// counts returns the total and node-summary call counts.
+func (s *pdfOutlineStubLLM) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls, s.summaries
+}
+
+func TestIndexPDFReusesOutlineAndExpandedSummaries(t *testing.T) {
+	pages := make([]string, 20)
+	for i := range pages {
+		pages[i] = fmt.Sprintf("page body %d", i+1)
+	}
+	parser := &fakePagesParser{pages: pages}
+	stub := &pdfOutlineStubLLM{}
+	cfg := defaultTestSettings()
+	cfg.Algo.MaxPageNumEachNode = 2
+	cfg.Algo.MaxTokenNumEachNode = 400
+	cfg.Algo.GenerateNodeSummary = true
+	cfg.Algo.GenerateDocDescription = true
+	idx, err := NewIndexer(Deps{LLM: stub, PDF: parser, Tokenizer: &fatTokenizer{perCall: 100}, Settings: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	first, _, err := idx.Index(ctx, KindPDF, []byte("v1"), IndexOptions{DocID: "p1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var leaves, expanded int
+	WalkNodes(first.Structure, func(n *Node) {
+		if len(n.Children) == 0 {
+			leaves++
+			if strings.HasPrefix(n.Title, "Page ") {
+				expanded++
+			}
+		}
+	})
+	if expanded == 0 {
+		t.Fatal("expected expansion to produce page-level leaves")
+	}
+	if _, summaries := stub.counts(); summaries != leaves {
+		t.Fatalf("expected %d summary calls on first index, got %d", leaves, summaries)
+	}
+
+	// Same page text under new bytes: the whole outline is reused.
+	callsBefore, _ := stub.counts()
+	same, sameStats, err := idx.Index(ctx, KindPDF, []byte("v1 resaved"), IndexOptions{DocID: "p1", Previous: first}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls, _ := stub.counts(); calls != callsBefore || sameStats.LLMCalls != 0 {
+		t.Fatalf("expected no LLM calls for unchanged pages, got %d", calls-callsBefore)
+	}
+	if same.ContentHash != first.ContentHash || same.DocDescription != first.DocDescription {
+		t.Fatal("expected the reused outline to keep its hash and description")
+	}
+
+	// One edited page inside an expanded node: the outline is rebuilt, but
+	// only the leaf covering that page is summarized again.
+	edited := append([]string(nil), pages...)
+	edited[9] = "page body 10, revised"
+	parser.pages = edited
+	_, summariesBefore := stub.counts()
+	_, editStats, err := idx.Index(ctx, KindPDF, []byte("v2"), IndexOptions{DocID: "p1", Previous: first}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, summaries := stub.counts(); summaries-summariesBefore != 1 {
+		t.Fatalf("expected 1 summary call after editing one page, got %d", summaries-summariesBefore)
+	}
+	if editStats.SavedLLMCalls != leaves-1 {
+		t.Fatalf("expected %d saved summary calls, got %d", leaves-1, editStats.SavedLLMCalls)
+	}
+}
@@ -24,7 +24,17 @@ type Stats struct {
 	InputTokens  int
 	OutputTokens int
 	Cached       int
-	Wallclock    time.Duration
+	// SavedLLMCalls counts summary and description calls skipped because an
+	// unchanged node was found in IndexOptions.Previous.
+	SavedLLMCalls int
+	// CostUSD prices the uncached calls with LimitSettings token rates.
+	CostUSD float64
+	// BudgetExhausted names the first budget that refused an LLM call, or is
+	// empty when the index completed within every budget.
+	BudgetExhausted string
+	Wallclock       time.Duration
+
+	ceiling *spendCeiling
 }
 
 // addLLMCall accumulates one LLM-call's accounting under the stats lock so
@@ -40,6 +50,31 @@ func (s *Stats) addLLMCall(in, out int) {
 	s.mu.Unlock()
 }
 
+// charge records one uncached response against the cost total and the
+// document and API-key limits.
+func (s *Stats) charge(limits LimitSettings, usage Usage) {
+	if s == nil {
+		return
+	}
+	cost := limits.costUSD(usage)
+	s.mu.Lock()
+	s.CostUSD += cost
+	s.mu.Unlock()
+	s.ceiling.charge(int64(usage.TotalTokens), cost)
+}
+
+// exhaust records the first budget that refused a call.
+func (s *Stats) exhaust(reason string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.BudgetExhausted == "" {
+		s.BudgetExhausted = reason
+	}
+	s.mu.Unlock()
+}
+
 // addCached increments the cached counter atomically.
 func (s *Stats) addCached() {
 	if s == nil {
@@ -50,10 +85,26 @@ func (s *Stats) addCached() {
 	s.mu.Unlock()
 }
 
+// addSaved increments the saved-call counter atomically.
+func (s *Stats) addSaved() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.SavedLLMCalls++
+	s.mu.Unlock()
+}
+
 // IndexOptions tweak per-call indexing behavior.
 type IndexOptions struct {
 	DocID            string
 	AlgorithmVersion string
+	// Previous is the last tree indexed for the same document, if any. Nodes
+	// whose content hash is unchanged reuse its summaries instead of calling
+	// the LLM again.
+	Previous *Tree
+	// APIKeyHash attributes the LLM spend to a key for the per-key limits.
+	APIKeyHash string
 }
 
 // Deps gathers the indexer's runtime collaborators.
@@ -76,6 +127,7 @@ type Indexer struct {
 	sem   *semaphore.Weighted
 	cfg   Settings
 	log   logSDK.Logger
+	keys  *keyLedger
 }
 
 // NewIndexer wires the indexer fields, supplying defaults where allowed.
@@ -115,13 +167,14 @@ func NewIndexer(deps Deps) (*Indexer, error) {
 		sem:   deps.Sem,
 		cfg:   deps.Settings,
 		log:   deps.Logger,
+		keys:  newKeyLedger(deps.Settings.Indexer.Limits.KeyWindow),
 	}, nil
 }
 
 // Index drives the §2.6.4.1 pipeline against the supplied bytes and returns the tree.
 func (idx *Indexer) Index(ctx context.Context, kind DocKind, bytes []byte, opts IndexOptions, progress chan<- Progress) (*Tree, *Stats, error) {
 	rep := NewReporter(progress, idx.log)
-	stats := &Stats{}
+	stats := &Stats{ceiling: idx.newCeiling(opts.APIKeyHash)}
 	start := time.Now()
 	docID := opts.DocID
 	if docID == "" {
@@ -132,14 +185,21 @@ func (idx *Indexer) Index(ctx context.Context, kind DocKind, bytes []byte, opts
 	if algoVer == "" {
 		algoVer = AlgorithmVersion
 	}
+	reuse := newSummaryReuse(opts.Previous, kind, algoVer)
 	rep.Report(Progress{Phase: "init", Percent: 0, Message: "begin"})
 	var tree *Tree
 	var err error
 	switch kind {
 	case KindPDF:
-		tree, err = idx.runPDF(ctx, bytes, rep, stats)
+		tree, err = idx.runPDF(ctx, bytes, reuse, rep, stats)
 	case KindMarkdown:
-		tree, err = idx.runMarkdown(ctx, bytes, rep, stats)
+		tree, err = idx.runMarkdown(ctx, bytes, reuse, rep, stats)
+	case KindHTML:
+		tree, err = idx.runHTML(ctx, bytes, reuse, rep, stats)
+	case KindDOCX:
+		tree, err = idx.runDOCX(ctx, bytes, reuse, rep, stats)
+	case KindText:
+		tree, err = idx.runText(ctx, bytes, reuse, rep, stats)
 	default:
 		return nil, nil, errors.Errorf("unsupported kind %q", kind)
 	}
@@ -149,6 +209,11 @@ func (idx *Indexer) Index(ctx context.Context, kind DocKind, bytes []byte, opts
 	tree.DocID = docID
 	tree.Type = kind
 	tree.AlgorithmVer = algoVer
+	tree.Status = IndexStatusComplete
+	if stats.BudgetExhausted != "" {
+		tree.Status = IndexStatusBudgetExhausted
+		tree.StatusReason = stats.BudgetExhausted
+	}
 	tree.IndexedAt = time.Now().UTC().Format(time.RFC3339Nano)
 	stats.Wallclock = time.Since(start)
 	rep.Report(Progress{Phase: "done", Percent: 100, TokensIn: stats.InputTokens, TokensOut: stats.OutputTokens, LLMCalls: stats.LLMCalls})
@@ -158,6 +223,7 @@ func (idx *Indexer) Index(ctx context.Context, kind DocKind, bytes []byte, opts
 // callLLM is the cache-aware, budgeted entry point used by every phase.
 func (idx *Indexer) callLLM(ctx context.Context, req Request, budget *Budget, stats *Stats) (*Response, error) {
 	if budget != nil && budget.Remaining() <= 0 {
+		stats.exhaust(LimitReasonPhaseTokens)
 		return nil, ErrBudgetExceeded
 	}
 	if req.PromptHash == [32]byte{} {
@@ -167,6 +233,11 @@ func (idx *Indexer) callLLM(ctx context.Context, req Request, budget *Budget, st
 		stats.addCached()
 		return cached, nil
 	}
+	// Cached answers are free, so the spend limits only gate real calls.
+	if reason := stats.ceiling.exhausted(); reason != "" {
+		stats.exhaust(reason)
+		return nil, ErrBudgetExceeded
+	}
 	if idx.sem != nil {
 		if err := idx.sem.Acquire(ctx, 1); err != nil {
 			return nil, err
@@ -178,9 +249,16 @@ func (idx *Indexer) callLLM(ctx context.Context, req Request, budget *Budget, st
 		return nil, err
 	}
 	stats.addLLMCall(resp.Usage.InputTokens, resp.Usage.OutputTokens)
+	stats.charge(idx.cfg.Indexer.Limits, resp.Usage)
 	if budget != nil {
 		budget.Take(int64(resp.Usage.TotalTokens))
 	}
+	if resp.Model == "" {
+		// copy so scripted LLMs can keep sharing their response values
+		stamped := *resp
+		stamped.Model = req.Model
+		resp = &stamped
+	}
 	if err := idx.cache.Put(req.PromptHash, resp); err != nil && idx.log != nil {
 		idx.log.Warn(fmt.Sprintf("pageindex.cache.put: %v", err))
 	}
@@ -189,6 +267,11 @@ func (idx *Indexer) callLLM(ctx context.Context, req Request, budget *Budget, st
 
 // GetPageContent resolves page ranges against the cached tree's pages.
 func (idx *Indexer) GetPageContent(tree *Tree, ranges []PageRange) ([]Chunk, error) {
+	return pageContent(tree, ranges)
+}
+
+// pageContent resolves page ranges against tree.Pages, deduplicated and in page order.
+func pageContent(tree *Tree, ranges []PageRange) ([]Chunk, error) {
 	if tree == nil {
 		return nil, errors.New("tree is nil")
 	}
@@ -226,6 +309,11 @@ func (idx *Indexer) GetPageContent(tree *Tree, ranges []PageRange) ([]Chunk, err
 
 // GetDocumentStructure returns a token-light outline view.
 func (idx *Indexer) GetDocumentStructure(tree *Tree) StructureView {
+	return structureView(tree)
+}
+
+// structureView builds the outline view shared by search and the doc_outline tool.
+func structureView(tree *Tree) StructureView {
 	if tree == nil {
 		return StructureView{}
 	}
@@ -236,6 +324,8 @@ func (idx *Indexer) GetDocumentStructure(tree *Tree) StructureView {
 		PageCount:      tree.PageCount,
 		LineCount:      tree.LineCount,
 		Outline:        CloneOutline(tree.Structure),
+		Status:         tree.Status,
+		StatusReason:   tree.StatusReason,
 	}
 }
 
@@ -0,0 +1,194 @@
+package pageindex
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Tree.Status values.
+const (
+	// IndexStatusComplete marks a tree built without hitting any budget.
+	IndexStatusComplete = "complete"
+	// IndexStatusBudgetExhausted marks a tree that was cut short by a budget:
+	// some summaries, verification fixes or sub-trees are missing, and a PDF
+	// whose outline could not be extracted falls back to fixed page groups.
+	IndexStatusBudgetExhausted = "budget_exhausted"
+)
+
+// Tree.StatusReason values naming the budget that ran out first.
+const (
+	LimitReasonDocTokens   = "doc_max_tokens"
+	LimitReasonDocCalls    = "doc_max_calls"
+	LimitReasonDocCost     = "doc_max_cost"
+	LimitReasonKeyTokens   = "key_max_tokens"
+	LimitReasonKeyCalls    = "key_max_calls"
+	LimitReasonKeyCost     = "key_max_cost"
+	LimitReasonPhaseTokens = "phase_tokens"
+)
+
+// spend accumulates the LLM usage charged against one limit scope.
+type spend struct {
+	tokens  int64
+	calls   int
+	costUSD float64
+}
+
+// exhausted returns the first limit spend has reached, using the per-document
+// or per-key reason names; zero limits are disabled.
+func (s spend) exhausted(maxTokens int64, maxCalls int, maxCost float64, reasons [3]string) string {
+	switch {
+	case maxTokens > 0 && s.tokens >= maxTokens:
+		return reasons[0]
+	case maxCalls > 0 && s.calls >= maxCalls:
+		return reasons[1]
+	case maxCost > 0 && s.costUSD >= maxCost:
+		return reasons[2]
+	}
+	return ""
+}
+
+var (
+	docLimitReasons = [3]string{LimitReasonDocTokens, LimitReasonDocCalls, LimitReasonDocCost}
+	keyLimitReasons = [3]string{LimitReasonKeyTokens, LimitReasonKeyCalls, LimitReasonKeyCost}
+)
+
+// costUSD prices one response with the configured per-million-token rates.
+func (l LimitSettings) costUSD(u Usage) float64 {
+	return (float64(u.InputTokens)*l.InputCostPerMTok + float64(u.OutputTokens)*l.OutputCostPerMTok) / 1e6
+}
+
+// unpricedCostLimit reports whether a USD limit is set while a per-MTok rate
+// is 0, so the tokens at that rate never count toward the limit.
+func (l LimitSettings) unpricedCostLimit() bool {
+	return (l.DocMaxCostUSD > 0 || l.KeyMaxCostUSD > 0) && (l.InputCostPerMTok <= 0 || l.OutputCostPerMTok <= 0)
+}
+
+// keyLimited reports whether any per-API-key limit is configured.
+func (l LimitSettings) keyLimited() bool {
+	return l.KeyMaxTokens > 0 || l.KeyMaxCalls > 0 || l.KeyMaxCostUSD > 0
+}
+
+// keyLedger tracks per-API-key indexing spend over fixed windows. It lives
+// in memory, so a restart starts every key with a fresh window.
+type keyLedger struct {
+	mu     sync.Mutex
+	window time.Duration
+	now    func() time.Time
+	keys   map[string]*keySpend
+}
+
+type keySpend struct {
+	windowStart time.Time
+	spend
+}
+
+func newKeyLedger(window time.Duration) *keyLedger {
+	if window <= 0 {
+		window = 24 * time.Hour
+	}
+	return &keyLedger{window: window, now: time.Now, keys: map[string]*keySpend{}}
+}
+
+// current returns key's spend in the active window, opening a new window
+// when the previous one has elapsed. Callers hold l.mu.
+func (l *keyLedger) current(key string) *keySpend {
+	now := l.now()
+	ks, ok := l.keys[key]
+	if !ok || now.Sub(ks.windowStart) >= l.window {
+		ks = &keySpend{windowStart: now}
+		l.keys[key] = ks
+	}
+	return ks
+}
+
+// spent returns key's spend in the active window.
+func (l *keyLedger) spent(key string) spend {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.current(key).spend
+}
+
+// charge adds one response to key's active window.
+func (l *keyLedger) charge(key string, tokens int64, costUSD float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ks := l.current(key)
+	ks.tokens += tokens
+	ks.calls++
+	ks.costUSD += costUSD
+}
+
+// spendCeiling enforces LimitSettings for one Index call. Limits are checked
+// before each uncached LLM call, so concurrent phases may overshoot by the
+// calls already in flight.
+type spendCeiling struct {
+	mu     sync.Mutex
+	limits LimitSettings
+	doc    spend
+	ledger *keyLedger
+	key    string
+}
+
+// newCeiling returns the ceiling for one document indexed under apiKeyHash,
+// or nil when no limit applies.
+func (idx *Indexer) newCeiling(apiKeyHash string) *spendCeiling {
+	limits := idx.cfg.Indexer.Limits
+	c := &spendCeiling{limits: limits}
+	if apiKeyHash != "" && limits.keyLimited() {
+		c.ledger = idx.keys
+		c.key = apiKeyHash
+	}
+	if c.ledger == nil && limits.DocMaxTokens <= 0 && limits.DocMaxCalls <= 0 && limits.DocMaxCostUSD <= 0 {
+		return nil
+	}
+	return c
+}
+
+// exhausted returns the reason the next LLM call must be refused, if any.
+func (c *spendCeiling) exhausted() string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	reason := c.doc.exhausted(c.limits.DocMaxTokens, c.limits.DocMaxCalls, c.limits.DocMaxCostUSD, docLimitReasons)
+	c.mu.Unlock()
+	if reason != "" || c.ledger == nil {
+		return reason
+	}
+	return c.ledger.spent(c.key).exhausted(c.limits.KeyMaxTokens, c.limits.KeyMaxCalls, c.limits.KeyMaxCostUSD, keyLimitReasons)
+}
+
+// charge records one response against the document and the API key.
+func (c *spendCeiling) charge(tokens int64, costUSD float64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.doc.tokens += tokens
+	c.doc.calls++
+	c.doc.costUSD += costUSD
+	c.mu.Unlock()
+	if c.ledger != nil {
+		c.ledger.charge(c.key, tokens, costUSD)
+	}
+}
+
+// pageGroupTOC is the shallow outline used when a PDF's table of contents
+// cannot be extracted within budget: one entry per maxPages pages.
+func pageGroupTOC(pageCount, maxPages int) []map[string]any {
+	if maxPages <= 0 {
+		maxPages = 10
+	}
+	flat := make([]map[string]any, 0, (pageCount+maxPages-1)/maxPages)
+	for start := 1; start <= pageCount; start += maxPages {
+		end := min(start+maxPages-1, pageCount)
+		flat = append(flat, map[string]any{
+			"structure":      strconv.Itoa(len(flat) + 1),
+			"title":          fmt.Sprintf("Pages %d-%d", start, end),
+			"physical_index": start,
+		})
+	}
+	return flat
+}
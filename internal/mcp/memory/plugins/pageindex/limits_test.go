@@ -0,0 +1,152 @@
+package pageindex
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakePagesParser serves fixed page texts without parsing PDF bytes.
+type fakePagesParser struct{ pages []string }
+
+func (f fakePagesParser) PageCount(context.Context, []byte) (int, error) { return len(f.pages), nil }
+
+func (f fakePagesParser) PageText(_ context.Context, _ []byte, page int) (string, error) {
+	return f.pages[page-1], nil
+}
+
+func (f fakePagesParser) PagesText(context.Context, []byte) ([]string, error) { return f.pages, nil }
+
+func (f fakePagesParser) Outline(context.Context, []byte) ([]Bookmark, error) { return nil, nil }
+
+func newLimitedIndexer(t *testing.T, limits LimitSettings, pdf PDFParser) (*Indexer, *StubLLM) {
+	t.Helper()
+	tk, err := NewTokenizer("gpt-5.4-mini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := NewStubLLM()
+	stub.SetDefault(&Response{Text: "summary", Usage: Usage{InputTokens: 100, OutputTokens: 20, TotalTokens: 120}})
+	cfg := defaultTestSettings()
+	cfg.Algo.GenerateNodeSummary = true
+	cfg.Indexer.Limits = limits
+	idx, err := NewIndexer(Deps{LLM: stub, PDF: pdf, Tokenizer: tk, Settings: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idx, stub
+}
+
+const limitsTestDoc = "# Manual\n\n## Install\n\nrun make\n\n## Usage\n\ncall api\n\n## FAQ\n\nask us\n"
+
+func TestIndexDocLimitsDegradeGracefully(t *testing.T) {
+	ctx := context.Background()
+	idx, stub := newLimitedIndexer(t, LimitSettings{DocMaxCalls: 2}, nil)
+	tree, stats, err := idx.Index(ctx, KindMarkdown, []byte(limitsTestDoc), IndexOptions{DocID: "d1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stub.CallCount() != 2 || stats.LLMCalls != 2 {
+		t.Fatalf("expected the call limit to stop at 2 calls, got stub=%d stats=%d", stub.CallCount(), stats.LLMCalls)
+	}
+	if tree.Status != IndexStatusBudgetExhausted || tree.StatusReason != LimitReasonDocCalls {
+		t.Fatalf("unexpected status %q/%q", tree.Status, tree.StatusReason)
+	}
+	missing := 0
+	WalkNodes(tree.Structure, func(n *Node) {
+		if len(n.Children) == 0 && n.Summary == "" {
+			missing++
+		}
+	})
+	if missing != 1 {
+		t.Fatalf("expected exactly one unsummarized leaf, got %d", missing)
+	}
+
+	// $1 per million input tokens: each call costs $0.0001, so $0.0001 allows one call.
+	idx, _ = newLimitedIndexer(t, LimitSettings{DocMaxCostUSD: 0.0001, InputCostPerMTok: 1}, nil)
+	tree, stats, err = idx.Index(ctx, KindMarkdown, []byte(limitsTestDoc), IndexOptions{DocID: "d1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.LLMCalls != 1 || tree.StatusReason != LimitReasonDocCost {
+		t.Fatalf("expected the cost limit to stop after 1 call, got calls=%d reason=%q", stats.LLMCalls, tree.StatusReason)
+	}
+
+	idx, _ = newLimitedIndexer(t, LimitSettings{DocMaxTokens: 1000}, nil)
+	tree, _, err = idx.Index(ctx, KindMarkdown, []byte(limitsTestDoc), IndexOptions{DocID: "d1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Status != IndexStatusComplete || tree.StatusReason != "" {
+		t.Fatalf("expected a complete tree within budget, got %q/%q", tree.Status, tree.StatusReason)
+	}
+}
+
+func TestIndexKeyLimitsSpanDocumentsAndWindows(t *testing.T) {
+	ctx := context.Background()
+	idx, _ := newLimitedIndexer(t, LimitSettings{KeyMaxCalls: 4, KeyWindow: time.Hour}, nil)
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	idx.keys.now = func() time.Time { return now }
+
+	first, _, err := idx.Index(ctx, KindMarkdown, []byte(limitsTestDoc), IndexOptions{DocID: "a", APIKeyHash: "k1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Status != IndexStatusComplete {
+		t.Fatalf("expected the first document to complete, got %q", first.StatusReason)
+	}
+	second, stats, err := idx.Index(ctx, KindMarkdown, []byte(limitsTestDoc), IndexOptions{DocID: "b", APIKeyHash: "k1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.LLMCalls != 1 || second.StatusReason != LimitReasonKeyCalls {
+		t.Fatalf("expected the key limit to allow 1 more call, got calls=%d reason=%q", stats.LLMCalls, second.StatusReason)
+	}
+	other, _, err := idx.Index(ctx, KindMarkdown, []byte(limitsTestDoc), IndexOptions{DocID: "c", APIKeyHash: "k2"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Status != IndexStatusComplete {
+		t.Fatalf("expected another key to be unaffected, got %q", other.StatusReason)
+	}
+
+	now = now.Add(time.Hour)
+	renewed, _, err := idx.Index(ctx, KindMarkdown, []byte(limitsTestDoc), IndexOptions{DocID: "b", APIKeyHash: "k1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed.Status != IndexStatusComplete {
+		t.Fatalf("expected a new window to reset the key budget, got %q", renewed.StatusReason)
+	}
+}
+
+func TestIndexPDFFallsBackToPageGroups(t *testing.T) {
+	pages := make([]string, 25)
+	for i := range pages {
+		pages[i] = fmt.Sprintf("page %d body text", i+1)
+	}
+	idx, _ := newLimitedIndexer(t, LimitSettings{DocMaxCalls: 1}, fakePagesParser{pages: pages})
+	idx.cfg.Algo.MaxPageNumEachNode = 10
+
+	tree, _, err := idx.Index(context.Background(), KindPDF, []byte("%PDF"), IndexOptions{DocID: "p1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Status != IndexStatusBudgetExhausted {
+		t.Fatalf("expected a budget-exhausted tree, got %q", tree.Status)
+	}
+	want := []struct {
+		title      string
+		start, end int
+	}{{"Pages 1-10", 1, 10}, {"Pages 11-20", 11, 20}, {"Pages 21-25", 21, 25}}
+	if len(tree.Structure) != len(want) {
+		t.Fatalf("expected %d page groups, got %d", len(want), len(tree.Structure))
+	}
+	for i, w := range want {
+		n := tree.Structure[i]
+		if n.Title != w.title || n.StartIndex != w.start || n.EndIndex != w.end {
+			t.Fatalf("group %d: got %q %d-%d", i, n.Title, n.StartIndex, n.EndIndex)
+		}
+	}
+}
@@ -48,9 +48,11 @@ type Request struct {
 
 // Response is the raw output and accounting from one Respond call.
 type Response struct {
-	Output   json.RawMessage
-	Text     string
-	Usage    Usage
+	Output json.RawMessage
+	Text   string
+	Usage  Usage
+	// Model is the model that answered; the cache keeps it for pruning by model.
+	Model    string
 	CacheHit bool
 }
 
@@ -115,6 +117,44 @@ func NewOpenAILLM(cfg LLMConfig) (LLM, error) {
 	}, nil
 }
 
+// NewLLMFromSettings builds the LLM selected by s: the OpenAI Responses
+// client, optionally wrapped by a recording ReplayLLM, or an offline
+// ReplayLLM when s.ReplayMode is ReplayModeReplay.
+func NewLLMFromSettings(s LLMSettings, tok Tokenizer, logger logSDK.Logger) (LLM, error) {
+	if s.ReplayMode == ReplayModeReplay {
+		if s.ReplayPath == "" {
+			return nil, errors.New("llm.replay.path is required in replay mode")
+		}
+		replay, err := NewReplayLLM(ReplayConfig{Path: s.ReplayPath, Mode: ReplayModeReplay, Logger: logger})
+		if err != nil {
+			return nil, err
+		}
+		return replay, nil
+	}
+	live, err := NewOpenAILLM(LLMConfig{
+		APIKey:    s.APIKey,
+		BaseURL:   s.BaseURL,
+		Model:     s.IndexingModel,
+		Tokenizer: tok,
+		Logger:    logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch s.ReplayMode {
+	case "":
+		return live, nil
+	case ReplayModeRecord:
+		replay, err := NewReplayLLM(ReplayConfig{Path: s.ReplayPath, Mode: ReplayModeRecord, Upstream: live})
+		if err != nil {
+			return nil, err
+		}
+		return replay, nil
+	default:
+		return nil, errors.Errorf("unknown llm.replay.mode %q", s.ReplayMode)
+	}
+}
+
 type openaiLLM struct {
 	client     *openai.Client
 	model      string
@@ -202,7 +242,7 @@ func (l *openaiLLM) Respond(ctx context.Context, req Request) (*Response, error)
 		TotalTokens:  int(resp.Usage.TotalTokens),
 	}
 	text := resp.OutputText()
-	out := &Response{Text: text, Usage: usage}
+	out := &Response{Text: text, Usage: usage, Model: req.Model}
 	if len(req.Schema) > 0 {
 		out.Output = json.RawMessage(text)
 	}
@@ -263,6 +303,7 @@ var (
 	_ LLM = (*openaiLLM)(nil)
 	_ LLM = (*StubLLM)(nil)
 	_ LLM = (*NoopLLM)(nil)
+	_ LLM = (*ReplayLLM)(nil)
 )
 
 // NoopLLM returns empty responses for callers that disable LLM access.
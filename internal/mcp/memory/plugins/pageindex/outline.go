@@ -0,0 +1,131 @@
+package pageindex
+
+import (
+	"context"
+	"fmt"
+
+	errors "github.com/Laisky/errors/v2"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+)
+
+// defaultNodeReadPages caps ReadNode when the caller does not pass a limit.
+const defaultNodeReadPages = 10
+
+// NodeContent is one outline node together with the text of the pages it covers.
+type NodeContent struct {
+	DocID      string  `json:"doc_id"`
+	NodeID     string  `json:"node_id"`
+	Title      string  `json:"title"`
+	Summary    string  `json:"summary,omitempty"`
+	StartIndex int     `json:"start_index,omitempty"`
+	EndIndex   int     `json:"end_index,omitempty"`
+	LineNum    int     `json:"line_num,omitempty"`
+	Chunks     []Chunk `json:"chunks"`
+	// TotalPages is the number of pages the node covers; Truncated reports
+	// that only the first maxPages of them were returned.
+	TotalPages int  `json:"total_pages"`
+	Truncated  bool `json:"truncated"`
+}
+
+// Outline returns the indexed outline, including node summaries, of the
+// document the caller wrote at path.
+func (p *Plugin) Outline(ctx context.Context, auth files.AuthContext, project, path string) (StructureView, error) {
+	tree, err := p.treeForPath(ctx, auth, project, path)
+	if err != nil {
+		return StructureView{}, err
+	}
+	return structureView(tree), nil
+}
+
+// ReadNode returns the pages covered by nodeID, at most maxPages of them.
+// Page-range nodes (PDF, plain text) cover StartIndex..EndIndex; heading
+// nodes (Markdown, HTML, DOCX) cover their own section and every nested one.
+func (p *Plugin) ReadNode(ctx context.Context, auth files.AuthContext, project, path, nodeID string, maxPages int) (NodeContent, error) {
+	tree, err := p.treeForPath(ctx, auth, project, path)
+	if err != nil {
+		return NodeContent{}, err
+	}
+	node := findNode(tree.Structure, nodeID)
+	if node == nil {
+		return NodeContent{}, files.NewError(files.ErrCodeNotFound, fmt.Sprintf("node %q not found in %s", nodeID, path), false)
+	}
+	if maxPages <= 0 {
+		maxPages = defaultNodeReadPages
+	}
+
+	pages := nodePages(node)
+	out := NodeContent{
+		DocID:      tree.DocID,
+		NodeID:     node.NodeID,
+		Title:      node.Title,
+		Summary:    node.Summary,
+		StartIndex: node.StartIndex,
+		EndIndex:   node.EndIndex,
+		LineNum:    node.LineNum,
+		TotalPages: len(pages),
+	}
+	if len(pages) > maxPages {
+		pages = pages[:maxPages]
+		out.Truncated = true
+	}
+	ranges := make([]PageRange, 0, len(pages))
+	for _, page := range pages {
+		ranges = append(ranges, PageRange{Start: page, End: page})
+	}
+	chunks, err := pageContent(tree, ranges)
+	if err != nil {
+		return NodeContent{}, err
+	}
+	for i := range chunks {
+		chunks[i].FilePath = path
+	}
+	out.Chunks = chunks
+	return out, nil
+}
+
+// treeForPath loads the tree indexed for path. Documents indexed under a
+// different API key are reported as not found.
+func (p *Plugin) treeForPath(ctx context.Context, auth files.AuthContext, project, path string) (*Tree, error) {
+	ix, err := p.store.GetIndex(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := ix[path]
+	if !ok || entry.DocID != docIDFromAuth(auth, project, path) {
+		return nil, files.NewError(files.ErrCodeNotFound, fmt.Sprintf("%s is not indexed by pageindex", path), false)
+	}
+	tree, err := p.store.GetTree(ctx, project, entry.DocID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "load tree for %s", path)
+	}
+	return tree, nil
+}
+
+// findNode returns the node with the given ID, or nil.
+func findNode(nodes []*Node, nodeID string) *Node {
+	var found *Node
+	WalkNodes(nodes, func(n *Node) {
+		if found == nil && n.NodeID == nodeID {
+			found = n
+		}
+	})
+	return found
+}
+
+// nodePages lists the page numbers a node covers, in order.
+func nodePages(n *Node) []int {
+	if n.StartIndex > 0 {
+		end := max(n.EndIndex, n.StartIndex)
+		pages := make([]int, 0, end-n.StartIndex+1)
+		for page := n.StartIndex; page <= end; page++ {
+			pages = append(pages, page)
+		}
+		return pages
+	}
+	var pages []int
+	WalkNodes([]*Node{n}, func(c *Node) {
+		pages = append(pages, c.LineNum)
+	})
+	return pages
+}
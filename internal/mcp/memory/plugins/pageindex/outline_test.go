@@ -0,0 +1,82 @@
+package pageindex
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+)
+
+func TestPluginOutlineAndReadNode(t *testing.T) {
+	ctx := context.Background()
+	p, err := New(PluginDeps{UserFS: &files.Service{}, SystemFS: newMemoryFS(), Settings: Settings{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := files.AuthContext{APIKeyHash: "owner"}
+	tk, err := NewTokenizer("gpt-5.4-mini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := NewIndexer(Deps{LLM: NewStubLLM(), Tokenizer: tk, Settings: defaultTestSettings()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mdID := docIDFromAuth(owner, "proj", "/guide.md")
+	mdTree, _, err := idx.Index(ctx, KindMarkdown, []byte("# Guide\n\nintro\n\n## Setup\n\nsteps\n"), IndexOptions{DocID: mdID}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mdTree.Structure[0].Children[0].Summary = "how to set up"
+	txtID := docIDFromAuth(owner, "proj", "/log.txt")
+	txtTree, _, err := idx.Index(ctx, KindText, []byte(strings.Repeat("row\n", 3*textLinesPerPage)), IndexOptions{DocID: txtID}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, tree := range map[string]*Tree{"/guide.md": mdTree, "/log.txt": txtTree} {
+		if err := p.store.PutTree(ctx, "proj", tree.DocID, tree); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.store.UpdateIndexEntry(ctx, "proj", path, IndexEntry{DocID: tree.DocID, Type: string(tree.Type)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	view, err := p.Outline(ctx, owner, "proj", "/guide.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(view.Outline) != 1 || view.Outline[0].Text != "" || view.Outline[0].Children[0].Summary != "how to set up" {
+		t.Fatalf("unexpected outline: %+v", view.Outline)
+	}
+
+	section, err := p.ReadNode(ctx, owner, "proj", "/guide.md", "0001", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if section.TotalPages != 2 || len(section.Chunks) != 2 || !strings.Contains(section.Chunks[1].Content, "steps") {
+		t.Fatalf("expected heading node to include nested section: %+v", section)
+	}
+
+	pages, err := p.ReadNode(ctx, owner, "proj", "/log.txt", "0001", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages.TotalPages != 1 || pages.Truncated || pages.Chunks[0].FilePath != "/log.txt" {
+		t.Fatalf("unexpected page node read: %+v", pages)
+	}
+
+	if _, err := p.ReadNode(ctx, owner, "proj", "/guide.md", "9999", 0); !isNotFound(err) {
+		t.Fatalf("expected NOT_FOUND for unknown node, got %v", err)
+	}
+	if _, err := p.Outline(ctx, files.AuthContext{APIKeyHash: "other"}, "proj", "/guide.md"); !isNotFound(err) {
+		t.Fatalf("expected NOT_FOUND for another key, got %v", err)
+	}
+}
+
+func isNotFound(err error) bool {
+	typed, ok := files.AsError(err)
+	return ok && typed.Code == files.ErrCodeNotFound
+}
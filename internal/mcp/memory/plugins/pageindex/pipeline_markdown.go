@@ -6,7 +6,7 @@ import (
 )
 
 // runMarkdown builds a header-driven tree and optionally summarizes leaves.
-func (idx *Indexer) runMarkdown(ctx context.Context, data []byte, rep *Reporter, stats *Stats) (*Tree, error) {
+func (idx *Indexer) runMarkdown(ctx context.Context, data []byte, reuse *summaryReuse, rep *Reporter, stats *Stats) (*Tree, error) {
 	rep.Report(Progress{Phase: "md:headers", Percent: 10})
 	headers, err := ExtractHeaders(data)
 	if err != nil {
@@ -20,17 +20,19 @@ func (idx *Indexer) runMarkdown(ctx context.Context, data []byte, rep *Reporter,
 		roots = []*Node{{Title: "Document", LineNum: 1, Text: string(data)}}
 	}
 	assignNodeIDs(roots, 0)
+	treeHash := stampContentHashes(roots, nodeText)
 
 	pages := buildMarkdownPages(roots)
 	tree := &Tree{
-		LineCount: len(lines),
-		Structure: roots,
-		Pages:     pages,
+		LineCount:   len(lines),
+		ContentHash: treeHash,
+		Structure:   roots,
+		Pages:       pages,
 	}
 	if idx.cfg.Algo.GenerateNodeSummary {
 		rep.Report(Progress{Phase: "md:summarize", Percent: 70})
 		budget := NewBudget(int64(idx.cfg.Algo.MaxTokenNumEachNode * (len(roots) + 1)))
-		if err := idx.summarizeMarkdownNodes(ctx, roots, budget, stats); err != nil {
+		if err := idx.summarizeMarkdownNodes(ctx, roots, reuse, budget, stats); err != nil {
 			return nil, err
 		}
 	}
@@ -45,10 +47,17 @@ func buildMarkdownPages(nodes []*Node) []Page {
 	return pages
 }
 
-func (idx *Indexer) summarizeMarkdownNodes(ctx context.Context, nodes []*Node, budget *Budget, stats *Stats) error {
+// summarizeMarkdownNodes summarizes every leaf, reusing the previous summary
+// of any leaf whose text is unchanged.
+func (idx *Indexer) summarizeMarkdownNodes(ctx context.Context, nodes []*Node, reuse *summaryReuse, budget *Budget, stats *Stats) error {
 	var visit func(n *Node) error
 	visit = func(n *Node) error {
 		if len(n.Children) == 0 {
+			if summary, ok := reuse.summary(n.ContentHash); ok {
+				n.Summary = summary
+				stats.addSaved()
+				return nil
+			}
 			prompt, err := RenderPrompt(PromptGenerateNodeSummary, GenerateNodeSummaryVars{Text: n.Text})
 			if err != nil {
 				return err
@@ -10,7 +10,7 @@ import (
 )
 
 // runPDF executes the §2.6.4.1 nine-phase PDF pipeline.
-func (idx *Indexer) runPDF(ctx context.Context, data []byte, rep *Reporter, stats *Stats) (*Tree, error) {
+func (idx *Indexer) runPDF(ctx context.Context, data []byte, reuse *summaryReuse, rep *Reporter, stats *Stats) (*Tree, error) {
 	rep.Report(Progress{Phase: "pdf:extract", Percent: 5})
 	pages, err := idx.pdf.PagesText(ctx, data)
 	if err != nil {
@@ -22,15 +22,65 @@ func (idx *Indexer) runPDF(ctx context.Context, data []byte, rep *Reporter, stat
 	pageCount := len(pages)
 	budget := NewBudget(int64(idx.cfg.Algo.MaxTokenNumEachNode*pageCount + idx.cfg.TreeQuery.MaxTokens))
 
-	rep.Report(Progress{Phase: "pdf:toc-detect", Percent: 15})
-	tocPages, tocContent, hasPageIndex, err := idx.detectTOC(ctx, pages, budget, stats)
-	if err != nil {
+	// Unchanged page text means an unchanged outline, so the TOC, tree-build,
+	// verify and expand phases are skipped and the previous outline is reused.
+	rootNodes, reused := reuse.outline(pagesDigest(pages))
+	if reused {
+		rep.Report(Progress{Phase: "pdf:reuse-outline", Percent: 70})
+		stats.addSaved()
+	} else if rootNodes, err = idx.buildPDFOutline(ctx, pages, budget, rep, stats); err != nil {
 		return nil, err
 	}
+	// Re-stamp node IDs and hashes so expanded children get stable identifiers.
+	assignNodeIDs(rootNodes, 0)
+	treeHash := stampContentHashes(rootNodes, pageRangeTextOf(pages))
 
-	rep.Report(Progress{Phase: "pdf:tree-build", Percent: 40})
-	flat, err := idx.buildFlatTOC(ctx, pages, tocPages, tocContent, hasPageIndex, budget, stats)
-	if err != nil {
+	// Summaries run after expansion so expanded children, not just the
+	// original leaves, can reuse their previous summaries.
+	if idx.cfg.Algo.GenerateNodeSummary {
+		rep.Report(Progress{Phase: "pdf:summarize", Percent: 90})
+		if err := idx.summarizeNodes(ctx, rootNodes, pages, reuse, budget, stats); err != nil {
+			return nil, err
+		}
+	}
+
+	docDescription := ""
+	if idx.cfg.Algo.GenerateDocDescription {
+		rep.Report(Progress{Phase: "pdf:doc-description", Percent: 95})
+		if desc, ok := reuse.docDescription(treeHash); ok {
+			docDescription = desc
+			stats.addSaved()
+		} else if desc, err := idx.generateDocDescription(ctx, rootNodes, budget, stats); err == nil {
+			docDescription = desc
+		}
+	}
+	tree := &Tree{
+		ContentHash:    treeHash,
+		PageCount:      pageCount,
+		Structure:      rootNodes,
+		DocDescription: docDescription,
+		Pages:          buildPageCache(pages),
+	}
+	return tree, nil
+}
+
+// buildPDFOutline runs the TOC, tree-build, verify and expand phases and
+// returns the assembled outline without summaries.
+func (idx *Indexer) buildPDFOutline(ctx context.Context, pages []string, budget *Budget, rep *Reporter, stats *Stats) ([]*Node, error) {
+	pageCount := len(pages)
+	rep.Report(Progress{Phase: "pdf:toc-detect", Percent: 15})
+	var flat []map[string]any
+	tocPages, tocContent, hasPageIndex, err := idx.detectTOC(ctx, pages, budget, stats)
+	if err == nil {
+		rep.Report(Progress{Phase: "pdf:tree-build", Percent: 40})
+		flat, err = idx.buildFlatTOC(ctx, pages, tocPages, tocContent, hasPageIndex, budget, stats)
+	}
+	switch {
+	case errors.Is(err, ErrBudgetExceeded):
+		// Out of budget before an outline exists: degrade to fixed page groups
+		// so the document stays searchable.
+		flat = pageGroupTOC(pageCount, idx.cfg.Algo.MaxPageNumEachNode)
+	case err != nil:
 		return nil, err
 	}
 	if len(flat) == 0 {
@@ -41,43 +91,19 @@ func (idx *Indexer) runPDF(ctx context.Context, data []byte, rep *Reporter, stat
 	rep.Report(Progress{Phase: "pdf:tree-assemble", Percent: 65})
 	rootNodes := assembleTree(flat, pageCount)
 
-	if idx.cfg.Algo.GenerateNodeSummary {
-		rep.Report(Progress{Phase: "pdf:summarize", Percent: 75})
-		if err := idx.summarizeNodes(ctx, rootNodes, pages, budget, stats); err != nil {
-			return nil, err
-		}
-	}
-
 	// Phase 7: title-appearance verification + targeted fix.
-	rep.Report(Progress{Phase: "pdf:verify", Percent: 80})
+	rep.Report(Progress{Phase: "pdf:verify", Percent: 75})
 	tmpTree := &Tree{Structure: rootNodes}
 	if err := idx.verifyAndFix(ctx, tmpTree, pages, budget, stats); err != nil {
 		return nil, errors.Wrap(err, "verify and fix")
 	}
 
 	// Phase 8: bounded recursive expansion of oversized leaf nodes.
-	rep.Report(Progress{Phase: "pdf:expand", Percent: 88})
+	rep.Report(Progress{Phase: "pdf:expand", Percent: 85})
 	if err := idx.expandLargeNodes(ctx, rootNodes, pages, 0, budget, stats); err != nil {
 		return nil, errors.Wrap(err, "expand large nodes")
 	}
-	// Re-stamp node IDs so newly inserted children get stable identifiers.
-	assignNodeIDs(rootNodes, 0)
-
-	docDescription := ""
-	if idx.cfg.Algo.GenerateDocDescription {
-		rep.Report(Progress{Phase: "pdf:doc-description", Percent: 95})
-		desc, err := idx.generateDocDescription(ctx, rootNodes, budget, stats)
-		if err == nil {
-			docDescription = desc
-		}
-	}
-	tree := &Tree{
-		PageCount:      pageCount,
-		Structure:      rootNodes,
-		DocDescription: docDescription,
-		Pages:          buildPageCache(pages),
-	}
-	return tree, nil
+	return rootNodes, nil
 }
 
 func buildPageCache(pages []string) []Page {
@@ -275,6 +301,11 @@ func (idx *Indexer) modeCNoTOC(ctx context.Context, pages []string, budget *Budg
 			return nil, err
 		}
 		respC, err := idx.callLLM(ctx, Request{Input: userInput(contPrompt)}, budget, stats)
+		if errors.Is(err, ErrBudgetExceeded) {
+			// Keep the outline of the groups seen so far; the last node
+			// stretches to the final page.
+			break
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -389,10 +420,16 @@ func assignNodeIDs(nodes []*Node, counter int) int {
 }
 
 // summarizeNodes runs PromptGenerateNodeSummary on every leaf node sequentially.
-func (idx *Indexer) summarizeNodes(ctx context.Context, nodes []*Node, pages []string, budget *Budget, stats *Stats) error {
+// Leaves whose page text is unchanged since the previous index reuse its summary.
+func (idx *Indexer) summarizeNodes(ctx context.Context, nodes []*Node, pages []string, reuse *summaryReuse, budget *Budget, stats *Stats) error {
 	var visit func(n *Node) error
 	visit = func(n *Node) error {
 		if len(n.Children) == 0 {
+			if summary, ok := reuse.summary(n.ContentHash); ok {
+				n.Summary = summary
+				stats.addSaved()
+				return nil
+			}
 			text := pageRangeText(pages, n.StartIndex, n.EndIndex)
 			prompt, err := RenderPrompt(PromptGenerateNodeSummary, GenerateNodeSummaryVars{Text: text})
 			if err != nil {
@@ -0,0 +1,73 @@
+package pageindex
+
+import (
+	"context"
+	"strings"
+
+	errors "github.com/Laisky/errors/v2"
+)
+
+// runHTML indexes an HTML document through the heading-section pipeline.
+func (idx *Indexer) runHTML(ctx context.Context, data []byte, reuse *summaryReuse, rep *Reporter, stats *Stats) (*Tree, error) {
+	rep.Report(Progress{Phase: "html:parse", Percent: 10})
+	blocks, err := ExtractHTMLBlocks(data)
+	if err != nil {
+		return nil, err
+	}
+	return idx.runSections(ctx, "html", blocks, reuse, rep, stats)
+}
+
+// runDOCX indexes a Word document through the heading-section pipeline.
+func (idx *Indexer) runDOCX(ctx context.Context, data []byte, reuse *summaryReuse, rep *Reporter, stats *Stats) (*Tree, error) {
+	rep.Report(Progress{Phase: "docx:parse", Percent: 10})
+	blocks, err := ExtractDOCXBlocks(data)
+	if err != nil {
+		return nil, err
+	}
+	return idx.runSections(ctx, "docx", blocks, reuse, rep, stats)
+}
+
+// runSections builds the same header-driven tree as runMarkdown from parsed
+// blocks: each block is one line and each heading block opens a section.
+// Text before the first heading becomes a "Preamble" root so it stays
+// reachable from page 1.
+func (idx *Indexer) runSections(ctx context.Context, phase string, blocks []DocBlock, reuse *summaryReuse, rep *Reporter, stats *Stats) (*Tree, error) {
+	if len(blocks) == 0 {
+		return nil, errors.Errorf("%s document has no text", phase)
+	}
+	lines := make([]string, len(blocks))
+	headers := make([]MDHeader, 0)
+	for i, b := range blocks {
+		lines[i] = b.Text
+		if b.Level > 0 {
+			headers = append(headers, MDHeader{Level: b.Level, Title: b.Text, LineNum: i + 1})
+		}
+	}
+
+	rep.Report(Progress{Phase: phase + ":tree", Percent: 30})
+	roots := BuildMarkdownTree(headers, lines)
+	switch {
+	case len(roots) == 0:
+		roots = []*Node{{Title: "Document", LineNum: 1, Text: strings.Join(lines, "\n")}}
+	case headers[0].LineNum > 1:
+		preamble := &Node{Title: "Preamble", LineNum: 1, Text: strings.Join(lines[:headers[0].LineNum-1], "\n")}
+		roots = append([]*Node{preamble}, roots...)
+	}
+	assignNodeIDs(roots, 0)
+	treeHash := stampContentHashes(roots, nodeText)
+
+	tree := &Tree{
+		LineCount:   len(lines),
+		ContentHash: treeHash,
+		Structure:   roots,
+		Pages:       buildMarkdownPages(roots),
+	}
+	if idx.cfg.Algo.GenerateNodeSummary {
+		rep.Report(Progress{Phase: phase + ":summarize", Percent: 70})
+		budget := NewBudget(int64(idx.cfg.Algo.MaxTokenNumEachNode * (len(roots) + 1)))
+		if err := idx.summarizeMarkdownNodes(ctx, roots, reuse, budget, stats); err != nil {
+			return nil, err
+		}
+	}
+	return tree, nil
+}
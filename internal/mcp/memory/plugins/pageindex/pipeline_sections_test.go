@@ -0,0 +1,63 @@
+package pageindex
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSectionsPipelineHTMLPreamble(t *testing.T) {
+	tk, err := NewTokenizer("gpt-5.4-mini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := NewIndexer(Deps{LLM: NewStubLLM(), Tokenizer: tk, Settings: defaultTestSettings()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte("<p>lead</p><h1>A</h1><p>alpha</p><h2>B</h2><p>beta</p><h1>C</h1><p>gamma</p>")
+	tree, stats, err := idx.Index(context.Background(), KindHTML, body, IndexOptions{DocID: "doc1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Type != KindHTML || tree.LineCount != 7 {
+		t.Fatalf("unexpected tree header: type=%q lines=%d", tree.Type, tree.LineCount)
+	}
+	if len(tree.Structure) != 3 || tree.Structure[0].Title != "Preamble" || len(tree.Structure[1].Children) != 1 {
+		t.Fatalf("unexpected tree shape: %+v", tree.Structure)
+	}
+	chunks, err := idx.GetPageContent(tree, []PageRange{{Start: 1, End: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 || chunks[0].Content != "lead" {
+		t.Fatalf("expected preamble on page 1, got %+v", chunks)
+	}
+	if stats.LLMCalls != 0 {
+		t.Fatalf("expected 0 llm calls, got %d", stats.LLMCalls)
+	}
+
+	if _, _, err := idx.Index(context.Background(), KindHTML, []byte("<script>x</script>"), IndexOptions{DocID: "doc2"}, nil); err == nil {
+		t.Fatal("expected error for html without text")
+	}
+}
+
+func TestSectionsPipelineDOCX(t *testing.T) {
+	tk, err := NewTokenizer("gpt-5.4-mini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := NewIndexer(Deps{LLM: NewStubLLM(), Tokenizer: tk, Settings: defaultTestSettings()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := buildTestDOCX(t,
+		`<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Scope</w:t></w:r></w:p>`+
+			`<w:p><w:r><w:t>Everything.</w:t></w:r></w:p>`)
+	tree, _, err := idx.Index(context.Background(), KindDOCX, data, IndexOptions{DocID: "doc1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Structure) != 1 || tree.Structure[0].Title != "Scope" || tree.Structure[0].NodeID != "0001" {
+		t.Fatalf("unexpected tree shape: %+v", tree.Structure)
+	}
+}
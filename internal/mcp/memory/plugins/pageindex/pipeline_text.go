@@ -0,0 +1,126 @@
+package pageindex
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	errors "github.com/Laisky/errors/v2"
+)
+
+const (
+	// textLinesPerPage is the size of one synthetic plain-text page.
+	textLinesPerPage = 50
+	// textPagesPerSection groups synthetic pages under a parent node once a
+	// document is long enough for a flat outline to overwhelm the search prompt.
+	textPagesPerSection = 10
+	// textTitleMaxRunes caps the first-line snippet used in page titles.
+	textTitleMaxRunes = 60
+)
+
+// runText splits a plain-text document into synthetic pages of
+// textLinesPerPage lines and builds a page-range tree over them, so search
+// resolves ranges the same way it does for PDFs.
+func (idx *Indexer) runText(ctx context.Context, data []byte, reuse *summaryReuse, rep *Reporter, stats *Stats) (*Tree, error) {
+	rep.Report(Progress{Phase: "txt:paginate", Percent: 10})
+	if !utf8.Valid(data) {
+		return nil, errors.New("text document is not valid UTF-8")
+	}
+	body := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if strings.TrimSpace(body) == "" {
+		return nil, errors.New("text document is empty")
+	}
+	lines := strings.Split(strings.TrimRight(body, "\n"), "\n")
+	pages := paginateLines(lines, textLinesPerPage)
+
+	rep.Report(Progress{Phase: "txt:tree", Percent: 30})
+	roots := buildTextTree(lines, len(pages))
+	assignNodeIDs(roots, 0)
+	treeHash := stampContentHashes(roots, pageRangeTextOf(pages))
+
+	cache := buildPageCache(pages)
+	for i := range cache {
+		cache[i].LineNum = i*textLinesPerPage + 1
+	}
+	tree := &Tree{
+		PageCount:   len(pages),
+		LineCount:   len(lines),
+		Structure:   roots,
+		Pages:       cache,
+		ContentHash: treeHash,
+	}
+	if idx.cfg.Algo.GenerateNodeSummary {
+		rep.Report(Progress{Phase: "txt:summarize", Percent: 70})
+		budget := NewBudget(int64(idx.cfg.Algo.MaxTokenNumEachNode * (len(pages) + 1)))
+		if err := idx.summarizeNodes(ctx, roots, pages, reuse, budget, stats); err != nil {
+			return nil, err
+		}
+	}
+	return tree, nil
+}
+
+// paginateLines joins lines into pages of perPage lines each. Every page
+// keeps its trailing newline so concatenated ranges preserve line breaks.
+func paginateLines(lines []string, perPage int) []string {
+	pages := make([]string, 0, (len(lines)+perPage-1)/perPage)
+	for start := 0; start < len(lines); start += perPage {
+		end := min(start+perPage, len(lines))
+		pages = append(pages, strings.Join(lines[start:end], "\n")+"\n")
+	}
+	return pages
+}
+
+// buildTextTree emits one leaf per synthetic page, grouped under section
+// nodes of textPagesPerSection pages when the document spans more than one
+// section.
+func buildTextTree(lines []string, pageCount int) []*Node {
+	leaves := make([]*Node, 0, pageCount)
+	for page := 1; page <= pageCount; page++ {
+		first, last := textPageLines(page, len(lines))
+		title := fmt.Sprintf("Lines %d-%d", first, last)
+		if snippet := firstLineSnippet(lines[first-1 : last]); snippet != "" {
+			title += ": " + snippet
+		}
+		leaves = append(leaves, &Node{Title: title, StartIndex: page, EndIndex: page, LineNum: first})
+	}
+	if pageCount <= textPagesPerSection {
+		return leaves
+	}
+
+	sections := make([]*Node, 0, (pageCount+textPagesPerSection-1)/textPagesPerSection)
+	for start := 0; start < len(leaves); start += textPagesPerSection {
+		children := leaves[start:min(start+textPagesPerSection, len(leaves))]
+		first, _ := textPageLines(children[0].StartIndex, len(lines))
+		_, last := textPageLines(children[len(children)-1].EndIndex, len(lines))
+		sections = append(sections, &Node{
+			Title:      fmt.Sprintf("Lines %d-%d", first, last),
+			StartIndex: children[0].StartIndex,
+			EndIndex:   children[len(children)-1].EndIndex,
+			LineNum:    first,
+			Children:   children,
+		})
+	}
+	return sections
+}
+
+// textPageLines returns the 1-indexed first and last line covered by page.
+func textPageLines(page, lineCount int) (int, int) {
+	first := (page-1)*textLinesPerPage + 1
+	return first, min(first+textLinesPerPage-1, lineCount)
+}
+
+// firstLineSnippet returns the first non-blank line, truncated to textTitleMaxRunes.
+func firstLineSnippet(lines []string) string {
+	for _, line := range lines {
+		line = collapseSpace(line)
+		if line == "" {
+			continue
+		}
+		if utf8.RuneCountInString(line) > textTitleMaxRunes {
+			line = string([]rune(line)[:textTitleMaxRunes]) + "..."
+		}
+		return line
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package pageindex
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTextPipelineSyntheticPages(t *testing.T) {
+	tk, err := NewTokenizer("gpt-5.4-mini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := NewIndexer(Deps{LLM: NewStubLLM(), Tokenizer: tk, Settings: defaultTestSettings()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lineCount := textLinesPerPage*(textPagesPerSection+1) + 5
+	lines := make([]string, lineCount)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	body := []byte(strings.Join(lines, "\r\n") + "\r\n")
+	tree, _, err := idx.Index(context.Background(), KindText, body, IndexOptions{DocID: "doc1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPages := textPagesPerSection + 2
+	if tree.PageCount != wantPages || tree.LineCount != lineCount || len(tree.Pages) != wantPages {
+		t.Fatalf("unexpected counts: pages=%d lines=%d cached=%d", tree.PageCount, tree.LineCount, len(tree.Pages))
+	}
+	if len(tree.Structure) != 2 || len(tree.Structure[0].Children) != textPagesPerSection {
+		t.Fatalf("unexpected tree shape: %d roots", len(tree.Structure))
+	}
+	last := tree.Structure[1]
+	if last.StartIndex != textPagesPerSection+1 || last.EndIndex != wantPages {
+		t.Fatalf("unexpected last section range: %d-%d", last.StartIndex, last.EndIndex)
+	}
+	leaf := last.Children[1]
+	wantTitle := fmt.Sprintf("Lines %d-%d: line %d", leaf.LineNum, lineCount, leaf.LineNum)
+	if leaf.Title != wantTitle {
+		t.Fatalf("expected leaf title %q, got %q", wantTitle, leaf.Title)
+	}
+
+	chunks, err := idx.GetPageContent(tree, []PageRange{firstNodeRange(tree)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != textPagesPerSection || !strings.HasPrefix(chunks[0].Content, "line 1\nline 2\n") {
+		t.Fatalf("unexpected first section content: %d chunks", len(chunks))
+	}
+
+	if _, _, err := idx.Index(context.Background(), KindText, []byte(" \n\n"), IndexOptions{DocID: "doc2"}, nil); err == nil {
+		t.Fatal("expected error for empty text")
+	}
+}
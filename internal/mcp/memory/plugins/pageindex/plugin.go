@@ -3,12 +3,15 @@ package pageindex
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	pathpkg "path"
 	"strings"
 	"time"
 
 	errors "github.com/Laisky/errors/v2"
 	logSDK "github.com/Laisky/go-utils/v6/log"
+	glog "github.com/Laisky/zap"
 
 	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
 	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
@@ -48,6 +51,11 @@ func New(deps PluginDeps) (*Plugin, error) {
 	if deps.SystemFS == nil {
 		return nil, errors.New("systemFS is nil")
 	}
+	if limits := deps.Settings.Indexer.Limits; deps.Logger != nil && limits.unpricedCostLimit() {
+		deps.Logger.Warn("pageindex.limits: max_cost_usd is set but a per-MTok rate is 0; those tokens count as free",
+			glog.Float64("input_cost_per_mtok", limits.InputCostPerMTok),
+			glog.Float64("output_cost_per_mtok", limits.OutputCostPerMTok))
+	}
 	return &Plugin{
 		userFS: deps.UserFS,
 		sysFS:  deps.SystemFS,
@@ -109,6 +117,8 @@ func (p *Plugin) Start(ctx context.Context) error {
 			Enabled:      p.cfg.Indexer.Cache.Enabled,
 			Path:         p.cfg.Indexer.Cache.Path,
 			MaxSizeBytes: p.cfg.Indexer.Cache.MaxSizeBytes,
+			MaxEntries:   p.cfg.Indexer.Cache.MaxEntries,
+			TTL:          p.cfg.Indexer.Cache.TTL,
 		})
 		if err != nil {
 			return errors.Wrap(err, "open cache")
@@ -122,6 +132,15 @@ func (p *Plugin) Start(ctx context.Context) error {
 	return nil
 }
 
+// CacheStats reports the response cache counters; a plugin that has not
+// started reports a disabled cache.
+func (p *Plugin) CacheStats() CacheStats {
+	if p.cache == nil {
+		return CacheStats{}
+	}
+	return p.cache.Stats()
+}
+
 // Stop closes the bbolt cache.
 func (p *Plugin) Stop(ctx context.Context) error {
 	if p.cache != nil {
@@ -141,27 +160,35 @@ func (p *Plugin) Read(ctx context.Context, auth files.AuthContext, project, path
 }
 
 // Write forwards to userFS.WriteWith with SkipRAGIndex=true and triggers indexing.
+// A .docx may be sent base64-encoded: the archive is indexed while FileIO,
+// which only stores utf-8, keeps its plain-text rendering.
 func (p *Plugin) Write(ctx context.Context, auth files.AuthContext, project, path, content, encoding string, offset int64, mode files.WriteMode) (files.WriteResult, error) {
-	if isLongDocPath(path) && mode == files.WriteModeOverwrite && offset > 0 {
-		return files.WriteResult{}, errors.New("INVALID_ARGUMENT: pageindex rejects OVERWRITE@offset on .pdf/.md paths; use file_delete then file_write instead")
+	kind, longDoc := p.indexedKind(path)
+	if longDoc && mode == files.WriteModeOverwrite && offset > 0 {
+		return files.WriteResult{}, errors.New("INVALID_ARGUMENT: pageindex rejects OVERWRITE@offset on long-document paths (.pdf/.md/.html/.htm/.docx/.txt); use file_delete then file_write instead")
+	}
+	source := []byte(content)
+	if kind == KindDOCX && strings.EqualFold(strings.TrimSpace(encoding), "base64") {
+		raw, text, err := decodeDOCXPayload(content, offset, mode)
+		if err != nil {
+			return files.WriteResult{}, err
+		}
+		source, content, encoding = raw, text, "utf-8"
 	}
 	res, err := p.userFS.WriteWith(ctx, auth, project, path, content, encoding, offset, mode, files.WriteOpts{SkipRAGIndex: true})
 	if err != nil {
 		return res, err
 	}
-	if !isLongDocPath(path) {
+	if !longDoc {
 		return res, nil
 	}
 	if p.indexer == nil {
 		return res, nil
 	}
-	bytesContent := []byte(content)
-	kind := KindPDF
-	if strings.HasSuffix(strings.ToLower(path), ".md") {
-		kind = KindMarkdown
-	}
 	docID := docIDFromAuth(auth, project, path)
-	tree, _, indexErr := p.indexer.Index(ctx, kind, bytesContent, IndexOptions{DocID: docID}, nil)
+	// A missing or unreadable previous tree only disables summary reuse.
+	prev, _ := p.store.GetTree(ctx, project, docID)
+	tree, stats, indexErr := p.indexer.Index(ctx, kind, source, IndexOptions{DocID: docID, Previous: prev, APIKeyHash: auth.APIKeyHash}, nil)
 	if indexErr != nil {
 		// Indexing errors should not silently fail the write; surface as warning.
 		if p.log != nil {
@@ -169,6 +196,21 @@ func (p *Plugin) Write(ctx context.Context, auth files.AuthContext, project, pat
 		}
 		return res, nil
 	}
+	if stats != nil && stats.SavedLLMCalls > 0 && p.log != nil {
+		p.log.Debug("pageindex.write reused unchanged summaries",
+			glog.String("doc_id", docID),
+			glog.Int("saved_llm_calls", stats.SavedLLMCalls),
+			glog.Int("llm_calls", stats.LLMCalls),
+		)
+	}
+	if stats != nil && stats.BudgetExhausted != "" && p.log != nil {
+		p.log.Warn("pageindex.write budget exhausted; stored a partial tree",
+			glog.String("doc_id", docID),
+			glog.String("reason", stats.BudgetExhausted),
+			glog.Int("llm_calls", stats.LLMCalls),
+			glog.Float64("cost_usd", stats.CostUSD),
+		)
+	}
 	if err := p.store.PutTree(ctx, project, docID, tree); err != nil {
 		if p.log != nil {
 			p.log.Warn("pageindex.write put tree: " + err.Error())
@@ -176,11 +218,13 @@ func (p *Plugin) Write(ctx context.Context, auth files.AuthContext, project, pat
 		return res, nil
 	}
 	entry := IndexEntry{
-		DocID:     docID,
-		Type:      string(kind),
-		PageCount: tree.PageCount,
-		LineCount: tree.LineCount,
-		IndexedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		DocID:        docID,
+		Type:         string(kind),
+		PageCount:    tree.PageCount,
+		LineCount:    tree.LineCount,
+		IndexedAt:    time.Now().UTC().Format(time.RFC3339Nano),
+		Status:       tree.Status,
+		StatusReason: tree.StatusReason,
 	}
 	if err := p.store.UpdateIndexEntry(ctx, project, path, entry); err != nil && p.log != nil {
 		p.log.Warn("pageindex.write update index: " + err.Error())
@@ -224,9 +268,58 @@ func (p *Plugin) Search(ctx context.Context, auth files.AuthContext, project, qu
 	return p.searcher.Run(ctx, SearchInput{Project: project, Query: query, PathPrefix: pathPrefix, Limit: limit})
 }
 
-func isLongDocPath(path string) bool {
-	p := strings.ToLower(strings.TrimSpace(path))
-	return strings.HasSuffix(p, ".pdf") || strings.HasSuffix(p, ".md")
+// longDocKinds maps the file extensions pageindex indexes to their pipeline.
+var longDocKinds = map[string]DocKind{
+	".pdf":  KindPDF,
+	".md":   KindMarkdown,
+	".html": KindHTML,
+	".htm":  KindHTML,
+	".docx": KindDOCX,
+	".txt":  KindText,
+}
+
+// docKindForPath returns the document kind for a long-document path.
+func docKindForPath(path string) (DocKind, bool) {
+	kind, ok := longDocKinds[strings.ToLower(pathpkg.Ext(strings.TrimSpace(path)))]
+	return kind, ok
+}
+
+// indexedKind is docKindForPath narrowed to the kinds this plugin indexes on
+// write. Plain text is common and rarely worth LLM calls, so .txt is only
+// indexed when indexer.index_txt is set.
+func (p *Plugin) indexedKind(path string) (DocKind, bool) {
+	kind, ok := docKindForPath(path)
+	if ok && kind == KindText && !p.cfg.Indexer.IndexText {
+		return kind, false
+	}
+	return kind, ok
+}
+
+// decodeDOCXPayload decodes a base64 .docx upload into the archive bytes and
+// the plain-text rendering stored in FileIO, one paragraph per block with
+// headings marked in Markdown style.
+func decodeDOCXPayload(content string, offset int64, mode files.WriteMode) ([]byte, string, error) {
+	if offset != 0 || mode == files.WriteModeAppend {
+		return nil, "", files.NewError(files.ErrCodeInvalidArgument, "a base64 .docx upload must replace the whole file", false)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(content))
+	if err != nil {
+		return nil, "", files.NewError(files.ErrCodeInvalidArgument, "content is not valid base64", false)
+	}
+	blocks, err := ExtractDOCXBlocks(raw)
+	if err != nil {
+		return nil, "", files.NewError(files.ErrCodeInvalidArgument, "content is not a readable .docx document: "+err.Error(), false)
+	}
+
+	parts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		if block.Level > 0 {
+			parts = append(parts, strings.Repeat("#", block.Level)+" "+block.Text)
+			continue
+		}
+		parts = append(parts, block.Text)
+	}
+	return raw, strings.Join(parts, "\n\n"), nil
 }
 
 func docIDFromAuth(auth files.AuthContext, project, path string) string {
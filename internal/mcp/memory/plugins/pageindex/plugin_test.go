@@ -1,7 +1,9 @@
 package pageindex
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"strings"
 	"testing"
 
@@ -70,12 +72,15 @@ func TestIsLongDocPathClassification(t *testing.T) {
 		{"/Notes/X.PDF", true},
 		{"/notes/y.md", true},
 		{"/notes/Z.MD", true},
-		{"/notes/y.txt", false},
+		{"/notes/y.txt", true},
+		{"/site/page.HTM", true},
+		{"/docs/spec.docx", true},
+		{"/notes/y.doc", false},
 		{"/notes/y", false},
 	}
 	for _, c := range cases {
-		if got := isLongDocPath(c.path); got != c.want {
-			t.Errorf("isLongDocPath(%q) = %v, want %v", c.path, got, c.want)
+		if _, got := docKindForPath(c.path); got != c.want {
+			t.Errorf("docKindForPath(%q) = %v, want %v", c.path, got, c.want)
 		}
 	}
 }
@@ -111,3 +116,61 @@ func TestPluginP09_RenameAtomicity_Skipped(t *testing.T) {
 func TestPluginP13_SkipRAGIndex_Skipped(t *testing.T) {
 	t.Skip("TODO(P13): verifiable only against a real DB observing mcp_file_index_jobs; covered by E2E suite")
 }
+
+// TestPluginIndexesTextOnlyWhenEnabled verifies .txt writes skip LLM indexing by default.
+func TestPluginIndexesTextOnlyWhenEnabled(t *testing.T) {
+	p := &Plugin{}
+	if _, indexed := p.indexedKind("/logs/app.txt"); indexed {
+		t.Fatal("expected .txt to be stored unindexed by default")
+	}
+	if _, indexed := p.indexedKind("/docs/a.md"); !indexed {
+		t.Fatal("expected .md to be indexed")
+	}
+
+	p.cfg.Indexer.IndexText = true
+	if kind, indexed := p.indexedKind("/logs/app.txt"); !indexed || kind != KindText {
+		t.Fatalf("expected .txt to be indexed when enabled, got %v %v", kind, indexed)
+	}
+}
+
+// TestDecodeDOCXPayload verifies base64 uploads decode to the archive and a plain-text rendering.
+func TestDecodeDOCXPayload(t *testing.T) {
+	data := buildTestDOCX(t,
+		`<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Scope</w:t></w:r></w:p>`+
+			`<w:p><w:r><w:t>Body text</w:t></w:r></w:p>`)
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	raw, text, err := decodeDOCXPayload(encoded, 0, files.WriteModeTruncate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raw, data) {
+		t.Fatal("expected decoded bytes to match the archive")
+	}
+	if text != "# Scope\n\nBody text" {
+		t.Fatalf("unexpected rendering %q", text)
+	}
+
+	for name, tc := range map[string]struct {
+		content string
+		offset  int64
+		mode    files.WriteMode
+	}{
+		"append":     {content: encoded, mode: files.WriteModeAppend},
+		"offset":     {content: encoded, offset: 4, mode: files.WriteModeOverwrite},
+		"bad base64": {content: "%%%", mode: files.WriteModeTruncate},
+		"not docx":   {content: base64.StdEncoding.EncodeToString([]byte("plain")), mode: files.WriteModeTruncate},
+	} {
+		if _, _, err := decodeDOCXPayload(tc.content, tc.offset, tc.mode); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	p, err := New(PluginDeps{UserFS: &files.Service{}, SystemFS: newMemoryFS(), Settings: Settings{LLM: LLMSettings{APIKey: "x"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Write(context.Background(), files.AuthContext{}, "proj", "spec.docx", "%%%", "base64", 0, files.WriteModeTruncate); err == nil {
+		t.Fatal("expected invalid base64 .docx upload to be rejected before storage")
+	}
+}
@@ -0,0 +1,288 @@
+package pageindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	errors "github.com/Laisky/errors/v2"
+	logSDK "github.com/Laisky/go-utils/v6/log"
+	glog "github.com/Laisky/zap"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/library/jsonl"
+)
+
+const (
+	// ReplayModeReplay answers only from recorded fixtures.
+	ReplayModeReplay = "replay"
+	// ReplayModeRecord answers from fixtures and records upstream answers for misses.
+	ReplayModeRecord = "record"
+
+	// replayPromptSnippetRunes caps the prompt excerpt stored next to each
+	// fixture so reviewers can tell recordings apart in diffs.
+	replayPromptSnippetRunes = 160
+	// replayMaxLineBytes bounds one fixture line when loading.
+	replayMaxLineBytes = 16 << 20
+)
+
+// ReplayFixture is one recorded request-hash → response pair. Fixture files
+// are JSON Lines, one fixture per line; later lines override earlier ones.
+type ReplayFixture struct {
+	// Hash is the hex-encoded HashRequest of the request, so it changes
+	// whenever the model, prompt, schema, parameters or AlgorithmVersion do.
+	Hash   string          `json:"hash"`
+	Model  string          `json:"model,omitempty"`
+	Prompt string          `json:"prompt,omitempty"`
+	Output json.RawMessage `json:"output,omitempty"`
+	Text   string          `json:"text,omitempty"`
+	Usage  ReplayUsage     `json:"usage"`
+}
+
+// ReplayUsage is the recorded token usage of a fixture.
+type ReplayUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// ReplayConfig configures NewReplayLLM.
+type ReplayConfig struct {
+	// Path is the JSON Lines fixture file. It may be empty for purely
+	// scripted use through Add.
+	Path string
+	// Mode is ReplayModeReplay (default) or ReplayModeRecord.
+	Mode string
+	// Upstream answers fixture misses in record mode and serves CountTokens
+	// when set.
+	Upstream LLM
+	// Fallback is returned for misses in replay mode; nil makes a miss an error.
+	Fallback *Response
+	// Logger reports fixture misses; nil keeps misses silent apart from Misses.
+	Logger logSDK.Logger
+}
+
+// ReplayLLM is a deterministic LLM that answers from recorded fixtures keyed
+// by HashRequest, so whole indexing and search pipelines run offline. In
+// record mode, misses go to the upstream LLM and are appended to the fixture
+// file.
+type ReplayLLM struct {
+	mu       sync.Mutex
+	path     string
+	record   bool
+	upstream LLM
+	fallback *Response
+	logger   logSDK.Logger
+	fixtures map[string]ReplayFixture
+	misses   int
+}
+
+// NewReplayLLM loads the fixture file at cfg.Path, if it exists.
+func NewReplayLLM(cfg ReplayConfig) (*ReplayLLM, error) {
+	l := &ReplayLLM{
+		path:     cfg.Path,
+		upstream: cfg.Upstream,
+		fallback: cfg.Fallback,
+		logger:   cfg.Logger,
+		fixtures: map[string]ReplayFixture{},
+	}
+	switch cfg.Mode {
+	case "", ReplayModeReplay:
+	case ReplayModeRecord:
+		if cfg.Upstream == nil {
+			return nil, errors.New("replay: record mode requires an upstream llm")
+		}
+		if cfg.Path == "" {
+			return nil, errors.New("replay: record mode requires a fixture path")
+		}
+		l.record = true
+	default:
+		return nil, errors.Errorf("replay: unknown mode %q", cfg.Mode)
+	}
+	if cfg.Path != "" {
+		if err := l.load(); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// load reads every fixture line of l.path. A missing file is an empty set.
+func (l *ReplayLLM) load() error {
+	err := jsonl.ReadFile(l.path, replayMaxLineBytes, func(lineNo int, line []byte) error {
+		var fx ReplayFixture
+		if err := json.Unmarshal(line, &fx); err != nil {
+			return errors.Wrapf(err, "parse replay fixture %s:%d", l.path, lineNo)
+		}
+		if fx.Hash == "" {
+			return errors.Errorf("replay fixture %s:%d has no hash", l.path, lineNo)
+		}
+		l.fixtures[fx.Hash] = fx
+		return nil
+	})
+	return errors.Wrap(err, "load replay fixtures")
+}
+
+// Respond returns the recorded response for req. In record mode a miss is
+// answered by the upstream LLM and persisted before returning.
+func (l *ReplayLLM) Respond(ctx context.Context, req Request) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	key := replayKey(req)
+	l.mu.Lock()
+	fx, ok := l.fixtures[key]
+	if !ok {
+		l.misses++
+	}
+	l.mu.Unlock()
+	if ok {
+		return fx.response(), nil
+	}
+
+	if !l.record {
+		if l.logger != nil {
+			l.logger.Warn("pageindex.replay.miss",
+				glog.String("hash", key),
+				glog.String("model", req.Model),
+				glog.Bool("fallback", l.fallback != nil))
+		}
+		if l.fallback != nil {
+			cp := *l.fallback
+			return &cp, nil
+		}
+		return nil, errors.Errorf("replay: no fixture for prompt hash %s (model %s)", key, req.Model)
+	}
+
+	resp, err := l.upstream.Respond(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	fx = newReplayFixture(key, req, resp)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := jsonl.Append(l.path, fx); err != nil {
+		return nil, errors.Wrap(err, "append replay fixture")
+	}
+	l.fixtures[key] = fx
+	return fx.response(), nil
+}
+
+// CountTokens delegates to the upstream LLM when set and otherwise returns
+// the byte length divided by 4, like StubLLM.
+func (l *ReplayLLM) CountTokens(ctx context.Context, req Request) (int, error) {
+	if l.upstream != nil {
+		return l.upstream.CountTokens(ctx, req)
+	}
+	total := 0
+	for _, it := range req.Input {
+		total += len(it.Content) / 4
+	}
+	return total, nil
+}
+
+// Add registers a scripted response for req in memory only.
+func (l *ReplayLLM) Add(req Request, resp *Response) {
+	fx := newReplayFixture(replayKey(req), req, resp)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fixtures[fx.Hash] = fx
+}
+
+// Len returns the number of loaded or recorded fixtures.
+func (l *ReplayLLM) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.fixtures)
+}
+
+// Misses returns how many requests had no fixture.
+func (l *ReplayLLM) Misses() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.misses
+}
+
+// Save rewrites the fixture file with every fixture sorted by hash, which
+// keeps recordings stable under review. The file is replaced atomically.
+func (l *ReplayLLM) Save() error {
+	if l.path == "" {
+		return errors.New("replay: no fixture path configured")
+	}
+	l.mu.Lock()
+	fixtures := make([]ReplayFixture, 0, len(l.fixtures))
+	for _, fx := range l.fixtures {
+		fixtures = append(fixtures, fx)
+	}
+	l.mu.Unlock()
+	sort.Slice(fixtures, func(i, j int) bool { return fixtures[i].Hash < fixtures[j].Hash })
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, fx := range fixtures {
+		if err := enc.Encode(fx); err != nil {
+			return errors.Wrap(err, "encode replay fixture")
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return errors.Wrap(err, "create replay fixture dir")
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return errors.Wrap(err, "write replay fixtures")
+	}
+	return errors.Wrap(os.Rename(tmp, l.path), "replace replay fixtures")
+}
+
+// replayKey returns the hex fixture key for req, deriving the hash the same
+// way Indexer.callLLM does when PromptHash is unset.
+func replayKey(req Request) string {
+	hash := req.PromptHash
+	if hash == [32]byte{} {
+		hash = HashRequest(req)
+	}
+	return hex.EncodeToString(hash[:])
+}
+
+func newReplayFixture(key string, req Request, resp *Response) ReplayFixture {
+	fx := ReplayFixture{Hash: key, Model: req.Model}
+	if n := len(req.Input); n > 0 {
+		fx.Prompt = truncateRunes(req.Input[n-1].Content, replayPromptSnippetRunes)
+	}
+	if resp != nil {
+		fx.Output = resp.Output
+		fx.Text = resp.Text
+		fx.Usage = ReplayUsage{
+			InputTokens:  resp.Usage.InputTokens,
+			OutputTokens: resp.Usage.OutputTokens,
+			TotalTokens:  resp.Usage.TotalTokens,
+		}
+	}
+	return fx
+}
+
+// response returns a fresh Response for the fixture.
+func (fx ReplayFixture) response() *Response {
+	return &Response{
+		Output: append(json.RawMessage(nil), fx.Output...),
+		Text:   fx.Text,
+		Usage: Usage{
+			InputTokens:  fx.Usage.InputTokens,
+			OutputTokens: fx.Usage.OutputTokens,
+			TotalTokens:  fx.Usage.TotalTokens,
+		},
+	}
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with "...".
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
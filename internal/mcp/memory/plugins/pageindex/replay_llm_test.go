@@ -0,0 +1,127 @@
+package pageindex
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplayLLMRecordThenReplayIndex(t *testing.T) {
+	tk, err := NewTokenizer("gpt-5.4-mini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := defaultTestSettings()
+	cfg.Algo.GenerateNodeSummary = true
+	fixtures := filepath.Join(t.TempDir(), "fixtures", "pageindex.jsonl")
+	doc := []byte("# Manual\n\n## Install\n\nrun make\n\n## Usage\n\ncall api\n\n## FAQ\n\nask us\n")
+	ctx := context.Background()
+
+	upstream := NewStubLLM()
+	upstream.SetDefault(&Response{Text: "summary", Usage: Usage{InputTokens: 10, OutputTokens: 2, TotalTokens: 12}})
+	recorder, err := NewReplayLLM(ReplayConfig{Path: fixtures, Mode: ReplayModeRecord, Upstream: upstream})
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := NewIndexer(Deps{LLM: recorder, Tokenizer: tk, Settings: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, _, err := idx.Index(ctx, KindMarkdown, doc, IndexOptions{DocID: "d1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upstream.CallCount() != 3 || recorder.Len() != 3 {
+		t.Fatalf("expected 3 recorded calls, got upstream=%d fixtures=%d", upstream.CallCount(), recorder.Len())
+	}
+
+	replayer, err := NewReplayLLM(ReplayConfig{Path: fixtures})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayer.Len() != 3 {
+		t.Fatalf("expected 3 fixtures on disk, got %d", replayer.Len())
+	}
+	idx, err = NewIndexer(Deps{LLM: replayer, Tokenizer: tk, Settings: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, stats, err := idx.Index(ctx, KindMarkdown, doc, IndexOptions{DocID: "d1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayer.Misses() != 0 || stats.InputTokens != 30 {
+		t.Fatalf("expected a full replay with recorded usage, got misses=%d tokens_in=%d", replayer.Misses(), stats.InputTokens)
+	}
+	if replayed.ContentHash != recorded.ContentHash {
+		t.Fatal("expected the replayed tree to match the recorded one")
+	}
+	WalkNodes(replayed.Structure, func(n *Node) {
+		if len(n.Children) == 0 && n.Summary != "summary" {
+			t.Fatalf("leaf %q lost its summary", n.Title)
+		}
+	})
+
+	edited := []byte(strings.Replace(string(doc), "call api", "call the v2 api", 1))
+	if _, _, err := idx.Index(ctx, KindMarkdown, edited, IndexOptions{DocID: "d1"}, nil); err == nil || !strings.Contains(err.Error(), "no fixture") {
+		t.Fatalf("expected a fixture miss for the edited section, got %v", err)
+	}
+}
+
+func TestReplayLLMScriptedAndSave(t *testing.T) {
+	ctx := context.Background()
+	req := Request{Model: "gpt-5.4-mini", Input: []InputItem{{Role: "user", Content: "Which node answers the question?"}}}
+	other := Request{Model: "gpt-5.4-mini", Input: []InputItem{{Role: "user", Content: "unscripted"}}}
+
+	l, err := NewReplayLLM(ReplayConfig{Fallback: TextResponse("fallback")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Add(req, JSONResponse(map[string]any{"node_list": []string{"0001"}}))
+	resp, err := l.Respond(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Output) != `{"node_list":["0001"]}` {
+		t.Fatalf("unexpected scripted output %s", resp.Output)
+	}
+	resp, err = l.Respond(ctx, other)
+	if err != nil || resp.Text != "fallback" {
+		t.Fatalf("expected the fallback response, got %v %v", resp, err)
+	}
+	if err := l.Save(); err == nil {
+		t.Fatal("expected Save without a path to fail")
+	}
+
+	path := filepath.Join(t.TempDir(), "scripted.jsonl")
+	saved, err := NewReplayLLM(ReplayConfig{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved.Add(req, TextResponse("a"))
+	saved.Add(other, TextResponse("b"))
+	if err := saved.Save(); err != nil {
+		t.Fatal(err)
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(body), "\n"); lines != 2 {
+		t.Fatalf("expected 2 fixture lines, got %d", lines)
+	}
+	loaded, err := NewReplayLLM(ReplayConfig{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = loaded.Respond(ctx, other)
+	if err != nil || resp.Text != "b" {
+		t.Fatalf("expected saved fixture, got %v %v", resp, err)
+	}
+
+	if _, err := NewReplayLLM(ReplayConfig{Path: path, Mode: ReplayModeRecord}); err == nil {
+		t.Fatal("expected record mode without upstream to fail")
+	}
+}
@@ -19,6 +19,9 @@ type IndexerSettings struct {
 	MaxConcurrency int
 	Retry          RetrySettings
 	Cache          CacheSettings
+	Limits         LimitSettings
+	// IndexText opts .txt writes into LLM indexing; they are stored unindexed otherwise.
+	IndexText bool
 }
 
 // RetrySettings controls per-LLM-call exponential backoff.
@@ -28,11 +31,33 @@ type RetrySettings struct {
 	MaxBackoff     time.Duration
 }
 
+// LimitSettings caps the LLM spend of indexing. Zero disables a limit. When
+// a limit is reached the indexer stops calling the LLM and keeps the tree it
+// has, marked IndexStatusBudgetExhausted.
+type LimitSettings struct {
+	DocMaxTokens  int64
+	DocMaxCalls   int
+	DocMaxCostUSD float64
+	// Key limits apply per API key over KeyWindow, across all documents.
+	KeyMaxTokens  int64
+	KeyMaxCalls   int
+	KeyMaxCostUSD float64
+	KeyWindow     time.Duration
+	// InputCostPerMTok and OutputCostPerMTok price tokens in USD per million
+	// for the cost limits.
+	InputCostPerMTok  float64
+	OutputCostPerMTok float64
+}
+
 // CacheSettings controls the local bbolt response cache.
 type CacheSettings struct {
 	Enabled      bool
 	Path         string
 	MaxSizeBytes int64
+	// MaxEntries caps cached responses; zero leaves only the byte cap.
+	MaxEntries int
+	// TTL expires cached responses on read; zero keeps them until evicted.
+	TTL time.Duration
 }
 
 // LLMSettings captures the OpenAI Responses-API transport configuration.
@@ -41,6 +66,11 @@ type LLMSettings struct {
 	RetrieveModel string
 	APIKey        string
 	BaseURL       string
+	// ReplayMode is empty for live calls, ReplayModeReplay to answer only
+	// from ReplayPath fixtures, or ReplayModeRecord to call the API and
+	// record every new answer to ReplayPath.
+	ReplayMode string
+	ReplayPath string
 }
 
 // AlgoSettings tunes the upstream PageIndex algorithm parameters.
@@ -95,13 +125,29 @@ func LoadSettings() Settings {
 				Enabled:      gconfig.S.GetBool(settingsPrefix + ".indexer.cache.enabled"),
 				Path:         stringOr(settingsPrefix+".indexer.cache.path", "/var/lib/laisky/pageindex-cache.bbolt"),
 				MaxSizeBytes: int64Or(settingsPrefix+".indexer.cache.max_size_bytes", 1<<30),
+				MaxEntries:   intOr(settingsPrefix+".indexer.cache.max_entries", 0),
+				TTL:          durationOr(settingsPrefix+".indexer.cache.ttl", 0),
 			},
+			Limits: LimitSettings{
+				DocMaxTokens:      int64Or(settingsPrefix+".indexer.limits.doc.max_tokens", 0),
+				DocMaxCalls:       intOr(settingsPrefix+".indexer.limits.doc.max_calls", 0),
+				DocMaxCostUSD:     mcpplugin.FloatFromConfig(settingsPrefix + ".indexer.limits.doc.max_cost_usd"),
+				KeyMaxTokens:      int64Or(settingsPrefix+".indexer.limits.key.max_tokens", 0),
+				KeyMaxCalls:       intOr(settingsPrefix+".indexer.limits.key.max_calls", 0),
+				KeyMaxCostUSD:     mcpplugin.FloatFromConfig(settingsPrefix + ".indexer.limits.key.max_cost_usd"),
+				KeyWindow:         durationOr(settingsPrefix+".indexer.limits.key.window", 24*time.Hour),
+				InputCostPerMTok:  mcpplugin.FloatFromConfig(settingsPrefix + ".indexer.limits.input_cost_per_mtok"),
+				OutputCostPerMTok: mcpplugin.FloatFromConfig(settingsPrefix + ".indexer.limits.output_cost_per_mtok"),
+			},
+			IndexText: boolOr(settingsPrefix+".indexer.index_txt", false),
 		},
 		LLM: LLMSettings{
 			IndexingModel: stringOr(settingsPrefix+".llm.indexing_model", "gpt-5.4-mini"),
 			RetrieveModel: stringOr(settingsPrefix+".llm.retrieve_model", "gpt-5.4-mini"),
 			APIKey:        gconfig.S.GetString(settingsPrefix + ".llm.api_key"),
 			BaseURL:       gconfig.S.GetString(settingsPrefix + ".llm.base_url"),
+			ReplayMode:    gconfig.S.GetString(settingsPrefix + ".llm.replay.mode"),
+			ReplayPath:    gconfig.S.GetString(settingsPrefix + ".llm.replay.path"),
 		},
 		Algo: AlgoSettings{
 			TocCheckPageNum:        intOr(settingsPrefix+".algo.toc_check_page_num", 20),
@@ -123,8 +169,12 @@ func LoadSettings() Settings {
 }
 
 // Enabled reports whether the pageindex_plugin should be constructed at startup.
-// Per proposal §2.7 the plugin is gated on llm.api_key being non-empty.
+// Per proposal §2.7 the plugin is gated on llm.api_key being non-empty;
+// replay mode with a fixture path runs offline and needs no key.
 func (s Settings) Enabled() bool {
+	if s.LLM.ReplayMode == ReplayModeReplay && s.LLM.ReplayPath != "" {
+		return true
+	}
 	return s.LLM.APIKey != ""
 }
 
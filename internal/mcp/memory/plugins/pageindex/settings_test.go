@@ -91,6 +91,8 @@ func TestSettingsEnabledGate(t *testing.T) {
 
 	require.False(t, Settings{}.Enabled())
 	require.True(t, Settings{LLM: LLMSettings{APIKey: "sk-foo"}}.Enabled())
+	require.True(t, Settings{LLM: LLMSettings{ReplayMode: ReplayModeReplay, ReplayPath: "fixtures.jsonl"}}.Enabled())
+	require.False(t, Settings{LLM: LLMSettings{ReplayMode: ReplayModeRecord, ReplayPath: "fixtures.jsonl"}}.Enabled())
 }
 
 // TestSearchModeAlias keeps the manager/plugin agreement honest.
@@ -99,3 +101,30 @@ func TestSearchModeAlias(t *testing.T) {
 
 	require.Equal(t, mcpplugin.SearchModeTreeReasoning, SearchModeTreeReasoning)
 }
+
+// TestLoadSettingsCostLimits verifies YAML integers and strings load as USD
+// values and that a cost limit without both rates is flagged.
+func TestLoadSettingsCostLimits(t *testing.T) {
+	values := map[string]any{
+		settingsPrefix + ".indexer.limits.doc.max_cost_usd":     2,
+		settingsPrefix + ".indexer.limits.key.max_cost_usd":     "0.5",
+		settingsPrefix + ".indexer.limits.input_cost_per_mtok":  0.15,
+		settingsPrefix + ".indexer.limits.output_cost_per_mtok": nil,
+	}
+	for k, v := range values {
+		prior := gconfig.Shared.Get(k)
+		gconfig.Shared.Set(k, v)
+		t.Cleanup(func() { gconfig.Shared.Set(k, prior) })
+	}
+
+	limits := LoadSettings().Indexer.Limits
+	require.InDelta(t, 2, limits.DocMaxCostUSD, 1e-9)
+	require.InDelta(t, 0.5, limits.KeyMaxCostUSD, 1e-9)
+	require.InDelta(t, 0.15, limits.InputCostPerMTok, 1e-9)
+	require.Zero(t, limits.OutputCostPerMTok)
+	require.True(t, limits.unpricedCostLimit())
+
+	limits.OutputCostPerMTok = 0.6
+	require.False(t, limits.unpricedCostLimit())
+	require.False(t, LimitSettings{DocMaxTokens: 100}.unpricedCostLimit())
+}
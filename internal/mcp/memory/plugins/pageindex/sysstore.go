@@ -22,6 +22,10 @@ type IndexEntry struct {
 	PageCount int    `json:"page_count,omitempty"`
 	LineCount int    `json:"line_count,omitempty"`
 	IndexedAt string `json:"indexed_at"`
+	// Status mirrors Tree.Status so budget-limited documents can be listed
+	// without loading every tree.
+	Status       string `json:"status,omitempty"`
+	StatusReason string `json:"status_reason,omitempty"`
 }
 
 // Index is the persisted path↔doc_id catalog (one file per project).
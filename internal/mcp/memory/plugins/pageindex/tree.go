@@ -14,6 +14,13 @@ type Tree struct {
 	// Pages caches per-page extracted text so retrieval can resolve page ranges
 	// without re-parsing the original bytes.
 	Pages []Page `json:"pages,omitempty"`
+	// ContentHash digests the outline so a re-index can tell whether any
+	// node changed.
+	ContentHash string `json:"content_hash,omitempty"`
+	// Status is IndexStatusComplete or IndexStatusBudgetExhausted; for the
+	// latter StatusReason names the budget that ran out.
+	Status       string `json:"status,omitempty"`
+	StatusReason string `json:"status_reason,omitempty"`
 }
 
 // Node is a hierarchical PageIndex tree node (parity with upstream JSON shape).
@@ -26,9 +33,12 @@ type Node struct {
 	Summary    string  `json:"summary,omitempty"`
 	Text       string  `json:"text,omitempty"`
 	Children   []*Node `json:"nodes,omitempty"`
+	// ContentHash is the sha256 of the text the node summary is derived from.
+	ContentHash string `json:"content_hash,omitempty"`
 }
 
-// Page is one page (PDF) or one heading section (Markdown).
+// Page is one page (PDF), one heading section (Markdown, HTML, DOCX) or one
+// synthetic block of lines (plain text).
 type Page struct {
 	Page    int    `json:"page"`
 	Content string `json:"content"`
@@ -59,6 +69,8 @@ type StructureView struct {
 	PageCount      int     `json:"page_count,omitempty"`
 	LineCount      int     `json:"line_count,omitempty"`
 	Outline        []*Node `json:"outline"`
+	Status         string  `json:"status,omitempty"`
+	StatusReason   string  `json:"status_reason,omitempty"`
 }
 
 // DocKind discriminates supported source documents.
@@ -69,9 +81,16 @@ const (
 	KindPDF DocKind = "pdf"
 	// KindMarkdown identifies a markdown source.
 	KindMarkdown DocKind = "md"
+	// KindHTML identifies an HTML source.
+	KindHTML DocKind = "html"
+	// KindDOCX identifies a Word (Office Open XML) source.
+	KindDOCX DocKind = "docx"
+	// KindText identifies a plain-text source.
+	KindText DocKind = "txt"
 )
 
-// CloneOutline returns a deep copy of nodes with the heavy Text field stripped.
+// CloneOutline returns a deep copy of nodes with the heavy Text field and the
+// bookkeeping ContentHash stripped.
 func CloneOutline(nodes []*Node) []*Node {
 	if len(nodes) == 0 {
 		return nil
@@ -89,6 +108,7 @@ func cloneNodeOutline(n *Node) *Node {
 	}
 	cp := *n
 	cp.Text = ""
+	cp.ContentHash = ""
 	cp.Children = CloneOutline(n.Children)
 	return &cp
 }
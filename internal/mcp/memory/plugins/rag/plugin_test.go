@@ -68,13 +68,13 @@ type noStorageFixture struct{}
 func (f *noStorageFixture) Plugin() mcpplugin.Plugin { return &noStoragePlugin{} }
 
 // NewAuthContext returns a synthetic caller identity scoped to the test.
-func (f *noStorageFixture) NewAuthContext(t *testing.T) mcpplugin.AuthContext {
+func (f *noStorageFixture) NewAuthContext(t conformance.T) mcpplugin.AuthContext {
 	t.Helper()
 	return mcpplugin.AuthContext{APIKey: "test-key", APIKeyHash: "test-hash", UserIdentity: "user:" + t.Name()}
 }
 
 // NewProject returns a synthetic project name scoped to the test.
-func (f *noStorageFixture) NewProject(t *testing.T) string {
+func (f *noStorageFixture) NewProject(t conformance.T) string {
 	t.Helper()
 	return "conf-" + t.Name()
 }
@@ -83,7 +83,7 @@ func (f *noStorageFixture) NewProject(t *testing.T) string {
 func (f *noStorageFixture) HasStorage() bool { return false }
 
 // Cleanup is a no-op for the no-storage fixture.
-func (f *noStorageFixture) Cleanup(*testing.T) {}
+func (f *noStorageFixture) Cleanup(conformance.T) {}
 
 // noStoragePlugin is a contract-satisfying stand-in for the conformance harness.
 type noStoragePlugin struct{}
@@ -0,0 +1,394 @@
+package remote
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+)
+
+const (
+	defaultMemoryListLimit   = 100
+	defaultMemorySearchLimit = 5
+)
+
+// memoryFile is one stored file.
+type memoryFile struct {
+	content   []byte
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+// MemoryBackend is an in-memory plugin with the user-visible semantics of
+// the rag plugin minus semantic search: search ranks whole files by query
+// term counts. Serve it with NewServer to get a self-contained reference
+// service for tests and for external teams to diff their backend against.
+type MemoryBackend struct {
+	name string
+	now  func() time.Time
+
+	mu sync.RWMutex
+	// data is keyed by API key hash, then project, then path.
+	data map[string]map[string]map[string]*memoryFile
+}
+
+// NewMemoryBackend returns an empty backend that reports name.
+func NewMemoryBackend(name string) *MemoryBackend {
+	return &MemoryBackend{
+		name: mcpplugin.NormalizeName(name),
+		now:  func() time.Time { return time.Now().UTC() },
+		data: map[string]map[string]map[string]*memoryFile{},
+	}
+}
+
+// Name returns the backend name.
+func (b *MemoryBackend) Name() string { return b.name }
+
+// Capabilities advertises lexical search and full random IO.
+func (b *MemoryBackend) Capabilities() mcpplugin.Capabilities {
+	return mcpplugin.Capabilities{
+		SearchModes:      []mcpplugin.SearchMode{mcpplugin.SearchModeLexical},
+		SupportsRandomIO: true,
+		SupportsRename:   true,
+		Notes:            "in-memory reference backend",
+	}
+}
+
+// Start is a no-op.
+func (b *MemoryBackend) Start(context.Context) error { return nil }
+
+// Stop is a no-op.
+func (b *MemoryBackend) Stop(context.Context) error { return nil }
+
+// Stat reports a file, a directory implied by deeper files, or Exists=false.
+func (b *MemoryBackend) Stat(_ context.Context, auth files.AuthContext, project, path string) (files.StatResult, error) {
+	if err := validateCall(auth, project, path); err != nil {
+		return files.StatResult{}, err
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	tree := b.data[auth.APIKeyHash][project]
+	if file, ok := tree[path]; ok {
+		return files.StatResult{Exists: true, Type: files.FileTypeFile, Size: int64(len(file.content)), CreatedAt: file.createdAt, UpdatedAt: file.updatedAt}, nil
+	}
+	if path == "" || hasChildren(tree, path) {
+		return files.StatResult{Exists: true, Type: files.FileTypeDirectory}, nil
+	}
+	return files.StatResult{Exists: false}, nil
+}
+
+// Read returns length bytes from offset; a negative length reads to EOF.
+func (b *MemoryBackend) Read(_ context.Context, auth files.AuthContext, project, path string, offset, length int64) (files.ReadResult, error) {
+	if err := validateCall(auth, project, path); err != nil {
+		return files.ReadResult{}, err
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	tree := b.data[auth.APIKeyHash][project]
+	file, ok := tree[path]
+	if !ok {
+		if path == "" || hasChildren(tree, path) {
+			return files.ReadResult{}, errors.WithStack(files.NewError(files.ErrCodeIsDirectory, "path is a directory", false))
+		}
+		return files.ReadResult{}, errors.WithStack(files.NewError(files.ErrCodeNotFound, "file not found", false))
+	}
+	size := int64(len(file.content))
+	if offset < 0 || offset > size {
+		return files.ReadResult{}, errors.WithStack(files.NewError(files.ErrCodeInvalidOffset, "offset is outside the file", false))
+	}
+	end := size
+	if length >= 0 {
+		end = min(size, offset+length)
+	}
+	return files.ReadResult{Content: string(file.content[offset:end]), ContentEncoding: "utf-8"}, nil
+}
+
+// Write applies content in mode. OVERWRITE writes at offset, which may not
+// be past EOF.
+func (b *MemoryBackend) Write(_ context.Context, auth files.AuthContext, project, path, content, contentEncoding string, offset int64, mode files.WriteMode) (files.WriteResult, error) {
+	if err := validateCall(auth, project, path); err != nil {
+		return files.WriteResult{}, err
+	}
+	if path == "" {
+		return files.WriteResult{}, errors.WithStack(files.NewError(files.ErrCodeInvalidPath, "path is required", false))
+	}
+	if _, err := files.NormalizeContentEncoding(contentEncoding); err != nil {
+		return files.WriteResult{}, errors.WithStack(err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	tree := b.tree(auth.APIKeyHash, project)
+	if hasChildren(tree, path) {
+		return files.WriteResult{}, errors.WithStack(files.NewError(files.ErrCodeIsDirectory, "path is a directory", false))
+	}
+	for parent := parentOf(path); parent != ""; parent = parentOf(parent) {
+		if _, ok := tree[parent]; ok {
+			return files.WriteResult{}, errors.WithStack(files.NewError(files.ErrCodeNotDirectory, "parent path is a file", false))
+		}
+	}
+
+	now := b.now()
+	file, exists := tree[path]
+	if !exists {
+		file = &memoryFile{createdAt: now}
+	}
+	switch mode {
+	case files.WriteModeAppend:
+		file.content = append(file.content, content...)
+	case files.WriteModeTruncate:
+		file.content = []byte(content)
+	case files.WriteModeOverwrite, "":
+		if offset < 0 || offset > int64(len(file.content)) {
+			return files.WriteResult{}, errors.WithStack(files.NewError(files.ErrCodeInvalidOffset, "offset is outside the file", false))
+		}
+		tail := int64(len(file.content)) - offset - int64(len(content))
+		updated := append(append([]byte(nil), file.content[:offset]...), content...)
+		if tail > 0 {
+			updated = append(updated, file.content[int64(len(file.content))-tail:]...)
+		}
+		file.content = updated
+	default:
+		return files.WriteResult{}, errors.WithStack(files.NewError(files.ErrCodeInvalidArgument, "unknown write mode", false))
+	}
+	file.updatedAt = now
+	tree[path] = file
+	return files.WriteResult{BytesWritten: int64(len(content))}, nil
+}
+
+// Delete removes a file, or a directory when recursive is set.
+func (b *MemoryBackend) Delete(_ context.Context, auth files.AuthContext, project, path string, recursive bool) (files.DeleteResult, error) {
+	if err := validateCall(auth, project, path); err != nil {
+		return files.DeleteResult{}, err
+	}
+	if path == "" {
+		return files.DeleteResult{}, errors.WithStack(files.NewError(files.ErrCodePermissionDenied, "root directory cannot be deleted", false))
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	tree := b.data[auth.APIKeyHash][project]
+	if _, ok := tree[path]; ok {
+		delete(tree, path)
+		return files.DeleteResult{DeletedCount: 1}, nil
+	}
+	children := childPaths(tree, path)
+	if len(children) == 0 {
+		return files.DeleteResult{}, errors.WithStack(files.NewError(files.ErrCodeNotFound, "path not found", false))
+	}
+	if !recursive {
+		return files.DeleteResult{}, errors.WithStack(files.NewError(files.ErrCodeNotEmpty, "directory is not empty", false))
+	}
+	for _, child := range children {
+		delete(tree, child)
+	}
+	return files.DeleteResult{DeletedCount: len(children)}, nil
+}
+
+// Rename moves a file or a whole directory.
+func (b *MemoryBackend) Rename(_ context.Context, auth files.AuthContext, project, fromPath, toPath string, overwrite bool) (files.RenameResult, error) {
+	if err := validateCall(auth, project, fromPath); err != nil {
+		return files.RenameResult{}, err
+	}
+	if err := files.ValidatePath(toPath); err != nil {
+		return files.RenameResult{}, errors.WithStack(err)
+	}
+	if fromPath == "" || toPath == "" || fromPath == toPath || strings.HasPrefix(toPath, fromPath+"/") {
+		return files.RenameResult{}, errors.WithStack(files.NewError(files.ErrCodeInvalidPath, "invalid rename target", false))
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	tree := b.data[auth.APIKeyHash][project]
+	moves := map[string]string{}
+	if _, ok := tree[fromPath]; ok {
+		moves[fromPath] = toPath
+	} else {
+		for _, child := range childPaths(tree, fromPath) {
+			moves[child] = toPath + strings.TrimPrefix(child, fromPath)
+		}
+	}
+	if len(moves) == 0 {
+		return files.RenameResult{}, errors.WithStack(files.NewError(files.ErrCodeNotFound, "path not found", false))
+	}
+	for _, dst := range moves {
+		if _, taken := tree[dst]; taken && !overwrite {
+			return files.RenameResult{}, errors.WithStack(files.NewError(files.ErrCodeAlreadyExists, "target already exists", false))
+		}
+	}
+	now := b.now()
+	for src, dst := range moves {
+		file := tree[src]
+		delete(tree, src)
+		file.updatedAt = now
+		tree[dst] = file
+	}
+	return files.RenameResult{MovedCount: len(moves)}, nil
+}
+
+// List returns the files and directories under path down to depth levels.
+func (b *MemoryBackend) List(_ context.Context, auth files.AuthContext, project, path string, depth, limit int) (files.ListResult, error) {
+	if err := validateCall(auth, project, path); err != nil {
+		return files.ListResult{}, err
+	}
+	depth = max(depth, 1)
+	if limit <= 0 {
+		limit = defaultMemoryListLimit
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	tree := b.data[auth.APIKeyHash][project]
+	if _, isFile := tree[path]; isFile {
+		return files.ListResult{}, errors.WithStack(files.NewError(files.ErrCodeNotDirectory, "path is a file", false))
+	}
+	children := childPaths(tree, path)
+	if path != "" && len(children) == 0 {
+		return files.ListResult{}, errors.WithStack(files.NewError(files.ErrCodeNotFound, "path not found", false))
+	}
+
+	entries := map[string]files.FileEntry{}
+	for _, child := range children {
+		segments := strings.Split(strings.TrimPrefix(child, path+"/"), "/")
+		for i := 0; i < len(segments) && i < depth; i++ {
+			entryPath := path + "/" + strings.Join(segments[:i+1], "/")
+			if i == len(segments)-1 {
+				file := tree[child]
+				entries[entryPath] = files.FileEntry{Name: segments[i], Path: entryPath, Type: files.FileTypeFile, Size: int64(len(file.content)), CreatedAt: file.createdAt, UpdatedAt: file.updatedAt}
+			} else if _, seen := entries[entryPath]; !seen {
+				entries[entryPath] = files.FileEntry{Name: segments[i], Path: entryPath, Type: files.FileTypeDirectory}
+			}
+		}
+	}
+	paths := make([]string, 0, len(entries))
+	for p := range entries {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	res := files.ListResult{HasMore: len(paths) > limit}
+	for _, p := range paths[:min(len(paths), limit)] {
+		res.Entries = append(res.Entries, entries[p])
+	}
+	return res, nil
+}
+
+// Search ranks whole files under pathPrefix by how often the query terms
+// occur. Project "*" searches every project of the caller.
+func (b *MemoryBackend) Search(_ context.Context, auth files.AuthContext, project, query, pathPrefix string, limit int) (files.SearchResult, error) {
+	if auth.APIKeyHash == "" {
+		return files.SearchResult{}, errors.WithStack(files.NewError(files.ErrCodePermissionDenied, "missing api key", false))
+	}
+	if err := files.ValidateSearchProject(project); err != nil {
+		return files.SearchResult{}, errors.WithStack(err)
+	}
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return files.SearchResult{}, errors.WithStack(files.NewError(files.ErrCodeInvalidQuery, "query is required", false))
+	}
+	if limit <= 0 {
+		limit = defaultMemorySearchLimit
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var chunks []files.ChunkEntry
+	for name, tree := range b.data[auth.APIKeyHash] {
+		if project != files.ProjectWildcard && name != project {
+			continue
+		}
+		for path, file := range tree {
+			if pathPrefix != "" && path != pathPrefix && !strings.HasPrefix(path, strings.TrimSuffix(pathPrefix, "/")+"/") {
+				continue
+			}
+			text := strings.ToLower(string(file.content))
+			score := 0
+			for _, term := range terms {
+				score += strings.Count(text, term)
+			}
+			if score == 0 {
+				continue
+			}
+			chunks = append(chunks, files.ChunkEntry{
+				Project:          name,
+				FilePath:         path,
+				FileSeekEndBytes: int64(len(file.content)),
+				IsFullFile:       true,
+				ChunkContent:     string(file.content),
+				Score:            float64(score),
+			})
+		}
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		if chunks[i].Score != chunks[j].Score {
+			return chunks[i].Score > chunks[j].Score
+		}
+		return chunks[i].Project+chunks[i].FilePath < chunks[j].Project+chunks[j].FilePath
+	})
+	return files.SearchResult{Chunks: chunks[:min(len(chunks), limit)]}, nil
+}
+
+// tree returns the path map of one project, creating it. Callers hold b.mu.
+func (b *MemoryBackend) tree(apiKeyHash, project string) map[string]*memoryFile {
+	projects, ok := b.data[apiKeyHash]
+	if !ok {
+		projects = map[string]map[string]*memoryFile{}
+		b.data[apiKeyHash] = projects
+	}
+	tree, ok := projects[project]
+	if !ok {
+		tree = map[string]*memoryFile{}
+		projects[project] = tree
+	}
+	return tree
+}
+
+// validateCall checks the tenant, project and path of a single-project call.
+func validateCall(auth files.AuthContext, project, path string) error {
+	if auth.APIKeyHash == "" {
+		return errors.WithStack(files.NewError(files.ErrCodePermissionDenied, "missing api key", false))
+	}
+	if err := files.ValidateProject(project); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(files.ValidatePath(path))
+}
+
+// childPaths returns the files below the directory dir, sorted.
+func childPaths(tree map[string]*memoryFile, dir string) []string {
+	var out []string
+	for path := range tree {
+		if strings.HasPrefix(path, dir+"/") {
+			out = append(out, path)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// hasChildren reports whether dir has any file below it.
+func hasChildren(tree map[string]*memoryFile, dir string) bool {
+	for path := range tree {
+		if strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// parentOf returns the parent directory of path, or "" for a top-level path.
+func parentOf(path string) string {
+	idx := strings.LastIndex(path, "/")
+	if idx <= 0 {
+		return ""
+	}
+	return path[:idx]
+}
@@ -0,0 +1,341 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	logSDK "github.com/Laisky/go-utils/v6/log"
+	"github.com/Laisky/zap"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+	"github.com/Laisky/laisky-blog-graphql/library/log"
+)
+
+const (
+	// defaultTimeout bounds one remote call when Config.Timeout is zero.
+	defaultTimeout = 10 * time.Second
+	// defaultMaxResponseBytes caps a response body when Config.MaxResponseBytes is zero.
+	defaultMaxResponseBytes = 64 << 20
+)
+
+// Config configures New.
+type Config struct {
+	// Name is the plugin name callers select with plugin=<name>.
+	Name string
+	// BaseURL is the service root; paths such as /v1/stat are appended.
+	BaseURL string
+	// Token is sent as a bearer token on every request.
+	Token string
+	// Timeout bounds every call, in addition to the caller's context.
+	Timeout time.Duration
+	// MaxResponseBytes caps a response body.
+	MaxResponseBytes int64
+	// ForwardAPIKey sends the caller's raw API key, for services that bill
+	// embeddings or LLM calls to it. Leave it off for third-party services.
+	ForwardAPIKey bool
+	// HTTPClient defaults to a client without its own timeout.
+	HTTPClient *http.Client
+	Logger     logSDK.Logger
+}
+
+// Plugin forwards the plugin contract to a remote service. Capabilities are
+// negotiated on Start, or on the first call when Start failed.
+type Plugin struct {
+	name             string
+	baseURL          string
+	token            string
+	timeout          time.Duration
+	maxResponseBytes int64
+	forwardAPIKey    bool
+	client           *http.Client
+	logger           logSDK.Logger
+
+	mu         sync.Mutex
+	negotiated bool
+	caps       mcpplugin.Capabilities
+}
+
+// New validates cfg and builds the plugin. It does not contact the service.
+func New(cfg Config) (*Plugin, error) {
+	name := mcpplugin.NormalizeName(cfg.Name)
+	if name == "" {
+		return nil, errors.New("remote plugin name is required")
+	}
+	base, err := url.Parse(strings.TrimSpace(cfg.BaseURL))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, errors.Errorf("remote plugin %q base_url must be an absolute http(s) URL", name)
+	}
+	if strings.TrimSpace(cfg.Token) == "" {
+		return nil, errors.Errorf("remote plugin %q token is required", name)
+	}
+
+	p := &Plugin{
+		name:             name,
+		baseURL:          strings.TrimRight(base.String(), "/"),
+		token:            strings.TrimSpace(cfg.Token),
+		timeout:          cfg.Timeout,
+		maxResponseBytes: cfg.MaxResponseBytes,
+		forwardAPIKey:    cfg.ForwardAPIKey,
+		client:           cfg.HTTPClient,
+		logger:           cfg.Logger,
+	}
+	if p.timeout <= 0 {
+		p.timeout = defaultTimeout
+	}
+	if p.maxResponseBytes <= 0 {
+		p.maxResponseBytes = defaultMaxResponseBytes
+	}
+	if p.client == nil {
+		p.client = &http.Client{}
+	}
+	if p.logger == nil {
+		p.logger = log.Logger.Named("mcp_memory_remote")
+	}
+	return p, nil
+}
+
+// Name returns the configured plugin name.
+func (p *Plugin) Name() string { return p.name }
+
+// Capabilities returns the negotiated capabilities, or zero values before
+// the first successful negotiation.
+func (p *Plugin) Capabilities() mcpplugin.Capabilities {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.caps
+}
+
+// Start negotiates the protocol version and capabilities with the service.
+func (p *Plugin) Start(ctx context.Context) error {
+	return p.negotiate(ctx)
+}
+
+// Stop releases idle connections.
+func (p *Plugin) Stop(context.Context) error {
+	p.client.CloseIdleConnections()
+	return nil
+}
+
+// Stat forwards file_stat.
+func (p *Plugin) Stat(ctx context.Context, auth files.AuthContext, project, path string) (files.StatResult, error) {
+	var resp StatResponse
+	if err := p.call(ctx, statPath, StatRequest{Tenant: p.tenant(auth), Project: project, Path: path}, &resp); err != nil {
+		return files.StatResult{}, err
+	}
+	return files.StatResult{
+		Exists:    resp.Exists,
+		Type:      resp.Type,
+		Size:      resp.Size,
+		CreatedAt: resp.CreatedAt,
+		UpdatedAt: resp.UpdatedAt,
+	}, nil
+}
+
+// Read forwards file_read.
+func (p *Plugin) Read(ctx context.Context, auth files.AuthContext, project, path string, offset, length int64) (files.ReadResult, error) {
+	var resp ReadResponse
+	req := ReadRequest{Tenant: p.tenant(auth), Project: project, Path: path, Offset: offset, Length: length}
+	if err := p.call(ctx, readPath, req, &resp); err != nil {
+		return files.ReadResult{}, err
+	}
+	return files.ReadResult{Content: resp.Content, ContentEncoding: resp.ContentEncoding}, nil
+}
+
+// Write forwards file_write. Payloads over the negotiated MaxPayloadBytes
+// and offset writes the service does not support fail locally.
+func (p *Plugin) Write(ctx context.Context, auth files.AuthContext, project, path, content, contentEncoding string, offset int64, mode files.WriteMode) (files.WriteResult, error) {
+	if err := p.negotiate(ctx); err != nil {
+		return files.WriteResult{}, err
+	}
+	caps := p.Capabilities()
+	if err := files.ValidatePayloadSize(int64(len(content)), caps.MaxPayloadBytes); err != nil {
+		return files.WriteResult{}, errors.WithStack(err)
+	}
+	if mode == files.WriteModeOverwrite && offset > 0 && !caps.SupportsRandomIO {
+		return files.WriteResult{}, errors.WithStack(files.NewError(files.ErrCodeInvalidOffset,
+			fmt.Sprintf("plugin %q does not support writes at an offset", p.name), false))
+	}
+
+	var resp WriteResponse
+	req := WriteRequest{
+		Tenant:          p.tenant(auth),
+		Project:         project,
+		Path:            path,
+		Content:         content,
+		ContentEncoding: contentEncoding,
+		Offset:          offset,
+		Mode:            mode,
+	}
+	if err := p.call(ctx, writePath, req, &resp); err != nil {
+		return files.WriteResult{}, err
+	}
+	return files.WriteResult{BytesWritten: resp.BytesWritten}, nil
+}
+
+// Delete forwards file_delete.
+func (p *Plugin) Delete(ctx context.Context, auth files.AuthContext, project, path string, recursive bool) (files.DeleteResult, error) {
+	var resp DeleteResponse
+	req := DeleteRequest{Tenant: p.tenant(auth), Project: project, Path: path, Recursive: recursive}
+	if err := p.call(ctx, deletePath, req, &resp); err != nil {
+		return files.DeleteResult{}, err
+	}
+	return files.DeleteResult{DeletedCount: resp.DeletedCount}, nil
+}
+
+// Rename forwards file_rename, or fails locally when the service does not
+// support renames.
+func (p *Plugin) Rename(ctx context.Context, auth files.AuthContext, project, fromPath, toPath string, overwrite bool) (files.RenameResult, error) {
+	if err := p.negotiate(ctx); err != nil {
+		return files.RenameResult{}, err
+	}
+	if !p.Capabilities().SupportsRename {
+		return files.RenameResult{}, errors.WithStack(files.NewError(files.ErrCodeInvalidArgument,
+			fmt.Sprintf("plugin %q does not support rename", p.name), false))
+	}
+
+	var resp RenameResponse
+	req := RenameRequest{Tenant: p.tenant(auth), Project: project, FromPath: fromPath, ToPath: toPath, Overwrite: overwrite}
+	if err := p.call(ctx, renamePath, req, &resp); err != nil {
+		return files.RenameResult{}, err
+	}
+	return files.RenameResult{MovedCount: resp.MovedCount}, nil
+}
+
+// List forwards file_list.
+func (p *Plugin) List(ctx context.Context, auth files.AuthContext, project, path string, depth, limit int) (files.ListResult, error) {
+	var resp ListResponse
+	req := ListRequest{Tenant: p.tenant(auth), Project: project, Path: path, Depth: depth, Limit: limit}
+	if err := p.call(ctx, listPath, req, &resp); err != nil {
+		return files.ListResult{}, err
+	}
+	return files.ListResult{Entries: resp.Entries, HasMore: resp.HasMore}, nil
+}
+
+// Search forwards file_search.
+func (p *Plugin) Search(ctx context.Context, auth files.AuthContext, project, query, pathPrefix string, limit int) (files.SearchResult, error) {
+	var resp SearchResponse
+	req := SearchRequest{Tenant: p.tenant(auth), Project: project, Query: query, PathPrefix: pathPrefix, Limit: limit}
+	if err := p.call(ctx, searchPath, req, &resp); err != nil {
+		return files.SearchResult{}, err
+	}
+	return files.SearchResult{Chunks: resp.Chunks}, nil
+}
+
+// negotiate fetches the service capabilities once. It is retried on the
+// next call while it keeps failing.
+func (p *Plugin) negotiate(ctx context.Context) error {
+	p.mu.Lock()
+	negotiated := p.negotiated
+	p.mu.Unlock()
+	if negotiated {
+		return nil
+	}
+
+	// The round-trip runs without p.mu, so a slow remote never blocks
+	// Capabilities. Concurrent first calls may each ask; the first answer wins.
+	var resp CapabilitiesResponse
+	if err := p.do(ctx, http.MethodGet, capabilitiesPath, nil, &resp); err != nil {
+		return errors.Wrapf(err, "negotiate with remote plugin %q", p.name)
+	}
+	if resp.ProtocolVersion != ProtocolVersion {
+		return errors.Errorf("remote plugin %q speaks protocol version %d, want %d", p.name, resp.ProtocolVersion, ProtocolVersion)
+	}
+	caps := capabilitiesFromWire(resp.Capabilities)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.negotiated {
+		return nil
+	}
+	p.caps = caps
+	p.negotiated = true
+	p.logger.Info("remote memory plugin negotiated",
+		zap.String("plugin", p.name),
+		zap.String("remote_name", resp.Name),
+		zap.Bool("supports_rename", caps.SupportsRename),
+		zap.Int64("max_payload_bytes", caps.MaxPayloadBytes))
+	return nil
+}
+
+// call negotiates if needed and POSTs body to path.
+func (p *Plugin) call(ctx context.Context, path string, body, out any) error {
+	if err := p.negotiate(ctx); err != nil {
+		return err
+	}
+	return p.do(ctx, http.MethodPost, path, body, out)
+}
+
+// do sends one request and decodes the JSON response into out. Typed
+// remote errors come back as *files.Error.
+func (p *Plugin) do(ctx context.Context, method, path string, body, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "encode remote request")
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, reader)
+	if err != nil {
+		return errors.Wrap(err, "build remote request")
+	}
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set(ProtocolHeader, strconv.Itoa(ProtocolVersion))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "remote plugin %q %s", p.name, path)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, p.maxResponseBytes+1))
+	if err != nil {
+		return errors.Wrapf(err, "read remote plugin %q response", p.name)
+	}
+	if int64(len(raw)) > p.maxResponseBytes {
+		return errors.Errorf("remote plugin %q response exceeds %d bytes", p.name, p.maxResponseBytes)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return p.remoteError(resp.StatusCode, raw)
+	}
+	if err = json.Unmarshal(raw, out); err != nil {
+		return errors.Wrapf(err, "decode remote plugin %q response", p.name)
+	}
+	return nil
+}
+
+// remoteError converts a non-2xx response to an error.
+func (p *Plugin) remoteError(status int, raw []byte) error {
+	var envelope ErrorResponse
+	if json.Unmarshal(raw, &envelope) != nil || envelope.Error.Message == "" {
+		return errors.Errorf("remote plugin %q answered HTTP %d", p.name, status)
+	}
+	if envelope.Error.Code != "" {
+		return errors.WithStack(files.NewError(files.ErrorCode(envelope.Error.Code), envelope.Error.Message, envelope.Error.Retryable))
+	}
+	return errors.Errorf("remote plugin %q answered HTTP %d: %s", p.name, status, envelope.Error.Message)
+}
+
+// tenant converts auth to the wire tenant.
+func (p *Plugin) tenant(auth files.AuthContext) Tenant {
+	return tenantFor(auth, p.forwardAPIKey)
+}
@@ -0,0 +1,261 @@
+// Package remote implements a memory plugin that forwards every call to an
+// external service over JSON-over-HTTP, and a reference server that exposes
+// any in-process plugin over the same protocol.
+//
+// Protocol version 1:
+//
+//   - Every request carries "Authorization: Bearer <token>" and
+//     "X-Memory-Protocol: 1".
+//   - GET  <base>/v1/capabilities returns CapabilitiesResponse.
+//   - POST <base>/v1/{stat,read,write,delete,rename,list,search} take the
+//     matching *Request body and return the matching *Response body.
+//   - Failures use a non-2xx status and an ErrorResponse body. Codes are the
+//     files.ErrorCode values, such as NOT_FOUND or INVALID_PATH.
+//
+// Requests identify the tenant by API key hash. The raw API key is only sent
+// when the plugin is configured with ForwardAPIKey.
+package remote
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+)
+
+const (
+	// ProtocolVersion is the wire protocol version this package speaks.
+	ProtocolVersion = 1
+	// ProtocolHeader carries the protocol version on every request.
+	ProtocolHeader = "X-Memory-Protocol"
+
+	capabilitiesPath = "/v1/capabilities"
+	statPath         = "/v1/stat"
+	readPath         = "/v1/read"
+	writePath        = "/v1/write"
+	deletePath       = "/v1/delete"
+	renamePath       = "/v1/rename"
+	listPath         = "/v1/list"
+	searchPath       = "/v1/search"
+)
+
+// Tenant identifies the caller a request acts for.
+type Tenant struct {
+	APIKeyHash   string `json:"api_key_hash"`
+	UserID       string `json:"user_id,omitempty"`
+	UserIdentity string `json:"user_identity,omitempty"`
+	// APIKey is only sent when the plugin forwards API keys.
+	APIKey string `json:"api_key,omitempty"`
+}
+
+// CapabilitiesResponse is the body of GET /v1/capabilities.
+type CapabilitiesResponse struct {
+	ProtocolVersion int          `json:"protocol_version"`
+	Name            string       `json:"name"`
+	Capabilities    Capabilities `json:"capabilities"`
+}
+
+// Capabilities is the wire form of mcpplugin.Capabilities.
+type Capabilities struct {
+	SearchModes       []string `json:"search_modes"`
+	SupportsRandomIO  bool     `json:"supports_random_io"`
+	SupportsRename    bool     `json:"supports_rename"`
+	SupportsVersions  bool     `json:"supports_versions"`
+	AsyncIndexing     bool     `json:"async_indexing"`
+	FreshnessWindowMs int64    `json:"freshness_window_ms"`
+	MaxPayloadBytes   int64    `json:"max_payload_bytes"`
+	Notes             string   `json:"notes,omitempty"`
+}
+
+// StatRequest is the body of POST /v1/stat.
+type StatRequest struct {
+	Tenant  Tenant `json:"tenant"`
+	Project string `json:"project"`
+	Path    string `json:"path"`
+}
+
+// StatResponse answers StatRequest.
+type StatResponse struct {
+	Exists    bool           `json:"exists"`
+	Type      files.FileType `json:"type,omitempty"`
+	Size      int64          `json:"size"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// ReadRequest is the body of POST /v1/read. Length -1 reads to EOF.
+type ReadRequest struct {
+	Tenant  Tenant `json:"tenant"`
+	Project string `json:"project"`
+	Path    string `json:"path"`
+	Offset  int64  `json:"offset"`
+	Length  int64  `json:"length"`
+}
+
+// ReadResponse answers ReadRequest.
+type ReadResponse struct {
+	Content         string `json:"content"`
+	ContentEncoding string `json:"content_encoding"`
+}
+
+// WriteRequest is the body of POST /v1/write.
+type WriteRequest struct {
+	Tenant          Tenant          `json:"tenant"`
+	Project         string          `json:"project"`
+	Path            string          `json:"path"`
+	Content         string          `json:"content"`
+	ContentEncoding string          `json:"content_encoding"`
+	Offset          int64           `json:"offset"`
+	Mode            files.WriteMode `json:"mode"`
+}
+
+// WriteResponse answers WriteRequest.
+type WriteResponse struct {
+	BytesWritten int64 `json:"bytes_written"`
+}
+
+// DeleteRequest is the body of POST /v1/delete.
+type DeleteRequest struct {
+	Tenant    Tenant `json:"tenant"`
+	Project   string `json:"project"`
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive"`
+}
+
+// DeleteResponse answers DeleteRequest.
+type DeleteResponse struct {
+	DeletedCount int `json:"deleted_count"`
+}
+
+// RenameRequest is the body of POST /v1/rename.
+type RenameRequest struct {
+	Tenant    Tenant `json:"tenant"`
+	Project   string `json:"project"`
+	FromPath  string `json:"from_path"`
+	ToPath    string `json:"to_path"`
+	Overwrite bool   `json:"overwrite"`
+}
+
+// RenameResponse answers RenameRequest.
+type RenameResponse struct {
+	MovedCount int `json:"moved_count"`
+}
+
+// ListRequest is the body of POST /v1/list.
+type ListRequest struct {
+	Tenant  Tenant `json:"tenant"`
+	Project string `json:"project"`
+	Path    string `json:"path"`
+	Depth   int    `json:"depth"`
+	Limit   int    `json:"limit"`
+}
+
+// ListResponse answers ListRequest.
+type ListResponse struct {
+	Entries []files.FileEntry `json:"entries"`
+	HasMore bool              `json:"has_more"`
+}
+
+// SearchRequest is the body of POST /v1/search.
+type SearchRequest struct {
+	Tenant     Tenant `json:"tenant"`
+	Project    string `json:"project"`
+	Query      string `json:"query"`
+	PathPrefix string `json:"path_prefix"`
+	Limit      int    `json:"limit"`
+}
+
+// SearchResponse answers SearchRequest.
+type SearchResponse struct {
+	Chunks []files.ChunkEntry `json:"chunks"`
+}
+
+// ErrorResponse is the body of every non-2xx response.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody describes one failure. Code is a files.ErrorCode, or empty for
+// an untyped server failure.
+type ErrorBody struct {
+	Code      string `json:"code,omitempty"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable,omitempty"`
+}
+
+// tenantFor converts auth to its wire form.
+func tenantFor(auth files.AuthContext, forwardAPIKey bool) Tenant {
+	tenant := Tenant{APIKeyHash: auth.APIKeyHash, UserID: auth.UserID, UserIdentity: auth.UserIdentity}
+	if forwardAPIKey {
+		tenant.APIKey = auth.APIKey
+	}
+	return tenant
+}
+
+// authFor converts a wire tenant back to an AuthContext.
+func authFor(tenant Tenant) files.AuthContext {
+	return files.AuthContext{
+		APIKey:       tenant.APIKey,
+		APIKeyHash:   tenant.APIKeyHash,
+		UserID:       tenant.UserID,
+		UserIdentity: tenant.UserIdentity,
+	}
+}
+
+// capabilitiesToWire converts plugin capabilities to their wire form.
+func capabilitiesToWire(caps mcpplugin.Capabilities) Capabilities {
+	modes := make([]string, 0, len(caps.SearchModes))
+	for _, mode := range caps.SearchModes {
+		modes = append(modes, string(mode))
+	}
+	return Capabilities{
+		SearchModes:       modes,
+		SupportsRandomIO:  caps.SupportsRandomIO,
+		SupportsRename:    caps.SupportsRename,
+		SupportsVersions:  caps.SupportsVersions,
+		AsyncIndexing:     caps.AsyncIndexing,
+		FreshnessWindowMs: caps.FreshnessWindow.Milliseconds(),
+		MaxPayloadBytes:   caps.MaxPayloadBytes,
+		Notes:             caps.Notes,
+	}
+}
+
+// capabilitiesFromWire converts wire capabilities to plugin capabilities.
+func capabilitiesFromWire(caps Capabilities) mcpplugin.Capabilities {
+	modes := make([]mcpplugin.SearchMode, 0, len(caps.SearchModes))
+	for _, mode := range caps.SearchModes {
+		modes = append(modes, mcpplugin.SearchMode(mode))
+	}
+	return mcpplugin.Capabilities{
+		SearchModes:      modes,
+		SupportsRandomIO: caps.SupportsRandomIO,
+		SupportsRename:   caps.SupportsRename,
+		SupportsVersions: caps.SupportsVersions,
+		AsyncIndexing:    caps.AsyncIndexing,
+		FreshnessWindow:  time.Duration(caps.FreshnessWindowMs) * time.Millisecond,
+		MaxPayloadBytes:  caps.MaxPayloadBytes,
+		Notes:            caps.Notes,
+	}
+}
+
+// statusForCode maps a files error code to the HTTP status the reference
+// server answers with.
+func statusForCode(code files.ErrorCode) int {
+	switch code {
+	case files.ErrCodeNotFound:
+		return http.StatusNotFound
+	case files.ErrCodePermissionDenied:
+		return http.StatusForbidden
+	case files.ErrCodeAlreadyExists, files.ErrCodeResourceBusy, files.ErrCodeNotEmpty:
+		return http.StatusConflict
+	case files.ErrCodePayloadTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case files.ErrCodeRateLimited, files.ErrCodeQuotaExceeded:
+		return http.StatusTooManyRequests
+	case files.ErrCodeSearchBackend:
+		return http.StatusBadGateway
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,293 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/conformance"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+)
+
+const testToken = "secret-token"
+
+var testAuth = files.AuthContext{APIKey: "sk-raw", APIKeyHash: "tenant-a", UserIdentity: "user:a"}
+
+// newTestPair serves backend over httptest and returns a client plugin for it.
+func newTestPair(t *testing.T, backend mcpplugin.Plugin, mutate func(*Config)) *Plugin {
+	t.Helper()
+	srv, err := NewServer(ServerConfig{Backend: backend, Tokens: []string{testToken}})
+	require.NoError(t, err)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	cfg := Config{Name: "remote", BaseURL: ts.URL, Token: testToken}
+	if mutate != nil {
+		mutate(&cfg)
+	}
+	p, err := New(cfg)
+	require.NoError(t, err)
+	return p
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	_, err := New(Config{BaseURL: "http://x", Token: "t"})
+	require.Error(t, err)
+	_, err = New(Config{Name: "r", BaseURL: "/relative", Token: "t"})
+	require.Error(t, err)
+	_, err = New(Config{Name: "r", BaseURL: "ftp://x", Token: "t"})
+	require.Error(t, err)
+	_, err = New(Config{Name: "r", BaseURL: "https://x"})
+	require.Error(t, err)
+
+	p, err := New(Config{Name: " Remote ", BaseURL: "https://x/base/", Token: "t"})
+	require.NoError(t, err)
+	require.Equal(t, "remote", p.Name())
+	require.Equal(t, "https://x/base", p.baseURL)
+	require.Equal(t, defaultTimeout, p.timeout)
+}
+
+func TestPluginRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	p := newTestPair(t, NewMemoryBackend("ref"), nil)
+	require.NoError(t, p.Start(ctx))
+	require.True(t, p.Capabilities().SupportsRename)
+
+	_, err := p.Write(ctx, testAuth, "proj", "/notes/a.md", "hello remote world", "utf-8", 0, files.WriteModeTruncate)
+	require.NoError(t, err)
+
+	stat, err := p.Stat(ctx, testAuth, "proj", "/notes/a.md")
+	require.NoError(t, err)
+	require.True(t, stat.Exists)
+	require.Equal(t, files.FileTypeFile, stat.Type)
+	require.EqualValues(t, 18, stat.Size)
+
+	read, err := p.Read(ctx, testAuth, "proj", "/notes/a.md", 6, 6)
+	require.NoError(t, err)
+	require.Equal(t, "remote", read.Content)
+
+	list, err := p.List(ctx, testAuth, "proj", "", 2, 10)
+	require.NoError(t, err)
+	require.Len(t, list.Entries, 2)
+	require.Equal(t, "/notes", list.Entries[0].Path)
+	require.Equal(t, files.FileTypeDirectory, list.Entries[0].Type)
+
+	search, err := p.Search(ctx, testAuth, "proj", "remote", "", 5)
+	require.NoError(t, err)
+	require.Len(t, search.Chunks, 1)
+	require.Equal(t, "/notes/a.md", search.Chunks[0].FilePath)
+
+	renamed, err := p.Rename(ctx, testAuth, "proj", "/notes", "/archive", false)
+	require.NoError(t, err)
+	require.Equal(t, 1, renamed.MovedCount)
+
+	deleted, err := p.Delete(ctx, testAuth, "proj", "/archive", true)
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted.DeletedCount)
+}
+
+func TestPluginTypedErrors(t *testing.T) {
+	ctx := context.Background()
+	p := newTestPair(t, NewMemoryBackend("ref"), nil)
+
+	_, err := p.Read(ctx, testAuth, "proj", "/missing.md", 0, -1)
+	require.True(t, files.IsCode(err, files.ErrCodeNotFound), "got %v", err)
+
+	_, err = p.Delete(ctx, testAuth, "proj", "", true)
+	require.True(t, files.IsCode(err, files.ErrCodePermissionDenied), "got %v", err)
+
+	// Tenants are isolated by API key hash.
+	_, err = p.Write(ctx, testAuth, "proj", "/a.md", "x", "utf-8", 0, files.WriteModeTruncate)
+	require.NoError(t, err)
+	other := files.AuthContext{APIKeyHash: "tenant-b"}
+	stat, err := p.Stat(ctx, other, "proj", "/a.md")
+	require.NoError(t, err)
+	require.False(t, stat.Exists)
+}
+
+func TestPluginRejectsBadToken(t *testing.T) {
+	p := newTestPair(t, NewMemoryBackend("ref"), func(cfg *Config) { cfg.Token = "wrong" })
+	err := p.Start(context.Background())
+	require.ErrorContains(t, err, "HTTP 401")
+	require.False(t, files.IsCode(err, files.ErrCodeNotFound))
+}
+
+func TestPluginForwardsAPIKeyOnlyWhenConfigured(t *testing.T) {
+	for _, forward := range []bool{false, true} {
+		var seen Tenant
+		mux := http.NewServeMux()
+		mux.HandleFunc(capabilitiesPath, func(w http.ResponseWriter, _ *http.Request) {
+			_ = json.NewEncoder(w).Encode(CapabilitiesResponse{ProtocolVersion: ProtocolVersion})
+		})
+		mux.HandleFunc(statPath, func(w http.ResponseWriter, r *http.Request) {
+			var req StatRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			seen = req.Tenant
+			_ = json.NewEncoder(w).Encode(StatResponse{})
+		})
+		ts := httptest.NewServer(mux)
+
+		p, err := New(Config{Name: "r", BaseURL: ts.URL, Token: testToken, ForwardAPIKey: forward})
+		require.NoError(t, err)
+		_, err = p.Stat(context.Background(), testAuth, "proj", "/a.md")
+		require.NoError(t, err)
+		require.Equal(t, "tenant-a", seen.APIKeyHash)
+		if forward {
+			require.Equal(t, "sk-raw", seen.APIKey)
+		} else {
+			require.Empty(t, seen.APIKey)
+		}
+		ts.Close()
+	}
+}
+
+func TestPluginRejectsProtocolMismatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(CapabilitiesResponse{ProtocolVersion: ProtocolVersion + 1})
+	}))
+	t.Cleanup(ts.Close)
+
+	p, err := New(Config{Name: "r", BaseURL: ts.URL, Token: testToken})
+	require.NoError(t, err)
+	require.ErrorContains(t, p.Start(context.Background()), "protocol version 2")
+	_, err = p.Stat(context.Background(), testAuth, "proj", "/a.md")
+	require.Error(t, err)
+}
+
+func TestPluginNegotiateDoesNotBlockCapabilities(t *testing.T) {
+	srv, err := NewServer(ServerConfig{Backend: NewMemoryBackend("ref"), Tokens: []string{testToken}})
+	require.NoError(t, err)
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == capabilitiesPath {
+			entered <- struct{}{}
+			<-release
+		}
+		srv.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	p, err := New(Config{Name: "r", BaseURL: ts.URL, Token: testToken})
+	require.NoError(t, err)
+	started := make(chan error, 1)
+	go func() { started <- p.Start(context.Background()) }()
+	<-entered
+
+	// Negotiation is waiting on the remote; Capabilities must still answer.
+	done := make(chan mcpplugin.Capabilities, 1)
+	go func() { done <- p.Capabilities() }()
+	select {
+	case caps := <-done:
+		require.False(t, caps.SupportsRename)
+	case <-time.After(time.Second):
+		t.Fatal("Capabilities blocked behind the negotiation round-trip")
+	}
+
+	close(release)
+	require.NoError(t, <-started)
+	require.True(t, p.Capabilities().SupportsRename)
+}
+
+func TestPluginTimeout(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		ts.Close()
+	})
+
+	p, err := New(Config{Name: "r", BaseURL: ts.URL, Token: testToken, Timeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+	start := time.Now()
+	require.ErrorIs(t, p.Start(context.Background()), context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestPluginEnforcesNegotiatedCapabilities(t *testing.T) {
+	ctx := context.Background()
+	backend := &limitedBackend{MemoryBackend: NewMemoryBackend("limited")}
+	p := newTestPair(t, backend, nil)
+	require.NoError(t, p.Start(ctx))
+	require.EqualValues(t, 8, p.Capabilities().MaxPayloadBytes)
+
+	_, err := p.Write(ctx, testAuth, "proj", "/a.md", "123456789", "utf-8", 0, files.WriteModeTruncate)
+	require.True(t, files.IsCode(err, files.ErrCodePayloadTooLarge), "got %v", err)
+
+	_, err = p.Write(ctx, testAuth, "proj", "/a.md", "1234", "utf-8", 0, files.WriteModeTruncate)
+	require.NoError(t, err)
+	_, err = p.Write(ctx, testAuth, "proj", "/a.md", "x", "utf-8", 2, files.WriteModeOverwrite)
+	require.True(t, files.IsCode(err, files.ErrCodeInvalidOffset), "got %v", err)
+
+	_, err = p.Rename(ctx, testAuth, "proj", "/a.md", "/b.md", false)
+	require.True(t, files.IsCode(err, files.ErrCodeInvalidArgument), "got %v", err)
+	require.Zero(t, backend.renames, "rename must fail before reaching the service")
+}
+
+func TestPluginResponseLimit(t *testing.T) {
+	ctx := context.Background()
+	p := newTestPair(t, NewMemoryBackend("ref"), func(cfg *Config) { cfg.MaxResponseBytes = 512 })
+	_, err := p.Write(ctx, testAuth, "proj", "/big.md", string(make([]byte, 1024)), "utf-8", 0, files.WriteModeTruncate)
+	require.NoError(t, err)
+	_, err = p.Read(ctx, testAuth, "proj", "/big.md", 0, -1)
+	require.ErrorContains(t, err, "exceeds 512 bytes")
+}
+
+func TestServerRejectsMissingProtocolHeader(t *testing.T) {
+	srv, err := NewServer(ServerConfig{Backend: NewMemoryBackend("ref"), Tokens: []string{testToken}})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, capabilitiesPath, nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req.Header.Set(ProtocolHeader, "1")
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRemoteConformance(t *testing.T) {
+	p := newTestPair(t, NewMemoryBackend("ref"), nil)
+	require.NoError(t, p.Start(context.Background()))
+	fx, err := conformance.NewPluginFixture(conformance.PluginFixtureConfig{
+		Primary:       p,
+		Auth:          testAuth,
+		OtherAuth:     files.AuthContext{APIKey: "sk-other", APIKeyHash: "tenant-b", UserIdentity: "user:b"},
+		ProjectPrefix: "conformance",
+	})
+	require.NoError(t, err)
+	conformance.Run(t, fx, conformance.Options{SkipCrossPlugin: true})
+}
+
+// limitedBackend advertises a small payload cap and no rename or random IO.
+type limitedBackend struct {
+	*MemoryBackend
+	renames int
+}
+
+func (b *limitedBackend) Capabilities() mcpplugin.Capabilities {
+	caps := b.MemoryBackend.Capabilities()
+	caps.SupportsRename = false
+	caps.SupportsRandomIO = false
+	caps.MaxPayloadBytes = 8
+	return caps
+}
+
+func (b *limitedBackend) Rename(ctx context.Context, auth files.AuthContext, project, fromPath, toPath string, overwrite bool) (files.RenameResult, error) {
+	b.renames++
+	return b.MemoryBackend.Rename(ctx, auth, project, fromPath, toPath, overwrite)
+}
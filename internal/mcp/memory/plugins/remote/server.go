@@ -0,0 +1,215 @@
+package remote
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	errors "github.com/Laisky/errors/v2"
+	logSDK "github.com/Laisky/go-utils/v6/log"
+	"github.com/Laisky/zap"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+	"github.com/Laisky/laisky-blog-graphql/library/log"
+)
+
+// defaultMaxRequestBytes caps a request body when ServerConfig.MaxRequestBytes is zero.
+const defaultMaxRequestBytes = 64 << 20
+
+// ServerConfig configures NewServer.
+type ServerConfig struct {
+	// Backend answers every call.
+	Backend mcpplugin.Plugin
+	// Tokens lists the accepted bearer tokens.
+	Tokens []string
+	// MaxRequestBytes caps a request body.
+	MaxRequestBytes int64
+	Logger          logSDK.Logger
+}
+
+// Server is the reference implementation of the protocol. It exposes any
+// in-process plugin, so it doubles as a test double for Plugin and as a
+// starting point for external backends.
+type Server struct {
+	backend         mcpplugin.Plugin
+	tokens          [][]byte
+	maxRequestBytes int64
+	logger          logSDK.Logger
+}
+
+// NewServer validates cfg and returns the protocol handler.
+func NewServer(cfg ServerConfig) (*Server, error) {
+	if cfg.Backend == nil {
+		return nil, errors.New("remote server backend is required")
+	}
+	s := &Server{backend: cfg.Backend, maxRequestBytes: cfg.MaxRequestBytes, logger: cfg.Logger}
+	for _, token := range cfg.Tokens {
+		if token = strings.TrimSpace(token); token != "" {
+			s.tokens = append(s.tokens, []byte(token))
+		}
+	}
+	if len(s.tokens) == 0 {
+		return nil, errors.New("remote server needs at least one token")
+	}
+	if s.maxRequestBytes <= 0 {
+		s.maxRequestBytes = defaultMaxRequestBytes
+	}
+	if s.logger == nil {
+		s.logger = log.Logger.Named("mcp_memory_remote_server")
+	}
+	return s, nil
+}
+
+// ServeHTTP authenticates the request and dispatches it to the backend.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r.Header.Get("Authorization")) {
+		s.writeError(w, http.StatusUnauthorized, ErrorBody{Message: "invalid bearer token"})
+		return
+	}
+	if version := r.Header.Get(ProtocolHeader); version != strconv.Itoa(ProtocolVersion) {
+		s.writeError(w, http.StatusBadRequest, ErrorBody{Message: "unsupported protocol version " + strconv.Quote(version)})
+		return
+	}
+
+	if r.URL.Path == capabilitiesPath {
+		if r.Method != http.MethodGet {
+			s.writeError(w, http.StatusMethodNotAllowed, ErrorBody{Message: "use GET"})
+			return
+		}
+		s.writeJSON(w, CapabilitiesResponse{
+			ProtocolVersion: ProtocolVersion,
+			Name:            s.backend.Name(),
+			Capabilities:    capabilitiesToWire(s.backend.Capabilities()),
+		})
+		return
+	}
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, ErrorBody{Message: "use POST"})
+		return
+	}
+
+	ctx := r.Context()
+	var (
+		out any
+		err error
+	)
+	switch r.URL.Path {
+	case statPath:
+		out, err = serve(s, w, r, func(req StatRequest) (any, error) {
+			res, err := s.backend.Stat(ctx, authFor(req.Tenant), req.Project, req.Path)
+			return StatResponse{Exists: res.Exists, Type: res.Type, Size: res.Size, CreatedAt: res.CreatedAt, UpdatedAt: res.UpdatedAt}, err
+		})
+	case readPath:
+		out, err = serve(s, w, r, func(req ReadRequest) (any, error) {
+			res, err := s.backend.Read(ctx, authFor(req.Tenant), req.Project, req.Path, req.Offset, req.Length)
+			return ReadResponse{Content: res.Content, ContentEncoding: res.ContentEncoding}, err
+		})
+	case writePath:
+		out, err = serve(s, w, r, func(req WriteRequest) (any, error) {
+			res, err := s.backend.Write(ctx, authFor(req.Tenant), req.Project, req.Path, req.Content, req.ContentEncoding, req.Offset, req.Mode)
+			return WriteResponse{BytesWritten: res.BytesWritten}, err
+		})
+	case deletePath:
+		out, err = serve(s, w, r, func(req DeleteRequest) (any, error) {
+			res, err := s.backend.Delete(ctx, authFor(req.Tenant), req.Project, req.Path, req.Recursive)
+			return DeleteResponse{DeletedCount: res.DeletedCount}, err
+		})
+	case renamePath:
+		out, err = serve(s, w, r, func(req RenameRequest) (any, error) {
+			res, err := s.backend.Rename(ctx, authFor(req.Tenant), req.Project, req.FromPath, req.ToPath, req.Overwrite)
+			return RenameResponse{MovedCount: res.MovedCount}, err
+		})
+	case listPath:
+		out, err = serve(s, w, r, func(req ListRequest) (any, error) {
+			res, err := s.backend.List(ctx, authFor(req.Tenant), req.Project, req.Path, req.Depth, req.Limit)
+			return ListResponse{Entries: nonNil(res.Entries), HasMore: res.HasMore}, err
+		})
+	case searchPath:
+		out, err = serve(s, w, r, func(req SearchRequest) (any, error) {
+			res, err := s.backend.Search(ctx, authFor(req.Tenant), req.Project, req.Query, req.PathPrefix, req.Limit)
+			return SearchResponse{Chunks: nonNil(res.Chunks)}, err
+		})
+	default:
+		s.writeError(w, http.StatusNotFound, ErrorBody{Message: "unknown endpoint"})
+		return
+	}
+
+	switch {
+	case errors.Is(err, errRequestHandled):
+	case err != nil:
+		s.writeBackendError(ctx, w, r.URL.Path, err)
+	default:
+		s.writeJSON(w, out)
+	}
+}
+
+// errRequestHandled reports that serve already wrote the response.
+var errRequestHandled = errors.New("request handled")
+
+// serve decodes the request body into Req and runs fn.
+func serve[Req any](s *Server, w http.ResponseWriter, r *http.Request, fn func(Req) (any, error)) (any, error) {
+	var req Req
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxRequestBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, ErrorBody{Code: string(files.ErrCodeInvalidArgument), Message: "invalid request body"})
+		return nil, errRequestHandled
+	}
+	return fn(req)
+}
+
+// authorized compares the bearer token against every accepted token in
+// constant time.
+func (s *Server) authorized(header string) bool {
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return false
+	}
+	matched := 0
+	for _, want := range s.tokens {
+		matched |= subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), want)
+	}
+	return matched == 1
+}
+
+// writeBackendError answers with the typed error, or a generic 500 whose
+// detail stays in the server log.
+func (s *Server) writeBackendError(ctx context.Context, w http.ResponseWriter, path string, err error) {
+	if typed, ok := files.AsError(err); ok {
+		s.writeError(w, statusForCode(typed.Code), ErrorBody{Code: string(typed.Code), Message: typed.Message, Retryable: typed.Retryable})
+		return
+	}
+	if ctx.Err() != nil {
+		s.writeError(w, http.StatusServiceUnavailable, ErrorBody{Message: "request cancelled", Retryable: true})
+		return
+	}
+	s.logger.Error("remote backend call failed", zap.String("path", path), zap.Error(err))
+	s.writeError(w, http.StatusInternalServerError, ErrorBody{Message: "internal server error", Retryable: true})
+}
+
+func (s *Server) writeError(w http.ResponseWriter, status int, body ErrorBody) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(ErrorResponse{Error: body}); err != nil {
+		s.logger.Debug("write remote error response", zap.Error(err))
+	}
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		s.logger.Debug("write remote response", zap.Error(err))
+	}
+}
+
+// nonNil turns a nil slice into an empty one so it encodes as [].
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
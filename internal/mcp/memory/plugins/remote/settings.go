@@ -0,0 +1,80 @@
+package remote
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	gconfig "github.com/Laisky/go-config/v2"
+	logSDK "github.com/Laisky/go-utils/v6/log"
+)
+
+// settingsKey holds the list of remote plugins.
+const settingsKey = "settings.mcp.tools.memory.plugins.remote"
+
+// Settings is one entry of settings.mcp.tools.memory.plugins.remote.
+type Settings struct {
+	Name    string `json:"name"`
+	BaseURL string `json:"base_url"`
+	Token   string `json:"token"`
+	// Timeout is a Go duration such as "10s".
+	Timeout          string `json:"timeout"`
+	MaxResponseBytes int64  `json:"max_response_bytes"`
+	ForwardAPIKey    bool   `json:"forward_api_key"`
+}
+
+// LoadSettings reads the configured remote plugins. A missing key yields none.
+func LoadSettings() ([]Settings, error) {
+	raw := gconfig.S.Get(settingsKey)
+	if raw == nil {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(raw)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal %s", settingsKey)
+	}
+	var settings []Settings
+	if err = json.Unmarshal(body, &settings); err != nil {
+		return nil, errors.Wrapf(err, "parse %s", settingsKey)
+	}
+	return settings, nil
+}
+
+// Config converts s to a plugin Config.
+func (s Settings) Config(logger logSDK.Logger) (Config, error) {
+	cfg := Config{
+		Name:             s.Name,
+		BaseURL:          s.BaseURL,
+		Token:            s.Token,
+		MaxResponseBytes: s.MaxResponseBytes,
+		ForwardAPIKey:    s.ForwardAPIKey,
+		Logger:           logger,
+	}
+	if timeout := strings.TrimSpace(s.Timeout); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			return Config{}, errors.Errorf("remote plugin %q timeout %q must be a positive duration", s.Name, s.Timeout)
+		}
+		cfg.Timeout = d
+	}
+	return cfg, nil
+}
+
+// NewFromSettings builds one plugin per configured entry.
+func NewFromSettings(settings []Settings, logger logSDK.Logger) ([]*Plugin, error) {
+	plugins := make([]*Plugin, 0, len(settings))
+	for _, s := range settings {
+		cfg, err := s.Config(logger)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		p, err := New(cfg)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
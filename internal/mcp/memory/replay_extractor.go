@@ -0,0 +1,215 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	errors "github.com/Laisky/errors/v2"
+	sdkmemory "github.com/Laisky/go-utils/v6/agents/memory"
+	logSDK "github.com/Laisky/go-utils/v6/log"
+	glog "github.com/Laisky/zap"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/library/jsonl"
+)
+
+// replayFactMaxLineBytes bounds one fixture line when loading.
+const replayFactMaxLineBytes = 16 << 20
+
+// FactFixture is one recorded turn → extraction pair. Fixture files are JSON
+// Lines; later lines override earlier ones.
+type FactFixture struct {
+	// Hash identifies the turn by its input items and the IDs and values of
+	// existing facts, so timestamps and turn IDs do not affect matching.
+	Hash   string                        `json:"hash"`
+	Result sdkmemory.HeuristicFactResult `json:"result"`
+}
+
+// ReplayFactExtractorConfig configures NewReplayFactExtractor.
+type ReplayFactExtractorConfig struct {
+	// Path is the JSON Lines fixture file; it may be empty for scripted use through Add.
+	Path string
+	// Record appends every miss answered by an upstream extractor to Path.
+	Record bool
+	// Upstream answers fixture misses. Record mode may instead bind one per
+	// caller with WithUpstream. Without an upstream a miss is an error.
+	Upstream FactExtractor
+	// Logger reports fixture misses; nil keeps misses silent apart from Misses.
+	Logger logSDK.Logger
+}
+
+// ReplayFactExtractor is a deterministic FactExtractor that answers from
+// recorded fixtures, so memory heuristics can be tested and demoed offline.
+type ReplayFactExtractor struct {
+	mu       sync.Mutex
+	path     string
+	record   bool
+	upstream FactExtractor
+	logger   logSDK.Logger
+	fixtures map[string]sdkmemory.HeuristicFactResult
+	misses   int
+}
+
+// NewReplayFactExtractor loads the fixture file at cfg.Path, if it exists.
+func NewReplayFactExtractor(cfg ReplayFactExtractorConfig) (*ReplayFactExtractor, error) {
+	if cfg.Record && cfg.Path == "" {
+		return nil, errors.WithStack(NewError(ErrCodeInvalidArgument, "record mode requires a fixture path", false))
+	}
+	extractor := &ReplayFactExtractor{
+		path:     cfg.Path,
+		record:   cfg.Record,
+		upstream: cfg.Upstream,
+		logger:   cfg.Logger,
+		fixtures: map[string]sdkmemory.HeuristicFactResult{},
+	}
+	if cfg.Path == "" {
+		return extractor, nil
+	}
+
+	err := jsonl.ReadFile(cfg.Path, replayFactMaxLineBytes, func(lineNo int, line []byte) error {
+		var fixture FactFixture
+		if err := json.Unmarshal(line, &fixture); err != nil {
+			return errors.Wrapf(err, "parse fact fixture %s:%d", cfg.Path, lineNo)
+		}
+		extractor.fixtures[fixture.Hash] = fixture.Result
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "load fact fixtures")
+	}
+	return extractor, nil
+}
+
+// ExtractAndMergeFacts returns the recorded result for the turn, or asks the
+// configured upstream extractor on a miss. Replayed facts take their IDs and
+// timestamps from the current turn.
+func (extractor *ReplayFactExtractor) ExtractAndMergeFacts(ctx context.Context, in sdkmemory.HeuristicFactInput) (sdkmemory.HeuristicFactResult, error) {
+	return extractor.extract(ctx, in, extractor.upstream)
+}
+
+// WithUpstream returns a FactExtractor that shares this extractor's fixtures
+// but answers misses with upstream, so record mode can bill each caller's own key.
+func (extractor *ReplayFactExtractor) WithUpstream(upstream FactExtractor) FactExtractor {
+	return replayFactSession{extractor: extractor, upstream: upstream}
+}
+
+// Recording reports whether misses are appended to the fixture file.
+func (extractor *ReplayFactExtractor) Recording() bool {
+	return extractor.record
+}
+
+func (extractor *ReplayFactExtractor) extract(ctx context.Context, in sdkmemory.HeuristicFactInput, upstream FactExtractor) (sdkmemory.HeuristicFactResult, error) {
+	key := factFixtureKey(in)
+	extractor.mu.Lock()
+	recorded, ok := extractor.fixtures[key]
+	if !ok {
+		extractor.misses++
+	}
+	extractor.mu.Unlock()
+	if ok {
+		return stampReplayedFacts(recorded, in), nil
+	}
+
+	if extractor.logger != nil {
+		extractor.logger.Warn("heuristic replay fixture miss",
+			glog.String("hash", key),
+			glog.String("turn_id", in.TurnID),
+			glog.Bool("upstream", upstream != nil),
+			glog.Bool("record", extractor.record))
+	}
+	if upstream == nil {
+		return sdkmemory.HeuristicFactResult{}, errors.Errorf("replay: no heuristic fixture for turn hash %s", key)
+	}
+
+	result, err := upstream.ExtractAndMergeFacts(ctx, in)
+	if err != nil {
+		return sdkmemory.HeuristicFactResult{}, errors.WithStack(err)
+	}
+	if !extractor.record {
+		return result, nil
+	}
+
+	extractor.mu.Lock()
+	defer extractor.mu.Unlock()
+	if err := jsonl.Append(extractor.path, FactFixture{Hash: key, Result: result}); err != nil {
+		return sdkmemory.HeuristicFactResult{}, errors.Wrap(err, "append fact fixture")
+	}
+	extractor.fixtures[key] = result
+	return result, nil
+}
+
+// Add registers a scripted result for the turn in memory only.
+func (extractor *ReplayFactExtractor) Add(in sdkmemory.HeuristicFactInput, result sdkmemory.HeuristicFactResult) {
+	extractor.mu.Lock()
+	defer extractor.mu.Unlock()
+	extractor.fixtures[factFixtureKey(in)] = result
+}
+
+// Len returns the number of loaded or recorded fixtures.
+func (extractor *ReplayFactExtractor) Len() int {
+	extractor.mu.Lock()
+	defer extractor.mu.Unlock()
+	return len(extractor.fixtures)
+}
+
+// Misses returns how many turns had no fixture.
+func (extractor *ReplayFactExtractor) Misses() int {
+	extractor.mu.Lock()
+	defer extractor.mu.Unlock()
+	return extractor.misses
+}
+
+// replayFactSession binds a per-caller upstream to a shared ReplayFactExtractor.
+type replayFactSession struct {
+	extractor *ReplayFactExtractor
+	upstream  FactExtractor
+}
+
+// ExtractAndMergeFacts replays or records through the shared extractor.
+func (session replayFactSession) ExtractAndMergeFacts(ctx context.Context, in sdkmemory.HeuristicFactInput) (sdkmemory.HeuristicFactResult, error) {
+	return session.extractor.extract(ctx, in, session.upstream)
+}
+
+// factFixtureKey hashes the parts of a turn that determine extraction.
+func factFixtureKey(in sdkmemory.HeuristicFactInput) string {
+	type existingFact struct {
+		FactID string `json:"fact_id"`
+		Value  string `json:"value"`
+	}
+	existing := make([]existingFact, 0, len(in.ExistingFacts))
+	for _, fact := range in.ExistingFacts {
+		existing = append(existing, existingFact{FactID: fact.FactID, Value: fact.Value})
+	}
+	sort.Slice(existing, func(i, j int) bool { return existing[i].FactID < existing[j].FactID })
+
+	body, _ := json.Marshal(struct {
+		InputItems []sdkmemory.ResponseItem `json:"input_items"`
+		Existing   []existingFact           `json:"existing"`
+	}{in.InputItems, existing})
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// stampReplayedFacts rebinds recorded facts to the current turn so a fixture
+// recorded under one turn ID can be replayed under another.
+func stampReplayedFacts(recorded sdkmemory.HeuristicFactResult, in sdkmemory.HeuristicFactInput) sdkmemory.HeuristicFactResult {
+	out := sdkmemory.HeuristicFactResult{
+		UpdatedFacts:   make([]sdkmemory.MemoryFact, len(recorded.UpdatedFacts)),
+		DeletedFactIDs: append([]string(nil), recorded.DeletedFactIDs...),
+	}
+	for i, fact := range recorded.UpdatedFacts {
+		fact.ID = in.TurnID + "-replay-" + fact.FactID
+		fact.TS = in.NowRFC3339
+		if fact.SourceTurnID != "" {
+			fact.SourceTurnID = in.TurnID
+		}
+		if fact.SourceUserID != "" {
+			fact.SourceUserID = in.UserID
+		}
+		out.UpdatedFacts[i] = fact
+	}
+	return out
+}
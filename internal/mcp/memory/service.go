@@ -11,6 +11,7 @@ import (
 
 	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
 	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/rag"
 	"github.com/Laisky/laisky-blog-graphql/library/log"
 )
 
@@ -22,6 +23,9 @@ type Service struct {
 	settings    Settings
 	logger      logSDK.Logger
 	clock       func() time.Time
+	embedder    rag.Embedder
+	// factExtractor overrides the extractor selected by settings when set.
+	factExtractor FactExtractor
 }
 
 // sqlExecutor abstracts SQL execution over either *sql.DB or *sql.Tx.
@@ -38,8 +42,25 @@ func chooseExecutor(tx *sql.Tx, db *sql.DB) sqlExecutor {
 	return db
 }
 
+// ServiceOption customizes optional Service dependencies.
+type ServiceOption func(*Service)
+
+// WithEmbedder sets the embedder used by embedding-mode fact deduplication.
+func WithEmbedder(embedder rag.Embedder) ServiceOption {
+	return func(service *Service) {
+		service.embedder = embedder
+	}
+}
+
+// WithFactExtractor sets a custom fact extractor, taking precedence over HeuristicSettings.
+func WithFactExtractor(extractor FactExtractor) ServiceOption {
+	return func(service *Service) {
+		service.factExtractor = extractor
+	}
+}
+
 // NewService creates a memory lifecycle service.
-func NewService(db *sql.DB, fileService mcpplugin.Plugin, settings Settings, logger logSDK.Logger, clock func() time.Time) (*Service, error) {
+func NewService(db *sql.DB, fileService mcpplugin.Plugin, settings Settings, logger logSDK.Logger, clock func() time.Time, opts ...ServiceOption) (*Service, error) {
 	if db == nil {
 		return nil, errors.WithStack(NewError(ErrCodeInternal, "sql db is required", false))
 	}
@@ -59,14 +80,38 @@ func NewService(db *sql.DB, fileService mcpplugin.Plugin, settings Settings, log
 		return nil, errors.WithStack(err)
 	}
 
-	return &Service{
+	service := &Service{
 		db:          db,
 		isPostgres:  isPostgresDB(db),
 		fileService: fileService,
 		settings:    settings,
 		logger:      logger,
 		clock:       clock,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(service)
+	}
+	if service.factExtractor == nil && settings.Heuristic.Enabled && settings.Heuristic.Provider == HeuristicProviderReplay {
+		extractor, err := NewReplayFactExtractor(ReplayFactExtractorConfig{
+			Path:   settings.Heuristic.ReplayPath,
+			Record: settings.Heuristic.ReplayRecord,
+			Logger: logger,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "load heuristic replay fixtures")
+		}
+		service.factExtractor = extractor
+	}
+
+	return service, nil
+}
+
+// AvailablePlugins lists the plugin names the memory file backend can route to.
+func (s *Service) AvailablePlugins() []string {
+	if lister, ok := s.fileService.(interface{ AvailablePlugins() []string }); ok {
+		return lister.AvailablePlugins()
+	}
+	return []string{mcpplugin.NormalizeName(s.fileService.Name())}
 }
 
 // BeforeTurn prepares model input by recalling memory facts and recent context.
@@ -94,12 +139,15 @@ func (service *Service) BeforeTurn(ctx context.Context, auth files.AuthContext,
 		recallInsightIDs = []string{}
 	}
 
-	return BeforeTurnResponse{
+	response := BeforeTurnResponse{
 		InputItems:        output.InputItems,
 		RecallFactIDs:     recallFactIDs,
 		RecallInsightIDs:  recallInsightIDs,
 		ContextTokenCount: output.ContextTokenCount,
-	}, nil
+	}
+	service.dedupRecall(ctx, auth, request, &response)
+
+	return response, nil
 }
 
 // AfterTurn persists the turn output with idempotency guard and session serialization.
@@ -139,7 +187,8 @@ func (service *Service) AfterTurn(ctx context.Context, auth files.AuthContext, r
 	return nil
 }
 
-// RunMaintenance runs compaction and retention cleanup for one session.
+// RunMaintenance runs compaction and retention cleanup for one session,
+// optionally merging near-duplicate facts afterwards.
 func (service *Service) RunMaintenance(ctx context.Context, auth files.AuthContext, request SessionRequest) error {
 	if err := validateSessionRequest(auth, request); err != nil {
 		return errors.WithStack(err)
@@ -156,6 +205,12 @@ func (service *Service) RunMaintenance(ctx context.Context, auth files.AuthConte
 			return errors.Wrap(runErr, "run maintenance")
 		}
 
+		if service.settings.Dedup.Enabled && service.settings.Dedup.MergeOnMaintenance {
+			if mergeErr := service.mergeDuplicateFacts(ctx, auth, request); mergeErr != nil {
+				return errors.Wrap(mergeErr, "merge duplicate facts")
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
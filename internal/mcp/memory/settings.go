@@ -21,6 +21,7 @@ const (
 	defaultHeuristicTimeoutMS       = 12000
 	defaultHeuristicMaxOutputTokens = 800
 	defaultSessionLockTimeoutMS     = 5000
+	defaultDedupThreshold           = 0.8
 )
 
 // Settings controls MCP-native memory behavior.
@@ -36,17 +37,38 @@ type Settings struct {
 	MaxProcessedTurns      int
 	SessionLockTimeout     time.Duration
 	Heuristic              HeuristicSettings
+	Dedup                  DedupSettings
+	// OperatorAPIKeyHashes lists the SHA-256 API key hashes allowed to read
+	// process-wide admin endpoints such as the pageindex cache stats.
+	OperatorAPIKeyHashes []string
 }
 
-// HeuristicSettings controls optional model-assisted fact extraction.
+// HeuristicSettings controls optional heuristic fact extraction.
 type HeuristicSettings struct {
-	Enabled         bool
+	Enabled bool
+	// Provider is HeuristicProviderLLM, HeuristicProviderRules or HeuristicProviderReplay.
+	Provider string
+	// ReplayPath is the fixture file read by HeuristicProviderReplay.
+	ReplayPath string
+	// ReplayRecord answers replay misses with the remote model and appends them to ReplayPath.
+	ReplayRecord    bool
 	Model           string
 	BaseURL         string
 	Timeout         time.Duration
 	MaxOutputTokens int
 }
 
+// DedupSettings controls near-duplicate suppression of recalled facts.
+type DedupSettings struct {
+	Enabled bool
+	// Mode is DedupModeLexical or DedupModeEmbedding.
+	Mode string
+	// Threshold is the minimum similarity in (0, 1] for two facts to be treated as duplicates.
+	Threshold float64
+	// MergeOnMaintenance supersedes duplicates in storage during RunMaintenance.
+	MergeOnMaintenance bool
+}
+
 // LoadSettingsFromConfig loads memory settings with safe defaults.
 func LoadSettingsFromConfig() Settings {
 	timeoutMS := intFromConfig("settings.mcp.tools.memory.heuristic.timeout_ms", defaultHeuristicTimeoutMS)
@@ -54,6 +76,20 @@ func LoadSettingsFromConfig() Settings {
 		timeoutMS = defaultHeuristicTimeoutMS
 	}
 
+	heuristicProvider := strings.ToLower(stringFromConfig("settings.mcp.tools.memory.heuristic.provider", HeuristicProviderLLM))
+	if heuristicProvider != HeuristicProviderRules && heuristicProvider != HeuristicProviderReplay {
+		heuristicProvider = HeuristicProviderLLM
+	}
+
+	dedupMode := strings.ToLower(stringFromConfig("settings.mcp.tools.memory.dedup.mode", DedupModeLexical))
+	if dedupMode != DedupModeEmbedding {
+		dedupMode = DedupModeLexical
+	}
+	dedupThreshold := floatFromConfig("settings.mcp.tools.memory.dedup.threshold", defaultDedupThreshold)
+	if dedupThreshold <= 0 || dedupThreshold > 1 {
+		dedupThreshold = defaultDedupThreshold
+	}
+
 	return Settings{
 		RecentContextItems:     intFromConfig("settings.mcp.tools.memory.recent_context_items", defaultRecentContextItems),
 		RecallFactsLimit:       intFromConfig("settings.mcp.tools.memory.recall_facts_limit", defaultRecallFactsLimit),
@@ -67,11 +103,21 @@ func LoadSettingsFromConfig() Settings {
 		SessionLockTimeout:     time.Duration(intFromConfig("settings.mcp.tools.memory.session_lock_timeout_ms", defaultSessionLockTimeoutMS)) * time.Millisecond,
 		Heuristic: HeuristicSettings{
 			Enabled:         boolFromConfig("settings.mcp.tools.memory.heuristic.enabled", false),
+			Provider:        heuristicProvider,
+			ReplayPath:      stringFromConfig("settings.mcp.tools.memory.heuristic.replay_path", ""),
+			ReplayRecord:    strings.EqualFold(stringFromConfig("settings.mcp.tools.memory.heuristic.replay_mode", "replay"), "record"),
 			Model:           stringFromConfig("settings.mcp.tools.memory.heuristic.model", defaultHeuristicModel),
 			BaseURL:         stringFromConfig("settings.mcp.tools.memory.heuristic.base_url", "https://oneapi.laisky.com"),
 			Timeout:         time.Duration(timeoutMS) * time.Millisecond,
 			MaxOutputTokens: intFromConfig("settings.mcp.tools.memory.heuristic.max_output_tokens", defaultHeuristicMaxOutputTokens),
 		},
+		Dedup: DedupSettings{
+			Enabled:            boolFromConfig("settings.mcp.tools.memory.dedup.enabled", false),
+			Mode:               dedupMode,
+			Threshold:          dedupThreshold,
+			MergeOnMaintenance: boolFromConfig("settings.mcp.tools.memory.dedup.merge_on_maintenance", false),
+		},
+		OperatorAPIKeyHashes: gconfig.S.GetStringSlice("settings.mcp.tools.memory.operator_api_key_hashes"),
 	}
 }
 
@@ -3,6 +3,7 @@ package rag
 import (
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // ChunkFragment represents a cleaned portion of the input materials.
@@ -11,6 +12,11 @@ type ChunkFragment struct {
 	Text    string
 	Cleaned string
 	Tokens  []string
+	// Start and End are character (Unicode code point) offsets of the span of
+	// the original materials the fragment was taken from. End is exclusive;
+	// End <= Start means the chunker did not record the span.
+	Start int
+	End   int
 }
 
 // Chunker splits materials into bounded fragments.
@@ -23,24 +29,34 @@ type ParagraphChunker struct{}
 
 // Split divides the materials into roughly paragraph-sized fragments while enforcing the maxChars bound.
 func (ParagraphChunker) Split(materials string, maxChars int) []ChunkFragment {
-	normalized := normalizeWhitespace(materials)
-	blocks := strings.Split(normalized, "\n\n")
-	fragments := make([]ChunkFragment, 0, len(blocks))
+	normalized, origin := normalizeWhitespaceWithOrigin(materials)
+	offsets := newRuneOffsetCounter(materials)
+	fragments := make([]ChunkFragment, 0, 8)
 	idx := 0
-	for _, block := range blocks {
-		block = strings.TrimSpace(block)
+	blockStart := 0
+	for _, block := range strings.Split(normalized, "\n\n") {
+		rawLen := len(block)
+		trimmed := strings.TrimLeftFunc(block, unicode.IsSpace)
+		offset := blockStart + rawLen - len(trimmed)
+		blockStart += rawLen + len("\n\n")
+		block = strings.TrimRightFunc(trimmed, unicode.IsSpace)
 		if block == "" {
 			continue
 		}
-		segments := splitBlock(block, maxChars)
-		for _, segment := range segments {
-			cleaned := normalizeWhitespace(segment)
+		for _, segment := range splitBlock(block, maxChars) {
+			cleaned := normalizeWhitespace(segment.text)
 			tokens := Tokenize(cleaned)
+			// Non-space bytes map one to one, so the span ends right after
+			// the original byte of the segment's last normalized byte.
+			start := offsets.at(origin[offset+segment.start])
+			end := offsets.at(origin[offset+segment.end-1] + 1)
 			fragments = append(fragments, ChunkFragment{
 				Index:   idx,
-				Text:    segment,
+				Text:    segment.text,
 				Cleaned: cleaned,
 				Tokens:  tokens,
+				Start:   start,
+				End:     end,
 			})
 			idx++
 		}
@@ -48,61 +64,153 @@ func (ParagraphChunker) Split(materials string, maxChars int) []ChunkFragment {
 	return fragments
 }
 
-func splitBlock(block string, maxChars int) []string {
+// blockSegment is a piece of a block with its byte span [start, end) in the block.
+type blockSegment struct {
+	text  string
+	start int
+	end   int
+}
+
+// blockSentence is a sentence of a block with its byte span in the block,
+// including the terminating period consumed by the ". " separator.
+type blockSentence struct {
+	text  string
+	start int
+	end   int
+}
+
+func splitBlock(block string, maxChars int) []blockSegment {
 	if maxChars <= 0 || len(block) <= maxChars {
-		return []string{block}
+		return []blockSegment{{text: block, start: 0, end: len(block)}}
 	}
 
-	sentences := strings.Split(block, ". ")
-	segments := make([]string, 0)
+	segments := make([]blockSegment, 0)
 	var current strings.Builder
-	for _, sentence := range sentences {
-		sentence = strings.TrimSpace(sentence)
-		if sentence == "" {
-			continue
+	currentStart, currentEnd := 0, 0
+	flush := func() {
+		if current.Len() > 0 {
+			// A remainder left by a hard split may start with the space it was cut at.
+			raw := current.String()
+			text := strings.TrimSpace(raw)
+			start := currentStart + strings.Index(raw, text)
+			segments = append(segments, blockSegment{text: text, start: start, end: currentEnd})
+			current.Reset()
 		}
-		if current.Len()+len(sentence)+1 > maxChars {
-			if current.Len() > 0 {
-				segments = append(segments, strings.TrimSpace(current.String()))
-				current.Reset()
-			}
-			if len(sentence) > maxChars {
-				for len(sentence) > maxChars {
-					segments = append(segments, sentence[:maxChars])
-					sentence = sentence[maxChars:]
+	}
+	for _, sentence := range splitSentences(block) {
+		text := sentence.text
+		if current.Len()+len(text)+1 > maxChars {
+			flush()
+			start := sentence.start
+			if len(text) > maxChars {
+				for len(text) > maxChars {
+					segments = append(segments, blockSegment{text: text[:maxChars], start: start, end: start + maxChars})
+					text = text[maxChars:]
+					start += maxChars
 				}
 			}
-			current.WriteString(sentence)
+			current.WriteString(text)
+			currentStart, currentEnd = start, sentence.end
 		} else {
 			if current.Len() > 0 {
 				current.WriteString(" ")
+			} else {
+				currentStart = sentence.start
 			}
-			current.WriteString(sentence)
+			current.WriteString(text)
+			currentEnd = sentence.end
 		}
 	}
-	if current.Len() > 0 {
-		segments = append(segments, strings.TrimSpace(current.String()))
-	}
+	flush()
 	if len(segments) == 0 {
-		return []string{block[:maxChars]}
+		return []blockSegment{{text: block[:maxChars], start: 0, end: maxChars}}
 	}
 	return segments
 }
 
+// splitSentences splits block on ". " and returns the trimmed, non-empty sentences with their spans.
+func splitSentences(block string) []blockSentence {
+	var sentences []blockSentence
+	pos := 0
+	for pos <= len(block) {
+		sep := strings.Index(block[pos:], ". ")
+		end, next := len(block), len(block)+1
+		if sep >= 0 {
+			end, next = pos+sep, pos+sep+len(". ")
+		}
+		raw := block[pos:end]
+		text := strings.TrimSpace(raw)
+		if text != "" {
+			start := pos + strings.Index(raw, text)
+			spanEnd := start + len(text)
+			if sep >= 0 {
+				// Keep the period swallowed by the separator inside the span.
+				spanEnd = end + 1
+			}
+			sentences = append(sentences, blockSentence{text: text, start: start, end: spanEnd})
+		}
+		pos = next
+	}
+	return sentences
+}
+
 func normalizeWhitespace(input string) string {
+	normalized, _ := normalizeWhitespaceWithOrigin(input)
+	return normalized
+}
+
+// normalizeWhitespaceWithOrigin collapses whitespace runs into single spaces and trims the result.
+// origin[i] is the byte offset in input of byte i of the normalized string.
+func normalizeWhitespaceWithOrigin(input string) (string, []int) {
 	var b strings.Builder
 	b.Grow(len(input))
-	lastSpace := false
-	for _, r := range input {
+	origin := make([]int, 0, len(input))
+	lastSpace := true
+	for pos, r := range input {
 		if unicode.IsSpace(r) {
 			if !lastSpace {
 				b.WriteRune(' ')
+				origin = append(origin, pos)
 				lastSpace = true
 			}
 			continue
 		}
 		lastSpace = false
+		size := b.Len()
 		b.WriteRune(r)
+		for i := 0; i < b.Len()-size; i++ {
+			origin = append(origin, pos+i)
+		}
+	}
+	normalized := b.String()
+	if strings.HasSuffix(normalized, " ") {
+		normalized = normalized[:len(normalized)-1]
+		origin = origin[:len(origin)-1]
+	}
+	return normalized, origin
+}
+
+// runeOffsetCounter converts ascending byte offsets of a string into rune offsets in one pass.
+type runeOffsetCounter struct {
+	input string
+	bytes int
+	runes int
+}
+
+func newRuneOffsetCounter(input string) *runeOffsetCounter {
+	return &runeOffsetCounter{input: input}
+}
+
+// at returns the rune offset of byte offset pos, rounding up to the next rune
+// boundary when pos falls inside a rune. Offsets must not decrease between calls.
+func (c *runeOffsetCounter) at(pos int) int {
+	for pos < len(c.input) && !utf8.RuneStart(c.input[pos]) {
+		pos++
+	}
+	if pos < c.bytes {
+		return utf8.RuneCountInString(c.input[:pos])
 	}
-	return strings.TrimSpace(b.String())
+	c.runes += utf8.RuneCountInString(c.input[c.bytes:pos])
+	c.bytes = pos
+	return c.runes
 }
@@ -16,3 +16,25 @@ func TestParagraphChunkerSplit(t *testing.T) {
 		require.LessOrEqual(t, len(fragment.Text), 40, "fragment exceeds limit: %d", len(fragment.Text))
 	}
 }
+
+func TestParagraphChunkerOffsets(t *testing.T) {
+	chunker := ParagraphChunker{}
+	materials := "  Héllo   wörld.\n\tSecond  sentence is longer. Third."
+	fragments := chunker.Split(materials, 30)
+	require.Len(t, fragments, 3)
+
+	runes := []rune(materials)
+	require.Equal(t, "Héllo   wörld.", string(runes[fragments[0].Start:fragments[0].End]))
+	require.Equal(t, "Second  sentence is longer.", string(runes[fragments[1].Start:fragments[1].End]))
+	require.Equal(t, "Third.", string(runes[fragments[2].Start:fragments[2].End]))
+}
+
+func TestParagraphChunkerOffsetsSkipHardSplitSpace(t *testing.T) {
+	materials := "alpha paragraph\n\nbeta paragraph"
+	fragments := ParagraphChunker{}.Split(materials, 20)
+	require.Len(t, fragments, 2)
+	require.Equal(t, "paragraph", fragments[1].Text)
+
+	runes := []rune(materials)
+	require.Equal(t, "paragraph", string(runes[fragments[1].Start:fragments[1].End]))
+}
@@ -0,0 +1,610 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	errors "github.com/Laisky/errors/v2"
+	"github.com/Laisky/zap"
+)
+
+// ErrCorpusNotFound is returned when the user has no corpus with the requested name.
+var ErrCorpusNotFound = errors.New("rag corpus not found")
+
+// ErrCorpusLimitReached is returned when creating a corpus would exceed Settings.MaxCorpora.
+var ErrCorpusLimitReached = errors.New("rag corpus limit reached")
+
+// corpusNamePattern restricts corpus names to short lowercase slugs.
+var corpusNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,63}$`)
+
+// corpusTaskPrefix namespaces the task rows backing corpora. SanitizeTaskID never
+// emits ':' so corpus tasks cannot collide with per-call extract_key_info tasks.
+const corpusTaskPrefix = "corpus:"
+
+const (
+	maxCorpusDescriptionLength = 1024
+	maxCorpusSourceLength      = 512
+)
+
+// CorpusMaterialsInput describes materials added to a named corpus.
+type CorpusMaterialsInput struct {
+	UserID      string
+	APIKey      string
+	Corpus      string
+	Description string
+	// Source labels the materials, for example "file:docs/guide.md".
+	// Uploading to an existing label replaces its materials.
+	// Defaults to a digest of Materials, so identical uploads are no-ops.
+	Source    string
+	Materials string
+}
+
+// CorpusUpload reports the outcome of adding materials to a corpus.
+type CorpusUpload struct {
+	Corpus  string
+	Created bool
+	Source  CorpusSource
+	// Reused is set when no new embeddings were computed for the materials.
+	Reused bool
+	// Replaced is set when the source label previously held different materials.
+	Replaced bool
+}
+
+// CorpusQueryInput captures a retrieval request against a named corpus.
+type CorpusQueryInput struct {
+	UserID string
+	APIKey string
+	Corpus string
+	Query  string
+	TopK   int
+	// Neighbors is the number of adjacent chunks returned on each side of a result, 0 to MaxNeighbors.
+	Neighbors int
+}
+
+// CorpusInfo summarizes a corpus and its sources.
+type CorpusInfo struct {
+	Corpus
+	Chunks  int
+	Sources []CorpusSource
+}
+
+// ValidateCorpusName checks that name is a valid corpus name.
+func ValidateCorpusName(name string) error {
+	if !corpusNamePattern.MatchString(name) {
+		return errors.Errorf("invalid corpus name %q: use 1-64 lowercase letters, digits, '_' or '-', starting with a letter", name)
+	}
+	return nil
+}
+
+// AddCorpusMaterials chunks and embeds materials into the named corpus, creating it when needed.
+// Chunks already stored for the same materials hash are reused rather than embedded again.
+func (s *Service) AddCorpusMaterials(ctx context.Context, input CorpusMaterialsInput) (*CorpusUpload, error) {
+	if err := validateCorpusOwner(input.UserID, input.APIKey, input.Corpus); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if strings.TrimSpace(input.Materials) == "" {
+		return nil, errors.New("materials cannot be empty")
+	}
+	if len(input.Materials) > s.settings.MaxMaterialsSize {
+		return nil, errors.Errorf("materials exceed maximum size (%d bytes)", s.settings.MaxMaterialsSize)
+	}
+	if len(input.Description) > maxCorpusDescriptionLength {
+		return nil, errors.Errorf("description exceeds %d characters", maxCorpusDescriptionLength)
+	}
+
+	source := strings.TrimSpace(input.Source)
+	if source == "" {
+		sum := sha256.Sum256([]byte(input.Materials))
+		source = "inline:" + hex.EncodeToString(sum[:8])
+	}
+	if len(source) > maxCorpusSourceLength {
+		return nil, errors.Errorf("source label exceeds %d characters", maxCorpusSourceLength)
+	}
+
+	corpus, created, err := s.ensureCorpus(ctx, input.UserID, input.Corpus, input.Description)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	existing, err := s.getCorpusSource(ctx, corpus.ID, source)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ingest, err := s.ensureChunks(ctx, corpus.task(), input.APIKey, input.Materials)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	spans, err := json.Marshal(ingest.Spans)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode corpus source spans")
+	}
+
+	now := s.clock()
+	upload := &CorpusUpload{Corpus: corpus.Name, Created: created, Reused: ingest.Reused}
+	if existing == nil {
+		row := CorpusSource{
+			CorpusID:      corpus.ID,
+			Source:        source,
+			MaterialsHash: ingest.MaterialsHash,
+			Bytes:         int64(len(input.Materials)),
+			ChunkCount:    ingest.Chunks,
+			Spans:         spans,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		row.ID, err = s.insertReturningID(ctx, s.db, `
+			INSERT INTO mcp_rag_corpus_sources(corpus_id, source, materials_hash, bytes, chunk_count, spans, created_at, updated_at)
+			VALUES(?, ?, ?, ?, ?, ?, ?, ?)
+		`, row.CorpusID, row.Source, row.MaterialsHash, row.Bytes, row.ChunkCount, string(row.Spans), row.CreatedAt, row.UpdatedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "insert corpus source")
+		}
+		upload.Source = row
+	} else {
+		previousHash := existing.MaterialsHash
+		existing.MaterialsHash = ingest.MaterialsHash
+		existing.Bytes = int64(len(input.Materials))
+		existing.ChunkCount = ingest.Chunks
+		existing.Spans = spans
+		existing.UpdatedAt = now
+		query := rebindPlaceholders(s.dialect, `
+			UPDATE mcp_rag_corpus_sources
+			SET materials_hash = ?, bytes = ?, chunk_count = ?, spans = ?, updated_at = ?
+			WHERE id = ?
+		`)
+		if _, err := s.db.ExecContext(ctx, query, existing.MaterialsHash, existing.Bytes, existing.ChunkCount, string(existing.Spans), existing.UpdatedAt, existing.ID); err != nil {
+			return nil, errors.Wrap(err, "update corpus source")
+		}
+		if previousHash != ingest.MaterialsHash {
+			upload.Replaced = true
+			if err := s.pruneCorpusMaterials(ctx, corpus, previousHash); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+		upload.Source = *existing
+	}
+
+	if err := s.touchCorpus(ctx, corpus, input.Description); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	logger := s.loggerFromContext(ctx)
+	logger.Info("rag corpus materials added",
+		zap.String("user_id", input.UserID),
+		zap.String("corpus", corpus.Name),
+		zap.String("source", source),
+		zap.Int("chunks", ingest.Chunks),
+		zap.Bool("reused", ingest.Reused),
+		zap.Bool("replaced", upload.Replaced),
+	)
+	return upload, nil
+}
+
+// QueryCorpus runs hybrid retrieval over the chunks stored in the named corpus.
+// Results carry the labels of the corpus sources their materials came from.
+// Sources with the same materials hash may differ in whitespace, so a result
+// only carries offsets when every one of its sources places the chunk at the
+// same span.
+func (s *Service) QueryCorpus(ctx context.Context, input CorpusQueryInput) ([]KeyInfo, error) {
+	if err := validateCorpusOwner(input.UserID, input.APIKey, input.Corpus); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if strings.TrimSpace(input.Query) == "" {
+		return nil, errors.New("query cannot be empty")
+	}
+	if input.TopK <= 0 || input.TopK > s.settings.TopKLimit {
+		return nil, errors.Errorf("topK must be between 1 and %d", s.settings.TopKLimit)
+	}
+	if input.Neighbors < 0 || input.Neighbors > MaxNeighbors {
+		return nil, errors.Errorf("neighbors must be between 0 and %d", MaxNeighbors)
+	}
+
+	corpus, err := s.getCorpus(ctx, input.UserID, input.Corpus)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	results, err := s.retrieve(ctx, corpus.TaskRef, input.APIKey, input.Query, input.TopK, input.Neighbors)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sources, err := s.listCorpusSources(ctx, corpus.ID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	attachCorpusSources(results, sources)
+	return results, nil
+}
+
+// attachCorpusSources sets the source labels and offsets of results from the
+// corpus sources holding their materials.
+func attachCorpusSources(results []KeyInfo, sources []CorpusSource) {
+	labels := make(map[string][]string, len(sources))
+	spans := make(map[string][][][2]int, len(sources))
+	for _, source := range sources {
+		labels[source.MaterialsHash] = append(labels[source.MaterialsHash], source.Source)
+		var sourceSpans [][2]int
+		if json.Unmarshal(source.Spans, &sourceSpans) != nil {
+			sourceSpans = nil
+		}
+		spans[source.MaterialsHash] = append(spans[source.MaterialsHash], sourceSpans)
+	}
+	for i := range results {
+		results[i].Sources = labels[results[i].MaterialsHash]
+		results[i].setSpan(agreedSpans(spans[results[i].MaterialsHash], results[i].ChunkIndex))
+	}
+}
+
+// agreedSpans returns the spans of the first source when every source places
+// chunk index at the same offsets, and false otherwise.
+func agreedSpans(sources [][][2]int, index int) ([][2]int, bool) {
+	if len(sources) == 0 {
+		return nil, false
+	}
+	for _, spans := range sources {
+		if index < 0 || index >= len(spans) || spans[index] != sources[0][index] {
+			return nil, false
+		}
+	}
+	return sources[0], true
+}
+
+// ListCorpora returns the user's corpora ordered by name, with their sources.
+func (s *Service) ListCorpora(ctx context.Context, userID string) ([]CorpusInfo, error) {
+	if strings.TrimSpace(userID) == "" {
+		return nil, errors.New("user id cannot be empty")
+	}
+
+	query := rebindPlaceholders(s.dialect, `
+		SELECT id, user_id, name, description, task_ref, created_at, updated_at
+		FROM mcp_rag_corpora
+		WHERE user_id = ?
+		ORDER BY name ASC
+	`)
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query rag corpora")
+	}
+	var corpora []CorpusInfo
+	for rows.Next() {
+		var info CorpusInfo
+		if scanErr := rows.Scan(&info.ID, &info.UserID, &info.Name, &info.Description, &info.TaskRef, &info.CreatedAt, &info.UpdatedAt); scanErr != nil {
+			_ = rows.Close()
+			return nil, errors.Wrap(scanErr, "scan rag corpus")
+		}
+		corpora = append(corpora, info)
+	}
+	if rowsErr := rows.Err(); rowsErr != nil {
+		_ = rows.Close()
+		return nil, errors.Wrap(rowsErr, "iterate rag corpora")
+	}
+	_ = rows.Close()
+
+	for idx := range corpora {
+		if corpora[idx].Sources, err = s.listCorpusSources(ctx, corpora[idx].ID); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		countQuery := rebindPlaceholders(s.dialect, `SELECT COUNT(1) FROM mcp_rag_chunks WHERE task_id = ?`)
+		if err := s.db.QueryRowContext(ctx, countQuery, corpora[idx].TaskRef).Scan(&corpora[idx].Chunks); err != nil {
+			return nil, errors.Wrap(err, "count corpus chunks")
+		}
+	}
+	return corpora, nil
+}
+
+// DeleteCorpus removes the named corpus together with its chunks, embeddings and sources.
+func (s *Service) DeleteCorpus(ctx context.Context, userID, name string) error {
+	if strings.TrimSpace(userID) == "" {
+		return errors.New("user id cannot be empty")
+	}
+	if err := ValidateCorpusName(name); err != nil {
+		return errors.WithStack(err)
+	}
+
+	corpus, err := s.getCorpus(ctx, userID, name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin corpus delete tx")
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := s.deleteTaskChunksTx(ctx, tx, corpus.TaskRef, ""); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, statement := range []struct {
+		query string
+		arg   int64
+	}{
+		{`DELETE FROM mcp_rag_tasks WHERE id = ?`, corpus.TaskRef},
+		{`DELETE FROM mcp_rag_corpus_sources WHERE corpus_id = ?`, corpus.ID},
+		{`DELETE FROM mcp_rag_corpora WHERE id = ?`, corpus.ID},
+	} {
+		if _, err := tx.ExecContext(ctx, rebindPlaceholders(s.dialect, statement.query), statement.arg); err != nil {
+			return errors.Wrap(err, "delete rag corpus")
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "commit corpus delete tx")
+	}
+
+	logger := s.loggerFromContext(ctx)
+	logger.Info("rag corpus deleted", zap.String("user_id", userID), zap.String("corpus", name))
+	return nil
+}
+
+// validateCorpusOwner checks the identity fields shared by corpus operations.
+func validateCorpusOwner(userID, apiKey, name string) error {
+	if strings.TrimSpace(userID) == "" {
+		return errors.New("user id cannot be empty")
+	}
+	if strings.TrimSpace(apiKey) == "" {
+		return errors.New("api key cannot be empty")
+	}
+	return ValidateCorpusName(name)
+}
+
+// task returns the task row that stores the corpus chunks.
+func (c *Corpus) task() *Task {
+	return &Task{
+		ID:        c.TaskRef,
+		UserID:    c.UserID,
+		TaskID:    corpusTaskPrefix + c.Name,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+// ensureCorpus returns the named corpus, creating it and its backing task when missing.
+// The boolean result reports whether the corpus was created.
+func (s *Service) ensureCorpus(ctx context.Context, userID, name, description string) (*Corpus, bool, error) {
+	corpus, err := s.getCorpus(ctx, userID, name)
+	if err == nil {
+		return corpus, false, nil
+	}
+	if !errors.Is(err, ErrCorpusNotFound) {
+		return nil, false, errors.WithStack(err)
+	}
+
+	var count int
+	countQuery := rebindPlaceholders(s.dialect, `SELECT COUNT(1) FROM mcp_rag_corpora WHERE user_id = ?`)
+	if err := s.db.QueryRowContext(ctx, countQuery, userID).Scan(&count); err != nil {
+		return nil, false, errors.Wrap(err, "count rag corpora")
+	}
+	if count >= s.settings.MaxCorpora {
+		return nil, false, errors.Wrapf(ErrCorpusLimitReached, "%d corpora per user", s.settings.MaxCorpora)
+	}
+
+	now := s.clock()
+	created := &Corpus{
+		UserID:      userID,
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.insertCorpus(ctx, created); err != nil {
+		// A concurrent upload may have created the corpus first.
+		if existing, getErr := s.getCorpus(ctx, userID, name); getErr == nil {
+			return existing, false, nil
+		}
+		return nil, false, errors.Wrap(err, "create rag corpus")
+	}
+
+	logger := s.loggerFromContext(ctx)
+	logger.Info("rag corpus created", zap.String("user_id", userID), zap.String("corpus", name))
+	return created, true, nil
+}
+
+// insertCorpus inserts the backing task and the corpus row in one transaction.
+func (s *Service) insertCorpus(ctx context.Context, corpus *Corpus) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin corpus create tx")
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	corpus.TaskRef, err = s.insertReturningID(ctx, tx, `
+		INSERT INTO mcp_rag_tasks(user_id, task_id, created_at, updated_at)
+		VALUES(?, ?, ?, ?)
+	`, corpus.UserID, corpusTaskPrefix+corpus.Name, corpus.CreatedAt, corpus.UpdatedAt)
+	if err != nil {
+		return errors.Wrap(err, "insert corpus task")
+	}
+	corpus.ID, err = s.insertReturningID(ctx, tx, `
+		INSERT INTO mcp_rag_corpora(user_id, name, description, task_ref, created_at, updated_at)
+		VALUES(?, ?, ?, ?, ?, ?)
+	`, corpus.UserID, corpus.Name, corpus.Description, corpus.TaskRef, corpus.CreatedAt, corpus.UpdatedAt)
+	if err != nil {
+		return errors.Wrap(err, "insert corpus")
+	}
+
+	return errors.Wrap(tx.Commit(), "commit corpus create tx")
+}
+
+// touchCorpus bumps updated_at and replaces the description when one is given.
+func (s *Service) touchCorpus(ctx context.Context, corpus *Corpus, description string) error {
+	corpus.UpdatedAt = s.clock()
+	if description = strings.TrimSpace(description); description != "" {
+		corpus.Description = description
+	}
+	query := rebindPlaceholders(s.dialect, `UPDATE mcp_rag_corpora SET description = ?, updated_at = ? WHERE id = ?`)
+	if _, err := s.db.ExecContext(ctx, query, corpus.Description, corpus.UpdatedAt, corpus.ID); err != nil {
+		return errors.Wrap(err, "update rag corpus")
+	}
+	return nil
+}
+
+// getCorpus loads a corpus by owner and name, returning ErrCorpusNotFound when absent.
+func (s *Service) getCorpus(ctx context.Context, userID, name string) (*Corpus, error) {
+	query := rebindPlaceholders(s.dialect, `
+		SELECT id, user_id, name, description, task_ref, created_at, updated_at
+		FROM mcp_rag_corpora
+		WHERE user_id = ? AND name = ?
+		LIMIT 1
+	`)
+	var corpus Corpus
+	err := s.db.QueryRowContext(ctx, query, userID, name).Scan(
+		&corpus.ID,
+		&corpus.UserID,
+		&corpus.Name,
+		&corpus.Description,
+		&corpus.TaskRef,
+		&corpus.CreatedAt,
+		&corpus.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Wrapf(ErrCorpusNotFound, "corpus %q", name)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "query rag corpus")
+	}
+	return &corpus, nil
+}
+
+// getCorpusSource loads a source row by label, returning nil when absent.
+func (s *Service) getCorpusSource(ctx context.Context, corpusID int64, source string) (*CorpusSource, error) {
+	query := rebindPlaceholders(s.dialect, `
+		SELECT id, corpus_id, source, materials_hash, bytes, chunk_count, spans, created_at, updated_at
+		FROM mcp_rag_corpus_sources
+		WHERE corpus_id = ? AND source = ?
+		LIMIT 1
+	`)
+	var row CorpusSource
+	var spans string
+	err := s.db.QueryRowContext(ctx, query, corpusID, source).Scan(
+		&row.ID,
+		&row.CorpusID,
+		&row.Source,
+		&row.MaterialsHash,
+		&row.Bytes,
+		&row.ChunkCount,
+		&spans,
+		&row.CreatedAt,
+		&row.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "query corpus source")
+	}
+	row.Spans = []byte(spans)
+	return &row, nil
+}
+
+// listCorpusSources returns the sources of a corpus ordered by label.
+func (s *Service) listCorpusSources(ctx context.Context, corpusID int64) ([]CorpusSource, error) {
+	query := rebindPlaceholders(s.dialect, `
+		SELECT id, corpus_id, source, materials_hash, bytes, chunk_count, spans, created_at, updated_at
+		FROM mcp_rag_corpus_sources
+		WHERE corpus_id = ?
+		ORDER BY source ASC
+	`)
+	rows, err := s.db.QueryContext(ctx, query, corpusID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query corpus sources")
+	}
+	defer func() { _ = rows.Close() }()
+
+	sources := []CorpusSource{}
+	for rows.Next() {
+		var row CorpusSource
+		var spans string
+		if scanErr := rows.Scan(&row.ID, &row.CorpusID, &row.Source, &row.MaterialsHash, &row.Bytes, &row.ChunkCount, &spans, &row.CreatedAt, &row.UpdatedAt); scanErr != nil {
+			return nil, errors.Wrap(scanErr, "scan corpus source")
+		}
+		row.Spans = []byte(spans)
+		sources = append(sources, row)
+	}
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, errors.Wrap(rowsErr, "iterate corpus sources")
+	}
+	return sources, nil
+}
+
+// pruneCorpusMaterials drops the chunks of materialsHash once no source of the corpus references it.
+func (s *Service) pruneCorpusMaterials(ctx context.Context, corpus *Corpus, materialsHash string) error {
+	var refs int
+	query := rebindPlaceholders(s.dialect, `SELECT COUNT(1) FROM mcp_rag_corpus_sources WHERE corpus_id = ? AND materials_hash = ?`)
+	if err := s.db.QueryRowContext(ctx, query, corpus.ID, materialsHash).Scan(&refs); err != nil {
+		return errors.Wrap(err, "count corpus source references")
+	}
+	if refs > 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin corpus prune tx")
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if err := s.deleteTaskChunksTx(ctx, tx, corpus.TaskRef, materialsHash); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.Wrap(tx.Commit(), "commit corpus prune tx")
+}
+
+// deleteTaskChunksTx deletes the chunk, embedding and bm25 rows of a task.
+// An empty materialsHash deletes every chunk of the task.
+func (s *Service) deleteTaskChunksTx(ctx context.Context, tx *sql.Tx, taskID int64, materialsHash string) error {
+	filter := `task_id = ?`
+	args := []any{taskID}
+	if materialsHash != "" {
+		filter += ` AND materials_hash = ?`
+		args = append(args, materialsHash)
+	}
+
+	for _, table := range []string{TableRAGBM25, TableRAGEmbeddings} {
+		query := rebindPlaceholders(s.dialect, `DELETE FROM `+table+` WHERE chunk_id IN (SELECT id FROM mcp_rag_chunks WHERE `+filter+`)`)
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrapf(err, "delete %s rows", table)
+		}
+	}
+	query := rebindPlaceholders(s.dialect, `DELETE FROM mcp_rag_chunks WHERE `+filter)
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "delete chunk rows")
+	}
+	return nil
+}
+
+// sqlExecQuerier is satisfied by both *sql.DB and *sql.Tx.
+type sqlExecQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// insertReturningID runs an INSERT statement and returns the generated id.
+func (s *Service) insertReturningID(ctx context.Context, q sqlExecQuerier, query string, args ...any) (int64, error) {
+	if s.dialect == sqlDialectPostgres {
+		var id int64
+		if err := q.QueryRowContext(ctx, rebindPlaceholders(s.dialect, query+" RETURNING id"), args...).Scan(&id); err != nil {
+			return 0, errors.WithStack(err)
+		}
+		return id, nil
+	}
+
+	result, err := q.ExecContext(ctx, rebindPlaceholders(s.dialect, query), args...)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return id, nil
+}
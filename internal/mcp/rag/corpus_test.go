@@ -0,0 +1,235 @@
+package rag
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	errors "github.com/Laisky/errors/v2"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Laisky/laisky-blog-graphql/library/log"
+)
+
+func newCorpusTestService(t *testing.T, dsn string) (*Service, *captureRAGEmbedder, *sql.DB) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	embedder := &captureRAGEmbedder{}
+	settings := LoadSettingsFromConfig()
+	settings.TopKLimit = 10
+	settings.MaxMaterialsSize = 1024 * 1024
+	settings.MaxChunkChars = 240
+	settings.MaxCorpora = 2
+
+	svc, err := NewService(db, embedder, ParagraphChunker{}, settings, log.Logger.Named("rag_corpus_test"))
+	require.NoError(t, err)
+	return svc, embedder, db
+}
+
+func TestCorpusAddListDelete(t *testing.T) {
+	svc, embedder, db := newCorpusTestService(t, "file:rag_corpus_crud?mode=memory&cache=shared")
+	ctx := context.Background()
+
+	upload, err := svc.AddCorpusMaterials(ctx, CorpusMaterialsInput{
+		UserID:      "user-1",
+		APIKey:      "sk-corpus",
+		Corpus:      "handbook",
+		Description: "team handbook",
+		Materials:   "alpha paragraph\n\nbeta paragraph",
+	})
+	require.NoError(t, err)
+	require.True(t, upload.Created)
+	require.False(t, upload.Reused)
+	require.Len(t, embedder.keys, 1)
+
+	// Re-uploading the same materials is a no-op that embeds nothing.
+	upload, err = svc.AddCorpusMaterials(ctx, CorpusMaterialsInput{
+		UserID:    "user-1",
+		APIKey:    "sk-corpus",
+		Corpus:    "handbook",
+		Materials: "alpha paragraph\n\nbeta paragraph",
+	})
+	require.NoError(t, err)
+	require.False(t, upload.Created)
+	require.True(t, upload.Reused)
+	require.Len(t, embedder.keys, 1)
+
+	_, err = svc.AddCorpusMaterials(ctx, CorpusMaterialsInput{
+		UserID:    "user-1",
+		APIKey:    "sk-corpus",
+		Corpus:    "handbook",
+		Source:    "file:docs/guide.md",
+		Materials: "gamma paragraph",
+	})
+	require.NoError(t, err)
+
+	upload, err = svc.AddCorpusMaterials(ctx, CorpusMaterialsInput{
+		UserID:    "user-1",
+		APIKey:    "sk-corpus",
+		Corpus:    "handbook",
+		Source:    "file:docs/guide.md",
+		Materials: "delta paragraph",
+	})
+	require.NoError(t, err)
+	require.True(t, upload.Replaced)
+
+	corpora, err := svc.ListCorpora(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, corpora, 1)
+	require.Equal(t, "handbook", corpora[0].Name)
+	require.Equal(t, "team handbook", corpora[0].Description)
+	require.Len(t, corpora[0].Sources, 2)
+	require.Equal(t, "file:docs/guide.md", corpora[0].Sources[0].Source)
+	// The replaced gamma chunks are pruned, so only live sources hold chunks.
+	require.Equal(t, corpora[0].Sources[0].ChunkCount+corpora[0].Sources[1].ChunkCount, corpora[0].Chunks)
+
+	others, err := svc.ListCorpora(ctx, "user-2")
+	require.NoError(t, err)
+	require.Empty(t, others)
+
+	require.NoError(t, svc.DeleteCorpus(ctx, "user-1", "handbook"))
+	corpora, err = svc.ListCorpora(ctx, "user-1")
+	require.NoError(t, err)
+	require.Empty(t, corpora)
+
+	for _, table := range []string{TableRAGChunks, TableRAGEmbeddings, TableRAGBM25, TableRAGCorpusSources} {
+		var count int
+		require.NoError(t, db.QueryRow("SELECT COUNT(1) FROM "+table).Scan(&count))
+		require.Zero(t, count, table)
+	}
+
+	err = svc.DeleteCorpus(ctx, "user-1", "handbook")
+	require.True(t, errors.Is(err, ErrCorpusNotFound))
+}
+
+func TestCorpusReusesEmbeddingsFromOtherTasks(t *testing.T) {
+	svc, embedder, _ := newCorpusTestService(t, "file:rag_corpus_reuse?mode=memory&cache=shared")
+	ctx := context.Background()
+	materials := "shared paragraph one\n\nshared paragraph two"
+
+	task, err := svc.ensureTask(ctx, "user-1", "task-1", "sk-corpus")
+	require.NoError(t, err)
+	ingest, err := svc.ensureChunks(ctx, task, "sk-corpus", materials)
+	require.NoError(t, err)
+	require.Len(t, embedder.keys, 1)
+
+	upload, err := svc.AddCorpusMaterials(ctx, CorpusMaterialsInput{
+		UserID:    "user-1",
+		APIKey:    "sk-corpus",
+		Corpus:    "shared",
+		Materials: materials,
+	})
+	require.NoError(t, err)
+	require.True(t, upload.Reused)
+	require.Equal(t, ingest.MaterialsHash, upload.Source.MaterialsHash)
+	require.Equal(t, ingest.Chunks, upload.Source.ChunkCount)
+	require.Len(t, embedder.keys, 1)
+
+	// Another user's rows are never reused.
+	upload, err = svc.AddCorpusMaterials(ctx, CorpusMaterialsInput{
+		UserID:    "user-2",
+		APIKey:    "sk-other",
+		Corpus:    "shared",
+		Materials: materials,
+	})
+	require.NoError(t, err)
+	require.False(t, upload.Reused)
+	require.Len(t, embedder.keys, 2)
+}
+
+func TestCorpusOffsetsFollowEachSource(t *testing.T) {
+	svc, embedder, _ := newCorpusTestService(t, "file:rag_corpus_offsets?mode=memory&cache=shared")
+	svc.settings.MaxChunkChars = 20
+	ctx := context.Background()
+	first := "First sentence here. Second one is here."
+	second := "\n\nFirst   sentence here.\n\n\tSecond one is here.\n"
+
+	upload, err := svc.AddCorpusMaterials(ctx, CorpusMaterialsInput{
+		UserID: "user-1", APIKey: "sk", Corpus: "notes", Source: "file:a.md", Materials: first,
+	})
+	require.NoError(t, err)
+	hash := upload.Source.MaterialsHash
+
+	// The second source cleans to the same chunks, so the stored rows are
+	// reused even though every chunk sits at a different offset.
+	upload, err = svc.AddCorpusMaterials(ctx, CorpusMaterialsInput{
+		UserID: "user-1", APIKey: "sk", Corpus: "notes", Source: "file:b.md", Materials: second,
+	})
+	require.NoError(t, err)
+	require.True(t, upload.Reused)
+	require.Equal(t, hash, upload.Source.MaterialsHash)
+	require.Len(t, embedder.keys, 1)
+
+	corpus, err := svc.getCorpus(ctx, "user-1", "notes")
+	require.NoError(t, err)
+	sources, err := svc.listCorpusSources(ctx, corpus.ID)
+	require.NoError(t, err)
+
+	// Stale metadata offsets must not survive when the sources disagree.
+	results := []KeyInfo{{ChunkIndex: 1, MaterialsHash: hash, Start: 21, End: 40, HasOffsets: true}}
+	attachCorpusSources(results, sources)
+	require.Equal(t, []string{"file:a.md", "file:b.md"}, results[0].Sources)
+	require.False(t, results[0].HasOffsets)
+	require.Zero(t, results[0].Start)
+	require.Zero(t, results[0].End)
+
+	_, err = svc.AddCorpusMaterials(ctx, CorpusMaterialsInput{
+		UserID: "user-1", APIKey: "sk", Corpus: "notes", Source: "file:a.md", Materials: "Other text.",
+	})
+	require.NoError(t, err)
+	sources, err = svc.listCorpusSources(ctx, corpus.ID)
+	require.NoError(t, err)
+
+	results = []KeyInfo{{ChunkIndex: 1, MaterialsHash: hash}}
+	attachCorpusSources(results, sources)
+	require.Equal(t, []string{"file:b.md"}, results[0].Sources)
+	require.True(t, results[0].HasOffsets)
+	require.Equal(t, "Second one is here.", string([]rune(second)[results[0].Start:results[0].End]))
+}
+
+func TestEnsureChunksSpansFollowCurrentInput(t *testing.T) {
+	svc, _, _ := newCorpusTestService(t, "file:rag_ingest_spans?mode=memory&cache=shared")
+	svc.settings.MaxChunkChars = 20
+	ctx := context.Background()
+	first := "First sentence here. Second one is here."
+	second := "First sentence here.\n\n   Second   one is here."
+
+	task, err := svc.ensureTask(ctx, "user-1", "task-1", "sk")
+	require.NoError(t, err)
+	ingest, err := svc.ensureChunks(ctx, task, "sk", first)
+	require.NoError(t, err)
+	require.False(t, ingest.Reused)
+
+	ingest, err = svc.ensureChunks(ctx, task, "sk", second)
+	require.NoError(t, err)
+	require.True(t, ingest.Reused)
+	require.Len(t, ingest.Spans, 2)
+
+	result := KeyInfo{ChunkIndex: 1, MaterialsHash: ingest.MaterialsHash}
+	result.setSpan(ingest.Spans, true)
+	require.True(t, result.HasOffsets)
+	require.Equal(t, "Second   one is here.", string([]rune(second)[result.Start:result.End]))
+}
+
+func TestCorpusValidation(t *testing.T) {
+	svc, _, _ := newCorpusTestService(t, "file:rag_corpus_validation?mode=memory&cache=shared")
+	ctx := context.Background()
+
+	_, err := svc.AddCorpusMaterials(ctx, CorpusMaterialsInput{UserID: "user-1", APIKey: "sk", Corpus: "Bad Name", Materials: "text"})
+	require.ErrorContains(t, err, "invalid corpus name")
+
+	_, err = svc.QueryCorpus(ctx, CorpusQueryInput{UserID: "user-1", APIKey: "sk", Corpus: "missing", Query: "q", TopK: 1})
+	require.True(t, errors.Is(err, ErrCorpusNotFound))
+
+	for _, name := range []string{"one", "two"} {
+		_, err = svc.AddCorpusMaterials(ctx, CorpusMaterialsInput{UserID: "user-1", APIKey: "sk", Corpus: name, Materials: "text " + name})
+		require.NoError(t, err)
+	}
+	_, err = svc.AddCorpusMaterials(ctx, CorpusMaterialsInput{UserID: "user-1", APIKey: "sk", Corpus: "three", Materials: "text three"})
+	require.True(t, errors.Is(err, ErrCorpusLimitReached))
+}
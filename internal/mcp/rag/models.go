@@ -47,9 +47,39 @@ type BM25Row struct {
 	UpdatedAt  time.Time
 }
 
+// Corpus is a named, persistent set of materials owned by a user.
+// Its chunks live under the dedicated task referenced by TaskRef.
+type Corpus struct {
+	ID          int64
+	UserID      string
+	Name        string
+	Description string
+	TaskRef     int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// CorpusSource records one labelled materials upload within a corpus.
+type CorpusSource struct {
+	ID            int64
+	CorpusID      int64
+	Source        string
+	MaterialsHash string
+	Bytes         int64
+	ChunkCount    int
+	// Spans is the JSON list of [start, end) character offsets of each chunk in
+	// this source's materials, indexed by chunk index. Sources sharing a materials
+	// hash may differ in whitespace, so offsets are kept per source.
+	Spans     []byte
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 const (
-	TableRAGTasks      = "mcp_rag_tasks"
-	TableRAGChunks     = "mcp_rag_chunks"
-	TableRAGEmbeddings = "mcp_rag_embeddings"
-	TableRAGBM25       = "mcp_rag_bm25"
+	TableRAGTasks         = "mcp_rag_tasks"
+	TableRAGChunks        = "mcp_rag_chunks"
+	TableRAGEmbeddings    = "mcp_rag_embeddings"
+	TableRAGBM25          = "mcp_rag_bm25"
+	TableRAGCorpora       = "mcp_rag_corpora"
+	TableRAGCorpusSources = "mcp_rag_corpus_sources"
 )
@@ -34,6 +34,33 @@ type ExtractInput struct {
 	Query     string
 	Materials string
 	TopK      int
+	// Neighbors is the number of adjacent chunks returned on each side of a result, 0 to MaxNeighbors.
+	Neighbors int
+}
+
+// MaxNeighbors bounds how many adjacent chunks a result may carry on each side.
+const MaxNeighbors = 3
+
+// KeyInfo is one retrieved chunk together with the provenance needed to cite it.
+type KeyInfo struct {
+	Text          string
+	ChunkIndex    int
+	MaterialsHash string
+	// Start and End are character (Unicode code point) offsets of the chunk's span
+	// in the original materials; End is exclusive. HasOffsets is false for chunks
+	// stored before offsets were recorded.
+	Start      int
+	End        int
+	HasOffsets bool
+	// Score is the weighted hybrid score used for ranking.
+	Score         float64
+	SemanticScore float64
+	LexicalScore  float64
+	// Sources lists the corpus source labels whose materials contain the chunk.
+	Sources []string
+	// Before and After hold up to Neighbors adjacent chunk texts in document order.
+	Before []string
+	After  []string
 }
 
 // Service coordinates chunking, storage, and retrieval for the extract_key_info tool.
@@ -223,6 +250,28 @@ func ragMigrationStatements(dialect sqlDialect) []string {
 				created_at TIMESTAMPTZ NOT NULL,
 				updated_at TIMESTAMPTZ NOT NULL
 			)`,
+			`CREATE TABLE IF NOT EXISTS mcp_rag_corpora (
+				id BIGSERIAL PRIMARY KEY,
+				user_id VARCHAR(128) NOT NULL,
+				name VARCHAR(64) NOT NULL,
+				description TEXT NOT NULL DEFAULT '',
+				task_ref BIGINT NOT NULL,
+				created_at TIMESTAMPTZ NOT NULL,
+				updated_at TIMESTAMPTZ NOT NULL
+			)`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_rag_corpora_user_name ON mcp_rag_corpora(user_id, name)`,
+			`CREATE TABLE IF NOT EXISTS mcp_rag_corpus_sources (
+				id BIGSERIAL PRIMARY KEY,
+				corpus_id BIGINT NOT NULL,
+				source VARCHAR(512) NOT NULL,
+				materials_hash VARCHAR(96) NOT NULL,
+				bytes BIGINT NOT NULL,
+				chunk_count INTEGER NOT NULL,
+				spans TEXT NOT NULL DEFAULT '[]',
+				created_at TIMESTAMPTZ NOT NULL,
+				updated_at TIMESTAMPTZ NOT NULL
+			)`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_rag_corpus_sources_source ON mcp_rag_corpus_sources(corpus_id, source)`,
 		}
 	}
 
@@ -263,11 +312,33 @@ func ragMigrationStatements(dialect sqlDialect) []string {
 			created_at TIMESTAMP NOT NULL,
 			updated_at TIMESTAMP NOT NULL
 		)`,
+		`CREATE TABLE IF NOT EXISTS mcp_rag_corpora (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			task_ref INTEGER NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_rag_corpora_user_name ON mcp_rag_corpora(user_id, name)`,
+		`CREATE TABLE IF NOT EXISTS mcp_rag_corpus_sources (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			corpus_id INTEGER NOT NULL,
+			source TEXT NOT NULL,
+			materials_hash TEXT NOT NULL,
+			bytes INTEGER NOT NULL,
+			chunk_count INTEGER NOT NULL,
+			spans TEXT NOT NULL DEFAULT '[]',
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_rag_corpus_sources_source ON mcp_rag_corpus_sources(corpus_id, source)`,
 	}
 }
 
 // ExtractKeyInfo orchestrates ingestion (if needed) and hybrid retrieval for the request.
-func (s *Service) ExtractKeyInfo(ctx context.Context, input ExtractInput) ([]string, error) {
+func (s *Service) ExtractKeyInfo(ctx context.Context, input ExtractInput) ([]KeyInfo, error) {
 	if err := s.validateInput(input); err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -277,12 +348,28 @@ func (s *Service) ExtractKeyInfo(ctx context.Context, input ExtractInput) ([]str
 		return nil, errors.WithStack(err)
 	}
 
-	if err := s.ensureChunks(ctx, task, input); err != nil {
+	ingest, err := s.ensureChunks(ctx, task, input.APIKey, input.Materials)
+	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	queryTokens := Tokenize(input.Query)
-	queryVecs, err := s.embedder.EmbedTexts(ctx, input.APIKey, []string{input.Query})
+	results, err := s.retrieve(ctx, task.ID, input.APIKey, input.Query, input.TopK, input.Neighbors)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for i := range results {
+		if results[i].MaterialsHash == ingest.MaterialsHash {
+			results[i].setSpan(ingest.Spans, true)
+		}
+	}
+	return results, nil
+}
+
+// retrieve embeds the query and returns the top-ranked chunks stored under taskID,
+// each with up to neighbors adjacent chunks on either side.
+func (s *Service) retrieve(ctx context.Context, taskID int64, apiKey, query string, topK, neighbors int) ([]KeyInfo, error) {
+	queryTokens := Tokenize(query)
+	queryVecs, err := s.embedder.EmbedTexts(ctx, apiKey, []string{query})
 	if err != nil {
 		return nil, errors.Wrap(err, "embed query")
 	}
@@ -291,13 +378,55 @@ func (s *Service) ExtractKeyInfo(ctx context.Context, input ExtractInput) ([]str
 	}
 	queryVec := queryVecs[0]
 
-	candidates, err := s.fetchCandidates(ctx, task.ID, queryVec, max(16, input.TopK*4))
+	candidates, err := s.fetchCandidates(ctx, taskID, queryVec, max(16, topK*4))
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	contexts := s.rankAndSelect(candidates, queryVec, queryTokens, input.TopK)
-	return contexts, nil
+	results := s.rankAndSelect(candidates, queryVec, queryTokens, topK)
+	if neighbors > 0 {
+		if err := s.attachNeighbors(ctx, taskID, results, neighbors); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return results, nil
+}
+
+// attachNeighbors loads up to n chunks on each side of every result from the same materials.
+func (s *Service) attachNeighbors(ctx context.Context, taskID int64, results []KeyInfo, n int) error {
+	query := rebindPlaceholders(s.dialect, `
+		SELECT chunk_index, text
+		FROM mcp_rag_chunks
+		WHERE task_id = ? AND materials_hash = ? AND chunk_index BETWEEN ? AND ?
+		ORDER BY chunk_index ASC
+	`)
+	for i := range results {
+		result := &results[i]
+		rows, err := s.db.QueryContext(ctx, query, taskID, result.MaterialsHash, result.ChunkIndex-n, result.ChunkIndex+n)
+		if err != nil {
+			return errors.Wrap(err, "query neighbor chunks")
+		}
+		for rows.Next() {
+			var index int
+			var text string
+			if scanErr := rows.Scan(&index, &text); scanErr != nil {
+				_ = rows.Close()
+				return errors.Wrap(scanErr, "scan neighbor chunk")
+			}
+			switch {
+			case index < result.ChunkIndex:
+				result.Before = append(result.Before, text)
+			case index > result.ChunkIndex:
+				result.After = append(result.After, text)
+			}
+		}
+		rowsErr := rows.Err()
+		_ = rows.Close()
+		if rowsErr != nil {
+			return errors.Wrap(rowsErr, "iterate neighbor chunks")
+		}
+	}
+	return nil
 }
 
 func (s *Service) validateInput(input ExtractInput) error {
@@ -322,6 +451,9 @@ func (s *Service) validateInput(input ExtractInput) error {
 	if input.TopK <= 0 || input.TopK > s.settings.TopKLimit {
 		return errors.Errorf("topK must be between 1 and %d", s.settings.TopKLimit)
 	}
+	if input.Neighbors < 0 || input.Neighbors > MaxNeighbors {
+		return errors.Errorf("neighbors must be between 0 and %d", MaxNeighbors)
+	}
 	return nil
 }
 
@@ -392,18 +524,49 @@ func legacyUserIDFromAPIKey(apiKey string) string {
 	return keyPrefix + "_" + hashPrefix[:7]
 }
 
-func (s *Service) ensureChunks(ctx context.Context, task *Task, input ExtractInput) error {
-	fragments := s.chunker.Split(input.Materials, s.settings.MaxChunkChars)
+// ingestResult describes the chunks stored for one materials payload.
+type ingestResult struct {
+	MaterialsHash string
+	Chunks        int
+	// Reused is set when no new embeddings were requested, either because the
+	// task already holds the materials or because rows were copied from
+	// another task of the same user.
+	Reused bool
+	// Spans holds the [start, end) character offsets of each chunk in the
+	// materials just ingested, indexed by chunk index. Stored chunk metadata
+	// keeps the offsets of whichever upload first stored the hash, so results
+	// for these materials should take their offsets from Spans.
+	Spans [][2]int
+}
+
+// chunkRow is one chunk with its embedding and lexical tokens, ready to insert.
+type chunkRow struct {
+	Index   int
+	Text    string
+	Cleaned string
+	Vector  pgvector.Vector
+	Tokens  []string
+	// Start and End locate the chunk in the materials being ingested.
+	Start int
+	End   int
+}
+
+// ensureChunks stores the chunks of materials under task unless rows with the
+// same materials hash already exist. Embeddings already computed for the same
+// materials in another task of the user are copied instead of recomputed.
+func (s *Service) ensureChunks(ctx context.Context, task *Task, apiKey, materials string) (ingestResult, error) {
+	fragments := s.chunker.Split(materials, s.settings.MaxChunkChars)
 	if len(fragments) == 0 {
-		return errors.New("no chunks generated from materials")
+		return ingestResult{}, errors.New("no chunks generated from materials")
 	}
 
 	hash := sha256.Sum256([]byte(strings.Join(cleanedFragments(fragments), "\n")))
 	materialsHash := hex.EncodeToString(hash[:])
+	spans := fragmentSpans(fragments)
 
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return errors.Wrap(err, "begin rag ingestion tx")
+		return ingestResult{}, errors.Wrap(err, "begin rag ingestion tx")
 	}
 	defer func() {
 		_ = tx.Rollback()
@@ -412,34 +575,144 @@ func (s *Service) ensureChunks(ctx context.Context, task *Task, input ExtractInp
 	countQuery := rebindPlaceholders(s.dialect, `SELECT COUNT(1) FROM mcp_rag_chunks WHERE task_id = ? AND materials_hash = ?`)
 	var count int64
 	if err := tx.QueryRowContext(ctx, countQuery, task.ID, materialsHash).Scan(&count); err != nil {
-		return errors.Wrap(err, "check existing chunks")
+		return ingestResult{}, errors.Wrap(err, "check existing chunks")
 	}
 	if count > 0 {
 		if commitErr := tx.Commit(); commitErr != nil {
-			return errors.Wrap(commitErr, "commit rag ingestion tx")
+			return ingestResult{}, errors.Wrap(commitErr, "commit rag ingestion tx")
 		}
-		return nil
+		return ingestResult{MaterialsHash: materialsHash, Chunks: int(count), Reused: true, Spans: spans}, nil
 	}
 
-	texts := make([]string, 0, len(fragments))
-	for _, fragment := range fragments {
-		texts = append(texts, fragment.Cleaned)
+	rows, err := s.reusableChunksTx(ctx, tx, task, materialsHash)
+	if err != nil {
+		return ingestResult{}, errors.WithStack(err)
+	}
+	reused := len(rows) > 0
+	if reused {
+		// Equal hashes mean equal cleaned fragments, but the raw materials may
+		// differ in whitespace, so the copied rows take the current input's offsets.
+		for idx := range rows {
+			if pos := rows[idx].Index; pos >= 0 && pos < len(fragments) && fragments[pos].Index == pos {
+				rows[idx].Start, rows[idx].End = fragments[pos].Start, fragments[pos].End
+			}
+		}
+	} else {
+		texts := make([]string, 0, len(fragments))
+		for _, fragment := range fragments {
+			texts = append(texts, fragment.Cleaned)
+		}
+		embeddings, err := s.embedder.EmbedTexts(ctx, apiKey, texts)
+		if err != nil {
+			return ingestResult{}, errors.Wrap(err, "embed materials")
+		}
+		if len(embeddings) != len(fragments) {
+			return ingestResult{}, errors.New("embedding count mismatch")
+		}
+
+		rows = make([]chunkRow, 0, len(fragments))
+		for idx, fragment := range fragments {
+			rows = append(rows, chunkRow{
+				Index:   fragment.Index,
+				Text:    fragment.Text,
+				Cleaned: fragment.Cleaned,
+				Vector:  embeddings[idx],
+				Tokens:  fragment.Tokens,
+				Start:   fragment.Start,
+				End:     fragment.End,
+			})
+		}
 	}
-	embeddings, err := s.embedder.EmbedTexts(ctx, input.APIKey, texts)
+
+	if err := s.insertChunkRowsTx(ctx, tx, task, materialsHash, rows); err != nil {
+		return ingestResult{}, errors.WithStack(err)
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return ingestResult{}, errors.Wrap(commitErr, "commit rag ingestion tx")
+	}
+
+	logger := s.loggerFromContext(ctx)
+	logger.Info("rag materials ingested",
+		zap.String("user_id", task.UserID),
+		zap.String("task_id", task.TaskID),
+		zap.Int("chunks", len(rows)),
+		zap.Bool("reused_embeddings", reused),
+	)
+
+	return ingestResult{MaterialsHash: materialsHash, Chunks: len(rows), Reused: reused, Spans: spans}, nil
+}
+
+// reusableChunksTx loads the chunks of another task owned by the same user that
+// were embedded from the same materials with the current embedding model.
+// It returns nil when there is nothing to reuse.
+func (s *Service) reusableChunksTx(ctx context.Context, tx *sql.Tx, task *Task, materialsHash string) ([]chunkRow, error) {
+	sourceQuery := rebindPlaceholders(s.dialect, `
+		SELECT c.task_id
+		FROM mcp_rag_chunks c
+		JOIN mcp_rag_tasks t ON t.id = c.task_id
+		JOIN mcp_rag_embeddings e ON e.chunk_id = c.id
+		WHERE t.user_id = ? AND c.materials_hash = ? AND c.task_id <> ? AND e.model = ?
+		LIMIT 1
+	`)
+	var sourceTaskID int64
+	err := tx.QueryRowContext(ctx, sourceQuery, task.UserID, materialsHash, task.ID, s.settings.EmbeddingModel).Scan(&sourceTaskID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "find reusable chunks")
+	}
+
+	rowsQuery := rebindPlaceholders(s.dialect, `
+		SELECT c.chunk_index, c.text, c.cleaned_text, e.vector, b.tokens
+		FROM mcp_rag_chunks c
+		JOIN mcp_rag_embeddings e ON e.chunk_id = c.id
+		LEFT JOIN mcp_rag_bm25 b ON b.chunk_id = c.id
+		WHERE c.task_id = ? AND c.materials_hash = ?
+		ORDER BY c.chunk_index ASC
+	`)
+	dbRows, err := tx.QueryContext(ctx, rowsQuery, sourceTaskID, materialsHash)
 	if err != nil {
-		return errors.Wrap(err, "embed materials")
+		return nil, errors.Wrap(err, "query reusable chunks")
 	}
-	if len(embeddings) != len(fragments) {
-		return errors.New("embedding count mismatch")
+	defer func() { _ = dbRows.Close() }()
+
+	var rows []chunkRow
+	for dbRows.Next() {
+		var row chunkRow
+		var vectorRaw any
+		var tokensRaw any
+		if scanErr := dbRows.Scan(&row.Index, &row.Text, &row.Cleaned, &vectorRaw, &tokensRaw); scanErr != nil {
+			return nil, errors.Wrap(scanErr, "scan reusable chunk")
+		}
+		if convErr := scanVectorValue(vectorRaw, &row.Vector); convErr != nil {
+			return nil, errors.Wrap(convErr, "decode reusable embedding")
+		}
+		row.Tokens = candidateChunk{TokenBytes: toJSONBytes(tokensRaw)}.tokens()
+		rows = append(rows, row)
+	}
+	if rowsErr := dbRows.Err(); rowsErr != nil {
+		return nil, errors.Wrap(rowsErr, "iterate reusable chunks")
 	}
 
+	return rows, nil
+}
+
+// insertChunkRowsTx inserts chunk, embedding and bm25 rows for task in the active transaction.
+func (s *Service) insertChunkRowsTx(ctx context.Context, tx *sql.Tx, task *Task, materialsHash string, rows []chunkRow) error {
 	now := s.clock()
-	for idx, fragment := range fragments {
-		metadataBytes, marshalErr := json.Marshal(map[string]string{
+	for _, row := range rows {
+		metadata := map[string]any{
 			"user_id": task.UserID,
 			"task_id": task.TaskID,
 			"hash":    materialsHash,
-		})
+		}
+		if row.End > row.Start {
+			metadata["start"] = row.Start
+			metadata["end"] = row.End
+		}
+		metadataBytes, marshalErr := json.Marshal(metadata)
 		if marshalErr != nil {
 			return errors.Wrap(marshalErr, "encode chunk metadata")
 		}
@@ -447,9 +720,9 @@ func (s *Service) ensureChunks(ctx context.Context, task *Task, input ExtractInp
 		chunk := Chunk{
 			TaskID:        task.ID,
 			MaterialsHash: materialsHash,
-			ChunkIndex:    fragment.Index,
-			Text:          fragment.Text,
-			CleanedText:   fragment.Cleaned,
+			ChunkIndex:    row.Index,
+			Text:          row.Text,
+			CleanedText:   row.Cleaned,
 			Metadata:      metadataBytes,
 			CreatedAt:     now,
 			UpdatedAt:     now,
@@ -460,7 +733,7 @@ func (s *Service) ensureChunks(ctx context.Context, task *Task, input ExtractInp
 
 		if err := s.insertEmbeddingTx(ctx, tx, Embedding{
 			ChunkID:   chunk.ID,
-			Vector:    embeddings[idx],
+			Vector:    row.Vector,
 			Model:     s.settings.EmbeddingModel,
 			CreatedAt: now,
 			UpdatedAt: now,
@@ -468,14 +741,18 @@ func (s *Service) ensureChunks(ctx context.Context, task *Task, input ExtractInp
 			return errors.Wrap(err, "insert embedding")
 		}
 
-		tokensJSON, marshalErr := json.Marshal(fragment.Tokens)
+		tokens := row.Tokens
+		if tokens == nil {
+			tokens = []string{}
+		}
+		tokensJSON, marshalErr := json.Marshal(tokens)
 		if marshalErr != nil {
 			return errors.Wrap(marshalErr, "encode tokens")
 		}
 		if err := s.insertBM25Tx(ctx, tx, BM25Row{
 			ChunkID:    chunk.ID,
 			Tokens:     tokensJSON,
-			TokenCount: len(fragment.Tokens),
+			TokenCount: len(tokens),
 			Tokenizer:  "builtin",
 			CreatedAt:  now,
 			UpdatedAt:  now,
@@ -483,18 +760,6 @@ func (s *Service) ensureChunks(ctx context.Context, task *Task, input ExtractInp
 			return errors.Wrap(err, "insert bm25 row")
 		}
 	}
-
-	if commitErr := tx.Commit(); commitErr != nil {
-		return errors.Wrap(commitErr, "commit rag ingestion tx")
-	}
-
-	logger := s.loggerFromContext(ctx)
-	logger.Info("rag materials ingested",
-		zap.String("user_id", task.UserID),
-		zap.String("task_id", task.TaskID),
-		zap.Int("chunks", len(fragments)),
-	)
-
 	return nil
 }
 
@@ -503,7 +768,7 @@ func (s *Service) fetchCandidates(ctx context.Context, taskID int64, queryVec pg
 	logger.Debug("fetching rag candidates", zap.Int64("task_id", taskID), zap.Int("limit", limit))
 	rows := make([]candidateChunk, 0, limit)
 	query := rebindPlaceholders(s.dialect, `
-		    SELECT c.id, c.text, c.cleaned_text, e.vector AS embedding, b.tokens
+		    SELECT c.id, c.text, c.cleaned_text, e.vector AS embedding, b.tokens, c.chunk_index, c.materials_hash, c.metadata
             FROM mcp_rag_chunks c
             JOIN mcp_rag_embeddings e ON e.chunk_id = c.id
             LEFT JOIN mcp_rag_bm25 b ON b.chunk_id = c.id
@@ -527,9 +792,11 @@ func (s *Service) fetchCandidates(ctx context.Context, taskID int64, queryVec pg
 		var row candidateChunk
 		var embeddingRaw any
 		var tokensRaw any
-		if scanErr := dbRows.Scan(&row.ChunkID, &row.Text, &row.Cleaned, &embeddingRaw, &tokensRaw); scanErr != nil {
+		var metadataRaw any
+		if scanErr := dbRows.Scan(&row.ChunkID, &row.Text, &row.Cleaned, &embeddingRaw, &tokensRaw, &row.ChunkIndex, &row.MaterialsHash, &metadataRaw); scanErr != nil {
 			return nil, errors.Wrap(scanErr, "scan rag candidate")
 		}
+		row.Metadata = toJSONBytes(metadataRaw)
 		if convErr := scanVectorValue(embeddingRaw, &row.Embedding); convErr != nil {
 			return nil, errors.Wrap(convErr, "decode candidate embedding")
 		}
@@ -544,7 +811,7 @@ func (s *Service) fetchCandidates(ctx context.Context, taskID int64, queryVec pg
 	return rows, nil
 }
 
-func (s *Service) rankAndSelect(candidates []candidateChunk, queryVec pgvector.Vector, queryTokens []string, topK int) []string {
+func (s *Service) rankAndSelect(candidates []candidateChunk, queryVec pgvector.Vector, queryTokens []string, topK int) []KeyInfo {
 	if len(candidates) == 0 {
 		return nil
 	}
@@ -554,34 +821,54 @@ func (s *Service) rankAndSelect(candidates []candidateChunk, queryVec pgvector.V
 		tokenSet[t] = struct{}{}
 	}
 
-	type scored struct {
-		text  string
-		score float64
-	}
-
-	scoredChunks := make([]scored, 0, len(candidates))
+	scoredChunks := make([]KeyInfo, 0, len(candidates))
 	for _, candidate := range candidates {
 		semantic := CosineSimilarity(queryVec, candidate.Embedding)
 		lexical := LexicalScore(candidate.tokens(), tokenSet)
-		score := s.settings.SemanticWeight*semantic + s.settings.LexicalWeight*lexical
-		scoredChunks = append(scoredChunks, scored{text: candidate.Text, score: score})
+		result := KeyInfo{
+			Text:          candidate.Text,
+			ChunkIndex:    candidate.ChunkIndex,
+			MaterialsHash: candidate.MaterialsHash,
+			Score:         s.settings.SemanticWeight*semantic + s.settings.LexicalWeight*lexical,
+			SemanticScore: semantic,
+			LexicalScore:  lexical,
+		}
+		result.Start, result.End, result.HasOffsets = candidate.offsets()
+		scoredChunks = append(scoredChunks, result)
 	}
 
-	sort.Slice(scoredChunks, func(i, j int) bool {
-		if scoredChunks[i].score == scoredChunks[j].score {
-			return len(scoredChunks[i].text) < len(scoredChunks[j].text)
+	sort.SliceStable(scoredChunks, func(i, j int) bool {
+		if scoredChunks[i].Score == scoredChunks[j].Score {
+			return len(scoredChunks[i].Text) < len(scoredChunks[j].Text)
 		}
-		return scoredChunks[i].score > scoredChunks[j].score
+		return scoredChunks[i].Score > scoredChunks[j].Score
 	})
 
 	if topK > len(scoredChunks) {
 		topK = len(scoredChunks)
 	}
-	contexts := make([]string, 0, topK)
-	for i := 0; i < topK; i++ {
-		contexts = append(contexts, scoredChunks[i].text)
+	return scoredChunks[:topK]
+}
+
+// fragmentSpans returns the character offsets of fragments indexed by chunk index.
+func fragmentSpans(fragments []ChunkFragment) [][2]int {
+	spans := make([][2]int, len(fragments))
+	for _, fragment := range fragments {
+		if fragment.Index >= 0 && fragment.Index < len(spans) {
+			spans[fragment.Index] = [2]int{fragment.Start, fragment.End}
+		}
 	}
-	return contexts
+	return spans
+}
+
+// setSpan sets the result offsets from spans, indexed by chunk index.
+// When ok is false or spans has no valid entry for the chunk, the offsets are cleared.
+func (k *KeyInfo) setSpan(spans [][2]int, ok bool) {
+	if ok && k.ChunkIndex >= 0 && k.ChunkIndex < len(spans) && spans[k.ChunkIndex][1] > spans[k.ChunkIndex][0] {
+		k.Start, k.End, k.HasOffsets = spans[k.ChunkIndex][0], spans[k.ChunkIndex][1], true
+		return
+	}
+	k.Start, k.End, k.HasOffsets = 0, 0, false
 }
 
 func cleanedFragments(fragments []ChunkFragment) []string {
@@ -593,11 +880,26 @@ func cleanedFragments(fragments []ChunkFragment) []string {
 }
 
 type candidateChunk struct {
-	ChunkID    int64
-	Text       string
-	Cleaned    string
-	Embedding  pgvector.Vector
-	TokenBytes []byte
+	ChunkID       int64
+	Text          string
+	Cleaned       string
+	Embedding     pgvector.Vector
+	TokenBytes    []byte
+	ChunkIndex    int
+	MaterialsHash string
+	Metadata      []byte
+}
+
+// offsets returns the character span recorded in the chunk metadata, if any.
+func (c candidateChunk) offsets() (int, int, bool) {
+	var meta struct {
+		Start *int `json:"start"`
+		End   *int `json:"end"`
+	}
+	if len(c.Metadata) == 0 || json.Unmarshal(c.Metadata, &meta) != nil || meta.Start == nil || meta.End == nil || *meta.End <= *meta.Start {
+		return 0, 0, false
+	}
+	return *meta.Start, *meta.End, true
 }
 
 func (c candidateChunk) tokens() []string {
@@ -92,8 +92,8 @@ func TestFetchCandidatesUsesVectorColumn(t *testing.T) {
 	queryVec := pgvector.NewVector([]float32{0.1, 0.2})
 
 	pattern := regexp.MustCompile(`SELECT c\.id, c\.text, c\.cleaned_text, e\.vector AS embedding, b\.tokens[\s\S]+ORDER BY e\.vector <=> \$[0-9]+ ASC[\s\S]+LIMIT \$[0-9]+`)
-	rows := sqlmock.NewRows([]string{"id", "text", "cleaned_text", "embedding", "tokens"}).
-		AddRow(int64(1), "chunk text", "chunk cleaned", queryVec, []byte(`["jwt"]`))
+	rows := sqlmock.NewRows([]string{"id", "text", "cleaned_text", "embedding", "tokens", "chunk_index", "materials_hash", "metadata"}).
+		AddRow(int64(1), "chunk text", "chunk cleaned", queryVec, []byte(`["jwt"]`), 2, "hash-1", []byte(`{"start":10,"end":20}`))
 
 	mock.ExpectQuery(pattern.String()).
 		WithArgs(int64(1), sqlmock.AnyArg(), 5).
@@ -105,6 +105,11 @@ func TestFetchCandidatesUsesVectorColumn(t *testing.T) {
 	require.Equal(t, "chunk text", candidates[0].Text)
 	require.Equal(t, queryVec, candidates[0].Embedding)
 	require.Equal(t, []string{"jwt"}, candidates[0].tokens())
+	require.Equal(t, 2, candidates[0].ChunkIndex)
+	start, end, ok := candidates[0].offsets()
+	require.True(t, ok)
+	require.Equal(t, 10, start)
+	require.Equal(t, 20, end)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -192,3 +197,74 @@ func TestDetectSQLDialectByDriverTypePGXStdlib(t *testing.T) {
 	dialect := detectSQLDialectByDriverType("*stdlib.Driver")
 	require.Equal(t, sqlDialectPostgres, dialect)
 }
+
+func TestRankAndSelectReportsProvenance(t *testing.T) {
+	t.Parallel()
+
+	svc := &Service{settings: Settings{SemanticWeight: 0.5, LexicalWeight: 0.5}}
+	queryVec := pgvector.NewVector([]float32{1, 0})
+	candidates := []candidateChunk{
+		{
+			Text:          "unrelated text",
+			Embedding:     pgvector.NewVector([]float32{0, 1}),
+			TokenBytes:    []byte(`["unrelated","text"]`),
+			ChunkIndex:    0,
+			MaterialsHash: "hash-1",
+		},
+		{
+			Text:          "jwt tokens expire",
+			Embedding:     pgvector.NewVector([]float32{1, 0}),
+			TokenBytes:    []byte(`["jwt","tokens","expire"]`),
+			ChunkIndex:    4,
+			MaterialsHash: "hash-1",
+			Metadata:      []byte(`{"start":120,"end":137}`),
+		},
+	}
+
+	results := svc.rankAndSelect(candidates, queryVec, []string{"jwt"}, 2)
+	require.Len(t, results, 2)
+	require.Equal(t, "jwt tokens expire", results[0].Text)
+	require.Equal(t, 4, results[0].ChunkIndex)
+	require.True(t, results[0].HasOffsets)
+	require.Equal(t, 120, results[0].Start)
+	require.Equal(t, 137, results[0].End)
+	require.InDelta(t, 1, results[0].SemanticScore, 1e-9)
+	require.InDelta(t, 1.0/3, results[0].LexicalScore, 1e-9)
+	require.InDelta(t, 0.5+0.5/3, results[0].Score, 1e-9)
+	require.False(t, results[1].HasOffsets)
+}
+
+func TestAttachNeighborsAndStoredOffsets(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:rag_neighbors?mode=memory&cache=shared")
+	require.NoError(t, err)
+	defer db.Close()
+
+	settings := LoadSettingsFromConfig()
+	settings.MaxChunkChars = 20
+	svc, err := NewService(db, &captureRAGEmbedder{}, ParagraphChunker{}, settings, log.Logger.Named("rag_neighbors_test"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	materials := "First sentence here. Second one is here. Third bit here. Fourth one."
+	task, err := svc.ensureTask(ctx, "user-1", "task-1", "sk")
+	require.NoError(t, err)
+	ingest, err := svc.ensureChunks(ctx, task, "sk", materials)
+	require.NoError(t, err)
+	require.Equal(t, 4, ingest.Chunks)
+
+	var metadata []byte
+	require.NoError(t, db.QueryRow(`SELECT metadata FROM mcp_rag_chunks WHERE task_id = ? AND chunk_index = 1`, task.ID).Scan(&metadata))
+	start, end, ok := candidateChunk{Metadata: metadata}.offsets()
+	require.True(t, ok)
+	require.Equal(t, "Second one is here.", string([]rune(materials)[start:end]))
+
+	results := []KeyInfo{
+		{ChunkIndex: 0, MaterialsHash: ingest.MaterialsHash},
+		{ChunkIndex: 2, MaterialsHash: ingest.MaterialsHash},
+	}
+	require.NoError(t, svc.attachNeighbors(ctx, task.ID, results, 1))
+	require.Empty(t, results[0].Before)
+	require.Equal(t, []string{"Second one is here"}, results[0].After)
+	require.Equal(t, []string{"Second one is here"}, results[1].Before)
+	require.Equal(t, []string{"Fourth one."}, results[1].After)
+}
@@ -15,6 +15,7 @@ type Settings struct {
 	TopKLimit        int
 	MaxMaterialsSize int
 	MaxChunkChars    int
+	MaxCorpora       int
 	SemanticWeight   float64
 	LexicalWeight    float64
 	EmbeddingModel   string
@@ -29,6 +30,7 @@ func LoadSettingsFromConfig() Settings {
 		TopKLimit:        intFromConfig("settings.mcp.tools.extract_key_info.top_k_limit", 20),
 		MaxMaterialsSize: intFromConfig("settings.mcp.tools.extract_key_info.max_materials_size", 10_000_000),
 		MaxChunkChars:    intFromConfig("settings.mcp.tools.extract_key_info.max_chunk_chars", 500),
+		MaxCorpora:       intFromConfig("settings.mcp.tools.extract_key_info.max_corpora", 50),
 		SemanticWeight:   floatFromConfig("settings.mcp.tools.extract_key_info.semantic_weight", 0.65),
 		LexicalWeight:    floatFromConfig("settings.mcp.tools.extract_key_info.lexical_weight", 0.35),
 		EmbeddingModel:   strings.TrimSpace(gconfig.S.GetString("settings.openai.embedding_model")),
@@ -50,6 +52,9 @@ func LoadSettingsFromConfig() Settings {
 	if cfg.MaxChunkChars <= 200 {
 		cfg.MaxChunkChars = 500
 	}
+	if cfg.MaxCorpora <= 0 {
+		cfg.MaxCorpora = 50
+	}
 	if cfg.SemanticWeight <= 0 {
 		cfg.SemanticWeight = 0.65
 	}
@@ -95,6 +95,9 @@ type Server struct {
 	askUser                   *tools.AskUserTool
 	getUserRequest            *tools.GetUserRequestTool
 	extractKeyInfo            *tools.ExtractKeyInfoTool
+	ragCorpusUpload           *tools.RAGCorpusUploadTool
+	ragCorpusList             *tools.RAGCorpusListTool
+	ragCorpusDelete           *tools.RAGCorpusDeleteTool
 	fileStat                  *tools.FileStatTool
 	fileRead                  *tools.FileReadTool
 	fileWrite                 *tools.FileWriteTool
@@ -102,11 +105,14 @@ type Server struct {
 	fileRename                *tools.FileRenameTool
 	fileList                  *tools.FileListTool
 	fileSearch                *tools.FileSearchTool
+	docOutline                *tools.DocOutlineTool
+	docReadNode               *tools.DocReadNodeTool
 	memoryBeforeTurn          *tools.MemoryBeforeTurnTool
 	memoryAfterTurn           *tools.MemoryAfterTurnTool
 	memoryRunMaintenance      *tools.MemoryRunMaintenanceTool
 	memoryListDirWithAbstract *tools.MemoryListDirWithAbstractTool
 	mcpPipe                   *tools.MCPPipeTool
+	pipeRun                   *tools.PipeRunTool
 	findTool                  *tools.FindToolTool
 	callLogger                callRecorder
 	holdManager               *userrequests.HoldManager
@@ -122,6 +128,7 @@ type Server struct {
 // rdb enables the web_fetch tool when not nil and toolsSettings.WebFetchEnabled is true.
 // userRequestService enables the get_user_request tool when not nil and toolsSettings.GetUserRequestEnabled is true.
 // ragService enables the extract_key_info tool when not nil and toolsSettings.ExtractKeyInfoEnabled is true.
+// ragService also enables the rag_corpus_* tools when toolsSettings.RAGCorpusEnabled is true.
 // callLogger records tool invocations for auditing when provided.
 // logger overrides the default logger when provided.
 // It returns the configured server or an error if no capability is available.
@@ -162,7 +169,12 @@ func NewServer(
 	billingReporter := externalBillingReporter(oneapi.CheckUserExternalBilling)
 	trackedBillingReporter := func(ctx context.Context, apiKey string, price oneapi.Price, toolName string) error {
 		markBillingAttempted(ctx)
-		return billingReporter(ctx, apiKey, price, toolName)
+		if err := billingReporter(ctx, apiKey, price, toolName); err != nil {
+			return err
+		}
+		// Attribute the charge to the enclosing mcp_pipe step when tracing.
+		tools.RecordPipeStepCost(ctx, int64(price.Int()))
+		return nil
 	}
 
 	streamable := srv.NewStreamableHTTPServer(
@@ -305,6 +317,40 @@ func NewServer(
 		serverLogger.Info("extract_key_info tool disabled by configuration")
 	}
 
+	if ragService != nil && toolsSettings.RAGCorpusEnabled {
+		uploadTool, err := tools.NewRAGCorpusUploadTool(
+			ragService,
+			serverLogger.Named("rag_corpus_upload"),
+			headerProvider,
+			trackedBillingReporter,
+			ragSettings,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "init rag_corpus_upload tool")
+		}
+		if fileService != nil && toolsSettings.FileIOEnabled {
+			uploadTool.WithFileReader(fileService)
+		}
+		s.ragCorpusUpload = uploadTool
+		s.registerTool(mcpServer, uploadTool.Definition(), s.handleRAGCorpusUpload)
+
+		listTool, err := tools.NewRAGCorpusListTool(ragService, serverLogger.Named("rag_corpus_list"), headerProvider)
+		if err != nil {
+			return nil, errors.Wrap(err, "init rag_corpus_list tool")
+		}
+		s.ragCorpusList = listTool
+		s.registerTool(mcpServer, listTool.Definition(), s.handleRAGCorpusList)
+
+		deleteTool, err := tools.NewRAGCorpusDeleteTool(ragService, serverLogger.Named("rag_corpus_delete"), headerProvider)
+		if err != nil {
+			return nil, errors.Wrap(err, "init rag_corpus_delete tool")
+		}
+		s.ragCorpusDelete = deleteTool
+		s.registerTool(mcpServer, deleteTool.Definition(), s.handleRAGCorpusDelete)
+	} else if ragService != nil && !toolsSettings.RAGCorpusEnabled {
+		serverLogger.Info("rag_corpus tools disabled by configuration")
+	}
+
 	if fileService != nil && toolsSettings.FileIOEnabled {
 		fileStatTool, err := tools.NewFileStatTool(fileService)
 		if err != nil {
@@ -354,6 +400,22 @@ func NewServer(
 		}
 		s.fileSearch = fileSearchTool
 		s.registerTool(mcpServer, fileSearchTool.Definition(), s.handleFileSearch)
+
+		if toolsSettings.DocTreeEnabled && tools.SupportsDocTree(fileService) {
+			docOutlineTool, err := tools.NewDocOutlineTool(fileService)
+			if err != nil {
+				return nil, errors.Wrap(err, "init doc_outline tool")
+			}
+			s.docOutline = docOutlineTool
+			s.registerTool(mcpServer, docOutlineTool.Definition(), s.handleDocOutline)
+
+			docReadNodeTool, err := tools.NewDocReadNodeTool(fileService)
+			if err != nil {
+				return nil, errors.Wrap(err, "init doc_read_node tool")
+			}
+			s.docReadNode = docReadNodeTool
+			s.registerTool(mcpServer, docReadNodeTool.Definition(), s.handleDocReadNode)
+		}
 	} else if fileService != nil && !toolsSettings.FileIOEnabled {
 		serverLogger.Info("file tools disabled by configuration")
 	}
@@ -398,6 +460,10 @@ func NewServer(
 				if toolName == "mcp_pipe" {
 					return mcp.NewToolResultError("mcp_pipe cannot invoke itself"), nil
 				}
+				// A saved pipeline could otherwise run itself through pipe_run.
+				if toolName == "pipe_run" {
+					return mcp.NewToolResultError("mcp_pipe cannot invoke pipe_run"), nil
+				}
 
 				handler, ok := s.toolHandlers[toolName]
 				if !ok {
@@ -412,8 +478,32 @@ func NewServer(
 		if err != nil {
 			return nil, errors.Wrap(err, "init mcp_pipe tool")
 		}
+		pipeTool.WithToolLookup(func(toolName string) bool {
+			if toolName == "mcp_pipe" || toolName == "pipe_run" {
+				return false
+			}
+			_, ok := s.toolHandlers[toolName]
+			return ok
+		})
 		s.mcpPipe = pipeTool
 		s.registerTool(mcpServer, pipeTool.Definition(), s.handleMCPPipe)
+
+		if userRequestService != nil && toolsSettings.PipeRunEnabled {
+			pipeRunTool, err := tools.NewPipeRunTool(
+				userRequestService,
+				pipeTool,
+				serverLogger.Named("pipe_run"),
+				headerProvider,
+				askuser.ParseAuthorizationContext,
+			)
+			if err != nil {
+				return nil, errors.Wrap(err, "init pipe_run tool")
+			}
+			s.pipeRun = pipeRunTool
+			s.registerTool(mcpServer, pipeRunTool.Definition(), s.handlePipeRun)
+		} else if userRequestService != nil && !toolsSettings.PipeRunEnabled {
+			serverLogger.Info("pipe_run tool disabled by configuration")
+		}
 	} else {
 		serverLogger.Info("mcp_pipe tool disabled by configuration")
 	}
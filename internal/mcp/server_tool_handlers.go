@@ -332,6 +332,33 @@ func (s *Server) handleExtractKeyInfo(ctx context.Context, req mcp.CallToolReque
 	return s.executeToolHandler(ctx, req, "extract_key_info", oneapi.PriceExtractKeyInfo, "extract_key_info tool is not available", exec)
 }
 
+func (s *Server) handleRAGCorpusUpload(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var exec toolExecutor
+	if s.ragCorpusUpload != nil {
+		exec = s.ragCorpusUpload.Handle
+	}
+
+	return s.executeToolHandler(ctx, req, "rag_corpus_upload", oneapi.PriceExtractKeyInfo, "rag_corpus_upload tool is not available", exec)
+}
+
+func (s *Server) handleRAGCorpusList(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var exec toolExecutor
+	if s.ragCorpusList != nil {
+		exec = s.ragCorpusList.Handle
+	}
+
+	return s.executeToolHandler(ctx, req, "rag_corpus_list", 0, "rag_corpus_list tool is not available", exec)
+}
+
+func (s *Server) handleRAGCorpusDelete(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var exec toolExecutor
+	if s.ragCorpusDelete != nil {
+		exec = s.ragCorpusDelete.Handle
+	}
+
+	return s.executeToolHandler(ctx, req, "rag_corpus_delete", 0, "rag_corpus_delete tool is not available", exec)
+}
+
 func (s *Server) handleFileStat(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var exec toolExecutor
 	if s.fileStat != nil {
@@ -395,6 +422,24 @@ func (s *Server) handleFileSearch(ctx context.Context, req mcp.CallToolRequest)
 	return s.executeToolHandler(ctx, req, "file_search", 0, "file_search tool is not available", exec)
 }
 
+func (s *Server) handleDocOutline(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var exec toolExecutor
+	if s.docOutline != nil {
+		exec = s.docOutline.Handle
+	}
+
+	return s.executeToolHandler(ctx, req, "doc_outline", 0, "doc_outline tool is not available", exec)
+}
+
+func (s *Server) handleDocReadNode(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var exec toolExecutor
+	if s.docReadNode != nil {
+		exec = s.docReadNode.Handle
+	}
+
+	return s.executeToolHandler(ctx, req, "doc_read_node", 0, "doc_read_node tool is not available", exec)
+}
+
 // handleMCPPipe executes the mcp_pipe MCP tool, auditing the invocation via the call logger.
 func (s *Server) handleMCPPipe(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var exec toolExecutor
@@ -405,6 +450,16 @@ func (s *Server) handleMCPPipe(ctx context.Context, req mcp.CallToolRequest) (*m
 	return s.executeToolHandler(ctx, req, "mcp_pipe", 0, "mcp_pipe tool is not available", exec)
 }
 
+// handlePipeRun executes the pipe_run MCP tool, auditing the invocation via the call logger.
+func (s *Server) handlePipeRun(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var exec toolExecutor
+	if s.pipeRun != nil {
+		exec = s.pipeRun.Handle
+	}
+
+	return s.executeToolHandler(ctx, req, "pipe_run", 0, "pipe_run tool is not available", exec)
+}
+
 // handleFindTool executes the find_tool MCP tool, auditing the invocation via the call logger.
 func (s *Server) handleFindTool(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var exec toolExecutor
@@ -12,9 +12,12 @@ type ToolsSettings struct {
 	AskUserEnabled        bool
 	GetUserRequestEnabled bool
 	ExtractKeyInfoEnabled bool
+	RAGCorpusEnabled      bool
 	FileIOEnabled         bool
+	DocTreeEnabled        bool
 	MemoryEnabled         bool
 	MCPPipeEnabled        bool
+	PipeRunEnabled        bool
 	FindToolEnabled       bool
 }
 
@@ -27,9 +30,12 @@ func LoadToolsSettingsFromConfig() ToolsSettings {
 		AskUserEnabled:        boolFromConfig("settings.mcp.tools.ask_user.enabled", true),
 		GetUserRequestEnabled: boolFromConfig("settings.mcp.tools.get_user_request.enabled", true),
 		ExtractKeyInfoEnabled: boolFromConfig("settings.mcp.tools.extract_key_info.enabled", true),
+		RAGCorpusEnabled:      boolFromConfig("settings.mcp.tools.rag_corpus.enabled", true),
 		FileIOEnabled:         boolFromConfig("settings.mcp.tools.file_io.enabled", true),
+		DocTreeEnabled:        boolFromConfig("settings.mcp.tools.doc_tree.enabled", true),
 		MemoryEnabled:         boolFromConfig("settings.mcp.tools.memory.enabled", false),
 		MCPPipeEnabled:        boolFromConfig("settings.mcp.tools.mcp_pipe.enabled", true),
+		PipeRunEnabled:        boolFromConfig("settings.mcp.tools.pipe_run.enabled", true),
 		FindToolEnabled:       boolFromConfig("settings.mcp.tools.find_tool.enabled", true),
 	}
 }
@@ -0,0 +1,183 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugins/pageindex"
+)
+
+// maxDocReadNodePages bounds the max_pages argument of doc_read_node.
+const maxDocReadNodePages = 50
+
+// DocTreeReader is implemented by memory plugins that keep a browsable
+// outline of indexed documents (pageindex).
+type DocTreeReader interface {
+	Outline(ctx context.Context, auth files.AuthContext, project, path string) (pageindex.StructureView, error)
+	ReadNode(ctx context.Context, auth files.AuthContext, project, path, nodeID string, maxPages int) (pageindex.NodeContent, error)
+}
+
+// pluginResolver is the subset of *mcpplugin.Manager used to find the pageindex plugin.
+type pluginResolver interface {
+	Resolve(ctx context.Context, auth files.AuthContext, project string, override string) (mcpplugin.Plugin, error)
+	AvailablePlugins() []string
+}
+
+// SupportsDocTree reports whether svc can serve doc_outline and doc_read_node.
+func SupportsDocTree(svc FileService) bool {
+	if _, ok := svc.(DocTreeReader); ok {
+		return true
+	}
+	if resolver, ok := svc.(pluginResolver); ok {
+		return containsString(resolver.AvailablePlugins(), mcpplugin.DefaultPluginPageIndex)
+	}
+	return false
+}
+
+// resolveDocTreeReader returns the pageindex plugin behind svc.
+func resolveDocTreeReader(ctx context.Context, svc FileService, auth files.AuthContext, project string) (DocTreeReader, error) {
+	if reader, ok := svc.(DocTreeReader); ok {
+		return reader, nil
+	}
+	resolver, ok := svc.(pluginResolver)
+	if !ok {
+		return nil, files.NewError(files.ErrCodeInvalidArgument, "document outlines are not supported by this memory backend", false)
+	}
+	resolved, err := resolver.Resolve(ctx, auth, project, mcpplugin.DefaultPluginPageIndex)
+	if err != nil {
+		return nil, err
+	}
+	reader, ok := mcpplugin.As[DocTreeReader](resolved)
+	if !ok {
+		return nil, files.NewError(files.ErrCodeInvalidArgument, "document outlines are not supported by this memory backend", false)
+	}
+	return reader, nil
+}
+
+// DocOutlineTool implements the doc_outline MCP tool.
+type DocOutlineTool struct {
+	svc FileService
+}
+
+// NewDocOutlineTool constructs a DocOutlineTool.
+func NewDocOutlineTool(svc FileService) (*DocOutlineTool, error) {
+	if svc == nil {
+		return nil, files.NewError(files.ErrCodeSearchBackend, "file service is required", false)
+	}
+	return &DocOutlineTool{svc: svc}, nil
+}
+
+// Definition returns the MCP metadata for doc_outline.
+func (t *DocOutlineTool) Definition() mcp.Tool {
+	return mcp.NewTool(
+		"doc_outline",
+		mcp.WithDescription("Return the table of contents that pageindex built for a long document (PDF, Markdown, HTML, DOCX, plain text), with node IDs, titles, page ranges and summaries. Use doc_read_node to read a node."),
+		mcp.WithString("project", mcp.Required(), mcp.Description("Target project namespace.")),
+		mcp.WithString("path", mcp.Required(), mcp.Description("Path of an indexed document.")),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+	)
+}
+
+// Handle executes the doc_outline tool logic.
+func (t *DocOutlineTool) Handle(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project, err := req.RequireString("project")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	path, err := req.RequireString("path")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	auth, ok := fileAuthFromContext(ctx)
+	if !ok {
+		return fileToolErrorResult(files.ErrCodePermissionDenied, "missing authorization", false), nil
+	}
+
+	reader, err := resolveDocTreeReader(ctx, t.svc, auth, project)
+	if err != nil {
+		return fileToolErrorFromErr(err), nil //nolint:nilerr // error returned as tool result text
+	}
+	view, err := reader.Outline(ctx, auth, project, path)
+	if err != nil {
+		return fileToolErrorFromErr(err), nil //nolint:nilerr // error returned as tool result text
+	}
+	toolResult, encodeErr := mcp.NewToolResultJSON(view)
+	if encodeErr != nil {
+		return fileToolErrorResult(files.ErrCodeSearchBackend, "failed to encode response", true), nil //nolint:nilerr // error returned as tool result text
+	}
+	return toolResult, nil
+}
+
+// DocReadNodeTool implements the doc_read_node MCP tool.
+type DocReadNodeTool struct {
+	svc FileService
+}
+
+// NewDocReadNodeTool constructs a DocReadNodeTool.
+func NewDocReadNodeTool(svc FileService) (*DocReadNodeTool, error) {
+	if svc == nil {
+		return nil, files.NewError(files.ErrCodeSearchBackend, "file service is required", false)
+	}
+	return &DocReadNodeTool{svc: svc}, nil
+}
+
+// Definition returns the MCP metadata for doc_read_node.
+func (t *DocReadNodeTool) Definition() mcp.Tool {
+	return mcp.NewTool(
+		"doc_read_node",
+		mcp.WithDescription("Read the pages covered by one node of a document outline returned by doc_outline. Heading nodes include their nested sections."),
+		mcp.WithString("project", mcp.Required(), mcp.Description("Target project namespace.")),
+		mcp.WithString("path", mcp.Required(), mcp.Description("Path of an indexed document.")),
+		mcp.WithString("node_id", mcp.Required(), mcp.Description("Node ID from doc_outline.")),
+		mcp.WithNumber("max_pages", mcp.Description(fmt.Sprintf("Maximum number of pages to return (1-%d); defaults to 10. The response sets truncated=true when the node has more.", maxDocReadNodePages))),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+	)
+}
+
+// Handle executes the doc_read_node tool logic.
+func (t *DocReadNodeTool) Handle(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project, err := req.RequireString("project")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	path, err := req.RequireString("path")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	nodeID, err := req.RequireString("node_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	maxPages := 0
+	if raw, ok := req.GetArguments()["max_pages"]; ok {
+		parsed, ok := toInt(raw)
+		if !ok || parsed < 1 || parsed > maxDocReadNodePages {
+			return fileToolErrorResult(files.ErrCodeInvalidArgument, fmt.Sprintf("max_pages must be between 1 and %d", maxDocReadNodePages), false), nil
+		}
+		maxPages = parsed
+	}
+	auth, ok := fileAuthFromContext(ctx)
+	if !ok {
+		return fileToolErrorResult(files.ErrCodePermissionDenied, "missing authorization", false), nil
+	}
+
+	reader, err := resolveDocTreeReader(ctx, t.svc, auth, project)
+	if err != nil {
+		return fileToolErrorFromErr(err), nil //nolint:nilerr // error returned as tool result text
+	}
+	content, err := reader.ReadNode(ctx, auth, project, path, nodeID, maxPages)
+	if err != nil {
+		return fileToolErrorFromErr(err), nil //nolint:nilerr // error returned as tool result text
+	}
+	toolResult, encodeErr := mcp.NewToolResultJSON(content)
+	if encodeErr != nil {
+		return fileToolErrorResult(files.ErrCodeSearchBackend, "failed to encode response", true), nil //nolint:nilerr // error returned as tool result text
+	}
+	return toolResult, nil
+}
@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpplugin "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugin"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/memory/plugins/pageindex"
+)
+
+// docTreeService is a pageindex stand-in that serves a canned outline.
+type docTreeService struct {
+	*pluginBehaviorService
+	lastMaxPages int
+}
+
+// Outline returns a single-node outline for the requested path.
+func (s *docTreeService) Outline(_ context.Context, _ files.AuthContext, _, path string) (pageindex.StructureView, error) {
+	return pageindex.StructureView{DocID: path, Outline: []*pageindex.Node{{NodeID: "0001", Title: "Intro", Summary: "overview"}}}, nil
+}
+
+// ReadNode records the page limit and returns one chunk.
+func (s *docTreeService) ReadNode(_ context.Context, _ files.AuthContext, _, path, nodeID string, maxPages int) (pageindex.NodeContent, error) {
+	s.lastMaxPages = maxPages
+	if nodeID != "0001" {
+		return pageindex.NodeContent{}, files.NewError(files.ErrCodeNotFound, "node not found", false)
+	}
+	return pageindex.NodeContent{NodeID: nodeID, Chunks: []pageindex.Chunk{{FilePath: path, Page: 1, Content: "hello"}}, TotalPages: 1}, nil
+}
+
+// TestDocTreeToolsRouteToPageIndex verifies both tools resolve the pageindex plugin through the manager.
+func TestDocTreeToolsRouteToPageIndex(t *testing.T) {
+	t.Parallel()
+
+	ragSvc := &pluginBehaviorService{behaviorFileService: &behaviorFileService{}, name: mcpplugin.DefaultPluginRAG}
+	treeSvc := &docTreeService{pluginBehaviorService: &pluginBehaviorService{behaviorFileService: &behaviorFileService{}, name: mcpplugin.DefaultPluginPageIndex}}
+	manager, err := mcpplugin.NewManager(mcpplugin.DefaultPluginRAG, ragSvc, treeSvc)
+	require.NoError(t, err)
+	require.True(t, SupportsDocTree(manager))
+
+	outlineTool, err := NewDocOutlineTool(manager)
+	require.NoError(t, err)
+	result, err := outlineTool.Handle(behaviorAuthCtx(), behaviorReq(map[string]any{"project": "demo", "path": "/manual.pdf"}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	payload := behaviorJSONContent(t, result)
+	require.Equal(t, "/manual.pdf", payload["doc_id"])
+	outline, ok := payload["outline"].([]any)
+	require.True(t, ok)
+	require.Equal(t, "overview", outline[0].(map[string]any)["summary"])
+
+	readTool, err := NewDocReadNodeTool(manager)
+	require.NoError(t, err)
+	result, err = readTool.Handle(behaviorAuthCtx(), behaviorReq(map[string]any{"project": "demo", "path": "/manual.pdf", "node_id": "0001", "max_pages": 3}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Equal(t, 3, treeSvc.lastMaxPages)
+	chunks, ok := behaviorJSONContent(t, result)["chunks"].([]any)
+	require.True(t, ok)
+	require.Equal(t, "hello", chunks[0].(map[string]any)["content"])
+
+	result, err = readTool.Handle(behaviorAuthCtx(), behaviorReq(map[string]any{"project": "demo", "path": "/manual.pdf", "node_id": "0009"}))
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Equal(t, string(files.ErrCodeNotFound), behaviorJSONContent(t, result)["code"])
+
+	result, err = readTool.Handle(behaviorAuthCtx(), behaviorReq(map[string]any{"project": "demo", "path": "/manual.pdf", "node_id": "0001", "max_pages": 51}))
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Equal(t, string(files.ErrCodeInvalidArgument), behaviorJSONContent(t, result)["code"])
+}
+
+// TestDocTreeToolsThroughShadowWrapper verifies the tools reach pageindex when it is wrapped for shadow replay.
+func TestDocTreeToolsThroughShadowWrapper(t *testing.T) {
+	t.Parallel()
+
+	ragSvc := &pluginBehaviorService{behaviorFileService: &behaviorFileService{}, name: mcpplugin.DefaultPluginRAG}
+	treeSvc := &docTreeService{pluginBehaviorService: &pluginBehaviorService{behaviorFileService: &behaviorFileService{}, name: mcpplugin.DefaultPluginPageIndex}}
+	shadowSvc := &pluginBehaviorService{behaviorFileService: &behaviorFileService{}, name: "candidate"}
+	wrapped, err := mcpplugin.NewShadowPlugin(mcpplugin.ShadowConfig{
+		Live:     treeSvc,
+		Shadow:   shadowSvc,
+		Recorder: mcpplugin.NewMemRecorder(),
+		Name:     mcpplugin.DefaultPluginPageIndex,
+	})
+	require.NoError(t, err)
+	manager, err := mcpplugin.NewManager(mcpplugin.DefaultPluginRAG, ragSvc, wrapped)
+	require.NoError(t, err)
+
+	outlineTool, err := NewDocOutlineTool(manager)
+	require.NoError(t, err)
+	result, err := outlineTool.Handle(behaviorAuthCtx(), behaviorReq(map[string]any{"project": "demo", "path": "/manual.pdf"}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Equal(t, "/manual.pdf", behaviorJSONContent(t, result)["doc_id"])
+}
+
+// TestDocTreeToolsWithoutPageIndex verifies the tools report a correctable error when pageindex is absent.
+func TestDocTreeToolsWithoutPageIndex(t *testing.T) {
+	t.Parallel()
+
+	ragSvc := &pluginBehaviorService{behaviorFileService: &behaviorFileService{}, name: mcpplugin.DefaultPluginRAG}
+	manager, err := mcpplugin.NewManager(mcpplugin.DefaultPluginRAG, ragSvc)
+	require.NoError(t, err)
+	require.False(t, SupportsDocTree(manager))
+	require.False(t, SupportsDocTree(&behaviorFileService{}))
+
+	tool, err := NewDocOutlineTool(manager)
+	require.NoError(t, err)
+	result, err := tool.Handle(behaviorAuthCtx(), behaviorReq(map[string]any{"project": "demo", "path": "/manual.pdf"}))
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	payload := behaviorJSONContent(t, result)
+	require.Equal(t, string(files.ErrCodeInvalidArgument), payload["code"])
+	require.Equal(t, []any{mcpplugin.DefaultPluginRAG}, payload["available_plugins"])
+}
@@ -18,7 +18,8 @@ import (
 
 // KeyInfoService defines the subset of rag.Service methods required by the tool.
 type KeyInfoService interface {
-	ExtractKeyInfo(context.Context, rag.ExtractInput) ([]string, error)
+	ExtractKeyInfo(context.Context, rag.ExtractInput) ([]rag.KeyInfo, error)
+	QueryCorpus(context.Context, rag.CorpusQueryInput) ([]rag.KeyInfo, error)
 }
 
 // ExtractKeyInfoTool exposes the extract_key_info MCP capability.
@@ -61,7 +62,7 @@ func NewExtractKeyInfoTool(service KeyInfoService, logger logSDK.Logger, headerP
 func (t *ExtractKeyInfoTool) Definition() mcp.Tool {
 	return mcp.NewTool(
 		"extract_key_info",
-		mcp.WithDescription("Extract the most relevant context chunks for a query from provided materials using RAG (retrieval-augmented generation). Splits text into chunks, embeds them, and returns the top-K most relevant passages."),
+		mcp.WithDescription("Extract the most relevant context chunks for a query from provided materials using RAG (retrieval-augmented generation). Splits text into chunks, embeds them, and returns the top-K most relevant passages. Pass corpus instead of materials to query a persistent corpus built with rag_corpus_upload. Each result carries its chunk index, character offsets into the materials, scores and optional neighboring chunks for citation."),
 		mcp.WithString(
 			"query",
 			mcp.Required(),
@@ -69,13 +70,20 @@ func (t *ExtractKeyInfoTool) Definition() mcp.Tool {
 		),
 		mcp.WithString(
 			"materials",
-			mcp.Required(),
-			mcp.Description("Source text to scan for relevant information."),
+			mcp.Description("Source text to scan for relevant information. Required unless corpus is set."),
+		),
+		mcp.WithString(
+			"corpus",
+			mcp.Description("Name of a persistent corpus to query instead of materials."),
 		),
 		mcp.WithNumber(
 			"top_k",
 			mcp.Description("Maximum number of contexts to return."),
 		),
+		mcp.WithNumber(
+			"neighbors",
+			mcp.Description(fmt.Sprintf("Number of adjacent chunks (0-%d) to include before and after each result. Defaults to 0.", rag.MaxNeighbors)),
+		),
 	)
 }
 
@@ -85,9 +93,12 @@ func (t *ExtractKeyInfoTool) Handle(ctx context.Context, req mcp.CallToolRequest
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	materials, err := req.RequireString("materials")
-	if err != nil {
-		return mcp.NewToolResultError(err.Error()), nil
+	corpus := strings.TrimSpace(req.GetString("corpus", ""))
+	materials := req.GetString("materials", "")
+	if corpus == "" {
+		if materials, err = req.RequireString("materials"); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
 	}
 
 	query = strings.TrimSpace(query)
@@ -95,7 +106,14 @@ func (t *ExtractKeyInfoTool) Handle(ctx context.Context, req mcp.CallToolRequest
 	if query == "" {
 		return mcp.NewToolResultError("query cannot be empty"), nil
 	}
-	if materials == "" {
+	if corpus != "" {
+		if materials != "" {
+			return mcp.NewToolResultError("provide either materials or corpus, not both; add materials with rag_corpus_upload"), nil
+		}
+		if err := rag.ValidateCorpusName(corpus); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	} else if materials == "" {
 		return mcp.NewToolResultError("materials cannot be empty"), nil
 	}
 	if len(materials) > t.settings.MaxMaterialsSize {
@@ -116,6 +134,15 @@ func (t *ExtractKeyInfoTool) Handle(ctx context.Context, req mcp.CallToolRequest
 		return mcp.NewToolResultError(fmt.Sprintf("top_k must be between 1 and %d", t.settings.TopKLimit)), nil
 	}
 
+	neighbors := 0
+	if raw, ok := req.GetArguments()["neighbors"]; ok {
+		parsed, ok := toInt(raw)
+		if !ok || parsed < 0 || parsed > rag.MaxNeighbors {
+			return mcp.NewToolResultError(fmt.Sprintf("neighbors must be between 0 and %d", rag.MaxNeighbors)), nil
+		}
+		neighbors = parsed
+	}
+
 	header := t.headerProvider(ctx)
 	authCtx, err := mcpauth.FromContextOrHeader(ctx, header)
 	if err != nil {
@@ -128,6 +155,25 @@ func (t *ExtractKeyInfoTool) Handle(ctx context.Context, req mcp.CallToolRequest
 		return mcp.NewToolResultError(fmt.Sprintf("billing check failed: %v", err)), nil
 	}
 
+	if corpus != "" {
+		results, err := t.service.QueryCorpus(ctx, rag.CorpusQueryInput{
+			UserID:    authCtx.UserID,
+			APIKey:    authCtx.APIKey,
+			Corpus:    corpus,
+			Query:     query,
+			TopK:      topK,
+			Neighbors: neighbors,
+		})
+		if err != nil {
+			if errors.Is(err, rag.ErrCorpusNotFound) {
+				return mcp.NewToolResultError(fmt.Sprintf("corpus %q not found", corpus)), nil
+			}
+			t.logger.Error("extract_key_info corpus query failed", zap.Error(err), zap.String("user_id", authCtx.UserID), zap.String("corpus", corpus))
+			return mcp.NewToolResultError("failed to extract key information"), nil
+		}
+		return t.keyInfoResult(results, neighbors > 0), nil
+	}
+
 	input := rag.ExtractInput{
 		UserID:    authCtx.UserID,
 		TaskID:    taskID,
@@ -135,25 +181,67 @@ func (t *ExtractKeyInfoTool) Handle(ctx context.Context, req mcp.CallToolRequest
 		Query:     query,
 		Materials: materials,
 		TopK:      topK,
+		Neighbors: neighbors,
 	}
 
-	contexts, err := t.service.ExtractKeyInfo(ctx, input)
+	results, err := t.service.ExtractKeyInfo(ctx, input)
 	if err != nil {
 		t.logger.Error("extract_key_info failed", zap.Error(err), zap.String("user_id", authCtx.UserID), zap.String("task_id", taskID))
 		return mcp.NewToolResultError("failed to extract key information"), nil
 	}
 
+	return t.keyInfoResult(results, neighbors > 0), nil
+}
+
+// keyInfoResult encodes the retrieved chunks as the tool response.
+// contexts keeps the plain snippets for existing callers; results adds provenance.
+func (t *ExtractKeyInfoTool) keyInfoResult(results []rag.KeyInfo, withNeighbors bool) *mcp.CallToolResult {
+	contexts := make([]string, 0, len(results))
+	items := make([]map[string]any, 0, len(results))
+	for _, info := range results {
+		contexts = append(contexts, info.Text)
+		item := map[string]any{
+			"text":           info.Text,
+			"chunk_index":    info.ChunkIndex,
+			"materials_hash": info.MaterialsHash,
+			"score":          info.Score,
+			"semantic_score": info.SemanticScore,
+			"lexical_score":  info.LexicalScore,
+		}
+		if info.HasOffsets {
+			item["start"] = info.Start
+			item["end"] = info.End
+		}
+		if len(info.Sources) > 0 {
+			item["sources"] = info.Sources
+		}
+		if withNeighbors {
+			item["before"] = nonNilStrings(info.Before)
+			item["after"] = nonNilStrings(info.After)
+		}
+		items = append(items, item)
+	}
+
 	payload := map[string]any{
 		"contexts": contexts,
+		"results":  items,
 	}
 
 	result, err := mcp.NewToolResultJSON(payload)
 	if err != nil {
 		t.logger.Error("extract_key_info encode response", zap.Error(err))
-		return mcp.NewToolResultError("failed to encode extract_key_info response"), nil
+		return mcp.NewToolResultError("failed to encode extract_key_info response")
 	}
 
-	return result, nil
+	return result
+}
+
+// nonNilStrings keeps empty lists encoded as [] rather than null.
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
 }
 
 func findTopK(arguments any) (int, bool) {
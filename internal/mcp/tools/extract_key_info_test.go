@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	errors "github.com/Laisky/errors/v2"
 	mcp "github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/require"
 
@@ -13,17 +14,38 @@ import (
 )
 
 type stubKeyInfoService struct {
-	input    rag.ExtractInput
-	contexts []string
-	err      error
+	input       rag.ExtractInput
+	corpusInput rag.CorpusQueryInput
+	contexts    []string
+	results     []rag.KeyInfo
+	err         error
 }
 
-func (s *stubKeyInfoService) ExtractKeyInfo(ctx context.Context, input rag.ExtractInput) ([]string, error) {
+func (s *stubKeyInfoService) keyInfo() []rag.KeyInfo {
+	if s.results != nil {
+		return s.results
+	}
+	results := make([]rag.KeyInfo, 0, len(s.contexts))
+	for idx, text := range s.contexts {
+		results = append(results, rag.KeyInfo{Text: text, ChunkIndex: idx})
+	}
+	return results
+}
+
+func (s *stubKeyInfoService) ExtractKeyInfo(ctx context.Context, input rag.ExtractInput) ([]rag.KeyInfo, error) {
 	s.input = input
 	if s.err != nil {
 		return nil, s.err
 	}
-	return s.contexts, nil
+	return s.keyInfo(), nil
+}
+
+func (s *stubKeyInfoService) QueryCorpus(ctx context.Context, input rag.CorpusQueryInput) ([]rag.KeyInfo, error) {
+	s.corpusInput = input
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.keyInfo(), nil
 }
 
 func TestExtractKeyInfoTool_HandleSuccess(t *testing.T) {
@@ -138,3 +160,96 @@ func TestExtractKeyInfoTool_GeneratesUniqueTaskIDPerCall(t *testing.T) {
 	require.NotEmpty(t, second)
 	require.NotEqual(t, first, second)
 }
+
+func TestExtractKeyInfoTool_QueriesCorpus(t *testing.T) {
+	svc := &stubKeyInfoService{contexts: []string{"ctx"}}
+	settings := rag.Settings{TopKDefault: 2, TopKLimit: 5, MaxMaterialsSize: 1000, SemanticWeight: 0.5, LexicalWeight: 0.5}
+	tool, err := NewExtractKeyInfoTool(
+		svc,
+		log.Logger.Named("extract_key_info_test"),
+		func(ctx context.Context) string { return "Bearer sk-test" },
+		func(context.Context, string, oneapi.Price, string) error { return nil },
+		settings,
+	)
+	require.NoError(t, err)
+
+	req := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{"query": "q", "corpus": "handbook", "top_k": 3}}}
+	result, err := tool.Handle(context.Background(), req)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Equal(t, "handbook", svc.corpusInput.Corpus)
+	require.Equal(t, 3, svc.corpusInput.TopK)
+	require.Equal(t, "sk-test", svc.corpusInput.APIKey)
+	require.Empty(t, svc.input.TaskID)
+
+	req = mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{"query": "q", "corpus": "handbook", "materials": "text"}}}
+	result, err = tool.Handle(context.Background(), req)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+
+	svc.err = errors.Wrap(rag.ErrCorpusNotFound, "corpus")
+	req = mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{"query": "q", "corpus": "missing"}}}
+	result, err = tool.Handle(context.Background(), req)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, `corpus "missing" not found`)
+}
+
+func TestExtractKeyInfoTool_ReturnsCitations(t *testing.T) {
+	svc := &stubKeyInfoService{results: []rag.KeyInfo{
+		{
+			Text:          "tokens expire after an hour",
+			ChunkIndex:    3,
+			MaterialsHash: "hash-1",
+			Start:         120,
+			End:           147,
+			HasOffsets:    true,
+			Score:         0.8,
+			SemanticScore: 0.9,
+			LexicalScore:  0.6,
+			Sources:       []string{"file:docs/auth.md"},
+			Before:        []string{"Sessions use JWT."},
+		},
+		{Text: "legacy chunk", ChunkIndex: 7, MaterialsHash: "hash-1"},
+	}}
+	settings := rag.Settings{TopKDefault: 2, TopKLimit: 5, MaxMaterialsSize: 1000, SemanticWeight: 0.5, LexicalWeight: 0.5}
+	tool, err := NewExtractKeyInfoTool(
+		svc,
+		log.Logger.Named("extract_key_info_test"),
+		func(ctx context.Context) string { return "Bearer sk-test" },
+		func(context.Context, string, oneapi.Price, string) error { return nil },
+		settings,
+	)
+	require.NoError(t, err)
+
+	req := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{"query": "q", "corpus": "handbook", "neighbors": 1}}}
+	result, err := tool.Handle(context.Background(), req)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Equal(t, 1, svc.corpusInput.Neighbors)
+
+	payload := decodeToolPayload(t, result)
+	require.Equal(t, []any{"tokens expire after an hour", "legacy chunk"}, payload["contexts"])
+	results, ok := payload["results"].([]any)
+	require.True(t, ok)
+	require.Len(t, results, 2)
+
+	first := results[0].(map[string]any)
+	require.EqualValues(t, 3, first["chunk_index"])
+	require.EqualValues(t, 120, first["start"])
+	require.EqualValues(t, 147, first["end"])
+	require.EqualValues(t, 0.9, first["semantic_score"])
+	require.EqualValues(t, 0.6, first["lexical_score"])
+	require.Equal(t, []any{"file:docs/auth.md"}, first["sources"])
+	require.Equal(t, []any{"Sessions use JWT."}, first["before"])
+	require.Equal(t, []any{}, first["after"])
+
+	second := results[1].(map[string]any)
+	require.NotContains(t, second, "start")
+	require.NotContains(t, second, "sources")
+
+	req = mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{"query": "q", "materials": "text", "neighbors": rag.MaxNeighbors + 1}}}
+	result, err = tool.Handle(context.Background(), req)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
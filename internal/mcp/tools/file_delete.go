@@ -29,7 +29,7 @@ func (t *FileDeleteTool) Definition() mcp.Tool {
 		mcp.WithString("project", mcp.Required(), mcp.Description("Target project namespace.")),
 		mcp.WithString("path", mcp.Description("File or directory path; empty string means project root.")),
 		mcp.WithBoolean("recursive", mcp.Description("Delete descendants when target is a directory.")),
-		fileToolPluginOption(),
+		fileToolPluginOption(t.svc),
 		mcp.WithIdempotentHintAnnotation(false),
 	)
 }
@@ -31,7 +31,7 @@ func (t *FileListTool) Definition() mcp.Tool {
 		mcp.WithString("path", mcp.Description("Directory path; empty string means project root.")),
 		mcp.WithNumber("depth", mcp.Description("Depth of traversal; 0 lists the path itself.")),
 		mcp.WithNumber("limit", mcp.Description("Maximum number of entries to return.")),
-		fileToolPluginOption(),
+		fileToolPluginOption(t.svc),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithIdempotentHintAnnotation(true),
 	)
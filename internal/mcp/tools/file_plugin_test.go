@@ -61,8 +61,16 @@ func TestFileToolDefinitionsIncludePluginField(t *testing.T) {
 		require.True(t, ok, def.Name)
 		require.Equal(t, "string", property["type"])
 		require.Equal(t, "auto", property["default"])
-		require.Equal(t, []string{"rag", "pageindex", "auto"}, property["enum"])
+		require.Equal(t, []string{"auto"}, property["enum"])
 	}
+
+	routed := &pluginBehaviorService{behaviorFileService: svc, name: mcpplugin.DefaultPluginRAG}
+	manager, err := mcpplugin.NewManager(mcpplugin.DefaultPluginRAG, routed)
+	require.NoError(t, err)
+	def := mustFileSearchTool(t, manager).Definition()
+	property, ok := def.InputSchema.Properties["plugin"].(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, []string{"rag", "auto"}, property["enum"])
 }
 
 // TestFileToolUnknownPluginReturnsStructuredError verifies manager routing failures stay user-correctable.
@@ -30,7 +30,7 @@ func (t *FileReadTool) Definition() mcp.Tool {
 		mcp.WithString("path", mcp.Required(), mcp.Description("File path to read.")),
 		mcp.WithNumber("offset", mcp.Description("Byte offset to start reading from.")),
 		mcp.WithNumber("length", mcp.Description("Number of bytes to read; -1 reads to EOF.")),
-		fileToolPluginOption(),
+		fileToolPluginOption(t.svc),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithIdempotentHintAnnotation(true),
 	)
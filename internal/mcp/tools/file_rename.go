@@ -30,7 +30,7 @@ func (t *FileRenameTool) Definition() mcp.Tool {
 		mcp.WithString("from_path", mcp.Required(), mcp.Description("Source file or directory path.")),
 		mcp.WithString("to_path", mcp.Required(), mcp.Description("Destination file or directory path.")),
 		mcp.WithBoolean("overwrite", mcp.Description("When true, replace an existing destination file for file moves.")),
-		fileToolPluginOption(),
+		fileToolPluginOption(t.svc),
 		mcp.WithIdempotentHintAnnotation(false),
 	)
 }
@@ -30,7 +30,7 @@ func (t *FileSearchTool) Definition() mcp.Tool {
 		mcp.WithString("query", mcp.Required(), mcp.Description("Search query string.")),
 		mcp.WithString("path_prefix", mcp.Description("Optional path prefix filter.")),
 		mcp.WithNumber("limit", mcp.Description("Maximum number of chunks to return.")),
-		fileToolPluginOption(),
+		fileToolPluginOption(t.svc),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithIdempotentHintAnnotation(true),
 	)
@@ -28,7 +28,7 @@ func (t *FileStatTool) Definition() mcp.Tool {
 		mcp.WithDescription("Return metadata (size, timestamps, permissions) for a file or directory path. Use this to inspect file properties without reading content."),
 		mcp.WithString("project", mcp.Required(), mcp.Description("Target project namespace.")),
 		mcp.WithString("path", mcp.Description("File path; empty string means project root.")),
-		fileToolPluginOption(),
+		fileToolPluginOption(t.svc),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithIdempotentHintAnnotation(true),
 	)
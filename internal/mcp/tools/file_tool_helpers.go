@@ -102,12 +102,25 @@ func containsString(values []string, target string) bool {
 	return false
 }
 
+// pluginLister is implemented by services that route calls across registered plugins.
+type pluginLister interface {
+	AvailablePlugins() []string
+}
+
 // fileToolPluginOption adds the additive per-call plugin routing argument.
-func fileToolPluginOption() mcp.ToolOption {
+// The enum lists the plugins registered behind svc; a service that does not
+// route across plugins only accepts auto.
+func fileToolPluginOption(svc any) mcp.ToolOption {
+	values := []string{}
+	if lister, ok := svc.(pluginLister); ok {
+		values = append(values, lister.AvailablePlugins()...)
+	}
+	values = append(values, mcpplugin.DefaultPluginAuto)
+
 	return mcp.WithString(
 		"plugin",
 		mcp.Description("Memory backend to use for this call. Use auto or omit the field to follow the server default."),
-		mcp.Enum(mcpplugin.DefaultPluginRAG, mcpplugin.DefaultPluginPageIndex, mcpplugin.DefaultPluginAuto),
+		mcp.Enum(values...),
 		mcp.DefaultString(mcpplugin.DefaultPluginAuto),
 	)
 }
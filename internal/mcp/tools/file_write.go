@@ -30,10 +30,10 @@ func (t *FileWriteTool) Definition() mcp.Tool {
 		mcp.WithString("project", mcp.Required(), mcp.Description("Target project namespace.")),
 		mcp.WithString("path", mcp.Required(), mcp.Description("File path to write.")),
 		mcp.WithString("content", mcp.Required(), mcp.Description("UTF-8 encoded content.")),
-		mcp.WithString("content_encoding", mcp.Description("Content encoding; must be utf-8.")),
+		mcp.WithString("content_encoding", mcp.Description("Content encoding; utf-8, or base64 for a .docx file written through the pageindex plugin.")),
 		mcp.WithNumber("offset", mcp.Description("Byte offset for overwrite mode.")),
 		mcp.WithString("mode", mcp.Description("Write mode: APPEND, OVERWRITE, or TRUNCATE.")),
-		fileToolPluginOption(),
+		fileToolPluginOption(t.svc),
 		mcp.WithIdempotentHintAnnotation(false),
 	)
 }
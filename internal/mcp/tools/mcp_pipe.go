@@ -29,16 +29,24 @@ type PipeLimits struct {
 	MaxSteps    int
 	MaxDepth    int
 	MaxParallel int
+	// MaxAttempts caps step.retry.max_attempts.
+	MaxAttempts int
+	// MaxStepTimeout caps step.timeout_ms.
+	MaxStepTimeout time.Duration
 }
 
 // MCPPipeTool implements the mcp_pipe MCP tool.
 //
 // It executes a declarative pipeline containing sequential steps, parallel groups,
-// and nested pipelines, feeding outputs forward through variable interpolation.
+// nested pipelines, for_each loops and transform steps, feeding outputs forward
+// through variable interpolation. Steps may be guarded by if conditions and carry retry and
+// timeout settings.
 type MCPPipeTool struct {
 	logger  logSDK.Logger
 	invoker PipeInvoker
 	limits  PipeLimits
+	// toolLookup reports whether a tool can be invoked; dry runs use it to flag unknown tools.
+	toolLookup func(string) bool
 }
 
 // NewMCPPipeTool constructs an MCPPipeTool.
@@ -58,6 +66,12 @@ func NewMCPPipeTool(logger logSDK.Logger, invoker PipeInvoker, limits PipeLimits
 	if limits.MaxParallel <= 0 {
 		limits.MaxParallel = 8
 	}
+	if limits.MaxAttempts <= 0 {
+		limits.MaxAttempts = 5
+	}
+	if limits.MaxStepTimeout <= 0 {
+		limits.MaxStepTimeout = 5 * time.Minute
+	}
 
 	return &MCPPipeTool{
 		logger:  logger,
@@ -66,15 +80,33 @@ func NewMCPPipeTool(logger logSDK.Logger, invoker PipeInvoker, limits PipeLimits
 	}, nil
 }
 
+// WithToolLookup attaches a function reporting whether a tool name can be
+// invoked by the pipeline. Dry runs use it to flag unknown tools; without it
+// tool names are not checked.
+func (t *MCPPipeTool) WithToolLookup(lookup func(string) bool) *MCPPipeTool {
+	if t != nil {
+		t.toolLookup = lookup
+	}
+	return t
+}
+
 // Definition returns the MCP metadata describing the tool.
 func (t *MCPPipeTool) Definition() mcp.Tool {
 	return mcp.NewTool(
 		"mcp_pipe",
-		mcp.WithDescription("Execute a pipeline of MCP tools (sequential, parallel, nested) with output passing."),
+		mcp.WithDescription("Execute a pipeline of MCP tools (sequential, parallel, nested, for_each) with output passing, JMESPath transforms, if conditions, retries and timeouts."),
 		mcp.WithString(
 			"spec",
 			mcp.Description("Pipeline specification. Either a JSON object or a JSON-encoded string."),
 		),
+		mcp.WithBoolean(
+			"dry_run",
+			mcp.Description("Validate tool names, references and limits without invoking any tool."),
+		),
+		mcp.WithBoolean(
+			"trace",
+			mcp.Description("Include a per-step timeline with durations, billed cost and argument digests."),
+		),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithIdempotentHintAnnotation(false),
 		mcp.WithOpenWorldHintAnnotation(true),
@@ -94,10 +126,20 @@ func (t *MCPPipeTool) Handle(ctx context.Context, req mcp.CallToolRequest) (*mcp
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
+	if spec.DryRun {
+		return t.dryRunResult(logger, spec), nil
+	}
+
 	if len(spec.Steps) == 0 {
 		return mcp.NewToolResultError("steps cannot be empty"), nil
 	}
 
+	var trace *pipeTrace
+	if spec.Trace {
+		trace = newPipeTrace()
+		ctx = withPipeTrace(ctx, trace)
+	}
+
 	env := map[string]any{
 		"vars":  spec.Vars,
 		"steps": map[string]any{},
@@ -112,6 +154,9 @@ func (t *MCPPipeTool) Handle(ctx context.Context, req mcp.CallToolRequest) (*mcp
 		"result": result,
 		"steps":  env["steps"],
 	}
+	if trace != nil {
+		payload["trace"] = trace.summary()
+	}
 
 	toolResult, encodeErr := mcp.NewToolResultJSON(payload)
 	if encodeErr != nil {
@@ -125,15 +170,30 @@ func (t *MCPPipeTool) Handle(ctx context.Context, req mcp.CallToolRequest) (*mcp
 	return toolResult, nil
 }
 
+// dryRunResult encodes the dry-run report of spec as a tool result.
+func (t *MCPPipeTool) dryRunResult(logger logSDK.Logger, spec *pipeSpec) *mcp.CallToolResult {
+	payload, ok := t.dryRun(spec)
+	toolResult, err := mcp.NewToolResultJSON(payload)
+	if err != nil {
+		logger.Error("mcp_pipe encode dry run response", zap.Error(err))
+		return mcp.NewToolResultError("failed to encode mcp_pipe response")
+	}
+	toolResult.IsError = !ok
+	return toolResult
+}
+
 // pipeSpec defines the JSON schema accepted by mcp_pipe.
 //
 // It is intentionally small: clients can express sequential steps, parallel
-// groups, nested pipelines, and a final return selector.
+// groups, nested pipelines, for_each loops, transforms, and a final return selector.
 type pipeSpec struct {
 	Vars            map[string]any `json:"vars,omitempty"`
 	Steps           []pipeStep     `json:"steps"`
 	Return          any            `json:"return,omitempty"`
 	ContinueOnError bool           `json:"continue_on_error,omitempty"`
+	// DryRun and Trace are honored on the top-level spec only.
+	DryRun bool `json:"dry_run,omitempty"`
+	Trace  bool `json:"trace,omitempty"`
 }
 
 // pipeStep represents a single pipeline step.
@@ -143,8 +203,15 @@ type pipeStep struct {
 	Args     map[string]any `json:"args,omitempty"`
 	Parallel []pipeStep     `json:"parallel,omitempty"`
 	Pipe     *pipeSpec      `json:"pipe,omitempty"`
-	Meta     map[string]any `json:"meta,omitempty"`
-	_        map[string]any `json:"-"`
+	ForEach  *pipeForEach   `json:"for_each,omitempty"`
+	// Transform evaluates an expression over env instead of calling a tool.
+	Transform *pipeTransform `json:"transform,omitempty"`
+	If        *pipeCondition `json:"if,omitempty"`
+	Retry     *pipeRetry     `json:"retry,omitempty"`
+	// TimeoutMS bounds each attempt of the step; zero means no step timeout.
+	TimeoutMS int            `json:"timeout_ms,omitempty"`
+	Meta      map[string]any `json:"meta,omitempty"`
+	_         map[string]any `json:"-"`
 }
 
 // stepCounter tracks executed steps to enforce MaxSteps.
@@ -158,7 +225,18 @@ func parsePipeSpec(arguments any) (*pipeSpec, error) {
 	args, ok := arguments.(map[string]any)
 	if ok {
 		if rawSpec, ok := args["spec"]; ok {
-			return parsePipeSpecRaw(rawSpec)
+			spec, err := parsePipeSpecRaw(rawSpec)
+			if err != nil {
+				return nil, err
+			}
+			// dry_run and trace may also be passed next to spec.
+			if dryRun, _ := args["dry_run"].(bool); dryRun {
+				spec.DryRun = true
+			}
+			if trace, _ := args["trace"].(bool); trace {
+				spec.Trace = true
+			}
+			return spec, nil
 		}
 		// Allow passing the spec directly as the arguments object.
 		return parsePipeSpecRaw(args)
@@ -225,11 +303,21 @@ func (t *MCPPipeTool) executeSpec(ctx context.Context, logger logSDK.Logger, spe
 		}
 		seen[step.ID] = struct{}{}
 
+		run, err := evalStepCondition(step, env)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if !run {
+			stepsEnv[step.ID] = skippedStepResult(step)
+			traceSkipped(ctx, step)
+			continue
+		}
+
 		if err := counter.increment(t.limits.MaxSteps); err != nil {
 			return nil, errors.WithStack(err)
 		}
 
-		stepResult, err := t.executeStep(ctx, logger, step, env, depth, counter)
+		stepResult, err := t.runStep(ctx, logger, step, env, depth, counter)
 		stepsEnv[step.ID] = stepResult
 		env["last"] = stepResult
 
@@ -276,6 +364,7 @@ func (t *MCPPipeTool) executeStep(ctx context.Context, logger logSDK.Logger, ste
 			return stepResultMap(step, startedAt, time.Since(startedAt), nil, wrapped), wrapped
 		}
 
+		recordTraceArgs(ctx, resolvedArgs)
 		result, invokeErr := t.invoker(ctx, step.Tool, resolvedArgs)
 		dur := time.Since(startedAt)
 		if invokeErr != nil {
@@ -300,12 +389,17 @@ func (t *MCPPipeTool) executeStep(ctx context.Context, logger logSDK.Logger, ste
 		return stepResultMap(step, startedAt, dur, result, nil), nil
 	}
 
+	if step.Transform != nil {
+		return executeTransform(step, env)
+	}
+
 	if step.Pipe != nil {
 		childEnv := map[string]any{
 			"vars":  env["vars"],
 			"steps": map[string]any{},
 		}
-		childResult, err := t.executeSpec(ctx, logger, step.Pipe, childEnv, depth+1, counter)
+		inheritLoopVars(childEnv, env)
+		childResult, err := t.executeSpec(withTracePath(ctx, step.ID), logger, step.Pipe, childEnv, depth+1, counter)
 		dur := time.Since(startedAt)
 		result := map[string]any{
 			"id":          step.ID,
@@ -323,13 +417,18 @@ func (t *MCPPipeTool) executeStep(ctx context.Context, logger logSDK.Logger, ste
 		return result, nil
 	}
 
+	if step.ForEach != nil {
+		return t.executeForEach(ctx, logger, step, env, depth, counter)
+	}
+
 	// parallel group
 	children := step.Parallel
 	childResults := map[string]any{}
 	var mu sync.Mutex
 	var groupErr error
 
-	g, gctx := errgroup.WithContext(ctx)
+	groupCtx := withTracePath(ctx, step.ID)
+	g, gctx := errgroup.WithContext(groupCtx)
 	g.SetLimit(t.limits.MaxParallel)
 
 	seen := map[string]struct{}{}
@@ -346,6 +445,19 @@ func (t *MCPPipeTool) executeStep(ctx context.Context, logger logSDK.Logger, ste
 		}
 		seen[child.ID] = struct{}{}
 
+		run, err := evalStepCondition(child, env)
+		if err != nil {
+			wrapped := errors.WithStack(err)
+			return stepResultMap(step, startedAt, time.Since(startedAt), nil, wrapped), wrapped
+		}
+		if !run {
+			mu.Lock()
+			childResults[child.ID] = skippedStepResult(child)
+			mu.Unlock()
+			traceSkipped(groupCtx, child)
+			continue
+		}
+
 		if err := counter.increment(t.limits.MaxSteps); err != nil {
 			wrapped := errors.WithStack(err)
 			return stepResultMap(step, startedAt, time.Since(startedAt), nil, wrapped), wrapped
@@ -353,7 +465,7 @@ func (t *MCPPipeTool) executeStep(ctx context.Context, logger logSDK.Logger, ste
 
 		childCopy := child
 		g.Go(func() error {
-			res, err := t.executeStep(gctx, logger, childCopy, env, depth, counter)
+			res, err := t.runStep(gctx, logger, childCopy, env, depth, counter)
 			mu.Lock()
 			childResults[childCopy.ID] = res
 			if err != nil && groupErr == nil {
@@ -400,10 +512,21 @@ func validateStep(step pipeStep) error {
 	if len(step.Parallel) > 0 {
 		modeCount++
 	}
+	if step.ForEach != nil {
+		modeCount++
+	}
+	if step.Transform != nil {
+		modeCount++
+	}
 	if modeCount != 1 {
-		return errors.New("step must have exactly one of tool, pipe, or parallel")
+		return errors.New("step must have exactly one of tool, pipe, parallel, for_each, or transform")
 	}
-	return nil
+	if step.Transform != nil {
+		if err := validateTransform(step); err != nil {
+			return err
+		}
+	}
+	return validateStepControl(step)
 }
 
 // increment increases the step counter and enforces MaxSteps.
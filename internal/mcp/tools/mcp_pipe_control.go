@@ -0,0 +1,550 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	logSDK "github.com/Laisky/go-utils/v6/log"
+	"github.com/Laisky/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// defaultRetryBackoff is the first retry delay when retry.backoff_ms is unset.
+	defaultRetryBackoff = 200 * time.Millisecond
+	// maxRetryBackoff caps the delay between two attempts.
+	maxRetryBackoff = 30 * time.Second
+)
+
+// pipeCondition guards a step. Exactly one of Ref, All, Any or Not is set.
+// A JSON string is shorthand for {"ref": "<path>", "op": "truthy"}.
+type pipeCondition struct {
+	Ref   string          `json:"ref,omitempty"`
+	Op    string          `json:"op,omitempty"`
+	Value any             `json:"value,omitempty"`
+	All   []pipeCondition `json:"all,omitempty"`
+	Any   []pipeCondition `json:"any,omitempty"`
+	Not   *pipeCondition  `json:"not,omitempty"`
+}
+
+// UnmarshalJSON accepts either a condition object or a reference path string.
+func (c *pipeCondition) UnmarshalJSON(data []byte) error {
+	var ref string
+	if err := json.Unmarshal(data, &ref); err == nil {
+		*c = pipeCondition{Ref: ref, Op: "truthy"}
+		return nil
+	}
+
+	type alias pipeCondition
+	var decoded alias
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return errors.Wrap(err, "decode if condition")
+	}
+	*c = pipeCondition(decoded)
+	return nil
+}
+
+// pipeForEach runs Step once per element of Items.
+type pipeForEach struct {
+	// Items is an array, a {"$ref": ...} object, or a "${...}" string that
+	// resolves to an array or a JSON-encoded array.
+	Items any `json:"items"`
+	// MaxParallel bounds concurrent iterations; it cannot exceed PipeLimits.MaxParallel.
+	MaxParallel int       `json:"max_parallel,omitempty"`
+	Step        *pipeStep `json:"step"`
+}
+
+// pipeRetry configures retries of a tool step with exponential backoff.
+type pipeRetry struct {
+	MaxAttempts  int `json:"max_attempts"`
+	BackoffMS    int `json:"backoff_ms,omitempty"`
+	MaxBackoffMS int `json:"max_backoff_ms,omitempty"`
+}
+
+// validateStepControl validates the if, for_each, retry and timeout settings of a step.
+func validateStepControl(step pipeStep) error {
+	if step.If != nil {
+		if err := validateCondition(*step.If); err != nil {
+			return errors.Wrapf(err, "step %s: invalid if", step.ID)
+		}
+	}
+	if step.ForEach != nil {
+		if step.ForEach.Items == nil {
+			return errors.Errorf("step %s: for_each.items is required", step.ID)
+		}
+		if step.ForEach.Step == nil {
+			return errors.Errorf("step %s: for_each.step is required", step.ID)
+		}
+		if step.ForEach.MaxParallel < 0 {
+			return errors.Errorf("step %s: for_each.max_parallel cannot be negative", step.ID)
+		}
+		if err := validateStep(*step.ForEach.Step); err != nil {
+			return errors.Wrapf(err, "step %s: invalid for_each.step", step.ID)
+		}
+	}
+	if step.Retry != nil {
+		if strings.TrimSpace(step.Tool) == "" {
+			return errors.Errorf("step %s: retry is only supported on tool steps", step.ID)
+		}
+		if step.Retry.MaxAttempts < 1 {
+			return errors.Errorf("step %s: retry.max_attempts must be at least 1", step.ID)
+		}
+		if step.Retry.BackoffMS < 0 || step.Retry.MaxBackoffMS < 0 {
+			return errors.Errorf("step %s: retry backoff cannot be negative", step.ID)
+		}
+	}
+	if step.TimeoutMS < 0 {
+		return errors.Errorf("step %s: timeout_ms cannot be negative", step.ID)
+	}
+	return nil
+}
+
+// validateCondition checks the shape and operator of a condition tree.
+func validateCondition(c pipeCondition) error {
+	forms := 0
+	if strings.TrimSpace(c.Ref) != "" {
+		forms++
+	}
+	if len(c.All) > 0 {
+		forms++
+	}
+	if len(c.Any) > 0 {
+		forms++
+	}
+	if c.Not != nil {
+		forms++
+	}
+	if forms != 1 {
+		return errors.New("condition must have exactly one of ref, all, any, or not")
+	}
+
+	switch {
+	case c.Ref != "":
+		switch c.Op {
+		case "", "truthy", "falsy", "exists", "not_exists", "empty", "not_empty",
+			"eq", "ne", "gt", "gte", "lt", "lte", "contains":
+		default:
+			return errors.Errorf("unsupported condition op %q", c.Op)
+		}
+	case c.Not != nil:
+		return validateCondition(*c.Not)
+	}
+	for _, sub := range append(append([]pipeCondition{}, c.All...), c.Any...) {
+		if err := validateCondition(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evalStepCondition reports whether the step should run. Steps without an if always run.
+func evalStepCondition(step pipeStep, env map[string]any) (bool, error) {
+	if step.If == nil {
+		return true, nil
+	}
+	ok, err := evalCondition(*step.If, env)
+	if err != nil {
+		return false, errors.Wrapf(err, "step %s: evaluate if", step.ID)
+	}
+	return ok, nil
+}
+
+// evalCondition evaluates a condition against env. A ref that does not
+// resolve is treated as missing rather than as an error.
+func evalCondition(c pipeCondition, env map[string]any) (bool, error) {
+	switch {
+	case c.Not != nil:
+		ok, err := evalCondition(*c.Not, env)
+		return !ok, err
+	case len(c.All) > 0:
+		for _, sub := range c.All {
+			ok, err := evalCondition(sub, env)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case len(c.Any) > 0:
+		for _, sub := range c.Any {
+			ok, err := evalCondition(sub, env)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	value, refErr := resolvePath(c.Ref, env)
+	found := refErr == nil
+	value = normalizeJSONCompatible(value)
+
+	expected, err := resolveAny(c.Value, env)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	expected = normalizeJSONCompatible(expected)
+
+	switch c.Op {
+	case "", "truthy":
+		return found && isTruthy(value), nil
+	case "falsy":
+		return !found || !isTruthy(value), nil
+	case "exists":
+		return found, nil
+	case "not_exists":
+		return !found, nil
+	case "empty":
+		return !found || isEmptyValue(value), nil
+	case "not_empty":
+		return found && !isEmptyValue(value), nil
+	case "eq":
+		return found && valuesEqual(value, expected), nil
+	case "ne":
+		return !found || !valuesEqual(value, expected), nil
+	case "gt", "gte", "lt", "lte":
+		if !found {
+			return false, nil
+		}
+		left, lok := toFloat(value)
+		right, rok := toFloat(expected)
+		if !lok || !rok {
+			return false, nil
+		}
+		switch c.Op {
+		case "gt":
+			return left > right, nil
+		case "gte":
+			return left >= right, nil
+		case "lt":
+			return left < right, nil
+		default:
+			return left <= right, nil
+		}
+	case "contains":
+		return found && containsValue(value, expected), nil
+	default:
+		return false, errors.Errorf("unsupported condition op %q", c.Op)
+	}
+}
+
+// isTruthy follows JSON intuition: null, false, 0, "", "false", "0" and empty
+// collections are false.
+func isTruthy(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		s := strings.TrimSpace(strings.ToLower(v))
+		return s != "" && s != "false" && s != "0"
+	case []any:
+		return len(v) > 0
+	case map[string]any:
+		return len(v) > 0
+	}
+	if f, ok := toFloat(value); ok {
+		return f != 0
+	}
+	return true
+}
+
+// isEmptyValue reports whether value is null, an empty string or an empty collection.
+func isEmptyValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// valuesEqual compares numbers numerically and everything else structurally.
+func valuesEqual(a, b any) bool {
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			return af == bf
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// containsValue checks substrings of strings, elements of arrays and keys of objects.
+func containsValue(haystack, needle any) bool {
+	switch h := haystack.(type) {
+	case string:
+		return strings.Contains(h, valueToString(needle))
+	case []any:
+		for _, item := range h {
+			if valuesEqual(item, needle) {
+				return true
+			}
+		}
+	case map[string]any:
+		_, ok := h[valueToString(needle)]
+		return ok
+	}
+	return false
+}
+
+// toFloat converts JSON numbers and numeric strings to float64.
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// skippedStepResult is recorded for steps whose if condition is false.
+func skippedStepResult(step pipeStep) map[string]any {
+	return map[string]any{
+		"id":      step.ID,
+		"kind":    stepKind(step),
+		"skipped": true,
+		"ok":      true,
+		"error":   "",
+	}
+}
+
+// stepKind returns the execution mode name of a step.
+func stepKind(step pipeStep) string {
+	switch {
+	case step.Tool != "":
+		return "tool"
+	case step.Pipe != nil:
+		return "pipe"
+	case step.ForEach != nil:
+		return "for_each"
+	case step.Transform != nil:
+		return "transform"
+	default:
+		return "parallel"
+	}
+}
+
+// runStep executes a step with its timeout and retry settings applied.
+// Every attempt after the first counts toward MaxSteps.
+func (t *MCPPipeTool) runStep(ctx context.Context, logger logSDK.Logger, step pipeStep, env map[string]any, depth int, counter *stepCounter) (map[string]any, error) {
+	timeout := time.Duration(step.TimeoutMS) * time.Millisecond
+	if timeout > t.limits.MaxStepTimeout {
+		timeout = t.limits.MaxStepTimeout
+	}
+
+	maxAttempts := 1
+	backoff := defaultRetryBackoff
+	backoffCap := maxRetryBackoff
+	if step.Retry != nil {
+		maxAttempts = min(step.Retry.MaxAttempts, t.limits.MaxAttempts)
+		if step.Retry.BackoffMS > 0 {
+			backoff = time.Duration(step.Retry.BackoffMS) * time.Millisecond
+		}
+		if step.Retry.MaxBackoffMS > 0 {
+			backoffCap = min(time.Duration(step.Retry.MaxBackoffMS)*time.Millisecond, maxRetryBackoff)
+		}
+	}
+
+	var (
+		result  map[string]any
+		err     error
+		attempt int
+	)
+	for attempt = 1; ; attempt++ {
+		attemptStartedAt := time.Now()
+		attemptCtx, traceAttempt := beginTraceAttempt(ctx)
+		cancel := context.CancelFunc(func() {})
+		if timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(attemptCtx, timeout)
+		}
+		result, err = t.executeStep(attemptCtx, logger, step, env, depth, counter)
+		if err != nil && timeout > 0 && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			err = errors.Wrapf(err, "step timed out after %s", timeout)
+			result["ok"] = false
+			result["error"] = err.Error()
+		}
+		cancel()
+		finishTraceAttempt(attemptCtx, traceAttempt, step, attempt, attemptStartedAt, err)
+
+		if err == nil || attempt >= maxAttempts || ctx.Err() != nil {
+			break
+		}
+		if counter.increment(t.limits.MaxSteps) != nil {
+			break
+		}
+
+		delay := min(backoff<<(attempt-1), backoffCap)
+		logger.Debug("mcp_pipe retry step",
+			zap.String("step_id", step.ID),
+			zap.String("tool", step.Tool),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", delay),
+			zap.Error(err),
+		)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if step.Retry != nil && result != nil {
+		result["attempts"] = attempt
+	}
+	return result, err
+}
+
+// executeForEach runs the loop body once per item, bounded by MaxParallel.
+// Each iteration sees the current item and index as env "item" and "index".
+func (t *MCPPipeTool) executeForEach(ctx context.Context, logger logSDK.Logger, step pipeStep, env map[string]any, depth int, counter *stepCounter) (map[string]any, error) {
+	startedAt := time.Now().UTC()
+	loop := step.ForEach
+	fail := func(err error) (map[string]any, error) {
+		wrapped := errors.WithStack(err)
+		return forEachResultMap(step, startedAt, nil, nil, wrapped), wrapped
+	}
+
+	if depth+1 > t.limits.MaxDepth {
+		return fail(errors.New("pipeline nesting too deep"))
+	}
+
+	items, err := resolveForEachItems(loop.Items, env)
+	if err != nil {
+		return fail(errors.Wrapf(err, "step %s: resolve for_each.items", step.ID))
+	}
+
+	limit := t.limits.MaxParallel
+	if loop.MaxParallel > 0 && loop.MaxParallel < limit {
+		limit = loop.MaxParallel
+	}
+
+	body := *loop.Step
+	results := make([]any, len(items))
+	var mu sync.Mutex
+	var loopErr error
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(limit)
+	for idx, item := range items {
+		iterEnv := loopEnv(env, item, idx)
+		run, err := evalStepCondition(body, iterEnv)
+		if err != nil {
+			_ = g.Wait()
+			return fail(err)
+		}
+		iterCtx := withTracePath(gctx, step.ID+"["+strconv.Itoa(idx)+"]")
+		if !run {
+			results[idx] = skippedStepResult(body)
+			traceSkipped(iterCtx, body)
+			continue
+		}
+		if err := counter.increment(t.limits.MaxSteps); err != nil {
+			_ = g.Wait()
+			return fail(err)
+		}
+
+		g.Go(func() error {
+			res, err := t.runStep(iterCtx, logger, body, iterEnv, depth+1, counter)
+			mu.Lock()
+			results[idx] = res
+			if err != nil && loopErr == nil {
+				loopErr = errors.Wrapf(err, "for_each item %d", idx)
+			}
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	result := forEachResultMap(step, startedAt, items, results, loopErr)
+	if loopErr != nil {
+		return result, loopErr
+	}
+	return result, nil
+}
+
+// forEachResultMap builds the result of a for_each step.
+func forEachResultMap(step pipeStep, startedAt time.Time, items []any, results []any, loopErr error) map[string]any {
+	if results == nil {
+		results = []any{}
+	}
+	return map[string]any{
+		"id":          step.ID,
+		"kind":        "for_each",
+		"started_at":  startedAt.Format(time.RFC3339Nano),
+		"duration_ms": time.Since(startedAt).Milliseconds(),
+		"ok":          loopErr == nil,
+		"error":       errorString(loopErr),
+		"count":       len(items),
+		"items":       results,
+	}
+}
+
+// resolveForEachItems resolves for_each.items to an array.
+func resolveForEachItems(raw any, env map[string]any) ([]any, error) {
+	resolved, err := resolveAny(raw, env)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if s, ok := resolved.(string); ok {
+		var decoded any
+		if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+			return nil, errors.New("for_each.items must resolve to an array")
+		}
+		resolved = decoded
+	}
+	items, ok := normalizeJSONCompatible(resolved).([]any)
+	if !ok {
+		return nil, errors.New("for_each.items must resolve to an array")
+	}
+	return items, nil
+}
+
+// loopEnv returns a shallow copy of env carrying the current loop item.
+func loopEnv(env map[string]any, item any, index int) map[string]any {
+	out := make(map[string]any, len(env)+2)
+	for k, v := range env {
+		out[k] = v
+	}
+	out["item"] = item
+	out["index"] = index
+	return out
+}
+
+// inheritLoopVars copies the enclosing loop item into a nested pipe env.
+func inheritLoopVars(childEnv, env map[string]any) {
+	for _, key := range []string{"item", "index"} {
+		if v, ok := env[key]; ok {
+			childEnv[key] = v
+		}
+	}
+}
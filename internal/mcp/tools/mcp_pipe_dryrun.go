@@ -0,0 +1,271 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// pipeDryRun statically checks a spec without invoking any tool.
+//
+// It reports issues that would make the run fail (invalid steps, unknown
+// tools, unresolvable references, nesting depth) and warnings for limits that
+// can only be decided at run time.
+type pipeDryRun struct {
+	limits     PipeLimits
+	toolLookup func(string) bool
+	issues     []pipeDryRunIssue
+	warnings   []pipeDryRunIssue
+	plan       []pipeDryRunStep
+}
+
+// pipeDryRunIssue is a problem found at a step path.
+type pipeDryRunIssue struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// pipeDryRunStep is one entry of the execution plan.
+type pipeDryRunStep struct {
+	Path  string `json:"path"`
+	Kind  string `json:"kind"`
+	Tool  string `json:"tool,omitempty"`
+	Depth int    `json:"depth"`
+}
+
+// pipeRefScope describes which env roots a step can reference.
+type pipeRefScope struct {
+	vars    map[string]any
+	steps   map[string]struct{}
+	hasLast bool
+	inLoop  bool
+}
+
+// dryRun validates spec and returns the dry-run payload and whether it passed.
+func (t *MCPPipeTool) dryRun(spec *pipeSpec) (map[string]any, bool) {
+	d := &pipeDryRun{limits: t.limits, toolLookup: t.toolLookup}
+	maxSteps := d.checkSpec(spec, spec.Vars, "", 0, false)
+	if maxSteps > t.limits.MaxSteps {
+		d.warn("", fmt.Sprintf("pipeline may run up to %d steps, above the limit of %d", maxSteps, t.limits.MaxSteps))
+	}
+
+	firstIssue := ""
+	if len(d.issues) > 0 {
+		firstIssue = d.issues[0].Message
+		if d.issues[0].Path != "" {
+			firstIssue = d.issues[0].Path + ": " + firstIssue
+		}
+	}
+
+	ok := len(d.issues) == 0
+	return map[string]any{
+		"ok":        ok,
+		"dry_run":   true,
+		"error":     firstIssue,
+		"issues":    nonNilIssues(d.issues),
+		"warnings":  nonNilIssues(d.warnings),
+		"plan":      d.plan,
+		"max_steps": maxSteps,
+		"limits": map[string]any{
+			"max_steps":        t.limits.MaxSteps,
+			"max_depth":        t.limits.MaxDepth,
+			"max_parallel":     t.limits.MaxParallel,
+			"max_attempts":     t.limits.MaxAttempts,
+			"max_step_timeout": t.limits.MaxStepTimeout.Milliseconds(),
+		},
+	}, ok
+}
+
+// checkSpec checks a (possibly nested) spec and returns its worst-case step count.
+func (d *pipeDryRun) checkSpec(spec *pipeSpec, vars map[string]any, path string, depth int, inLoop bool) int {
+	if depth > d.limits.MaxDepth {
+		d.issue(path, "pipeline nesting too deep")
+		return 0
+	}
+	if len(spec.Steps) == 0 {
+		d.issue(path, "steps cannot be empty")
+		return 0
+	}
+
+	scope := &pipeRefScope{vars: vars, steps: map[string]struct{}{}, inLoop: inLoop}
+	total := 0
+	for _, step := range spec.Steps {
+		stepPath := joinTracePath(path, step.ID)
+		if err := validateStep(step); err != nil {
+			d.issue(stepPath, err.Error())
+			continue
+		}
+		if _, ok := scope.steps[step.ID]; ok {
+			d.issue(stepPath, "duplicate step id: "+step.ID)
+			continue
+		}
+		total += d.checkStep(step, scope, stepPath, depth)
+		scope.steps[step.ID] = struct{}{}
+		scope.hasLast = true
+	}
+
+	if spec.Return != nil {
+		d.checkRefs(spec.Return, scope, joinTracePath(path, "return"))
+	}
+	return total
+}
+
+// checkStep checks an already validated step and returns its worst-case step count,
+// counting every retry attempt and assuming all if conditions hold.
+func (d *pipeDryRun) checkStep(step pipeStep, scope *pipeRefScope, path string, depth int) int {
+	d.plan = append(d.plan, pipeDryRunStep{Path: path, Kind: stepKind(step), Tool: step.Tool, Depth: depth})
+	if step.If != nil {
+		d.checkCondition(*step.If, scope, path)
+	}
+
+	attempts := 1
+	if step.Retry != nil {
+		attempts = min(step.Retry.MaxAttempts, d.limits.MaxAttempts)
+	}
+
+	switch {
+	case step.Tool != "":
+		if d.toolLookup != nil && !d.toolLookup(step.Tool) {
+			d.issue(path, "unknown tool: "+step.Tool)
+		}
+		d.checkRefs(step.Args, scope, path)
+		return attempts
+	case step.Transform != nil:
+		for _, ref := range transformRootRefs(step.Transform.Expr) {
+			// Missing fields evaluate to null in JMESPath, so only roots and step ids are checked.
+			d.checkRefPath(ref, scope, path, true)
+		}
+		return attempts
+	case step.Pipe != nil:
+		return attempts * (1 + d.checkSpec(step.Pipe, scope.vars, path, depth+1, scope.inLoop))
+	case step.ForEach != nil:
+		d.checkRefs(step.ForEach.Items, scope, path)
+		iterations := 1
+		if items, ok := step.ForEach.Items.([]any); ok {
+			iterations = len(items)
+		} else {
+			d.warn(path, "for_each items are resolved at run time; the step estimate assumes one iteration")
+		}
+		if depth+1 > d.limits.MaxDepth {
+			d.issue(path, "pipeline nesting too deep")
+			return attempts
+		}
+		bodyScope := *scope
+		bodyScope.inLoop = true
+		body := *step.ForEach.Step
+		bodyCount := d.checkStep(body, &bodyScope, joinTracePath(path+"[*]", body.ID), depth+1)
+		return attempts * (1 + iterations*bodyCount)
+	default:
+		total := 0
+		seen := map[string]struct{}{}
+		for _, child := range step.Parallel {
+			childPath := joinTracePath(path, child.ID)
+			if err := validateStep(child); err != nil {
+				d.issue(childPath, err.Error())
+				continue
+			}
+			if _, ok := seen[child.ID]; ok {
+				d.issue(childPath, "duplicate parallel step id: "+child.ID)
+				continue
+			}
+			seen[child.ID] = struct{}{}
+			// Parallel siblings run concurrently and cannot see each other.
+			total += d.checkStep(child, scope, childPath, depth)
+		}
+		return attempts * (1 + total)
+	}
+}
+
+// checkCondition checks the references of an if condition tree.
+func (d *pipeDryRun) checkCondition(c pipeCondition, scope *pipeRefScope, path string) {
+	if c.Ref != "" {
+		// Conditions treat a missing value as not found, so only the root is checked.
+		d.checkRefPath(c.Ref, scope, path, true)
+		d.checkRefs(c.Value, scope, path)
+	}
+	if c.Not != nil {
+		d.checkCondition(*c.Not, scope, path)
+	}
+	for _, sub := range append(append([]pipeCondition{}, c.All...), c.Any...) {
+		d.checkCondition(sub, scope, path)
+	}
+}
+
+// checkRefs walks a value the way resolveAny does and checks every reference.
+func (d *pipeDryRun) checkRefs(value any, scope *pipeRefScope, path string) {
+	switch v := value.(type) {
+	case string:
+		for _, m := range placeholderPattern.FindAllStringSubmatch(v, -1) {
+			d.checkRefPath(m[1], scope, path, false)
+		}
+	case map[string]any:
+		if ref, ok := v["$ref"]; ok && len(v) == 1 {
+			refStr, ok := ref.(string)
+			if !ok {
+				d.issue(path, "$ref must be a string")
+				return
+			}
+			d.checkRefPath(refStr, scope, path, false)
+			return
+		}
+		for _, val := range v {
+			d.checkRefs(val, scope, path)
+		}
+	case []any:
+		for _, item := range v {
+			d.checkRefs(item, scope, path)
+		}
+	}
+}
+
+// checkRefPath checks that a reference can resolve when the step runs.
+// Step outputs are only known at run time, so only the step id is checked.
+// With optional set, vars paths may be absent.
+func (d *pipeDryRun) checkRefPath(ref string, scope *pipeRefScope, path string, optional bool) {
+	ref = strings.TrimSpace(ref)
+	parts := strings.Split(ref, ".")
+	root := strings.TrimSpace(parts[0])
+
+	switch root {
+	case "vars":
+		if optional {
+			return
+		}
+		if _, err := resolvePath(ref, map[string]any{"vars": scope.vars}); err != nil {
+			d.issue(path, "unresolved reference "+ref+": "+err.Error())
+		}
+	case "steps":
+		if len(parts) < 2 {
+			d.issue(path, "reference "+ref+" must name a step")
+			return
+		}
+		if _, ok := scope.steps[strings.TrimSpace(parts[1])]; !ok {
+			d.issue(path, "reference "+ref+" does not name an earlier step of this pipeline")
+		}
+	case "last":
+		if !scope.hasLast {
+			d.issue(path, "reference "+ref+" is used before any step has run")
+		}
+	case "item", "index":
+		if !scope.inLoop {
+			d.issue(path, "reference "+ref+" is only available inside for_each")
+		}
+	default:
+		d.issue(path, "unknown reference root: "+root)
+	}
+}
+
+func (d *pipeDryRun) issue(path, message string) {
+	d.issues = append(d.issues, pipeDryRunIssue{Path: path, Message: message})
+}
+
+func (d *pipeDryRun) warn(path, message string) {
+	d.warnings = append(d.warnings, pipeDryRunIssue{Path: path, Message: message})
+}
+
+// nonNilIssues keeps empty lists encoded as [] rather than null.
+func nonNilIssues(issues []pipeDryRunIssue) []pipeDryRunIssue {
+	if issues == nil {
+		return []pipeDryRunIssue{}
+	}
+	return issues
+}
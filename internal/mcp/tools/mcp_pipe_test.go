@@ -2,7 +2,10 @@ package tools
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	mcp "github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/require"
@@ -212,3 +215,333 @@ func TestMCPPipeRefOverStructStructuredContent(t *testing.T) {
 	require.Equal(t, true, structured["ok"])
 	require.Equal(t, "ok", structured["result"])
 }
+
+// TestMCPPipeIfCondition verifies that steps run only when their if condition holds.
+func TestMCPPipeIfCondition(t *testing.T) {
+	var calls []string
+	var mu sync.Mutex
+	invoker := func(ctx context.Context, toolName string, args any) (*mcp.CallToolResult, error) {
+		mu.Lock()
+		calls = append(calls, toolName)
+		mu.Unlock()
+		switch toolName {
+		case "web_search":
+			return mcp.NewToolResultJSON(map[string]any{"results": []any{}, "total": 0})
+		default:
+			return mcp.NewToolResultJSON(map[string]any{"content": toolName})
+		}
+	}
+
+	tool, err := NewMCPPipeTool(log.Logger.Named("test_mcp_pipe_if"), invoker, PipeLimits{MaxSteps: 3, MaxDepth: 2, MaxParallel: 4})
+	require.NoError(t, err)
+
+	req := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{
+		"steps": []any{
+			map[string]any{"id": "s", "tool": "web_search", "args": map[string]any{"query": "x"}},
+			map[string]any{"id": "fetch", "tool": "web_fetch", "if": "steps.s.structured.results", "args": map[string]any{"url": "x"}},
+			map[string]any{"id": "fallback", "tool": "web_fallback",
+				"if": map[string]any{"all": []any{
+					map[string]any{"ref": "steps.fetch.skipped", "op": "eq", "value": true},
+					map[string]any{"ref": "steps.s.structured.total", "op": "lte", "value": 0},
+				}}},
+			map[string]any{"id": "never", "tool": "web_never", "if": map[string]any{"ref": "steps.missing.ok", "op": "exists"}},
+		},
+		"return": map[string]any{"$ref": "steps.fallback.structured.content"},
+	}}}
+
+	result, handleErr := tool.Handle(context.Background(), req)
+	require.NoError(t, handleErr)
+	require.False(t, result.IsError)
+
+	structured := result.StructuredContent.(map[string]any)
+	require.Equal(t, true, structured["ok"])
+	require.Equal(t, "web_fallback", structured["result"])
+	require.Equal(t, []string{"web_search", "web_fallback"}, calls)
+
+	steps := structured["steps"].(map[string]any)
+	require.Equal(t, true, steps["fetch"].(map[string]any)["skipped"])
+	require.Equal(t, true, steps["never"].(map[string]any)["skipped"])
+}
+
+// TestMCPPipeForEach verifies for_each iterations, bounded concurrency and MaxSteps accounting.
+func TestMCPPipeForEach(t *testing.T) {
+	var running, peak atomic.Int32
+	invoker := func(ctx context.Context, toolName string, args any) (*mcp.CallToolResult, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		url := args.(map[string]any)["url"].(string)
+		return mcp.NewToolResultJSON(map[string]any{"content": "content:" + url})
+	}
+
+	spec := func(maxParallel int) map[string]any {
+		return map[string]any{
+			"vars": map[string]any{"urls": []any{"a", "b", "skip", "c", "d"}},
+			"steps": []any{
+				map[string]any{"id": "fetch", "for_each": map[string]any{
+					"items":        map[string]any{"$ref": "vars.urls"},
+					"max_parallel": maxParallel,
+					"step": map[string]any{
+						"id":   "f",
+						"tool": "web_fetch",
+						"if":   map[string]any{"ref": "item", "op": "ne", "value": "skip"},
+						"args": map[string]any{"url": "${item}#${index}"},
+					},
+				}},
+			},
+			"return": map[string]any{"$ref": "steps.fetch.items.3.structured.content"},
+		}
+	}
+
+	tool, err := NewMCPPipeTool(log.Logger.Named("test_mcp_pipe_for_each"), invoker, PipeLimits{MaxSteps: 5, MaxDepth: 2, MaxParallel: 4})
+	require.NoError(t, err)
+
+	result, handleErr := tool.Handle(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: spec(2)}})
+	require.NoError(t, handleErr)
+	require.False(t, result.IsError)
+	structured := result.StructuredContent.(map[string]any)
+	require.Equal(t, "content:c#3", structured["result"])
+	require.LessOrEqual(t, peak.Load(), int32(2))
+
+	loop := structured["steps"].(map[string]any)["fetch"].(map[string]any)
+	require.Equal(t, 5, loop["count"])
+	require.Equal(t, true, loop["items"].([]any)[2].(map[string]any)["skipped"])
+
+	// The loop step plus four iterations exceed a budget of four steps.
+	tight, err := NewMCPPipeTool(log.Logger.Named("test_mcp_pipe_for_each_limit"), invoker, PipeLimits{MaxSteps: 4, MaxDepth: 2, MaxParallel: 4})
+	require.NoError(t, err)
+	result, handleErr = tight.Handle(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: spec(0)}})
+	require.NoError(t, handleErr)
+	require.True(t, result.IsError)
+	require.Contains(t, result.StructuredContent.(map[string]any)["error"], "maximum step limit")
+}
+
+// TestMCPPipeRetry verifies that a tool step is retried until it succeeds.
+func TestMCPPipeRetry(t *testing.T) {
+	var attempts atomic.Int32
+	invoker := func(ctx context.Context, toolName string, args any) (*mcp.CallToolResult, error) {
+		if attempts.Add(1) < 3 {
+			return mcp.NewToolResultError("temporarily unavailable"), nil
+		}
+		return mcp.NewToolResultJSON(map[string]any{"content": "ok"})
+	}
+
+	tool, err := NewMCPPipeTool(log.Logger.Named("test_mcp_pipe_retry"), invoker, PipeLimits{MaxSteps: 10, MaxDepth: 2, MaxParallel: 4})
+	require.NoError(t, err)
+
+	req := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{
+		"steps": []any{
+			map[string]any{"id": "f", "tool": "web_fetch", "args": map[string]any{"url": "x"},
+				"retry": map[string]any{"max_attempts": 3, "backoff_ms": 1}},
+		},
+	}}}
+
+	result, handleErr := tool.Handle(context.Background(), req)
+	require.NoError(t, handleErr)
+	require.False(t, result.IsError)
+	step := result.StructuredContent.(map[string]any)["steps"].(map[string]any)["f"].(map[string]any)
+	require.Equal(t, 3, step["attempts"])
+	require.Equal(t, int32(3), attempts.Load())
+}
+
+// TestMCPPipeStepTimeout verifies that timeout_ms cancels a slow step.
+func TestMCPPipeStepTimeout(t *testing.T) {
+	invoker := func(ctx context.Context, toolName string, args any) (*mcp.CallToolResult, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return mcp.NewToolResultJSON(map[string]any{"content": "late"})
+		}
+	}
+
+	tool, err := NewMCPPipeTool(log.Logger.Named("test_mcp_pipe_timeout"), invoker, PipeLimits{MaxSteps: 10, MaxDepth: 2, MaxParallel: 4})
+	require.NoError(t, err)
+
+	req := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{
+		"steps": []any{
+			map[string]any{"id": "slow", "tool": "web_fetch", "args": map[string]any{"url": "x"}, "timeout_ms": 20},
+		},
+	}}}
+
+	startedAt := time.Now()
+	result, handleErr := tool.Handle(context.Background(), req)
+	require.NoError(t, handleErr)
+	require.True(t, result.IsError)
+	require.Less(t, time.Since(startedAt), 2*time.Second)
+	require.Contains(t, result.StructuredContent.(map[string]any)["error"], "timed out")
+}
+
+// TestMCPPipeDryRun verifies that dry runs report issues without invoking tools.
+func TestMCPPipeDryRun(t *testing.T) {
+	invoker := func(ctx context.Context, toolName string, args any) (*mcp.CallToolResult, error) {
+		t.Fatalf("dry run invoked tool %s", toolName)
+		return nil, nil
+	}
+	tool, err := NewMCPPipeTool(log.Logger.Named("test_mcp_pipe_dry_run"), invoker, PipeLimits{MaxSteps: 5})
+	require.NoError(t, err)
+	tool.WithToolLookup(func(name string) bool { return name == "web_search" || name == "web_fetch" })
+
+	run := func(args map[string]any) (*mcp.CallToolResult, map[string]any) {
+		result, err := tool.Handle(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+		require.NoError(t, err)
+		return result, result.StructuredContent.(map[string]any)
+	}
+
+	result, payload := run(map[string]any{
+		"dry_run": true,
+		"spec": map[string]any{
+			"vars": map[string]any{"q": "golang"},
+			"steps": []any{
+				map[string]any{"id": "s", "tool": "web_search", "args": map[string]any{"query": "${vars.q}"}},
+				map[string]any{"id": "pages", "for_each": map[string]any{
+					"items": map[string]any{"$ref": "steps.s.structured.results"},
+					"step":  map[string]any{"id": "get", "tool": "web_fetch", "args": map[string]any{"url": "${item.url}"}},
+				}},
+			},
+			"return": map[string]any{"$ref": "steps.pages.items"},
+		},
+	})
+	require.False(t, result.IsError, toolResultText(result))
+	require.Equal(t, true, payload["dry_run"])
+	require.Empty(t, payload["issues"])
+	require.Len(t, payload["plan"], 3)
+	require.Equal(t, 3, payload["max_steps"])
+	require.Len(t, payload["warnings"], 1)
+
+	result, payload = run(map[string]any{
+		"dry_run": true,
+		"steps": []any{
+			map[string]any{"id": "a", "tool": "web_serch", "args": map[string]any{"query": "${vars.missing}"}},
+			map[string]any{"id": "b", "tool": "web_fetch", "args": map[string]any{"url": "${steps.c.structured.url} ${item}"}},
+			map[string]any{"id": "c", "tool": "web_fetch", "retry": map[string]any{"max_attempts": 5}},
+		},
+	})
+	require.True(t, result.IsError)
+	issues := payload["issues"].([]pipeDryRunIssue)
+	require.Len(t, issues, 4)
+	require.Equal(t, "a: unknown tool: web_serch", payload["error"])
+	require.Contains(t, issues[1].Message, "vars.missing")
+	require.Contains(t, issues[2].Message, "steps.c.structured.url")
+	require.Contains(t, issues[3].Message, "only available inside for_each")
+	require.Equal(t, 7, payload["max_steps"])
+	require.Contains(t, payload["warnings"].([]pipeDryRunIssue)[0].Message, "above the limit of 5")
+}
+
+// TestMCPPipeTrace verifies the per-step timeline, including nested steps, retries and cost.
+func TestMCPPipeTrace(t *testing.T) {
+	var calls atomic.Int32
+	invoker := func(ctx context.Context, toolName string, args any) (*mcp.CallToolResult, error) {
+		RecordPipeStepCost(ctx, 10)
+		if toolName == "flaky" && calls.Add(1) == 1 {
+			return mcp.NewToolResultError("try again"), nil
+		}
+		return mcp.NewToolResultJSON(map[string]any{"ok": true})
+	}
+	tool, err := NewMCPPipeTool(log.Logger.Named("test_mcp_pipe_trace"), invoker, PipeLimits{})
+	require.NoError(t, err)
+
+	result, err := tool.Handle(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{
+		"trace": true,
+		"spec": map[string]any{
+			"steps": []any{
+				map[string]any{"id": "f", "tool": "flaky", "retry": map[string]any{"max_attempts": 2, "backoff_ms": 1}},
+				map[string]any{"id": "p", "pipe": map[string]any{"steps": []any{
+					map[string]any{"id": "inner", "tool": "echo", "args": map[string]any{"q": "x"}},
+				}}},
+				map[string]any{"id": "skip", "if": "vars.missing", "tool": "echo"},
+			},
+		},
+	}}})
+	require.NoError(t, err)
+	require.False(t, result.IsError, toolResultText(result))
+
+	trace := result.StructuredContent.(map[string]any)["trace"].(map[string]any)
+	require.Equal(t, int64(30), trace["total_cost"])
+	events := trace["events"].([]pipeTraceEvent)
+	byPath := map[string][]pipeTraceEvent{}
+	for _, event := range events {
+		byPath[event.Path] = append(byPath[event.Path], event)
+	}
+
+	require.Len(t, byPath["f"], 2)
+	require.False(t, byPath["f"][0].OK)
+	require.Equal(t, "try again", byPath["f"][0].Error)
+	require.Equal(t, 2, byPath["f"][1].Attempt)
+	require.Equal(t, int64(10), byPath["f"][1].Cost)
+
+	require.Len(t, byPath["p"], 1)
+	require.Equal(t, int64(10), byPath["p"][0].Cost)
+	inner := byPath["p/inner"]
+	require.Len(t, inner, 1)
+	require.Equal(t, argsDigest(map[string]any{"q": "x"}), inner[0].ArgsDigest)
+	require.NotEmpty(t, inner[0].ArgsDigest)
+
+	require.Len(t, byPath["skip"], 1)
+	require.True(t, byPath["skip"][0].Skipped)
+}
+
+// TestMCPPipeTransform verifies search → top 3 → fetch → extract without an agent round-trip.
+func TestMCPPipeTransform(t *testing.T) {
+	var mu sync.Mutex
+	var fetched []string
+	invoker := func(ctx context.Context, toolName string, args any) (*mcp.CallToolResult, error) {
+		reqArgs := args.(map[string]any)
+		switch toolName {
+		case "web_search":
+			return mcp.NewToolResultJSON(map[string]any{"results": []any{
+				map[string]any{"url": "https://a"}, map[string]any{"url": "https://b"},
+				map[string]any{"url": "https://c"}, map[string]any{"url": "https://d"},
+			}})
+		case "web_fetch":
+			mu.Lock()
+			fetched = append(fetched, reqArgs["url"].(string))
+			mu.Unlock()
+			return mcp.NewToolResultJSON(map[string]any{"content": "page " + reqArgs["url"].(string)})
+		case "extract_key_info":
+			return mcp.NewToolResultJSON(map[string]any{"materials": reqArgs["materials"]})
+		default:
+			return mcp.NewToolResultError("unknown tool"), nil
+		}
+	}
+	tool, err := NewMCPPipeTool(log.Logger.Named("test_mcp_pipe_transform"), invoker, PipeLimits{})
+	require.NoError(t, err)
+
+	result, err := tool.Handle(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{
+		"steps": []any{
+			map[string]any{"id": "search", "tool": "web_search", "args": map[string]any{"query": "golang"}},
+			map[string]any{"id": "top", "transform": "steps.search.structured.results[:3].url"},
+			map[string]any{"id": "pages", "for_each": map[string]any{
+				"items": map[string]any{"$ref": "steps.top.result"},
+				"step":  map[string]any{"id": "get", "tool": "web_fetch", "args": map[string]any{"url": "${item}"}},
+			}},
+			map[string]any{"id": "joined", "transform": map[string]any{"expr": "join('\n', steps.pages.items[*].structured.content)"}},
+			map[string]any{"id": "extract", "tool": "extract_key_info", "args": map[string]any{"materials": "${steps.joined.result}"}},
+		},
+		"return": map[string]any{"$ref": "steps.extract.structured.materials"},
+	}}})
+	require.NoError(t, err)
+	require.False(t, result.IsError, toolResultText(result))
+	require.Equal(t, "page https://a\npage https://b\npage https://c", result.StructuredContent.(map[string]any)["result"])
+	require.ElementsMatch(t, []string{"https://a", "https://b", "https://c"}, fetched)
+
+	result, err = tool.Handle(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{
+		"steps": []any{map[string]any{"id": "bad", "transform": "steps.[", "tool": "web_search"}},
+	}}})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Contains(t, toolResultText(result), "exactly one of")
+
+	result, err = tool.Handle(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{
+		"steps": []any{map[string]any{"id": "bad", "transform": "steps.["}},
+	}}})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Contains(t, toolResultText(result), "invalid transform")
+}
@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// pipeTrace collects the execution timeline of a pipeline run with trace enabled.
+type pipeTrace struct {
+	mu        sync.Mutex
+	startedAt time.Time
+	root      *pipeTraceAttempt
+	events    []pipeTraceEvent
+}
+
+// pipeTraceEvent describes one executed (or skipped) step attempt.
+type pipeTraceEvent struct {
+	// Path locates the step inside nested pipes, parallel groups and loops,
+	// for example "fetch/page[2]/get".
+	Path       string `json:"path"`
+	ID         string `json:"id"`
+	Kind       string `json:"kind"`
+	Tool       string `json:"tool,omitempty"`
+	Attempt    int    `json:"attempt,omitempty"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	StartedAt  string `json:"started_at"`
+	EndedAt    string `json:"ended_at"`
+	OffsetMS   int64  `json:"offset_ms"`
+	DurationMS int64  `json:"duration_ms"`
+	OK         bool   `json:"ok"`
+	Error      string `json:"error,omitempty"`
+	// Cost is the billed amount reported by the tools run within this attempt,
+	// including nested steps.
+	Cost       int64  `json:"cost"`
+	ArgsDigest string `json:"args_digest,omitempty"`
+
+	started time.Time
+}
+
+// pipeTraceAttempt accumulates the cost and argument digest of one step attempt.
+// Costs propagate to every enclosing attempt so parent steps report totals.
+type pipeTraceAttempt struct {
+	parent     *pipeTraceAttempt
+	cost       atomic.Int64
+	argsDigest string
+}
+
+// pipeTraceScope is stored in the context while a traced pipeline runs.
+type pipeTraceScope struct {
+	trace   *pipeTrace
+	path    string
+	attempt *pipeTraceAttempt
+}
+
+type pipeTraceCtxKey struct{}
+
+// newPipeTrace starts a new trace timeline.
+func newPipeTrace() *pipeTrace {
+	return &pipeTrace{
+		startedAt: time.Now().UTC(),
+		root:      &pipeTraceAttempt{},
+	}
+}
+
+// withPipeTrace attaches the trace to ctx so nested steps record into it.
+func withPipeTrace(ctx context.Context, trace *pipeTrace) context.Context {
+	return context.WithValue(ctx, pipeTraceCtxKey{}, &pipeTraceScope{trace: trace, attempt: trace.root})
+}
+
+// traceScopeFrom returns the active trace scope, or nil when tracing is off.
+func traceScopeFrom(ctx context.Context) *pipeTraceScope {
+	scope, _ := ctx.Value(pipeTraceCtxKey{}).(*pipeTraceScope)
+	return scope
+}
+
+// withTracePath appends a path segment for the steps executed under ctx.
+func withTracePath(ctx context.Context, segment string) context.Context {
+	scope := traceScopeFrom(ctx)
+	if scope == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, pipeTraceCtxKey{}, &pipeTraceScope{
+		trace:   scope.trace,
+		path:    joinTracePath(scope.path, segment),
+		attempt: scope.attempt,
+	})
+}
+
+// beginTraceAttempt opens a cost scope for one step attempt.
+// It returns ctx unchanged and a nil attempt when tracing is off.
+func beginTraceAttempt(ctx context.Context) (context.Context, *pipeTraceAttempt) {
+	scope := traceScopeFrom(ctx)
+	if scope == nil {
+		return ctx, nil
+	}
+	attempt := &pipeTraceAttempt{parent: scope.attempt}
+	return context.WithValue(ctx, pipeTraceCtxKey{}, &pipeTraceScope{
+		trace:   scope.trace,
+		path:    scope.path,
+		attempt: attempt,
+	}), attempt
+}
+
+// RecordPipeStepCost attributes a billed tool cost to the mcp_pipe step running
+// under ctx. It is a no-op outside a traced pipeline.
+func RecordPipeStepCost(ctx context.Context, cost int64) {
+	scope := traceScopeFrom(ctx)
+	if scope == nil {
+		return
+	}
+	for attempt := scope.attempt; attempt != nil; attempt = attempt.parent {
+		attempt.cost.Add(cost)
+	}
+}
+
+// recordTraceArgs stores the digest of the resolved tool arguments for the current attempt.
+func recordTraceArgs(ctx context.Context, args any) {
+	scope := traceScopeFrom(ctx)
+	if scope == nil || scope.attempt == nil {
+		return
+	}
+	scope.attempt.argsDigest = argsDigest(args)
+}
+
+// finishTraceAttempt appends the timeline event for a completed attempt.
+func finishTraceAttempt(ctx context.Context, attempt *pipeTraceAttempt, step pipeStep, number int, startedAt time.Time, err error) {
+	scope := traceScopeFrom(ctx)
+	if scope == nil || attempt == nil {
+		return
+	}
+	event := scope.trace.newEvent(scope.path, step, startedAt)
+	event.Attempt = number
+	event.OK = err == nil
+	event.Error = errorString(err)
+	event.Cost = attempt.cost.Load()
+	event.ArgsDigest = attempt.argsDigest
+	scope.trace.add(event)
+}
+
+// traceSkipped records a step whose if condition evaluated to false.
+func traceSkipped(ctx context.Context, step pipeStep) {
+	scope := traceScopeFrom(ctx)
+	if scope == nil {
+		return
+	}
+	event := scope.trace.newEvent(scope.path, step, time.Now())
+	event.Skipped = true
+	event.OK = true
+	scope.trace.add(event)
+}
+
+// newEvent builds an event for step that started at startedAt and ends now.
+func (t *pipeTrace) newEvent(path string, step pipeStep, startedAt time.Time) pipeTraceEvent {
+	startedAt = startedAt.UTC()
+	endedAt := time.Now().UTC()
+	return pipeTraceEvent{
+		Path:       joinTracePath(path, step.ID),
+		ID:         step.ID,
+		Kind:       stepKind(step),
+		Tool:       step.Tool,
+		StartedAt:  startedAt.Format(time.RFC3339Nano),
+		EndedAt:    endedAt.Format(time.RFC3339Nano),
+		OffsetMS:   startedAt.Sub(t.startedAt).Milliseconds(),
+		DurationMS: endedAt.Sub(startedAt).Milliseconds(),
+		started:    startedAt,
+	}
+}
+
+// add appends an event to the timeline.
+func (t *pipeTrace) add(event pipeTraceEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+// summary returns the timeline ordered by start time together with run totals.
+func (t *pipeTrace) summary() map[string]any {
+	t.mu.Lock()
+	events := slices.Clone(t.events)
+	t.mu.Unlock()
+
+	slices.SortStableFunc(events, func(a, b pipeTraceEvent) int {
+		if c := a.started.Compare(b.started); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	if events == nil {
+		events = []pipeTraceEvent{}
+	}
+
+	return map[string]any{
+		"started_at":  t.startedAt.Format(time.RFC3339Nano),
+		"duration_ms": time.Since(t.startedAt).Milliseconds(),
+		"total_cost":  t.root.cost.Load(),
+		"events":      events,
+	}
+}
+
+// joinTracePath joins step path segments with "/".
+func joinTracePath(prefix, segment string) string {
+	if prefix == "" {
+		return segment
+	}
+	return prefix + "/" + segment
+}
+
+// argsDigest returns a short stable digest of resolved tool arguments, so
+// attempts can be compared without echoing possibly sensitive values.
+func argsDigest(args any) string {
+	data, err := json.Marshal(normalizeJSONCompatible(args))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
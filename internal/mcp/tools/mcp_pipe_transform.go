@@ -0,0 +1,178 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	jmespath "github.com/jmespath-community/go-jmespath"
+	"github.com/jmespath-community/go-jmespath/pkg/parsing"
+)
+
+// pipeTransform reshapes data between steps without calling a tool.
+// A JSON string is shorthand for {"expr": "<expression>"}.
+type pipeTransform struct {
+	// Expr is a JMESPath expression evaluated against the pipeline env
+	// (vars, steps, last, and item/index inside for_each).
+	Expr string `json:"expr"`
+}
+
+// UnmarshalJSON accepts either a transform object or an expression string.
+func (tr *pipeTransform) UnmarshalJSON(data []byte) error {
+	var expr string
+	if err := json.Unmarshal(data, &expr); err == nil {
+		*tr = pipeTransform{Expr: expr}
+		return nil
+	}
+
+	type alias pipeTransform
+	var decoded alias
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return errors.Wrap(err, "decode transform")
+	}
+	*tr = pipeTransform(decoded)
+	return nil
+}
+
+// maxTransformExprLength bounds the size of a transform expression.
+const maxTransformExprLength = 4096
+
+// compileTransformExpr checks the size of expr and compiles it.
+func compileTransformExpr(expr string) (jmespath.JMESPath, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, errors.New("expression cannot be empty")
+	}
+	if len(expr) > maxTransformExprLength {
+		return nil, errors.Errorf("expression exceeds %d characters", maxTransformExprLength)
+	}
+	compiled, err := jmespath.Compile(expr)
+	if err != nil {
+		return nil, errors.Wrap(err, "compile expression")
+	}
+	return compiled, nil
+}
+
+// validateTransform checks that the transform expression compiles.
+func validateTransform(step pipeStep) error {
+	if _, err := compileTransformExpr(step.Transform.Expr); err != nil {
+		return errors.Wrapf(err, "step %s: invalid transform", step.ID)
+	}
+	return nil
+}
+
+// executeTransform evaluates the transform expression against env.
+// The value is exposed as steps.<id>.result.
+func executeTransform(step pipeStep, env map[string]any) (map[string]any, error) {
+	startedAt := time.Now().UTC()
+	value, err := evalTransform(step.Transform.Expr, env)
+	if err != nil {
+		err = errors.Wrapf(err, "step %s: transform", step.ID)
+	}
+
+	result := map[string]any{
+		"id":          step.ID,
+		"kind":        "transform",
+		"started_at":  startedAt.Format(time.RFC3339Nano),
+		"duration_ms": time.Since(startedAt).Milliseconds(),
+		"ok":          err == nil,
+		"error":       errorString(err),
+		"result":      value,
+	}
+	return result, err
+}
+
+// evalTransform compiles and evaluates expr over the generic JSON view of env.
+func evalTransform(expr string, env map[string]any) (any, error) {
+	compiled, err := compileTransformExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	root, err := transformEnv(env)
+	if err != nil {
+		return nil, err
+	}
+	value, err := compiled.Search(root)
+	if err != nil {
+		return nil, errors.Wrap(err, "evaluate expression")
+	}
+	return value, nil
+}
+
+// transformEnv returns a JSON copy of the env roots visible to transform
+// expressions. The copy keeps every number a float64, as the evaluator
+// expects, and keeps functions such as sort_by, which sort their argument in
+// place, from reordering earlier step results.
+func transformEnv(env map[string]any) (map[string]any, error) {
+	roots := make(map[string]any, len(env))
+	for _, key := range []string{"vars", "steps", "last", "item", "index"} {
+		if v, ok := env[key]; ok {
+			roots[key] = v
+		}
+	}
+	data, err := json.Marshal(roots)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode transform env")
+	}
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, errors.Wrap(err, "decode transform env")
+	}
+	return out, nil
+}
+
+// transformRootRefs returns the leading field paths an expression reads from
+// the env root, e.g. "steps.search" for steps.search.structured.results[:3].
+// Dry runs check them like other references.
+func transformRootRefs(expr string) []string {
+	if _, err := compileTransformExpr(expr); err != nil {
+		return nil
+	}
+	node, err := jmespath.NewParser().Parse(expr)
+	if err != nil {
+		return nil
+	}
+	var refs []string
+	collectRootRefs(node, &refs)
+	return refs
+}
+
+// collectRootRefs walks the nodes that are evaluated against the root value.
+// Projection bodies, filters and exprefs run against elements and are skipped.
+func collectRootRefs(node parsing.ASTNode, refs *[]string) {
+	if chain := fieldChain(node); len(chain) > 0 {
+		*refs = append(*refs, strings.Join(chain[:min(len(chain), 2)], "."))
+		return
+	}
+	switch node.NodeType {
+	case parsing.ASTSubexpression, parsing.ASTIndexExpression, parsing.ASTProjection,
+		parsing.ASTValueProjection, parsing.ASTFilterProjection, parsing.ASTFlatten, parsing.ASTPipe:
+		collectRootRefs(node.Children[0], refs)
+	case parsing.ASTMultiSelectList, parsing.ASTMultiSelectHash, parsing.ASTKeyValPair, parsing.ASTComparator,
+		parsing.ASTOrExpression, parsing.ASTAndExpression, parsing.ASTNotExpression,
+		parsing.ASTArithmeticExpression, parsing.ASTArithmeticUnaryExpression, parsing.ASTFunctionExpression:
+		for _, child := range node.Children {
+			collectRootRefs(child, refs)
+		}
+	}
+}
+
+// fieldChain returns the identifiers of a pure a.b.c field chain.
+func fieldChain(node parsing.ASTNode) []string {
+	switch node.NodeType {
+	case parsing.ASTField:
+		name, _ := node.Value.(string)
+		return []string{name}
+	case parsing.ASTSubexpression:
+		left := fieldChain(node.Children[0])
+		if len(left) == 0 {
+			return nil
+		}
+		if right := node.Children[1]; right.NodeType == parsing.ASTField {
+			name, _ := right.Value.(string)
+			return append(left, name)
+		}
+		return left
+	}
+	return nil
+}
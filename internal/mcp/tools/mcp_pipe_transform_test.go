@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestTransformExprEval covers the JMESPath features transform steps rely on.
+func TestTransformExprEval(t *testing.T) {
+	const fixture = `{
+		"results": [
+			{"url": "https://a", "score": 3, "tags": ["x", "y"]},
+			{"url": "https://b", "score": 9, "tags": ["y"]},
+			{"url": "https://c", "score": 5, "tags": []},
+			{"url": "https://d", "score": 1}
+		],
+		"meta": {"q": "golang", "lang": "en"},
+		"key with space": 1
+	}`
+
+	cases := []struct {
+		expr string
+		want string
+	}{
+		{"meta.q", `"golang"`},
+		{"missing.field", `null`},
+		{`"key with space"`, `1`},
+		{"results[0].url", `"https://a"`},
+		{"results[-1].url", `"https://d"`},
+		{"results[:2].url", `["https://a","https://b"]`},
+		{"results[::-1].score", `[1,5,9,3]`},
+		{"results[*].url | [1]", `"https://b"`},
+		{"results[].tags[]", `["x","y","y"]`},
+		{"results[?score > `4`].url", `["https://b","https://c"]`},
+		{"results[?contains(tags || `[]`, 'y')].url", `["https://a","https://b"]`},
+		{"results[?!tags].url", `["https://c","https://d"]`},
+		{"sort(meta.*)", `["en","golang"]`},
+		{"{first: results[0].url, count: length(results)}", `{"first":"https://a","count":4}`},
+		{"[meta.q, meta.lang]", `["golang","en"]`},
+		{"join(', ', results[:3].url)", `"https://a, https://b, https://c"`},
+		{"sort_by(results, &score)[-1].url", `"https://b"`},
+		{"max_by(results, &score).url", `"https://b"`},
+		{"map(&score, results)", `[3,9,5,1]`},
+		{"sum(results[*].score)", `18`},
+		{"avg(results[*].score)", `4.5`},
+		{"sort(keys(meta))", `["lang","q"]`},
+		{"not_null(missing, meta.q)", `"golang"`},
+		{"to_string(results[0].score)", `"3"`},
+		{"to_number('42')", `42`},
+		{"type(results)", `"array"`},
+		{"meta.q == 'golang' && length(results) >= `4`", `true`},
+		{"starts_with(meta.q, 'go')", `true`},
+		{"merge(meta, `{\"lang\": \"de\"}`).lang", `"de"`},
+		{"reverse('abc')", `"cba"`},
+		{"@.meta.lang", `"en"`},
+		{"(results[0].score)", `3`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			var data any
+			require.NoError(t, json.Unmarshal([]byte(fixture), &data))
+			compiled, err := compileTransformExpr(tc.expr)
+			require.NoError(t, err)
+			got, err := compiled.Search(data)
+			require.NoError(t, err)
+			encoded, err := json.Marshal(got)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.want, string(encoded))
+		})
+	}
+}
+
+// TestTransformExprErrors verifies syntax and runtime errors.
+func TestTransformExprErrors(t *testing.T) {
+	for _, expr := range []string{"", "a.", "a[", "a ==", "{a}", "'unterminated", "`{bad`", strings.Repeat("a", maxTransformExprLength+1)} {
+		_, err := compileTransformExpr(expr)
+		require.Error(t, err, expr)
+	}
+
+	_, err := evalTransform("length(vars.n)", map[string]any{"vars": map[string]any{"n": 1}})
+	require.ErrorContains(t, err, "evaluate expression")
+
+	_, err = evalTransform("sum(vars.words)", map[string]any{"vars": map[string]any{"words": []any{"a"}}})
+	require.ErrorContains(t, err, "evaluate expression")
+}
+
+// TestEvalTransformCopiesEnv verifies sorting inside an expression leaves
+// earlier step results in their original order.
+func TestEvalTransformCopiesEnv(t *testing.T) {
+	results := []any{map[string]any{"score": 3}, map[string]any{"score": 1}, map[string]any{"score": 2}}
+	env := map[string]any{"steps": map[string]any{"search": map[string]any{"results": results}}}
+
+	got, err := evalTransform("sort_by(steps.search.results, &score)[*].score", env)
+	require.NoError(t, err)
+	require.Equal(t, []any{float64(1), float64(2), float64(3)}, got)
+	require.Equal(t, 3, results[0].(map[string]any)["score"])
+}
+
+// TestTransformRootRefs verifies dry runs see the env roots an expression reads.
+func TestTransformRootRefs(t *testing.T) {
+	require.Equal(t, []string{"steps.search"}, transformRootRefs("steps.search.structured.results[:3].url"))
+	require.Equal(t, []string{"steps.search"}, transformRootRefs("steps.search.structured.results[0]"))
+	require.Equal(t, []string{"vars.q", "steps.fetch"}, transformRootRefs("{q: vars.q, pages: length(steps.fetch.items)}"))
+	require.Equal(t, []string{"last.items"}, transformRootRefs("last.items[?score > `1`].url"))
+	require.Nil(t, transformRootRefs("steps.["))
+}
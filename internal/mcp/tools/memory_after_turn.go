@@ -27,7 +27,7 @@ func (tool *MemoryAfterTurnTool) Definition() mcp.Tool {
 		"memory_after_turn",
 		mcp.WithDescription("Persist turn artifacts and update memory tiers after model response."),
 		mcp.WithString("project", mcp.Description("Target project namespace. Defaults to `default` when omitted.")),
-		fileToolPluginOption(),
+		fileToolPluginOption(tool.service),
 		mcp.WithString("session_id", mcp.Description("Session identifier. Defaults to `default` when omitted.")),
 		mcp.WithString("turn_id", mcp.Description("Turn identifier. Auto-generated when omitted.")),
 		mcp.WithString("user_id", mcp.Description("Optional user identifier.")),
@@ -30,7 +30,7 @@ func (tool *MemoryBeforeTurnTool) Definition() mcp.Tool {
 		"memory_before_turn",
 		mcp.WithDescription("Prepare model input with recalled memory context for the current turn."),
 		mcp.WithString("project", mcp.Description("Target project namespace. Defaults to `default` when omitted.")),
-		fileToolPluginOption(),
+		fileToolPluginOption(tool.service),
 		mcp.WithString("session_id", mcp.Description("Session identifier. Defaults to `default` when omitted.")),
 		mcp.WithString("turn_id", mcp.Description("Turn identifier. Auto-generated when omitted.")),
 		mcp.WithString("user_id", mcp.Description("Optional user identifier.")),
@@ -27,7 +27,7 @@ func (tool *MemoryListDirWithAbstractTool) Definition() mcp.Tool {
 		"memory_list_dir_with_abstract",
 		mcp.WithDescription("List memory directories and include abstract/overview metadata."),
 		mcp.WithString("project", mcp.Description("Target project namespace. Defaults to `default` when omitted.")),
-		fileToolPluginOption(),
+		fileToolPluginOption(tool.service),
 		mcp.WithString("session_id", mcp.Description("Session identifier. Defaults to `default` when omitted.")),
 		mcp.WithString("path", mcp.Description("Directory path relative to session root.")),
 		mcp.WithNumber("depth", mcp.Description("Directory traversal depth. Defaults to 8 when omitted.")),
@@ -32,7 +32,7 @@ func TestMemoryToolDefinitionsIncludePluginField(t *testing.T) {
 		require.True(t, ok, def.Name)
 		require.Equal(t, "string", property["type"])
 		require.Equal(t, "auto", property["default"])
-		require.Equal(t, []string{"rag", "pageindex", "auto"}, property["enum"])
+		require.Equal(t, []string{"rag", "auto"}, property["enum"])
 	}
 }
 
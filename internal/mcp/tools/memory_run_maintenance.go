@@ -27,7 +27,7 @@ func (tool *MemoryRunMaintenanceTool) Definition() mcp.Tool {
 		"memory_run_maintenance",
 		mcp.WithDescription("Run compaction, retention sweep, and summary refresh for one memory session."),
 		mcp.WithString("project", mcp.Description("Target project namespace. Defaults to `default` when omitted.")),
-		fileToolPluginOption(),
+		fileToolPluginOption(tool.service),
 		mcp.WithString("session_id", mcp.Description("Session identifier. Defaults to `default` when omitted.")),
 		mcp.WithReadOnlyHintAnnotation(false),
 		mcp.WithIdempotentHintAnnotation(true),
@@ -0,0 +1,183 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	errors "github.com/Laisky/errors/v2"
+	logSDK "github.com/Laisky/go-utils/v6/log"
+	"github.com/Laisky/zap"
+	"github.com/google/uuid"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/askuser"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/userrequests"
+)
+
+// SavedPipelineStore loads saved pipelines for pipe_run.
+type SavedPipelineStore interface {
+	GetSavedPipelineByName(context.Context, *askuser.AuthorizationContext, string) (*userrequests.SavedPipeline, error)
+	GetVisibleSavedPipeline(context.Context, *askuser.AuthorizationContext, uuid.UUID) (*userrequests.SavedPipeline, error)
+}
+
+// PipeRunTool implements the pipe_run MCP tool.
+//
+// It runs a pipeline saved under the caller's API key by name, or a pipeline
+// another key shared by ID, binding the call arguments to the pipeline's
+// declared params and executing the result through mcp_pipe.
+type PipeRunTool struct {
+	store          SavedPipelineStore
+	pipe           *MCPPipeTool
+	logger         logSDK.Logger
+	headerProvider AuthorizationHeaderProvider
+	parser         AuthorizationParser
+}
+
+// NewPipeRunTool constructs a PipeRunTool with the provided dependencies.
+func NewPipeRunTool(store SavedPipelineStore, pipe *MCPPipeTool, logger logSDK.Logger, headerProvider AuthorizationHeaderProvider, parser AuthorizationParser) (*PipeRunTool, error) {
+	if store == nil {
+		return nil, errors.New("saved pipeline store is required")
+	}
+	if pipe == nil {
+		return nil, errors.New("mcp_pipe tool is required")
+	}
+	if headerProvider == nil {
+		return nil, errors.New("authorization header provider is required")
+	}
+	if parser == nil {
+		return nil, errors.New("authorization parser is required")
+	}
+	if logger == nil {
+		logger = logSDK.Shared.Named("pipe_run_tool")
+	}
+
+	return &PipeRunTool{
+		store:          store,
+		pipe:           pipe,
+		logger:         logger,
+		headerProvider: headerProvider,
+		parser:         parser,
+	}, nil
+}
+
+// Definition returns the MCP metadata describing the tool.
+func (t *PipeRunTool) Definition() mcp.Tool {
+	return mcp.NewTool(
+		"pipe_run",
+		mcp.WithDescription("Run a saved mcp_pipe pipeline by name, or a shared one by ID. Arguments are checked against the pipeline's params and exposed to it as vars."),
+		mcp.WithString(
+			"name",
+			mcp.Description("Name of a pipeline saved under your API key. Provide name or id."),
+		),
+		mcp.WithString(
+			"id",
+			mcp.Description("ID of a pipeline you saved or another API key shared. Provide name or id."),
+		),
+		mcp.WithObject(
+			"args",
+			mcp.Description("Arguments for the pipeline's declared params, keyed by param name."),
+		),
+		mcp.WithBoolean(
+			"dry_run",
+			mcp.Description("Validate the bound pipeline without invoking any tool."),
+		),
+		mcp.WithBoolean(
+			"trace",
+			mcp.Description("Include a per-step execution timeline in the response."),
+		),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// Handle loads the saved pipeline, binds the arguments, and runs it through mcp_pipe.
+func (t *PipeRunTool) Handle(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := strings.TrimSpace(req.GetString("name", ""))
+	rawID := strings.TrimSpace(req.GetString("id", ""))
+	if (name == "") == (rawID == "") {
+		return mcp.NewToolResultError("provide exactly one of name or id"), nil
+	}
+	var id uuid.UUID
+	if rawID != "" {
+		parsed, err := uuid.Parse(rawID)
+		if err != nil {
+			return mcp.NewToolResultError("id must be a UUID"), nil
+		}
+		id = parsed
+	}
+
+	args, err := pipeRunArgs(req.GetArguments()["args"])
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	authCtx, err := t.parser(t.headerProvider(ctx))
+	if err != nil {
+		t.logger.Warn("pipe_run authorization failed", zap.Error(err))
+		return mcp.NewToolResultError("invalid authorization header"), nil
+	}
+
+	ref := name
+	var pipeline *userrequests.SavedPipeline
+	if name != "" {
+		pipeline, err = t.store.GetSavedPipelineByName(ctx, authCtx, name)
+	} else {
+		ref = id.String()
+		pipeline, err = t.store.GetVisibleSavedPipeline(ctx, authCtx, id)
+	}
+	if err != nil {
+		if errors.Is(err, userrequests.ErrSavedPipelineNotFound) {
+			return mcp.NewToolResultError("saved pipeline not found: " + ref), nil
+		}
+		t.logger.Error("pipe_run load saved pipeline", zap.String("pipeline", ref), zap.Error(err))
+		return mcp.NewToolResultError("failed to load saved pipeline"), nil
+	}
+	name = pipeline.Name
+
+	spec, err := pipeline.BuildSpec(args)
+	if err != nil {
+		if errors.Is(err, userrequests.ErrInvalidPipelineArgs) {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		t.logger.Error("pipe_run build spec", zap.String("name", name), zap.Error(err))
+		return mcp.NewToolResultError("failed to prepare saved pipeline"), nil
+	}
+
+	t.logger.Debug("pipe_run executing saved pipeline",
+		zap.String("name", name),
+		zap.String("pipeline_id", pipeline.ID.String()),
+		zap.Bool("shared", pipeline.APIKeyHash != authCtx.APIKeyHash),
+		zap.Strings("arg_keys", mapKeys(args)),
+	)
+
+	return t.pipe.Handle(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{
+		Name: "mcp_pipe",
+		Arguments: map[string]any{
+			"spec":    spec,
+			"dry_run": req.GetBool("dry_run", false),
+			"trace":   req.GetBool("trace", false),
+		},
+	}})
+}
+
+// pipeRunArgs accepts the args argument as an object, a JSON-encoded object, or nothing.
+func pipeRunArgs(raw any) (map[string]any, error) {
+	switch v := raw.(type) {
+	case nil:
+		return map[string]any{}, nil
+	case map[string]any:
+		return v, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return map[string]any{}, nil
+		}
+		var decoded map[string]any
+		if err := json.Unmarshal([]byte(v), &decoded); err != nil {
+			return nil, errors.New("args must be a JSON object")
+		}
+		return decoded, nil
+	default:
+		return nil, errors.New("args must be an object")
+	}
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/askuser"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/userrequests"
+	"github.com/Laisky/laisky-blog-graphql/library/log"
+)
+
+type fakeSavedPipelineStore struct {
+	pipelines map[string]*userrequests.SavedPipeline
+}
+
+func (s *fakeSavedPipelineStore) GetSavedPipelineByName(_ context.Context, auth *askuser.AuthorizationContext, name string) (*userrequests.SavedPipeline, error) {
+	pipeline, ok := s.pipelines[auth.APIKeyHash+"/"+name]
+	if !ok {
+		return nil, userrequests.ErrSavedPipelineNotFound
+	}
+	return pipeline, nil
+}
+
+func (s *fakeSavedPipelineStore) GetVisibleSavedPipeline(_ context.Context, auth *askuser.AuthorizationContext, id uuid.UUID) (*userrequests.SavedPipeline, error) {
+	for _, pipeline := range s.pipelines {
+		if pipeline.ID == id && (pipeline.APIKeyHash == auth.APIKeyHash || pipeline.Visibility == userrequests.SavedPipelineVisibilityShared) {
+			return pipeline, nil
+		}
+	}
+	return nil, userrequests.ErrSavedPipelineNotFound
+}
+
+// TestPipeRunSavedPipeline verifies that pipe_run binds arguments and executes the saved spec.
+func TestPipeRunSavedPipeline(t *testing.T) {
+	invoker := func(ctx context.Context, toolName string, args any) (*mcp.CallToolResult, error) {
+		reqArgs := args.(map[string]any)
+		require.Equal(t, "web_search", toolName)
+		return mcp.NewToolResultJSON(map[string]any{"query": reqArgs["query"]})
+	}
+	pipe, err := NewMCPPipeTool(log.Logger.Named("test_pipe_run_pipe"), invoker, PipeLimits{})
+	require.NoError(t, err)
+
+	sharedID := uuid.New()
+	privateID := uuid.New()
+	store := &fakeSavedPipelineStore{pipelines: map[string]*userrequests.SavedPipeline{
+		"hash/search": {
+			Name:   "search",
+			Spec:   `{"vars":{"suffix":"docs"},"steps":[{"id":"s","tool":"web_search","args":{"query":"${vars.q} ${vars.suffix}"}}],"return":{"$ref":"steps.s.structured.query"}}`,
+			Params: []userrequests.SavedPipelineParam{{Name: "q", Type: "string", Required: true}},
+		},
+		"teammate/shared": {
+			ID: sharedID, Name: "shared", APIKeyHash: "teammate", Visibility: userrequests.SavedPipelineVisibilityShared,
+			Spec:   `{"steps":[{"id":"s","tool":"web_search","args":{"query":"${vars.q}"}}],"return":{"$ref":"steps.s.structured.query"}}`,
+			Params: []userrequests.SavedPipelineParam{{Name: "q", Type: "string", Required: true}},
+		},
+		"teammate/private": {
+			ID: privateID, Name: "private", APIKeyHash: "teammate", Visibility: userrequests.SavedPipelineVisibilityPrivate,
+			Spec: `{"steps":[{"id":"s","tool":"web_search","args":{"query":"x"}}]}`,
+		},
+	}}
+	tool, err := NewPipeRunTool(store, pipe, log.Logger.Named("test_pipe_run"),
+		func(context.Context) string { return "Bearer token" },
+		func(string) (*askuser.AuthorizationContext, error) {
+			return &askuser.AuthorizationContext{APIKeyHash: "hash"}, nil
+		})
+	require.NoError(t, err)
+
+	call := func(args map[string]any) *mcp.CallToolResult {
+		result, err := tool.Handle(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+		require.NoError(t, err)
+		return result
+	}
+
+	result := call(map[string]any{"name": "search", "args": map[string]any{"q": "golang"}})
+	require.False(t, result.IsError)
+	structured := result.StructuredContent.(map[string]any)
+	require.Equal(t, "golang docs", structured["result"])
+
+	result = call(map[string]any{"name": "search", "args": `{"q":"mcp"}`})
+	require.False(t, result.IsError)
+	require.Equal(t, "mcp docs", result.StructuredContent.(map[string]any)["result"])
+
+	result = call(map[string]any{"name": "search"})
+	require.True(t, result.IsError)
+	require.Contains(t, toolResultText(result), "missing required argument")
+
+	result = call(map[string]any{"name": "search", "args": map[string]any{"q": "x", "other": 1}})
+	require.True(t, result.IsError)
+	require.Contains(t, toolResultText(result), "unknown argument")
+
+	result = call(map[string]any{"name": "missing"})
+	require.True(t, result.IsError)
+	require.Contains(t, toolResultText(result), "saved pipeline not found")
+
+	// Another key's pipeline runs by ID once it is shared, never by name.
+	result = call(map[string]any{"id": sharedID.String(), "args": map[string]any{"q": "team"}})
+	require.False(t, result.IsError, toolResultText(result))
+	require.Equal(t, "team", result.StructuredContent.(map[string]any)["result"])
+	result = call(map[string]any{"name": "shared", "args": map[string]any{"q": "team"}})
+	require.True(t, result.IsError)
+	result = call(map[string]any{"id": privateID.String()})
+	require.True(t, result.IsError)
+	require.Contains(t, toolResultText(result), "saved pipeline not found")
+
+	for _, args := range []map[string]any{{}, {"name": "search", "id": sharedID.String()}, {"id": "nope"}} {
+		require.True(t, call(args).IsError, "%v", args)
+	}
+}
@@ -0,0 +1,371 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	logSDK "github.com/Laisky/go-utils/v6/log"
+	"github.com/Laisky/zap"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+
+	mcpauth "github.com/Laisky/laisky-blog-graphql/internal/mcp/auth"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/rag"
+	"github.com/Laisky/laisky-blog-graphql/library/billing/oneapi"
+)
+
+// maxCorpusUploadPaths bounds how many FileIO files one rag_corpus_upload call may read.
+const maxCorpusUploadPaths = 20
+
+// CorpusService defines the subset of rag.Service methods required by the rag_corpus_* tools.
+type CorpusService interface {
+	AddCorpusMaterials(context.Context, rag.CorpusMaterialsInput) (*rag.CorpusUpload, error)
+	ListCorpora(context.Context, string) ([]rag.CorpusInfo, error)
+	DeleteCorpus(context.Context, string, string) error
+}
+
+// CorpusFileReader reads FileIO files whose content is added to a corpus.
+type CorpusFileReader interface {
+	Read(ctx context.Context, auth files.AuthContext, project, path string, offset, length int64) (files.ReadResult, error)
+}
+
+// RAGCorpusUploadTool exposes the rag_corpus_upload MCP capability.
+type RAGCorpusUploadTool struct {
+	service        CorpusService
+	logger         logSDK.Logger
+	headerProvider AuthorizationHeaderProvider
+	billingChecker BillingChecker
+	settings       rag.Settings
+	fileReader     CorpusFileReader
+}
+
+// NewRAGCorpusUploadTool wires the dependencies for the rag_corpus_upload handler.
+func NewRAGCorpusUploadTool(service CorpusService, logger logSDK.Logger, headerProvider AuthorizationHeaderProvider, billingChecker BillingChecker, settings rag.Settings) (*RAGCorpusUploadTool, error) {
+	if service == nil {
+		return nil, errors.New("rag service is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if headerProvider == nil {
+		return nil, errors.New("authorization header provider is required")
+	}
+	if billingChecker == nil {
+		return nil, errors.New("billing checker is required")
+	}
+	if settings.TopKDefault == 0 {
+		settings = rag.LoadSettingsFromConfig()
+	}
+
+	return &RAGCorpusUploadTool{
+		service:        service,
+		logger:         logger,
+		headerProvider: headerProvider,
+		billingChecker: billingChecker,
+		settings:       settings,
+	}, nil
+}
+
+// WithFileReader enables loading corpus materials from FileIO paths.
+func (t *RAGCorpusUploadTool) WithFileReader(reader CorpusFileReader) *RAGCorpusUploadTool {
+	if t == nil {
+		return nil
+	}
+	t.fileReader = reader
+	return t
+}
+
+// Definition returns the MCP metadata for rag_corpus_upload.
+func (t *RAGCorpusUploadTool) Definition() mcp.Tool {
+	return mcp.NewTool(
+		"rag_corpus_upload",
+		mcp.WithDescription("Add materials to a named, persistent RAG corpus so extract_key_info can query it by name without resending them. Creates the corpus when missing. Materials already uploaded are not embedded again; uploading to an existing source label replaces its content."),
+		mcp.WithString("corpus", mcp.Required(), mcp.Description("Corpus name: 1-64 lowercase letters, digits, '_' or '-', starting with a letter.")),
+		mcp.WithString("description", mcp.Description("Optional corpus description; replaces the current one when set.")),
+		mcp.WithString("materials", mcp.Description("Inline source text to add.")),
+		mcp.WithString("source", mcp.Description("Label for the inline materials. Defaults to a digest of the text.")),
+		mcp.WithString("project", mcp.Description("FileIO project to read paths from.")),
+		mcp.WithArray(
+			"paths",
+			mcp.Description(fmt.Sprintf("FileIO text files to add (at most %d). Each file is stored under the source label file:<project>/<path>.", maxCorpusUploadPaths)),
+			mcp.WithStringItems(),
+		),
+	)
+}
+
+// corpusUploadItem is one labelled materials payload of an upload call.
+type corpusUploadItem struct {
+	source    string
+	materials string
+}
+
+// Handle executes the rag_corpus_upload workflow.
+func (t *RAGCorpusUploadTool) Handle(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, err := req.RequireString("corpus")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	name = strings.TrimSpace(name)
+	if err := rag.ValidateCorpusName(name); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	materials := strings.TrimSpace(req.GetString("materials", ""))
+	project := strings.TrimSpace(req.GetString("project", ""))
+	paths := req.GetStringSlice("paths", nil)
+	if materials == "" && len(paths) == 0 {
+		return mcp.NewToolResultError("provide materials or paths"), nil
+	}
+	if len(paths) > 0 {
+		if t.fileReader == nil {
+			return mcp.NewToolResultError("paths require FileIO, which is not enabled"), nil
+		}
+		if project == "" {
+			return mcp.NewToolResultError("project is required when paths are set"), nil
+		}
+		if len(paths) > maxCorpusUploadPaths {
+			return mcp.NewToolResultError(fmt.Sprintf("at most %d paths can be uploaded per call", maxCorpusUploadPaths)), nil
+		}
+	}
+	if len(materials) > t.settings.MaxMaterialsSize {
+		return mcp.NewToolResultError(fmt.Sprintf("materials exceed maximum size (%d bytes)", t.settings.MaxMaterialsSize)), nil
+	}
+
+	header := t.headerProvider(ctx)
+	authCtx, err := mcpauth.FromContextOrHeader(ctx, header)
+	if err != nil {
+		t.logger.Warn("rag_corpus_upload authorization failed", zap.Error(err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var items []corpusUploadItem
+	if materials != "" {
+		items = append(items, corpusUploadItem{source: strings.TrimSpace(req.GetString("source", "")), materials: materials})
+	}
+	fileAuth := files.AuthContext{
+		APIKey:       authCtx.APIKey,
+		APIKeyHash:   authCtx.APIKeyHash,
+		UserID:       authCtx.UserID,
+		UserIdentity: authCtx.UserIdentity,
+	}
+	for _, path := range paths {
+		content, errResult := t.readFile(ctx, fileAuth, project, path)
+		if errResult != nil {
+			return errResult, nil
+		}
+		items = append(items, corpusUploadItem{source: "file:" + project + "/" + strings.TrimPrefix(path, "/"), materials: content})
+	}
+
+	if err := t.billingChecker(ctx, authCtx.APIKey, oneapi.PriceExtractKeyInfo, "rag_corpus_upload"); err != nil {
+		t.logger.Warn("rag_corpus_upload billing denied", zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("billing check failed: %v", err)), nil
+	}
+
+	created := false
+	sources := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		upload, err := t.service.AddCorpusMaterials(ctx, rag.CorpusMaterialsInput{
+			UserID:      authCtx.UserID,
+			APIKey:      authCtx.APIKey,
+			Corpus:      name,
+			Description: req.GetString("description", ""),
+			Source:      item.source,
+			Materials:   item.materials,
+		})
+		if err != nil {
+			if errors.Is(err, rag.ErrCorpusLimitReached) {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			t.logger.Error("rag_corpus_upload failed", zap.Error(err), zap.String("user_id", authCtx.UserID), zap.String("corpus", name))
+			return mcp.NewToolResultError(fmt.Sprintf("failed to add %s to corpus; %d earlier source(s) were stored", describeCorpusSource(item.source), len(sources))), nil
+		}
+		created = created || upload.Created
+		sources = append(sources, map[string]any{
+			"source":         upload.Source.Source,
+			"materials_hash": upload.Source.MaterialsHash,
+			"bytes":          upload.Source.Bytes,
+			"chunks":         upload.Source.ChunkCount,
+			"reused":         upload.Reused,
+			"replaced":       upload.Replaced,
+		})
+	}
+
+	result, err := mcp.NewToolResultJSON(map[string]any{
+		"corpus":  name,
+		"created": created,
+		"sources": sources,
+	})
+	if err != nil {
+		t.logger.Error("rag_corpus_upload encode response", zap.Error(err))
+		return mcp.NewToolResultError("failed to encode rag_corpus_upload response"), nil
+	}
+	return result, nil
+}
+
+// readFile loads a whole FileIO text file for upload.
+func (t *RAGCorpusUploadTool) readFile(ctx context.Context, auth files.AuthContext, project, path string) (string, *mcp.CallToolResult) {
+	result, err := t.fileReader.Read(ctx, auth, project, path, 0, -1)
+	if err != nil {
+		return "", fileToolErrorFromErr(err)
+	}
+	if result.ContentEncoding != "" && result.ContentEncoding != "utf-8" {
+		return "", mcp.NewToolResultError(fmt.Sprintf("%s is not a text file", path))
+	}
+	if strings.TrimSpace(result.Content) == "" {
+		return "", mcp.NewToolResultError(fmt.Sprintf("%s is empty", path))
+	}
+	if len(result.Content) > t.settings.MaxMaterialsSize {
+		return "", mcp.NewToolResultError(fmt.Sprintf("%s exceeds maximum size (%d bytes)", path, t.settings.MaxMaterialsSize))
+	}
+	return result.Content, nil
+}
+
+// describeCorpusSource names a source in error messages.
+func describeCorpusSource(source string) string {
+	if source == "" {
+		return "materials"
+	}
+	return source
+}
+
+// RAGCorpusListTool exposes the rag_corpus_list MCP capability.
+type RAGCorpusListTool struct {
+	service        CorpusService
+	logger         logSDK.Logger
+	headerProvider AuthorizationHeaderProvider
+}
+
+// NewRAGCorpusListTool wires the dependencies for the rag_corpus_list handler.
+func NewRAGCorpusListTool(service CorpusService, logger logSDK.Logger, headerProvider AuthorizationHeaderProvider) (*RAGCorpusListTool, error) {
+	if service == nil {
+		return nil, errors.New("rag service is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if headerProvider == nil {
+		return nil, errors.New("authorization header provider is required")
+	}
+	return &RAGCorpusListTool{service: service, logger: logger, headerProvider: headerProvider}, nil
+}
+
+// Definition returns the MCP metadata for rag_corpus_list.
+func (t *RAGCorpusListTool) Definition() mcp.Tool {
+	return mcp.NewTool(
+		"rag_corpus_list",
+		mcp.WithDescription("List the persistent RAG corpora owned by the caller, with their sources and chunk counts."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+	)
+}
+
+// Handle executes the rag_corpus_list workflow.
+func (t *RAGCorpusListTool) Handle(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	authCtx, err := mcpauth.FromContextOrHeader(ctx, t.headerProvider(ctx))
+	if err != nil {
+		t.logger.Warn("rag_corpus_list authorization failed", zap.Error(err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	corpora, err := t.service.ListCorpora(ctx, authCtx.UserID)
+	if err != nil {
+		t.logger.Error("rag_corpus_list failed", zap.Error(err), zap.String("user_id", authCtx.UserID))
+		return mcp.NewToolResultError("failed to list corpora"), nil
+	}
+
+	items := make([]map[string]any, 0, len(corpora))
+	for _, corpus := range corpora {
+		sources := make([]map[string]any, 0, len(corpus.Sources))
+		for _, source := range corpus.Sources {
+			sources = append(sources, map[string]any{
+				"source":         source.Source,
+				"materials_hash": source.MaterialsHash,
+				"bytes":          source.Bytes,
+				"chunks":         source.ChunkCount,
+				"updated_at":     source.UpdatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+		items = append(items, map[string]any{
+			"name":        corpus.Name,
+			"description": corpus.Description,
+			"chunks":      corpus.Chunks,
+			"sources":     sources,
+			"created_at":  corpus.CreatedAt.UTC().Format(time.RFC3339),
+			"updated_at":  corpus.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	result, err := mcp.NewToolResultJSON(map[string]any{"corpora": items})
+	if err != nil {
+		t.logger.Error("rag_corpus_list encode response", zap.Error(err))
+		return mcp.NewToolResultError("failed to encode rag_corpus_list response"), nil
+	}
+	return result, nil
+}
+
+// RAGCorpusDeleteTool exposes the rag_corpus_delete MCP capability.
+type RAGCorpusDeleteTool struct {
+	service        CorpusService
+	logger         logSDK.Logger
+	headerProvider AuthorizationHeaderProvider
+}
+
+// NewRAGCorpusDeleteTool wires the dependencies for the rag_corpus_delete handler.
+func NewRAGCorpusDeleteTool(service CorpusService, logger logSDK.Logger, headerProvider AuthorizationHeaderProvider) (*RAGCorpusDeleteTool, error) {
+	if service == nil {
+		return nil, errors.New("rag service is required")
+	}
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if headerProvider == nil {
+		return nil, errors.New("authorization header provider is required")
+	}
+	return &RAGCorpusDeleteTool{service: service, logger: logger, headerProvider: headerProvider}, nil
+}
+
+// Definition returns the MCP metadata for rag_corpus_delete.
+func (t *RAGCorpusDeleteTool) Definition() mcp.Tool {
+	return mcp.NewTool(
+		"rag_corpus_delete",
+		mcp.WithDescription("Delete a persistent RAG corpus together with its stored chunks and embeddings."),
+		mcp.WithString("corpus", mcp.Required(), mcp.Description("Name of the corpus to delete.")),
+		mcp.WithDestructiveHintAnnotation(true),
+	)
+}
+
+// Handle executes the rag_corpus_delete workflow.
+func (t *RAGCorpusDeleteTool) Handle(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, err := req.RequireString("corpus")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	name = strings.TrimSpace(name)
+	if err := rag.ValidateCorpusName(name); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	authCtx, err := mcpauth.FromContextOrHeader(ctx, t.headerProvider(ctx))
+	if err != nil {
+		t.logger.Warn("rag_corpus_delete authorization failed", zap.Error(err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := t.service.DeleteCorpus(ctx, authCtx.UserID, name); err != nil {
+		if errors.Is(err, rag.ErrCorpusNotFound) {
+			return mcp.NewToolResultError(fmt.Sprintf("corpus %q not found", name)), nil
+		}
+		t.logger.Error("rag_corpus_delete failed", zap.Error(err), zap.String("user_id", authCtx.UserID), zap.String("corpus", name))
+		return mcp.NewToolResultError("failed to delete corpus"), nil
+	}
+
+	result, err := mcp.NewToolResultJSON(map[string]any{"deleted": name})
+	if err != nil {
+		t.logger.Error("rag_corpus_delete encode response", zap.Error(err))
+		return mcp.NewToolResultError("failed to encode rag_corpus_delete response"), nil
+	}
+	return result, nil
+}
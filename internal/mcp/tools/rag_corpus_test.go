@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/rag"
+	"github.com/Laisky/laisky-blog-graphql/library/billing/oneapi"
+	"github.com/Laisky/laisky-blog-graphql/library/log"
+)
+
+type stubCorpusService struct {
+	inputs  []rag.CorpusMaterialsInput
+	corpora []rag.CorpusInfo
+	deleted []string
+	err     error
+}
+
+func (s *stubCorpusService) AddCorpusMaterials(_ context.Context, input rag.CorpusMaterialsInput) (*rag.CorpusUpload, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.inputs = append(s.inputs, input)
+	return &rag.CorpusUpload{
+		Corpus:  input.Corpus,
+		Created: len(s.inputs) == 1,
+		Source:  rag.CorpusSource{Source: input.Source, Bytes: int64(len(input.Materials)), ChunkCount: 1},
+	}, nil
+}
+
+func (s *stubCorpusService) ListCorpora(context.Context, string) ([]rag.CorpusInfo, error) {
+	return s.corpora, s.err
+}
+
+func (s *stubCorpusService) DeleteCorpus(_ context.Context, _ string, name string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.deleted = append(s.deleted, name)
+	return nil
+}
+
+type stubCorpusFileReader struct {
+	files map[string]files.ReadResult
+}
+
+func (r *stubCorpusFileReader) Read(_ context.Context, _ files.AuthContext, project, path string, _, _ int64) (files.ReadResult, error) {
+	result, ok := r.files[project+"/"+path]
+	if !ok {
+		return files.ReadResult{}, files.NewError(files.ErrCodeNotFound, "file not found", false)
+	}
+	return result, nil
+}
+
+func newTestCorpusUploadTool(t *testing.T, svc CorpusService) *RAGCorpusUploadTool {
+	t.Helper()
+
+	settings := rag.Settings{TopKDefault: 2, TopKLimit: 5, MaxMaterialsSize: 1000}
+	tool, err := NewRAGCorpusUploadTool(
+		svc,
+		log.Logger.Named("rag_corpus_test"),
+		func(ctx context.Context) string { return "Bearer sk-test" },
+		func(context.Context, string, oneapi.Price, string) error { return nil },
+		settings,
+	)
+	require.NoError(t, err)
+	return tool
+}
+
+func TestRAGCorpusUploadTool_InlineAndFiles(t *testing.T) {
+	svc := &stubCorpusService{}
+	tool := newTestCorpusUploadTool(t, svc).WithFileReader(&stubCorpusFileReader{files: map[string]files.ReadResult{
+		"docs/guide.md": {Content: "guide text", ContentEncoding: "utf-8"},
+	}})
+
+	req := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{
+		"corpus":    "handbook",
+		"materials": "inline text",
+		"source":    "notes",
+		"project":   "docs",
+		"paths":     []any{"guide.md"},
+	}}}
+	result, err := tool.Handle(context.Background(), req)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	require.Len(t, svc.inputs, 2)
+	require.Equal(t, "notes", svc.inputs[0].Source)
+	require.Equal(t, "file:docs/guide.md", svc.inputs[1].Source)
+	require.Equal(t, "guide text", svc.inputs[1].Materials)
+	require.Equal(t, "sk-test", svc.inputs[1].APIKey)
+
+	payload := decodeToolPayload(t, result)
+	require.Equal(t, "handbook", payload["corpus"])
+	require.Equal(t, true, payload["created"])
+	require.Len(t, payload["sources"], 2)
+}
+
+func TestRAGCorpusUploadTool_Rejects(t *testing.T) {
+	cases := []struct {
+		name   string
+		args   map[string]any
+		reader CorpusFileReader
+	}{
+		{name: "invalid name", args: map[string]any{"corpus": "Bad Name", "materials": "text"}},
+		{name: "nothing to upload", args: map[string]any{"corpus": "handbook"}},
+		{name: "paths without fileio", args: map[string]any{"corpus": "handbook", "project": "docs", "paths": []any{"a.md"}}},
+		{
+			name:   "paths without project",
+			args:   map[string]any{"corpus": "handbook", "paths": []any{"a.md"}},
+			reader: &stubCorpusFileReader{},
+		},
+		{
+			name:   "missing file",
+			args:   map[string]any{"corpus": "handbook", "project": "docs", "paths": []any{"a.md"}},
+			reader: &stubCorpusFileReader{},
+		},
+		{
+			name: "binary file",
+			args: map[string]any{"corpus": "handbook", "project": "docs", "paths": []any{"a.png"}},
+			reader: &stubCorpusFileReader{files: map[string]files.ReadResult{
+				"docs/a.png": {Content: "iVBORw0KGgo=", ContentEncoding: "base64"},
+			}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &stubCorpusService{}
+			tool := newTestCorpusUploadTool(t, svc)
+			if tc.reader != nil {
+				tool.WithFileReader(tc.reader)
+			}
+			result, err := tool.Handle(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: tc.args}})
+			require.NoError(t, err)
+			require.True(t, result.IsError)
+			require.Empty(t, svc.inputs)
+		})
+	}
+}
+
+func TestRAGCorpusListAndDeleteTools(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	svc := &stubCorpusService{corpora: []rag.CorpusInfo{{
+		Corpus:  rag.Corpus{Name: "handbook", Description: "team handbook", CreatedAt: now, UpdatedAt: now},
+		Chunks:  3,
+		Sources: []rag.CorpusSource{{Source: "notes", Bytes: 10, ChunkCount: 3, UpdatedAt: now}},
+	}}}
+	header := func(ctx context.Context) string { return "Bearer sk-test" }
+
+	listTool, err := NewRAGCorpusListTool(svc, log.Logger.Named("rag_corpus_test"), header)
+	require.NoError(t, err)
+	result, err := listTool.Handle(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	corpora, ok := decodeToolPayload(t, result)["corpora"].([]any)
+	require.True(t, ok)
+	require.Len(t, corpora, 1)
+	require.Equal(t, "handbook", corpora[0].(map[string]any)["name"])
+	require.Equal(t, "2026-01-02T03:04:05Z", corpora[0].(map[string]any)["updated_at"])
+
+	deleteTool, err := NewRAGCorpusDeleteTool(svc, log.Logger.Named("rag_corpus_test"), header)
+	require.NoError(t, err)
+	result, err = deleteTool.Handle(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{"corpus": "handbook"}}})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Equal(t, []string{"handbook"}, svc.deleted)
+
+	svc.err = errors.Wrap(rag.ErrCorpusNotFound, "corpus")
+	result, err = deleteTool.Handle(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{"corpus": "missing"}}})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
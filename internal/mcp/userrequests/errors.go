@@ -15,6 +15,16 @@ var (
 	ErrSavedCommandNotFound = errors.New("saved command not found")
 	// ErrSavedCommandLimitReached indicates the user has reached the maximum number of saved commands.
 	ErrSavedCommandLimitReached = errors.New("saved command limit reached")
+	// ErrSavedPipelineNotFound is returned when a referenced saved pipeline cannot be located for the authenticated user.
+	ErrSavedPipelineNotFound = errors.New("saved pipeline not found")
+	// ErrSavedPipelineLimitReached indicates the user has reached the maximum number of saved pipelines.
+	ErrSavedPipelineLimitReached = errors.New("saved pipeline limit reached")
+	// ErrSavedPipelineNameTaken indicates another saved pipeline of the same user already uses the name.
+	ErrSavedPipelineNameTaken = errors.New("saved pipeline name already exists")
+	// ErrInvalidSavedPipeline indicates the saved pipeline name, spec, or params did not pass validation.
+	ErrInvalidSavedPipeline = errors.New("invalid saved pipeline")
+	// ErrInvalidPipelineArgs indicates pipe_run arguments do not match the saved pipeline params.
+	ErrInvalidPipelineArgs = errors.New("invalid pipeline arguments")
 	// ErrInvalidSearchQuery indicates the search query did not pass validation.
 	ErrInvalidSearchQuery = errors.New("invalid search query")
 	// ErrInvalidCursor indicates the cursor parameter did not pass validation.
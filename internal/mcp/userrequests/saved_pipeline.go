@@ -0,0 +1,349 @@
+package userrequests
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxSavedPipelinesPerUser is the maximum number of saved pipelines a single API key can store.
+	MaxSavedPipelinesPerUser = 100
+	// MaxSavedPipelineSpecLength is the maximum size in bytes of a saved pipeline spec.
+	MaxSavedPipelineSpecLength = 64 * 1024
+	// MaxSavedPipelineDescriptionLength is the maximum length allowed for a saved pipeline's description.
+	MaxSavedPipelineDescriptionLength = 1000
+	// MaxSavedPipelineParams is the maximum number of parameters a saved pipeline can declare.
+	MaxSavedPipelineParams = 32
+)
+
+// Parameter types accepted by SavedPipelineParam.Type.
+const (
+	PipelineParamString  = "string"
+	PipelineParamNumber  = "number"
+	PipelineParamInteger = "integer"
+	PipelineParamBoolean = "boolean"
+	PipelineParamArray   = "array"
+	PipelineParamObject  = "object"
+)
+
+// Visibilities accepted by SavedPipeline.Visibility.
+const (
+	// SavedPipelineVisibilityPrivate limits a pipeline to the API key that saved it.
+	SavedPipelineVisibilityPrivate = "private"
+	// SavedPipelineVisibilityShared lets any API key that knows the pipeline ID
+	// read and run it. Only the owner can change or delete it.
+	SavedPipelineVisibilityShared = "shared"
+)
+
+// savedPipelineNamePattern restricts pipeline and parameter names to identifiers
+// that are safe to use in tool arguments and ${vars.<name>} references.
+var savedPipelineNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,63}$`)
+
+// SavedPipeline is a named mcp_pipe spec stored per API key so it can be run by name through pipe_run.
+type SavedPipeline struct {
+	ID          uuid.UUID
+	Name        string
+	Description string
+	// Spec is the mcp_pipe spec as a JSON object.
+	Spec string
+	// Params declares the arguments pipe_run accepts; they are exposed to the spec as vars.
+	Params []SavedPipelineParam
+	// Visibility is SavedPipelineVisibilityPrivate or SavedPipelineVisibilityShared.
+	Visibility   string
+	APIKeyHash   string
+	KeySuffix    string
+	UserIdentity string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// TableName specifies the database table name for saved pipelines.
+func (SavedPipeline) TableName() string {
+	return "mcp_saved_pipelines"
+}
+
+// SavedPipelineParam declares one argument of a saved pipeline.
+type SavedPipelineParam struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Default     any    `json:"default,omitempty"`
+}
+
+// SavedPipelineInput carries the fields of a new saved pipeline.
+type SavedPipelineInput struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Spec        json.RawMessage      `json:"spec"`
+	Params      []SavedPipelineParam `json:"params"`
+	// Visibility defaults to SavedPipelineVisibilityPrivate.
+	Visibility string `json:"visibility"`
+}
+
+// SavedPipelineUpdate carries the fields to change on a saved pipeline; nil fields are left untouched.
+type SavedPipelineUpdate struct {
+	Name        *string               `json:"name,omitempty"`
+	Description *string               `json:"description,omitempty"`
+	Spec        json.RawMessage       `json:"spec,omitempty"`
+	Params      *[]SavedPipelineParam `json:"params,omitempty"`
+	Visibility  *string               `json:"visibility,omitempty"`
+}
+
+// SavedPipelineDTO is the data transfer object for saved pipeline API responses.
+type SavedPipelineDTO struct {
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Spec        json.RawMessage      `json:"spec"`
+	Params      []SavedPipelineParam `json:"params"`
+	Visibility  string               `json:"visibility"`
+	CreatedAt   string               `json:"created_at"`
+	UpdatedAt   string               `json:"updated_at"`
+}
+
+// ToDTO converts a SavedPipeline model to its DTO representation.
+func (p *SavedPipeline) ToDTO() SavedPipelineDTO {
+	params := p.Params
+	if params == nil {
+		params = []SavedPipelineParam{}
+	}
+	return SavedPipelineDTO{
+		ID:          p.ID.String(),
+		Name:        p.Name,
+		Description: p.Description,
+		Spec:        json.RawMessage(p.Spec),
+		Params:      params,
+		Visibility:  p.Visibility,
+		CreatedAt:   p.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   p.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// BuildSpec binds args to the declared parameters and returns the spec with
+// the bound values merged into its vars, overriding vars of the same name.
+func (p *SavedPipeline) BuildSpec(args map[string]any) (map[string]any, error) {
+	bound, err := bindPipelineArgs(p.Params, args)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var spec map[string]any
+	if err := json.Unmarshal([]byte(p.Spec), &spec); err != nil {
+		return nil, errors.Wrap(err, "decode saved pipeline spec")
+	}
+	vars, _ := spec["vars"].(map[string]any)
+	if vars == nil {
+		vars = map[string]any{}
+	}
+	for name, value := range bound {
+		vars[name] = value
+	}
+	spec["vars"] = vars
+	return spec, nil
+}
+
+// bindPipelineArgs validates args against params, fills defaults, and rejects unknown arguments.
+func bindPipelineArgs(params []SavedPipelineParam, args map[string]any) (map[string]any, error) {
+	declared := make(map[string]struct{}, len(params))
+	bound := make(map[string]any, len(params))
+	for _, param := range params {
+		declared[param.Name] = struct{}{}
+		value, ok := args[param.Name]
+		if !ok || value == nil {
+			switch {
+			case param.Default != nil:
+				bound[param.Name] = param.Default
+			case param.Required:
+				return nil, errors.Wrapf(ErrInvalidPipelineArgs, "missing required argument %q", param.Name)
+			}
+			continue
+		}
+		if !pipelineParamTypeMatches(param.Type, value) {
+			return nil, errors.Wrapf(ErrInvalidPipelineArgs, "argument %q must be of type %s", param.Name, pipelineParamType(param))
+		}
+		bound[param.Name] = value
+	}
+	for name := range args {
+		if _, ok := declared[name]; !ok {
+			return nil, errors.Wrapf(ErrInvalidPipelineArgs, "unknown argument %q", name)
+		}
+	}
+	return bound, nil
+}
+
+// normalizeSavedPipelineName trims and validates a pipeline name.
+func normalizeSavedPipelineName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if !savedPipelineNamePattern.MatchString(name) {
+		return "", errors.Wrapf(ErrInvalidSavedPipeline, "name %q must match %s", name, savedPipelineNamePattern.String())
+	}
+	return name, nil
+}
+
+// normalizeSavedPipelineVisibility validates a visibility, defaulting to private.
+func normalizeSavedPipelineVisibility(visibility string) (string, error) {
+	switch visibility = strings.ToLower(strings.TrimSpace(visibility)); visibility {
+	case "":
+		return SavedPipelineVisibilityPrivate, nil
+	case SavedPipelineVisibilityPrivate, SavedPipelineVisibilityShared:
+		return visibility, nil
+	default:
+		return "", errors.Wrapf(ErrInvalidSavedPipeline, "visibility must be %q or %q", SavedPipelineVisibilityPrivate, SavedPipelineVisibilityShared)
+	}
+}
+
+// normalizeSavedPipelineSpec checks that raw is a JSON object with a non-empty
+// steps array and returns its compact form. Step contents are validated by
+// mcp_pipe when the pipeline runs.
+func normalizeSavedPipelineSpec(raw json.RawMessage) (string, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return "", errors.Wrap(ErrInvalidSavedPipeline, "spec is required")
+	}
+	// Accept a spec sent as a JSON-encoded string, like mcp_pipe does.
+	if trimmed[0] == '"' {
+		var encoded string
+		if err := json.Unmarshal(trimmed, &encoded); err != nil {
+			return "", errors.Wrap(ErrInvalidSavedPipeline, "spec is not valid JSON")
+		}
+		trimmed = bytes.TrimSpace([]byte(encoded))
+	}
+	if len(trimmed) > MaxSavedPipelineSpecLength {
+		return "", errors.Wrapf(ErrInvalidSavedPipeline, "spec exceeds %d bytes", MaxSavedPipelineSpecLength)
+	}
+
+	var spec map[string]any
+	if err := json.Unmarshal(trimmed, &spec); err != nil {
+		return "", errors.Wrap(ErrInvalidSavedPipeline, "spec must be a JSON object")
+	}
+	steps, ok := spec["steps"].([]any)
+	if !ok || len(steps) == 0 {
+		return "", errors.Wrap(ErrInvalidSavedPipeline, "spec.steps must be a non-empty array")
+	}
+	if vars, ok := spec["vars"]; ok && vars != nil {
+		if _, ok := vars.(map[string]any); !ok {
+			return "", errors.Wrap(ErrInvalidSavedPipeline, "spec.vars must be an object")
+		}
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, trimmed); err != nil {
+		return "", errors.Wrap(ErrInvalidSavedPipeline, "spec is not valid JSON")
+	}
+	return compact.String(), nil
+}
+
+// normalizeSavedPipelineParams validates parameter declarations and fills the default type.
+func normalizeSavedPipelineParams(params []SavedPipelineParam) ([]SavedPipelineParam, error) {
+	if len(params) > MaxSavedPipelineParams {
+		return nil, errors.Wrapf(ErrInvalidSavedPipeline, "at most %d params are allowed", MaxSavedPipelineParams)
+	}
+	out := make([]SavedPipelineParam, 0, len(params))
+	seen := make(map[string]struct{}, len(params))
+	for _, param := range params {
+		param.Name = strings.TrimSpace(param.Name)
+		if !savedPipelineNamePattern.MatchString(param.Name) {
+			return nil, errors.Wrapf(ErrInvalidSavedPipeline, "param name %q must match %s", param.Name, savedPipelineNamePattern.String())
+		}
+		if _, ok := seen[param.Name]; ok {
+			return nil, errors.Wrapf(ErrInvalidSavedPipeline, "duplicate param %q", param.Name)
+		}
+		seen[param.Name] = struct{}{}
+
+		param.Type = strings.ToLower(strings.TrimSpace(param.Type))
+		if param.Type == "" {
+			param.Type = PipelineParamString
+		}
+		switch param.Type {
+		case PipelineParamString, PipelineParamNumber, PipelineParamInteger,
+			PipelineParamBoolean, PipelineParamArray, PipelineParamObject:
+		default:
+			return nil, errors.Wrapf(ErrInvalidSavedPipeline, "param %q has unsupported type %q", param.Name, param.Type)
+		}
+		if param.Default != nil && !pipelineParamTypeMatches(param.Type, param.Default) {
+			return nil, errors.Wrapf(ErrInvalidSavedPipeline, "default of param %q must be of type %s", param.Name, param.Type)
+		}
+		param.Description = strings.TrimSpace(param.Description)
+		out = append(out, param)
+	}
+	return out, nil
+}
+
+// pipelineParamType returns the effective type of a parameter.
+func pipelineParamType(param SavedPipelineParam) string {
+	if param.Type == "" {
+		return PipelineParamString
+	}
+	return param.Type
+}
+
+// pipelineParamTypeMatches reports whether a decoded JSON value fits the parameter type.
+func pipelineParamTypeMatches(paramType string, value any) bool {
+	switch paramType {
+	case "", PipelineParamString:
+		_, ok := value.(string)
+		return ok
+	case PipelineParamBoolean:
+		_, ok := value.(bool)
+		return ok
+	case PipelineParamNumber, PipelineParamInteger:
+		var f float64
+		switch v := value.(type) {
+		case float64:
+			f = v
+		case int:
+			f = float64(v)
+		case int64:
+			f = float64(v)
+		case json.Number:
+			parsed, err := v.Float64()
+			if err != nil {
+				return false
+			}
+			f = parsed
+		default:
+			return false
+		}
+		return paramType == PipelineParamNumber || f == math.Trunc(f)
+	case PipelineParamArray:
+		_, ok := value.([]any)
+		return ok
+	case PipelineParamObject:
+		_, ok := value.(map[string]any)
+		return ok
+	default:
+		return false
+	}
+}
+
+// encodeSavedPipelineParams serializes params for storage.
+func encodeSavedPipelineParams(params []SavedPipelineParam) (string, error) {
+	if params == nil {
+		params = []SavedPipelineParam{}
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", errors.Wrap(err, "encode saved pipeline params")
+	}
+	return string(data), nil
+}
+
+// decodeSavedPipelineParams parses stored params.
+func decodeSavedPipelineParams(raw string) ([]SavedPipelineParam, error) {
+	if strings.TrimSpace(raw) == "" {
+		return []SavedPipelineParam{}, nil
+	}
+	var params []SavedPipelineParam
+	if err := json.Unmarshal([]byte(raw), &params); err != nil {
+		return nil, errors.Wrap(err, "decode saved pipeline params")
+	}
+	return params, nil
+}
@@ -0,0 +1,281 @@
+package userrequests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	gmw "github.com/Laisky/gin-middlewares/v7"
+	logSDK "github.com/Laisky/go-utils/v6/log"
+	"github.com/Laisky/zap"
+	"github.com/google/uuid"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/askuser"
+	mcpauth "github.com/Laisky/laisky-blog-graphql/internal/mcp/auth"
+)
+
+const savedPipelinesAPIPath = "/api/saved-pipelines"
+
+// NewSavedPipelinesHTTPHandler constructs an HTTP mux exposing the saved pipelines APIs under /api/saved-pipelines.
+func NewSavedPipelinesHTTPHandler(service *Service, logger logSDK.Logger) http.Handler {
+	return mcpauth.HTTPMiddleware(&savedPipelinesHTTPHandler{service: service, logger: logger})
+}
+
+type savedPipelinesHTTPHandler struct {
+	service *Service
+	logger  logSDK.Logger
+}
+
+// ServeHTTP routes requests for saved pipelines endpoints.
+func (h *savedPipelinesHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == savedPipelinesAPIPath && r.Method == http.MethodGet:
+		h.handleList(w, r)
+	case r.URL.Path == savedPipelinesAPIPath && r.Method == http.MethodPost:
+		h.handleCreate(w, r)
+	case strings.HasPrefix(r.URL.Path, savedPipelinesAPIPath+"/") && r.Method == http.MethodGet:
+		h.handleGet(w, r)
+	case strings.HasPrefix(r.URL.Path, savedPipelinesAPIPath+"/") && r.Method == http.MethodPut:
+		h.handleUpdate(w, r)
+	case strings.HasPrefix(r.URL.Path, savedPipelinesAPIPath+"/") && r.Method == http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		logger := h.logFromCtx(r.Context())
+		h.writeErrorWithLogger(w, logger, http.StatusNotFound, "resource not found")
+	}
+}
+
+func (h *savedPipelinesHTTPHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	logger := h.logFromCtx(ctx)
+
+	service := h.service
+	if service == nil {
+		h.writeErrorWithLogger(w, logger, http.StatusServiceUnavailable, "saved pipelines service unavailable")
+		return
+	}
+
+	auth, err := askuser.ParseAuthorizationFromContext(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	pipelines, err := service.ListSavedPipelines(ctx, auth)
+	if err != nil {
+		logger.Error("list saved pipelines", zap.Error(err), zap.String("api_key_hash", auth.APIKeyHash))
+		h.writeErrorWithLogger(w, logger, http.StatusInternalServerError, "failed to load saved pipelines")
+		return
+	}
+
+	dtos := make([]SavedPipelineDTO, 0, len(pipelines))
+	for _, pipeline := range pipelines {
+		dtos = append(dtos, pipeline.ToDTO())
+	}
+
+	h.writeJSON(w, map[string]any{
+		"pipelines": dtos,
+		"user_id":   auth.UserIdentity,
+		"key_hint":  auth.KeySuffix,
+	})
+}
+
+func (h *savedPipelinesHTTPHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	logger := h.logFromCtx(ctx)
+
+	service := h.service
+	if service == nil {
+		h.writeErrorWithLogger(w, logger, http.StatusServiceUnavailable, "saved pipelines service unavailable")
+		return
+	}
+
+	id, ok := h.parseID(w, r, logger)
+	if !ok {
+		return
+	}
+
+	auth, err := askuser.ParseAuthorizationFromContext(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	pipeline, err := service.GetVisibleSavedPipeline(ctx, auth, id)
+	if err != nil {
+		h.writeServiceError(w, logger, "get saved pipeline", "failed to load saved pipeline", err)
+		return
+	}
+
+	h.writeJSON(w, map[string]any{
+		"pipeline": pipeline.ToDTO(),
+	})
+}
+
+func (h *savedPipelinesHTTPHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	logger := h.logFromCtx(ctx)
+
+	service := h.service
+	if service == nil {
+		h.writeErrorWithLogger(w, logger, http.StatusServiceUnavailable, "saved pipelines service unavailable")
+		return
+	}
+
+	auth, err := askuser.ParseAuthorizationFromContext(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var payload SavedPipelineInput
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&payload); err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	pipeline, err := service.CreateSavedPipeline(ctx, auth, payload)
+	if err != nil {
+		h.writeServiceError(w, logger, "create saved pipeline", "failed to create saved pipeline", err)
+		return
+	}
+
+	h.writeJSON(w, map[string]any{
+		"pipeline": pipeline.ToDTO(),
+	})
+}
+
+func (h *savedPipelinesHTTPHandler) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	logger := h.logFromCtx(ctx)
+
+	service := h.service
+	if service == nil {
+		h.writeErrorWithLogger(w, logger, http.StatusServiceUnavailable, "saved pipelines service unavailable")
+		return
+	}
+
+	id, ok := h.parseID(w, r, logger)
+	if !ok {
+		return
+	}
+
+	auth, err := askuser.ParseAuthorizationFromContext(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var payload SavedPipelineUpdate
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&payload); err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	pipeline, err := service.UpdateSavedPipeline(ctx, auth, id, payload)
+	if err != nil {
+		h.writeServiceError(w, logger, "update saved pipeline", "failed to update saved pipeline", err)
+		return
+	}
+
+	h.writeJSON(w, map[string]any{
+		"pipeline": pipeline.ToDTO(),
+	})
+}
+
+func (h *savedPipelinesHTTPHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	logger := h.logFromCtx(ctx)
+
+	service := h.service
+	if service == nil {
+		h.writeErrorWithLogger(w, logger, http.StatusServiceUnavailable, "saved pipelines service unavailable")
+		return
+	}
+
+	id, ok := h.parseID(w, r, logger)
+	if !ok {
+		return
+	}
+
+	auth, err := askuser.ParseAuthorizationFromContext(r.Context(), r.Header.Get("Authorization"))
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if err := service.DeleteSavedPipeline(ctx, auth, id); err != nil {
+		h.writeServiceError(w, logger, "delete saved pipeline", "failed to delete saved pipeline", err)
+		return
+	}
+
+	h.writeJSON(w, map[string]any{"deleted": true})
+}
+
+// parseID extracts the pipeline ID from the URL path, writing a 400 response when it is invalid.
+func (h *savedPipelinesHTTPHandler) parseID(w http.ResponseWriter, r *http.Request, logger logSDK.Logger) (uuid.UUID, bool) {
+	trimmed := strings.TrimPrefix(r.URL.Path, savedPipelinesAPIPath+"/")
+	id, err := uuid.Parse(strings.TrimSpace(trimmed))
+	if err != nil {
+		h.writeErrorWithLogger(w, logger, http.StatusBadRequest, "invalid pipeline id")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// writeServiceError maps saved pipeline service errors to HTTP status codes.
+func (h *savedPipelinesHTTPHandler) writeServiceError(w http.ResponseWriter, logger logSDK.Logger, action, message string, err error) {
+	switch {
+	case errors.Is(err, ErrSavedPipelineNotFound):
+		h.writeErrorWithLogger(w, logger, http.StatusNotFound, ErrSavedPipelineNotFound.Error())
+	case errors.Is(err, ErrSavedPipelineNameTaken):
+		h.writeErrorWithLogger(w, logger, http.StatusConflict, ErrSavedPipelineNameTaken.Error())
+	case errors.Is(err, ErrInvalidSavedPipeline), errors.Is(err, ErrSavedPipelineLimitReached):
+		h.writeErrorWithLogger(w, logger, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrInvalidAuthorization):
+		h.writeErrorWithLogger(w, logger, http.StatusUnauthorized, err.Error())
+	default:
+		logger.Error(action, zap.Error(err))
+		h.writeErrorWithLogger(w, logger, http.StatusInternalServerError, message)
+	}
+}
+
+// writeErrorWithLogger writes an error response with the provided logger for context-aware logging.
+func (h *savedPipelinesHTTPHandler) writeErrorWithLogger(w http.ResponseWriter, logger logSDK.Logger, status int, message string) {
+	if status >= 500 {
+		logger.Error("saved pipelines http error", zap.Int("status", status), zap.String("message", message))
+	} else {
+		logger.Warn("saved pipelines http warning", zap.Int("status", status), zap.String("message", message))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": message}) //nolint:errchkjson // best-effort error response
+}
+
+func (h *savedPipelinesHTTPHandler) writeJSON(w http.ResponseWriter, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(payload) //nolint:errchkjson // best-effort JSON response
+}
+
+// logFromCtx extracts a context-aware logger from the context.
+// Falls back to the handler's logger or a shared logger if context logger is unavailable.
+func (h *savedPipelinesHTTPHandler) logFromCtx(ctx context.Context) logSDK.Logger {
+	if logger := gmw.GetLogger(ctx); logger != nil {
+		return logger.Named("saved_pipelines_http")
+	}
+	if h != nil && h.logger != nil {
+		return h.logger
+	}
+	return logSDK.Shared.Named("saved_pipelines_http")
+}
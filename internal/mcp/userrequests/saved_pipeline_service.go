@@ -0,0 +1,416 @@
+package userrequests
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	errors "github.com/Laisky/errors/v2"
+	gutils "github.com/Laisky/go-utils/v6"
+	"github.com/Laisky/zap"
+	"github.com/google/uuid"
+
+	"github.com/Laisky/laisky-blog-graphql/internal/mcp/askuser"
+)
+
+const savedPipelineColumns = `id, name, description, spec, params, visibility, api_key_hash, key_suffix, user_identity, created_at, updated_at`
+
+// CreateSavedPipeline stores a new named pipeline for the authenticated user.
+func (s *Service) CreateSavedPipeline(ctx context.Context, auth *askuser.AuthorizationContext, input SavedPipelineInput) (*SavedPipeline, error) {
+	if auth == nil {
+		return nil, ErrInvalidAuthorization
+	}
+
+	name, err := normalizeSavedPipelineName(input.Name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	spec, err := normalizeSavedPipelineSpec(input.Spec)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	params, err := normalizeSavedPipelineParams(input.Params)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	paramsRaw, err := encodeSavedPipelineParams(params)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	visibility, err := normalizeSavedPipelineVisibility(input.Visibility)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var count int64
+	if err := s.queryRowContext(ctx,
+		`SELECT COUNT(1) FROM mcp_saved_pipelines WHERE api_key_hash = ?`,
+		auth.APIKeyHash,
+	).Scan(&count); err != nil {
+		return nil, errors.Wrap(err, "count saved pipelines")
+	}
+	if count >= MaxSavedPipelinesPerUser {
+		return nil, ErrSavedPipelineLimitReached
+	}
+	if err := s.ensureSavedPipelineNameFree(ctx, auth, name, uuid.Nil); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	now := s.clock()
+	pipeline := &SavedPipeline{
+		ID:           gutils.UUID7Bytes(),
+		Name:         name,
+		Description:  sanitizeSavedPipelineDescription(input.Description),
+		Spec:         spec,
+		Params:       params,
+		Visibility:   visibility,
+		APIKeyHash:   auth.APIKeyHash,
+		KeySuffix:    auth.KeySuffix,
+		UserIdentity: auth.UserIdentity,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if _, err := s.execContext(ctx,
+		`INSERT INTO mcp_saved_pipelines (`+savedPipelineColumns+`)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		pipeline.ID.String(),
+		pipeline.Name,
+		pipeline.Description,
+		pipeline.Spec,
+		paramsRaw,
+		pipeline.Visibility,
+		pipeline.APIKeyHash,
+		pipeline.KeySuffix,
+		pipeline.UserIdentity,
+		pipeline.CreatedAt,
+		pipeline.UpdatedAt,
+	); err != nil {
+		return nil, errors.Wrap(err, "create saved pipeline")
+	}
+
+	s.log().Info("saved pipeline created",
+		zap.String("pipeline_id", pipeline.ID.String()),
+		zap.String("user", auth.UserIdentity),
+		zap.String("name", pipeline.Name),
+		zap.String("visibility", pipeline.Visibility),
+	)
+
+	return pipeline, nil
+}
+
+// ListSavedPipelines returns all saved pipelines for the authenticated user, ordered by name.
+func (s *Service) ListSavedPipelines(ctx context.Context, auth *askuser.AuthorizationContext) ([]SavedPipeline, error) {
+	if auth == nil {
+		return nil, ErrInvalidAuthorization
+	}
+
+	rows, err := s.queryContext(ctx,
+		`SELECT `+savedPipelineColumns+`
+		 FROM mcp_saved_pipelines
+		 WHERE api_key_hash = ?
+		 ORDER BY name ASC
+		 LIMIT ?`,
+		auth.APIKeyHash,
+		MaxSavedPipelinesPerUser,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "list saved pipelines")
+	}
+
+	pipelines, err := scanSavedPipelineRows(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "scan saved pipelines")
+	}
+
+	return pipelines, nil
+}
+
+// GetSavedPipeline returns one saved pipeline of the authenticated user by ID.
+func (s *Service) GetSavedPipeline(ctx context.Context, auth *askuser.AuthorizationContext, id uuid.UUID) (*SavedPipeline, error) {
+	if auth == nil {
+		return nil, ErrInvalidAuthorization
+	}
+
+	pipeline, err := scanSavedPipelineRow(s.queryRowContext(ctx,
+		`SELECT `+savedPipelineColumns+`
+		 FROM mcp_saved_pipelines
+		 WHERE id = ? AND api_key_hash = ?
+		 LIMIT 1`,
+		id.String(),
+		auth.APIKeyHash,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSavedPipelineNotFound
+		}
+		return nil, errors.Wrap(err, "load saved pipeline")
+	}
+	return pipeline, nil
+}
+
+// GetVisibleSavedPipeline returns a saved pipeline by ID that the
+// authenticated user owns or that its owner has shared.
+func (s *Service) GetVisibleSavedPipeline(ctx context.Context, auth *askuser.AuthorizationContext, id uuid.UUID) (*SavedPipeline, error) {
+	if auth == nil {
+		return nil, ErrInvalidAuthorization
+	}
+
+	pipeline, err := scanSavedPipelineRow(s.queryRowContext(ctx,
+		`SELECT `+savedPipelineColumns+`
+		 FROM mcp_saved_pipelines
+		 WHERE id = ? AND (api_key_hash = ? OR visibility = ?)
+		 LIMIT 1`,
+		id.String(),
+		auth.APIKeyHash,
+		SavedPipelineVisibilityShared,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSavedPipelineNotFound
+		}
+		return nil, errors.Wrap(err, "load visible saved pipeline")
+	}
+	return pipeline, nil
+}
+
+// GetSavedPipelineByName returns one saved pipeline of the authenticated user by name.
+func (s *Service) GetSavedPipelineByName(ctx context.Context, auth *askuser.AuthorizationContext, name string) (*SavedPipeline, error) {
+	if auth == nil {
+		return nil, ErrInvalidAuthorization
+	}
+
+	pipeline, err := scanSavedPipelineRow(s.queryRowContext(ctx,
+		`SELECT `+savedPipelineColumns+`
+		 FROM mcp_saved_pipelines
+		 WHERE api_key_hash = ? AND name = ?
+		 LIMIT 1`,
+		auth.APIKeyHash,
+		strings.TrimSpace(name),
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSavedPipelineNotFound
+		}
+		return nil, errors.Wrap(err, "load saved pipeline by name")
+	}
+	return pipeline, nil
+}
+
+// UpdateSavedPipeline modifies an existing saved pipeline belonging to the authenticated user.
+func (s *Service) UpdateSavedPipeline(ctx context.Context, auth *askuser.AuthorizationContext, id uuid.UUID, update SavedPipelineUpdate) (*SavedPipeline, error) {
+	if auth == nil {
+		return nil, ErrInvalidAuthorization
+	}
+
+	pipeline, err := s.GetSavedPipeline(ctx, auth, id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	updates := make(map[string]any)
+	if update.Name != nil {
+		name, err := normalizeSavedPipelineName(*update.Name)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if name != pipeline.Name {
+			if err := s.ensureSavedPipelineNameFree(ctx, auth, name, id); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			updates["name"] = name
+		}
+	}
+	if update.Description != nil {
+		updates["description"] = sanitizeSavedPipelineDescription(*update.Description)
+	}
+	if len(update.Spec) > 0 {
+		spec, err := normalizeSavedPipelineSpec(update.Spec)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		updates["spec"] = spec
+	}
+	if update.Params != nil {
+		params, err := normalizeSavedPipelineParams(*update.Params)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		paramsRaw, err := encodeSavedPipelineParams(params)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		updates["params"] = paramsRaw
+	}
+	if update.Visibility != nil {
+		visibility, err := normalizeSavedPipelineVisibility(*update.Visibility)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		updates["visibility"] = visibility
+	}
+
+	if len(updates) == 0 {
+		return pipeline, nil
+	}
+
+	updates["updated_at"] = s.clock()
+
+	assignments := make([]string, 0, len(updates))
+	args := make([]any, 0, len(updates)+2)
+	for column, value := range updates {
+		assignments = append(assignments, column+" = ?")
+		args = append(args, value)
+	}
+	args = append(args, id.String(), auth.APIKeyHash)
+
+	query := `UPDATE mcp_saved_pipelines SET ` + strings.Join(assignments, ", ") + ` WHERE id = ? AND api_key_hash = ?`
+	if _, err := s.execContext(ctx, query, args...); err != nil {
+		return nil, errors.Wrap(err, "update saved pipeline")
+	}
+
+	pipeline, err = s.GetSavedPipeline(ctx, auth, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "reload saved pipeline after update")
+	}
+
+	s.log().Info("saved pipeline updated",
+		zap.String("pipeline_id", pipeline.ID.String()),
+		zap.String("user", auth.UserIdentity),
+		zap.String("name", pipeline.Name),
+	)
+
+	return pipeline, nil
+}
+
+// DeleteSavedPipeline removes a single saved pipeline belonging to the authenticated user.
+func (s *Service) DeleteSavedPipeline(ctx context.Context, auth *askuser.AuthorizationContext, id uuid.UUID) error {
+	if auth == nil {
+		return ErrInvalidAuthorization
+	}
+
+	result, err := s.execContext(ctx,
+		`DELETE FROM mcp_saved_pipelines WHERE id = ? AND api_key_hash = ?`,
+		id.String(),
+		auth.APIKeyHash,
+	)
+	if err != nil {
+		return errors.Wrap(err, "delete saved pipeline")
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "read deleted saved pipeline rows affected")
+	}
+	if rowsAffected == 0 {
+		return ErrSavedPipelineNotFound
+	}
+
+	s.log().Info("saved pipeline deleted",
+		zap.String("pipeline_id", id.String()),
+		zap.String("user", auth.UserIdentity),
+	)
+
+	return nil
+}
+
+// ensureSavedPipelineNameFree returns ErrSavedPipelineNameTaken when another
+// pipeline than exceptID already uses name.
+func (s *Service) ensureSavedPipelineNameFree(ctx context.Context, auth *askuser.AuthorizationContext, name string, exceptID uuid.UUID) error {
+	var count int64
+	if err := s.queryRowContext(ctx,
+		`SELECT COUNT(1) FROM mcp_saved_pipelines WHERE api_key_hash = ? AND name = ? AND id <> ?`,
+		auth.APIKeyHash,
+		name,
+		exceptID.String(),
+	).Scan(&count); err != nil {
+		return errors.Wrap(err, "check saved pipeline name")
+	}
+	if count > 0 {
+		return ErrSavedPipelineNameTaken
+	}
+	return nil
+}
+
+func sanitizeSavedPipelineDescription(input string) string {
+	trimmed := strings.TrimSpace(input)
+	if len(trimmed) > MaxSavedPipelineDescriptionLength {
+		return trimmed[:MaxSavedPipelineDescriptionLength]
+	}
+	return trimmed
+}
+
+// scanSavedPipelineRows reads saved pipeline rows into models.
+func scanSavedPipelineRows(rows *sql.Rows) ([]SavedPipeline, error) {
+	defer func() { _ = rows.Close() }()
+
+	items := make([]SavedPipeline, 0)
+	for rows.Next() {
+		item, err := scanSavedPipelineValues(rows.Scan)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		items = append(items, *item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate saved pipeline rows")
+	}
+
+	return items, nil
+}
+
+// scanSavedPipelineRow reads one saved pipeline row into a model.
+func scanSavedPipelineRow(row *sql.Row) (*SavedPipeline, error) {
+	item, err := scanSavedPipelineValues(row.Scan)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return item, nil
+}
+
+// scanSavedPipelineValues extracts a SavedPipeline from a scanner callback.
+func scanSavedPipelineValues(scanFn func(dest ...any) error) (*SavedPipeline, error) {
+	var (
+		idRaw        string
+		paramsRaw    string
+		createdAtRaw any
+		updatedAtRaw any
+		item         SavedPipeline
+	)
+	if err := scanFn(
+		&idRaw,
+		&item.Name,
+		&item.Description,
+		&item.Spec,
+		&paramsRaw,
+		&item.Visibility,
+		&item.APIKeyHash,
+		&item.KeySuffix,
+		&item.UserIdentity,
+		&createdAtRaw,
+		&updatedAtRaw,
+	); err != nil {
+		return nil, errors.Wrap(err, "scan saved pipeline row")
+	}
+
+	parsedID, err := uuid.Parse(idRaw)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse saved pipeline id")
+	}
+	item.ID = parsedID
+
+	item.Params, err = decodeSavedPipelineParams(paramsRaw)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	item.CreatedAt, err = parseSQLTime(createdAtRaw)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse saved pipeline created_at")
+	}
+	item.UpdatedAt, err = parseSQLTime(updatedAtRaw)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse saved pipeline updated_at")
+	}
+
+	return &item, nil
+}
@@ -0,0 +1,213 @@
+package userrequests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	errors "github.com/Laisky/errors/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Laisky/laisky-blog-graphql/library/log"
+)
+
+const testPipelineSpec = `{"vars":{"lang":"en"},"steps":[{"id":"s","tool":"web_search","args":{"query":"${vars.q}"}}]}`
+
+func TestSavedPipelineLifecycle(t *testing.T) {
+	db := newTestDB(t)
+	svc, err := NewService(db, nil, fixedClock(time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)).Now, Settings{RetentionDays: DefaultRetentionDays})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	auth := testAuth("pipeline-hash", "abcd")
+	other := testAuth("pipeline-other", "efgh")
+
+	created, err := svc.CreateSavedPipeline(ctx, auth, SavedPipelineInput{
+		Name:        "search",
+		Description: "  search the web  ",
+		Spec:        json.RawMessage(testPipelineSpec),
+		Params: []SavedPipelineParam{
+			{Name: "q", Required: true},
+			{Name: "limit", Type: "integer", Default: float64(5)},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "search the web", created.Description)
+	require.Equal(t, PipelineParamString, created.Params[0].Type)
+
+	_, err = svc.CreateSavedPipeline(ctx, auth, SavedPipelineInput{Name: "search", Spec: json.RawMessage(testPipelineSpec)})
+	require.ErrorIs(t, err, ErrSavedPipelineNameTaken)
+
+	// Names are scoped per API key.
+	_, err = svc.CreateSavedPipeline(ctx, other, SavedPipelineInput{Name: "search", Spec: json.RawMessage(testPipelineSpec)})
+	require.NoError(t, err)
+
+	loaded, err := svc.GetSavedPipelineByName(ctx, auth, "search")
+	require.NoError(t, err)
+	require.Equal(t, created.ID, loaded.ID)
+	require.Len(t, loaded.Params, 2)
+
+	_, err = svc.GetSavedPipelineByName(ctx, auth, "missing")
+	require.ErrorIs(t, err, ErrSavedPipelineNotFound)
+
+	newName := "web-search"
+	updated, err := svc.UpdateSavedPipeline(ctx, auth, created.ID, SavedPipelineUpdate{Name: &newName})
+	require.NoError(t, err)
+	require.Equal(t, "web-search", updated.Name)
+	require.Equal(t, created.Spec, updated.Spec)
+
+	list, err := svc.ListSavedPipelines(ctx, auth)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	// Other keys see a pipeline only once it is shared, and never edit it.
+	require.Equal(t, SavedPipelineVisibilityPrivate, created.Visibility)
+	_, err = svc.GetVisibleSavedPipeline(ctx, other, created.ID)
+	require.ErrorIs(t, err, ErrSavedPipelineNotFound)
+	shared := SavedPipelineVisibilityShared
+	_, err = svc.UpdateSavedPipeline(ctx, other, created.ID, SavedPipelineUpdate{Visibility: &shared})
+	require.ErrorIs(t, err, ErrSavedPipelineNotFound)
+	updated, err = svc.UpdateSavedPipeline(ctx, auth, created.ID, SavedPipelineUpdate{Visibility: &shared})
+	require.NoError(t, err)
+	require.Equal(t, SavedPipelineVisibilityShared, updated.Visibility)
+	visible, err := svc.GetVisibleSavedPipeline(ctx, other, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, "web-search", visible.Name)
+	_, err = svc.GetSavedPipelineByName(ctx, other, "web-search")
+	require.ErrorIs(t, err, ErrSavedPipelineNotFound)
+	otherList, err := svc.ListSavedPipelines(ctx, other)
+	require.NoError(t, err)
+	require.Len(t, otherList, 1, "shared pipelines are not listed for other keys")
+	bogus := "public"
+	_, err = svc.UpdateSavedPipeline(ctx, auth, created.ID, SavedPipelineUpdate{Visibility: &bogus})
+	require.ErrorIs(t, err, ErrInvalidSavedPipeline)
+
+	require.ErrorIs(t, svc.DeleteSavedPipeline(ctx, other, created.ID), ErrSavedPipelineNotFound)
+	require.NoError(t, svc.DeleteSavedPipeline(ctx, auth, created.ID))
+	_, err = svc.GetSavedPipeline(ctx, auth, created.ID)
+	require.ErrorIs(t, err, ErrSavedPipelineNotFound)
+}
+
+func TestSavedPipelineValidation(t *testing.T) {
+	cases := []struct {
+		name  string
+		input SavedPipelineInput
+	}{
+		{"bad name", SavedPipelineInput{Name: "Bad Name", Spec: json.RawMessage(testPipelineSpec)}},
+		{"missing spec", SavedPipelineInput{Name: "p"}},
+		{"no steps", SavedPipelineInput{Name: "p", Spec: json.RawMessage(`{"steps":[]}`)}},
+		{"spec not object", SavedPipelineInput{Name: "p", Spec: json.RawMessage(`[1]`)}},
+		{"bad param type", SavedPipelineInput{Name: "p", Spec: json.RawMessage(testPipelineSpec), Params: []SavedPipelineParam{{Name: "q", Type: "date"}}}},
+		{"duplicate param", SavedPipelineInput{Name: "p", Spec: json.RawMessage(testPipelineSpec), Params: []SavedPipelineParam{{Name: "q"}, {Name: "q"}}}},
+		{"default mismatch", SavedPipelineInput{Name: "p", Spec: json.RawMessage(testPipelineSpec), Params: []SavedPipelineParam{{Name: "n", Type: "integer", Default: 1.5}}}},
+	}
+
+	db := newTestDB(t)
+	svc, err := NewService(db, nil, nil, Settings{RetentionDays: DefaultRetentionDays})
+	require.NoError(t, err)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := svc.CreateSavedPipeline(context.Background(), testAuth("pipeline-validate", "abcd"), tc.input)
+			require.ErrorIs(t, err, ErrInvalidSavedPipeline)
+		})
+	}
+}
+
+func TestSavedPipelineBuildSpec(t *testing.T) {
+	pipeline := &SavedPipeline{
+		Spec: testPipelineSpec,
+		Params: []SavedPipelineParam{
+			{Name: "q", Type: PipelineParamString, Required: true},
+			{Name: "lang", Type: PipelineParamString},
+			{Name: "limit", Type: PipelineParamInteger, Default: float64(5)},
+		},
+	}
+
+	spec, err := pipeline.BuildSpec(map[string]any{"q": "golang"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"q": "golang", "lang": "en", "limit": float64(5)}, spec["vars"])
+
+	spec, err = pipeline.BuildSpec(map[string]any{"q": "golang", "lang": "de", "limit": float64(2)})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"q": "golang", "lang": "de", "limit": float64(2)}, spec["vars"])
+
+	for _, args := range []map[string]any{
+		{},
+		{"q": 1.0},
+		{"q": "x", "limit": 2.5},
+		{"q": "x", "extra": true},
+	} {
+		_, err := pipeline.BuildSpec(args)
+		require.True(t, errors.Is(err, ErrInvalidPipelineArgs), "args %v", args)
+	}
+}
+
+func TestSavedPipelinesHTTP(t *testing.T) {
+	db := newTestDB(t)
+	svc, err := NewService(db, nil, nil, Settings{RetentionDays: DefaultRetentionDays})
+	require.NoError(t, err)
+	handler := NewSavedPipelinesHTTPHandler(svc, log.Logger.Named("test"))
+	authHeader := "Bearer sk-pipelines1234567890"
+
+	doAs := func(header, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", header)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		return doAs(authHeader, method, path, body)
+	}
+
+	rec := do(http.MethodPost, "/api/saved-pipelines",
+		`{"name":"search","spec":`+testPipelineSpec+`,"params":[{"name":"q","required":true}]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var created struct {
+		Pipeline SavedPipelineDTO `json:"pipeline"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.Equal(t, "search", created.Pipeline.Name)
+	require.JSONEq(t, testPipelineSpec, string(created.Pipeline.Spec))
+
+	rec = do(http.MethodPost, "/api/saved-pipelines", `{"name":"search","spec":`+testPipelineSpec+`}`)
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = do(http.MethodPost, "/api/saved-pipelines", `{"name":"empty","spec":{"steps":[]}}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(http.MethodPut, "/api/saved-pipelines/"+created.Pipeline.ID, `{"description":"web search"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = do(http.MethodGet, "/api/saved-pipelines/"+created.Pipeline.ID, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"description": "web search"`)
+
+	teammate := "Bearer sk-teammate0987654321"
+	rec = doAs(teammate, http.MethodGet, "/api/saved-pipelines/"+created.Pipeline.ID, "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	rec = do(http.MethodPut, "/api/saved-pipelines/"+created.Pipeline.ID, `{"visibility":"shared"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = doAs(teammate, http.MethodGet, "/api/saved-pipelines/"+created.Pipeline.ID, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"visibility": "shared"`)
+	rec = doAs(teammate, http.MethodDelete, "/api/saved-pipelines/"+created.Pipeline.ID, "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = do(http.MethodGet, "/api/saved-pipelines", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list struct {
+		Pipelines []SavedPipelineDTO `json:"pipelines"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Pipelines, 1)
+
+	rec = do(http.MethodDelete, "/api/saved-pipelines/"+created.Pipeline.ID, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = do(http.MethodDelete, "/api/saved-pipelines/"+created.Pipeline.ID, "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -109,6 +109,20 @@ func (s *Service) ensureSchema(ctx context.Context) error {
 			updated_at TIMESTAMPTZ NOT NULL
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_mcp_saved_commands_api_sort_created ON mcp_saved_commands (api_key_hash, sort_order, created_at)`,
+		`CREATE TABLE IF NOT EXISTS mcp_saved_pipelines (
+			id UUID PRIMARY KEY,
+			name VARCHAR(64) NOT NULL,
+			description TEXT NOT NULL,
+			spec TEXT NOT NULL,
+			params TEXT NOT NULL,
+			visibility VARCHAR(16) NOT NULL DEFAULT 'private',
+			api_key_hash CHAR(64) NOT NULL,
+			key_suffix VARCHAR(16) NOT NULL,
+			user_identity VARCHAR(255) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_mcp_saved_pipelines_api_name ON mcp_saved_pipelines (api_key_hash, name)`,
 		`CREATE TABLE IF NOT EXISTS mcp_user_preferences (
 			api_key_hash CHAR(64) PRIMARY KEY,
 			key_suffix VARCHAR(16) NOT NULL,
@@ -25,6 +25,7 @@ import (
 	"github.com/Laisky/laisky-blog-graphql/internal/mcp/askuser"
 	"github.com/Laisky/laisky-blog-graphql/internal/mcp/calllog"
 	"github.com/Laisky/laisky-blog-graphql/internal/mcp/files"
+	mcpmemory "github.com/Laisky/laisky-blog-graphql/internal/mcp/memory"
 	"github.com/Laisky/laisky-blog-graphql/internal/mcp/userrequests"
 	blog "github.com/Laisky/laisky-blog-graphql/internal/web/blog/controller"
 	"github.com/Laisky/laisky-blog-graphql/library/jwt"
@@ -287,6 +288,25 @@ func RunServer(addr string, resolver *Resolver) {
 				}
 			}
 
+			if resolver.args.UserRequestService != nil {
+				pipelinesMux := userrequests.NewSavedPipelinesHTTPHandler(resolver.args.UserRequestService, log.Logger.Named("saved_pipelines_http"))
+				pipelinesBase := prefix.join("/tools/mcp_pipe")
+				stripPrefix := strings.TrimSuffix(pipelinesBase, "/")
+				if stripPrefix == "" {
+					stripPrefix = "/"
+				}
+				pipelinesHandler := gin.WrapH(http.StripPrefix(stripPrefix, pipelinesMux))
+
+				apiBase := prefix.join("/tools/mcp_pipe/api")
+				server.Any(apiBase, pipelinesHandler)
+				server.Any(apiBase+"/*path", pipelinesHandler)
+
+				if prefix.public == "" {
+					server.Any("/tools/mcp_pipe/api", gin.WrapH(http.StripPrefix("/tools/mcp_pipe", pipelinesMux)))
+					server.Any("/tools/mcp_pipe/api/*path", gin.WrapH(http.StripPrefix("/tools/mcp_pipe", pipelinesMux)))
+				}
+			}
+
 			if resolver.args.FilesService != nil {
 				filesMux := files.NewHTTPHandler(resolver.args.FilesService, log.Logger.Named("file_io_http"))
 				filesBase := prefix.join("/tools/file_io")
@@ -305,6 +325,25 @@ func RunServer(addr string, resolver *Resolver) {
 					server.Any("/tools/file_io/api/*path", gin.WrapH(http.StripPrefix("/tools/file_io", filesMux)))
 				}
 			}
+
+			if resolver.args.MemoryService != nil {
+				memoryMux := mcpmemory.NewHTTPHandler(resolver.args.MemoryService, log.Logger.Named("memory_http"))
+				memoryBase := prefix.join("/tools/memory")
+				stripPrefix := strings.TrimSuffix(memoryBase, "/")
+				if stripPrefix == "" {
+					stripPrefix = "/"
+				}
+				memoryHandler := gin.WrapH(http.StripPrefix(stripPrefix, memoryMux))
+
+				apiBase := prefix.join("/tools/memory/api")
+				server.Any(apiBase, memoryHandler)
+				server.Any(apiBase+"/*path", memoryHandler)
+
+				if prefix.public == "" {
+					server.Any("/tools/memory/api", gin.WrapH(http.StripPrefix("/tools/memory", memoryMux)))
+					server.Any("/tools/memory/api/*path", gin.WrapH(http.StripPrefix("/tools/memory", memoryMux)))
+				}
+			}
 		}
 	} else {
 		searchNil := resolver != nil && resolver.args.WebSearchProvider == nil